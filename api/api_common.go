@@ -39,6 +39,13 @@ type Common interface {
 	// node
 	LogAlerts(ctx context.Context) ([]alerting.Alert, error) //perm:admin
 
+	// MethodGroup: Config
+
+	// ConfigReload re-reads the config file and applies the settings which can be
+	// changed without restarting the node, as on SIGHUP. Nothing is applied when
+	// any other setting has changed.
+	ConfigReload(context.Context) error //perm:admin
+
 	// MethodGroup: Common
 
 	// Version provides information about API provider
@@ -18,6 +18,7 @@ import (
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 	"github.com/filecoin-project/go-jsonrpc"
 	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
 	"github.com/filecoin-project/go-state-types/builtin/v9/market"
@@ -191,6 +192,11 @@ type FullNode interface {
 	// the splitstore
 	ChainHotGC(ctx context.Context, opts HotGCOpts) error //perm:admin
 
+	// ChainColdDedup rewrites the coldstore without the redundant copies of objects written to
+	// it more than once, and reports the space reclaimed; only supported if you are using the
+	// splitstore with a badger coldstore. It may take as long as a moving GC of the coldstore.
+	ChainColdDedup(ctx context.Context) (ColdDedupResult, error) //perm:admin
+
 	// ChainCheckBlockstore performs an (asynchronous) health check on the chain/state blockstore
 	// if supported by the underlying implementation.
 	ChainCheckBlockstore(context.Context) error //perm:admin
@@ -198,6 +204,17 @@ type FullNode interface {
 	// ChainBlockstoreInfo returns some basic information about the blockstore
 	ChainBlockstoreInfo(context.Context) (map[string]interface{}, error) //perm:read
 
+	// ChainBlockstorePendingWrites returns the objects written or referenced during the
+	// running compaction which are yet to be protected from purging; only supported if you
+	// are using the splitstore
+	ChainBlockstorePendingWrites(context.Context) ([]cid.Cid, error) //perm:admin
+
+	// ChainBlockstoreDumpMarkSet writes the multihashes of the objects marked so far by the
+	// running compaction to a file at the given path on the node's host, which must be inside
+	// the directory set with LOTUS_MARKSET_DUMP_BASE_PATH; only supported if you are using
+	// the splitstore
+	ChainBlockstoreDumpMarkSet(ctx context.Context, path string) error //perm:admin
+
 	// ChainGetEvents returns the events under an event AMT root CID.
 	ChainGetEvents(context.Context, cid.Cid) ([]types.Event, error) //perm:read
 
@@ -217,6 +234,17 @@ type FullNode interface {
 	// GasEstimateMessageGas estimates gas values for unset message gas fields
 	GasEstimateMessageGas(context.Context, *types.Message, *MessageSendSpec, types.TipSetKey) (*types.Message, error) //perm:read
 
+	// GasEstimateFeeTiers returns slow/medium/fast gas premium and fee cap suggestions
+	// based on the premiums paid by messages included in the recent tipsets. The number
+	// of sampled epochs is configured with Fees.FeeTiersWindow.
+	GasEstimateFeeTiers(context.Context, types.TipSetKey) (*FeeTiers, error) //perm:read
+
+	// GasBaseFeeHistory returns the base fee of the last `lookback` tipsets up to the
+	// given tipset, and the base fee projected for the next `projection` epochs,
+	// assuming blocks keep being as full as they were on average over the lookback.
+	// Both lookback and projection are capped at 1024 epochs.
+	GasBaseFeeHistory(ctx context.Context, lookback, projection uint64, tsk types.TipSetKey) (*BaseFeeHistory, error) //perm:read
+
 	// MethodGroup: Sync
 	// The Sync method group contains methods for interacting with and
 	// observing the lotus sync service.
@@ -285,6 +313,34 @@ type FullNode interface {
 	// MpoolBatchPushMessage batch pushes a unsigned message to mempool.
 	MpoolBatchPushMessage(context.Context, []*types.Message, *MessageSendSpec) ([]*types.SignedMessage, error) //perm:sign
 
+	// MpoolPrepareOffline assigns a nonce and gas parameters to a message, and
+	// returns it along with the chain context needed to check and sign it on a
+	// machine without access to a node. Unless set by the caller, the nonce is
+	// leased as with MpoolLeaseNonce for the maximum lease duration, so that it
+	// isn't used by the messages signed by the node in the meantime.
+	MpoolPrepareOffline(ctx context.Context, msg *types.Message, spec *MessageSendSpec) (*OfflineMessage, error) //perm:sign
+
+	// MpoolPushOffline checks the signature of a message prepared with
+	// MpoolPrepareOffline and signed offline, and pushes it to mempool.
+	MpoolPushOffline(ctx context.Context, om *OfflineMessage) (cid.Cid, error) //perm:write
+
+	// MpoolLeaseNonce reserves the next nonce of an address for ttl, so that
+	// services sending from the same address, with their own signers, don't
+	// use the same nonces. Nonces assigned by MpoolPushMessage skip the leased
+	// ones. The lease ends when a message with the nonce reaches the mempool;
+	// leases which expire or are released without being used free their nonce
+	// for the next lease or message.
+	MpoolLeaseNonce(ctx context.Context, addr address.Address, ttl time.Duration) (*NonceLease, error) //perm:sign
+	// MpoolReleaseNonce ends a nonce lease which won't be used.
+	MpoolReleaseNonce(ctx context.Context, lease uuid.UUID) error //perm:sign
+
+	// MpoolReplaceBatch reprices all pending messages from the given sender, in nonce
+	// order, and pushes the replacements to the mpool. New gas values are estimated,
+	// raised at least to the replace-by-fee minimum, and capped at spec.MaxFee (or the
+	// default max fee). It returns the outcome for each pending message; a message
+	// which can't be replaced doesn't stop the replacement of the following ones.
+	MpoolReplaceBatch(ctx context.Context, from address.Address, spec *MessageSendSpec) ([]ReplaceResult, error) //perm:sign
+
 	// MpoolCheckMessages performs logical checks on a batch of messages
 	MpoolCheckMessages(context.Context, []*MessagePrototype) ([][]MessageCheckStatus, error) //perm:read
 	// MpoolCheckPendingMessages performs logical checks for all pending messages from a given address
@@ -296,6 +352,20 @@ type FullNode interface {
 	// Note that this method may not be atomic. Use MpoolPushMessage instead.
 	MpoolGetNonce(context.Context, address.Address) (uint64, error) //perm:read
 	MpoolSub(context.Context) (<-chan MpoolUpdate, error)           //perm:read
+	// MpoolSubFiltered subscribes to the mpool changes of messages matching the
+	// filter. The filter is evaluated by the node, so that only matching updates
+	// are sent to the client.
+	MpoolSubFiltered(context.Context, MpoolUpdateFilter) (<-chan MpoolUpdate, error) //perm:read
+
+	// MpoolSendQueue returns the local messages held back because their nonce is
+	// past the next nonce of their sender, for the given sender, or for all senders
+	// if the address is empty. Held messages are released to the mpool once the
+	// messages filling the gap are pushed or included on chain.
+	MpoolSendQueue(context.Context, address.Address) ([]*types.SignedMessage, error) //perm:read
+	// MpoolSendQueueFlush removes the held messages of a sender from the send queue,
+	// pushing them to the mpool despite the nonce gap, or dropping them if drop is
+	// set. It returns the CIDs of the flushed messages.
+	MpoolSendQueueFlush(ctx context.Context, from address.Address, drop bool) ([]cid.Cid, error) //perm:write
 
 	// MpoolClear clears pending messages from the mpool
 	MpoolClear(context.Context, bool) error //perm:write
@@ -305,6 +375,14 @@ type FullNode interface {
 	// MpoolSetConfig sets the mpool config to (a copy of) the supplied config
 	MpoolSetConfig(context.Context, *types.MpoolConfig) error //perm:admin
 
+	// MpoolPeerStats returns the counters kept for the peers sending untrusted
+	// messages, over gossip or with MpoolPushUntrusted. Peers are rate limited and
+	// banned for sending invalid messages according to the Mpool config section.
+	MpoolPeerStats(context.Context) ([]MpoolPeerInfo, error) //perm:read
+	// MpoolPeerReset resets the counters, rate limit and ban of a peer, or of all
+	// the peers if the peer is empty
+	MpoolPeerReset(ctx context.Context, peer string) error //perm:admin
+
 	// MethodGroup: Miner
 
 	MinerGetBaseInfo(context.Context, address.Address, abi.ChainEpoch, types.TipSetKey) (*MiningBaseInfo, error) //perm:read
@@ -339,10 +417,27 @@ type FullNode interface {
 	WalletExport(context.Context, address.Address) (*types.KeyInfo, error) //perm:admin
 	// WalletImport receives a KeyInfo, which includes a private key, and imports it into the wallet.
 	WalletImport(context.Context, *types.KeyInfo) (address.Address, error) //perm:admin
+	// WalletExportEncrypted returns the private key of an address in the wallet,
+	// encrypted with a key derived from the given password.
+	WalletExportEncrypted(ctx context.Context, addr address.Address, password string) (*types.EncryptedKeyInfo, error) //perm:admin
+	// WalletImportEncrypted decrypts a private key exported with WalletExportEncrypted
+	// and imports it into the wallet.
+	WalletImportEncrypted(ctx context.Context, eki *types.EncryptedKeyInfo, password string) (address.Address, error) //perm:admin
 	// WalletDelete deletes an address from the wallet.
 	WalletDelete(context.Context, address.Address) error //perm:admin
 	// WalletValidateAddress validates whether a given string can be decoded as a well-formed address
 	WalletValidateAddress(context.Context, string) (address.Address, error) //perm:read
+	// WalletWatch adds an address to the wallet without its private key. Watched
+	// addresses can't be used for signing.
+	WalletWatch(context.Context, address.Address) error //perm:write
+	// WalletUnwatch removes a watch-only address from the wallet.
+	WalletUnwatch(context.Context, address.Address) error //perm:write
+	// WalletListWatched lists the watch-only addresses in the wallet.
+	WalletListWatched(context.Context) ([]address.Address, error) //perm:write
+	// WalletSubscribeBalances sends a WalletBalanceChange whenever the balance of
+	// an address in the wallet, including the watch-only ones, differs between
+	// the states of consecutive chain heads.
+	WalletSubscribeBalances(context.Context) (<-chan WalletBalanceChange, error) //perm:write
 
 	// Other
 
@@ -406,6 +501,20 @@ type FullNode interface {
 	// ClientCancelRetrievalDeal cancels an ongoing retrieval deal based on DealID
 	ClientCancelRetrievalDeal(ctx context.Context, dealid retrievalmarket.DealID) error //perm:write
 
+	// ClientListResumableRetrievals lists the retrieval deals which didn't complete yet, with their
+	// persisted progress. Interrupted retrievals can be resumed with ClientResumeRetrieval.
+	ClientListResumableRetrievals(ctx context.Context) ([]ResumableRetrieval, error) //perm:write
+	// ClientResumeRetrieval restarts the data transfer of an interrupted retrieval deal, resuming it
+	// from the last verified block received.
+	ClientResumeRetrieval(ctx context.Context, dealid retrievalmarket.DealID) error //perm:write
+	// ClientRetrievalProviderStats summarizes the retrieval deals made with each provider: how many
+	// succeeded, the average throughput and the total price paid. Providers with the most successful
+	// retrievals are listed first.
+	ClientRetrievalProviderStats(ctx context.Context) ([]RetrievalProviderStats, error) //perm:read
+	// ClientRetrievalHistory lists the retrieval deals recorded with miner, or with all the providers
+	// if miner is address.Undef, most recent first.
+	ClientRetrievalHistory(ctx context.Context, miner address.Address) ([]RetrievalAttempt, error) //perm:read
+
 	// ClientUnimport removes references to the specified file from filestore
 	// ClientUnimport(path string)
 
@@ -424,7 +533,18 @@ type FullNode interface {
 	// StateCall applies the message to the tipset's parent state. The
 	// message is not applied on-top-of the messages in the passed-in
 	// tipset.
+	//
+	// Results are cached by tipset and message, so repeating a call is cheap.
 	StateCall(context.Context, *types.Message, types.TipSetKey) (*InvocResult, error) //perm:read
+	// StateCallBundle runs the given messages in order on top of the tipset's parent
+	// state, each message seeing the changes made by the previous ones, and returns
+	// their results along with the actors they changed, without persisting anything.
+	//
+	// As with StateCall, message nonces are set from the state of their senders and
+	// unset gas fields are defaulted. The messages are charged gas as if they were
+	// signed; gas is free only if none of them sets a fee cap. A failing message
+	// doesn't stop the execution of the following ones.
+	StateCallBundle(context.Context, []*types.Message, types.TipSetKey) (*CallBundleResult, error) //perm:read
 	// StateReplay replays a given message, assuming it was included in a block in the specified tipset.
 	//
 	// If a tipset key is provided, and a replacing message is not found on chain,
@@ -443,8 +563,21 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*InvocResult, error) //perm:read
+	// StateReplayWithOptions replays a given message like StateReplay, with options
+	// controlling the returned result.
+	//
+	// With opts.GasCharges set, the gas charges of the execution trace are returned
+	// in InvocResult.GasCharges, along with the depth, receiver and method of the call
+	// each was charged in. Messages executed by the legacy VM, before network version
+	// 16, only record gas charges if the node runs with LOTUS_VM_ENABLE_TRACING=1.
+	StateReplayWithOptions(ctx context.Context, tsk types.TipSetKey, mc cid.Cid, opts ReplayOptions) (*InvocResult, error) //perm:read
 	// StateGetActor returns the indicated actor's nonce and balance.
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) //perm:read
+	// StateSubscribeActorChanges subscribes to changes of the given actors. An
+	// ActorChange is sent whenever the head, nonce, balance or code of a watched
+	// actor differs between the states of consecutive chain heads, including
+	// when the chain reorgs.
+	StateSubscribeActorChanges(ctx context.Context, actors []address.Address) (<-chan ActorChange, error) //perm:read
 	// StateReadState returns the indicated actor's state.
 	StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*ActorState, error) //perm:read
 	// StateListMessages looks back and returns all messages with a matching to or from address, stopping at the given height.
@@ -467,6 +600,12 @@ type FullNode interface {
 	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*MinerPower, error) //perm:read
 	// StateMinerInfo returns info about the indicated miner
 	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (MinerInfo, error) //perm:read
+	// StateMinerKeyRotation returns the messages, in the order they must be sent,
+	// changing the owner and / or the worker key of a miner to the given addresses.
+	// Either address may be address.Undef to keep the current key. The messages
+	// which can be sent right away are dry-run in sequence on top of the state of
+	// the given tipset.
+	StateMinerKeyRotation(ctx context.Context, maddr, newOwner, newWorker address.Address, tsk types.TipSetKey) ([]MinerKeyRotationStep, error) //perm:read
 	// StateMinerDeadlines returns all the proving deadlines for the given miner
 	StateMinerDeadlines(context.Context, address.Address, types.TipSetKey) ([]Deadline, error) //perm:read
 	// StateMinerPartitions returns all partitions in the specified deadline
@@ -502,6 +641,11 @@ type FullNode interface {
 	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*lminer.SectorLocation, error) //perm:read
 	// StateSearchMsg looks back up to limit epochs in the chain for a message, and returns its receipt and the tipset where it was executed
 	//
+	// When the message index is enabled, messages found in the index are returned
+	// regardless of the limit; the limit bounds the walk through the chain for the
+	// messages which aren't indexed. The node may cap that walk with
+	// Index.MaxMsgSearchLookback, including when limit is LookbackNoLimit.
+	//
 	// NOTE: If a replacing message is found on chain, this method will return
 	// a MsgLookup for the replacing message - the MsgLookup.Message will be a different
 	// CID than the one provided in the 'cid' param, MsgLookup.Receipt will contain the
@@ -521,6 +665,9 @@ type FullNode interface {
 	// If not found, it blocks until the message arrives on chain, and gets to the
 	// indicated confidence depth.
 	//
+	// As with StateSearchMsg, the message index is searched first, and the limit,
+	// capped by Index.MaxMsgSearchLookback, bounds the walk through the chain.
+	//
 	// NOTE: If a replacing message is found on chain, this method will return
 	// a MsgLookup for the replacing message - the MsgLookup.Message will be a different
 	// CID than the one provided in the 'cid' param, MsgLookup.Receipt will contain the
@@ -570,6 +717,12 @@ type FullNode interface {
 	// StateChangedActors returns all the actors whose states change between the two given state CIDs
 	// TODO: Should this take tipset keys instead?
 	StateChangedActors(context.Context, cid.Cid, cid.Cid) (map[string]types.Actor, error) //perm:read
+	// StateDiff returns the actors created, deleted or modified between the parent
+	// states of the `from` and `to` tipsets, ordered by address. The actors HAMTs are
+	// diffed structurally, skipping the subtrees shared by both states. If withState
+	// is set, the changed fields of the states of modified actors are included,
+	// along with the changed entries of fields pointing to a HAMT or an AMT.
+	StateDiff(ctx context.Context, from, to types.TipSetKey, withState bool) ([]ActorDiff, error) //perm:read
 	// StateMinerSectorCount returns the number of sectors in a miner's sector set and proving set
 	StateMinerSectorCount(context.Context, address.Address, types.TipSetKey) (MinerSectors, error) //perm:read
 	// StateMinerAllocated returns a bitfield containing all sector numbers marked as allocated in miner state
@@ -606,6 +759,8 @@ type FullNode interface {
 	//
 	// Messages in the `apply` parameter must have the correct nonces, and gas
 	// values set.
+	//
+	// Results are cached by tipset, vmheight and messages.
 	StateCompute(context.Context, abi.ChainEpoch, []*types.Message, types.TipSetKey) (*ComputeStateOutput, error) //perm:read
 	// StateVerifierStatus returns the data cap for the given address.
 	// Returns nil if there is no entry in the data cap table for the
@@ -627,12 +782,22 @@ type FullNode interface {
 	// StateVMCirculatingSupplyInternal returns an approximation of the circulating supply of Filecoin at the given tipset.
 	// This is the value reported by the runtime interface to actors code.
 	StateVMCirculatingSupplyInternal(context.Context, types.TipSetKey) (CirculatingSupply, error) //perm:read
+	// StateVMCirculatingSupplyAt returns an approximation of the circulating supply of Filecoin at
+	// the given epoch of the current chain, like StateVMCirculatingSupplyInternal. If the epoch is a
+	// null round, the supply at the following tipset is returned.
+	// Historical states are read from the coldstore without being moved back to the hotstore; the
+	// call fails if the node doesn't keep the state at the epoch.
+	StateVMCirculatingSupplyAt(ctx context.Context, epoch abi.ChainEpoch) (CirculatingSupply, error) //perm:read
 	// StateNetworkVersion returns the network version at the given tipset
 	StateNetworkVersion(context.Context, types.TipSetKey) (apitypes.NetworkVersion, error) //perm:read
 	// StateActorCodeCIDs returns the CIDs of all the builtin actors for the given network version
 	StateActorCodeCIDs(context.Context, abinetwork.Version) (map[string]cid.Cid, error) //perm:read
 	// StateActorManifestCID returns the CID of the builtin actors manifest for the given network version
 	StateActorManifestCID(context.Context, abinetwork.Version) (cid.Cid, error) //perm:read
+	// StateActorRegistry returns the code CIDs of the builtin actors of all the network
+	// versions supported by the node, along with the actor type, the network versions
+	// using each of them, and the methods they export.
+	StateActorRegistry(context.Context) ([]ActorCodeInfo, error) //perm:read
 
 	// StateGetRandomnessFromTickets is used to sample the chain for randomness.
 	StateGetRandomnessFromTickets(ctx context.Context, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte, tsk types.TipSetKey) (abi.Randomness, error) //perm:read
@@ -664,6 +829,11 @@ type FullNode interface {
 	// appear here.
 	MsigGetPending(context.Context, address.Address, types.TipSetKey) ([]*MsigTransaction, error) //perm:read
 
+	// MsigGetPendingDecoded returns the pending transactions of the given multisig
+	// wallet like MsigGetPending, ordered by ID, along with the name of the method
+	// they call and their decoded parameters.
+	MsigGetPendingDecoded(context.Context, address.Address, types.TipSetKey) ([]*MsigDecodedTransaction, error) //perm:read
+
 	// MsigCreate creates a multisig wallet
 	// It takes the following params: <required number of senders>, <approving addresses>, <unlock duration>
 	// <initial balance>, <sender address of the create msg>, <gas price>
@@ -778,6 +948,11 @@ type FullNode interface {
 
 	NodeStatus(ctx context.Context, inclChainStatus bool) (NodeStatus, error) //perm:read
 
+	// NodeHealth returns a health report aggregating sync status, blockstore
+	// (splitstore) state, free disk space of the node's store paths and
+	// datastore errors. It is also served as JSON on the /healthz endpoint.
+	NodeHealth(ctx context.Context) (NodeHealth, error) //perm:read
+
 	// MethodGroup: Eth
 	// These methods are used for Ethereum-compatible JSON-RPC calls
 	//
@@ -885,6 +1060,9 @@ type StorageAsk struct {
 type FileRef struct {
 	Path  string
 	IsCAR bool
+	// CARv2 exports a CARv2 file with an index of its blocks, instead of a
+	// CARv1. It requires IsCAR, and is ignored by imports.
+	CARv2 bool
 }
 
 type MinerSectors struct {
@@ -964,6 +1142,46 @@ type MsgGasCost struct {
 	TotalCost          abi.TokenAmount
 }
 
+// FeeTier is a gas fee suggestion for a given inclusion percentile.
+type FeeTier struct {
+	// Percentile of the gas included in the sampled epochs which paid a premium
+	// lower than GasPremium
+	Percentile int
+	GasPremium abi.TokenAmount
+	GasFeeCap  abi.TokenAmount
+}
+
+type FeeTiers struct {
+	// Height of the tipset the suggestions were computed at
+	Height abi.ChainEpoch
+	// Epochs is the number of sampled epochs
+	Epochs  int
+	BaseFee abi.TokenAmount
+
+	Slow   FeeTier
+	Medium FeeTier
+	Fast   FeeTier
+}
+
+// BaseFeePoint is the base fee at a given epoch.
+type BaseFeePoint struct {
+	Height  abi.ChainEpoch
+	BaseFee abi.TokenAmount
+	// Fullness is the gas limit included per block, relative to the block gas
+	// target. It is only set for past tipsets.
+	Fullness float64
+}
+
+type BaseFeeHistory struct {
+	// History is ordered by height, oldest first, and doesn't include null rounds
+	History []BaseFeePoint
+	// Projected base fees for the epochs following the last tipset in History
+	Projected []BaseFeePoint
+	// Fullness is the average fullness of the tipsets in History, used for
+	// the projection
+	Fullness float64
+}
+
 // BlsMessages[x].cid = Cids[x]
 // SecpkMessages[y].cid = Cids[BlsMessages.length + y]
 type BlockMessages struct {
@@ -1137,6 +1355,14 @@ type InvocResult struct {
 	ExecutionTrace types.ExecutionTrace
 	Error          string
 	Duration       time.Duration
+	// GasCharges are the gas charges of ExecutionTrace, set by StateReplayWithOptions
+	GasCharges []types.GasChargeEvent `json:",omitempty"`
+}
+
+// ReplayOptions are the options of StateReplayWithOptions.
+type ReplayOptions struct {
+	// GasCharges returns the gas charges of the execution trace in InvocResult.GasCharges
+	GasCharges bool
 }
 
 type MethodCall struct {
@@ -1241,6 +1467,154 @@ type MpoolUpdate struct {
 	Message *types.SignedMessage
 }
 
+// CallBundleResult is the outcome of StateCallBundle.
+type CallBundleResult struct {
+	// Results holds the result of each message, in order
+	Results []*InvocResult
+	// Root is the state root after applying the messages; it isn't persisted
+	Root cid.Cid
+	// Changes are the actors changed by the messages, with their changed state fields
+	Changes []ActorDiff
+}
+
+// ActorDiff is a change of an actor returned by StateDiff and StateCallBundle.
+type ActorDiff struct {
+	Address address.Address
+	// Before is nil if the actor was created
+	Before *types.Actor
+	// After is nil if the actor was deleted
+	After *types.Actor
+	// State holds the changed fields of the state of a modified actor
+	State []ActorStateFieldDiff `json:",omitempty"`
+}
+
+// ActorCodeInfo describes a builtin actor code CID.
+type ActorCodeInfo struct {
+	Code cid.Cid
+	// Name is the manifest key of the actor, e.g. "storageminer"
+	Name          string
+	ActorsVersion actorstypes.Version
+	// NetworkVersions are the network versions running this code
+	NetworkVersions []abinetwork.Version
+	// Methods are the methods exported by the actor, ordered by method number
+	Methods []ActorMethodInfo
+}
+
+// ActorMethodInfo describes a method exported by a builtin actor.
+type ActorMethodInfo struct {
+	Num  abi.MethodNum
+	Name string
+	// Params and Return are the Go types of the method parameters and return value
+	Params, Return string
+}
+
+// ActorStateFieldDiff is a change of a top-level field of an actor state.
+type ActorStateFieldDiff struct {
+	// Field is the index of the field in the actor state tuple
+	Field int
+	// Before and After are the CBOR encoded values of the field
+	Before, After []byte
+	// Entries are the changed entries of a field pointing to a HAMT or an AMT
+	Entries []ActorStateEntryDiff `json:",omitempty"`
+}
+
+// ActorStateEntryDiff is a change of a HAMT or AMT entry of an actor state.
+type ActorStateEntryDiff struct {
+	// Key is the hex encoded key of a HAMT entry, or the index of an AMT entry
+	Key string
+	// Before and After are the CBOR encoded values, nil if the entry was added or
+	// removed
+	Before, After []byte
+}
+
+// ActorChange describes a change of an actor watched with StateSubscribeActorChanges.
+type ActorChange struct {
+	// Address is the watched address, as passed to StateSubscribeActorChanges
+	Address address.Address
+	// TipSet is the head whose parent state contains the change
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	// Actor is the new state of the actor, nil if the actor doesn't exist
+	Actor *types.Actor
+	// Previous is the previous state of the actor, nil if it didn't exist
+	Previous *types.Actor
+}
+
+// WalletBalanceChange describes a change of the balance of a wallet address,
+// sent by WalletSubscribeBalances.
+type WalletBalanceChange struct {
+	Address   address.Address
+	WatchOnly bool
+	// TipSet is the head whose parent state contains the change
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+
+	Balance  types.BigInt
+	Previous types.BigInt
+}
+
+// MpoolPeerInfo holds the counters of a peer sending untrusted messages. Gossip
+// peers are identified by their peer ID, API clients by their remote host.
+type MpoolPeerInfo struct {
+	Peer string
+
+	Accepted    uint64
+	Rejected    uint64
+	RateLimited uint64
+
+	LastSeen time.Time
+	// BannedUntil is set when the peer sent too many invalid messages
+	BannedUntil time.Time
+}
+
+// MpoolUpdateFilter selects the mpool updates sent by MpoolSubFiltered. Empty
+// fields match any message.
+type MpoolUpdateFilter struct {
+	// From matches messages sent by any of the addresses
+	From []address.Address
+	// To matches messages sent to any of the addresses
+	To []address.Address
+	// Methods matches messages calling any of the methods
+	Methods []abi.MethodNum
+	// MinValue matches messages transferring at least the value
+	MinValue abi.TokenAmount
+}
+
+// Matches returns true if the message passes the filter
+func (f *MpoolUpdateFilter) Matches(m *types.Message) bool {
+	if len(f.From) > 0 && !containsAddr(f.From, m.From) {
+		return false
+	}
+	if len(f.To) > 0 && !containsAddr(f.To, m.To) {
+		return false
+	}
+	if len(f.Methods) > 0 {
+		found := false
+		for _, method := range f.Methods {
+			if method == m.Method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinValue.Int != nil && m.Value.LessThan(f.MinValue) {
+		return false
+	}
+	return true
+}
+
+func containsAddr(addrs []address.Address, a address.Address) bool {
+	for _, addr := range addrs {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
+
 type ComputeStateOutput struct {
 	Root  cid.Cid
 	Trace []*InvocResult
@@ -1355,6 +1729,31 @@ type MsigTransaction struct {
 	Approved []address.Address
 }
 
+// MinerKeyRotationStep is a message of a miner owner / worker key rotation.
+type MinerKeyRotationStep struct {
+	// Description says what the message does
+	Description string
+	Message     *types.Message
+	// NotBefore is the first epoch the message can be sent at
+	NotBefore abi.ChainEpoch
+	// DryRun is the result of applying the message after the previous steps; it
+	// is nil for messages which can't be sent yet
+	DryRun *InvocResult
+}
+
+// MsigDecodedTransaction is a pending multisig transaction with its method and
+// parameters decoded.
+type MsigDecodedTransaction struct {
+	MsigTransaction
+
+	// MethodName is empty if the method of the target actor isn't known, e.g.
+	// when the actor doesn't exist yet
+	MethodName string
+	// DecodedParams are the JSON encoded parameters, unset if they couldn't be
+	// decoded
+	DecodedParams json.RawMessage `json:",omitempty"`
+}
+
 type PruneOpts struct {
 	MovingGC    bool
 	RetainState int64
@@ -1366,6 +1765,17 @@ type HotGCOpts struct {
 	Moving    bool
 }
 
+type ColdDedupResult struct {
+	// Objects is the number of distinct objects in the coldstore
+	Objects int64
+	// Duplicates is the number of redundant copies of objects dropped, and DuplicateSize their size
+	Duplicates    int64
+	DuplicateSize int64
+	// SizeBefore and SizeAfter are the on-disk size of the coldstore before and after deduplication
+	SizeBefore int64
+	SizeAfter  int64
+}
+
 type EthTxReceipt struct {
 	TransactionHash   ethtypes.EthHash     `json:"transactionHash"`
 	TransactionIndex  ethtypes.EthUint64   `json:"transactionIndex"`
@@ -26,6 +26,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -79,12 +80,20 @@ type StorageMiner interface {
 
 	SectorsUnsealPiece(ctx context.Context, sector storiface.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error //perm:admin
 
+	// SectorsReleaseUnsealed removes the unsealed copy of a sector
+	SectorsReleaseUnsealed(ctx context.Context, sector storiface.SectorRef) error //perm:admin
+
 	// List all staged sectors
 	SectorsList(context.Context) ([]abi.SectorNumber, error) //perm:read
 
 	// Get summary info of sectors
 	SectorsSummary(ctx context.Context) (map[SectorState]int, error) //perm:read
 
+	// SectorsPipelineStats returns statistics of the sealing pipeline: the number of
+	// sectors in each state, the time they spent in each state and the number of
+	// failures since the node started, and the utilization of the sealing workers.
+	SectorsPipelineStats(ctx context.Context) (SectorsPipelineStats, error) //perm:read
+
 	// List sectors in particular states
 	SectorsListInStates(context.Context, []SectorState) ([]abi.SectorNumber, error) //perm:read
 
@@ -126,7 +135,10 @@ type StorageMiner interface {
 	SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) //perm:admin
 	// SectorCommitPending returns a list of pending Commit sectors to be sent in the next aggregate message
 	SectorCommitPending(ctx context.Context) ([]abi.SectorID, error) //perm:admin
-	SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error //perm:admin
+	// SectorBatchingStatus returns the sectors pending in the PreCommit and Commit batches with their
+	// cutoffs, and how and when the batches are going to be sent
+	SectorBatchingStatus(ctx context.Context) (SectorBatchingStatus, error) //perm:read
+	SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error        //perm:admin
 	// SectorAbortUpgrade can be called on sectors that are in the process of being upgraded to abort it
 	SectorAbortUpgrade(context.Context, abi.SectorNumber) error //perm:admin
 
@@ -151,6 +163,9 @@ type StorageMiner interface {
 	WorkerConnect(context.Context, string) error                              //perm:admin retry:true
 	WorkerStats(context.Context) (map[uuid.UUID]storiface.WorkerStats, error) //perm:admin
 	WorkerJobs(context.Context) (map[uuid.UUID][]storiface.WorkerJob, error)  //perm:admin
+	// WorkerResourceTable returns the resources the scheduler assumes each task
+	// type needs on the worker, with the overrides set on the worker applied
+	WorkerResourceTable(ctx context.Context, worker uuid.UUID) (map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) //perm:admin
 
 	// storiface.WorkerReturn
 	ReturnDataCid(ctx context.Context, callID storiface.CallID, pi abi.PieceInfo, err *storiface.CallError) error                                         //perm:admin retry:true
@@ -177,6 +192,14 @@ type StorageMiner interface {
 	SealingAbort(ctx context.Context, call storiface.CallID) error           //perm:admin
 	// SealingSchedRemove removes a request from sealing pipeline
 	SealingRemoveRequest(ctx context.Context, schedId uuid.UUID) error //perm:admin
+	// SealingPauseStates pauses the given sector states: sectors entering them
+	// wait without scheduling any work until the states are resumed
+	SealingPauseStates(ctx context.Context, states []SectorState) error //perm:admin
+	// SealingResumeStates resumes the given paused sector states, restarting
+	// the sectors waiting in them
+	SealingResumeStates(ctx context.Context, states []SectorState) error //perm:admin
+	// SealingPausedStates lists the paused sector states
+	SealingPausedStates(ctx context.Context) ([]SectorState, error) //perm:read
 
 	// paths.SectorIndex
 	StorageAttach(context.Context, storiface.StorageInfo, fsutil.FsStat) error                                                         //perm:admin
@@ -202,6 +225,9 @@ type StorageMiner interface {
 	StorageTryLock(ctx context.Context, sector abi.SectorID, read storiface.SectorFileType, write storiface.SectorFileType) (bool, error)                        //perm:admin
 	StorageList(ctx context.Context) (map[storiface.ID][]storiface.Decl, error)                                                                                  //perm:admin
 	StorageGetLocks(ctx context.Context) (storiface.SectorLocks, error)                                                                                          //perm:admin
+	// StorageHealth returns the health of the storage paths. Read-only paths aren't used for new sector
+	// files, and offline paths are only read from when no other path has the sector files.
+	StorageHealth(ctx context.Context) (map[storiface.ID]storiface.PathHealth, error) //perm:admin
 
 	StorageLocal(ctx context.Context) (map[storiface.ID]string, error)       //perm:admin
 	StorageStat(ctx context.Context, id storiface.ID) (fsutil.FsStat, error) //perm:admin
@@ -225,6 +251,10 @@ type StorageMiner interface {
 	MarketGetRetrievalAsk(ctx context.Context) (*retrievalmarket.Ask, error)                                                                                                             //perm:read
 	MarketListDataTransfers(ctx context.Context) ([]DataTransferChannel, error)                                                                                                          //perm:write
 	MarketDataTransferUpdates(ctx context.Context) (<-chan DataTransferChannel, error)                                                                                                   //perm:write
+	// MarketDataTransferProgress returns the progress of the ongoing data transfers: bytes
+	// transferred, recent rate, restarts and the time of the last progress, along with the
+	// storage deals the transfers are for
+	MarketDataTransferProgress(ctx context.Context) ([]DataTransferProgress, error) //perm:read
 	// MarketDataTransferDiagnostics generates debugging information about current data transfers over graphsync
 	MarketDataTransferDiagnostics(ctx context.Context, p peer.ID) (*TransferDiagnostics, error) //perm:write
 	// MarketRestartDataTransfer attempts to restart a data transfer with the given transfer ID and other peer
@@ -235,6 +265,9 @@ type StorageMiner interface {
 	MarketPublishPendingDeals(ctx context.Context) error                                                                         //perm:admin
 	MarketRetryPublishDeal(ctx context.Context, propcid cid.Cid) error                                                           //perm:admin
 
+	// MarketClientQuotaUsage returns the deal quotas of the clients and how much of them is used
+	MarketClientQuotaUsage(ctx context.Context) ([]ClientQuotaUsage, error) //perm:read
+
 	// DagstoreListShards returns information about all shards known to the
 	// DAG store. Only available on nodes running the markets subsystem.
 	DagstoreListShards(ctx context.Context) ([]DagstoreShardInfo, error) //perm:read
@@ -287,6 +320,15 @@ type StorageMiner interface {
 	// IndexerAnnounceAllDeals informs the indexer nodes aboutall active deals.
 	IndexerAnnounceAllDeals(ctx context.Context) error //perm:admin
 
+	// IndexerEndpointAnnounceDeals queues announcements of the given active deals to the
+	// external indexer endpoint, or of all active deals if no proposal CIDs are given.
+	// It returns the number of queued announcements.
+	IndexerEndpointAnnounceDeals(ctx context.Context, proposalCids []cid.Cid) (int, error) //perm:admin
+
+	// IndexerEndpointQueue lists the announcements waiting to be delivered to the external
+	// indexer endpoint.
+	IndexerEndpointQueue(ctx context.Context) ([]PendingPieceAnnouncement, error) //perm:read
+
 	// DagstoreLookupPieces returns information about shards that contain the given CID.
 	DagstoreLookupPieces(ctx context.Context, cid cid.Cid) ([]DagstoreShardInfo, error) //perm:admin
 
@@ -452,6 +494,82 @@ type PendingDealInfo struct {
 	PublishPeriod      time.Duration
 }
 
+// SectorBatchingStatus describes the sectors pending in the PreCommit and Commit batches
+type SectorBatchingStatus struct {
+	PreCommit sealiface.BatchStatus
+	Commit    sealiface.BatchStatus
+}
+
+// SectorsPipelineStats describes the state of the sealing pipeline
+type SectorsPipelineStats struct {
+	States map[SectorState]SectorStateStats
+	// Failures is the number of times sectors entered a failed state since the node started
+	Failures int64
+	Workers  map[uuid.UUID]WorkerUtilization
+}
+
+// SectorStateStats describes the sectors in a state. Durations only account for
+// the sectors which left the state since the node started.
+type SectorStateStats struct {
+	Sectors int
+
+	Left        int64
+	AvgDuration time.Duration
+	MaxDuration time.Duration
+}
+
+// WorkerUtilization describes the load of a sealing worker
+type WorkerUtilization struct {
+	Hostname string
+	// Utilization is the fraction of the most used worker resource, it can exceed
+	// 1 when tasks are assigned in advance
+	Utilization float64
+	Tasks       int
+}
+
+// ClientQuotaUsage describes the deal quota of a client and its usage. Zero
+// limits are unlimited.
+type ClientQuotaUsage struct {
+	Client address.Address
+
+	// OpenDeals is the number of the client's deals in progress
+	OpenDeals    uint64
+	MaxOpenDeals uint64
+
+	// BytesLastDay is the padded size of the client's deals accepted in the
+	// last 24 hours
+	BytesLastDay   uint64
+	MaxBytesPerDay uint64
+}
+
+// PieceAnnouncement is sent to the external indexer endpoint when a deal
+// becomes active, announcing that the piece can be retrieved from the
+// provider.
+type PieceAnnouncement struct {
+	Provider address.Address
+	PeerID   peer.ID
+	Addrs    []string
+
+	ProposalCid  cid.Cid
+	DealID       abi.DealID
+	PieceCid     cid.Cid
+	PieceSize    abi.PaddedPieceSize
+	PayloadCid   cid.Cid
+	Client       address.Address
+	VerifiedDeal bool
+	StartEpoch   abi.ChainEpoch
+	EndEpoch     abi.ChainEpoch
+}
+
+// PendingPieceAnnouncement is an announcement waiting to be delivered to the
+// external indexer endpoint.
+type PendingPieceAnnouncement struct {
+	Announcement PieceAnnouncement
+	Attempts     int
+	NextAttempt  time.Time
+	LastError    string
+}
+
 type SectorOffset struct {
 	Sector abi.SectorNumber
 	Offset abi.PaddedPieceSize
@@ -14,7 +14,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
 )
 
 func goCmd() string {
@@ -142,3 +146,27 @@ func TestRetryWrappedErrorIsInTrue(t *testing.T) {
 	errorsToRetry := []error{&jsonrpc.RPCConnectionError{}}
 	require.True(t, ErrorIsIn(xerrors.Errorf("wrapped: %w", &jsonrpc.RPCConnectionError{}), errorsToRetry))
 }
+
+func TestMpoolUpdateFilter(t *testing.T) {
+	a1, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+	a2, err := address.NewIDAddress(1002)
+	require.NoError(t, err)
+
+	msg := &types.Message{
+		From:   a1,
+		To:     a2,
+		Method: 2,
+		Value:  abi.NewTokenAmount(100),
+	}
+
+	require.True(t, (&MpoolUpdateFilter{}).Matches(msg))
+	require.True(t, (&MpoolUpdateFilter{From: []address.Address{a2, a1}}).Matches(msg))
+	require.False(t, (&MpoolUpdateFilter{From: []address.Address{a2}}).Matches(msg))
+	require.True(t, (&MpoolUpdateFilter{To: []address.Address{a2}}).Matches(msg))
+	require.False(t, (&MpoolUpdateFilter{To: []address.Address{a1}}).Matches(msg))
+	require.True(t, (&MpoolUpdateFilter{Methods: []abi.MethodNum{0, 2}}).Matches(msg))
+	require.False(t, (&MpoolUpdateFilter{Methods: []abi.MethodNum{0}}).Matches(msg))
+	require.True(t, (&MpoolUpdateFilter{MinValue: abi.NewTokenAmount(100)}).Matches(msg))
+	require.False(t, (&MpoolUpdateFilter{MinValue: abi.NewTokenAmount(101)}).Matches(msg))
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
 	"github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/exitcode"
@@ -45,6 +46,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo/imports"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -141,6 +143,7 @@ func init() {
 	addExample(&apiSelExample)
 	addExample(network.ReachabilityPublic)
 	addExample(build.TestNetworkVersion)
+	addExample(actorstypes.Version10)
 	allocationId := verifreg.AllocationId(0)
 	addExample(allocationId)
 	addExample(&allocationId)
@@ -218,9 +221,26 @@ func init() {
 		},
 	})
 	addExample(api.SectorState(sealing.Proving))
+	addExample(map[api.SectorState]api.SectorStateStats{
+		api.SectorState(sealing.PreCommit1): {
+			Sectors:     2,
+			Left:        10,
+			AvgDuration: 3 * time.Hour,
+			MaxDuration: 4 * time.Hour,
+		},
+	})
+	addExample(map[uuid.UUID]api.WorkerUtilization{
+		uuid.MustParse("ef8d99a2-6865-4189-8ffa-9fef0f806eee"): {
+			Hostname:    "host",
+			Utilization: 0.5,
+			Tasks:       2,
+		},
+	})
+	addExample(sealiface.BatchModeBatch)
 	addExample(storiface.ID("76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8"))
 	addExample(storiface.FTUnsealed)
 	addExample(storiface.PathSealing)
+	addExample(storiface.PathHealthOK)
 	addExample(map[storiface.ID][]storiface.Decl{
 		"76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8": {
 			{
@@ -232,6 +252,13 @@ func init() {
 	addExample(map[storiface.ID]string{
 		"76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8": "/data/path",
 	})
+	addExample(map[storiface.ID]storiface.PathHealth{
+		"76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8": {
+			State:         storiface.PathHealthOK,
+			LastHeartbeat: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			Latency:       time.Millisecond,
+		},
+	})
 	addExample(map[uuid.UUID][]storiface.WorkerJob{
 		uuid.MustParse("ef8d99a2-6865-4189-8ffa-9fef0f806eee"): {
 			{
@@ -293,6 +320,9 @@ func init() {
 	addExample(map[sealtasks.TaskType]struct{}{
 		sealtasks.TTPreCommit2: {},
 	})
+	addExample(map[sealtasks.TaskType]int{
+		sealtasks.TTPreCommit2: 10,
+	})
 	addExample(sealtasks.TTCommit2)
 	addExample(apitypes.OpenRPCDocument{
 		"openrpc": "1.2.6",
@@ -97,6 +97,20 @@ func (mr *MockFullNodeMockRecorder) AuthVerify(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthVerify", reflect.TypeOf((*MockFullNode)(nil).AuthVerify), arg0, arg1)
 }
 
+// ChainBlockstoreDumpMarkSet mocks base method.
+func (m *MockFullNode) ChainBlockstoreDumpMarkSet(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreDumpMarkSet", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreDumpMarkSet indicates an expected call of ChainBlockstoreDumpMarkSet.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreDumpMarkSet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreDumpMarkSet", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreDumpMarkSet), arg0, arg1)
+}
+
 // ChainBlockstoreInfo mocks base method.
 func (m *MockFullNode) ChainBlockstoreInfo(arg0 context.Context) (map[string]interface{}, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +126,21 @@ func (mr *MockFullNodeMockRecorder) ChainBlockstoreInfo(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreInfo", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreInfo), arg0)
 }
 
+// ChainBlockstorePendingWrites mocks base method.
+func (m *MockFullNode) ChainBlockstorePendingWrites(arg0 context.Context) ([]cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstorePendingWrites", arg0)
+	ret0, _ := ret[0].([]cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstorePendingWrites indicates an expected call of ChainBlockstorePendingWrites.
+func (mr *MockFullNodeMockRecorder) ChainBlockstorePendingWrites(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstorePendingWrites", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstorePendingWrites), arg0)
+}
+
 // ChainCheckBlockstore mocks base method.
 func (m *MockFullNode) ChainCheckBlockstore(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -126,6 +155,21 @@ func (mr *MockFullNodeMockRecorder) ChainCheckBlockstore(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainCheckBlockstore", reflect.TypeOf((*MockFullNode)(nil).ChainCheckBlockstore), arg0)
 }
 
+// ChainColdDedup mocks base method.
+func (m *MockFullNode) ChainColdDedup(arg0 context.Context) (api.ColdDedupResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainColdDedup", arg0)
+	ret0, _ := ret[0].(api.ColdDedupResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainColdDedup indicates an expected call of ChainColdDedup.
+func (mr *MockFullNodeMockRecorder) ChainColdDedup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainColdDedup", reflect.TypeOf((*MockFullNode)(nil).ChainColdDedup), arg0)
+}
+
 // ChainDeleteObj mocks base method.
 func (m *MockFullNode) ChainDeleteObj(arg0 context.Context, arg1 cid.Cid) error {
 	m.ctrl.T.Helper()
@@ -776,6 +820,21 @@ func (mr *MockFullNodeMockRecorder) ClientListImports(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListImports", reflect.TypeOf((*MockFullNode)(nil).ClientListImports), arg0)
 }
 
+// ClientListResumableRetrievals mocks base method.
+func (m *MockFullNode) ClientListResumableRetrievals(arg0 context.Context) ([]api.ResumableRetrieval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientListResumableRetrievals", arg0)
+	ret0, _ := ret[0].([]api.ResumableRetrieval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientListResumableRetrievals indicates an expected call of ClientListResumableRetrievals.
+func (mr *MockFullNodeMockRecorder) ClientListResumableRetrievals(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListResumableRetrievals", reflect.TypeOf((*MockFullNode)(nil).ClientListResumableRetrievals), arg0)
+}
+
 // ClientListRetrievals mocks base method.
 func (m *MockFullNode) ClientListRetrievals(arg0 context.Context) ([]api.RetrievalInfo, error) {
 	m.ctrl.T.Helper()
@@ -849,6 +908,50 @@ func (mr *MockFullNodeMockRecorder) ClientRestartDataTransfer(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRestartDataTransfer", reflect.TypeOf((*MockFullNode)(nil).ClientRestartDataTransfer), arg0, arg1, arg2, arg3)
 }
 
+// ClientResumeRetrieval mocks base method.
+func (m *MockFullNode) ClientResumeRetrieval(arg0 context.Context, arg1 retrievalmarket.DealID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientResumeRetrieval", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClientResumeRetrieval indicates an expected call of ClientResumeRetrieval.
+func (mr *MockFullNodeMockRecorder) ClientResumeRetrieval(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientResumeRetrieval", reflect.TypeOf((*MockFullNode)(nil).ClientResumeRetrieval), arg0, arg1)
+}
+
+// ClientRetrievalHistory mocks base method.
+func (m *MockFullNode) ClientRetrievalHistory(arg0 context.Context, arg1 address.Address) ([]api.RetrievalAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientRetrievalHistory", arg0, arg1)
+	ret0, _ := ret[0].([]api.RetrievalAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientRetrievalHistory indicates an expected call of ClientRetrievalHistory.
+func (mr *MockFullNodeMockRecorder) ClientRetrievalHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRetrievalHistory", reflect.TypeOf((*MockFullNode)(nil).ClientRetrievalHistory), arg0, arg1)
+}
+
+// ClientRetrievalProviderStats mocks base method.
+func (m *MockFullNode) ClientRetrievalProviderStats(arg0 context.Context) ([]api.RetrievalProviderStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientRetrievalProviderStats", arg0)
+	ret0, _ := ret[0].([]api.RetrievalProviderStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientRetrievalProviderStats indicates an expected call of ClientRetrievalProviderStats.
+func (mr *MockFullNodeMockRecorder) ClientRetrievalProviderStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRetrievalProviderStats", reflect.TypeOf((*MockFullNode)(nil).ClientRetrievalProviderStats), arg0)
+}
+
 // ClientRetrieve mocks base method.
 func (m *MockFullNode) ClientRetrieve(arg0 context.Context, arg1 api.RetrievalOrder) (*api.RestrievalRes, error) {
 	m.ctrl.T.Helper()
@@ -937,6 +1040,20 @@ func (mr *MockFullNodeMockRecorder) Closing(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Closing", reflect.TypeOf((*MockFullNode)(nil).Closing), arg0)
 }
 
+// ConfigReload mocks base method.
+func (m *MockFullNode) ConfigReload(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigReload", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfigReload indicates an expected call of ConfigReload.
+func (mr *MockFullNodeMockRecorder) ConfigReload(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigReload", reflect.TypeOf((*MockFullNode)(nil).ConfigReload), arg0)
+}
+
 // CreateBackup mocks base method.
 func (m *MockFullNode) CreateBackup(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -1521,6 +1638,21 @@ func (mr *MockFullNodeMockRecorder) FilecoinAddressToEthAddress(arg0, arg1 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilecoinAddressToEthAddress", reflect.TypeOf((*MockFullNode)(nil).FilecoinAddressToEthAddress), arg0, arg1)
 }
 
+// GasBaseFeeHistory mocks base method.
+func (m *MockFullNode) GasBaseFeeHistory(arg0 context.Context, arg1, arg2 uint64, arg3 types.TipSetKey) (*api.BaseFeeHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasBaseFeeHistory", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.BaseFeeHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasBaseFeeHistory indicates an expected call of GasBaseFeeHistory.
+func (mr *MockFullNodeMockRecorder) GasBaseFeeHistory(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasBaseFeeHistory", reflect.TypeOf((*MockFullNode)(nil).GasBaseFeeHistory), arg0, arg1, arg2, arg3)
+}
+
 // GasEstimateFeeCap mocks base method.
 func (m *MockFullNode) GasEstimateFeeCap(arg0 context.Context, arg1 *types.Message, arg2 int64, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -1536,6 +1668,21 @@ func (mr *MockFullNodeMockRecorder) GasEstimateFeeCap(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasEstimateFeeCap", reflect.TypeOf((*MockFullNode)(nil).GasEstimateFeeCap), arg0, arg1, arg2, arg3)
 }
 
+// GasEstimateFeeTiers mocks base method.
+func (m *MockFullNode) GasEstimateFeeTiers(arg0 context.Context, arg1 types.TipSetKey) (*api.FeeTiers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GasEstimateFeeTiers", arg0, arg1)
+	ret0, _ := ret[0].(*api.FeeTiers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GasEstimateFeeTiers indicates an expected call of GasEstimateFeeTiers.
+func (mr *MockFullNodeMockRecorder) GasEstimateFeeTiers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GasEstimateFeeTiers", reflect.TypeOf((*MockFullNode)(nil).GasEstimateFeeTiers), arg0, arg1)
+}
+
 // GasEstimateGasLimit mocks base method.
 func (m *MockFullNode) GasEstimateGasLimit(arg0 context.Context, arg1 *types.Message, arg2 types.TipSetKey) (int64, error) {
 	m.ctrl.T.Helper()
@@ -1878,6 +2025,50 @@ func (mr *MockFullNodeMockRecorder) MpoolGetNonce(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGetNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolGetNonce), arg0, arg1)
 }
 
+// MpoolLeaseNonce mocks base method.
+func (m *MockFullNode) MpoolLeaseNonce(arg0 context.Context, arg1 address.Address, arg2 time.Duration) (*api.NonceLease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolLeaseNonce", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.NonceLease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolLeaseNonce indicates an expected call of MpoolLeaseNonce.
+func (mr *MockFullNodeMockRecorder) MpoolLeaseNonce(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolLeaseNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolLeaseNonce), arg0, arg1, arg2)
+}
+
+// MpoolPeerReset mocks base method.
+func (m *MockFullNode) MpoolPeerReset(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPeerReset", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MpoolPeerReset indicates an expected call of MpoolPeerReset.
+func (mr *MockFullNodeMockRecorder) MpoolPeerReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPeerReset", reflect.TypeOf((*MockFullNode)(nil).MpoolPeerReset), arg0, arg1)
+}
+
+// MpoolPeerStats mocks base method.
+func (m *MockFullNode) MpoolPeerStats(arg0 context.Context) ([]api.MpoolPeerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPeerStats", arg0)
+	ret0, _ := ret[0].([]api.MpoolPeerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPeerStats indicates an expected call of MpoolPeerStats.
+func (mr *MockFullNodeMockRecorder) MpoolPeerStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPeerStats", reflect.TypeOf((*MockFullNode)(nil).MpoolPeerStats), arg0)
+}
+
 // MpoolPending mocks base method.
 func (m *MockFullNode) MpoolPending(arg0 context.Context, arg1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1893,6 +2084,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPending(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPending", reflect.TypeOf((*MockFullNode)(nil).MpoolPending), arg0, arg1)
 }
 
+// MpoolPrepareOffline mocks base method.
+func (m *MockFullNode) MpoolPrepareOffline(arg0 context.Context, arg1 *types.Message, arg2 *api.MessageSendSpec) (*api.OfflineMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPrepareOffline", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.OfflineMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPrepareOffline indicates an expected call of MpoolPrepareOffline.
+func (mr *MockFullNodeMockRecorder) MpoolPrepareOffline(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPrepareOffline", reflect.TypeOf((*MockFullNode)(nil).MpoolPrepareOffline), arg0, arg1, arg2)
+}
+
 // MpoolPush mocks base method.
 func (m *MockFullNode) MpoolPush(arg0 context.Context, arg1 *types.SignedMessage) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1923,6 +2129,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPushMessage(arg0, arg1, arg2 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushMessage", reflect.TypeOf((*MockFullNode)(nil).MpoolPushMessage), arg0, arg1, arg2)
 }
 
+// MpoolPushOffline mocks base method.
+func (m *MockFullNode) MpoolPushOffline(arg0 context.Context, arg1 *api.OfflineMessage) (cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPushOffline", arg0, arg1)
+	ret0, _ := ret[0].(cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPushOffline indicates an expected call of MpoolPushOffline.
+func (mr *MockFullNodeMockRecorder) MpoolPushOffline(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushOffline", reflect.TypeOf((*MockFullNode)(nil).MpoolPushOffline), arg0, arg1)
+}
+
 // MpoolPushUntrusted mocks base method.
 func (m *MockFullNode) MpoolPushUntrusted(arg0 context.Context, arg1 *types.SignedMessage) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1938,6 +2159,35 @@ func (mr *MockFullNodeMockRecorder) MpoolPushUntrusted(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushUntrusted", reflect.TypeOf((*MockFullNode)(nil).MpoolPushUntrusted), arg0, arg1)
 }
 
+// MpoolReleaseNonce mocks base method.
+func (m *MockFullNode) MpoolReleaseNonce(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolReleaseNonce", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MpoolReleaseNonce indicates an expected call of MpoolReleaseNonce.
+func (mr *MockFullNodeMockRecorder) MpoolReleaseNonce(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolReleaseNonce", reflect.TypeOf((*MockFullNode)(nil).MpoolReleaseNonce), arg0, arg1)
+}
+
+// MpoolReplaceBatch mocks base method.
+func (m *MockFullNode) MpoolReplaceBatch(arg0 context.Context, arg1 address.Address, arg2 *api.MessageSendSpec) ([]api.ReplaceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolReplaceBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]api.ReplaceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolReplaceBatch indicates an expected call of MpoolReplaceBatch.
+func (mr *MockFullNodeMockRecorder) MpoolReplaceBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolReplaceBatch", reflect.TypeOf((*MockFullNode)(nil).MpoolReplaceBatch), arg0, arg1, arg2)
+}
+
 // MpoolSelect mocks base method.
 func (m *MockFullNode) MpoolSelect(arg0 context.Context, arg1 types.TipSetKey, arg2 float64) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1953,6 +2203,36 @@ func (mr *MockFullNodeMockRecorder) MpoolSelect(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSelect", reflect.TypeOf((*MockFullNode)(nil).MpoolSelect), arg0, arg1, arg2)
 }
 
+// MpoolSendQueue mocks base method.
+func (m *MockFullNode) MpoolSendQueue(arg0 context.Context, arg1 address.Address) ([]*types.SignedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSendQueue", arg0, arg1)
+	ret0, _ := ret[0].([]*types.SignedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSendQueue indicates an expected call of MpoolSendQueue.
+func (mr *MockFullNodeMockRecorder) MpoolSendQueue(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSendQueue", reflect.TypeOf((*MockFullNode)(nil).MpoolSendQueue), arg0, arg1)
+}
+
+// MpoolSendQueueFlush mocks base method.
+func (m *MockFullNode) MpoolSendQueueFlush(arg0 context.Context, arg1 address.Address, arg2 bool) ([]cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSendQueueFlush", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSendQueueFlush indicates an expected call of MpoolSendQueueFlush.
+func (mr *MockFullNodeMockRecorder) MpoolSendQueueFlush(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSendQueueFlush", reflect.TypeOf((*MockFullNode)(nil).MpoolSendQueueFlush), arg0, arg1, arg2)
+}
+
 // MpoolSetConfig mocks base method.
 func (m *MockFullNode) MpoolSetConfig(arg0 context.Context, arg1 *types.MpoolConfig) error {
 	m.ctrl.T.Helper()
@@ -1982,6 +2262,21 @@ func (mr *MockFullNodeMockRecorder) MpoolSub(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSub", reflect.TypeOf((*MockFullNode)(nil).MpoolSub), arg0)
 }
 
+// MpoolSubFiltered mocks base method.
+func (m *MockFullNode) MpoolSubFiltered(arg0 context.Context, arg1 api.MpoolUpdateFilter) (<-chan api.MpoolUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSubFiltered", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.MpoolUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSubFiltered indicates an expected call of MpoolSubFiltered.
+func (mr *MockFullNodeMockRecorder) MpoolSubFiltered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSubFiltered", reflect.TypeOf((*MockFullNode)(nil).MpoolSubFiltered), arg0, arg1)
+}
+
 // MsigAddApprove mocks base method.
 func (m *MockFullNode) MsigAddApprove(arg0 context.Context, arg1, arg2 address.Address, arg3 uint64, arg4, arg5 address.Address, arg6 bool) (*api.MessagePrototype, error) {
 	m.ctrl.T.Helper()
@@ -2132,6 +2427,21 @@ func (mr *MockFullNodeMockRecorder) MsigGetPending(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MsigGetPending", reflect.TypeOf((*MockFullNode)(nil).MsigGetPending), arg0, arg1, arg2)
 }
 
+// MsigGetPendingDecoded mocks base method.
+func (m *MockFullNode) MsigGetPendingDecoded(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) ([]*api.MsigDecodedTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MsigGetPendingDecoded", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.MsigDecodedTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MsigGetPendingDecoded indicates an expected call of MsigGetPendingDecoded.
+func (mr *MockFullNodeMockRecorder) MsigGetPendingDecoded(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MsigGetPendingDecoded", reflect.TypeOf((*MockFullNode)(nil).MsigGetPendingDecoded), arg0, arg1, arg2)
+}
+
 // MsigGetVested mocks base method.
 func (m *MockFullNode) MsigGetVested(arg0 context.Context, arg1 address.Address, arg2, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -2605,6 +2915,21 @@ func (mr *MockFullNodeMockRecorder) NetVersion(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetVersion", reflect.TypeOf((*MockFullNode)(nil).NetVersion), arg0)
 }
 
+// NodeHealth mocks base method.
+func (m *MockFullNode) NodeHealth(arg0 context.Context) (api.NodeHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NodeHealth", arg0)
+	ret0, _ := ret[0].(api.NodeHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodeHealth indicates an expected call of NodeHealth.
+func (mr *MockFullNodeMockRecorder) NodeHealth(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeHealth", reflect.TypeOf((*MockFullNode)(nil).NodeHealth), arg0)
+}
+
 // NodeStatus mocks base method.
 func (m *MockFullNode) NodeStatus(arg0 context.Context, arg1 bool) (api.NodeStatus, error) {
 	m.ctrl.T.Helper()
@@ -2993,6 +3318,21 @@ func (mr *MockFullNodeMockRecorder) StateActorManifestCID(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorManifestCID", reflect.TypeOf((*MockFullNode)(nil).StateActorManifestCID), arg0, arg1)
 }
 
+// StateActorRegistry mocks base method.
+func (m *MockFullNode) StateActorRegistry(arg0 context.Context) ([]api.ActorCodeInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateActorRegistry", arg0)
+	ret0, _ := ret[0].([]api.ActorCodeInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateActorRegistry indicates an expected call of StateActorRegistry.
+func (mr *MockFullNodeMockRecorder) StateActorRegistry(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorRegistry", reflect.TypeOf((*MockFullNode)(nil).StateActorRegistry), arg0)
+}
+
 // StateAllMinerFaults mocks base method.
 func (m *MockFullNode) StateAllMinerFaults(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey) ([]*api.Fault, error) {
 	m.ctrl.T.Helper()
@@ -3023,6 +3363,21 @@ func (mr *MockFullNodeMockRecorder) StateCall(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCall", reflect.TypeOf((*MockFullNode)(nil).StateCall), arg0, arg1, arg2)
 }
 
+// StateCallBundle mocks base method.
+func (m *MockFullNode) StateCallBundle(arg0 context.Context, arg1 []*types.Message, arg2 types.TipSetKey) (*api.CallBundleResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCallBundle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.CallBundleResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCallBundle indicates an expected call of StateCallBundle.
+func (mr *MockFullNodeMockRecorder) StateCallBundle(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCallBundle", reflect.TypeOf((*MockFullNode)(nil).StateCallBundle), arg0, arg1, arg2)
+}
+
 // StateChangedActors mocks base method.
 func (m *MockFullNode) StateChangedActors(arg0 context.Context, arg1, arg2 cid.Cid) (map[string]types.ActorV5, error) {
 	m.ctrl.T.Helper()
@@ -3113,6 +3468,21 @@ func (mr *MockFullNodeMockRecorder) StateDecodeParams(arg0, arg1, arg2, arg3, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeParams", reflect.TypeOf((*MockFullNode)(nil).StateDecodeParams), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateDiff mocks base method.
+func (m *MockFullNode) StateDiff(arg0 context.Context, arg1, arg2 types.TipSetKey, arg3 bool) ([]api.ActorDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDiff", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]api.ActorDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDiff indicates an expected call of StateDiff.
+func (mr *MockFullNodeMockRecorder) StateDiff(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDiff", reflect.TypeOf((*MockFullNode)(nil).StateDiff), arg0, arg1, arg2, arg3)
+}
+
 // StateEncodeParams mocks base method.
 func (m *MockFullNode) StateEncodeParams(arg0 context.Context, arg1 cid.Cid, arg2 abi.MethodNum, arg3 json.RawMessage) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -3518,6 +3888,21 @@ func (mr *MockFullNodeMockRecorder) StateMinerInitialPledgeCollateral(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerInitialPledgeCollateral", reflect.TypeOf((*MockFullNode)(nil).StateMinerInitialPledgeCollateral), arg0, arg1, arg2, arg3)
 }
 
+// StateMinerKeyRotation mocks base method.
+func (m *MockFullNode) StateMinerKeyRotation(arg0 context.Context, arg1, arg2, arg3 address.Address, arg4 types.TipSetKey) ([]api.MinerKeyRotationStep, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinerKeyRotation", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]api.MinerKeyRotationStep)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinerKeyRotation indicates an expected call of StateMinerKeyRotation.
+func (mr *MockFullNodeMockRecorder) StateMinerKeyRotation(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerKeyRotation", reflect.TypeOf((*MockFullNode)(nil).StateMinerKeyRotation), arg0, arg1, arg2, arg3, arg4)
+}
+
 // StateMinerPartitions mocks base method.
 func (m *MockFullNode) StateMinerPartitions(arg0 context.Context, arg1 address.Address, arg2 uint64, arg3 types.TipSetKey) ([]api.Partition, error) {
 	m.ctrl.T.Helper()
@@ -3698,6 +4083,21 @@ func (mr *MockFullNodeMockRecorder) StateReplay(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplay", reflect.TypeOf((*MockFullNode)(nil).StateReplay), arg0, arg1, arg2)
 }
 
+// StateReplayWithOptions mocks base method.
+func (m *MockFullNode) StateReplayWithOptions(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 api.ReplayOptions) (*api.InvocResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateReplayWithOptions", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.InvocResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateReplayWithOptions indicates an expected call of StateReplayWithOptions.
+func (mr *MockFullNodeMockRecorder) StateReplayWithOptions(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplayWithOptions", reflect.TypeOf((*MockFullNode)(nil).StateReplayWithOptions), arg0, arg1, arg2, arg3)
+}
+
 // StateSearchMsg mocks base method.
 func (m *MockFullNode) StateSearchMsg(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 abi.ChainEpoch, arg4 bool) (*api.MsgLookup, error) {
 	m.ctrl.T.Helper()
@@ -3773,6 +4173,36 @@ func (mr *MockFullNodeMockRecorder) StateSectorPreCommitInfo(arg0, arg1, arg2, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateSectorPreCommitInfo", reflect.TypeOf((*MockFullNode)(nil).StateSectorPreCommitInfo), arg0, arg1, arg2, arg3)
 }
 
+// StateSubscribeActorChanges mocks base method.
+func (m *MockFullNode) StateSubscribeActorChanges(arg0 context.Context, arg1 []address.Address) (<-chan api.ActorChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateSubscribeActorChanges", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.ActorChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateSubscribeActorChanges indicates an expected call of StateSubscribeActorChanges.
+func (mr *MockFullNodeMockRecorder) StateSubscribeActorChanges(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateSubscribeActorChanges", reflect.TypeOf((*MockFullNode)(nil).StateSubscribeActorChanges), arg0, arg1)
+}
+
+// StateVMCirculatingSupplyAt mocks base method.
+func (m *MockFullNode) StateVMCirculatingSupplyAt(arg0 context.Context, arg1 abi.ChainEpoch) (api.CirculatingSupply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateVMCirculatingSupplyAt", arg0, arg1)
+	ret0, _ := ret[0].(api.CirculatingSupply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateVMCirculatingSupplyAt indicates an expected call of StateVMCirculatingSupplyAt.
+func (mr *MockFullNodeMockRecorder) StateVMCirculatingSupplyAt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateVMCirculatingSupplyAt", reflect.TypeOf((*MockFullNode)(nil).StateVMCirculatingSupplyAt), arg0, arg1)
+}
+
 // StateVMCirculatingSupplyInternal mocks base method.
 func (m *MockFullNode) StateVMCirculatingSupplyInternal(arg0 context.Context, arg1 types.TipSetKey) (api.CirculatingSupply, error) {
 	m.ctrl.T.Helper()
@@ -4052,6 +4482,21 @@ func (mr *MockFullNodeMockRecorder) WalletExport(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletExport", reflect.TypeOf((*MockFullNode)(nil).WalletExport), arg0, arg1)
 }
 
+// WalletExportEncrypted mocks base method.
+func (m *MockFullNode) WalletExportEncrypted(arg0 context.Context, arg1 address.Address, arg2 string) (*types.EncryptedKeyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletExportEncrypted", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.EncryptedKeyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletExportEncrypted indicates an expected call of WalletExportEncrypted.
+func (mr *MockFullNodeMockRecorder) WalletExportEncrypted(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletExportEncrypted", reflect.TypeOf((*MockFullNode)(nil).WalletExportEncrypted), arg0, arg1, arg2)
+}
+
 // WalletHas mocks base method.
 func (m *MockFullNode) WalletHas(arg0 context.Context, arg1 address.Address) (bool, error) {
 	m.ctrl.T.Helper()
@@ -4082,6 +4527,21 @@ func (mr *MockFullNodeMockRecorder) WalletImport(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletImport", reflect.TypeOf((*MockFullNode)(nil).WalletImport), arg0, arg1)
 }
 
+// WalletImportEncrypted mocks base method.
+func (m *MockFullNode) WalletImportEncrypted(arg0 context.Context, arg1 *types.EncryptedKeyInfo, arg2 string) (address.Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletImportEncrypted", arg0, arg1, arg2)
+	ret0, _ := ret[0].(address.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletImportEncrypted indicates an expected call of WalletImportEncrypted.
+func (mr *MockFullNodeMockRecorder) WalletImportEncrypted(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletImportEncrypted", reflect.TypeOf((*MockFullNode)(nil).WalletImportEncrypted), arg0, arg1, arg2)
+}
+
 // WalletList mocks base method.
 func (m *MockFullNode) WalletList(arg0 context.Context) ([]address.Address, error) {
 	m.ctrl.T.Helper()
@@ -4097,6 +4557,21 @@ func (mr *MockFullNodeMockRecorder) WalletList(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletList", reflect.TypeOf((*MockFullNode)(nil).WalletList), arg0)
 }
 
+// WalletListWatched mocks base method.
+func (m *MockFullNode) WalletListWatched(arg0 context.Context) ([]address.Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletListWatched", arg0)
+	ret0, _ := ret[0].([]address.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletListWatched indicates an expected call of WalletListWatched.
+func (mr *MockFullNodeMockRecorder) WalletListWatched(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletListWatched", reflect.TypeOf((*MockFullNode)(nil).WalletListWatched), arg0)
+}
+
 // WalletNew mocks base method.
 func (m *MockFullNode) WalletNew(arg0 context.Context, arg1 types.KeyType) (address.Address, error) {
 	m.ctrl.T.Helper()
@@ -4156,6 +4631,35 @@ func (mr *MockFullNodeMockRecorder) WalletSignMessage(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletSignMessage", reflect.TypeOf((*MockFullNode)(nil).WalletSignMessage), arg0, arg1, arg2)
 }
 
+// WalletSubscribeBalances mocks base method.
+func (m *MockFullNode) WalletSubscribeBalances(arg0 context.Context) (<-chan api.WalletBalanceChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletSubscribeBalances", arg0)
+	ret0, _ := ret[0].(<-chan api.WalletBalanceChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WalletSubscribeBalances indicates an expected call of WalletSubscribeBalances.
+func (mr *MockFullNodeMockRecorder) WalletSubscribeBalances(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletSubscribeBalances", reflect.TypeOf((*MockFullNode)(nil).WalletSubscribeBalances), arg0)
+}
+
+// WalletUnwatch mocks base method.
+func (m *MockFullNode) WalletUnwatch(arg0 context.Context, arg1 address.Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletUnwatch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletUnwatch indicates an expected call of WalletUnwatch.
+func (mr *MockFullNodeMockRecorder) WalletUnwatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletUnwatch", reflect.TypeOf((*MockFullNode)(nil).WalletUnwatch), arg0, arg1)
+}
+
 // WalletValidateAddress mocks base method.
 func (m *MockFullNode) WalletValidateAddress(arg0 context.Context, arg1 string) (address.Address, error) {
 	m.ctrl.T.Helper()
@@ -4186,6 +4690,20 @@ func (mr *MockFullNodeMockRecorder) WalletVerify(arg0, arg1, arg2, arg3 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletVerify", reflect.TypeOf((*MockFullNode)(nil).WalletVerify), arg0, arg1, arg2, arg3)
 }
 
+// WalletWatch mocks base method.
+func (m *MockFullNode) WalletWatch(arg0 context.Context, arg1 address.Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalletWatch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalletWatch indicates an expected call of WalletWatch.
+func (mr *MockFullNodeMockRecorder) WalletWatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletWatch", reflect.TypeOf((*MockFullNode)(nil).WalletWatch), arg0, arg1)
+}
+
 // Web3ClientVersion mocks base method.
 func (m *MockFullNode) Web3ClientVersion(arg0 context.Context) (string, error) {
 	m.ctrl.T.Helper()
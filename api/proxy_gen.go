@@ -75,6 +75,8 @@ type CommonMethods struct {
 
 	Closing func(p0 context.Context) (<-chan struct{}, error) `perm:"read"`
 
+	ConfigReload func(p0 context.Context) error `perm:"admin"`
+
 	Discover func(p0 context.Context) (apitypes.OpenRPCDocument, error) `perm:"read"`
 
 	LogAlerts func(p0 context.Context) ([]alerting.Alert, error) `perm:"admin"`
@@ -132,10 +134,16 @@ type FullNodeStruct struct {
 }
 
 type FullNodeMethods struct {
+	ChainBlockstoreDumpMarkSet func(p0 context.Context, p1 string) error `perm:"admin"`
+
 	ChainBlockstoreInfo func(p0 context.Context) (map[string]interface{}, error) `perm:"read"`
 
+	ChainBlockstorePendingWrites func(p0 context.Context) ([]cid.Cid, error) `perm:"admin"`
+
 	ChainCheckBlockstore func(p0 context.Context) error `perm:"admin"`
 
+	ChainColdDedup func(p0 context.Context) (ColdDedupResult, error) `perm:"admin"`
+
 	ChainDeleteObj func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
 	ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
@@ -224,6 +232,8 @@ type FullNodeMethods struct {
 
 	ClientListImports func(p0 context.Context) ([]Import, error) `perm:"write"`
 
+	ClientListResumableRetrievals func(p0 context.Context) ([]ResumableRetrieval, error) `perm:"write"`
+
 	ClientListRetrievals func(p0 context.Context) ([]RetrievalInfo, error) `perm:"write"`
 
 	ClientMinerQueryOffer func(p0 context.Context, p1 address.Address, p2 cid.Cid, p3 *cid.Cid) (QueryOffer, error) `perm:"read"`
@@ -234,6 +244,12 @@ type FullNodeMethods struct {
 
 	ClientRestartDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
+	ClientResumeRetrieval func(p0 context.Context, p1 retrievalmarket.DealID) error `perm:"write"`
+
+	ClientRetrievalHistory func(p0 context.Context, p1 address.Address) ([]RetrievalAttempt, error) `perm:"read"`
+
+	ClientRetrievalProviderStats func(p0 context.Context) ([]RetrievalProviderStats, error) `perm:"read"`
+
 	ClientRetrieve func(p0 context.Context, p1 RetrievalOrder) (*RestrievalRes, error) `perm:"admin"`
 
 	ClientRetrieveTryRestartInsufficientFunds func(p0 context.Context, p1 address.Address) error `perm:"write"`
@@ -320,8 +336,12 @@ type FullNodeMethods struct {
 
 	FilecoinAddressToEthAddress func(p0 context.Context, p1 address.Address) (ethtypes.EthAddress, error) `perm:"read"`
 
+	GasBaseFeeHistory func(p0 context.Context, p1 uint64, p2 uint64, p3 types.TipSetKey) (*BaseFeeHistory, error) `perm:"read"`
+
 	GasEstimateFeeCap func(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
+	GasEstimateFeeTiers func(p0 context.Context, p1 types.TipSetKey) (*FeeTiers, error) `perm:"read"`
+
 	GasEstimateGasLimit func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) `perm:"read"`
 
 	GasEstimateGasPremium func(p0 context.Context, p1 uint64, p2 address.Address, p3 int64, p4 types.TipSetKey) (types.BigInt, error) `perm:"read"`
@@ -360,20 +380,40 @@ type FullNodeMethods struct {
 
 	MpoolGetNonce func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"read"`
 
+	MpoolLeaseNonce func(p0 context.Context, p1 address.Address, p2 time.Duration) (*NonceLease, error) `perm:"sign"`
+
+	MpoolPeerReset func(p0 context.Context, p1 string) error `perm:"admin"`
+
+	MpoolPeerStats func(p0 context.Context) ([]MpoolPeerInfo, error) `perm:"read"`
+
 	MpoolPending func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) `perm:"read"`
 
+	MpoolPrepareOffline func(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*OfflineMessage, error) `perm:"sign"`
+
 	MpoolPush func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
 	MpoolPushMessage func(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*types.SignedMessage, error) `perm:"sign"`
 
+	MpoolPushOffline func(p0 context.Context, p1 *OfflineMessage) (cid.Cid, error) `perm:"write"`
+
 	MpoolPushUntrusted func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+	MpoolReleaseNonce func(p0 context.Context, p1 uuid.UUID) error `perm:"sign"`
+
+	MpoolReplaceBatch func(p0 context.Context, p1 address.Address, p2 *MessageSendSpec) ([]ReplaceResult, error) `perm:"sign"`
+
 	MpoolSelect func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) `perm:"read"`
 
+	MpoolSendQueue func(p0 context.Context, p1 address.Address) ([]*types.SignedMessage, error) `perm:"read"`
+
+	MpoolSendQueueFlush func(p0 context.Context, p1 address.Address, p2 bool) ([]cid.Cid, error) `perm:"write"`
+
 	MpoolSetConfig func(p0 context.Context, p1 *types.MpoolConfig) error `perm:"admin"`
 
 	MpoolSub func(p0 context.Context) (<-chan MpoolUpdate, error) `perm:"read"`
 
+	MpoolSubFiltered func(p0 context.Context, p1 MpoolUpdateFilter) (<-chan MpoolUpdate, error) `perm:"read"`
+
 	MsigAddApprove func(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 address.Address, p6 bool) (*MessagePrototype, error) `perm:"sign"`
 
 	MsigAddCancel func(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 bool) (*MessagePrototype, error) `perm:"sign"`
@@ -394,6 +434,8 @@ type FullNodeMethods struct {
 
 	MsigGetPending func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*MsigTransaction, error) `perm:"read"`
 
+	MsigGetPendingDecoded func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*MsigDecodedTransaction, error) `perm:"read"`
+
 	MsigGetVested func(p0 context.Context, p1 address.Address, p2 types.TipSetKey, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
 	MsigGetVestingSchedule func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (MsigVesting, error) `perm:"read"`
@@ -412,6 +454,8 @@ type FullNodeMethods struct {
 
 	NetVersion func(p0 context.Context) (string, error) `perm:"read"`
 
+	NodeHealth func(p0 context.Context) (NodeHealth, error) `perm:"read"`
+
 	NodeStatus func(p0 context.Context, p1 bool) (NodeStatus, error) `perm:"read"`
 
 	PaychAllocateLane func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"sign"`
@@ -458,10 +502,14 @@ type FullNodeMethods struct {
 
 	StateActorManifestCID func(p0 context.Context, p1 abinetwork.Version) (cid.Cid, error) `perm:"read"`
 
+	StateActorRegistry func(p0 context.Context) ([]ActorCodeInfo, error) `perm:"read"`
+
 	StateAllMinerFaults func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) `perm:"read"`
 
 	StateCall func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (*InvocResult, error) `perm:"read"`
 
+	StateCallBundle func(p0 context.Context, p1 []*types.Message, p2 types.TipSetKey) (*CallBundleResult, error) `perm:"read"`
+
 	StateChangedActors func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (map[string]types.Actor, error) `perm:"read"`
 
 	StateCirculatingSupply func(p0 context.Context, p1 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
@@ -474,6 +522,8 @@ type FullNodeMethods struct {
 
 	StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
 
+	StateDiff func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 bool) ([]ActorDiff, error) `perm:"read"`
+
 	StateEncodeParams func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) `perm:"read"`
 
 	StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
@@ -528,6 +578,8 @@ type FullNodeMethods struct {
 
 	StateMinerInitialPledgeCollateral func(p0 context.Context, p1 address.Address, p2 miner.SectorPreCommitInfo, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
+	StateMinerKeyRotation func(p0 context.Context, p1 address.Address, p2 address.Address, p3 address.Address, p4 types.TipSetKey) ([]MinerKeyRotationStep, error) `perm:"read"`
+
 	StateMinerPartitions func(p0 context.Context, p1 address.Address, p2 uint64, p3 types.TipSetKey) ([]Partition, error) `perm:"read"`
 
 	StateMinerPower func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*MinerPower, error) `perm:"read"`
@@ -552,6 +604,8 @@ type FullNodeMethods struct {
 
 	StateReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*InvocResult, error) `perm:"read"`
 
+	StateReplayWithOptions func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 ReplayOptions) (*InvocResult, error) `perm:"read"`
+
 	StateSearchMsg func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
 	StateSectorExpiration func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*lminer.SectorExpiration, error) `perm:"read"`
@@ -562,6 +616,10 @@ type FullNodeMethods struct {
 
 	StateSectorPreCommitInfo func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*miner.SectorPreCommitOnChainInfo, error) `perm:"read"`
 
+	StateSubscribeActorChanges func(p0 context.Context, p1 []address.Address) (<-chan ActorChange, error) `perm:"read"`
+
+	StateVMCirculatingSupplyAt func(p0 context.Context, p1 abi.ChainEpoch) (CirculatingSupply, error) `perm:"read"`
+
 	StateVMCirculatingSupplyInternal func(p0 context.Context, p1 types.TipSetKey) (CirculatingSupply, error) `perm:"read"`
 
 	StateVerifiedClientStatus func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*abi.StoragePower, error) `perm:"read"`
@@ -598,12 +656,18 @@ type FullNodeMethods struct {
 
 	WalletExport func(p0 context.Context, p1 address.Address) (*types.KeyInfo, error) `perm:"admin"`
 
+	WalletExportEncrypted func(p0 context.Context, p1 address.Address, p2 string) (*types.EncryptedKeyInfo, error) `perm:"admin"`
+
 	WalletHas func(p0 context.Context, p1 address.Address) (bool, error) `perm:"write"`
 
 	WalletImport func(p0 context.Context, p1 *types.KeyInfo) (address.Address, error) `perm:"admin"`
 
+	WalletImportEncrypted func(p0 context.Context, p1 *types.EncryptedKeyInfo, p2 string) (address.Address, error) `perm:"admin"`
+
 	WalletList func(p0 context.Context) ([]address.Address, error) `perm:"write"`
 
+	WalletListWatched func(p0 context.Context) ([]address.Address, error) `perm:"write"`
+
 	WalletNew func(p0 context.Context, p1 types.KeyType) (address.Address, error) `perm:"write"`
 
 	WalletSetDefault func(p0 context.Context, p1 address.Address) error `perm:"write"`
@@ -612,10 +676,16 @@ type FullNodeMethods struct {
 
 	WalletSignMessage func(p0 context.Context, p1 address.Address, p2 *types.Message) (*types.SignedMessage, error) `perm:"sign"`
 
+	WalletSubscribeBalances func(p0 context.Context) (<-chan WalletBalanceChange, error) `perm:"write"`
+
+	WalletUnwatch func(p0 context.Context, p1 address.Address) error `perm:"write"`
+
 	WalletValidateAddress func(p0 context.Context, p1 string) (address.Address, error) `perm:"read"`
 
 	WalletVerify func(p0 context.Context, p1 address.Address, p2 []byte, p3 *crypto.Signature) (bool, error) `perm:"read"`
 
+	WalletWatch func(p0 context.Context, p1 address.Address) error `perm:"write"`
+
 	Web3ClientVersion func(p0 context.Context) (string, error) `perm:"read"`
 }
 
@@ -947,10 +1017,18 @@ type StorageMinerMethods struct {
 
 	IndexerAnnounceDeal func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
+	IndexerEndpointAnnounceDeals func(p0 context.Context, p1 []cid.Cid) (int, error) `perm:"admin"`
+
+	IndexerEndpointQueue func(p0 context.Context) ([]PendingPieceAnnouncement, error) `perm:"read"`
+
 	MarketCancelDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
+	MarketClientQuotaUsage func(p0 context.Context) ([]ClientQuotaUsage, error) `perm:"read"`
+
 	MarketDataTransferDiagnostics func(p0 context.Context, p1 peer.ID) (*TransferDiagnostics, error) `perm:"write"`
 
+	MarketDataTransferProgress func(p0 context.Context) ([]DataTransferProgress, error) `perm:"read"`
+
 	MarketDataTransferUpdates func(p0 context.Context) (<-chan DataTransferChannel, error) `perm:"write"`
 
 	MarketGetAsk func(p0 context.Context) (*storagemarket.SignedStorageAsk, error) `perm:"read"`
@@ -1035,14 +1113,22 @@ type StorageMinerMethods struct {
 
 	SealingAbort func(p0 context.Context, p1 storiface.CallID) error `perm:"admin"`
 
+	SealingPauseStates func(p0 context.Context, p1 []SectorState) error `perm:"admin"`
+
+	SealingPausedStates func(p0 context.Context) ([]SectorState, error) `perm:"read"`
+
 	SealingRemoveRequest func(p0 context.Context, p1 uuid.UUID) error `perm:"admin"`
 
+	SealingResumeStates func(p0 context.Context, p1 []SectorState) error `perm:"admin"`
+
 	SealingSchedDiag func(p0 context.Context, p1 bool) (interface{}, error) `perm:"admin"`
 
 	SectorAbortUpgrade func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
 	SectorAddPieceToAny func(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storiface.Data, p3 PieceDealInfo) (SectorOffset, error) `perm:"admin"`
 
+	SectorBatchingStatus func(p0 context.Context) (SectorBatchingStatus, error) `perm:"read"`
+
 	SectorCommitFlush func(p0 context.Context) ([]sealiface.CommitBatchRes, error) `perm:"admin"`
 
 	SectorCommitPending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
@@ -1089,8 +1175,12 @@ type StorageMinerMethods struct {
 
 	SectorsListInStates func(p0 context.Context, p1 []SectorState) ([]abi.SectorNumber, error) `perm:"read"`
 
+	SectorsPipelineStats func(p0 context.Context) (SectorsPipelineStats, error) `perm:"read"`
+
 	SectorsRefs func(p0 context.Context) (map[string][]SealedRef, error) `perm:"read"`
 
+	SectorsReleaseUnsealed func(p0 context.Context, p1 storiface.SectorRef) error `perm:"admin"`
+
 	SectorsStatus func(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) `perm:"read"`
 
 	SectorsSummary func(p0 context.Context) (map[SectorState]int, error) `perm:"read"`
@@ -1119,6 +1209,8 @@ type StorageMinerMethods struct {
 
 	StorageGetLocks func(p0 context.Context) (storiface.SectorLocks, error) `perm:"admin"`
 
+	StorageHealth func(p0 context.Context) (map[storiface.ID]storiface.PathHealth, error) `perm:"admin"`
+
 	StorageInfo func(p0 context.Context, p1 storiface.ID) (storiface.StorageInfo, error) `perm:"admin"`
 
 	StorageList func(p0 context.Context) (map[storiface.ID][]storiface.Decl, error) `perm:"admin"`
@@ -1139,6 +1231,8 @@ type StorageMinerMethods struct {
 
 	WorkerJobs func(p0 context.Context) (map[uuid.UUID][]storiface.WorkerJob, error) `perm:"admin"`
 
+	WorkerResourceTable func(p0 context.Context, p1 uuid.UUID) (map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) `perm:"admin"`
+
 	WorkerStats func(p0 context.Context) (map[uuid.UUID]storiface.WorkerStats, error) `perm:"admin"`
 }
 
@@ -1320,6 +1414,17 @@ func (s *CommonStub) Closing(p0 context.Context) (<-chan struct{}, error) {
 	return nil, ErrNotSupported
 }
 
+func (s *CommonStruct) ConfigReload(p0 context.Context) error {
+	if s.Internal.ConfigReload == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ConfigReload(p0)
+}
+
+func (s *CommonStub) ConfigReload(p0 context.Context) error {
+	return ErrNotSupported
+}
+
 func (s *CommonStruct) Discover(p0 context.Context) (apitypes.OpenRPCDocument, error) {
 	if s.Internal.Discover == nil {
 		return *new(apitypes.OpenRPCDocument), ErrNotSupported
@@ -1419,6 +1524,17 @@ func (s *EthSubscriberStub) EthSubscription(p0 context.Context, p1 jsonrpc.RawPa
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBlockstoreDumpMarkSet(p0 context.Context, p1 string) error {
+	if s.Internal.ChainBlockstoreDumpMarkSet == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreDumpMarkSet(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreDumpMarkSet(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainBlockstoreInfo(p0 context.Context) (map[string]interface{}, error) {
 	if s.Internal.ChainBlockstoreInfo == nil {
 		return *new(map[string]interface{}), ErrNotSupported
@@ -1430,6 +1546,17 @@ func (s *FullNodeStub) ChainBlockstoreInfo(p0 context.Context) (map[string]inter
 	return *new(map[string]interface{}), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBlockstorePendingWrites(p0 context.Context) ([]cid.Cid, error) {
+	if s.Internal.ChainBlockstorePendingWrites == nil {
+		return *new([]cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstorePendingWrites(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstorePendingWrites(p0 context.Context) ([]cid.Cid, error) {
+	return *new([]cid.Cid), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainCheckBlockstore(p0 context.Context) error {
 	if s.Internal.ChainCheckBlockstore == nil {
 		return ErrNotSupported
@@ -1441,6 +1568,17 @@ func (s *FullNodeStub) ChainCheckBlockstore(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainColdDedup(p0 context.Context) (ColdDedupResult, error) {
+	if s.Internal.ChainColdDedup == nil {
+		return *new(ColdDedupResult), ErrNotSupported
+	}
+	return s.Internal.ChainColdDedup(p0)
+}
+
+func (s *FullNodeStub) ChainColdDedup(p0 context.Context) (ColdDedupResult, error) {
+	return *new(ColdDedupResult), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainDeleteObj(p0 context.Context, p1 cid.Cid) error {
 	if s.Internal.ChainDeleteObj == nil {
 		return ErrNotSupported
@@ -1925,6 +2063,17 @@ func (s *FullNodeStub) ClientListImports(p0 context.Context) ([]Import, error) {
 	return *new([]Import), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientListResumableRetrievals(p0 context.Context) ([]ResumableRetrieval, error) {
+	if s.Internal.ClientListResumableRetrievals == nil {
+		return *new([]ResumableRetrieval), ErrNotSupported
+	}
+	return s.Internal.ClientListResumableRetrievals(p0)
+}
+
+func (s *FullNodeStub) ClientListResumableRetrievals(p0 context.Context) ([]ResumableRetrieval, error) {
+	return *new([]ResumableRetrieval), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientListRetrievals(p0 context.Context) ([]RetrievalInfo, error) {
 	if s.Internal.ClientListRetrievals == nil {
 		return *new([]RetrievalInfo), ErrNotSupported
@@ -1980,6 +2129,39 @@ func (s *FullNodeStub) ClientRestartDataTransfer(p0 context.Context, p1 datatran
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientResumeRetrieval(p0 context.Context, p1 retrievalmarket.DealID) error {
+	if s.Internal.ClientResumeRetrieval == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ClientResumeRetrieval(p0, p1)
+}
+
+func (s *FullNodeStub) ClientResumeRetrieval(p0 context.Context, p1 retrievalmarket.DealID) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientRetrievalHistory(p0 context.Context, p1 address.Address) ([]RetrievalAttempt, error) {
+	if s.Internal.ClientRetrievalHistory == nil {
+		return *new([]RetrievalAttempt), ErrNotSupported
+	}
+	return s.Internal.ClientRetrievalHistory(p0, p1)
+}
+
+func (s *FullNodeStub) ClientRetrievalHistory(p0 context.Context, p1 address.Address) ([]RetrievalAttempt, error) {
+	return *new([]RetrievalAttempt), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientRetrievalProviderStats(p0 context.Context) ([]RetrievalProviderStats, error) {
+	if s.Internal.ClientRetrievalProviderStats == nil {
+		return *new([]RetrievalProviderStats), ErrNotSupported
+	}
+	return s.Internal.ClientRetrievalProviderStats(p0)
+}
+
+func (s *FullNodeStub) ClientRetrievalProviderStats(p0 context.Context) ([]RetrievalProviderStats, error) {
+	return *new([]RetrievalProviderStats), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientRetrieve(p0 context.Context, p1 RetrievalOrder) (*RestrievalRes, error) {
 	if s.Internal.ClientRetrieve == nil {
 		return nil, ErrNotSupported
@@ -2453,6 +2635,17 @@ func (s *FullNodeStub) FilecoinAddressToEthAddress(p0 context.Context, p1 addres
 	return *new(ethtypes.EthAddress), ErrNotSupported
 }
 
+func (s *FullNodeStruct) GasBaseFeeHistory(p0 context.Context, p1 uint64, p2 uint64, p3 types.TipSetKey) (*BaseFeeHistory, error) {
+	if s.Internal.GasBaseFeeHistory == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GasBaseFeeHistory(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) GasBaseFeeHistory(p0 context.Context, p1 uint64, p2 uint64, p3 types.TipSetKey) (*BaseFeeHistory, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) GasEstimateFeeCap(p0 context.Context, p1 *types.Message, p2 int64, p3 types.TipSetKey) (types.BigInt, error) {
 	if s.Internal.GasEstimateFeeCap == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -2464,6 +2657,17 @@ func (s *FullNodeStub) GasEstimateFeeCap(p0 context.Context, p1 *types.Message,
 	return *new(types.BigInt), ErrNotSupported
 }
 
+func (s *FullNodeStruct) GasEstimateFeeTiers(p0 context.Context, p1 types.TipSetKey) (*FeeTiers, error) {
+	if s.Internal.GasEstimateFeeTiers == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GasEstimateFeeTiers(p0, p1)
+}
+
+func (s *FullNodeStub) GasEstimateFeeTiers(p0 context.Context, p1 types.TipSetKey) (*FeeTiers, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) GasEstimateGasLimit(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (int64, error) {
 	if s.Internal.GasEstimateGasLimit == nil {
 		return 0, ErrNotSupported
@@ -2673,6 +2877,39 @@ func (s *FullNodeStub) MpoolGetNonce(p0 context.Context, p1 address.Address) (ui
 	return 0, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolLeaseNonce(p0 context.Context, p1 address.Address, p2 time.Duration) (*NonceLease, error) {
+	if s.Internal.MpoolLeaseNonce == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolLeaseNonce(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolLeaseNonce(p0 context.Context, p1 address.Address, p2 time.Duration) (*NonceLease, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolPeerReset(p0 context.Context, p1 string) error {
+	if s.Internal.MpoolPeerReset == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MpoolPeerReset(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolPeerReset(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolPeerStats(p0 context.Context) ([]MpoolPeerInfo, error) {
+	if s.Internal.MpoolPeerStats == nil {
+		return *new([]MpoolPeerInfo), ErrNotSupported
+	}
+	return s.Internal.MpoolPeerStats(p0)
+}
+
+func (s *FullNodeStub) MpoolPeerStats(p0 context.Context) ([]MpoolPeerInfo, error) {
+	return *new([]MpoolPeerInfo), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolPending == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2684,6 +2921,17 @@ func (s *FullNodeStub) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*
 	return *new([]*types.SignedMessage), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolPrepareOffline(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*OfflineMessage, error) {
+	if s.Internal.MpoolPrepareOffline == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolPrepareOffline(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolPrepareOffline(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*OfflineMessage, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPush(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) {
 	if s.Internal.MpoolPush == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2706,6 +2954,17 @@ func (s *FullNodeStub) MpoolPushMessage(p0 context.Context, p1 *types.Message, p
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolPushOffline(p0 context.Context, p1 *OfflineMessage) (cid.Cid, error) {
+	if s.Internal.MpoolPushOffline == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.MpoolPushOffline(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolPushOffline(p0 context.Context, p1 *OfflineMessage) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) {
 	if s.Internal.MpoolPushUntrusted == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2717,6 +2976,28 @@ func (s *FullNodeStub) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMe
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolReleaseNonce(p0 context.Context, p1 uuid.UUID) error {
+	if s.Internal.MpoolReleaseNonce == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MpoolReleaseNonce(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolReleaseNonce(p0 context.Context, p1 uuid.UUID) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolReplaceBatch(p0 context.Context, p1 address.Address, p2 *MessageSendSpec) ([]ReplaceResult, error) {
+	if s.Internal.MpoolReplaceBatch == nil {
+		return *new([]ReplaceResult), ErrNotSupported
+	}
+	return s.Internal.MpoolReplaceBatch(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolReplaceBatch(p0 context.Context, p1 address.Address, p2 *MessageSendSpec) ([]ReplaceResult, error) {
+	return *new([]ReplaceResult), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolSelect == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2728,6 +3009,28 @@ func (s *FullNodeStub) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 fl
 	return *new([]*types.SignedMessage), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolSendQueue(p0 context.Context, p1 address.Address) ([]*types.SignedMessage, error) {
+	if s.Internal.MpoolSendQueue == nil {
+		return *new([]*types.SignedMessage), ErrNotSupported
+	}
+	return s.Internal.MpoolSendQueue(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolSendQueue(p0 context.Context, p1 address.Address) ([]*types.SignedMessage, error) {
+	return *new([]*types.SignedMessage), ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolSendQueueFlush(p0 context.Context, p1 address.Address, p2 bool) ([]cid.Cid, error) {
+	if s.Internal.MpoolSendQueueFlush == nil {
+		return *new([]cid.Cid), ErrNotSupported
+	}
+	return s.Internal.MpoolSendQueueFlush(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolSendQueueFlush(p0 context.Context, p1 address.Address, p2 bool) ([]cid.Cid, error) {
+	return *new([]cid.Cid), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolSetConfig(p0 context.Context, p1 *types.MpoolConfig) error {
 	if s.Internal.MpoolSetConfig == nil {
 		return ErrNotSupported
@@ -2750,6 +3053,17 @@ func (s *FullNodeStub) MpoolSub(p0 context.Context) (<-chan MpoolUpdate, error)
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolSubFiltered(p0 context.Context, p1 MpoolUpdateFilter) (<-chan MpoolUpdate, error) {
+	if s.Internal.MpoolSubFiltered == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolSubFiltered(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolSubFiltered(p0 context.Context, p1 MpoolUpdateFilter) (<-chan MpoolUpdate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MsigAddApprove(p0 context.Context, p1 address.Address, p2 address.Address, p3 uint64, p4 address.Address, p5 address.Address, p6 bool) (*MessagePrototype, error) {
 	if s.Internal.MsigAddApprove == nil {
 		return nil, ErrNotSupported
@@ -2860,6 +3174,17 @@ func (s *FullNodeStub) MsigGetPending(p0 context.Context, p1 address.Address, p2
 	return *new([]*MsigTransaction), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MsigGetPendingDecoded(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*MsigDecodedTransaction, error) {
+	if s.Internal.MsigGetPendingDecoded == nil {
+		return *new([]*MsigDecodedTransaction), ErrNotSupported
+	}
+	return s.Internal.MsigGetPendingDecoded(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MsigGetPendingDecoded(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*MsigDecodedTransaction, error) {
+	return *new([]*MsigDecodedTransaction), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MsigGetVested(p0 context.Context, p1 address.Address, p2 types.TipSetKey, p3 types.TipSetKey) (types.BigInt, error) {
 	if s.Internal.MsigGetVested == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -2959,6 +3284,17 @@ func (s *FullNodeStub) NetVersion(p0 context.Context) (string, error) {
 	return "", ErrNotSupported
 }
 
+func (s *FullNodeStruct) NodeHealth(p0 context.Context) (NodeHealth, error) {
+	if s.Internal.NodeHealth == nil {
+		return *new(NodeHealth), ErrNotSupported
+	}
+	return s.Internal.NodeHealth(p0)
+}
+
+func (s *FullNodeStub) NodeHealth(p0 context.Context) (NodeHealth, error) {
+	return *new(NodeHealth), ErrNotSupported
+}
+
 func (s *FullNodeStruct) NodeStatus(p0 context.Context, p1 bool) (NodeStatus, error) {
 	if s.Internal.NodeStatus == nil {
 		return *new(NodeStatus), ErrNotSupported
@@ -3212,6 +3548,17 @@ func (s *FullNodeStub) StateActorManifestCID(p0 context.Context, p1 abinetwork.V
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateActorRegistry(p0 context.Context) ([]ActorCodeInfo, error) {
+	if s.Internal.StateActorRegistry == nil {
+		return *new([]ActorCodeInfo), ErrNotSupported
+	}
+	return s.Internal.StateActorRegistry(p0)
+}
+
+func (s *FullNodeStub) StateActorRegistry(p0 context.Context) ([]ActorCodeInfo, error) {
+	return *new([]ActorCodeInfo), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateAllMinerFaults(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) {
 	if s.Internal.StateAllMinerFaults == nil {
 		return *new([]*Fault), ErrNotSupported
@@ -3234,6 +3581,17 @@ func (s *FullNodeStub) StateCall(p0 context.Context, p1 *types.Message, p2 types
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateCallBundle(p0 context.Context, p1 []*types.Message, p2 types.TipSetKey) (*CallBundleResult, error) {
+	if s.Internal.StateCallBundle == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateCallBundle(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateCallBundle(p0 context.Context, p1 []*types.Message, p2 types.TipSetKey) (*CallBundleResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateChangedActors(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (map[string]types.Actor, error) {
 	if s.Internal.StateChangedActors == nil {
 		return *new(map[string]types.Actor), ErrNotSupported
@@ -3300,6 +3658,17 @@ func (s *FullNodeStub) StateDecodeParams(p0 context.Context, p1 address.Address,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateDiff(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 bool) ([]ActorDiff, error) {
+	if s.Internal.StateDiff == nil {
+		return *new([]ActorDiff), ErrNotSupported
+	}
+	return s.Internal.StateDiff(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateDiff(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 bool) ([]ActorDiff, error) {
+	return *new([]ActorDiff), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateEncodeParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) {
 	if s.Internal.StateEncodeParams == nil {
 		return *new([]byte), ErrNotSupported
@@ -3597,6 +3966,17 @@ func (s *FullNodeStub) StateMinerInitialPledgeCollateral(p0 context.Context, p1
 	return *new(types.BigInt), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateMinerKeyRotation(p0 context.Context, p1 address.Address, p2 address.Address, p3 address.Address, p4 types.TipSetKey) ([]MinerKeyRotationStep, error) {
+	if s.Internal.StateMinerKeyRotation == nil {
+		return *new([]MinerKeyRotationStep), ErrNotSupported
+	}
+	return s.Internal.StateMinerKeyRotation(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) StateMinerKeyRotation(p0 context.Context, p1 address.Address, p2 address.Address, p3 address.Address, p4 types.TipSetKey) ([]MinerKeyRotationStep, error) {
+	return *new([]MinerKeyRotationStep), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateMinerPartitions(p0 context.Context, p1 address.Address, p2 uint64, p3 types.TipSetKey) ([]Partition, error) {
 	if s.Internal.StateMinerPartitions == nil {
 		return *new([]Partition), ErrNotSupported
@@ -3729,6 +4109,17 @@ func (s *FullNodeStub) StateReplay(p0 context.Context, p1 types.TipSetKey, p2 ci
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateReplayWithOptions(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 ReplayOptions) (*InvocResult, error) {
+	if s.Internal.StateReplayWithOptions == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateReplayWithOptions(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateReplayWithOptions(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 ReplayOptions) (*InvocResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateSearchMsg(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) {
 	if s.Internal.StateSearchMsg == nil {
 		return nil, ErrNotSupported
@@ -3784,6 +4175,28 @@ func (s *FullNodeStub) StateSectorPreCommitInfo(p0 context.Context, p1 address.A
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateSubscribeActorChanges(p0 context.Context, p1 []address.Address) (<-chan ActorChange, error) {
+	if s.Internal.StateSubscribeActorChanges == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateSubscribeActorChanges(p0, p1)
+}
+
+func (s *FullNodeStub) StateSubscribeActorChanges(p0 context.Context, p1 []address.Address) (<-chan ActorChange, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateVMCirculatingSupplyAt(p0 context.Context, p1 abi.ChainEpoch) (CirculatingSupply, error) {
+	if s.Internal.StateVMCirculatingSupplyAt == nil {
+		return *new(CirculatingSupply), ErrNotSupported
+	}
+	return s.Internal.StateVMCirculatingSupplyAt(p0, p1)
+}
+
+func (s *FullNodeStub) StateVMCirculatingSupplyAt(p0 context.Context, p1 abi.ChainEpoch) (CirculatingSupply, error) {
+	return *new(CirculatingSupply), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateVMCirculatingSupplyInternal(p0 context.Context, p1 types.TipSetKey) (CirculatingSupply, error) {
 	if s.Internal.StateVMCirculatingSupplyInternal == nil {
 		return *new(CirculatingSupply), ErrNotSupported
@@ -3982,6 +4395,17 @@ func (s *FullNodeStub) WalletExport(p0 context.Context, p1 address.Address) (*ty
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletExportEncrypted(p0 context.Context, p1 address.Address, p2 string) (*types.EncryptedKeyInfo, error) {
+	if s.Internal.WalletExportEncrypted == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletExportEncrypted(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletExportEncrypted(p0 context.Context, p1 address.Address, p2 string) (*types.EncryptedKeyInfo, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletHas(p0 context.Context, p1 address.Address) (bool, error) {
 	if s.Internal.WalletHas == nil {
 		return false, ErrNotSupported
@@ -4004,6 +4428,17 @@ func (s *FullNodeStub) WalletImport(p0 context.Context, p1 *types.KeyInfo) (addr
 	return *new(address.Address), ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletImportEncrypted(p0 context.Context, p1 *types.EncryptedKeyInfo, p2 string) (address.Address, error) {
+	if s.Internal.WalletImportEncrypted == nil {
+		return *new(address.Address), ErrNotSupported
+	}
+	return s.Internal.WalletImportEncrypted(p0, p1, p2)
+}
+
+func (s *FullNodeStub) WalletImportEncrypted(p0 context.Context, p1 *types.EncryptedKeyInfo, p2 string) (address.Address, error) {
+	return *new(address.Address), ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletList(p0 context.Context) ([]address.Address, error) {
 	if s.Internal.WalletList == nil {
 		return *new([]address.Address), ErrNotSupported
@@ -4015,6 +4450,17 @@ func (s *FullNodeStub) WalletList(p0 context.Context) ([]address.Address, error)
 	return *new([]address.Address), ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletListWatched(p0 context.Context) ([]address.Address, error) {
+	if s.Internal.WalletListWatched == nil {
+		return *new([]address.Address), ErrNotSupported
+	}
+	return s.Internal.WalletListWatched(p0)
+}
+
+func (s *FullNodeStub) WalletListWatched(p0 context.Context) ([]address.Address, error) {
+	return *new([]address.Address), ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletNew(p0 context.Context, p1 types.KeyType) (address.Address, error) {
 	if s.Internal.WalletNew == nil {
 		return *new(address.Address), ErrNotSupported
@@ -4059,6 +4505,28 @@ func (s *FullNodeStub) WalletSignMessage(p0 context.Context, p1 address.Address,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletSubscribeBalances(p0 context.Context) (<-chan WalletBalanceChange, error) {
+	if s.Internal.WalletSubscribeBalances == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.WalletSubscribeBalances(p0)
+}
+
+func (s *FullNodeStub) WalletSubscribeBalances(p0 context.Context) (<-chan WalletBalanceChange, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) WalletUnwatch(p0 context.Context, p1 address.Address) error {
+	if s.Internal.WalletUnwatch == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletUnwatch(p0, p1)
+}
+
+func (s *FullNodeStub) WalletUnwatch(p0 context.Context, p1 address.Address) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletValidateAddress(p0 context.Context, p1 string) (address.Address, error) {
 	if s.Internal.WalletValidateAddress == nil {
 		return *new(address.Address), ErrNotSupported
@@ -4081,6 +4549,17 @@ func (s *FullNodeStub) WalletVerify(p0 context.Context, p1 address.Address, p2 [
 	return false, ErrNotSupported
 }
 
+func (s *FullNodeStruct) WalletWatch(p0 context.Context, p1 address.Address) error {
+	if s.Internal.WalletWatch == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WalletWatch(p0, p1)
+}
+
+func (s *FullNodeStub) WalletWatch(p0 context.Context, p1 address.Address) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) Web3ClientVersion(p0 context.Context) (string, error) {
 	if s.Internal.Web3ClientVersion == nil {
 		return "", ErrNotSupported
@@ -5665,6 +6144,28 @@ func (s *StorageMinerStub) IndexerAnnounceDeal(p0 context.Context, p1 cid.Cid) e
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) IndexerEndpointAnnounceDeals(p0 context.Context, p1 []cid.Cid) (int, error) {
+	if s.Internal.IndexerEndpointAnnounceDeals == nil {
+		return 0, ErrNotSupported
+	}
+	return s.Internal.IndexerEndpointAnnounceDeals(p0, p1)
+}
+
+func (s *StorageMinerStub) IndexerEndpointAnnounceDeals(p0 context.Context, p1 []cid.Cid) (int, error) {
+	return 0, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) IndexerEndpointQueue(p0 context.Context) ([]PendingPieceAnnouncement, error) {
+	if s.Internal.IndexerEndpointQueue == nil {
+		return *new([]PendingPieceAnnouncement), ErrNotSupported
+	}
+	return s.Internal.IndexerEndpointQueue(p0)
+}
+
+func (s *StorageMinerStub) IndexerEndpointQueue(p0 context.Context) ([]PendingPieceAnnouncement, error) {
+	return *new([]PendingPieceAnnouncement), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketCancelDataTransfer(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error {
 	if s.Internal.MarketCancelDataTransfer == nil {
 		return ErrNotSupported
@@ -5676,6 +6177,17 @@ func (s *StorageMinerStub) MarketCancelDataTransfer(p0 context.Context, p1 datat
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketClientQuotaUsage(p0 context.Context) ([]ClientQuotaUsage, error) {
+	if s.Internal.MarketClientQuotaUsage == nil {
+		return *new([]ClientQuotaUsage), ErrNotSupported
+	}
+	return s.Internal.MarketClientQuotaUsage(p0)
+}
+
+func (s *StorageMinerStub) MarketClientQuotaUsage(p0 context.Context) ([]ClientQuotaUsage, error) {
+	return *new([]ClientQuotaUsage), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketDataTransferDiagnostics(p0 context.Context, p1 peer.ID) (*TransferDiagnostics, error) {
 	if s.Internal.MarketDataTransferDiagnostics == nil {
 		return nil, ErrNotSupported
@@ -5687,6 +6199,17 @@ func (s *StorageMinerStub) MarketDataTransferDiagnostics(p0 context.Context, p1
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketDataTransferProgress(p0 context.Context) ([]DataTransferProgress, error) {
+	if s.Internal.MarketDataTransferProgress == nil {
+		return *new([]DataTransferProgress), ErrNotSupported
+	}
+	return s.Internal.MarketDataTransferProgress(p0)
+}
+
+func (s *StorageMinerStub) MarketDataTransferProgress(p0 context.Context) ([]DataTransferProgress, error) {
+	return *new([]DataTransferProgress), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketDataTransferUpdates(p0 context.Context) (<-chan DataTransferChannel, error) {
 	if s.Internal.MarketDataTransferUpdates == nil {
 		return nil, ErrNotSupported
@@ -6149,6 +6672,28 @@ func (s *StorageMinerStub) SealingAbort(p0 context.Context, p1 storiface.CallID)
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SealingPauseStates(p0 context.Context, p1 []SectorState) error {
+	if s.Internal.SealingPauseStates == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SealingPauseStates(p0, p1)
+}
+
+func (s *StorageMinerStub) SealingPauseStates(p0 context.Context, p1 []SectorState) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SealingPausedStates(p0 context.Context) ([]SectorState, error) {
+	if s.Internal.SealingPausedStates == nil {
+		return *new([]SectorState), ErrNotSupported
+	}
+	return s.Internal.SealingPausedStates(p0)
+}
+
+func (s *StorageMinerStub) SealingPausedStates(p0 context.Context) ([]SectorState, error) {
+	return *new([]SectorState), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SealingRemoveRequest(p0 context.Context, p1 uuid.UUID) error {
 	if s.Internal.SealingRemoveRequest == nil {
 		return ErrNotSupported
@@ -6160,6 +6705,17 @@ func (s *StorageMinerStub) SealingRemoveRequest(p0 context.Context, p1 uuid.UUID
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SealingResumeStates(p0 context.Context, p1 []SectorState) error {
+	if s.Internal.SealingResumeStates == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SealingResumeStates(p0, p1)
+}
+
+func (s *StorageMinerStub) SealingResumeStates(p0 context.Context, p1 []SectorState) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SealingSchedDiag(p0 context.Context, p1 bool) (interface{}, error) {
 	if s.Internal.SealingSchedDiag == nil {
 		return nil, ErrNotSupported
@@ -6193,6 +6749,17 @@ func (s *StorageMinerStub) SectorAddPieceToAny(p0 context.Context, p1 abi.Unpadd
 	return *new(SectorOffset), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorBatchingStatus(p0 context.Context) (SectorBatchingStatus, error) {
+	if s.Internal.SectorBatchingStatus == nil {
+		return *new(SectorBatchingStatus), ErrNotSupported
+	}
+	return s.Internal.SectorBatchingStatus(p0)
+}
+
+func (s *StorageMinerStub) SectorBatchingStatus(p0 context.Context) (SectorBatchingStatus, error) {
+	return *new(SectorBatchingStatus), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorCommitFlush(p0 context.Context) ([]sealiface.CommitBatchRes, error) {
 	if s.Internal.SectorCommitFlush == nil {
 		return *new([]sealiface.CommitBatchRes), ErrNotSupported
@@ -6446,6 +7013,17 @@ func (s *StorageMinerStub) SectorsListInStates(p0 context.Context, p1 []SectorSt
 	return *new([]abi.SectorNumber), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsPipelineStats(p0 context.Context) (SectorsPipelineStats, error) {
+	if s.Internal.SectorsPipelineStats == nil {
+		return *new(SectorsPipelineStats), ErrNotSupported
+	}
+	return s.Internal.SectorsPipelineStats(p0)
+}
+
+func (s *StorageMinerStub) SectorsPipelineStats(p0 context.Context) (SectorsPipelineStats, error) {
+	return *new(SectorsPipelineStats), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsRefs(p0 context.Context) (map[string][]SealedRef, error) {
 	if s.Internal.SectorsRefs == nil {
 		return *new(map[string][]SealedRef), ErrNotSupported
@@ -6457,6 +7035,17 @@ func (s *StorageMinerStub) SectorsRefs(p0 context.Context) (map[string][]SealedR
 	return *new(map[string][]SealedRef), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsReleaseUnsealed(p0 context.Context, p1 storiface.SectorRef) error {
+	if s.Internal.SectorsReleaseUnsealed == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SectorsReleaseUnsealed(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorsReleaseUnsealed(p0 context.Context, p1 storiface.SectorRef) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsStatus(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) {
 	if s.Internal.SectorsStatus == nil {
 		return *new(SectorInfo), ErrNotSupported
@@ -6611,6 +7200,17 @@ func (s *StorageMinerStub) StorageGetLocks(p0 context.Context) (storiface.Sector
 	return *new(storiface.SectorLocks), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StorageHealth(p0 context.Context) (map[storiface.ID]storiface.PathHealth, error) {
+	if s.Internal.StorageHealth == nil {
+		return *new(map[storiface.ID]storiface.PathHealth), ErrNotSupported
+	}
+	return s.Internal.StorageHealth(p0)
+}
+
+func (s *StorageMinerStub) StorageHealth(p0 context.Context) (map[storiface.ID]storiface.PathHealth, error) {
+	return *new(map[storiface.ID]storiface.PathHealth), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) StorageInfo(p0 context.Context, p1 storiface.ID) (storiface.StorageInfo, error) {
 	if s.Internal.StorageInfo == nil {
 		return *new(storiface.StorageInfo), ErrNotSupported
@@ -6721,6 +7321,17 @@ func (s *StorageMinerStub) WorkerJobs(p0 context.Context) (map[uuid.UUID][]stori
 	return *new(map[uuid.UUID][]storiface.WorkerJob), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) WorkerResourceTable(p0 context.Context, p1 uuid.UUID) (map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) {
+	if s.Internal.WorkerResourceTable == nil {
+		return *new(map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources), ErrNotSupported
+	}
+	return s.Internal.WorkerResourceTable(p0, p1)
+}
+
+func (s *StorageMinerStub) WorkerResourceTable(p0 context.Context, p1 uuid.UUID) (map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) {
+	return *new(map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) WorkerStats(p0 context.Context) (map[uuid.UUID]storiface.WorkerStats, error) {
 	if s.Internal.WorkerStats == nil {
 		return *new(map[uuid.UUID]storiface.WorkerStats), ErrNotSupported
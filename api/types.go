@@ -20,6 +20,7 @@ import (
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/crypto"
 
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -66,6 +67,45 @@ type MpoolMessageWhole struct {
 	Spec *MessageSendSpec
 }
 
+// OfflineMessage is an unsigned message prepared for signing on a machine
+// without access to a node. Once the Signature is set, it can be published
+// with MpoolPushOffline.
+type OfflineMessage struct {
+	// Message with the nonce and gas parameters set
+	Message *types.Message
+	// SigningKey is the key address of the sender
+	SigningKey address.Address
+
+	// Chain context the message was prepared at
+	NetworkName dtypes.NetworkName
+	Height      abi.ChainEpoch
+	TipSet      types.TipSetKey
+	// Balance of the sender at TipSet
+	Balance types.BigInt
+	// Lease reserves the nonce of the message, which has to be pushed before
+	// the lease expires; it's nil if the nonce was set by the caller
+	Lease *NonceLease `json:",omitempty"`
+
+	Signature *crypto.Signature `json:",omitempty"`
+}
+
+// NonceLease is a nonce reserved for sending a message from Address. The
+// message has to be pushed before the lease expires.
+type NonceLease struct {
+	ID      uuid.UUID
+	Address address.Address
+	Nonce   uint64
+	Expires time.Time
+}
+
+// ReplaceResult is the outcome of replacing a pending message. Replacement is
+// nil, and Error is set, if the message couldn't be replaced.
+type ReplaceResult struct {
+	Nonce       uint64
+	Replacement *types.SignedMessage
+	Error       string
+}
+
 // GraphSyncDataTransfer provides diagnostics on a data transfer happening over graphsync
 type GraphSyncDataTransfer struct {
 	// GraphSync request id for this transfer
@@ -90,6 +130,35 @@ type TransferDiagnostics struct {
 	SendingTransfers   []*GraphSyncDataTransfer
 }
 
+// DataTransferProgress describes the progress of an ongoing data transfer.
+// Rates and restarts are tracked in memory, since the node started.
+type DataTransferProgress struct {
+	TransferID  datatransfer.TransferID
+	Status      datatransfer.Status
+	IsInitiator bool
+	IsSender    bool
+	OtherPeer   peer.ID
+	Message     string
+
+	// ProposalCid is the proposal of the storage deal the transfer is for, if
+	// any
+	ProposalCid *cid.Cid
+
+	// Transferred is the number of bytes sent or received so far, and
+	// TotalSize the size of the transfer if known
+	Transferred uint64
+	TotalSize   uint64
+	// Rate is the recent transfer rate in bytes per second
+	Rate     uint64
+	Restarts uint64
+
+	// Started is when the node first saw the transfer, and LastProgress when
+	// data was last transferred; both are zero for transfers without events
+	// since the node started
+	Started      time.Time
+	LastProgress time.Time
+}
+
 type DataTransferChannel struct {
 	TransferID  datatransfer.TransferID
 	Status      datatransfer.Status
@@ -189,6 +258,33 @@ type NodeChainStatus struct {
 	BlocksPerTipsetLastFinality float64
 }
 
+type NodeHealth struct {
+	// Healthy is true when no component of the report is in a degraded state
+	Healthy bool
+
+	SyncStatus NodeSyncStatus
+	// Blockstore contains the blockstore info (e.g. splitstore compaction state),
+	// if the blockstore exposes it
+	Blockstore map[string]interface{} `json:",omitempty"`
+	Storage    []NodeStorageHealth
+
+	// Errors lists the problems detected while building the report
+	Errors []string
+}
+
+type NodeStorageHealth struct {
+	// Name is the role of the path, e.g. "repo", "datastore" or "splitstore"
+	Name string
+	// Path is omitted from the unauthenticated /healthz report
+	Path string `json:",omitempty"`
+
+	Capacity  int64
+	Available int64
+	LowSpace  bool
+
+	Error string `json:",omitempty"`
+}
+
 type CheckStatusCode int
 
 //go:generate go run golang.org/x/tools/cmd/stringer -type=CheckStatusCode -trimprefix=CheckStatus
@@ -247,6 +343,57 @@ type RetrievalInfo struct {
 	Event *retrievalmarket.ClientEvent
 }
 
+// ResumableRetrieval is the persisted progress of a retrieval deal which didn't complete yet
+type ResumableRetrieval struct {
+	ID         retrievalmarket.DealID
+	PayloadCID cid.Cid
+	PieceCID   *cid.Cid
+	Provider   peer.ID
+
+	Status         retrievalmarket.DealStatus
+	Message        string
+	BytesReceived  uint64
+	BlocksReceived int64 // blocks received and verified by the data transfer
+
+	TransferChannelID *datatransfer.ChannelID
+
+	LastProgress time.Time
+	// Interrupted is set when the node restarted since the last progress of the
+	// retrieval, or when the retrieval stalled
+	Interrupted bool
+}
+
+// RetrievalAttempt is the record of a retrieval deal made by the client
+type RetrievalAttempt struct {
+	ID         retrievalmarket.DealID
+	Miner      address.Address // undefined when the deal wasn't made through the API
+	Provider   peer.ID
+	PayloadCID cid.Cid
+
+	Status        retrievalmarket.DealStatus
+	Message       string
+	BytesReceived uint64
+	Paid          abi.TokenAmount
+
+	Started time.Time // zero when not recorded
+	Ended   time.Time // zero while the deal is in progress
+}
+
+// RetrievalProviderStats summarizes the completed retrieval deals made with a provider
+type RetrievalProviderStats struct {
+	Miner    address.Address // undefined when the deals weren't made through the API
+	Provider peer.ID
+
+	Attempts      int
+	Successes     int
+	BytesReceived uint64
+	Throughput    float64 // average throughput of the successful deals, in bytes per second
+	Paid          abi.TokenAmount
+
+	LastAttempt time.Time
+	LastError   string
+}
+
 type RestrievalRes struct {
 	DealID retrievalmarket.DealID
 }
@@ -867,6 +867,20 @@ func (mr *MockFullNodeMockRecorder) Closing(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Closing", reflect.TypeOf((*MockFullNode)(nil).Closing), arg0)
 }
 
+// ConfigReload mocks base method.
+func (m *MockFullNode) ConfigReload(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigReload", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfigReload indicates an expected call of ConfigReload.
+func (mr *MockFullNodeMockRecorder) ConfigReload(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigReload", reflect.TypeOf((*MockFullNode)(nil).ConfigReload), arg0)
+}
+
 // CreateBackup mocks base method.
 func (m *MockFullNode) CreateBackup(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
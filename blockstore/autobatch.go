@@ -225,6 +225,14 @@ func (bs *AutobatchBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error)
 	return false, err
 }
 
+func (bs *AutobatchBlockstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	return HasEach(ctx, bs, cids)
+}
+
+func (bs *AutobatchBlockstore) GetMany(ctx context.Context, cids []cid.Cid) ([]block.Block, error) {
+	return GetEach(ctx, bs, cids)
+}
+
 func (bs *AutobatchBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
 	blk, err := bs.Get(ctx, c)
 	if err != nil {
@@ -1,6 +1,7 @@
 package badgerbs
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -56,6 +57,10 @@ type Options struct {
 
 	// Prefix is an optional prefix to prepend to keys. Default: "".
 	Prefix string
+
+	// MetricsName, when set, enables periodic reporting of badger internal
+	// statistics, tagged with this name. Default: "" (disabled).
+	MetricsName string
 }
 
 func DefaultOptions(path string) Options {
@@ -128,6 +133,9 @@ var _ blockstore.Viewer = (*Blockstore)(nil)
 var _ blockstore.BlockstoreIterator = (*Blockstore)(nil)
 var _ blockstore.BlockstoreGC = (*Blockstore)(nil)
 var _ blockstore.BlockstoreSize = (*Blockstore)(nil)
+var _ blockstore.BlockstoreEstimator = (*Blockstore)(nil)
+var _ blockstore.BlockstoreKeyOrder = (*Blockstore)(nil)
+var _ blockstore.BlockstoreDedup = (*Blockstore)(nil)
 var _ io.Closer = (*Blockstore)(nil)
 
 // Open creates a new badger-backed blockstore, with the supplied options.
@@ -151,6 +159,10 @@ func Open(opts Options) (*Blockstore, error) {
 
 	bs.moveCond.L = &bs.moveMx
 
+	if opts.MetricsName != "" {
+		go bs.reportMetrics(opts.MetricsName)
+	}
+
 	return bs, nil
 }
 
@@ -539,6 +551,121 @@ func (b *Blockstore) GCOnce(ctx context.Context, opts ...blockstore.BlockstoreGC
 	return err
 }
 
+// Dedup implements BlockstoreDedup. An object written again, e.g. by successive compactions
+// moving it to the coldstore, is stored as a new version of its key with a new copy of its value
+// in the value log; the stale copies are only dropped as badger compacts the LSM tree and
+// garbage collects the value log, which may never happen in a store which is rarely written to.
+// Dedup counts the stale copies, and then rewrites the blockstore with moving GC, which only
+// copies the latest version of each object.
+func (b *Blockstore) Dedup(ctx context.Context) (blockstore.DedupStats, error) {
+	var stats blockstore.DedupStats
+
+	if err := b.access(); err != nil {
+		return stats, err
+	}
+	defer b.viewers.Done()
+
+	size, err := b.Size()
+	if err != nil {
+		return stats, xerrors.Errorf("error measuring blockstore: %w", err)
+	}
+	stats.SizeBefore = size
+
+	if err := b.scanDuplicates(ctx, &stats); err != nil {
+		return stats, xerrors.Errorf("error scanning for duplicates: %w", err)
+	}
+
+	log.Infow("rewriting blockstore", "objects", stats.Objects, "duplicates", stats.Duplicates, "duplicate size", stats.DuplicateSize)
+	if err := b.movingGC(); err != nil {
+		return stats, xerrors.Errorf("error rewriting blockstore: %w", err)
+	}
+
+	size, err = b.Size()
+	if err != nil {
+		return stats, xerrors.Errorf("error measuring blockstore: %w", err)
+	}
+	stats.SizeAfter = size
+
+	return stats, nil
+}
+
+func (b *Blockstore) scanDuplicates(ctx context.Context, stats *blockstore.DedupStats) error {
+	b.lockDB()
+	defer b.unlockDB()
+
+	txn := b.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.IteratorOptions{AllVersions: true}
+	if b.prefixing {
+		opts.Prefix = b.prefix
+	}
+
+	iter := txn.NewIterator(opts)
+	defer iter.Close()
+
+	// versions are iterated latest first; the older versions of a live object are duplicates,
+	// while the older versions of a deleted object are just garbage
+	var key []byte
+	var live bool
+	for iter.Rewind(); iter.Valid(); iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !b.isOpen() {
+			return ErrBlockstoreClosed
+		}
+
+		item := iter.Item()
+		if !bytes.Equal(key, item.Key()) {
+			key = item.KeyCopy(key[:0])
+			live = !item.IsDeletedOrExpired()
+			if live {
+				stats.Objects++
+			}
+			continue
+		}
+
+		if live && !item.IsDeletedOrExpired() {
+			stats.Duplicates++
+			stats.DuplicateSize += item.ValueSize()
+		}
+	}
+
+	return nil
+}
+
+// EstimateKeys implements BlockstoreEstimator. The objects are counted from the keys of the
+// LSM tables, without reading any values; objects still in memtables are not counted, while
+// deleted objects are counted until their tables are compacted, and so are the objects of other
+// prefixes sharing the database. The size is the on-disk size.
+func (b *Blockstore) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	if err := b.access(); err != nil {
+		return 0, 0, err
+	}
+
+	b.lockDB()
+	var count int64
+	for _, t := range b.db.Tables(true) {
+		count += int64(t.KeyCount)
+	}
+	b.unlockDB()
+	b.viewers.Done()
+
+	size, err := b.Size()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return count, size, nil
+}
+
+// KeysOrdered implements BlockstoreKeyOrder; badger iterates its keys in key order, as they are
+// the base32 encoded multihashes.
+func (b *Blockstore) KeysOrdered() bool {
+	return true
+}
+
 // Size returns the aggregate size of the blockstore
 func (b *Blockstore) Size() (int64, error) {
 	if err := b.access(); err != nil {
@@ -680,6 +807,88 @@ func (b *Blockstore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error)
 	return blocks.NewBlockWithCid(val, cid)
 }
 
+// HasMany implements Blockstore.HasMany, checking all the blocks in a single transaction.
+func (b *Blockstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	if err := b.access(); err != nil {
+		return nil, err
+	}
+	defer b.viewers.Done()
+
+	b.lockDB()
+	defer b.unlockDB()
+
+	res := make([]bool, len(cids))
+	err := b.db.View(func(txn *badger.Txn) error {
+		for i, c := range cids {
+			k, pooled := b.PooledStorageKey(c)
+			_, err := txn.Get(k)
+			if pooled {
+				KeyPool.Put(k)
+			}
+
+			switch err {
+			case nil:
+				res[i] = true
+			case badger.ErrKeyNotFound:
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if blocks exist in badger blockstore: %w", err)
+	}
+	return res, nil
+}
+
+// GetMany implements Blockstore.GetMany, reading all the blocks in a single transaction.
+func (b *Blockstore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	if err := b.access(); err != nil {
+		return nil, err
+	}
+	defer b.viewers.Done()
+
+	b.lockDB()
+	defer b.unlockDB()
+
+	res := make([]blocks.Block, len(cids))
+	err := b.db.View(func(txn *badger.Txn) error {
+		for i, c := range cids {
+			if !c.Defined() {
+				continue
+			}
+
+			k, pooled := b.PooledStorageKey(c)
+			item, err := txn.Get(k)
+			if pooled {
+				KeyPool.Put(k)
+			}
+
+			switch err {
+			case nil:
+			case badger.ErrKeyNotFound:
+				continue
+			default:
+				return fmt.Errorf("failed to get block from badger blockstore: %w", err)
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return fmt.Errorf("failed to get block from badger blockstore: %w", err)
+			}
+			if res[i], err = blocks.NewBlockWithCid(val, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 // GetSize implements Blockstore.GetSize.
 func (b *Blockstore) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
 	if err := b.access(); err != nil {
@@ -13,6 +13,8 @@ import (
 	"github.com/ipfs/go-cid"
 	blocks "github.com/ipfs/go-libipfs/blocks"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/filecoin-project/lotus/blockstore"
@@ -270,3 +272,70 @@ func TestMoveWithPrefix(t *testing.T) {
 		return opts
 	})
 }
+
+func TestDedup(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := Open(DefaultOptions(filepath.Join(t.TempDir(), "db")))
+	require.NoError(t, err)
+	defer db.Close() //nolint
+
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("some data %d", i))))
+	}
+
+	// every object is written three times, as by repeated moves to the coldstore
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.PutMany(ctx, blks))
+	}
+
+	// the copies of deleted objects are not duplicates
+	require.NoError(t, db.DeleteMany(ctx, []cid.Cid{blks[8].Cid(), blks[9].Cid()}))
+
+	stats, err := db.Dedup(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 8, stats.Objects)
+	require.EqualValues(t, 16, stats.Duplicates)
+	require.EqualValues(t, 16*len(blks[0].RawData()), stats.DuplicateSize)
+
+	for i, blk := range blks {
+		has, err := db.Has(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, i < 8, has)
+	}
+
+	// the rewritten blockstore has no duplicates left
+	stats, err = db.Dedup(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 8, stats.Objects)
+	require.EqualValues(t, 0, stats.Duplicates)
+}
+
+func TestBadgerMetrics(t *testing.T) {
+	require.NoError(t, view.Register(DefaultViews...))
+	defer view.Unregister(DefaultViews...)
+
+	bs, _ := newBlockstore(DefaultOptions)(t)
+	bbs := bs.(*Blockstore)
+	defer bbs.Close() //nolint:errcheck
+
+	require.NoError(t, bbs.Put(context.Background(), blocks.NewBlock([]byte("some data"))))
+
+	ctx, err := tag.New(context.Background(), tag.Upsert(StoreName, "test"))
+	require.NoError(t, err)
+	require.NoError(t, bbs.recordMetrics(ctx))
+
+	rows, err := view.RetrieveData(Views.LevelTables.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, bbs.opts.MaxLevels)
+
+	rows, err = view.RetrieveData(Views.CompactionBacklog.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, []tag.Tag{{Key: StoreName, Value: "test"}}, rows[0].Tags)
+
+	// closed stores stop reporting
+	require.NoError(t, bbs.Close())
+	require.ErrorIs(t, bbs.recordMetrics(ctx), ErrBlockstoreClosed)
+}
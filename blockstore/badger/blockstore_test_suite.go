@@ -297,6 +297,36 @@ func (s *Suite) TestPutMany(t *testing.T) {
 	require.Len(t, cids, 3)
 }
 
+func (s *Suite) TestHasManyGetMany(t *testing.T) {
+	ctx := context.Background()
+	bs, _ := s.NewBlockstore(t)
+	if c, ok := bs.(io.Closer); ok {
+		defer func() { require.NoError(t, c.Close()) }()
+	}
+
+	blks := []blocks.Block{
+		blocks.NewBlock([]byte("foo1")),
+		blocks.NewBlock([]byte("foo2")),
+		blocks.NewBlock([]byte("foo3")),
+	}
+	err := bs.PutMany(ctx, blks[:2])
+	require.NoError(t, err)
+
+	cids := []cid.Cid{blks[0].Cid(), blks[2].Cid(), blks[1].Cid()}
+
+	has, err := blockstore.HasMany(ctx, bs, cids)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, true}, has)
+
+	fetched, err := blockstore.GetMany(ctx, bs, append(cids, cid.Undef))
+	require.NoError(t, err)
+	require.Len(t, fetched, 4)
+	require.Equal(t, blks[0].RawData(), fetched[0].RawData())
+	require.Nil(t, fetched[1])
+	require.Equal(t, blks[1].RawData(), fetched[2].RawData())
+	require.Nil(t, fetched[3])
+}
+
 func (s *Suite) TestDelete(t *testing.T) {
 	//stm: @SPLITSTORE_BADGER_PUT_001, @SPLITSTORE_BADGER_POOLED_STORAGE_KEY_001
 	//stm: @SPLITSTORE_BADGER_DELETE_001, @SPLITSTORE_BADGER_POOLED_STORAGE_HAS_001
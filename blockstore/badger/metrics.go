@@ -0,0 +1,160 @@
+package badgerbs
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// MetricsEmitInterval is the interval at which badger internal statistics are
+// emitted onto OpenCensus.
+var MetricsEmitInterval = 10 * time.Second
+
+var (
+	// StoreName is the role of the store the statistics belong to (e.g. "hot", "universal").
+	StoreName, _ = tag.NewKey("store")
+	// Level is the LSM level the statistics belong to.
+	Level, _ = tag.NewKey("level")
+)
+
+// Measures groups all metrics emitted by badger blockstores.
+var Measures = struct {
+	LSMSize            *stats.Int64Measure
+	ValueLogSize       *stats.Int64Measure
+	LevelTables        *stats.Int64Measure
+	LevelSize          *stats.Int64Measure
+	CompactionBacklog  *stats.Int64Measure
+	BlockCacheHitRatio *stats.Float64Measure
+	IndexCacheHitRatio *stats.Float64Measure
+}{
+	LSMSize:            stats.Int64("blockstore/badger/lsm_size", "Size of the badger LSM tree", stats.UnitBytes),
+	ValueLogSize:       stats.Int64("blockstore/badger/vlog_size", "Size of the badger value log", stats.UnitBytes),
+	LevelTables:        stats.Int64("blockstore/badger/level_tables", "Number of tables in each LSM level", stats.UnitDimensionless),
+	LevelSize:          stats.Int64("blockstore/badger/level_size", "Estimated size of each LSM level", stats.UnitBytes),
+	CompactionBacklog:  stats.Int64("blockstore/badger/compaction_backlog", "Number of level 0 tables waiting to be compacted", stats.UnitDimensionless),
+	BlockCacheHitRatio: stats.Float64("blockstore/badger/block_cache_hit_ratio", "Hit ratio of the badger block cache", stats.UnitDimensionless),
+	IndexCacheHitRatio: stats.Float64("blockstore/badger/index_cache_hit_ratio", "Hit ratio of the badger index cache", stats.UnitDimensionless),
+}
+
+// Views groups all badger-related default views.
+var Views = struct {
+	LSMSize            *view.View
+	ValueLogSize       *view.View
+	LevelTables        *view.View
+	LevelSize          *view.View
+	CompactionBacklog  *view.View
+	BlockCacheHitRatio *view.View
+	IndexCacheHitRatio *view.View
+}{
+	LSMSize: &view.View{
+		Measure:     Measures.LSMSize,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName},
+	},
+	ValueLogSize: &view.View{
+		Measure:     Measures.ValueLogSize,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName},
+	},
+	LevelTables: &view.View{
+		Measure:     Measures.LevelTables,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName, Level},
+	},
+	LevelSize: &view.View{
+		Measure:     Measures.LevelSize,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName, Level},
+	},
+	CompactionBacklog: &view.View{
+		Measure:     Measures.CompactionBacklog,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName},
+	},
+	BlockCacheHitRatio: &view.View{
+		Measure:     Measures.BlockCacheHitRatio,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName},
+	},
+	IndexCacheHitRatio: &view.View{
+		Measure:     Measures.IndexCacheHitRatio,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StoreName},
+	},
+}
+
+// DefaultViews exports all default views for this package.
+var DefaultViews = []*view.View{
+	Views.LSMSize,
+	Views.ValueLogSize,
+	Views.LevelTables,
+	Views.LevelSize,
+	Views.CompactionBacklog,
+	Views.BlockCacheHitRatio,
+	Views.IndexCacheHitRatio,
+}
+
+// reportMetrics periodically emits the internal badger statistics until the
+// blockstore is closed.
+func (b *Blockstore) reportMetrics(name string) {
+	ctx, _ := tag.New(context.Background(), tag.Upsert(StoreName, name))
+
+	ticker := time.NewTicker(MetricsEmitInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !b.isOpen() {
+			return
+		}
+
+		if err := b.recordMetrics(ctx); err != nil {
+			log.Debugf("error recording badger metrics for %s blockstore: %s", name, err)
+		}
+	}
+}
+
+func (b *Blockstore) recordMetrics(ctx context.Context) error {
+	if err := b.access(); err != nil {
+		return err
+	}
+	defer b.viewers.Done()
+
+	b.lockDB()
+	defer b.unlockDB()
+
+	lsm, vlog := b.db.Size()
+	stats.Record(ctx, Measures.LSMSize.M(lsm), Measures.ValueLogSize.M(vlog))
+
+	tables := make(map[int]int64)
+	sizes := make(map[int]int64)
+	for _, t := range b.db.Tables(false) {
+		tables[t.Level]++
+		sizes[t.Level] += int64(t.EstimatedSz)
+	}
+
+	for level := 0; level < b.opts.MaxLevels; level++ {
+		lctx, err := tag.New(ctx, tag.Upsert(Level, strconv.Itoa(level)))
+		if err != nil {
+			return err
+		}
+		stats.Record(lctx, Measures.LevelTables.M(tables[level]), Measures.LevelSize.M(sizes[level]))
+	}
+
+	// level 0 tables are flushed memtables that have yet to be compacted into the
+	// rest of the tree; writes stall when there are too many of them.
+	stats.Record(ctx, Measures.CompactionBacklog.M(tables[0]))
+
+	// caches are optional; badger returns nil metrics when they are disabled
+	if cm := b.db.BlockCacheMetrics(); cm != nil {
+		stats.Record(ctx, Measures.BlockCacheHitRatio.M(cm.Ratio()))
+	}
+	if cm := b.db.IndexCacheMetrics(); cm != nil {
+		stats.Record(ctx, Measures.IndexCacheHitRatio.M(cm.Ratio()))
+	}
+
+	return nil
+}
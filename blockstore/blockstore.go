@@ -2,11 +2,16 @@ package blockstore
 
 import (
 	"context"
+	"sort"
+	"strings"
 
 	"github.com/ipfs/go-cid"
 	ds "github.com/ipfs/go-datastore"
 	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-base32"
 )
 
 var log = logging.Logger("blockstore")
@@ -18,6 +23,7 @@ type Blockstore interface {
 	blockstore.Blockstore
 	blockstore.Viewer
 	BatchDeleter
+	BatchReader
 	Flusher
 }
 
@@ -34,6 +40,16 @@ type BatchDeleter interface {
 	DeleteMany(ctx context.Context, cids []cid.Cid) error
 }
 
+// BatchReader is a trait for checking for and reading many blocks at once, with less
+// overhead than one call per block.
+type BatchReader interface {
+	// HasMany returns whether each of the cids is in the blockstore, in order.
+	HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error)
+	// GetMany returns the blocks of the cids, in order; the blocks missing from the
+	// blockstore are nil.
+	GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error)
+}
+
 // BlockstoreIterator is a trait for efficient iteration
 type BlockstoreIterator interface {
 	ForEachKey(func(cid.Cid) error) error
@@ -78,6 +94,69 @@ type BlockstoreSize interface {
 	Size() (int64, error)
 }
 
+// BlockstoreEstimator is a trait for blockstores that can estimate the number of their objects
+// and their total size more cheaply than by iterating over their keys.
+type BlockstoreEstimator interface {
+	EstimateKeys(ctx context.Context) (count int64, size int64, err error)
+}
+
+// BlockstoreKeyOrder is a trait for blockstores whose AllKeysChan can emit the keys in key order
+// (see CompareKeys).
+type BlockstoreKeyOrder interface {
+	// KeysOrdered returns true if AllKeysChan emits the keys in key order.
+	KeysOrdered() bool
+}
+
+// CompareKeys compares two keys in key order: by the base32 encoding of their multihash, which
+// is the order the badger blockstore iterates its keys in.
+func CompareKeys(a, b cid.Cid) int {
+	return strings.Compare(orderKey(a), orderKey(b))
+}
+
+// SortKeys sorts keys in key order.
+func SortKeys(keys []cid.Cid) {
+	okeys := make([]string, len(keys))
+	for i, c := range keys {
+		okeys[i] = orderKey(c)
+	}
+	sort.Sort(keysByOrder{keys: keys, okeys: okeys})
+}
+
+func orderKey(c cid.Cid) string {
+	return base32.RawStdEncoding.EncodeToString(c.Hash())
+}
+
+type keysByOrder struct {
+	keys  []cid.Cid
+	okeys []string
+}
+
+func (k keysByOrder) Len() int           { return len(k.keys) }
+func (k keysByOrder) Less(i, j int) bool { return k.okeys[i] < k.okeys[j] }
+func (k keysByOrder) Swap(i, j int) {
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+	k.okeys[i], k.okeys[j] = k.okeys[j], k.okeys[i]
+}
+
+// BlockstoreDedup is a trait for blockstores which may keep redundant copies of objects written
+// more than once, and can rewrite themselves compactly without them.
+type BlockstoreDedup interface {
+	Dedup(ctx context.Context) (DedupStats, error)
+}
+
+// DedupStats reports the outcome of a deduplication pass.
+type DedupStats struct {
+	// Objects is the number of distinct objects in the blockstore.
+	Objects int64
+	// Duplicates is the number of redundant copies of objects dropped by the pass, and
+	// DuplicateSize their size.
+	Duplicates    int64
+	DuplicateSize int64
+	// SizeBefore and SizeAfter are the on-disk size of the blockstore before and after the pass.
+	SizeBefore int64
+	SizeAfter  int64
+}
+
 // WrapIDStore wraps the underlying blockstore in an "identity" blockstore.
 // The ID store filters out all puts for blocks with CIDs using the "identity"
 // hash function. It also extracts inlined blocks from CIDs using the identity
@@ -137,6 +216,123 @@ func (a *adaptedBlockstore) DeleteMany(ctx context.Context, cids []cid.Cid) erro
 	return nil
 }
 
+func (a *adaptedBlockstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	return HasMany(ctx, a.Blockstore, cids)
+}
+
+func (a *adaptedBlockstore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	return GetMany(ctx, a.Blockstore, cids)
+}
+
+// HasMany checks whether each of the cids is in the blockstore, with a single call when
+// the blockstore is a BatchReader.
+func HasMany(ctx context.Context, bs blockstore.Blockstore, cids []cid.Cid) ([]bool, error) {
+	if br, ok := bs.(BatchReader); ok {
+		return br.HasMany(ctx, cids)
+	}
+	return HasEach(ctx, bs, cids)
+}
+
+// GetMany reads the blocks of the cids, with a single call when the blockstore is a
+// BatchReader; the blocks missing from the blockstore are nil.
+func GetMany(ctx context.Context, bs blockstore.Blockstore, cids []cid.Cid) ([]blocks.Block, error) {
+	if br, ok := bs.(BatchReader); ok {
+		return br.GetMany(ctx, cids)
+	}
+	return GetEach(ctx, bs, cids)
+}
+
+// HasEach checks for the cids with one Has call per block; it implements HasMany for
+// blockstores with no more efficient way.
+func HasEach(ctx context.Context, bs blockstore.Blockstore, cids []cid.Cid) ([]bool, error) {
+	res := make([]bool, len(cids))
+	for i, c := range cids {
+		has, err := bs.Has(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = has
+	}
+	return res, nil
+}
+
+// GetEach reads the cids with one Get call per block; it implements GetMany for
+// blockstores with no more efficient way.
+func GetEach(ctx context.Context, bs blockstore.Blockstore, cids []cid.Cid) ([]blocks.Block, error) {
+	res := make([]blocks.Block, len(cids))
+	for i, c := range cids {
+		blk, err := bs.Get(ctx, c)
+		switch {
+		case err == nil:
+			res[i] = blk
+		case ipld.IsNotFound(err):
+		default:
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// MissingHas returns the cids not found so far by a HasMany query, with their indices
+// in cids, so that the query can be completed from another blockstore.
+func MissingHas(cids []cid.Cid, res []bool) (idx []int, missing []cid.Cid) {
+	for i, has := range res {
+		if !has {
+			idx = append(idx, i)
+			missing = append(missing, cids[i])
+		}
+	}
+	return idx, missing
+}
+
+// MissingGet returns the cids not found so far by a GetMany query, with their indices
+// in cids, so that the query can be completed from another blockstore.
+func MissingGet(cids []cid.Cid, res []blocks.Block) (idx []int, missing []cid.Cid) {
+	for i, blk := range res {
+		if blk == nil {
+			idx = append(idx, i)
+			missing = append(missing, cids[i])
+		}
+	}
+	return idx, missing
+}
+
+// hasManyFrom completes the results of a HasMany call with the blocks found in bs among
+// the blocks not found so far.
+func hasManyFrom(ctx context.Context, bs Blockstore, cids []cid.Cid, res []bool) ([]bool, error) {
+	idx, missing := MissingHas(cids, res)
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	found, err := bs.HasMany(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		res[i] = found[j]
+	}
+	return res, nil
+}
+
+// getManyFrom completes the results of a GetMany call with the blocks read from bs among
+// the blocks not found so far.
+func getManyFrom(ctx context.Context, bs Blockstore, cids []cid.Cid, res []blocks.Block) ([]blocks.Block, error) {
+	idx, missing := MissingGet(cids, res)
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	found, err := bs.GetMany(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		res[i] = found[j]
+	}
+	return res, nil
+}
+
 // Adapt adapts a standard blockstore to a Lotus blockstore by
 // enriching it with the extra methods that Lotus requires (e.g. View, Sync).
 //
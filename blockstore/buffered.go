@@ -163,6 +163,24 @@ func (bs *BufferedBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error)
 	return bs.read.Has(ctx, c)
 }
 
+func (bs *BufferedBlockstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res, err := bs.write.HasMany(ctx, cids)
+	if err != nil {
+		return nil, err
+	}
+
+	return hasManyFrom(ctx, bs.read, cids, res)
+}
+
+func (bs *BufferedBlockstore) GetMany(ctx context.Context, cids []cid.Cid) ([]block.Block, error) {
+	res, err := bs.write.GetMany(ctx, cids)
+	if err != nil {
+		return nil, err
+	}
+
+	return getManyFrom(ctx, bs.read, cids, res)
+}
+
 func (bs *BufferedBlockstore) HashOnRead(hor bool) {
 	bs.read.HashOnRead(hor)
 	bs.write.HashOnRead(hor)
@@ -19,3 +19,20 @@ func IsHotView(ctx context.Context) bool {
 	v := ctx.Value(hotView)
 	return v != nil
 }
+
+type orderedKeysKey struct{}
+
+var orderedKeys = orderedKeysKey{}
+
+// WithOrderedKeys constructs a new context with an option that requests the blockstore (e.g. the
+// splitstore) to emit the keys of AllKeysChan in a deterministic order, the key order (see
+// CompareKeys).
+func WithOrderedKeys(ctx context.Context) context.Context {
+	return context.WithValue(ctx, orderedKeys, struct{}{})
+}
+
+// IsOrderedKeys returns true if the ordered keys option is set in the context
+func IsOrderedKeys(ctx context.Context) bool {
+	v := ctx.Value(orderedKeys)
+	return v != nil
+}
@@ -6,9 +6,12 @@ import (
 
 	"github.com/ipfs/go-cid"
 	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
 )
 
 var _ Blockstore = (*discardstore)(nil)
+var _ BlockstoreKeyOrder = (*discardstore)(nil)
+var _ BlockstoreEstimator = (*discardstore)(nil)
 
 type discardstore struct {
 	bs Blockstore
@@ -22,6 +25,14 @@ func (b *discardstore) Has(ctx context.Context, cid cid.Cid) (bool, error) {
 	return b.bs.Has(ctx, cid)
 }
 
+func (b *discardstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	return b.bs.HasMany(ctx, cids)
+}
+
+func (b *discardstore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	return b.bs.GetMany(ctx, cids)
+}
+
 func (b *discardstore) HashOnRead(hor bool) {
 	b.bs.HashOnRead(hor)
 }
@@ -62,6 +73,19 @@ func (b *discardstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error)
 	return b.bs.AllKeysChan(ctx)
 }
 
+func (b *discardstore) KeysOrdered() bool {
+	o, ok := b.bs.(BlockstoreKeyOrder)
+	return ok && o.KeysOrdered()
+}
+
+func (b *discardstore) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	est, ok := b.bs.(BlockstoreEstimator)
+	if !ok {
+		return 0, 0, xerrors.Errorf("underlying blockstore (type %T) doesn't support estimates", b.bs)
+	}
+	return est.EstimateKeys(ctx)
+}
+
 func (b *discardstore) Close() error {
 	if c, ok := b.bs.(io.Closer); ok {
 		return c.Close()
@@ -89,6 +89,29 @@ func (fbs *FallbackStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, err
 	}
 }
 
+func (fbs *FallbackStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res, err := fbs.Blockstore.GetMany(ctx, cids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, blk := range res {
+		if blk != nil {
+			continue
+		}
+
+		blk, err := fbs.getFallback(cids[i])
+		switch {
+		case err == nil:
+			res[i] = blk
+		case ipld.IsNotFound(err):
+		default:
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 func (fbs *FallbackStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
 	sz, err := fbs.Blockstore.GetSize(ctx, c)
 	switch {
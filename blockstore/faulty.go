@@ -0,0 +1,225 @@
+package blockstore
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+)
+
+// ErrInjectedFault is returned by the writes failed by a faulty blockstore.
+var ErrInjectedFault = xerrors.New("injected blockstore fault")
+
+// FaultConfig configures the faults injected by a faulty blockstore.
+type FaultConfig struct {
+	// NotFoundRate is the fraction of reads failing as if the block were missing.
+	NotFoundRate float64
+	// WriteFailureRate is the fraction of writes and deletes failing with ErrInjectedFault.
+	WriteFailureRate float64
+	// LatencyRate is the fraction of operations delayed by Latency.
+	LatencyRate float64
+	Latency     time.Duration
+	// Seed seeds the fault generator, so that the faults injected into the same
+	// sequence of operations are reproducible.
+	Seed int64
+}
+
+// ParseFaultConfig parses a fault configuration in the format
+// "notfound=0.01,write=0.001,latency=0.05,delay=200ms,seed=1"; omitted keys are zero.
+func ParseFaultConfig(s string) (FaultConfig, error) {
+	var cfg FaultConfig
+	for _, kv := range strings.Split(s, ",") {
+		if kv == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return cfg, xerrors.Errorf("invalid fault config entry %q: expected key=value", kv)
+		}
+
+		var err error
+		switch k {
+		case "notfound":
+			cfg.NotFoundRate, err = strconv.ParseFloat(v, 64)
+		case "write":
+			cfg.WriteFailureRate, err = strconv.ParseFloat(v, 64)
+		case "latency":
+			cfg.LatencyRate, err = strconv.ParseFloat(v, 64)
+		case "delay":
+			cfg.Latency, err = time.ParseDuration(v)
+		case "seed":
+			cfg.Seed, err = strconv.ParseInt(v, 10, 64)
+		default:
+			return cfg, xerrors.Errorf("unknown fault config key %q", k)
+		}
+		if err != nil {
+			return cfg, xerrors.Errorf("invalid value for fault config key %q: %w", k, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+var _ Blockstore = (*faultyStore)(nil)
+
+// faultyStore injects faults into the operations of a blockstore, for exercising
+// the error paths of its users.
+type faultyStore struct {
+	bs  Blockstore
+	cfg FaultConfig
+
+	mx  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewFaulty returns a blockstore injecting the faults configured by cfg into the
+// operations of bs. The optional traits of bs, such as iteration, aren't exposed.
+func NewFaulty(bs Blockstore, cfg FaultConfig) Blockstore {
+	return &faultyStore{
+		bs:  bs,
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+func (b *faultyStore) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.rng.Float64() < rate
+}
+
+func (b *faultyStore) delay(ctx context.Context) error {
+	if !b.roll(b.cfg.LatencyRate) {
+		return nil
+	}
+
+	select {
+	case <-time.After(b.cfg.Latency):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *faultyStore) read(ctx context.Context, c cid.Cid) error {
+	if err := b.delay(ctx); err != nil {
+		return err
+	}
+	if b.roll(b.cfg.NotFoundRate) {
+		return ipld.ErrNotFound{Cid: c}
+	}
+	return nil
+}
+
+func (b *faultyStore) write(ctx context.Context) error {
+	if err := b.delay(ctx); err != nil {
+		return err
+	}
+	if b.roll(b.cfg.WriteFailureRate) {
+		return ErrInjectedFault
+	}
+	return nil
+}
+
+func (b *faultyStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	if err := b.read(ctx, c); err != nil {
+		if ipld.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return b.bs.Has(ctx, c)
+}
+
+func (b *faultyStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	if err := b.read(ctx, c); err != nil {
+		return nil, err
+	}
+	return b.bs.Get(ctx, c)
+}
+
+func (b *faultyStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	if err := b.read(ctx, c); err != nil {
+		return 0, err
+	}
+	return b.bs.GetSize(ctx, c)
+}
+
+func (b *faultyStore) View(ctx context.Context, c cid.Cid, f func([]byte) error) error {
+	if err := b.read(ctx, c); err != nil {
+		return err
+	}
+	return b.bs.View(ctx, c, f)
+}
+
+// HasMany and GetMany inject faults into the read of every block.
+func (b *faultyStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	return HasEach(ctx, b, cids)
+}
+
+func (b *faultyStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	return GetEach(ctx, b, cids)
+}
+
+func (b *faultyStore) HashOnRead(hor bool) {
+	b.bs.HashOnRead(hor)
+}
+
+func (b *faultyStore) Put(ctx context.Context, blk blocks.Block) error {
+	if err := b.write(ctx); err != nil {
+		return err
+	}
+	return b.bs.Put(ctx, blk)
+}
+
+func (b *faultyStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	if err := b.write(ctx); err != nil {
+		return err
+	}
+	return b.bs.PutMany(ctx, blks)
+}
+
+func (b *faultyStore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	if err := b.write(ctx); err != nil {
+		return err
+	}
+	return b.bs.DeleteBlock(ctx, c)
+}
+
+func (b *faultyStore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	if err := b.write(ctx); err != nil {
+		return err
+	}
+	return b.bs.DeleteMany(ctx, cids)
+}
+
+func (b *faultyStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	if err := b.delay(ctx); err != nil {
+		return nil, err
+	}
+	return b.bs.AllKeysChan(ctx)
+}
+
+func (b *faultyStore) Flush(ctx context.Context) error {
+	return b.bs.Flush(ctx)
+}
+
+func (b *faultyStore) Close() error {
+	if c, ok := b.bs.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
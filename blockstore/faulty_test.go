@@ -0,0 +1,110 @@
+package blockstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaulty(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	require.NoError(t, m.Put(ctx, b0))
+
+	// no faults
+	bs := NewFaulty(m, FaultConfig{})
+	blk, err := bs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), blk.RawData())
+	require.NoError(t, bs.Put(ctx, b1))
+
+	// reads always miss
+	bs = NewFaulty(m, FaultConfig{NotFoundRate: 1})
+	_, err = bs.Get(ctx, b0.Cid())
+	require.True(t, ipld.IsNotFound(err))
+	_, err = bs.GetSize(ctx, b0.Cid())
+	require.True(t, ipld.IsNotFound(err))
+	require.True(t, ipld.IsNotFound(bs.View(ctx, b0.Cid(), func([]byte) error { return nil })))
+	has, err := bs.Has(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// writes always fail, and leave the store untouched
+	bs = NewFaulty(m, FaultConfig{WriteFailureRate: 1})
+	require.ErrorIs(t, bs.Put(ctx, b2), ErrInjectedFault)
+	require.ErrorIs(t, bs.PutMany(ctx, []blocks.Block{b2}), ErrInjectedFault)
+	require.ErrorIs(t, bs.DeleteBlock(ctx, b0.Cid()), ErrInjectedFault)
+	has, err = m.Has(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = m.Has(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// operations are delayed
+	bs = NewFaulty(m, FaultConfig{LatencyRate: 1, Latency: 20 * time.Millisecond})
+	start := time.Now()
+	_, err = bs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = bs.Get(cctx, b0.Cid())
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFaultyRate(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	require.NoError(t, m.Put(ctx, b0))
+
+	misses := func(seed int64) []bool {
+		bs := NewFaulty(m, FaultConfig{NotFoundRate: 0.5, Seed: seed})
+		var res []bool
+		for i := 0; i < 1000; i++ {
+			has, err := bs.Has(ctx, b0.Cid())
+			require.NoError(t, err)
+			res = append(res, !has)
+		}
+		return res
+	}
+
+	// faults are injected at the configured rate, reproducibly
+	res := misses(1)
+	cnt := 0
+	for _, miss := range res {
+		if miss {
+			cnt++
+		}
+	}
+	require.InDelta(t, 500, cnt, 100)
+	require.Equal(t, res, misses(1))
+}
+
+func TestParseFaultConfig(t *testing.T) {
+	cfg, err := ParseFaultConfig("notfound=0.01,write=0.5,latency=0.1,delay=200ms,seed=7")
+	require.NoError(t, err)
+	require.Equal(t, FaultConfig{
+		NotFoundRate:     0.01,
+		WriteFailureRate: 0.5,
+		LatencyRate:      0.1,
+		Latency:          200 * time.Millisecond,
+		Seed:             7,
+	}, cfg)
+
+	cfg, err = ParseFaultConfig("")
+	require.NoError(t, err)
+	require.Equal(t, FaultConfig{}, cfg)
+
+	_, err = ParseFaultConfig("notfound")
+	require.Error(t, err)
+	_, err = ParseFaultConfig("bogus=1")
+	require.Error(t, err)
+	_, err = ParseFaultConfig("write=x")
+	require.Error(t, err)
+}
@@ -11,6 +11,8 @@ import (
 )
 
 var _ Blockstore = (*idstore)(nil)
+var _ BlockstoreKeyOrder = (*idstore)(nil)
+var _ BlockstoreEstimator = (*idstore)(nil)
 
 type idstore struct {
 	bs Blockstore
@@ -63,6 +65,36 @@ func (b *idstore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 	return b.bs.Get(ctx, cid)
 }
 
+func (b *idstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res := make([]bool, len(cids))
+	for i, c := range cids {
+		inline, _, err := decodeCid(c)
+		if err != nil {
+			return nil, xerrors.Errorf("error decoding Cid: %w", err)
+		}
+		res[i] = inline
+	}
+
+	return hasManyFrom(ctx, b.bs, cids, res)
+}
+
+func (b *idstore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res := make([]blocks.Block, len(cids))
+	for i, c := range cids {
+		inline, data, err := decodeCid(c)
+		if err != nil {
+			return nil, xerrors.Errorf("error decoding Cid: %w", err)
+		}
+		if inline {
+			if res[i], err = blocks.NewBlockWithCid(data, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return getManyFrom(ctx, b.bs, cids, res)
+}
+
 func (b *idstore) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
 	inline, data, err := decodeCid(cid)
 	if err != nil {
@@ -169,6 +201,19 @@ func (b *idstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
 	return b.bs.AllKeysChan(ctx)
 }
 
+func (b *idstore) KeysOrdered() bool {
+	o, ok := b.bs.(BlockstoreKeyOrder)
+	return ok && o.KeysOrdered()
+}
+
+func (b *idstore) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	est, ok := b.bs.(BlockstoreEstimator)
+	if !ok {
+		return 0, 0, xerrors.Errorf("underlying blockstore (type %T) doesn't support estimates", b.bs)
+	}
+	return est.EstimateKeys(ctx)
+}
+
 func (b *idstore) HashOnRead(enabled bool) {
 	b.bs.HashOnRead(enabled)
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"io/ioutil"
+	"strings"
 
 	"github.com/ipfs/go-cid"
 	httpapi "github.com/ipfs/go-ipfs-http-client"
+	ipld "github.com/ipfs/go-ipld-format"
 	blocks "github.com/ipfs/go-libipfs/blocks"
 	iface "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/ipfs/interface-go-ipfs-core/options"
@@ -77,6 +79,16 @@ func NewRemoteIPFSBlockstore(ctx context.Context, maddr multiaddr.Multiaddr, onl
 	return Adapt(bs), nil
 }
 
+// isIpfsNotFound returns whether err is the error returned by the IPFS API for a
+// block missing from an offline node. The HTTP API only returns the message of
+// the error, which differs between IPFS versions.
+func isIpfsNotFound(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "blockservice: key not found") ||
+		strings.Contains(msg, "block was not found locally") ||
+		strings.Contains(msg, "ipld: could not find")
+}
+
 func (i *IPFSBlockstore) DeleteBlock(ctx context.Context, cid cid.Cid) error {
 	return xerrors.Errorf("not supported")
 }
@@ -88,7 +100,7 @@ func (i *IPFSBlockstore) Has(ctx context.Context, cid cid.Cid) (bool, error) {
 		// Stat() will fail with an err if the block isn't in the
 		// blockstore. If that's the case, return false without
 		// an error since that's the original intention of this method.
-		if err.Error() == "blockservice: key not found" {
+		if isIpfsNotFound(err) {
 			return false, nil
 		}
 		return false, xerrors.Errorf("getting ipfs block: %w", err)
@@ -100,6 +112,9 @@ func (i *IPFSBlockstore) Has(ctx context.Context, cid cid.Cid) (bool, error) {
 func (i *IPFSBlockstore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 	rd, err := i.api.Block().Get(ctx, path.IpldPath(cid))
 	if err != nil {
+		if isIpfsNotFound(err) {
+			return nil, ipld.ErrNotFound{Cid: cid}
+		}
 		return nil, xerrors.Errorf("getting ipfs block: %w", err)
 	}
 
@@ -114,6 +129,9 @@ func (i *IPFSBlockstore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, er
 func (i *IPFSBlockstore) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
 	st, err := i.api.Block().Stat(ctx, path.IpldPath(cid))
 	if err != nil {
+		if isIpfsNotFound(err) {
+			return 0, ipld.ErrNotFound{Cid: cid}
+		}
 		return 0, xerrors.Errorf("getting ipfs block: %w", err)
 	}
 
@@ -36,6 +36,14 @@ func (m MemBlockstore) Has(ctx context.Context, k cid.Cid) (bool, error) {
 	return ok, nil
 }
 
+func (m MemBlockstore) HasMany(ctx context.Context, ks []cid.Cid) ([]bool, error) {
+	return HasEach(ctx, m, ks)
+}
+
+func (m MemBlockstore) GetMany(ctx context.Context, ks []cid.Cid) ([]blocks.Block, error) {
+	return GetEach(ctx, m, ks)
+}
+
 func (m MemBlockstore) View(ctx context.Context, k cid.Cid, callback func([]byte) error) error {
 	b, ok := m[string(k.Hash())]
 	if !ok {
@@ -107,3 +115,12 @@ func (m MemBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error)
 func (m MemBlockstore) HashOnRead(enabled bool) {
 	// no-op
 }
+
+// EstimateKeys implements BlockstoreEstimator, with an exact count.
+func (m MemBlockstore) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	var size int64
+	for _, b := range m {
+		size += int64(len(b.RawData()))
+	}
+	return int64(len(m)), size, nil
+}
@@ -106,7 +106,12 @@ func NewNetworkStore(mss msgio.ReadWriteCloser) *NetworkStore {
 
 func (n *NetworkStore) shutdown(msg string) {
 	if err := n.msgStream.Close(); err != nil {
-		log.Errorw("closing netstore msg stream", "error", err)
+		select {
+		case <-n.closing:
+			// already closed by Stop
+		default:
+			log.Errorw("closing netstore msg stream", "error", err)
+		}
 	}
 
 	nerr := NetRpcErr{
@@ -298,6 +303,10 @@ func (n *NetworkStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
 		return false, err
 	}
 
+	return parseHasResp(resp)
+}
+
+func parseHasResp(resp NetRpcResp) (bool, error) {
 	if len(resp.Data) != 1 {
 		return false, xerrors.Errorf("expected reposnse length to be 1 byte")
 	}
@@ -311,6 +320,68 @@ func (n *NetworkStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
 	}
 }
 
+type pendingReq struct {
+	id uint64
+	ch <-chan NetRpcResp
+}
+
+// sendRpcEach sends a request of type rt for each of the cids, without waiting for the
+// responses, so that they are pipelined over the stream.
+func (n *NetworkStore) sendRpcEach(rt NetRPCReqType, cids []cid.Cid) ([]pendingReq, error) {
+	reqs := make([]pendingReq, len(cids))
+	for i, c := range cids {
+		id, ch, err := n.sendRpc(rt, []cid.Cid{c}, nil)
+		if err != nil {
+			return nil, err
+		}
+		reqs[i] = pendingReq{id: id, ch: ch}
+	}
+	return reqs, nil
+}
+
+// HasMany pipelines the checks for all the blocks.
+func (n *NetworkStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	reqs, err := n.sendRpcEach(NRpcHas, cids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]bool, len(cids))
+	for i, req := range reqs {
+		resp, err := n.waitResp(ctx, req.ch, req.id)
+		if err != nil {
+			return nil, err
+		}
+		if res[i], err = parseHasResp(resp); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// GetMany pipelines the reads of all the blocks.
+func (n *NetworkStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	reqs, err := n.sendRpcEach(NRpcGet, cids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]blocks.Block, len(cids))
+	for i, req := range reqs {
+		resp, err := n.waitResp(ctx, req.ch, req.id)
+		if ipld.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if res[i], err = blocks.NewBlockWithCid(resp.Data, cids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 func (n *NetworkStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
 	req, rch, err := n.sendRpc(NRpcGet, []cid.Cid{c}, nil)
 	if err != nil {
@@ -415,6 +486,11 @@ func (*NetworkStore) Flush(context.Context) error { return nil }
 func (n *NetworkStore) Stop(ctx context.Context) error {
 	close(n.closing)
 
+	// unblock the receive loop waiting for a message
+	if err := n.msgStream.Close(); err != nil {
+		log.Debugw("closing netstore msg stream", "error", err)
+	}
+
 	select {
 	case <-n.closed:
 		return nil
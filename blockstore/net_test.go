@@ -6,6 +6,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/ipfs/go-cid"
 	ipld "github.com/ipfs/go-ipld-format"
 	block "github.com/ipfs/go-libipfs/blocks"
 	"github.com/libp2p/go-msgio"
@@ -60,4 +61,16 @@ func TestNetBstore(t *testing.T) {
 	b, err := nbs.Get(ctx, tb1.Cid())
 	require.NoError(t, err)
 	require.Equal(t, "aoeu", string(b.RawData()))
+
+	tb2 := block.NewBlock([]byte("snth"))
+	cids := []cid.Cid{tb2.Cid(), tb1.Cid()}
+
+	hs, err := nbs.HasMany(ctx, cids)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true}, hs)
+
+	bs, err := nbs.GetMany(ctx, cids)
+	require.NoError(t, err)
+	require.Nil(t, bs[0])
+	require.Equal(t, "aoeu", string(bs[1].RawData()))
 }
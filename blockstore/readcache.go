@@ -0,0 +1,214 @@
+package blockstore
+
+import (
+	"context"
+	"io"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+)
+
+// ErrReadOnly is returned when writing to a read-only blockstore.
+var ErrReadOnly = xerrors.New("blockstore is read-only")
+
+var _ Blockstore = (*readCacheStore)(nil)
+
+// readCacheStore is a read-through cache in front of a (remote) blockstore. Blocks
+// are immutable, so cached blocks never need to be invalidated; deleted blocks may
+// still be served from the cache.
+type readCacheStore struct {
+	bs    Blockstore
+	cache *lru.ARCCache[cid.Cid, blocks.Block]
+}
+
+// NewReadCache returns a blockstore caching up to size of the blocks read from bs.
+// Writes go straight to bs.
+func NewReadCache(bs Blockstore, size int) (Blockstore, error) {
+	cache, err := lru.NewARC[cid.Cid, blocks.Block](size)
+	if err != nil {
+		return nil, xerrors.Errorf("creating block cache: %w", err)
+	}
+	return &readCacheStore{bs: bs, cache: cache}, nil
+}
+
+func (b *readCacheStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	if b.cache.Contains(c) {
+		return true, nil
+	}
+	return b.bs.Has(ctx, c)
+}
+
+func (b *readCacheStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	if blk, ok := b.cache.Get(c); ok {
+		return blk, nil
+	}
+
+	blk, err := b.bs.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	b.cache.Add(c, blk)
+	return blk, nil
+}
+
+func (b *readCacheStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res := make([]bool, len(cids))
+	for i, c := range cids {
+		res[i] = b.cache.Contains(c)
+	}
+	return hasManyFrom(ctx, b.bs, cids, res)
+}
+
+func (b *readCacheStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res := make([]blocks.Block, len(cids))
+	cached := make([]bool, len(cids))
+	for i, c := range cids {
+		res[i], cached[i] = b.cache.Get(c)
+	}
+
+	res, err := getManyFrom(ctx, b.bs, cids, res)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, blk := range res {
+		if blk != nil && !cached[i] {
+			b.cache.Add(cids[i], blk)
+		}
+	}
+	return res, nil
+}
+
+func (b *readCacheStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	if blk, ok := b.cache.Get(c); ok {
+		return len(blk.RawData()), nil
+	}
+	return b.bs.GetSize(ctx, c)
+}
+
+func (b *readCacheStore) View(ctx context.Context, c cid.Cid, f func([]byte) error) error {
+	blk, err := b.Get(ctx, c)
+	if err != nil {
+		return err
+	}
+	return f(blk.RawData())
+}
+
+func (b *readCacheStore) HashOnRead(hor bool) {
+	b.bs.HashOnRead(hor)
+}
+
+func (b *readCacheStore) Put(ctx context.Context, blk blocks.Block) error {
+	return b.bs.Put(ctx, blk)
+}
+
+func (b *readCacheStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return b.bs.PutMany(ctx, blks)
+}
+
+func (b *readCacheStore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	b.cache.Remove(c)
+	return b.bs.DeleteBlock(ctx, c)
+}
+
+func (b *readCacheStore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	for _, c := range cids {
+		b.cache.Remove(c)
+	}
+	return b.bs.DeleteMany(ctx, cids)
+}
+
+func (b *readCacheStore) Flush(ctx context.Context) error {
+	return b.bs.Flush(ctx)
+}
+
+func (b *readCacheStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return b.bs.AllKeysChan(ctx)
+}
+
+func (b *readCacheStore) Close() error {
+	if c, ok := b.bs.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+var _ Blockstore = (*readOnlyStore)(nil)
+
+// readOnlyStore refuses all writes to the wrapped blockstore, or only deletes when puts are
+// allowed.
+type readOnlyStore struct {
+	bs   Blockstore
+	puts bool
+}
+
+// NewReadOnly returns a view of bs refusing writes with ErrReadOnly.
+func NewReadOnly(bs Blockstore) Blockstore {
+	return &readOnlyStore{bs: bs}
+}
+
+// NewPutOnly returns a view of bs accepting new objects, but refusing deletes with ErrReadOnly.
+func NewPutOnly(bs Blockstore) Blockstore {
+	return &readOnlyStore{bs: bs, puts: true}
+}
+
+func (b *readOnlyStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	return b.bs.Has(ctx, c)
+}
+
+func (b *readOnlyStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	return b.bs.Get(ctx, c)
+}
+
+func (b *readOnlyStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	return b.bs.HasMany(ctx, cids)
+}
+
+func (b *readOnlyStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	return b.bs.GetMany(ctx, cids)
+}
+
+func (b *readOnlyStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	return b.bs.GetSize(ctx, c)
+}
+
+func (b *readOnlyStore) View(ctx context.Context, c cid.Cid, f func([]byte) error) error {
+	return b.bs.View(ctx, c, f)
+}
+
+func (b *readOnlyStore) HashOnRead(hor bool) {}
+
+func (b *readOnlyStore) Put(ctx context.Context, blk blocks.Block) error {
+	if !b.puts {
+		return ErrReadOnly
+	}
+	return b.bs.Put(ctx, blk)
+}
+
+func (b *readOnlyStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	if !b.puts {
+		return ErrReadOnly
+	}
+	return b.bs.PutMany(ctx, blks)
+}
+
+func (b *readOnlyStore) DeleteBlock(context.Context, cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (b *readOnlyStore) DeleteMany(context.Context, []cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (b *readOnlyStore) Flush(ctx context.Context) error {
+	if !b.puts {
+		return nil
+	}
+	return b.bs.Flush(ctx)
+}
+
+func (b *readOnlyStore) AllKeysChan(context.Context) (<-chan cid.Cid, error) {
+	return nil, xerrors.New("listing the keys of a read-only blockstore isn't supported")
+}
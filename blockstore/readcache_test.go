@@ -0,0 +1,92 @@
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCache(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	require.NoError(t, m.Put(ctx, b0))
+
+	bs, err := NewReadCache(m, 2)
+	require.NoError(t, err)
+
+	blk, err := bs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), blk.RawData())
+
+	// cached blocks are served without reading the backing store
+	require.NoError(t, m.DeleteBlock(ctx, b0.Cid()))
+	blk, err = bs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), blk.RawData())
+
+	has, err := bs.Has(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	size, err := bs.GetSize(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, len(b0.RawData()), size)
+
+	_, err = bs.Get(ctx, b1.Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	// writes go to the backing store
+	require.NoError(t, bs.Put(ctx, b1))
+	has, err = m.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	require.NoError(t, bs.DeleteBlock(ctx, b0.Cid()))
+	has, err = bs.Has(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	require.NoError(t, m.Put(ctx, b0))
+
+	bs := NewReadOnly(m)
+
+	blk, err := bs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), blk.RawData())
+
+	require.ErrorIs(t, bs.Put(ctx, b1), ErrReadOnly)
+	require.ErrorIs(t, bs.PutMany(ctx, []blocks.Block{b1}), ErrReadOnly)
+	require.ErrorIs(t, bs.DeleteBlock(ctx, b0.Cid()), ErrReadOnly)
+
+	has, err := m.Has(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestPutOnly(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	require.NoError(t, m.Put(ctx, b0))
+
+	bs := NewPutOnly(m)
+
+	require.NoError(t, bs.Put(ctx, b1))
+	has, err := m.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	require.ErrorIs(t, bs.DeleteBlock(ctx, b0.Cid()), ErrReadOnly)
+	require.ErrorIs(t, bs.DeleteMany(ctx, []cid.Cid{b0.Cid(), b1.Cid()}), ErrReadOnly)
+
+	has, err = m.Has(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+}
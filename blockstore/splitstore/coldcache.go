@@ -0,0 +1,404 @@
+package splitstore
+
+import (
+	"context"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+)
+
+// ColdCacheGCThreshold is the fraction of garbage in the value log of the cache above which it is
+// reclaimed once the objects evicted since the last GC add up to the capacity of the cache.
+var ColdCacheGCThreshold = 0.5
+
+var _ bstore.Blockstore = (*ColdCache)(nil)
+var _ bstore.BlockstoreKeyOrder = (*ColdCache)(nil)
+var _ bstore.BlockstoreEstimator = (*ColdCache)(nil)
+
+// ColdCache is a read-through cache in front of a slow or remote coldstore, holding the objects
+// recently read from it in a small badger blockstore of bounded size, so that bursts of historical
+// queries don't read the same objects from the coldstore over and over. The least recently read
+// objects are evicted once the cache is full. Writes go straight to the coldstore.
+//
+// The recency of the cached objects is only tracked in memory, so the cache is cleared when it is
+// opened.
+type ColdCache struct {
+	cold  bstore.Blockstore
+	cache *badgerbs.Blockstore
+
+	capacity int64
+
+	mx      sync.Mutex
+	lru     *simplelru.LRU[cid.Cid, int]
+	size    int64
+	evicted int64
+
+	gcRunning atomic.Bool
+
+	ctx    context.Context
+	cancel func()
+}
+
+// OpenColdCache opens a cache of up to capacity bytes at path, in front of the cold blockstore.
+func OpenColdCache(path string, cold bstore.Blockstore, capacity int64) (*ColdCache, error) {
+	if capacity <= 0 {
+		return nil, xerrors.Errorf("invalid coldstore cache capacity %d", capacity)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return nil, xerrors.Errorf("error clearing coldstore cache directory: %w", err)
+	}
+	if err := os.MkdirAll(path, 0755); err != nil { //nolint:gosec
+		return nil, xerrors.Errorf("error creating coldstore cache directory: %w", err)
+	}
+
+	opts := badgerbs.DefaultOptions(path)
+	// the cache is cleared on open, no need to sync
+	opts.SyncWrites = false
+	opts.CompactL0OnClose = false
+
+	cache, err := badgerbs.Open(opts)
+	if err != nil {
+		return nil, xerrors.Errorf("error opening coldstore cache: %w", err)
+	}
+
+	// the cache is bounded by the size of the objects rather than their number
+	lru, err := simplelru.NewLRU[cid.Cid, int](math.MaxInt32, nil)
+	if err != nil {
+		_ = cache.Close()
+		return nil, xerrors.Errorf("error creating coldstore cache index: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ColdCache{
+		cold:     cold,
+		cache:    cache,
+		capacity: capacity,
+		lru:      lru,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// cached returns whether the object is in the cache, making it the most recently read object.
+func (c *ColdCache) cached(cid cid.Cid) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	_, ok := c.lru.Get(cid)
+	return ok
+}
+
+func (c *ColdCache) contains(cid cid.Cid) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.lru.Contains(cid)
+}
+
+// add caches the objects read from the coldstore, evicting the least recently read objects as
+// needed to stay within capacity. Failures are logged, as the objects are still served from the
+// coldstore.
+func (c *ColdCache) add(blks ...blocks.Block) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	var toPut []blocks.Block
+	for _, blk := range blks {
+		if blk == nil || c.lru.Contains(blk.Cid()) {
+			continue
+		}
+
+		sz := len(blk.RawData())
+		if int64(sz) > c.capacity {
+			continue
+		}
+
+		c.lru.Add(blk.Cid(), sz)
+		c.size += int64(sz)
+		toPut = append(toPut, blk)
+	}
+
+	if len(toPut) == 0 {
+		return
+	}
+
+	if err := c.cache.PutMany(c.ctx, toPut); err != nil {
+		log.Warnf("error caching coldstore objects: %s", err)
+		for _, blk := range toPut {
+			c.removeLocked(blk.Cid())
+		}
+		return
+	}
+
+	var toEvict []cid.Cid
+	for c.size > c.capacity {
+		k, sz, ok := c.lru.RemoveOldest()
+		if !ok {
+			break
+		}
+		c.size -= int64(sz)
+		c.evicted += int64(sz)
+		toEvict = append(toEvict, k)
+	}
+
+	if len(toEvict) == 0 {
+		return
+	}
+
+	if err := c.cache.DeleteMany(c.ctx, toEvict); err != nil {
+		log.Warnf("error evicting coldstore cache objects: %s", err)
+	}
+
+	if c.evicted >= c.capacity && c.gcRunning.CompareAndSwap(false, true) {
+		c.evicted = 0
+		go c.gc()
+	}
+}
+
+func (c *ColdCache) removeLocked(cid cid.Cid) {
+	if sz, ok := c.lru.Peek(cid); ok {
+		c.lru.Remove(cid)
+		c.size -= int64(sz)
+	}
+}
+
+func (c *ColdCache) remove(cids ...cid.Cid) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	var toDelete []cid.Cid
+	for _, cid := range cids {
+		if c.lru.Contains(cid) {
+			c.removeLocked(cid)
+			toDelete = append(toDelete, cid)
+		}
+	}
+
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	return c.cache.DeleteMany(c.ctx, toDelete)
+}
+
+// gc reclaims the space of the evicted objects.
+func (c *ColdCache) gc() {
+	defer c.gcRunning.Store(false)
+
+	err := c.cache.CollectGarbage(c.ctx, bstore.WithThreshold(ColdCacheGCThreshold))
+	if err != nil && c.ctx.Err() == nil {
+		log.Warnf("error garbage collecting coldstore cache: %s", err)
+	}
+}
+
+func (c *ColdCache) Has(ctx context.Context, cid cid.Cid) (bool, error) {
+	if c.contains(cid) {
+		return true, nil
+	}
+	return c.cold.Has(ctx, cid)
+}
+
+func (c *ColdCache) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res := make([]bool, len(cids))
+
+	var idx []int
+	var query []cid.Cid
+	for i, cid := range cids {
+		if c.contains(cid) {
+			res[i] = true
+			continue
+		}
+		idx = append(idx, i)
+		query = append(query, cid)
+	}
+
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err := c.cold.HasMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		res[i] = found[j]
+	}
+
+	return res, nil
+}
+
+func (c *ColdCache) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
+	if c.cached(cid) {
+		blk, err := c.cache.Get(ctx, cid)
+		if err == nil {
+			return blk, nil
+		}
+		log.Warnf("error reading %s from the coldstore cache: %s", cid, err)
+	}
+
+	blk, err := c.cold.Get(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	c.add(blk)
+	return blk, nil
+}
+
+func (c *ColdCache) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res := make([]blocks.Block, len(cids))
+
+	var idx []int
+	var query []cid.Cid
+	for i, cid := range cids {
+		if c.cached(cid) {
+			blk, err := c.cache.Get(ctx, cid)
+			if err == nil {
+				res[i] = blk
+				continue
+			}
+			log.Warnf("error reading %s from the coldstore cache: %s", cid, err)
+		}
+		idx = append(idx, i)
+		query = append(query, cid)
+	}
+
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err := c.cold.GetMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		res[i] = found[j]
+	}
+
+	c.add(found...)
+	return res, nil
+}
+
+func (c *ColdCache) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
+	c.mx.Lock()
+	sz, ok := c.lru.Peek(cid)
+	c.mx.Unlock()
+
+	if ok {
+		return sz, nil
+	}
+	return c.cold.GetSize(ctx, cid)
+}
+
+func (c *ColdCache) View(ctx context.Context, cid cid.Cid, cb func([]byte) error) error {
+	if c.cached(cid) {
+		err := c.cache.View(ctx, cid, cb)
+		if err == nil {
+			return nil
+		}
+		if !ipld.IsNotFound(err) {
+			// the error came from the callback
+			return err
+		}
+	}
+
+	blk, err := c.cold.Get(ctx, cid)
+	if err != nil {
+		return err
+	}
+
+	c.add(blk)
+	return cb(blk.RawData())
+}
+
+func (c *ColdCache) Put(ctx context.Context, blk blocks.Block) error {
+	return c.cold.Put(ctx, blk)
+}
+
+func (c *ColdCache) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return c.cold.PutMany(ctx, blks)
+}
+
+func (c *ColdCache) DeleteBlock(ctx context.Context, cid cid.Cid) error {
+	if err := c.remove(cid); err != nil {
+		return xerrors.Errorf("error deleting from the coldstore cache: %w", err)
+	}
+	return c.cold.DeleteBlock(ctx, cid)
+}
+
+func (c *ColdCache) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	if err := c.remove(cids...); err != nil {
+		return xerrors.Errorf("error deleting from the coldstore cache: %w", err)
+	}
+	return c.cold.DeleteMany(ctx, cids)
+}
+
+func (c *ColdCache) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return c.cold.AllKeysChan(ctx)
+}
+
+func (c *ColdCache) KeysOrdered() bool {
+	o, ok := c.cold.(bstore.BlockstoreKeyOrder)
+	return ok && o.KeysOrdered()
+}
+
+func (c *ColdCache) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	est, ok := c.cold.(bstore.BlockstoreEstimator)
+	if !ok {
+		return 0, 0, xerrors.Errorf("coldstore (type %T) doesn't support estimates", c.cold)
+	}
+	return est.EstimateKeys(ctx)
+}
+
+func (c *ColdCache) HashOnRead(enabled bool) {
+	c.cold.HashOnRead(enabled)
+}
+
+func (c *ColdCache) Flush(ctx context.Context) error {
+	return c.cold.Flush(ctx)
+}
+
+// ForEachKey iterates over the keys of the coldstore, which must support efficient iteration.
+func (c *ColdCache) ForEachKey(f func(cid.Cid) error) error {
+	iter, ok := c.cold.(bstore.BlockstoreIterator)
+	if !ok {
+		return xerrors.Errorf("coldstore does not support efficient iteration")
+	}
+	return iter.ForEachKey(f)
+}
+
+// CollectGarbage garbage collects the coldstore, which must support online garbage collection.
+func (c *ColdCache) CollectGarbage(ctx context.Context, opts ...bstore.BlockstoreGCOption) error {
+	gc, ok := c.cold.(bstore.BlockstoreGC)
+	if !ok {
+		return xerrors.Errorf("blockstore doesn't support garbage collection: %T", c.cold)
+	}
+	return gc.CollectGarbage(ctx, opts...)
+}
+
+// Dedup deduplicates the coldstore, which must support deduplication; the cache is unaffected.
+func (c *ColdCache) Dedup(ctx context.Context) (bstore.DedupStats, error) {
+	dedup, ok := c.cold.(bstore.BlockstoreDedup)
+	if !ok {
+		return bstore.DedupStats{}, xerrors.Errorf("blockstore doesn't support deduplication: %T", c.cold)
+	}
+	return dedup.Dedup(ctx)
+}
+
+// Close closes the cache; the coldstore is left open.
+func (c *ColdCache) Close() error {
+	c.cancel()
+	return c.cache.Close()
+}
@@ -0,0 +1,85 @@
+package splitstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// countingStore counts the reads reaching the wrapped blockstore.
+type countingStore struct {
+	bstore.Blockstore
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	s.gets++
+	return s.Blockstore.Get(ctx, c)
+}
+
+func TestColdCache(t *testing.T) {
+	ctx := context.Background()
+	cold := &countingStore{Blockstore: bstore.NewMemory()}
+
+	// 10 objects of 100 bytes each
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("%0100d", i))))
+	}
+
+	cc, err := OpenColdCache(t.TempDir(), cold, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close() //nolint:errcheck
+
+	// writes go straight to the coldstore
+	if err := cc.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(i int, fromCold bool) {
+		t.Helper()
+
+		gets := cold.gets
+		err := cc.View(ctx, blks[i].Cid(), func(data []byte) error {
+			if string(data) != string(blks[i].RawData()) {
+				return fmt.Errorf("unexpected data for object %d", i)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (cold.gets > gets) != fromCold {
+			t.Fatalf("object %d: expected read from the coldstore=%t", i, fromCold)
+		}
+	}
+
+	// the first read of an object hits the coldstore, the next ones the cache
+	for i := 0; i < 5; i++ {
+		read(i, true)
+	}
+	for i := 0; i < 5; i++ {
+		read(i, false)
+	}
+
+	// reading more objects evicts the least recently read ones
+	read(5, true)
+	read(0, true)
+	read(2, false)
+
+	// deleted objects are removed from the cache as well
+	if err := cc.DeleteBlock(ctx, blks[2].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.Get(ctx, blks[2].Cid()); !ipld.IsNotFound(err) {
+		t.Fatalf("expected a not found error reading a deleted object, got %v", err)
+	}
+}
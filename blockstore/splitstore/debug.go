@@ -16,10 +16,12 @@ import (
 	blocks "github.com/ipfs/go-libipfs/blocks"
 	"go.uber.org/multierr"
 	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
 )
 
 type debugLog struct {
-	readLog, writeLog, deleteLog, stackLog *debugLogOp
+	readLog, writeLog, deleteLog, stackLog, compactLog *debugLogOp
 
 	stackMx  sync.Mutex
 	stackMap map[string]string
@@ -65,12 +67,22 @@ func openDebugLog(path string) (*debugLog, error) {
 		return nil, xerrors.Errorf("error opening stack log: %w", err)
 	}
 
+	compactLog, err := openDebugLogOp(basePath, "compact.log")
+	if err != nil {
+		_ = readLog.Close()
+		_ = writeLog.Close()
+		_ = deleteLog.Close()
+		_ = stackLog.Close()
+		return nil, xerrors.Errorf("error opening compaction log: %w", err)
+	}
+
 	return &debugLog{
-		readLog:   readLog,
-		writeLog:  writeLog,
-		deleteLog: deleteLog,
-		stackLog:  stackLog,
-		stackMap:  make(map[string]string),
+		readLog:    readLog,
+		writeLog:   writeLog,
+		deleteLog:  deleteLog,
+		stackLog:   stackLog,
+		compactLog: compactLog,
+		stackMap:   make(map[string]string),
 	}, nil
 }
 
@@ -137,6 +149,25 @@ func (d *debugLog) LogDelete(cids []cid.Cid) {
 	}
 }
 
+// LogCompaction records the head a compaction starts at, so that the compaction
+// can be replayed together with the writes; each line has the format
+// `<timestamp> <block cid>,<block cid>,... <epoch>`.
+func (d *debugLog) LogCompaction(curTs *types.TipSet) {
+	if d == nil {
+		return
+	}
+
+	blks := make([]string, 0, len(curTs.Cids()))
+	for _, c := range curTs.Cids() {
+		blks = append(blks, c.String())
+	}
+
+	err := d.compactLog.Log("%s %s %d\n", d.timestamp(), strings.Join(blks, ","), curTs.Height())
+	if err != nil {
+		log.Warnf("error writing compaction log: %s", err)
+	}
+}
+
 func (d *debugLog) Flush() {
 	if d == nil {
 		return
@@ -147,6 +178,7 @@ func (d *debugLog) Flush() {
 	d.writeLog.Rotate()
 	d.deleteLog.Rotate()
 	d.stackLog.Rotate()
+	d.compactLog.Rotate()
 }
 
 func (d *debugLog) Close() error {
@@ -158,8 +190,9 @@ func (d *debugLog) Close() error {
 	err2 := d.writeLog.Close()
 	err3 := d.deleteLog.Close()
 	err4 := d.stackLog.Close()
+	err5 := d.compactLog.Close()
 
-	return multierr.Combine(err1, err2, err3, err4)
+	return multierr.Combine(err1, err2, err3, err4, err5)
 }
 
 func (d *debugLog) getStack() string {
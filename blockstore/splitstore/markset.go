@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
 	"golang.org/x/xerrors"
 )
 
@@ -24,6 +25,14 @@ type MarkSet interface {
 	EndCriticalSection()
 }
 
+// MarkSetIterator is implemented by marksets which can enumerate the objects they have
+// marked, for inspecting compactions.
+type MarkSetIterator interface {
+	// ForEach calls f with the multihash of every marked object; marking blocks until it
+	// returns. An object may be seen more than once.
+	ForEach(f func(mh.Multihash) error) error
+}
+
 type MarkSetEnv interface {
 	// New creates a new markset within the environment.
 	// name is a unique name for this markset, mapped to the filesystem for on-disk persistence.
@@ -9,6 +9,7 @@ import (
 	"github.com/dgraph-io/badger/v2"
 	"github.com/dgraph-io/badger/v2/options"
 	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
 	"go.uber.org/zap"
 	"golang.org/x/xerrors"
 
@@ -36,6 +37,7 @@ type BadgerMarkSet struct {
 }
 
 var _ MarkSet = (*BadgerMarkSet)(nil)
+var _ MarkSetIterator = (*BadgerMarkSet)(nil)
 
 var badgerMarkSetBatchSize = 16384
 
@@ -235,6 +237,45 @@ func (s *BadgerMarkSet) Visit(c cid.Cid) (bool, error) {
 	return true, err
 }
 
+func (s *BadgerMarkSet) ForEach(f func(mh.Multihash) error) error {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	if s.pend == nil {
+		return errMarkSetClosed
+	}
+
+	for k := range s.pend {
+		if err := f(mh.Multihash(k)); err != nil {
+			return err
+		}
+	}
+
+	// batches being written may be in the db already
+	for _, wr := range s.writing {
+		for k := range wr {
+			if err := f(mh.Multihash(k)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			if err := f(mh.Multihash(iter.Item().KeyCopy(nil))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 // reader holds the (r)lock
 func (s *BadgerMarkSet) tryPending(key string) (has bool, err error) {
 	if s.pend == nil {
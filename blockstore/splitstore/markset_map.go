@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
 	"golang.org/x/xerrors"
 )
 
@@ -29,6 +30,7 @@ type MapMarkSet struct {
 }
 
 var _ MarkSet = (*MapMarkSet)(nil)
+var _ MarkSetIterator = (*MapMarkSet)(nil)
 
 func NewMapMarkSetEnv(path string) (*MapMarkSetEnv, error) {
 	msPath := filepath.Join(path, "markset.map")
@@ -246,6 +248,23 @@ func (s *MapMarkSet) Visit(c cid.Cid) (bool, error) {
 	return true, nil
 }
 
+func (s *MapMarkSet) ForEach(f func(mh.Multihash) error) error {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	if s.set == nil {
+		return errMarkSetClosed
+	}
+
+	for k := range s.set {
+		if err := f(mh.Multihash(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *MapMarkSet) Close() error {
 	s.mx.Lock()
 	defer s.mx.Unlock()
@@ -16,6 +16,7 @@ func TestMapMarkSet(t *testing.T) {
 	testMarkSetMarkMany(t, "map")
 	testMarkSetVisitor(t, "map")
 	testMarkSetVisitorRecovery(t, "map")
+	testMarkSetForEach(t, "map")
 }
 
 func TestBadgerMarkSet(t *testing.T) {
@@ -31,6 +32,7 @@ func TestBadgerMarkSet(t *testing.T) {
 	testMarkSetMarkMany(t, "badger")
 	testMarkSetVisitor(t, "badger")
 	testMarkSetVisitorRecovery(t, "badger")
+	testMarkSetForEach(t, "badger")
 }
 
 func testMarkSet(t *testing.T, lsType string) {
@@ -515,3 +517,57 @@ func testMarkSetMarkMany(t *testing.T, lsType string) {
 		t.Fatal("expected recovery to fail")
 	}
 }
+
+func testMarkSetForEach(t *testing.T, lsType string) {
+	path := t.TempDir()
+
+	env, err := OpenMarkSetEnv(path, lsType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close() //nolint:errcheck
+
+	markSet, err := env.New("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer markSet.Close() //nolint:errcheck
+
+	expected := make(map[string]struct{})
+	for i := 0; i < 16; i++ {
+		h, err := multihash.Sum([]byte{byte(i)}, multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := markSet.Mark(cid.NewCidV1(cid.Raw, h)); err != nil {
+			t.Fatal(err)
+		}
+		expected[string(h)] = struct{}{}
+	}
+
+	seen := make(map[string]struct{})
+	err = markSet.(MarkSetIterator).ForEach(func(h multihash.Multihash) error {
+		seen[string(h)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != len(expected) {
+		t.Fatalf("expected %d marked objects, got %d", len(expected), len(seen))
+	}
+	for k := range expected {
+		if _, ok := seen[k]; !ok {
+			t.Fatal("marked object not enumerated")
+		}
+	}
+
+	if err := markSet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := markSet.(MarkSetIterator).ForEach(func(multihash.Multihash) error { return nil }); err != errMarkSetClosed {
+		t.Fatalf("expected markset closed error, got %v", err)
+	}
+}
@@ -0,0 +1,499 @@
+package splitstore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// key prefixes of the reference tracker, followed by the multihash of the object
+const (
+	// the number of references to an object from tracked objects
+	refCountPrefix = 'c'
+	// tracked objects; the value is the CID of the object, followed by the links it released
+	refTrackedPrefix = 't'
+	// tracked objects without references, candidates for purge; the value is the CID of the object
+	refZeroPrefix = 'z'
+)
+
+var (
+	// set once all the objects in the hotstore have been tracked
+	refInitKey = []byte("/init")
+	// the epochs below which block headers released their state roots, and their messages and
+	// receipts
+	refStateEpochKey = []byte("/stateEpoch")
+	refMsgsEpochKey  = []byte("/msgsEpoch")
+)
+
+// blockHeaderPrefix is the first byte of an encoded block header, a CBOR array of 16 fields.
+const blockHeaderPrefix = 0x90
+
+// refTracker keeps the reference count of every object in the hotstore for the refcount
+// compaction strategy, in a badger db in the splitstore directory. Writing an object for the
+// first time increments the counts of its links, and purging it from the hotstore decrements
+// them; objects whose count drops to zero are candidates for the next purge.
+//
+// Updates are ordered so that, should the process stop between two of them, counts are never
+// lower than the number of tracked objects linking to an object: a count which is too high
+// only keeps an object in the hotstore, while one which is too low could purge an object
+// which is still linked.
+type refTracker struct {
+	mx sync.Mutex // serializes updates
+	db *badger.DB
+}
+
+func openRefTracker(path string) (*refTracker, error) {
+	path = filepath.Join(path, "refs.badger")
+	if err := os.MkdirAll(path, 0755); err != nil { //nolint:gosec
+		return nil, xerrors.Errorf("error creating reference tracker directory: %w", err)
+	}
+
+	db, err := openBadgerDB(path, true)
+	if err != nil {
+		return nil, xerrors.Errorf("error opening reference tracker: %w", err)
+	}
+
+	return &refTracker{db: db}, nil
+}
+
+func (r *refTracker) Close() error {
+	return r.db.Close()
+}
+
+func (r *refTracker) Sync() error {
+	return r.db.Sync()
+}
+
+// track tracks the objects written to the hotstore; objects already tracked are skipped.
+func (r *refTracker) track(blks []blocks.Block) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	txn := r.begin()
+	defer txn.discard()
+
+	for _, blk := range blks {
+		c := blk.Cid()
+		if isUnitaryObject(c) {
+			continue
+		}
+
+		tk := refKey(refTrackedPrefix, c)
+		v, err := txn.get(tk)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			continue
+		}
+
+		// the links are counted before the object is tracked
+		for _, l := range objectLinks(blk.RawData()) {
+			if err := txn.addRef(l, 1); err != nil {
+				return err
+			}
+		}
+
+		if err := txn.set(tk, c.Bytes()); err != nil {
+			return err
+		}
+
+		n, err := txn.count(c)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			if err := txn.set(refKey(refZeroPrefix, c), c.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return txn.commit()
+}
+
+// release drops the references of a tracked object to links it keeps but which are no longer
+// retained through it, such as the state root of a block header below the compaction boundary.
+// Links which have already been released are skipped, so releasing is idempotent.
+func (r *refTracker) release(c cid.Cid, links []cid.Cid) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	txn := r.begin()
+	defer txn.discard()
+
+	tk := refKey(refTrackedPrefix, c)
+	v, err := txn.get(tk)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		// not in the hotstore
+		return nil
+	}
+
+	released, err := releasedLinks(v)
+	if err != nil {
+		return xerrors.Errorf("error decoding tracked object %s: %w", c, err)
+	}
+
+	for _, l := range links {
+		if isUnitaryObject(l) {
+			continue
+		}
+		if k := string(l.Hash()); released[k] > 0 {
+			released[k]--
+			continue
+		}
+
+		// the link is recorded as released before its count is decremented
+		v = append(v, l.Bytes()...)
+		if err := txn.set(tk, v); err != nil {
+			return err
+		}
+		if err := txn.addRef(l, -1); err != nil {
+			return err
+		}
+	}
+
+	return txn.commit()
+}
+
+// purge untracks objects deleted from the hotstore, dropping their references to the links
+// they haven't released; links is the list of links of each object.
+func (r *refTracker) purge(dead []cid.Cid, links [][]cid.Cid) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	txn := r.begin()
+	defer txn.discard()
+
+	for i, c := range dead {
+		tk := refKey(refTrackedPrefix, c)
+		v, err := txn.get(tk)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			continue
+		}
+
+		released, err := releasedLinks(v)
+		if err != nil {
+			return xerrors.Errorf("error decoding tracked object %s: %w", c, err)
+		}
+
+		// the object is untracked before the counts of its links are decremented
+		if err := txn.del(tk); err != nil {
+			return err
+		}
+		if err := txn.del(refKey(refZeroPrefix, c)); err != nil {
+			return err
+		}
+
+		for _, l := range links[i] {
+			if k := string(l.Hash()); released[k] > 0 {
+				released[k]--
+				continue
+			}
+			if err := txn.addRef(l, -1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return txn.commit()
+}
+
+// isGarbage returns whether an object is tracked and has no references.
+func (r *refTracker) isGarbage(c cid.Cid) (bool, error) {
+	var garbage bool
+	err := r.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(refKey(refZeroPrefix, c))
+		switch err {
+		case nil:
+			garbage = true
+			return nil
+		case badger.ErrKeyNotFound:
+			return nil
+		default:
+			return xerrors.Errorf("error reading reference tracker: %w", err)
+		}
+	})
+	return garbage, err
+}
+
+// forEachGarbage calls f with the tracked objects without references, in a snapshot of the
+// tracker taken when it is called.
+func (r *refTracker) forEachGarbage(f func(cid.Cid) error) error {
+	return r.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte{refZeroPrefix}
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			var c cid.Cid
+			err := iter.Item().Value(func(v []byte) error {
+				var err error
+				c, err = cid.Cast(v)
+				return err
+			})
+			if err != nil {
+				return xerrors.Errorf("error decoding purge candidate: %w", err)
+			}
+
+			if err := f(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// count returns the number of references to an object.
+func (r *refTracker) count(c cid.Cid) (int64, error) {
+	txn := r.begin()
+	defer txn.discard()
+	return txn.count(c)
+}
+
+func (r *refTracker) isInitialized() (bool, error) {
+	txn := r.begin()
+	defer txn.discard()
+
+	v, err := txn.get(refInitKey)
+	return v != nil, err
+}
+
+func (r *refTracker) setInitialized() error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(refInitKey, []byte{1})
+	})
+}
+
+// releaseEpochs returns the epochs below which the block headers of the chain released their
+// state roots, and their messages and receipts.
+func (r *refTracker) releaseEpochs() (state, msgs abi.ChainEpoch, err error) {
+	txn := r.begin()
+	defer txn.discard()
+
+	v, err := txn.get(refStateEpochKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	if v != nil {
+		state = bytesToEpoch(v)
+	}
+
+	v, err = txn.get(refMsgsEpochKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	if v != nil {
+		msgs = bytesToEpoch(v)
+	}
+
+	return state, msgs, nil
+}
+
+func (r *refTracker) setReleaseEpochs(state, msgs abi.ChainEpoch) error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(refStateEpochKey, epochToBytes(state)); err != nil {
+			return err
+		}
+		return txn.Set(refMsgsEpochKey, epochToBytes(msgs))
+	})
+}
+
+func (r *refTracker) begin() *refTxn {
+	return &refTxn{db: r.db, txn: r.db.NewTransaction(true)}
+}
+
+// refTxn is an update of the tracker, which is committed in several transactions when it is
+// too big for one.
+type refTxn struct {
+	db  *badger.DB
+	txn *badger.Txn
+}
+
+func (t *refTxn) get(k []byte) ([]byte, error) {
+	item, err := t.txn.Get(k)
+	switch err {
+	case nil:
+		return item.ValueCopy(nil)
+	case badger.ErrKeyNotFound:
+		return nil, nil
+	default:
+		return nil, xerrors.Errorf("error reading reference tracker: %w", err)
+	}
+}
+
+func (t *refTxn) set(k, v []byte) error {
+	err := t.txn.Set(k, v)
+	if err == badger.ErrTxnTooBig {
+		if err := t.renew(); err != nil {
+			return err
+		}
+		err = t.txn.Set(k, v)
+	}
+	if err != nil {
+		return xerrors.Errorf("error writing reference tracker: %w", err)
+	}
+	return nil
+}
+
+func (t *refTxn) del(k []byte) error {
+	err := t.txn.Delete(k)
+	if err == badger.ErrTxnTooBig {
+		if err := t.renew(); err != nil {
+			return err
+		}
+		err = t.txn.Delete(k)
+	}
+	if err != nil {
+		return xerrors.Errorf("error writing reference tracker: %w", err)
+	}
+	return nil
+}
+
+func (t *refTxn) renew() error {
+	if err := t.commit(); err != nil {
+		return err
+	}
+	t.txn = t.db.NewTransaction(true)
+	return nil
+}
+
+func (t *refTxn) commit() error {
+	if err := t.txn.Commit(); err != nil {
+		return xerrors.Errorf("error committing reference tracker update: %w", err)
+	}
+	return nil
+}
+
+func (t *refTxn) discard() {
+	t.txn.Discard()
+}
+
+func (t *refTxn) count(c cid.Cid) (int64, error) {
+	v, err := t.get(refKey(refCountPrefix, c))
+	if err != nil || v == nil {
+		return 0, err
+	}
+	return bytesToInt64(v), nil
+}
+
+// addRef adds delta to the reference count of an object, moving it in or out of the purge
+// candidates when it is tracked.
+func (t *refTxn) addRef(c cid.Cid, delta int64) error {
+	if isUnitaryObject(c) {
+		return nil
+	}
+
+	before, err := t.count(c)
+	if err != nil {
+		return err
+	}
+
+	after := before + delta
+	if after < 0 {
+		log.Warnf("negative reference count for %s", c)
+		after = 0
+	}
+
+	ck := refKey(refCountPrefix, c)
+	if after == 0 {
+		err = t.del(ck)
+	} else {
+		err = t.set(ck, int64ToBytes(after))
+	}
+	if err != nil {
+		return err
+	}
+
+	zk := refKey(refZeroPrefix, c)
+	switch {
+	case before == 0 && after > 0:
+		return t.del(zk)
+
+	case before > 0 && after == 0:
+		v, err := t.get(refKey(refTrackedPrefix, c))
+		if err != nil || v == nil {
+			return err
+		}
+		_, tc, err := cid.CidFromBytes(v)
+		if err != nil {
+			return xerrors.Errorf("error decoding tracked object %s: %w", c, err)
+		}
+		return t.set(zk, tc.Bytes())
+	}
+
+	return nil
+}
+
+func refKey(prefix byte, c cid.Cid) []byte {
+	h := c.Hash()
+	k := make([]byte, 1+len(h))
+	k[0] = prefix
+	copy(k[1:], h)
+	return k
+}
+
+// releasedLinks decodes the links released by a tracked object, counting repeated links.
+func releasedLinks(v []byte) (map[string]int, error) {
+	released := make(map[string]int)
+
+	// skip the CID of the object
+	n, _, err := cid.CidFromBytes(v)
+	if err != nil {
+		return nil, err
+	}
+	v = v[n:]
+
+	for len(v) > 0 {
+		n, l, err := cid.CidFromBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		released[string(l.Hash())]++
+		v = v[n:]
+	}
+
+	return released, nil
+}
+
+// objectLinks returns the links counted as references of an object: the links in its data, if
+// it is a CBOR object, and for block headers, the tipset key of their parents, which is kept
+// along with the headers.
+func objectLinks(data []byte) []cid.Cid {
+	var links []cid.Cid
+	if err := scanLinks(data, func(c cid.Cid) {
+		links = append(links, c)
+	}); err != nil {
+		// not a CBOR object
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == blockHeaderPrefix {
+		var hdr types.BlockHeader
+		if err := hdr.UnmarshalCBOR(bytes.NewReader(data)); err == nil {
+			if pRef, err := types.NewTipSetKey(hdr.Parents...).Cid(); err == nil {
+				links = append(links, pRef)
+			}
+		}
+	}
+
+	return links
+}
@@ -0,0 +1,429 @@
+package splitstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/ipfs/go-merkledag"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// RetentionBatchSize is the number of objects evicted from the hotstore of a RetentionStore at
+// a time.
+var RetentionBatchSize = 16384
+
+// RetentionConfig configures a RetentionStore.
+type RetentionConfig struct {
+	// MarkSetType is the type of markset used for marking the retained objects; it can be
+	// "map" or "badger".
+	MarkSetType string
+}
+
+// RetentionStore is a simplified splitstore for blockstores whose objects are not reachable
+// from the chain, such as the staging blockstore of the markets subsystem, which grows
+// unboundedly as deal data is transferred. Instead of walking the chain, compaction retains
+// the DAGs below the roots supplied by the caller, which derives them from the lifecycle of
+// its objects (e.g. the payloads of the deals not yet handed to sealing), along with the
+// objects written since the previous compaction, so that objects always survive at least one
+// compaction interval. The remaining objects are moved to the coldstore, or discarded when
+// there is no coldstore.
+//
+// Objects of all codecs are stored; links are followed in dag-cbor and dag-pb objects.
+type RetentionStore struct {
+	compacting int32 // compaction in progress
+
+	hot  bstore.Blockstore
+	cold bstore.Blockstore // nil when evicted objects are discarded
+
+	markSetEnv MarkSetEnv
+
+	// objects written since the previous compaction, keyed by multihash
+	writeMx sync.Mutex
+	writes  map[string]struct{}
+}
+
+var _ bstore.Blockstore = (*RetentionStore)(nil)
+
+// OpenRetention opens a RetentionStore on top of the hot and cold blockstores; cold may be nil,
+// in which case evicted objects are discarded. path is the directory of on-disk marksets.
+func OpenRetention(path string, hot, cold bstore.Blockstore, cfg *RetentionConfig) (*RetentionStore, error) {
+	markSetEnv, err := OpenMarkSetEnv(path, cfg.MarkSetType)
+	if err != nil {
+		return nil, xerrors.Errorf("error opening markset environment: %w", err)
+	}
+
+	return &RetentionStore{
+		hot:        hot,
+		cold:       cold,
+		markSetEnv: markSetEnv,
+		writes:     make(map[string]struct{}),
+	}, nil
+}
+
+// Compact evicts the objects which are neither reachable from roots nor written since the
+// previous compaction from the hotstore.
+func (s *RetentionStore) Compact(ctx context.Context, roots []cid.Cid) error {
+	if !atomic.CompareAndSwapInt32(&s.compacting, 0, 1) {
+		return xerrors.Errorf("compaction already in progress")
+	}
+	defer atomic.StoreInt32(&s.compacting, 0)
+
+	start := time.Now()
+
+	s.writeMx.Lock()
+	written := s.writes
+	s.writes = make(map[string]struct{})
+	s.writeMx.Unlock()
+
+	markSet, err := s.markSetEnv.New("retain", 0)
+	if err != nil {
+		return xerrors.Errorf("error creating markset: %w", err)
+	}
+	defer markSet.Close() //nolint:errcheck
+
+	for _, c := range roots {
+		if err := s.walkRetained(ctx, c, markSet); err != nil {
+			return xerrors.Errorf("error marking objects below %s: %w", c, err)
+		}
+	}
+
+	log.Infow("marking retained objects done", "took", time.Since(start), "roots", len(roots))
+
+	// the hotstore can't be modified while it is iterated, so the evicted objects are collected
+	// first
+	var evict []cid.Cid
+	err = s.forEachHotKey(ctx, func(c cid.Cid) error {
+		// the objects written since the previous compaction are retained for one more interval
+		if _, ok := written[string(c.Hash())]; ok {
+			return nil
+		}
+
+		mark, err := markSet.Has(c)
+		if err != nil {
+			return xerrors.Errorf("error checking markset: %w", err)
+		}
+		if !mark {
+			evict = append(evict, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("error collecting evicted objects: %w", err)
+	}
+
+	var evicted int
+	for len(evict) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch := evict
+		if len(batch) > RetentionBatchSize {
+			batch = batch[:RetentionBatchSize]
+		}
+		evict = evict[len(batch):]
+
+		n, err := s.evict(ctx, batch)
+		if err != nil {
+			return xerrors.Errorf("error evicting objects: %w", err)
+		}
+		evicted += n
+	}
+
+	log.Infow("retention compaction done", "took", time.Since(start), "evicted", evicted)
+	return nil
+}
+
+// walkRetained marks the objects in the hotstore reachable from c; missing objects, whose
+// transfer hasn't completed or which were already evicted, end the walk.
+func (s *RetentionStore) walkRetained(ctx context.Context, c cid.Cid, markSet MarkSet) error {
+	stack := []cid.Cid{c}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		visit, err := markSet.Visit(c)
+		if err != nil {
+			return xerrors.Errorf("error visiting object: %w", err)
+		}
+		if !visit {
+			continue
+		}
+
+		codec := c.Prefix().Codec
+		if codec != cid.DagCBOR && codec != cid.DagProtobuf {
+			continue
+		}
+
+		err = s.hot.View(ctx, c, func(data []byte) error {
+			if codec == cid.DagCBOR {
+				return scanLinks(data, func(c cid.Cid) {
+					stack = append(stack, c)
+				})
+			}
+
+			nd, err := merkledag.DecodeProtobuf(data)
+			if err != nil {
+				return err
+			}
+			for _, l := range nd.Links() {
+				stack = append(stack, l.Cid)
+			}
+			return nil
+		})
+		switch {
+		case err == nil:
+		case ipld.IsNotFound(err):
+		case errors.Is(err, context.Canceled):
+			return err
+		default:
+			// a malformed object received from a client must not fail every compaction
+			log.Warnf("error scanning links of %s: %s", c, err)
+		}
+	}
+
+	return nil
+}
+
+// evict moves a batch of objects to the coldstore and deletes them from the hotstore, except
+// for the objects written while compacting, which are retained.
+func (s *RetentionStore) evict(ctx context.Context, batch []cid.Cid) (int, error) {
+	if s.cold != nil {
+		blks, err := s.hot.GetMany(ctx, batch)
+		if err != nil {
+			return 0, xerrors.Errorf("error retrieving objects from hotstore: %w", err)
+		}
+
+		moved := blks[:0]
+		for _, blk := range blks {
+			if blk != nil {
+				moved = append(moved, blk)
+			}
+		}
+
+		if err := s.cold.PutMany(ctx, moved); err != nil {
+			return 0, xerrors.Errorf("error moving objects to coldstore: %w", err)
+		}
+	}
+
+	// writes add the object to the write set before putting it to the hotstore, so holding
+	// the lock while deleting ensures that no object written concurrently is deleted
+	s.writeMx.Lock()
+	defer s.writeMx.Unlock()
+
+	deleted := batch[:0]
+	for _, c := range batch {
+		if _, ok := s.writes[string(c.Hash())]; !ok {
+			deleted = append(deleted, c)
+		}
+	}
+
+	if err := s.hot.DeleteMany(ctx, deleted); err != nil {
+		return 0, xerrors.Errorf("error deleting objects from hotstore: %w", err)
+	}
+
+	return len(deleted), nil
+}
+
+func (s *RetentionStore) forEachHotKey(ctx context.Context, f func(cid.Cid) error) error {
+	if iter, ok := s.hot.(bstore.BlockstoreIterator); ok {
+		return iter.ForEachKey(f)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch, err := s.hot.AllKeysChan(ctx)
+	if err != nil {
+		return err
+	}
+
+	for c := range ch {
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *RetentionStore) trackWrite(c cid.Cid) {
+	s.writeMx.Lock()
+	s.writes[string(c.Hash())] = struct{}{}
+	s.writeMx.Unlock()
+}
+
+func (s *RetentionStore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	has, err := s.hot.Has(ctx, c)
+	if err != nil || has || s.cold == nil {
+		return has, err
+	}
+
+	return s.cold.Has(ctx, c)
+}
+
+func (s *RetentionStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res, err := s.hot.HasMany(ctx, cids)
+	if err != nil || s.cold == nil {
+		return res, err
+	}
+
+	idx, query := bstore.MissingHas(cids, res)
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err := s.cold.HasMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		res[i] = found[j]
+	}
+	return res, nil
+}
+
+func (s *RetentionStore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := s.hot.Get(ctx, c)
+	if ipld.IsNotFound(err) && s.cold != nil {
+		return s.cold.Get(ctx, c)
+	}
+	return blk, err
+}
+
+func (s *RetentionStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res, err := s.hot.GetMany(ctx, cids)
+	if err != nil || s.cold == nil {
+		return res, err
+	}
+
+	idx, query := bstore.MissingGet(cids, res)
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err := s.cold.GetMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		res[i] = found[j]
+	}
+	return res, nil
+}
+
+func (s *RetentionStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	size, err := s.hot.GetSize(ctx, c)
+	if ipld.IsNotFound(err) && s.cold != nil {
+		return s.cold.GetSize(ctx, c)
+	}
+	return size, err
+}
+
+func (s *RetentionStore) View(ctx context.Context, c cid.Cid, cb func([]byte) error) error {
+	err := s.hot.View(ctx, c, cb)
+	if ipld.IsNotFound(err) && s.cold != nil {
+		return s.cold.View(ctx, c, cb)
+	}
+	return err
+}
+
+func (s *RetentionStore) Put(ctx context.Context, blk blocks.Block) error {
+	s.trackWrite(blk.Cid())
+	return s.hot.Put(ctx, blk)
+}
+
+func (s *RetentionStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	s.writeMx.Lock()
+	for _, blk := range blks {
+		s.writes[string(blk.Cid().Hash())] = struct{}{}
+	}
+	s.writeMx.Unlock()
+
+	return s.hot.PutMany(ctx, blks)
+}
+
+func (s *RetentionStore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	return s.DeleteMany(ctx, []cid.Cid{c})
+}
+
+func (s *RetentionStore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	if err := s.hot.DeleteMany(ctx, cids); err != nil {
+		return err
+	}
+	if s.cold != nil {
+		return s.cold.DeleteMany(ctx, cids)
+	}
+	return nil
+}
+
+func (s *RetentionStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	if s.cold == nil {
+		return s.hot.AllKeysChan(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	chHot, err := s.hot.AllKeysChan(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	chCold, err := s.cold.AllKeysChan(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	ch := make(chan cid.Cid)
+	go func() {
+		defer cancel()
+		defer close(ch)
+
+		for _, in := range []<-chan cid.Cid{chHot, chCold} {
+			for c := range in {
+				select {
+				case ch <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (s *RetentionStore) HashOnRead(enabled bool) {
+	s.hot.HashOnRead(enabled)
+	if s.cold != nil {
+		s.cold.HashOnRead(enabled)
+	}
+}
+
+func (s *RetentionStore) Flush(ctx context.Context) error {
+	if err := s.hot.Flush(ctx); err != nil {
+		return err
+	}
+	if s.cold != nil {
+		return s.cold.Flush(ctx)
+	}
+	return nil
+}
+
+// Close closes the markset environment; the hot and cold blockstores are owned by the caller.
+func (s *RetentionStore) Close() error {
+	return s.markSetEnv.Close()
+}
@@ -0,0 +1,109 @@
+package splitstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/ipfs/go-merkledag"
+	mh "github.com/multiformats/go-multihash"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+func TestRetentionStore(t *testing.T) {
+	t.Run("coldstore", func(t *testing.T) {
+		testRetentionStore(t, bstore.NewMemory())
+	})
+	t.Run("discard", func(t *testing.T) {
+		testRetentionStore(t, nil)
+	})
+}
+
+func testRetentionStore(t *testing.T, cold bstore.Blockstore) {
+	ctx := context.Background()
+	hot := bstore.NewMemory()
+
+	rs, err := OpenRetention(t.TempDir(), hot, cold, &RetentionConfig{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Close() //nolint:errcheck
+
+	raw := func(data string) blocks.Block {
+		c, err := cid.V1Builder{Codec: cid.Raw, MhType: mh.SHA2_256}.Sum([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		blk, err := blocks.NewBlockWithCid([]byte(data), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blk
+	}
+
+	put := func(blk blocks.Block) cid.Cid {
+		if err := rs.Put(ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+		return blk.Cid()
+	}
+
+	// a dag-pb payload with a raw leaf, and an unrelated object
+	leaf := put(raw("leaf"))
+	nd := merkledag.NodeWithData([]byte("root"))
+	if err := nd.AddRawLink("leaf", &ipld.Link{Cid: leaf}); err != nil {
+		t.Fatal(err)
+	}
+	root := put(nd)
+	other := put(raw("other"))
+
+	checkHot := func(c cid.Cid, expected bool) {
+		t.Helper()
+
+		has, err := hot.Has(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != expected {
+			t.Fatalf("object %s: expected hot=%t", c, expected)
+		}
+	}
+
+	// the first compaction retains everything written since the store was opened
+	if err := rs.Compact(ctx, []cid.Cid{root}); err != nil {
+		t.Fatal(err)
+	}
+	checkHot(root, true)
+	checkHot(leaf, true)
+	checkHot(other, true)
+
+	// the second evicts the unreachable objects, but retains the objects written since the
+	// first one
+	late := put(raw("late"))
+	if err := rs.Compact(ctx, []cid.Cid{root}); err != nil {
+		t.Fatal(err)
+	}
+	checkHot(root, true)
+	checkHot(leaf, true)
+	checkHot(other, false)
+	checkHot(late, true)
+
+	has, err := rs.Has(ctx, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has != (cold != nil) {
+		t.Fatalf("expected the evicted object to be readable only from a coldstore")
+	}
+
+	// once the payload is released, it is evicted as well
+	if err := rs.Compact(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkHot(root, false)
+	checkHot(leaf, false)
+	checkHot(late, false)
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/ipfs/go-cid"
 	dstore "github.com/ipfs/go-datastore"
 	ipld "github.com/ipfs/go-ipld-format"
@@ -60,6 +62,12 @@ var (
 
 	// upgradeBoundary is the boundary before and after an upgrade where we suppress compaction
 	upgradeBoundary = build.Finality
+
+	// finality is the finality the compaction thresholds and boundaries derive from
+	finality = build.Finality
+
+	// estimateSampleSize is the number of hot keys sampled to estimate the objects in both stores
+	estimateSampleSize = 1024
 )
 
 type CompactType int
@@ -70,6 +78,8 @@ const (
 	hot
 	cold
 	check
+	backup
+	dedup
 )
 
 func init() {
@@ -80,6 +90,33 @@ func init() {
 	if os.Getenv("LOTUS_SPLITSTORE_DEBUG_LOG_WRITE_TRACES") == "1" {
 		enableDebugLogWriteTraces = true
 	}
+
+	// accelerated compaction for devnets, which otherwise take hours to reach the
+	// first compaction
+	if s := os.Getenv("LOTUS_SPLITSTORE_FINALITY"); s != "" {
+		f, err := strconv.ParseInt(s, 10, 64)
+		switch {
+		case err != nil || f <= 0:
+			log.Errorf("invalid LOTUS_SPLITSTORE_FINALITY %q: expected a positive number of epochs", s)
+		case build.BuildType != build.Build2k && build.BuildType != build.BuildDebug:
+			log.Errorf("ignoring LOTUS_SPLITSTORE_FINALITY: it can only be set on devnets")
+		default:
+			SetFinality(abi.ChainEpoch(f))
+		}
+	}
+}
+
+// SetFinality scales the compaction and prune thresholds and boundaries, which are
+// multiples of the chain finality, to the given finality. This is meant for devnets
+// and tests exercising compaction, warmup and GC in minutes rather than hours; it must
+// be called before the splitstore is started.
+func SetFinality(f abi.ChainEpoch) {
+	finality = f
+	upgradeBoundary = f
+	CompactionThreshold = 5 * f
+	CompactionBoundary = 4 * f
+	PruneThreshold = 7 * f
+	WarmupBoundary = f
 }
 
 type Config struct {
@@ -132,6 +169,67 @@ type Config struct {
 	// Moving GC will not occur when total moving size exceeds
 	// HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer
 	HotstoreMaxSpaceSafetyBuffer uint64
+
+	// HotStorePath is the path of the hotstore, when it isn't kept in the splitstore path;
+	// its free space is monitored instead of the splitstore path.
+	HotStorePath string
+
+	// EphemeralHotStore indicates that the hotstore is kept on volatile storage, such as
+	// tmpfs, and may be lost on restart. Objects are written through to the coldstore,
+	// which must be universal, and a lost hotstore is warmed up again from the coldstore.
+	EphemeralHotStore bool
+
+	// ColdStorePath is the path of the coldstore, if it is backed by disk; its free space
+	// is monitored together with the hotstore path.
+	ColdStorePath string
+
+	// DiskSpaceLowThreshold is the free disk space (in bytes) below which compaction and
+	// pruning are paused. A value of 0 disables the threshold.
+	DiskSpaceLowThreshold uint64
+
+	// DiskSpaceCriticalThreshold is the free disk space (in bytes) below which writes through
+	// the exposed blockstore (i.e. writes not required for consensus) are refused.
+	// A value of 0 disables the threshold.
+	DiskSpaceCriticalThreshold uint64
+
+	// ShutdownGracePeriod is how long Close waits for an ongoing compaction or prune to
+	// stop before aborting it. A value of 0 waits indefinitely.
+	ShutdownGracePeriod time.Duration
+
+	// CompactionProfiling enables capturing CPU and heap profiles at the start, middle
+	// (before purging) and end of each compaction; profiles are written to
+	// <splitstore-path>/profiles.
+	CompactionProfiling bool
+
+	// ColdReadPrefetch enables promoting the DAG below objects read from the coldstore to
+	// the hotstore in the background, down to PrefetchDepth.
+	ColdReadPrefetch bool
+
+	// ColdReadPromotion is the policy for promoting objects read from the coldstore to the
+	// hotstore, when not prefetching: PromoteNever (the default), PromoteAlways or PromoteHits,
+	// which promotes objects read ColdReadPromotionHits times within ColdReadPromotionWindow
+	// epochs.
+	ColdReadPromotion       string
+	ColdReadPromotionHits   int
+	ColdReadPromotionWindow abi.ChainEpoch
+
+	// CompactionStrategy decides when compaction runs and which objects it evicts from the
+	// hotstore: CompactEpoch (the default), CompactCapacity, which compacts whenever the hotstore
+	// grows beyond HotStoreCapacity bytes and keeps the most recently accessed objects, or
+	// CompactRefCount.
+	CompactionStrategy string
+	HotStoreCapacity   uint64
+
+	// ColdArchivePath is the directory where each compaction writes the objects it moves out of
+	// the hotstore as a cold archive, a CAR file rooted at the compaction tipset. Archives are
+	// not written when empty.
+	ColdArchivePath string
+
+	// ColdArchiveUploader, if set, ships the cold archives to remote storage after each
+	// compaction. ColdArchiveRetention is the policy for the local copy of uploaded archives:
+	// ArchiveKeep (the default) or ArchiveDrop, which deletes it once the upload is verified.
+	ColdArchiveUploader  ArchiveUploader
+	ColdArchiveRetention string
 }
 
 // ChainAccessor allows the Splitstore to access the chain. It will most likely
@@ -160,8 +258,12 @@ type SplitStore struct {
 	compactType CompactType // compaction type, protected by compacting atomic, only meaningful when compacting == 1
 	closing     int32       // the splitstore is closing
 
-	cfg  *Config
-	path string
+	compactPhase      atomic.Value // string, the phase of the running compaction or prune
+	compactPhaseStart atomic.Value // time.Time
+
+	cfgMx sync.Mutex
+	cfg   *Config // protected by cfgMx, replaced as a whole on update
+	path  string
 
 	mx          sync.Mutex
 	warmupEpoch abi.ChainEpoch // protected by mx
@@ -189,6 +291,21 @@ type SplitStore struct {
 
 	debug *debugLog
 
+	// called with the objects purged by a compaction; set while replaying compactions
+	purgeHook func([]cid.Cid)
+
+	// last error encountered by a background operation (compaction, prune, warmup, check),
+	// cleared when the operation next succeeds
+	errMx     sync.Mutex
+	lastErr   error
+	lastErrOp string
+	lastErrAt time.Time
+	opHooks   []func(op string, err error)
+
+	// disk space watchdog state, updated atomically
+	diskState int32
+	diskFree  uint64
+
 	// transactional protection for concurrent read/writes during compaction
 	txnLk           sync.RWMutex
 	txnViewsMx      sync.Mutex
@@ -211,9 +328,36 @@ type SplitStore struct {
 	reifyPend       map[cid.Cid]struct{}
 	reifyInProgress map[cid.Cid]struct{}
 
+	// background upload of cold archives
+	archiveWorkers   sync.WaitGroup
+	archiveUploading int32
+
+	// background promotion of cold reads and their DAGs
+	prefetchCh chan prefetchReq
+
+	// cold reads counted by the hits promotion policy
+	coldHitsMx sync.Mutex
+	coldHits   map[cid.Cid]*coldHits
+
+	// objects promoted since the last compaction, which keeps them hot
+	promotedMx sync.Mutex
+	promoted   map[cid.Cid]struct{}
+
+	// recently accessed objects, tracked by the capacity compaction strategy
+	access *lru.Cache[cid.Cid, struct{}]
+
+	// reference counts of the hot objects, tracked by the refcount compaction strategy
+	refs *refTracker
+
 	// registered protectors
 	protectors []func(func(cid.Cid) error) error
 
+	// markset of the running compaction, for inspection; protected by mx
+	liveMarkSet MarkSet
+
+	// profiler of the ongoing compaction, protected by compaction lock
+	profiler *compactionProfiler
+
 	// dag sizes measured during latest compaction
 	// logged and used for GC strategy
 
@@ -243,6 +387,22 @@ func Open(path string, ds dstore.Datastore, hot, cold bstore.Blockstore, cfg *Co
 		return nil, xerrors.Errorf("hot blockstore does not support the necessary traits: %T", hot)
 	}
 
+	if err := checkPromotionPolicy(cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.EphemeralHotStore && !cfg.UniversalColdBlocks {
+		return nil, xerrors.Errorf("an ephemeral hotstore requires a universal coldstore")
+	}
+
+	if err := checkCompactionStrategy(cfg, hot); err != nil {
+		return nil, err
+	}
+
+	if err := checkArchivePolicy(cfg); err != nil {
+		return nil, err
+	}
+
 	// the markset env
 	markSetEnv, err := OpenMarkSetEnv(path, cfg.MarkSetType)
 	if err != nil {
@@ -267,6 +427,21 @@ func Open(path string, ds dstore.Datastore, hot, cold bstore.Blockstore, cfg *Co
 	ss.reifyPend = make(map[cid.Cid]struct{})
 	ss.reifyInProgress = make(map[cid.Cid]struct{})
 
+	ss.prefetchCh = make(chan prefetchReq, PrefetchQueueSize)
+
+	if cfg.CompactionStrategy == CompactCapacity {
+		ss.access, err = lru.New[cid.Cid, struct{}](AccessTrackerSize)
+		if err != nil {
+			markSetEnv.Close() //nolint:errcheck
+			return nil, xerrors.Errorf("error creating access tracker: %w", err)
+		}
+	}
+
+	if err := ss.openRefs(); err != nil {
+		markSetEnv.Close() //nolint:errcheck
+		return nil, xerrors.Errorf("error opening reference tracker: %w", err)
+	}
+
 	if enableDebugLog {
 		ss.debug, err = openDebugLog(path)
 		if err != nil {
@@ -392,6 +567,7 @@ func (s *SplitStore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error)
 	switch {
 	case err == nil:
 		s.trackTxnRef(cid)
+		s.trackAccess(cid)
 		return blk, nil
 
 	case ipld.IsNotFound(err):
@@ -402,8 +578,11 @@ func (s *SplitStore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error)
 		blk, err = s.cold.Get(ctx, cid)
 		if err == nil {
 			s.trackTxnRef(cid)
+			s.trackAccess(cid)
 			if bstore.IsHotView(ctx) {
 				s.reifyColdObject(cid)
+			} else {
+				s.coldRead(cid)
 			}
 
 			stats.Record(s.ctx, metrics.SplitstoreMiss.M(1))
@@ -489,6 +668,11 @@ func (s *SplitStore) Flush(ctx context.Context) error {
 	if err := s.ds.Sync(ctx, dstore.Key{}); err != nil {
 		return err
 	}
+	if s.refs != nil {
+		if err := s.refs.Sync(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -501,6 +685,18 @@ func (s *SplitStore) Put(ctx context.Context, blk blocks.Block) error {
 	s.txnLk.RLock()
 	defer s.txnLk.RUnlock()
 
+	// write through first, so that the hotstore never holds an object the coldstore lacks
+	if s.config().EphemeralHotStore {
+		if err := s.cold.Put(ctx, blk); err != nil {
+			return err
+		}
+	}
+
+	// the references are tracked before the write, see trackRefs
+	if err := s.trackRefs([]blocks.Block{blk}); err != nil {
+		return err
+	}
+
 	err := s.hot.Put(ctx, blk)
 	if err != nil {
 		return err
@@ -552,6 +748,16 @@ func (s *SplitStore) PutMany(ctx context.Context, blks []blocks.Block) error {
 	s.txnLk.RLock()
 	defer s.txnLk.RUnlock()
 
+	if s.config().EphemeralHotStore {
+		if err := s.cold.PutMany(ctx, blks); err != nil {
+			return err
+		}
+	}
+
+	if err := s.trackRefs(blks); err != nil {
+		return err
+	}
+
 	err := s.hot.PutMany(ctx, blks)
 	if err != nil {
 		return err
@@ -569,39 +775,75 @@ func (s *SplitStore) PutMany(ctx context.Context, blks []blocks.Block) error {
 	return nil
 }
 
+// AllKeysChan emits the keys of the hotstore, followed by the keys of the coldstore which are
+// not in the hotstore; only the hot keys are kept in memory to deduplicate the keys.
+//
+// With the ordered keys option (see bstore.WithOrderedKeys), the keys of the two stores are
+// merged in key order instead, which deduplicates them without keeping them in memory; only the
+// keys of a store which can't emit them in key order are sorted in memory first.
 func (s *SplitStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
 	ctx, cancel := context.WithCancel(ctx)
 
-	chHot, err := s.hot.AllKeysChan(ctx)
+	ordered := bstore.IsOrderedKeys(ctx)
+	allKeys := func(bs bstore.Blockstore) (<-chan cid.Cid, error) {
+		if ordered {
+			return orderedKeys(ctx, bs)
+		}
+		return bs.AllKeysChan(ctx)
+	}
+
+	chHot, err := allKeys(s.hot)
 	if err != nil {
 		cancel()
 		return nil, err
 	}
 
-	chCold, err := s.cold.AllKeysChan(ctx)
+	chCold, err := allKeys(s.cold)
 	if err != nil {
 		cancel()
 		return nil, err
 	}
 
-	seen := cid.NewSet()
 	ch := make(chan cid.Cid, 8) // buffer is arbitrary, just enough to avoid context switches
 	go func() {
 		defer cancel()
 		defer close(ch)
 
-		for _, in := range []<-chan cid.Cid{chHot, chCold} {
-			for c := range in {
-				// ensure we only emit each key once
-				if !seen.Visit(c) {
-					continue
-				}
-
-				select {
-				case ch <- c:
-				case <-ctx.Done():
-					return
-				}
+		emit := func(c cid.Cid) bool {
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if ordered {
+			mergeKeys(chHot, chCold, emit)
+			return
+		}
+
+		hotKeys := make(map[string]struct{})
+		for c := range chHot {
+			// keys are compared by multihash, as the stores key objects by multihash
+			k := string(c.Hash())
+			if _, ok := hotKeys[k]; ok {
+				continue
+			}
+			hotKeys[k] = struct{}{}
+
+			if !emit(c) {
+				return
+			}
+		}
+
+		for c := range chCold {
+			if _, ok := hotKeys[string(c.Hash())]; ok {
+				continue
+			}
+
+			if !emit(c) {
+				return
 			}
 		}
 	}()
@@ -609,6 +851,135 @@ func (s *SplitStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
 	return ch, nil
 }
 
+// orderedKeys returns the keys of bs in key order; the keys of a blockstore which can't emit
+// them in key order are sorted in memory.
+func orderedKeys(ctx context.Context, bs bstore.Blockstore) (<-chan cid.Cid, error) {
+	ch, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if o, ok := bs.(bstore.BlockstoreKeyOrder); ok && o.KeysOrdered() {
+		return ch, nil
+	}
+
+	out := make(chan cid.Cid)
+	go func() {
+		defer close(out)
+
+		var keys []cid.Cid
+		for c := range ch {
+			keys = append(keys, c)
+		}
+		bstore.SortKeys(keys)
+
+		for _, c := range keys {
+			select {
+			case out <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// mergeKeys merges two channels of keys in key order, emitting the keys in both of them once,
+// until emit returns false.
+func mergeKeys(chA, chB <-chan cid.Cid, emit func(cid.Cid) bool) {
+	a, okA := <-chA
+	b, okB := <-chB
+	for okA || okB {
+		var cmp int
+		switch {
+		case !okB:
+			cmp = -1
+		case !okA:
+			cmp = 1
+		default:
+			cmp = bstore.CompareKeys(a, b)
+		}
+
+		c := a
+		if cmp > 0 {
+			c = b
+		}
+		if !emit(c) {
+			return
+		}
+
+		if cmp <= 0 {
+			a, okA = <-chA
+		}
+		if cmp >= 0 {
+			b, okB = <-chB
+		}
+	}
+}
+
+// EstimateKeys implements BlockstoreEstimator, as the sum of the estimates of the hotstore and
+// the coldstore, less the objects in both stores. Their share of the hotstore is estimated from
+// a sample of the hot keys.
+func (s *SplitStore) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	var counts, sizes [2]int64
+	for i, bs := range []bstore.Blockstore{s.hot, s.cold} {
+		est, ok := bs.(bstore.BlockstoreEstimator)
+		if !ok {
+			return 0, 0, xerrors.Errorf("%T does not support estimates", bs)
+		}
+
+		c, sz, err := est.EstimateKeys(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		counts[i] = c
+		sizes[i] = sz
+	}
+
+	shared, err := s.sharedHotObjects(ctx)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("error sampling hot objects: %w", err)
+	}
+
+	count := counts[0] + counts[1] - int64(shared*float64(counts[0]))
+	size := sizes[0] + sizes[1] - int64(shared*float64(sizes[0]))
+	return count, size, nil
+}
+
+// sharedHotObjects estimates the fraction of the hot objects which are also in the coldstore from
+// the first estimateSampleSize hot keys; they are a uniform sample, as keys are hashes.
+func (s *SplitStore) sharedHotObjects(ctx context.Context) (float64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch, err := s.hot.AllKeysChan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var sampled, shared int
+	for c := range ch {
+		has, err := s.cold.Has(ctx, c)
+		if err != nil {
+			return 0, err
+		}
+
+		sampled++
+		if has {
+			shared++
+		}
+		if sampled == estimateSampleSize {
+			break
+		}
+	}
+
+	if sampled == 0 {
+		return 0, nil
+	}
+	return float64(shared) / float64(sampled), nil
+}
+
 func (s *SplitStore) HashOnRead(enabled bool) {
 	s.hot.HashOnRead(enabled)
 	s.cold.HashOnRead(enabled)
@@ -666,14 +1037,20 @@ func (s *SplitStore) View(ctx context.Context, cid cid.Cid, cb func([]byte) erro
 
 		err = s.cold.View(ctx, cid, cb)
 		if err == nil {
+			s.trackAccess(cid)
 			if bstore.IsHotView(ctx) {
 				s.reifyColdObject(cid)
+			} else {
+				s.coldRead(cid)
 			}
 
 			stats.Record(s.ctx, metrics.SplitstoreMiss.M(1))
 		}
 		return err
 	}
+	if err == nil {
+		s.trackAccess(cid)
+	}
 	return err
 }
 
@@ -785,6 +1162,18 @@ func (s *SplitStore) Start(chain ChainAccessor, us stmgr.UpgradeSchedule) error
 		return xerrors.Errorf("error loading compaction index: %w", err)
 	}
 
+	lost, err := s.checkEphemeralHotStore()
+	if err != nil {
+		return err
+	}
+	if lost {
+		log.Warn("the ephemeral hotstore was lost; warming it up again from the coldstore")
+		s.mx.Lock()
+		s.warmupEpoch = 0
+		s.mx.Unlock()
+		warmup = true
+	}
+
 	log.Infow("starting splitstore", "baseEpoch", s.baseEpoch, "warmupEpoch", s.warmupEpoch)
 
 	if warmup {
@@ -794,8 +1183,16 @@ func (s *SplitStore) Start(chain ChainAccessor, us stmgr.UpgradeSchedule) error
 		}
 	}
 
-	// spawn the reifier
+	// spawn the reifier and the prefetcher
 	go s.reifyOrchestrator()
+	go s.prefetcher()
+
+	// and the disk space watchdog; it keeps running with no thresholds, as they can be
+	// set at runtime
+	if s.diskSpaceWatchdogEnabled() {
+		s.checkDiskSpace()
+	}
+	go s.diskSpaceWatchdog()
 
 	// watch the chain
 	chain.SubscribeHeadChanges(s.HeadChange)
@@ -803,6 +1200,53 @@ func (s *SplitStore) Start(chain ChainAccessor, us stmgr.UpgradeSchedule) error
 	return nil
 }
 
+// UpdateConfig applies the settings of cfg which are safe to change at runtime: the
+// hotstore message retention, the GC and disk space thresholds, compaction
+// profiling, cold read prefetching and promotion, and the shutdown grace period. They
+// take effect from the next compaction or disk space check; the other settings of cfg
+// are ignored. Invalid settings are rejected with an error naming them, and keep their
+// current value while the valid ones are still applied.
+func (s *SplitStore) UpdateConfig(cfg *Config) error {
+	s.cfgMx.Lock()
+	defer s.cfgMx.Unlock()
+
+	var errs error
+
+	ncfg := *s.cfg
+	ncfg.HotStoreMessageRetention = cfg.HotStoreMessageRetention
+	ncfg.HotStoreFullGCFrequency = cfg.HotStoreFullGCFrequency
+	ncfg.HotstoreMaxSpaceTarget = cfg.HotstoreMaxSpaceTarget
+	ncfg.HotstoreMaxSpaceThreshold = cfg.HotstoreMaxSpaceThreshold
+	ncfg.HotstoreMaxSpaceSafetyBuffer = cfg.HotstoreMaxSpaceSafetyBuffer
+	ncfg.DiskSpaceLowThreshold = cfg.DiskSpaceLowThreshold
+	ncfg.DiskSpaceCriticalThreshold = cfg.DiskSpaceCriticalThreshold
+	ncfg.CompactionProfiling = cfg.CompactionProfiling
+	ncfg.ColdReadPrefetch = cfg.ColdReadPrefetch
+	if err := checkPromotionPolicy(cfg); err != nil {
+		errs = multierr.Append(errs, xerrors.Errorf("rejected ColdReadPromotion: %w", err))
+	} else {
+		ncfg.ColdReadPromotion = cfg.ColdReadPromotion
+		ncfg.ColdReadPromotionHits = cfg.ColdReadPromotionHits
+		ncfg.ColdReadPromotionWindow = cfg.ColdReadPromotionWindow
+	}
+	ncfg.ShutdownGracePeriod = cfg.ShutdownGracePeriod
+	// the compaction strategy can't change at runtime, as accesses are tracked from Open
+	if ncfg.CompactionStrategy == CompactCapacity && cfg.HotStoreCapacity == 0 {
+		errs = multierr.Append(errs, xerrors.Errorf("rejected HotStoreCapacity: the %q compaction strategy needs a hotstore capacity", CompactCapacity))
+	} else {
+		ncfg.HotStoreCapacity = cfg.HotStoreCapacity
+	}
+	s.cfg = &ncfg
+
+	return errs
+}
+
+func (s *SplitStore) config() *Config {
+	s.cfgMx.Lock()
+	defer s.cfgMx.Unlock()
+	return s.cfg
+}
+
 func (s *SplitStore) AddProtector(protector func(func(cid.Cid) error) error) {
 	s.mx.Lock()
 	defer s.mx.Unlock()
@@ -822,16 +1266,23 @@ func (s *SplitStore) Close() error {
 		s.txnSyncCond.Broadcast()
 		s.txnSyncMx.Unlock()
 
-		log.Warn("close with ongoing compaction in progress; waiting for it to finish...")
-		for atomic.LoadInt32(&s.compacting) == 1 {
-			time.Sleep(time.Second)
+		if err := s.waitForCompaction(); err != nil {
+			// the operation still uses the markset env, leave it open
+			s.reifyCond.Broadcast()
+			return err
 		}
 	}
 
 	s.reifyCond.Broadcast()
 	s.reifyWorkers.Wait()
 	s.cancel()
-	return multierr.Combine(s.markSetEnv.Close(), s.debug.Close())
+	s.archiveWorkers.Wait()
+
+	err := multierr.Combine(s.markSetEnv.Close(), s.debug.Close())
+	if s.refs != nil {
+		err = multierr.Append(err, s.refs.Close())
+	}
+	return err
 }
 
 func (s *SplitStore) checkClosing() error {
@@ -842,6 +1293,38 @@ func (s *SplitStore) checkClosing() error {
 	return nil
 }
 
+// AddOperationHook registers a hook which is called with the outcome of every background
+// operation (compaction, prune, warmup, check); err is nil when the operation succeeded.
+func (s *SplitStore) AddOperationHook(hook func(op string, err error)) {
+	s.errMx.Lock()
+	defer s.errMx.Unlock()
+
+	s.opHooks = append(s.opHooks, hook)
+}
+
+// recordResult records the outcome of a background operation; the last error is remembered so
+// that it can be reported through Info until the same operation succeeds, and registered
+// operation hooks are notified.
+func (s *SplitStore) recordResult(op string, err error) {
+	s.errMx.Lock()
+	switch {
+	case err != nil:
+		s.lastErr = err
+		s.lastErrOp = op
+		s.lastErrAt = time.Now()
+	case s.lastErrOp == op:
+		s.lastErr = nil
+		s.lastErrOp = ""
+		s.lastErrAt = time.Time{}
+	}
+	hooks := s.opHooks
+	s.errMx.Unlock()
+
+	for _, hook := range hooks {
+		hook(op, err)
+	}
+}
+
 func (s *SplitStore) setBaseEpoch(epoch abi.ChainEpoch) error {
 	s.baseEpoch = epoch
 	return s.ds.Put(s.ctx, baseEpochKey, epochToBytes(epoch))
@@ -0,0 +1,367 @@
+package splitstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	car "github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const (
+	// ArchiveKeep keeps the local copy of cold archives after they are uploaded.
+	ArchiveKeep = "keep"
+	// ArchiveDrop deletes the local copy of cold archives once their upload is verified.
+	ArchiveDrop = "drop"
+)
+
+const (
+	coldArchiveExt      = ".car"
+	coldArchiveUploaded = ".uploaded"
+)
+
+// ArchiveUploader ships cold archives to remote storage.
+type ArchiveUploader interface {
+	// Upload uploads the archive file at path under the given name, and verifies that the
+	// remote copy is complete; the local file is only dropped after Upload succeeds.
+	Upload(ctx context.Context, name, path string) error
+}
+
+func checkArchivePolicy(cfg *Config) error {
+	switch cfg.ColdArchiveRetention {
+	case "", ArchiveKeep:
+	case ArchiveDrop:
+		if cfg.ColdArchiveUploader == nil {
+			return xerrors.Errorf("the %q cold archive retention policy needs an uploader", ArchiveDrop)
+		}
+	default:
+		return xerrors.Errorf("unknown cold archive retention policy %q", cfg.ColdArchiveRetention)
+	}
+
+	if cfg.ColdArchiveUploader != nil && cfg.ColdArchivePath == "" {
+		return xerrors.Errorf("uploading cold archives needs a cold archive path")
+	}
+
+	return nil
+}
+
+// archiveColdBlocks writes the cold objects of the current compaction to a cold archive, a CAR
+// file rooted at the compaction tipset. The objects are read from the hotstore, so this must be
+// called before purging. Like the coldset, the archive keys objects by multihash, with raw CIDs.
+func (s *SplitStore) archiveColdBlocks(coldr *ColdSetReader, curTs *types.TipSet) error {
+	dir := s.config().ColdArchivePath
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return xerrors.Errorf("error creating cold archive directory: %w", err)
+	}
+
+	name := fmt.Sprintf("cold-%d-%d%s", s.compactionIndex, curTs.Height(), coldArchiveExt)
+	tmp := filepath.Join(dir, name+".tmp")
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return xerrors.Errorf("error creating cold archive: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+	}()
+
+	w := bufio.NewWriterSize(f, 1<<20)
+	if err := car.WriteHeader(&car.CarHeader{Roots: curTs.Cids(), Version: 1}, w); err != nil {
+		return xerrors.Errorf("error writing cold archive header: %w", err)
+	}
+
+	var count, size int64
+	writeBatch := func(batch []cid.Cid) error {
+		blks, err := s.hot.GetMany(s.ctx, batch)
+		if err != nil {
+			return xerrors.Errorf("error retrieving batch from hotstore: %w", err)
+		}
+
+		for i, blk := range blks {
+			if blk == nil {
+				log.Warnf("hotstore missing block %s", batch[i])
+				continue
+			}
+			if err := carutil.LdWrite(w, batch[i].Bytes(), blk.RawData()); err != nil {
+				return xerrors.Errorf("error writing cold archive: %w", err)
+			}
+			count++
+			size += int64(len(blk.RawData()))
+		}
+
+		return nil
+	}
+
+	batch := make([]cid.Cid, 0, batchSize)
+	err = coldr.ForEach(func(c cid.Cid) error {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		batch = append(batch, c)
+		if len(batch) == batchSize {
+			if err := writeBatch(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("error iterating coldset: %w", err)
+	}
+
+	if len(batch) > 0 {
+		if err := writeBatch(batch); err != nil {
+			return err
+		}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	if err := w.Flush(); err != nil {
+		return xerrors.Errorf("error writing cold archive: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return xerrors.Errorf("error syncing cold archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return xerrors.Errorf("error closing cold archive: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return xerrors.Errorf("error renaming cold archive: %w", err)
+	}
+
+	log.Infow("cold archive written", "archive", name, "objects", count, "size", size)
+	return nil
+}
+
+// uploadColdArchives ships the cold archives which haven't been uploaded yet in the background,
+// oldest first. Archives failing to upload are retried after the next compaction.
+func (s *SplitStore) uploadColdArchives() {
+	cfg := s.config()
+	if cfg.ColdArchiveUploader == nil {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.archiveUploading, 0, 1) {
+		// still shipping the previous archives
+		return
+	}
+
+	s.archiveWorkers.Add(1)
+	go func() {
+		defer s.archiveWorkers.Done()
+		defer atomic.StoreInt32(&s.archiveUploading, 0)
+
+		if err := s.doUploadColdArchives(cfg); err != nil {
+			log.Warnf("error uploading cold archives: %s", err)
+		}
+	}()
+}
+
+func (s *SplitStore) doUploadColdArchives(cfg *Config) error {
+	pending, err := pendingColdArchives(cfg.ColdArchivePath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range pending {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		p := filepath.Join(cfg.ColdArchivePath, name)
+
+		start := time.Now()
+		if err := cfg.ColdArchiveUploader.Upload(s.ctx, name, p); err != nil {
+			return xerrors.Errorf("error uploading cold archive %s: %w", name, err)
+		}
+		log.Infow("cold archive uploaded", "archive", name, "took", time.Since(start))
+
+		if cfg.ColdArchiveRetention == ArchiveDrop {
+			if err := os.Remove(p); err != nil {
+				return xerrors.Errorf("error dropping uploaded cold archive %s: %w", name, err)
+			}
+			continue
+		}
+
+		if err := os.WriteFile(p+coldArchiveUploaded, nil, 0644); err != nil { //nolint:gosec
+			return xerrors.Errorf("error marking cold archive %s as uploaded: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// pendingColdArchives returns the names of the cold archives in dir which haven't been uploaded,
+// oldest first.
+func pendingColdArchives(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, xerrors.Errorf("error listing cold archives: %w", err)
+	}
+
+	uploaded := make(map[string]struct{})
+	var archives []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case strings.HasSuffix(name, coldArchiveExt):
+			archives = append(archives, name)
+		case strings.HasSuffix(name, coldArchiveExt+coldArchiveUploaded):
+			uploaded[strings.TrimSuffix(name, coldArchiveUploaded)] = struct{}{}
+		}
+	}
+
+	pending := archives[:0]
+	for _, name := range archives {
+		if _, ok := uploaded[name]; !ok {
+			pending = append(pending, name)
+		}
+	}
+
+	// archives are named after the compaction index
+	sort.Slice(pending, func(i, j int) bool {
+		return coldArchiveIndex(pending[i]) < coldArchiveIndex(pending[j])
+	})
+
+	return pending, nil
+}
+
+func coldArchiveIndex(name string) int64 {
+	var index, epoch int64
+	if _, err := fmt.Sscanf(name, "cold-%d-%d"+coldArchiveExt, &index, &epoch); err != nil {
+		return -1
+	}
+	return index
+}
+
+// HTTPArchiveUploader uploads cold archives with a single HTTP PUT request each under a base
+// URL, to any HTTP server accepting uploads, and verifies them with a HEAD request: the remote
+// size must match, and so must the ETag when it is the MD5 digest of the archive. Requests only
+// carry static headers, so targets needing per-request signatures are not supported.
+type HTTPArchiveUploader struct {
+	base   *url.URL
+	header http.Header
+	client *http.Client
+}
+
+var _ ArchiveUploader = (*HTTPArchiveUploader)(nil)
+
+// NewHTTPArchiveUploader creates an uploader to the given base URL, sending the given headers,
+// in the "Name: value" format, with every request.
+func NewHTTPArchiveUploader(baseURL string, headers []string) (*HTTPArchiveUploader, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid cold archive upload URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, xerrors.Errorf("invalid cold archive upload URL %q: expected an http or https URL", baseURL)
+	}
+
+	header := make(http.Header)
+	for _, h := range headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, xerrors.Errorf("invalid cold archive upload header %q: expected \"Name: value\"", h)
+		}
+		header.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+
+	return &HTTPArchiveUploader{
+		base:   base,
+		header: header,
+		client: &http.Client{},
+	}, nil
+}
+
+func (u *HTTPArchiveUploader) Upload(ctx context.Context, name, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return xerrors.Errorf("error opening archive: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	digest := md5.New() //nolint:gosec
+	size, err := io.Copy(digest, f)
+	if err != nil {
+		return xerrors.Errorf("error reading archive: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return xerrors.Errorf("error reading archive: %w", err)
+	}
+
+	target := *u.base
+	target.Path = path.Join(target.Path, name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), f)
+	if err != nil {
+		return xerrors.Errorf("error creating upload request: %w", err)
+	}
+	req.Header = u.header.Clone()
+	req.Header.Set("Content-Type", "application/vnd.ipld.car")
+	req.ContentLength = size
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("error uploading archive: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return xerrors.Errorf("error uploading archive: %s", resp.Status)
+	}
+
+	// verify the remote copy
+	req, err = http.NewRequestWithContext(ctx, http.MethodHead, target.String(), nil)
+	if err != nil {
+		return xerrors.Errorf("error creating verification request: %w", err)
+	}
+	req.Header = u.header.Clone()
+
+	resp, err = u.client.Do(req)
+	if err != nil {
+		return xerrors.Errorf("error verifying upload: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return xerrors.Errorf("error verifying upload: %s", resp.Status)
+	}
+
+	if resp.ContentLength != size {
+		return xerrors.Errorf("error verifying upload: remote size %d, expected %d", resp.ContentLength, size)
+	}
+
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if _, err := hex.DecodeString(etag); err == nil && len(etag) == 2*md5.Size {
+		if expected := hex.EncodeToString(digest.Sum(nil)); etag != expected {
+			return xerrors.Errorf("error verifying upload: remote digest %s, expected %s", etag, expected)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package splitstore
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	car "github.com/ipld/go-car"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// archiveServer is an HTTP server accepting PUT uploads, which replies to HEAD requests with the
+// size and MD5 ETag of the stored objects.
+type archiveServer struct {
+	mx      sync.Mutex
+	objects map[string][]byte
+	corrupt bool
+}
+
+func (s *archiveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if s.corrupt {
+			data = data[:len(data)/2]
+		}
+		s.objects[r.URL.Path] = data
+
+	case http.MethodHead:
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		digest := md5.Sum(data) //nolint:gosec
+		w.Header().Set("ETag", `"`+hex.EncodeToString(digest[:])+`"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestSplitStoreColdArchive(t *testing.T) {
+	ctx := context.Background()
+
+	hot, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hot.Close() //nolint:errcheck
+
+	var blks []blocks.Block
+	for i := 0; i < 2*batchSize+1; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("cold object %d", i))))
+	}
+	if err := hot.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+
+	server := &archiveServer{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	uploader, err := NewHTTPArchiveUploader(srv.URL+"/archives", []string{"Authorization: Bearer secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archiveDir := t.TempDir()
+	cfg := &Config{
+		MarkSetType:          "map",
+		ColdArchivePath:      archiveDir,
+		ColdArchiveUploader:  uploader,
+		ColdArchiveRetention: ArchiveDrop,
+	}
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, bstore.NewMemory(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint:errcheck
+
+	coldw, err := NewColdSetWriter(ss.coldSetPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blk := range blks {
+		if err := coldw.Write(blk.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := coldw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	coldr, err := NewColdSetReader(ss.coldSetPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer coldr.Close() //nolint:errcheck
+
+	curTs := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	ss.compactionIndex = 3
+	if err := ss.archiveColdBlocks(coldr, curTs); err != nil {
+		t.Fatal(err)
+	}
+
+	// the archive is a CAR file of the cold objects, rooted at the compaction tipset
+	name := fmt.Sprintf("cold-3-%d.car", curTs.Height())
+	archive, err := os.ReadFile(filepath.Join(archiveDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(archiveDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr, err := car.NewCarReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cr.Header.Roots) != 1 || cr.Header.Roots[0] != curTs.Cids()[0] {
+		t.Fatalf("unexpected archive roots %v", cr.Header.Roots)
+	}
+	// objects are keyed by multihash, as in the coldset
+	expected := make(map[string]struct{})
+	for _, blk := range blks {
+		expected[string(blk.Cid().Hash())] = struct{}{}
+	}
+	count := 0
+	for {
+		blk, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := expected[string(blk.Cid().Hash())]; !ok {
+			t.Fatalf("unexpected object %s in the archive", blk.Cid())
+		}
+		delete(expected, string(blk.Cid().Hash()))
+		count++
+	}
+	_ = f.Close()
+	if count != len(blks) {
+		t.Fatalf("expected %d objects in the archive, got %d", len(blks), count)
+	}
+
+	// a failed upload keeps the archive for the next attempt
+	server.mx.Lock()
+	server.corrupt = true
+	server.mx.Unlock()
+	if err := ss.doUploadColdArchives(ss.config()); err == nil {
+		t.Fatal("expected verification of a corrupt upload to fail")
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, name)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a verified upload drops the local copy
+	server.mx.Lock()
+	server.corrupt = false
+	server.mx.Unlock()
+	ss.uploadColdArchives()
+	ss.archiveWorkers.Wait()
+
+	if _, err := os.Stat(filepath.Join(archiveDir, name)); !os.IsNotExist(err) {
+		t.Fatalf("expected the uploaded archive to be dropped, got %v", err)
+	}
+	if string(server.objects[path.Join("/archives", name)]) != string(archive) {
+		t.Fatal("uploaded archive differs from the local copy")
+	}
+}
+
+func TestSplitStoreColdArchiveConfig(t *testing.T) {
+	uploader, err := NewHTTPArchiveUploader("http://localhost/archives", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"none", Config{}, true},
+		{"archive only", Config{ColdArchivePath: "archives"}, true},
+		{"keep", Config{ColdArchivePath: "archives", ColdArchiveUploader: uploader, ColdArchiveRetention: ArchiveKeep}, true},
+		{"drop", Config{ColdArchivePath: "archives", ColdArchiveUploader: uploader, ColdArchiveRetention: ArchiveDrop}, true},
+		{"drop without uploader", Config{ColdArchivePath: "archives", ColdArchiveRetention: ArchiveDrop}, false},
+		{"upload without path", Config{ColdArchiveUploader: uploader}, false},
+		{"unknown", Config{ColdArchivePath: "archives", ColdArchiveRetention: "shred"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkArchivePolicy(&tc.cfg)
+			if tc.ok != (err == nil) {
+				t.Fatalf("expected ok=%t, got error %v", tc.ok, err)
+			}
+		})
+	}
+
+	if _, err := NewHTTPArchiveUploader("s3://bucket", nil); err == nil {
+		t.Fatal("expected an error for a non http upload URL")
+	}
+	if _, err := NewHTTPArchiveUploader("http://localhost", []string{"no separator"}); err == nil {
+		t.Fatal("expected an error for a malformed header")
+	}
+}
@@ -0,0 +1,114 @@
+package splitstore
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	dstore "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+var metadataKeys = []dstore.Key{
+	baseEpochKey,
+	warmupEpochKey,
+	markSetSizeKey,
+	compactionIndexKey,
+	pruneIndexKey,
+	pruneEpochKey,
+}
+
+// Metadata is the splitstore state persisted in the metadata datastore.
+type Metadata struct {
+	BaseEpoch       abi.ChainEpoch
+	WarmupEpoch     abi.ChainEpoch
+	PruneEpoch      abi.ChainEpoch
+	MarkSetSize     int64
+	CompactionIndex int64
+	PruneIndex      int64
+
+	// WarmedUp is set when the hotstore has been warmed up, which is required for
+	// the rest of the metadata to be meaningful.
+	WarmedUp bool
+}
+
+// LoadMetadata reads the splitstore metadata from ds. It returns nil when ds
+// holds no splitstore metadata.
+func LoadMetadata(ctx context.Context, ds dstore.Datastore) (*Metadata, error) {
+	var md Metadata
+	found := false
+	for _, k := range metadataKeys {
+		bs, err := ds.Get(ctx, k)
+		switch err {
+		case nil:
+		case dstore.ErrNotFound:
+			continue
+		default:
+			return nil, xerrors.Errorf("error loading %s: %w", k, err)
+		}
+		found = true
+
+		switch k {
+		case baseEpochKey:
+			md.BaseEpoch = bytesToEpoch(bs)
+		case warmupEpochKey:
+			md.WarmupEpoch = bytesToEpoch(bs)
+			md.WarmedUp = true
+		case markSetSizeKey:
+			md.MarkSetSize = bytesToInt64(bs)
+		case compactionIndexKey:
+			md.CompactionIndex = bytesToInt64(bs)
+		case pruneIndexKey:
+			md.PruneIndex = bytesToInt64(bs)
+		case pruneEpochKey:
+			md.PruneEpoch = bytesToEpoch(bs)
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return &md, nil
+}
+
+// ClearMetadata deletes the splitstore metadata from ds, so that the splitstore
+// starts afresh with a warmup when it is next opened.
+func ClearMetadata(ctx context.Context, ds dstore.Datastore) error {
+	for _, k := range metadataKeys {
+		if err := ds.Delete(ctx, k); err != nil {
+			return xerrors.Errorf("error deleting %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// LockCompaction waits for the background operation in progress to finish, and
+// prevents a new one from starting until the returned function is called, so
+// that the splitstore metadata can be backed up consistently with the state of
+// the hot and cold stores.
+func (s *SplitStore) LockCompaction(ctx context.Context) (func(), error) {
+	logged := false
+	for !atomic.CompareAndSwapInt32(&s.compacting, 0, 1) {
+		if !logged {
+			log.Infow("waiting for background operation to finish", "operation", compactTypeNames[s.compactType])
+			logged = true
+		}
+
+		select {
+		case <-time.After(shutdownPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if err := s.checkClosing(); err != nil {
+			return nil, err
+		}
+	}
+	s.compactType = backup
+
+	return func() {
+		atomic.StoreInt32(&s.compacting, 0)
+	}, nil
+}
@@ -0,0 +1,73 @@
+package splitstore
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	md, err := LoadMetadata(ctx, ds)
+	require.NoError(t, err)
+	require.Nil(t, md)
+
+	require.NoError(t, ds.Put(ctx, baseEpochKey, epochToBytes(100)))
+	require.NoError(t, ds.Put(ctx, warmupEpochKey, epochToBytes(10)))
+	require.NoError(t, ds.Put(ctx, compactionIndexKey, int64ToBytes(3)))
+
+	md, err = LoadMetadata(ctx, ds)
+	require.NoError(t, err)
+	require.Equal(t, &Metadata{
+		BaseEpoch:       100,
+		WarmupEpoch:     10,
+		CompactionIndex: 3,
+		WarmedUp:        true,
+	}, md)
+
+	require.NoError(t, ClearMetadata(ctx, ds))
+	md, err = LoadMetadata(ctx, ds)
+	require.NoError(t, err)
+	require.Nil(t, md)
+}
+
+func TestLockCompaction(t *testing.T) {
+	shutdownPollInterval = 10 * time.Millisecond
+	defer func() {
+		shutdownPollInterval = time.Second
+	}()
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ss, err := Open(t.TempDir(), ds, newMockStore(), newMockStore(), &Config{MarkSetType: "map"})
+	require.NoError(t, err)
+	defer ss.Close() //nolint:errcheck
+
+	// wait for the running compaction
+	atomic.StoreInt32(&ss.compacting, 1)
+	ss.compactType = hot
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&ss.compacting, 0)
+	}()
+
+	unlock, err := ss.LockCompaction(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&ss.compacting))
+	require.Equal(t, backup, ss.compactType)
+
+	// no other operation can start while locked
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = ss.LockCompaction(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	unlock()
+	require.Equal(t, int32(0), atomic.LoadInt32(&ss.compacting))
+}
@@ -0,0 +1,171 @@
+package splitstore
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"go.opencensus.io/stats"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// HasMany checks for all the objects with a single query to the hotstore, followed by a single
+// query to the coldstore for the objects missing from the hotstore.
+func (s *SplitStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	s.txnLk.RLock()
+	// critical section; the objects are checked one by one against the transactional markset
+	if s.txnMarkSet != nil {
+		s.txnLk.RUnlock()
+
+		res := make([]bool, len(cids))
+		for i, c := range cids {
+			has, err := s.Has(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = has
+		}
+		return res, nil
+	}
+	defer s.txnLk.RUnlock()
+
+	res := make([]bool, len(cids))
+	for i, c := range cids {
+		res[i] = isIdentiyCid(c)
+	}
+
+	idx, query := bstore.MissingHas(cids, res)
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err := s.hot.HasMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		if found[j] {
+			res[i] = true
+			s.trackTxnRef(cids[i])
+		}
+	}
+
+	idx, query = bstore.MissingHas(cids, res)
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err = s.cold.HasMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		if found[j] {
+			res[i] = true
+			s.trackTxnRef(cids[i])
+			if bstore.IsHotView(ctx) {
+				s.reifyColdObject(cids[i])
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// GetMany reads all the objects with a single query to the hotstore, followed by a single
+// query to the coldstore for the objects missing from the hotstore.
+func (s *SplitStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	s.txnLk.RLock()
+	// critical section; the objects are read one by one against the transactional markset
+	if s.txnMarkSet != nil {
+		s.txnLk.RUnlock()
+
+		res := make([]blocks.Block, len(cids))
+		for i, c := range cids {
+			blk, err := s.Get(ctx, c)
+			switch {
+			case err == nil:
+				res[i] = blk
+			case ipld.IsNotFound(err):
+			default:
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	defer s.txnLk.RUnlock()
+
+	res := make([]blocks.Block, len(cids))
+	for i, c := range cids {
+		if !isIdentiyCid(c) {
+			continue
+		}
+
+		data, err := decodeIdentityCid(c)
+		if err != nil {
+			return nil, err
+		}
+		if res[i], err = blocks.NewBlockWithCid(data, c); err != nil {
+			return nil, err
+		}
+	}
+
+	idx, query := bstore.MissingGet(cids, res)
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	found, err := s.hot.GetMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range idx {
+		if found[j] != nil {
+			res[i] = found[j]
+			s.trackTxnRef(cids[i])
+			s.trackAccess(cids[i])
+		}
+	}
+
+	idx, query = bstore.MissingGet(cids, res)
+	if len(query) == 0 {
+		return res, nil
+	}
+
+	if s.isWarm() {
+		for _, c := range query {
+			s.debug.LogReadMiss(c)
+		}
+	}
+
+	found, err = s.cold.GetMany(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	misses := 0
+	for j, i := range idx {
+		if found[j] == nil {
+			continue
+		}
+
+		res[i] = found[j]
+		s.trackTxnRef(cids[i])
+		s.trackAccess(cids[i])
+		if bstore.IsHotView(ctx) {
+			s.reifyColdObject(cids[i])
+		} else {
+			s.coldRead(cids[i])
+		}
+		misses++
+	}
+
+	if misses > 0 {
+		stats.Record(s.ctx, metrics.SplitstoreMiss.M(int64(misses)))
+	}
+
+	return res, nil
+}
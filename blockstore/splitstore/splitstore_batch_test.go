@@ -0,0 +1,75 @@
+package splitstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestSplitStoreHasManyGetMany(t *testing.T) {
+	ctx := context.Background()
+	hot := newMockStore()
+	cold := newMockStore()
+
+	hotBlk := blocks.NewBlock([]byte("hot"))
+	coldBlk := blocks.NewBlock([]byte("cold"))
+	missing := blocks.NewBlock([]byte("missing"))
+
+	idHash, err := mh.Sum([]byte("identity"), mh.IDENTITY, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idCid := cid.NewCidV1(cid.Raw, idHash)
+
+	if err := hot.Put(ctx, hotBlk); err != nil {
+		t.Fatal(err)
+	}
+	if err := cold.Put(ctx, coldBlk); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	cids := []cid.Cid{coldBlk.Cid(), missing.Cid(), idCid, hotBlk.Cid()}
+	expected := []bool{true, false, true, true}
+
+	for _, bs := range []interface {
+		HasMany(context.Context, []cid.Cid) ([]bool, error)
+		GetMany(context.Context, []cid.Cid) ([]blocks.Block, error)
+	}{ss, ss.Expose()} {
+		has, err := bs.HasMany(ctx, cids)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		blks, err := bs.GetMany(ctx, cids)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, c := range cids {
+			if has[i] != expected[i] {
+				t.Fatalf("expected HasMany to return %t for %s", expected[i], c)
+			}
+			if (blks[i] != nil) != expected[i] {
+				t.Fatalf("expected GetMany to find %s: %t", c, expected[i])
+			}
+			if blks[i] != nil && !blks[i].Cid().Equals(c) {
+				t.Fatalf("GetMany returned the wrong block for %s", c)
+			}
+		}
+
+		if string(blks[2].RawData()) != "identity" {
+			t.Fatal("identity cid was not decoded")
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package splitstore
+
+import (
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// Compaction strategies, deciding when compaction runs and which objects it evicts from the
+// hotstore.
+const (
+	// CompactEpoch compacts every CompactionThreshold epochs, evicting the objects which are not
+	// reachable from the chain within the compaction boundary.
+	CompactEpoch = "epoch"
+	// CompactCapacity compacts when the hotstore grows beyond HotStoreCapacity bytes, whatever the
+	// number of epochs since the last compaction, keeping the most recently accessed objects, in
+	// addition to the objects reachable from the chain within the compaction boundary, until the
+	// capacity is filled; accessed objects which were read from the coldstore are promoted.
+	CompactCapacity = "capacity"
+	// CompactRefCount compacts every CompactionThreshold epochs like CompactEpoch, but tracks the
+	// reference count of every hot object instead of walking the chain: the headers below the
+	// compaction boundary release their state, and the objects left without references are
+	// evicted.
+	CompactRefCount = "refcount"
+)
+
+// AccessTrackerSize is the number of recently accessed objects tracked by the capacity
+// compaction strategy.
+var AccessTrackerSize = 1 << 20
+
+func checkCompactionStrategy(cfg *Config, hot bstore.Blockstore) error {
+	switch cfg.CompactionStrategy {
+	case "", CompactEpoch:
+		return nil
+	case CompactCapacity:
+		if cfg.HotStoreCapacity == 0 {
+			return xerrors.Errorf("the %q compaction strategy needs a hotstore capacity", CompactCapacity)
+		}
+		// evicted objects can be read again, so they must all be kept in the coldstore
+		if !cfg.UniversalColdBlocks {
+			return xerrors.Errorf("the %q compaction strategy requires a universal coldstore", CompactCapacity)
+		}
+		if _, ok := hot.(bstore.BlockstoreSize); !ok {
+			return xerrors.Errorf("the %q compaction strategy requires a hotstore reporting its size: %T", CompactCapacity, hot)
+		}
+		return nil
+	case CompactRefCount:
+		// evicted objects aren't told apart by a chain walk, so they are either all moved or
+		// all discarded
+		if !cfg.UniversalColdBlocks && !cfg.DiscardColdBlocks {
+			return xerrors.Errorf("the %q compaction strategy requires a universal or discard coldstore", CompactRefCount)
+		}
+		if cfg.EphemeralHotStore {
+			return xerrors.Errorf("the %q compaction strategy can't track the references of an ephemeral hotstore", CompactRefCount)
+		}
+		if cfg.ColdArchivePath != "" {
+			return xerrors.Errorf("the %q compaction strategy doesn't write cold archives", CompactRefCount)
+		}
+		return nil
+	default:
+		return xerrors.Errorf("unknown compaction strategy %q", cfg.CompactionStrategy)
+	}
+}
+
+// trackAccess records an access to an object for the capacity compaction strategy.
+func (s *SplitStore) trackAccess(c cid.Cid) {
+	if s.access == nil || isUnitaryObject(c) {
+		return
+	}
+
+	s.access.Add(c, struct{}{})
+}
+
+// isOverCapacity returns whether the hotstore has grown beyond its capacity.
+func (s *SplitStore) isOverCapacity() bool {
+	size, err := s.hot.(bstore.BlockstoreSize).Size()
+	if err != nil {
+		log.Warnf("error measuring hotstore size: %s", err)
+		return false
+	}
+
+	return size > int64(s.config().HotStoreCapacity)
+}
+
+// markRecentlyAccessed marks the most recently accessed objects, which are not reachable from
+// the chain within the compaction boundary, until the hotstore capacity is filled; it must be
+// called after the chain walk, which measures the size of the objects it marks. The objects
+// which were read from the coldstore are promoted to the hotstore, after marking them so that
+// this compaction doesn't purge them.
+func (s *SplitStore) markRecentlyAccessed(markSet MarkSet) error {
+	budget := int64(s.config().HotStoreCapacity) - s.szWalk
+	if budget <= 0 {
+		log.Warnw("objects reachable from the chain fill the hotstore capacity", "walk size", s.szWalk)
+		return nil
+	}
+
+	start := time.Now()
+	var count, promoted, size int64
+
+	// keys are ordered from the least to the most recently accessed
+	keys := s.access.Keys()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		c := keys[i]
+		mark, err := markSet.Has(c)
+		if err != nil {
+			return xerrors.Errorf("error checking markset: %w", err)
+		}
+		if mark {
+			continue
+		}
+
+		var blk blocks.Block
+		sz, err := s.hot.GetSize(s.ctx, c)
+		switch {
+		case err == nil:
+		case ipld.IsNotFound(err):
+			// evicted or never promoted, and read from the coldstore since
+			blk, err = s.cold.Get(s.ctx, c)
+			if ipld.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return xerrors.Errorf("error retrieving cold object %s: %w", c, err)
+			}
+			sz = len(blk.RawData())
+		default:
+			return xerrors.Errorf("error retrieving size of %s: %w", c, err)
+		}
+
+		if size+int64(sz) > budget {
+			break
+		}
+
+		if err := markSet.Mark(c); err != nil {
+			return xerrors.Errorf("error marking %s: %w", c, err)
+		}
+		if blk != nil {
+			if err := s.hot.Put(s.ctx, blk); err != nil {
+				return xerrors.Errorf("error promoting %s: %w", c, err)
+			}
+			promoted++
+		}
+		count++
+		size += int64(sz)
+	}
+
+	s.szMarkedLiveRefs += size
+	log.Infow("marking recently accessed objects done", "took", time.Since(start), "marked", count, "promoted", promoted, "size", size, "budget", budget)
+	return nil
+}
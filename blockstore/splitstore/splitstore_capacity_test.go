@@ -0,0 +1,145 @@
+package splitstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+)
+
+func TestSplitStoreCapacityStrategyConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"default", Config{}, true},
+		{"epoch", Config{CompactionStrategy: CompactEpoch}, true},
+		{"capacity", Config{CompactionStrategy: CompactCapacity, HotStoreCapacity: 1 << 30, UniversalColdBlocks: true}, true},
+		{"no capacity", Config{CompactionStrategy: CompactCapacity, UniversalColdBlocks: true}, false},
+		{"no universal coldstore", Config{CompactionStrategy: CompactCapacity, HotStoreCapacity: 1 << 30}, false},
+		{"unknown", Config{CompactionStrategy: "height"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hot, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer hot.Close() //nolint:errcheck
+
+			tc.cfg.MarkSetType = "map"
+			ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, bstore.NewMemory(), &tc.cfg)
+			if tc.ok != (err == nil) {
+				t.Fatalf("expected ok=%t, got error %v", tc.ok, err)
+			}
+			if err == nil {
+				_ = ss.Close()
+			}
+		})
+	}
+
+	// the capacity strategy needs to measure the hotstore
+	cfg := &Config{MarkSetType: "map", CompactionStrategy: CompactCapacity, HotStoreCapacity: 1 << 30, UniversalColdBlocks: true}
+	_, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), bstore.NewMemory(), bstore.NewMemory(), cfg)
+	if err == nil {
+		t.Fatal("expected an error opening a capacity splitstore with an unmeasurable hotstore")
+	}
+}
+
+func TestSplitStoreMarkRecentlyAccessed(t *testing.T) {
+	ctx := context.Background()
+
+	hot, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hot.Close() //nolint:errcheck
+
+	// 10 objects of 100 bytes each
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("%0100d", i))))
+	}
+	if err := hot.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		MarkSetType:         "map",
+		UniversalColdBlocks: true,
+		CompactionStrategy:  CompactCapacity,
+		HotStoreCapacity:    500,
+	}
+	// an object evicted to the coldstore
+	cold := bstore.NewMemory()
+	evicted := blocks.NewBlock([]byte(fmt.Sprintf("%0100d", 10)))
+	if err := cold.Put(ctx, evicted); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	// read all objects, then read the first three again, making them the most recent, and
+	// then the evicted object
+	for _, blk := range append(append(blks, blks[:3]...), evicted) {
+		if _, err := ss.Get(ctx, blk.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	markSet, err := ss.markSetEnv.New("live", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer markSet.Close() //nolint:errcheck
+
+	// object 9 is reachable from the chain and fills 100 bytes of the capacity
+	if err := markSet.Mark(blks[9].Cid()); err != nil {
+		t.Fatal(err)
+	}
+	ss.szWalk = 100
+
+	if err := ss.markRecentlyAccessed(markSet); err != nil {
+		t.Fatal(err)
+	}
+
+	// the remaining 400 bytes keep the evicted object and objects 2, 1 and 0, in order of
+	// recency
+	for i, blk := range blks {
+		expected := i <= 2 || i == 9
+		mark, err := markSet.Has(blk.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mark != expected {
+			t.Fatalf("object %d: expected marked=%t", i, expected)
+		}
+	}
+
+	// the evicted object is promoted back to the hotstore
+	mark, err := markSet.Has(evicted.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	has, err := hot.Has(ctx, evicted.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mark || !has {
+		t.Fatalf("evicted object was not kept: marked=%t, hot=%t", mark, has)
+	}
+
+	if ss.szMarkedLiveRefs != 400 {
+		t.Fatalf("expected 400 bytes marked, got %d", ss.szMarkedLiveRefs)
+	}
+}
@@ -1,6 +1,7 @@
 package splitstore
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,10 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
+// ErrMissingReferences is reported by the health check when objects reachable from the chain
+// are missing in the blockstore.
+var ErrMissingReferences = errors.New("missing object references")
+
 // performs an asynchronous health-check on the splitstore; results are appended to
 // <splitstore-path>/check.txt
 func (s *SplitStore) Check() error {
@@ -48,10 +53,12 @@ func (s *SplitStore) Check() error {
 		err := s.doCheck(curTs)
 		if err != nil {
 			log.Errorf("error checking splitstore health: %s", err)
+			s.recordResult("check", err)
 			return
 		}
 
 		log.Infow("health check done", "took", time.Since(start))
+		s.recordResult("check", nil)
 	}()
 
 	return nil
@@ -138,6 +145,10 @@ func (s *SplitStore) doCheck(curTs *types.TipSet) error {
 	write("cold: %d missing: %d", *coldCnt, *missingCnt)
 	write("DONE")
 
+	if *missingCnt > 0 {
+		return xerrors.Errorf("%d %w", *missingCnt, ErrMissingReferences)
+	}
+
 	return nil
 }
 
@@ -149,6 +160,9 @@ func (s *SplitStore) Info() map[string]interface{} {
 	info["compactions"] = s.compactionIndex
 	info["prunes"] = s.pruneIndex
 	info["compacting"] = s.compacting == 1
+	if phase, _ := s.compactionPhase(); phase != "" {
+		info["compaction phase"] = phase
+	}
 
 	sizer, ok := s.hot.(bstore.BlockstoreSize)
 	if ok {
@@ -160,5 +174,26 @@ func (s *SplitStore) Info() map[string]interface{} {
 		}
 	}
 
+	count, size, err := s.EstimateKeys(s.ctx)
+	if err != nil {
+		// not all coldstores support estimates
+		log.Debugf("error estimating objects: %s", err)
+	} else {
+		info["estimated objects"] = count
+		info["estimated size"] = size
+	}
+
+	s.errMx.Lock()
+	if s.lastErr != nil {
+		info["last error"] = fmt.Sprintf("%s: %s", s.lastErrOp, s.lastErr)
+		info["last error time"] = s.lastErrAt
+	}
+	s.errMx.Unlock()
+
+	if s.diskSpaceWatchdogEnabled() {
+		info["disk space"] = diskSpaceStateNames[atomic.LoadInt32(&s.diskState)]
+		info["disk free"] = atomic.LoadUint64(&s.diskFree)
+	}
+
 	return info
 }
@@ -13,7 +13,6 @@ import (
 	"github.com/ipfs/go-cid"
 	ipld "github.com/ipfs/go-ipld-format"
 	blocks "github.com/ipfs/go-libipfs/blocks"
-	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.opencensus.io/stats"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
@@ -116,7 +115,20 @@ func (s *SplitStore) HeadChange(_, apply []*types.TipSet) error {
 		return nil
 	}
 
-	if epoch-s.baseEpoch > CompactionThreshold {
+	if s.isDiskSpaceLow() {
+		// we are running out of disk space, suppress compaction until space is freed
+		atomic.StoreInt32(&s.compacting, 0)
+		return nil
+	}
+
+	shouldCompact := epoch-s.baseEpoch > CompactionThreshold
+	if s.config().CompactionStrategy == CompactCapacity {
+		// compaction evicts objects whenever the hotstore has grown beyond its capacity; the
+		// compaction boundary must still be past the last one
+		shouldCompact = epoch-s.baseEpoch > CompactionBoundary && s.isOverCapacity()
+	}
+
+	if shouldCompact {
 		// it's time to compact -- prepare the transaction and go!
 		s.beginTxnProtect()
 		s.compactType = hot
@@ -431,6 +443,12 @@ func (s *SplitStore) doTxnProtect(root cid.Cid, markSet MarkSet) (int64, error)
 		return 0, err
 	}
 
+	// with the refcount strategy, objects are only purged once nothing links to them, so the
+	// DAG of a protected object is protected by its references
+	if s.refs != nil {
+		return 0, markSet.Mark(root)
+	}
+
 	// Note: cold objects are deleted heaviest first, so the consituents of an object
 	// cannot be deleted before the object itself.
 	return s.walkObjectIncomplete(root, newTmpVisitor(),
@@ -503,14 +521,27 @@ func (s *SplitStore) compact(curTs *types.TipSet) {
 	s.viewWait()
 	log.Infow("waiting for active views done", "took", time.Since(start))
 
+	s.beginCompactionProfile()
+
 	start = time.Now()
-	err := s.doCompact(curTs)
+	var err error
+	if s.refs != nil {
+		err = s.doCompactRefs(curTs)
+	} else {
+		err = s.doCompact(curTs)
+	}
 	took := time.Since(start).Milliseconds()
+	s.setCompactionPhase("")
+
+	s.endCompactionProfile()
 	stats.Record(s.ctx, metrics.SplitstoreCompactionTimeSeconds.M(float64(took)/1e3))
 
 	if err != nil {
 		log.Errorf("COMPACTION ERROR: %s", err)
 	}
+	s.recordResult("compaction", err)
+
+	s.uploadColdArchives()
 }
 
 func (s *SplitStore) doCompact(curTs *types.TipSet) error {
@@ -525,12 +556,13 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	boundaryEpoch := currentEpoch - CompactionBoundary
 
 	var inclMsgsEpoch abi.ChainEpoch
-	inclMsgsRange := abi.ChainEpoch(s.cfg.HotStoreMessageRetention) * build.Finality
+	inclMsgsRange := abi.ChainEpoch(s.config().HotStoreMessageRetention) * finality
 	if inclMsgsRange < boundaryEpoch {
 		inclMsgsEpoch = boundaryEpoch - inclMsgsRange
 	}
 
 	log.Infow("running compaction", "currentEpoch", currentEpoch, "baseEpoch", s.baseEpoch, "boundaryEpoch", boundaryEpoch, "inclMsgsEpoch", inclMsgsEpoch, "compactionIndex", s.compactionIndex)
+	s.debug.LogCompaction(curTs)
 
 	markSet, err := s.markSetEnv.New("live", s.markSetSize)
 	if err != nil {
@@ -539,6 +571,9 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	defer markSet.Close() //nolint:errcheck
 	defer s.debug.Flush()
 
+	s.setLiveMarkSet(markSet)
+	defer s.setLiveMarkSet(nil)
+
 	coldSet, err := s.markSetEnv.New("cold", s.markSetSize)
 	if err != nil {
 		return xerrors.Errorf("error creating cold mark set: %w", err)
@@ -551,6 +586,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 
 	// 0. track all protected references at beginning of compaction; anything added later should
 	//    be transactionally protected by the write
+	s.setCompactionPhase("protecting")
 	log.Info("protecting references with registered protectors")
 	err = s.applyProtectors()
 	if err != nil {
@@ -559,6 +595,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 
 	// 1. mark reachable objects by walking the chain from the current epoch; we keep state roots
 	//   and messages until the boundary epoch.
+	s.setCompactionPhase("marking")
 	log.Info("marking reachable objects")
 	startMark := time.Now()
 
@@ -570,7 +607,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		//
 		// Nothing gets written to cold store in discard mode so no cold objects to write
 		// Everything not marked hot gets written to cold store in universal mode so no need to track cold objects separately
-		if s.cfg.DiscardColdBlocks || s.cfg.UniversalColdBlocks {
+		if s.config().DiscardColdBlocks || s.config().UniversalColdBlocks {
 			return nil
 		}
 
@@ -621,7 +658,20 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		return err
 	}
 
-	// 1.1 protect transactional refs
+	// 1.1 keep the recently accessed objects hot, as long as they fit the hotstore capacity
+	if s.config().CompactionStrategy == CompactCapacity {
+		log.Info("marking recently accessed objects")
+		if err := s.markRecentlyAccessed(markSet); err != nil {
+			return xerrors.Errorf("error marking recently accessed objects: %w", err)
+		}
+	}
+
+	// 1.2 keep the objects promoted from the coldstore since the last compaction hot
+	if err := s.markPromoted(markSet); err != nil {
+		return xerrors.Errorf("error marking promoted objects: %w", err)
+	}
+
+	// 1.3 protect transactional refs
 	err = s.protectTxnRefs(markSet)
 	if err != nil {
 		return xerrors.Errorf("error protecting transactional refs: %w", err)
@@ -632,6 +682,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	}
 
 	// 2. iterate through the hotstore to collect cold objects
+	s.setCompactionPhase("collecting")
 	log.Info("collecting cold objects")
 	startCollect := time.Now()
 
@@ -675,7 +726,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		// Discard mode: coldMark == false, s.cfg.UniversalColdBlocks == false, always return here, no writes to cold store
 		// Universal mode: coldMark == false, s.cfg.UniversalColdBlocks == true, never stop here, all writes to cold store
 		// Otherwise: s.cfg.UniversalColdBlocks == false, if !coldMark stop here and don't write to cold store, if coldMark continue and write to cold store
-		if !coldMark && !s.cfg.UniversalColdBlocks { // universal mode means mark everything as cold
+		if !coldMark && !s.config().UniversalColdBlocks { // universal mode means mark everything as cold
 			return nil
 		}
 
@@ -724,7 +775,8 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	defer coldr.Close() //nolint:errcheck
 
 	// 3. copy the cold objects to the coldstore -- if we have one
-	if !s.cfg.DiscardColdBlocks {
+	if !s.config().DiscardColdBlocks {
+		s.setCompactionPhase("moving")
 		log.Info("moving cold objects to the coldstore")
 		startMove := time.Now()
 		err = s.moveColdBlocks(coldr)
@@ -742,12 +794,34 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		}
 	}
 
+	// 3.1 archive the cold objects -- if configured; failing to archive doesn't fail compaction
+	if s.config().ColdArchivePath != "" {
+		s.setCompactionPhase("archiving")
+		log.Info("archiving cold objects")
+		startArchive := time.Now()
+		if err := s.archiveColdBlocks(coldr, curTs); err != nil {
+			log.Warnf("error archiving cold objects: %s", err)
+		} else {
+			log.Infow("archiving done", "took", time.Since(startArchive))
+		}
+
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		if err := coldr.Reset(); err != nil {
+			return xerrors.Errorf("error resetting coldset: %w", err)
+		}
+	}
+
 	purger, err := NewColdSetReader(s.discardSetPath())
 	if err != nil {
 		return xerrors.Errorf("error opening coldset: %w", err)
 	}
 	defer purger.Close() //nolint:errcheck
 
+	s.profileCompaction("middle")
+
 	// 4. Purge cold objects with checkpointing for recovery.
 	// This is the critical section of compaction, whereby any cold object not in the markSet is
 	// considered already deleted.
@@ -755,6 +829,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	// again for new references created by the VM.
 	// After each batch, we write a checkpoint to disk; if the process is interrupted before completion,
 	// the process will continue from the checkpoint in the next recovery.
+	s.setCompactionPhase("purging")
 	if err := s.beginCriticalSection(markSet); err != nil {
 		return xerrors.Errorf("error beginning critical section: %w", err)
 	}
@@ -805,6 +880,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 
 	// we are done; do some housekeeping
 	s.endTxnProtect()
+	s.setCompactionPhase("gc")
 	s.gcHotAfterCompaction()
 
 	err = s.setBaseEpoch(boundaryEpoch)
@@ -1113,7 +1189,7 @@ func (s *SplitStore) walkObject(c cid.Cid, visitor ObjectVisitor, f func(cid.Cid
 	var links []cid.Cid
 	err = s.view(c, func(data []byte) error {
 		sz += int64(len(data))
-		return cbg.ScanForLinks(bytes.NewReader(data), func(c cid.Cid) {
+		return scanLinks(data, func(c cid.Cid) {
 			links = append(links, c)
 		})
 	})
@@ -1182,7 +1258,7 @@ func (s *SplitStore) walkObjectIncomplete(c cid.Cid, visitor ObjectVisitor, f, m
 	var links []cid.Cid
 	err = s.view(c, func(data []byte) error {
 		sz += int64(len(data))
-		return cbg.ScanForLinks(bytes.NewReader(data), func(c cid.Cid) {
+		return scanLinks(data, func(c cid.Cid) {
 			links = append(links, c)
 		})
 	})
@@ -1259,30 +1335,19 @@ func (s *SplitStore) getSize(c cid.Cid) (int, error) {
 }
 
 func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
-	batch := make([]blocks.Block, 0, batchSize)
+	batch := make([]cid.Cid, 0, batchSize)
 
 	err := coldr.ForEach(func(c cid.Cid) error {
 		if err := s.checkClosing(); err != nil {
 			return err
 		}
-		blk, err := s.hot.Get(s.ctx, c)
-		if err != nil {
-			if ipld.IsNotFound(err) {
-				log.Warnf("hotstore missing block %s", c)
-				return nil
-			}
-
-			return xerrors.Errorf("error retrieving block %s from hotstore: %w", c, err)
-		}
 
-		batch = append(batch, blk)
+		batch = append(batch, c)
 		if len(batch) == batchSize {
-			err = s.cold.PutMany(s.ctx, batch)
-			if err != nil {
-				return xerrors.Errorf("error putting batch to coldstore: %w", err)
+			if err := s.moveColdBatch(batch); err != nil {
+				return err
 			}
 			batch = batch[:0]
-
 		}
 
 		return nil
@@ -1293,10 +1358,54 @@ func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
 	}
 
 	if len(batch) > 0 {
-		err := s.cold.PutMany(s.ctx, batch)
+		return s.moveColdBatch(batch)
+	}
+
+	return nil
+}
+
+// moveColdBatch copies a batch of cold objects from the hotstore to the coldstore, reading
+// them with a single call.
+func (s *SplitStore) moveColdBatch(batch []cid.Cid) error {
+	// objects written through to the coldstore don't have to be moved
+	if s.config().EphemeralHotStore {
+		has, err := s.cold.HasMany(s.ctx, batch)
 		if err != nil {
-			return xerrors.Errorf("error putting batch to coldstore: %w", err)
+			return xerrors.Errorf("error checking coldstore for batch: %w", err)
+		}
+
+		missing := make([]cid.Cid, 0, len(batch))
+		for i, c := range batch {
+			if !has[i] {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		batch = missing
+	}
+
+	blks, err := s.hot.GetMany(s.ctx, batch)
+	if err != nil {
+		return xerrors.Errorf("error retrieving batch from hotstore: %w", err)
+	}
+
+	found := blks[:0]
+	for i, blk := range blks {
+		if blk == nil {
+			log.Warnf("hotstore missing block %s", batch[i])
+			continue
 		}
+		found = append(found, blk)
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	if err := s.cold.PutMany(s.ctx, found); err != nil {
+		return xerrors.Errorf("error putting batch to coldstore: %w", err)
 	}
 
 	return nil
@@ -1385,6 +1494,9 @@ func (s *SplitStore) purgeBatch(batch, deadCids []cid.Cid, checkpoint *Checkpoin
 	}
 
 	s.debug.LogDelete(deadCids)
+	if s.purgeHook != nil {
+		s.purgeHook(deadCids)
+	}
 	purgeCnt = len(deadCids)
 
 	if err := checkpoint.Set(batch[len(batch)-1]); err != nil {
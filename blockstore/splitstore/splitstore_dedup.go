@@ -0,0 +1,82 @@
+package splitstore
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// DedupColdStore rewrites the coldstore compactly, without the redundant copies of objects which
+// were written to it more than once, e.g. by successive compactions moving the same objects or
+// by writes through the universal blockstore, and reports the space reclaimed. The coldstore must
+// support deduplication, as badger does.
+// Compaction and prune are inhibited while the coldstore is rewritten, which may take as long as
+// a moving GC of the coldstore, and needs as much free disk space as the deduplicated coldstore.
+func (s *SplitStore) DedupColdStore(ctx context.Context) (api.ColdDedupResult, error) {
+	var res api.ColdDedupResult
+
+	deduper, ok := s.cold.(bstore.BlockstoreDedup)
+	if !ok {
+		return res, xerrors.Errorf("coldstore does not support deduplication: %T", s.cold)
+	}
+
+	// take the compaction lock; fail if there is a compaction in progress
+	s.headChangeMx.Lock()
+	if !atomic.CompareAndSwapInt32(&s.compacting, 0, 1) {
+		s.headChangeMx.Unlock()
+		return res, xerrors.Errorf("compaction, prune or warmup in progress")
+	}
+	s.compactType = dedup
+	s.headChangeMx.Unlock()
+	defer atomic.StoreInt32(&s.compacting, 0)
+
+	if err := s.checkClosing(); err != nil {
+		return res, err
+	}
+
+	// the coldstore is copied while it is rewritten
+	if s.isDiskSpaceLow() {
+		return res, xerrors.Errorf("splitstore is low on disk space; coldstore deduplication paused")
+	}
+
+	// abort when the splitstore is closed after the shutdown grace period
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.Info("deduplicating coldstore")
+	s.setCompactionPhase("dedup")
+	start := time.Now()
+
+	stats, err := deduper.Dedup(ctx)
+	s.setCompactionPhase("")
+	s.recordResult("dedup", err)
+	if err != nil {
+		return res, xerrors.Errorf("error deduplicating coldstore: %w", err)
+	}
+
+	res = api.ColdDedupResult{
+		Objects:       stats.Objects,
+		Duplicates:    stats.Duplicates,
+		DuplicateSize: stats.DuplicateSize,
+		SizeBefore:    stats.SizeBefore,
+		SizeAfter:     stats.SizeAfter,
+	}
+
+	log.Infow("deduplicating coldstore done", "took", time.Since(start),
+		"objects", res.Objects, "duplicates", res.Duplicates, "duplicate size", res.DuplicateSize,
+		"size before", res.SizeBefore, "size after", res.SizeAfter)
+
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package splitstore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+)
+
+func TestSplitStoreDedupColdStore(t *testing.T) {
+	ctx := context.Background()
+
+	hot, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hot.Close() //nolint:errcheck
+
+	cold, err := badgerbs.Open(badgerbs.DefaultOptions(filepath.Join(t.TempDir(), "cold")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cold.Close() //nolint:errcheck
+
+	// the same objects moved to the coldstore by two compactions
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("cold object %d", i))))
+	}
+	for i := 0; i < 2; i++ {
+		if err := cold.PutMany(ctx, blks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint:errcheck
+
+	// deduplication doesn't run concurrently with compaction
+	atomic.StoreInt32(&ss.compacting, 1)
+	if _, err := ss.DedupColdStore(ctx); err == nil {
+		t.Fatal("expected deduplication to fail while compacting")
+	}
+	atomic.StoreInt32(&ss.compacting, 0)
+
+	res, err := ss.DedupColdStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Objects != 10 || res.Duplicates != 10 {
+		t.Fatalf("expected 10 objects and 10 duplicates, got %d objects and %d duplicates", res.Objects, res.Duplicates)
+	}
+	if atomic.LoadInt32(&ss.compacting) != 0 {
+		t.Fatal("expected the compaction lock to be released")
+	}
+
+	for _, blk := range blks {
+		if _, err := ss.Get(ctx, blk.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the coldstore must support deduplication
+	ss2, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, bstore.NewMemory(), &Config{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss2.Close() //nolint:errcheck
+
+	if _, err := ss2.DedupColdStore(ctx); err == nil {
+		t.Fatal("expected an error deduplicating a memory coldstore")
+	}
+}
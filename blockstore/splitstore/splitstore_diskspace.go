@@ -0,0 +1,132 @@
+package splitstore
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+)
+
+var (
+	// DiskSpaceCheckInterval is the interval at which the free disk space of the hotstore
+	// and coldstore paths is checked.
+	DiskSpaceCheckInterval = 10 * time.Second
+
+	// ErrDiskSpaceCritical is returned by non-consensus writes when the splitstore is
+	// critically low on disk space.
+	ErrDiskSpaceCritical = errors.New("splitstore is critically low on disk space; refusing write")
+)
+
+const (
+	diskSpaceOK int32 = iota
+	diskSpaceLow
+	diskSpaceCritical
+)
+
+var diskSpaceStateNames = map[int32]string{
+	diskSpaceOK:       "ok",
+	diskSpaceLow:      "low",
+	diskSpaceCritical: "critical",
+}
+
+// diskSpaceWatchdogEnabled returns true when at least one disk space threshold is configured.
+func (s *SplitStore) diskSpaceWatchdogEnabled() bool {
+	cfg := s.config()
+	return cfg.DiskSpaceLowThreshold > 0 || cfg.DiskSpaceCriticalThreshold > 0
+}
+
+// diskSpaceWatchdog periodically checks the free disk space until the splitstore is closed.
+func (s *SplitStore) diskSpaceWatchdog() {
+	ticker := time.NewTicker(DiskSpaceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !s.diskSpaceWatchdogEnabled() {
+				// the thresholds may have been removed at runtime
+				atomic.StoreInt32(&s.diskState, diskSpaceOK)
+				continue
+			}
+			s.checkDiskSpace()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkDiskSpace updates the disk space state from the least free space among the hotstore
+// and coldstore paths; state transitions are reported as results of the "disk-space" operation.
+func (s *SplitStore) checkDiskSpace() {
+	cfg := s.config()
+
+	paths := []string{s.path}
+	if cfg.HotStorePath != "" {
+		paths[0] = cfg.HotStorePath
+	}
+	if cfg.ColdStorePath != "" {
+		paths = append(paths, cfg.ColdStorePath)
+	}
+
+	var minFree uint64
+	var minPath string
+	for i, path := range paths {
+		st, err := fsutil.Statfs(path)
+		if err != nil {
+			log.Warnf("error checking disk space of %s: %s", path, err)
+			return
+		}
+
+		free := uint64(0)
+		if st.FSAvailable > 0 {
+			free = uint64(st.FSAvailable)
+		}
+		if i == 0 || free < minFree {
+			minFree = free
+			minPath = path
+		}
+	}
+
+	state := diskSpaceOK
+	switch {
+	case cfg.DiskSpaceCriticalThreshold > 0 && minFree < cfg.DiskSpaceCriticalThreshold:
+		state = diskSpaceCritical
+	case cfg.DiskSpaceLowThreshold > 0 && minFree < cfg.DiskSpaceLowThreshold:
+		state = diskSpaceLow
+	}
+
+	atomic.StoreUint64(&s.diskFree, minFree)
+	prev := atomic.SwapInt32(&s.diskState, state)
+	if prev == state {
+		return
+	}
+
+	switch state {
+	case diskSpaceOK:
+		log.Infow("disk space recovered; resuming compaction", "path", minPath, "free", minFree)
+		s.recordResult("disk-space", nil)
+	case diskSpaceLow:
+		log.Warnw("disk space is low; pausing compaction", "path", minPath, "free", minFree)
+		s.recordResult("disk-space", xerrors.Errorf("%s is low on disk space (%d bytes free); compaction paused", minPath, minFree))
+	case diskSpaceCritical:
+		log.Errorw("disk space is critically low; pausing compaction and refusing non-consensus writes", "path", minPath, "free", minFree)
+		s.recordResult("disk-space", xerrors.Errorf("%s is critically low on disk space (%d bytes free); compaction paused and non-consensus writes refused", minPath, minFree))
+	}
+}
+
+// isDiskSpaceLow returns true when compaction should be paused because of low disk space.
+func (s *SplitStore) isDiskSpaceLow() bool {
+	return atomic.LoadInt32(&s.diskState) >= diskSpaceLow
+}
+
+// checkDiskSpaceWrite returns an error when non-consensus writes must be refused.
+func (s *SplitStore) checkDiskSpaceWrite() error {
+	if atomic.LoadInt32(&s.diskState) == diskSpaceCritical {
+		return ErrDiskSpaceCritical
+	}
+
+	return nil
+}
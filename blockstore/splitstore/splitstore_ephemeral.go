@@ -0,0 +1,128 @@
+package splitstore
+
+import (
+	"os"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+)
+
+// ephemeralHotStoreKey records that every object of the hotstore is also in the coldstore,
+// so that the hotstore can be lost and rebuilt from the coldstore.
+var ephemeralHotStoreKey = dstore.NewKey("/splitstore/ephemeralHotStore")
+
+// checkEphemeralHotStore prepares the splitstore for running with an ephemeral hotstore
+// and returns whether the hotstore has been lost, in which case it must be warmed up again.
+//
+// Objects are written through to the coldstore while the hotstore is ephemeral; when the
+// hotstore is first made ephemeral, its existing objects are copied to the coldstore.
+func (s *SplitStore) checkEphemeralHotStore() (lost bool, err error) {
+	if !s.config().EphemeralHotStore {
+		// objects written from now on only go to the hotstore
+		if err := s.ds.Delete(s.ctx, ephemeralHotStoreKey); err != nil {
+			return false, xerrors.Errorf("error clearing ephemeral hotstore flag: %w", err)
+		}
+		return false, nil
+	}
+
+	empty, err := s.hotStoreEmpty()
+	if err != nil {
+		return false, xerrors.Errorf("error checking whether the hotstore is empty: %w", err)
+	}
+
+	has, err := s.ds.Has(s.ctx, ephemeralHotStoreKey)
+	if err != nil {
+		return false, xerrors.Errorf("error loading ephemeral hotstore flag: %w", err)
+	}
+
+	if has {
+		// an empty hotstore which has been warmed up before was lost
+		return empty && s.isWarm(), nil
+	}
+
+	if !empty {
+		log.Info("copying the hotstore to the coldstore before making it ephemeral")
+		start := time.Now()
+		if err := s.copyHotToCold(); err != nil {
+			return false, xerrors.Errorf("error copying the hotstore to the coldstore: %w", err)
+		}
+		log.Infow("copying the hotstore done", "took", time.Since(start))
+	}
+
+	if err := s.ds.Put(s.ctx, ephemeralHotStoreKey, []byte{1}); err != nil {
+		return false, xerrors.Errorf("error saving ephemeral hotstore flag: %w", err)
+	}
+
+	return false, nil
+}
+
+func (s *SplitStore) hotStoreEmpty() (bool, error) {
+	empty := true
+	err := s.hot.ForEachKey(func(cid.Cid) error {
+		empty = false
+		return errStopWalk
+	})
+	if err != nil && err != errStopWalk {
+		return false, err
+	}
+
+	return empty, nil
+}
+
+// copyHotToCold writes the objects of the hotstore which are missing from the coldstore to
+// the coldstore; they are collected in a coldset first, as the hotstore can't be read while
+// iterating over it.
+func (s *SplitStore) copyHotToCold() error {
+	coldw, err := NewColdSetWriter(s.coldSetPath())
+	if err != nil {
+		return xerrors.Errorf("error creating coldset: %w", err)
+	}
+	defer coldw.Close() //nolint:errcheck
+
+	count := 0
+	err = s.hot.ForEachKey(func(c cid.Cid) error {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		has, err := s.cold.Has(s.ctx, c)
+		if err != nil {
+			return xerrors.Errorf("error checking coldstore for %s: %w", c, err)
+		}
+		if has {
+			return nil
+		}
+
+		count++
+		return coldw.Write(c)
+	})
+	if err != nil {
+		return xerrors.Errorf("error collecting hot objects: %w", err)
+	}
+
+	if err := coldw.Close(); err != nil {
+		return xerrors.Errorf("error closing coldset: %w", err)
+	}
+
+	coldr, err := NewColdSetReader(s.coldSetPath())
+	if err != nil {
+		return xerrors.Errorf("error opening coldset: %w", err)
+	}
+	defer coldr.Close() //nolint:errcheck
+
+	if err := s.moveColdBlocks(coldr); err != nil {
+		return err
+	}
+
+	if err := coldr.Close(); err != nil {
+		return xerrors.Errorf("error closing coldset: %w", err)
+	}
+	if err := os.Remove(s.coldSetPath()); err != nil {
+		log.Warnf("error removing coldset: %s", err)
+	}
+
+	log.Infow("copied hotstore objects to the coldstore", "count", count)
+	return nil
+}
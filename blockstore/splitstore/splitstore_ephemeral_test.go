@@ -0,0 +1,131 @@
+package splitstore
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestEphemeralHotStore(t *testing.T) {
+	ctx := context.Background()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	chain := &mockChain{t: t}
+	hot := newMockStore()
+	cold := newMockStore()
+
+	_, err := Open(t.TempDir(), ds, hot, cold, &Config{MarkSetType: "map", EphemeralHotStore: true})
+	if err == nil {
+		t.Fatal("expected an ephemeral hotstore without a universal coldstore to be refused")
+	}
+
+	garbage := blocks.NewBlock([]byte{1, 2, 3})
+	if err := cold.Put(ctx, garbage); err != nil {
+		t.Fatal(err)
+	}
+
+	mkBlock := func(parent *types.TipSet, i int, bs blockstore.Blockstore) *types.TipSet {
+		stateRoot := blocks.NewBlock([]byte{byte(i), 3, 3, 7})
+		blk := mock.MkBlock(parent, uint64(i), uint64(i))
+		blk.Messages = garbage.Cid()
+		blk.ParentMessageReceipts = garbage.Cid()
+		blk.ParentStateRoot = stateRoot.Cid()
+		blk.Timestamp = uint64(time.Now().Unix())
+
+		sblk, err := blk.ToStorageBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bs.PutMany(ctx, []blocks.Block{stateRoot, sblk}); err != nil {
+			t.Fatal(err)
+		}
+
+		ts := mock.TipSet(blk)
+		chain.push(ts)
+		return ts
+	}
+
+	waitForWarmup := func(ss *SplitStore) {
+		for atomic.LoadInt32(&ss.compacting) == 1 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if ss.lastErr != nil {
+			t.Fatal(ss.lastErr)
+		}
+		if !ss.isWarm() {
+			t.Fatal("expected the hotstore to be warm")
+		}
+	}
+
+	requireHas := func(bs blockstore.Blockstore, blk blocks.Block, what string) {
+		has, err := bs.Has(ctx, blk.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Fatalf("%s is missing %s", what, blk.Cid())
+		}
+	}
+
+	// the genesis is in the coldstore, and the rest of the chain only in the hotstore,
+	// which is made ephemeral
+	curTs := mkBlock(nil, 0, cold)
+	for i := 1; i < 4; i++ {
+		curTs = mkBlock(curTs, i, hot)
+	}
+	existing, err := curTs.Blocks()[0].ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{MarkSetType: "map", UniversalColdBlocks: true, EphemeralHotStore: true}
+	path := t.TempDir()
+
+	ss, err := Open(path, ds, hot, cold, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.Start(chain, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitForWarmup(ss)
+
+	requireHas(cold, existing, "coldstore")
+
+	// objects are written through to the coldstore
+	curTs = mkBlock(curTs, 4, ss)
+	head, err := curTs.Blocks()[0].ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireHas(hot, head, "hotstore")
+	requireHas(cold, head, "coldstore")
+
+	if err := ss.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the hotstore is lost on restart, and warmed up again from the coldstore
+	lost := newMockStore()
+	ss, err = Open(path, ds, lost, cold, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	if err := ss.Start(chain, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitForWarmup(ss)
+
+	requireHas(lost, head, "warmed up hotstore")
+	requireHas(lost, blocks.NewBlock([]byte{4, 3, 3, 7}), "warmed up hotstore")
+}
@@ -60,6 +60,64 @@ func (es *exposedSplitStore) Get(ctx context.Context, c cid.Cid) (blocks.Block,
 	return blk, err
 }
 
+func (es *exposedSplitStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res := make([]bool, len(cids))
+	for i, c := range cids {
+		res[i] = isIdentiyCid(c)
+	}
+
+	for _, bs := range []bstore.Blockstore{es.s.hot, es.s.cold} {
+		idx, query := bstore.MissingHas(cids, res)
+		if len(query) == 0 {
+			break
+		}
+
+		found, err := bs.HasMany(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range idx {
+			res[i] = found[j]
+		}
+	}
+
+	return res, nil
+}
+
+func (es *exposedSplitStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res := make([]blocks.Block, len(cids))
+	for i, c := range cids {
+		if !isIdentiyCid(c) {
+			continue
+		}
+
+		data, err := decodeIdentityCid(c)
+		if err != nil {
+			return nil, err
+		}
+		if res[i], err = blocks.NewBlockWithCid(data, c); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, bs := range []bstore.Blockstore{es.s.hot, es.s.cold} {
+		idx, query := bstore.MissingGet(cids, res)
+		if len(query) == 0 {
+			break
+		}
+
+		found, err := bs.GetMany(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range idx {
+			res[i] = found[j]
+		}
+	}
+
+	return res, nil
+}
+
 func (es *exposedSplitStore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
 	if isIdentiyCid(c) {
 		data, err := decodeIdentityCid(c)
@@ -82,10 +140,18 @@ func (es *exposedSplitStore) Flush(ctx context.Context) error {
 }
 
 func (es *exposedSplitStore) Put(ctx context.Context, blk blocks.Block) error {
+	if err := es.s.checkDiskSpaceWrite(); err != nil {
+		return err
+	}
+
 	return es.s.Put(ctx, blk)
 }
 
 func (es *exposedSplitStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	if err := es.s.checkDiskSpaceWrite(); err != nil {
+		return err
+	}
+
 	return es.s.PutMany(ctx, blks)
 }
 
@@ -93,6 +159,10 @@ func (es *exposedSplitStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, e
 	return es.s.AllKeysChan(ctx)
 }
 
+func (es *exposedSplitStore) EstimateKeys(ctx context.Context) (int64, int64, error) {
+	return es.s.EstimateKeys(ctx)
+}
+
 func (es *exposedSplitStore) HashOnRead(enabled bool) {}
 
 func (es *exposedSplitStore) View(ctx context.Context, c cid.Cid, f func([]byte) error) error {
@@ -0,0 +1,107 @@
+package splitstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	mh "github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// newFuzzSplitStore returns a splitstore that is just enough to walk the objects in
+// its hotstore.
+func newFuzzSplitStore() (*SplitStore, *mockStore) {
+	hot := newMockStore()
+	return &SplitStore{
+		ctx:  context.Background(),
+		hot:  hot,
+		cold: newMockStore(),
+	}, hot
+}
+
+func fuzzSeedHeader(f *testing.F) *types.BlockHeader {
+	f.Helper()
+
+	genBlock := mock.MkBlock(nil, 0, 0)
+	return mock.MkBlock(mock.TipSet(genBlock), 1, 1)
+}
+
+func fuzzSeeds(f *testing.F) {
+	hdr := fuzzSeedHeader(f)
+	data, err := hdr.Serialize()
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := cbg.WriteCid(&buf, hdr.Messages); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(data)
+	f.Add(data[:len(data)/2])
+	f.Add(buf.Bytes())
+	f.Add([]byte{})
+	// an array claiming more elements than there are bytes
+	f.Add([]byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	// a tag 42 link with garbage in place of the cid
+	f.Add([]byte{0xd8, 0x2a, 0x45, 0x00, 0x01, 0x02, 0x03, 0x04})
+}
+
+// FuzzWalkObject feeds arbitrary data to the link scanning of objects walked by
+// compaction; malformed objects must fail the walk, not crash it.
+func FuzzWalkObject(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s, hot := newFuzzSplitStore()
+
+		h, err := mh.Sum(data, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := cid.NewCidV1(cid.DagCBOR, h)
+		blk, err := blocks.NewBlockWithCid(data, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := hot.Put(s.ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+
+		noop := func(cid.Cid) error { return nil }
+		_, _ = s.walkObject(c, newTmpVisitor(), noop)
+		_, _ = s.walkObjectIncomplete(c, newTmpVisitor(), noop, noop)
+	})
+}
+
+// FuzzWalkChain feeds arbitrary data as the block header of the tipset a chain walk
+// starts from; malformed headers must fail the walk, not crash it.
+func FuzzWalkChain(f *testing.F) {
+	fuzzSeeds(f)
+
+	hdr := fuzzSeedHeader(f)
+	ts := mock.TipSet(hdr)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s, hot := newFuzzSplitStore()
+
+		// the mock store doesn't verify the data against the cid
+		blk, err := blocks.NewBlockWithCid(data, hdr.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := hot.Put(s.ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+
+		noop := func(cid.Cid) error { return nil }
+		_ = s.walkChain(ts, 0, 0, newTmpVisitor(), noop, noop)
+	})
+}
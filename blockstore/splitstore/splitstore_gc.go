@@ -39,11 +39,12 @@ func (s *SplitStore) gcHotAfterCompaction() {
 	}
 	hotSize := getSize()
 
+	cfg := s.config()
 	copySizeApprox := s.szKeys + s.szMarkedLiveRefs + s.szProtectedTxns + s.szWalk
-	shouldTarget := s.cfg.HotstoreMaxSpaceTarget > 0 && hotSize+copySizeApprox > int64(s.cfg.HotstoreMaxSpaceTarget)-int64(s.cfg.HotstoreMaxSpaceThreshold)
-	shouldFreq := s.cfg.HotStoreFullGCFrequency > 0 && s.compactionIndex%int64(s.cfg.HotStoreFullGCFrequency) == 0
+	shouldTarget := cfg.HotstoreMaxSpaceTarget > 0 && hotSize+copySizeApprox > int64(cfg.HotstoreMaxSpaceTarget)-int64(cfg.HotstoreMaxSpaceThreshold)
+	shouldFreq := cfg.HotStoreFullGCFrequency > 0 && s.compactionIndex%int64(cfg.HotStoreFullGCFrequency) == 0
 	shouldDoFull := shouldTarget || shouldFreq
-	canDoFull := s.cfg.HotstoreMaxSpaceTarget == 0 || hotSize+copySizeApprox < int64(s.cfg.HotstoreMaxSpaceTarget)-int64(s.cfg.HotstoreMaxSpaceSafetyBuffer)
+	canDoFull := cfg.HotstoreMaxSpaceTarget == 0 || hotSize+copySizeApprox < int64(cfg.HotstoreMaxSpaceTarget)-int64(cfg.HotstoreMaxSpaceSafetyBuffer)
 	log.Debugw("approximating new hot store size", "key size", s.szKeys, "marked live refs", s.szMarkedLiveRefs, "protected txns", s.szProtectedTxns, "walked DAG", s.szWalk)
 	log.Infof("measured hot store size: %d, approximate new size: %d, should do full %t, can do full %t", hotSize, copySizeApprox, shouldDoFull, canDoFull)
 
@@ -51,7 +52,7 @@ func (s *SplitStore) gcHotAfterCompaction() {
 	if shouldDoFull && canDoFull {
 		opts = append(opts, bstore.WithFullGC(true))
 	} else if shouldDoFull && !canDoFull {
-		log.Warnf("Attention! Estimated moving GC size %d is not within safety buffer %d of target max %d, performing aggressive online GC to attempt to bring hotstore size down safely", copySizeApprox, s.cfg.HotstoreMaxSpaceSafetyBuffer, s.cfg.HotstoreMaxSpaceTarget)
+		log.Warnf("Attention! Estimated moving GC size %d is not within safety buffer %d of target max %d, performing aggressive online GC to attempt to bring hotstore size down safely", copySizeApprox, cfg.HotstoreMaxSpaceSafetyBuffer, cfg.HotstoreMaxSpaceTarget)
 		log.Warn("If problem continues you can 1) temporarily allocate more disk space to hotstore and 2) reflect in HotstoreMaxSpaceTarget OR trigger manual move with `lotus chain prune hot-moving`")
 		log.Warn("If problem continues and you do not have any more disk space you can run continue to manually trigger online GC at aggressive thresholds (< 0.01) with `lotus chain prune hot`")
 
@@ -0,0 +1,72 @@
+package splitstore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"golang.org/x/xerrors"
+)
+
+func (s *SplitStore) setLiveMarkSet(markSet MarkSet) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.liveMarkSet = markSet
+}
+
+// PendingWrites returns the objects written or referenced during the running
+// compaction which are yet to be protected from purging by marking; it is empty
+// outside compactions.
+func (s *SplitStore) PendingWrites() []cid.Cid {
+	s.txnRefsMx.Lock()
+	defer s.txnRefsMx.Unlock()
+
+	res := make([]cid.Cid, 0, len(s.txnRefs))
+	for c := range s.txnRefs {
+		res = append(res, c)
+	}
+
+	return res
+}
+
+// DumpMarkSet writes the multihashes of the objects marked so far by the running
+// compaction to the file at path, one per line in base58, in no particular order.
+// Marking blocks while the dump is in progress.
+func (s *SplitStore) DumpMarkSet(path string) error {
+	s.mx.Lock()
+	markSet := s.liveMarkSet
+	s.mx.Unlock()
+
+	if markSet == nil {
+		return xerrors.Errorf("no compaction in progress")
+	}
+
+	iter, ok := markSet.(MarkSetIterator)
+	if !ok {
+		return xerrors.Errorf("markset of type %T can't be dumped", markSet)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return xerrors.Errorf("error creating markset dump: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	w := bufio.NewWriter(f)
+	err = iter.ForEach(func(h mh.Multihash) error {
+		_, err := fmt.Fprintln(w, h.B58String())
+		return err
+	})
+	if err != nil {
+		return xerrors.Errorf("error dumping markset: %w", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return xerrors.Errorf("error flushing markset dump: %w", err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,146 @@
+package splitstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+)
+
+func TestSplitStoreAllKeys(t *testing.T) {
+	t.Run("memory coldstore", func(t *testing.T) {
+		testSplitStoreAllKeys(t, bstore.NewMemory())
+	})
+	t.Run("badger coldstore", func(t *testing.T) {
+		cold, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cold.Close() //nolint:errcheck
+
+		testSplitStoreAllKeys(t, cold)
+	})
+}
+
+func testSplitStoreAllKeys(t *testing.T, cold bstore.Blockstore) {
+	ctx := context.Background()
+
+	hot, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hot.Close() //nolint:errcheck
+
+	blks := splitKeysBlocks(t, ctx, hot, cold)
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	collect := func(ctx context.Context) []cid.Cid {
+		ch, err := ss.AllKeysChan(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []cid.Cid
+		seen := make(map[string]struct{})
+		for c := range ch {
+			if _, ok := seen[string(c.Hash())]; ok {
+				t.Fatalf("duplicate key %s", c)
+			}
+			seen[string(c.Hash())] = struct{}{}
+			keys = append(keys, c)
+		}
+
+		if len(keys) != len(blks) {
+			t.Fatalf("expected %d keys, got %d", len(blks), len(keys))
+		}
+		return keys
+	}
+
+	collect(ctx)
+
+	keys := collect(bstore.WithOrderedKeys(ctx))
+	for i := 1; i < len(keys); i++ {
+		if bstore.CompareKeys(keys[i-1], keys[i]) >= 0 {
+			t.Fatal("keys are not ordered")
+		}
+	}
+}
+
+func TestSplitStoreEstimateKeys(t *testing.T) {
+	ctx := context.Background()
+
+	// the memory blockstores count exactly
+	hot := iterableMemory{bstore.NewMemory()}
+	cold := bstore.NewMemory()
+	splitKeysBlocks(t, ctx, hot, cold)
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	count, size, err := ss.EstimateKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// objects in both stores are counted once
+	if count != 20 {
+		t.Fatalf("expected an estimate of %d objects, got %d", 20, count)
+	}
+
+	_, hotSize, err := hot.EstimateKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, coldSize, err := cold.EstimateKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != coldSize+hotSize/2 {
+		t.Fatalf("expected an estimated size of %d, got %d", coldSize+hotSize/2, size)
+	}
+}
+
+// splitKeysBlocks writes objects 0-9 to the hotstore and 5-19 to the coldstore, so that 5-9 are in
+// both, and returns them.
+func splitKeysBlocks(t *testing.T, ctx context.Context, hot, cold bstore.Blockstore) []blocks.Block {
+	var blks []blocks.Block
+	for i := 0; i < 20; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("object %02d", i))))
+	}
+	if err := hot.PutMany(ctx, blks[:10]); err != nil {
+		t.Fatal(err)
+	}
+	if err := cold.PutMany(ctx, blks[5:]); err != nil {
+		t.Fatal(err)
+	}
+	return blks
+}
+
+// iterableMemory is a memory blockstore which can be used as a hotstore.
+type iterableMemory struct {
+	bstore.MemBlockstore
+}
+
+func (m iterableMemory) ForEachKey(f func(cid.Cid) error) error {
+	for _, b := range m.MemBlockstore {
+		if err := f(b.Cid()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package splitstore
+
+import (
+	"sync/atomic"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"go.opencensus.io/stats"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var (
+	// PrefetchDepth is the depth, below an object read from the coldstore, down to which
+	// its DAG is promoted to the hotstore by the cold read prefetcher.
+	PrefetchDepth = 2
+	// PrefetchLimit is the maximum number of objects promoted for a single cold read.
+	PrefetchLimit = 1024
+	// PrefetchQueueSize is the number of cold reads which can be queued for prefetching;
+	// cold reads are not prefetched while the queue is full.
+	PrefetchQueueSize = 4096
+)
+
+// prefetchReq is a request to promote the DAG of a cold object to the hotstore, down to depth;
+// a depth of 0 promotes the object alone.
+type prefetchReq struct {
+	c     cid.Cid
+	depth int
+}
+
+// queuePrefetch queues an object read from the coldstore for promotion to the hotstore,
+// together with its DAG down to depth.
+//
+// Deep history queries tend to read state clustered around the objects they have read already,
+// such as the other entries of a HAMT node, so prefetching promotes the children of the object
+// together with it. As reads walk down from roots, this also promotes the siblings of the
+// objects read next.
+func (s *SplitStore) queuePrefetch(c cid.Cid, depth int) {
+	if !s.isWarm() || isUnitaryObject(c) {
+		return
+	}
+
+	select {
+	case s.prefetchCh <- prefetchReq{c: c, depth: depth}:
+	default:
+	}
+}
+
+func (s *SplitStore) prefetcher() {
+	for {
+		select {
+		case req := <-s.prefetchCh:
+			s.doPrefetch(req.c, req.depth)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *SplitStore) doPrefetch(c cid.Cid, maxDepth int) {
+	// objects promoted while compaction collects the hot objects could be purged when it ends
+	if atomic.LoadInt32(&s.compacting) == 1 {
+		return
+	}
+
+	s.txnLk.RLock()
+	defer s.txnLk.RUnlock()
+
+	var batch []blocks.Block
+	frontier := []cid.Cid{c}
+	for depth := 0; depth <= maxDepth && len(frontier) > 0 && len(batch) < PrefetchLimit; depth++ {
+		var next []cid.Cid
+		for _, c := range frontier {
+			if len(batch) == PrefetchLimit {
+				break
+			}
+
+			if isUnitaryObject(c) {
+				continue
+			}
+
+			has, err := s.hot.Has(s.ctx, c)
+			if err != nil {
+				log.Warnf("error checking hotstore for prefetched object (cid: %s): %s", c, err)
+				return
+			}
+			if has {
+				continue
+			}
+
+			blk, err := s.cold.Get(s.ctx, c)
+			if err != nil {
+				if !ipld.IsNotFound(err) {
+					log.Warnf("error retrieving cold object for prefetching (cid: %s): %s", c, err)
+				}
+				continue
+			}
+
+			batch = append(batch, blk)
+
+			if depth < maxDepth && c.Prefix().Codec == cid.DagCBOR {
+				err := scanLinks(blk.RawData(), func(l cid.Cid) {
+					next = append(next, l)
+				})
+				if err != nil {
+					log.Warnf("error scanning links of prefetched object (cid: %s): %s", c, err)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.checkClosing(); err != nil {
+		return
+	}
+
+	cids := make([]cid.Cid, 0, len(batch))
+	for _, blk := range batch {
+		cids = append(cids, blk.Cid())
+	}
+
+	if err := s.trackRefs(batch); err != nil {
+		log.Warnf("error promoting cold object (cid: %s): %s", c, err)
+		return
+	}
+	if err := s.hot.PutMany(s.ctx, batch); err != nil {
+		log.Warnf("error promoting cold object (cid: %s): %s", c, err)
+		return
+	}
+
+	// a compaction which started since is protecting the objects written during it, and the
+	// next one keeps the promoted objects hot
+	s.trackTxnRefMany(cids)
+	s.trackPromoted(cids)
+
+	log.Debugf("promoted %d objects rooted at %s", len(batch), c)
+	stats.Record(s.ctx, metrics.SplitstorePrefetched.M(int64(len(batch))))
+}
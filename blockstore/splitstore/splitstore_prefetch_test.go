@@ -0,0 +1,122 @@
+package splitstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	mh "github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+func TestSplitStoreColdReadPrefetch(t *testing.T) {
+	ctx := context.Background()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := newMockStore()
+	cold := newMockStore()
+
+	mkNode := func(links ...cid.Cid) blocks.Block {
+		var buf bytes.Buffer
+		if err := cbg.WriteMajorTypeHeader(&buf, cbg.MajArray, uint64(len(links))); err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range links {
+			if err := cbg.WriteCid(&buf, l); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		c, err := cid.Prefix{Version: 1, Codec: cid.DagCBOR, MhType: mh.SHA2_256, MhLength: -1}.Sum(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		blk, err := blocks.NewBlockWithCid(buf.Bytes(), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blk
+	}
+
+	// root -> (node1 -> (node2 -> leaf), sibling)
+	leaf := blocks.NewBlock([]byte("leaf"))
+	node2 := mkNode(leaf.Cid())
+	node1 := mkNode(node2.Cid())
+	sibling := blocks.NewBlock([]byte("sibling"))
+	root := mkNode(node1.Cid(), sibling.Cid())
+
+	for _, blk := range []blocks.Block{leaf, node2, node1, sibling, root} {
+		if err := cold.Put(ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, err := Open(t.TempDir(), ds, hot, cold, &Config{MarkSetType: "map", UniversalColdBlocks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	ss.warmupEpoch = 1
+
+	// cold reads are not prefetched unless enabled
+	if _, err := ss.Get(ctx, root.Cid()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ss.prefetchCh) != 0 {
+		t.Fatal("cold read unexpectedly queued for prefetching")
+	}
+
+	if err := ss.UpdateConfig(&Config{ColdReadPrefetch: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ss.View(ctx, root.Cid(), func([]byte) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss.prefetchCh) != 1 {
+		t.Fatal("cold read was not queued for prefetching")
+	}
+
+	go ss.prefetcher()
+
+	// the prefetched objects are written in one batch
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		has, err := hot.Has(ctx, root.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for prefetching")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for name, blk := range map[string]blocks.Block{"node1": node1, "sibling": sibling, "node2": node2} {
+		has, err := hot.Has(ctx, blk.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Fatalf("%s was not prefetched", name)
+		}
+	}
+
+	// the leaf is below the prefetch depth
+	has, err := hot.Has(ctx, leaf.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Fatal("leaf below the prefetch depth was prefetched")
+	}
+}
@@ -0,0 +1,180 @@
+package splitstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// CompactionProfileRetention is the number of compactions for which profiles are retained.
+var CompactionProfileRetention = 5
+
+// compactionProfiler captures CPU and heap profiles at the phases of a compaction.
+// A heap profile is written at every phase; a CPU profile is started at every phase but the
+// last, and covers the compaction from that phase until the next one.
+type compactionProfiler struct {
+	dir    string
+	prefix string
+
+	cpu     *os.File
+	cpuName string
+}
+
+func (s *SplitStore) profileDir() string {
+	return filepath.Join(s.path, "profiles")
+}
+
+// beginCompactionProfile starts profiling the current compaction, if profiling is enabled.
+// Must be called with the compaction lock held.
+func (s *SplitStore) beginCompactionProfile() {
+	if !s.config().CompactionProfiling {
+		return
+	}
+
+	dir := s.profileDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Warnf("error creating compaction profile directory: %s", err)
+		return
+	}
+
+	if err := rotateCompactionProfiles(dir, CompactionProfileRetention-1); err != nil {
+		log.Warnf("error rotating compaction profiles: %s", err)
+	}
+
+	s.profiler = &compactionProfiler{
+		dir:    dir,
+		prefix: fmt.Sprintf("compaction-%d", s.compactionIndex),
+	}
+	s.profiler.snapshot("start", true)
+}
+
+// profileCompaction takes a profile snapshot of the current compaction at the given phase.
+func (s *SplitStore) profileCompaction(phase string) {
+	if s.profiler == nil {
+		return
+	}
+
+	s.profiler.snapshot(phase, true)
+}
+
+// endCompactionProfile takes the final profile snapshot of the current compaction.
+func (s *SplitStore) endCompactionProfile() {
+	if s.profiler == nil {
+		return
+	}
+
+	s.profiler.snapshot("end", false)
+	s.profiler = nil
+}
+
+func (p *compactionProfiler) snapshot(phase string, cpu bool) {
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			log.Warnf("error closing cpu profile %s: %s", p.cpuName, err)
+		}
+		p.cpu = nil
+	}
+
+	if err := p.writeHeapProfile(phase); err != nil {
+		log.Warnf("error writing compaction heap profile: %s", err)
+	}
+
+	if !cpu {
+		return
+	}
+
+	if err := p.startCPUProfile(phase); err != nil {
+		log.Warnf("error starting compaction cpu profile: %s", err)
+	}
+}
+
+func (p *compactionProfiler) writeHeapProfile(phase string) error {
+	path := filepath.Join(p.dir, fmt.Sprintf("%s-%s.heap.pprof", p.prefix, phase))
+	f, err := os.Create(path)
+	if err != nil {
+		return xerrors.Errorf("error creating heap profile: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		return xerrors.Errorf("error writing heap profile: %w", err)
+	}
+
+	return nil
+}
+
+func (p *compactionProfiler) startCPUProfile(phase string) error {
+	path := filepath.Join(p.dir, fmt.Sprintf("%s-%s.cpu.pprof", p.prefix, phase))
+	f, err := os.Create(path)
+	if err != nil {
+		return xerrors.Errorf("error creating cpu profile: %w", err)
+	}
+
+	// this fails if the cpu is already being profiled, eg through the pprof endpoint
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return xerrors.Errorf("error starting cpu profile: %w", err)
+	}
+
+	p.cpu = f
+	p.cpuName = path
+	return nil
+}
+
+// rotateCompactionProfiles removes the profiles of all but the latest keep compactions.
+func rotateCompactionProfiles(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	byIndex := make(map[int64][]string)
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "compaction-") {
+			continue
+		}
+
+		idx := strings.TrimPrefix(name, "compaction-")
+		if i := strings.IndexByte(idx, '-'); i > 0 {
+			idx = idx[:i]
+		}
+		index, err := strconv.ParseInt(idx, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		byIndex[index] = append(byIndex[index], name)
+	}
+
+	indices := make([]int64, 0, len(byIndex))
+	for index := range byIndex {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] > indices[j] })
+
+	if keep < 0 {
+		keep = 0
+	}
+	if len(indices) <= keep {
+		return nil
+	}
+
+	for _, index := range indices[keep:] {
+		for _, name := range byIndex[index] {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
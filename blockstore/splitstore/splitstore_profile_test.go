@@ -0,0 +1,62 @@
+package splitstore
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestCompactionProfiler(t *testing.T) {
+	dir := t.TempDir()
+
+	for i := 0; i < 3; i++ {
+		ss := &SplitStore{
+			cfg:             &Config{CompactionProfiling: true},
+			path:            dir,
+			compactionIndex: int64(i),
+		}
+
+		ss.beginCompactionProfile()
+		ss.profileCompaction("middle")
+		ss.endCompactionProfile()
+	}
+
+	list := func() []string {
+		entries, err := os.ReadDir(filepath.Join(dir, "profiles"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	names := list()
+	for _, name := range []string{
+		"compaction-2-start.heap.pprof",
+		"compaction-2-start.cpu.pprof",
+		"compaction-2-middle.heap.pprof",
+		"compaction-2-middle.cpu.pprof",
+		"compaction-2-end.heap.pprof",
+	} {
+		idx := sort.SearchStrings(names, name)
+		if idx == len(names) || names[idx] != name {
+			t.Fatalf("missing profile %s in %v", name, names)
+		}
+	}
+
+	// only keep the latest compaction
+	if err := rotateCompactionProfiles(filepath.Join(dir, "profiles"), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range list() {
+		if name[:len("compaction-2-")] != "compaction-2-" {
+			t.Fatalf("unexpected profile %s after rotation", name)
+		}
+	}
+}
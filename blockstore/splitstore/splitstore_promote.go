@@ -0,0 +1,154 @@
+package splitstore
+
+import (
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// Policies for promoting objects read from the coldstore to the hotstore.
+const (
+	// PromoteNever leaves objects read from the coldstore in the coldstore.
+	PromoteNever = "never"
+	// PromoteAlways promotes every object read from the coldstore.
+	PromoteAlways = "always"
+	// PromoteHits promotes objects read from the coldstore ColdReadPromotionHits times
+	// within ColdReadPromotionWindow epochs.
+	PromoteHits = "hits"
+)
+
+// ColdHitsTrackerSize is the maximum number of cold objects whose reads are counted by the
+// hits promotion policy.
+var ColdHitsTrackerSize = 1 << 20
+
+// PromotedTrackerSize is the maximum number of objects promoted between compactions which are
+// kept hot by the next compaction; the objects promoted beyond it are purged by it.
+var PromotedTrackerSize = 1 << 20
+
+// coldHits counts the reads of a cold object since the first read in the current window.
+type coldHits struct {
+	count int
+	since abi.ChainEpoch
+}
+
+func checkPromotionPolicy(cfg *Config) error {
+	switch cfg.ColdReadPromotion {
+	case "", PromoteNever, PromoteAlways:
+		return nil
+	case PromoteHits:
+		if cfg.ColdReadPromotionHits < 1 {
+			return xerrors.Errorf("the %q cold read promotion policy needs a positive number of hits", PromoteHits)
+		}
+		return nil
+	default:
+		return xerrors.Errorf("unknown cold read promotion policy %q", cfg.ColdReadPromotion)
+	}
+}
+
+// coldRead is called with the objects read from the coldstore outside of hot views, which
+// reify them instead; it prefetches or promotes them to the hotstore, as configured.
+func (s *SplitStore) coldRead(c cid.Cid) {
+	cfg := s.config()
+
+	switch {
+	case cfg.ColdReadPrefetch:
+		// prefetching promotes the object with its DAG
+		s.queuePrefetch(c, PrefetchDepth)
+
+	case cfg.ColdReadPromotion == PromoteAlways:
+		s.queuePrefetch(c, 0)
+
+	case cfg.ColdReadPromotion == PromoteHits:
+		if s.countColdHit(c, cfg) {
+			s.queuePrefetch(c, 0)
+		}
+	}
+}
+
+// countColdHit counts a read of a cold object and returns whether it has been read enough
+// times within the promotion window to be promoted.
+func (s *SplitStore) countColdHit(c cid.Cid, cfg *Config) bool {
+	var epoch abi.ChainEpoch
+	if s.chain != nil {
+		if ts := s.chain.GetHeaviestTipSet(); ts != nil {
+			epoch = ts.Height()
+		}
+	}
+
+	s.coldHitsMx.Lock()
+	defer s.coldHitsMx.Unlock()
+
+	if s.coldHits == nil {
+		s.coldHits = make(map[cid.Cid]*coldHits)
+	}
+
+	hits, ok := s.coldHits[c]
+	if !ok || epoch-hits.since > cfg.ColdReadPromotionWindow {
+		if !ok && len(s.coldHits) >= ColdHitsTrackerSize {
+			s.expireColdHits(epoch, cfg.ColdReadPromotionWindow)
+			if len(s.coldHits) >= ColdHitsTrackerSize {
+				return false
+			}
+		}
+
+		hits = &coldHits{since: epoch}
+		s.coldHits[c] = hits
+	}
+
+	hits.count++
+	if hits.count < cfg.ColdReadPromotionHits {
+		return false
+	}
+
+	delete(s.coldHits, c)
+	return true
+}
+
+// expireColdHits forgets the reads counted before the current promotion window; it must be
+// called with coldHitsMx held.
+func (s *SplitStore) expireColdHits(epoch, window abi.ChainEpoch) {
+	for c, hits := range s.coldHits {
+		if epoch-hits.since > window {
+			delete(s.coldHits, c)
+		}
+	}
+}
+
+// trackPromoted records promoted objects, for the next compaction to keep them hot.
+func (s *SplitStore) trackPromoted(cids []cid.Cid) {
+	s.promotedMx.Lock()
+	defer s.promotedMx.Unlock()
+
+	if s.promoted == nil {
+		s.promoted = make(map[cid.Cid]struct{})
+	}
+
+	for _, c := range cids {
+		if len(s.promoted) >= PromotedTrackerSize {
+			log.Warnf("more than %d objects promoted since the last compaction; the next compaction purges the others", PromotedTrackerSize)
+			return
+		}
+		s.promoted[c] = struct{}{}
+	}
+}
+
+// markPromoted marks the objects promoted since the last compaction, so that they stay hot
+// until the next one, which purges them; the objects still read are promoted again.
+func (s *SplitStore) markPromoted(markSet MarkSet) error {
+	s.promotedMx.Lock()
+	promoted := s.promoted
+	s.promoted = nil
+	s.promotedMx.Unlock()
+
+	for c := range promoted {
+		if err := markSet.Mark(c); err != nil {
+			return xerrors.Errorf("error marking promoted object %s: %w", c, err)
+		}
+	}
+
+	if len(promoted) > 0 {
+		log.Infow("kept promoted objects hot", "objects", len(promoted))
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package splitstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestSplitStoreColdReadPromotion(t *testing.T) {
+	ctx := context.Background()
+
+	for _, cfg := range []*Config{
+		{MarkSetType: "map", ColdReadPromotion: "sometimes"},
+		{MarkSetType: "map", ColdReadPromotion: PromoteHits},
+	} {
+		_, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), newMockStore(), newMockStore(), cfg)
+		if err == nil {
+			t.Fatalf("expected promotion policy %q with %d hits to be refused", cfg.ColdReadPromotion, cfg.ColdReadPromotionHits)
+		}
+	}
+
+	hot := newMockStore()
+	cold := newMockStore()
+	chain := &mockChain{t: t}
+
+	blk := mock.MkBlock(nil, 0, 0)
+	chain.push(mock.TipSet(blk))
+
+	objs := make([]blocks.Block, 3)
+	for i := range objs {
+		objs[i] = blocks.NewBlock([]byte{byte(i), 4, 2})
+		if err := cold.Put(ctx, objs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{
+		MarkSetType:             "map",
+		UniversalColdBlocks:     true,
+		ColdReadPromotion:       PromoteHits,
+		ColdReadPromotionHits:   2,
+		ColdReadPromotionWindow: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	ss.warmupEpoch = 1
+	ss.chain = chain
+
+	read := func(blk blocks.Block) {
+		if _, err := ss.Get(ctx, blk.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requireQueued := func(n int) {
+		t.Helper()
+		if len(ss.prefetchCh) != n {
+			t.Fatalf("expected %d queued promotions, got %d", n, len(ss.prefetchCh))
+		}
+	}
+
+	// objects are promoted on their second read within the window
+	read(objs[0])
+	requireQueued(0)
+	read(objs[0])
+	requireQueued(1)
+
+	// reads outside the window are not counted
+	read(objs[1])
+	blk = mock.MkBlock(nil, 0, 0)
+	blk.Height = abi.ChainEpoch(20)
+	chain.push(mock.TipSet(blk))
+	read(objs[1])
+	requireQueued(1)
+	read(objs[1])
+	requireQueued(2)
+
+	// the always policy promotes objects on their first read
+	if err := ss.UpdateConfig(&Config{ColdReadPromotion: PromoteAlways}); err != nil {
+		t.Fatal(err)
+	}
+	read(objs[2])
+	requireQueued(3)
+
+	go ss.prefetcher()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for _, obj := range objs {
+		for {
+			has, err := hot.Has(ctx, obj.Cid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if has {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("object %s was not promoted", obj.Cid())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// the never policy leaves objects in the coldstore
+	if err := ss.UpdateConfig(&Config{ColdReadPromotion: PromoteNever}); err != nil {
+		t.Fatal(err)
+	}
+	fresh := blocks.NewBlock([]byte("fresh"))
+	if err := cold.Put(ctx, fresh); err != nil {
+		t.Fatal(err)
+	}
+	read(fresh)
+	read(fresh)
+	requireQueued(0)
+
+	// invalid policies are rejected, and the current one is kept
+	err = ss.UpdateConfig(&Config{ColdReadPromotion: PromoteHits})
+	if err == nil || !strings.Contains(err.Error(), "ColdReadPromotion") {
+		t.Fatalf("expected the promotion policy to be rejected, got %v", err)
+	}
+	read(fresh)
+	requireQueued(0)
+}
+
+func TestSplitStoreMarkPromoted(t *testing.T) {
+	ctx := context.Background()
+
+	hot := newMockStore()
+	cold := newMockStore()
+
+	obj := blocks.NewBlock([]byte("promoted"))
+	if err := cold.Put(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{
+		MarkSetType:         "map",
+		UniversalColdBlocks: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	ss.doPrefetch(obj.Cid(), 0)
+
+	has, err := hot.Has(ctx, obj.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has {
+		t.Fatal("object was not promoted")
+	}
+
+	mark := func() bool {
+		markSet, err := ss.markSetEnv.New("live", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer markSet.Close() //nolint:errcheck
+
+		if err := ss.markPromoted(markSet); err != nil {
+			t.Fatal(err)
+		}
+
+		marked, err := markSet.Has(obj.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return marked
+	}
+
+	// the compaction following the promotion keeps the object hot, the next one doesn't
+	if !mark() {
+		t.Fatal("promoted object was not marked")
+	}
+	if mark() {
+		t.Fatal("promoted object was marked by a second compaction")
+	}
+}
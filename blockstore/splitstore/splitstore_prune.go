@@ -10,7 +10,6 @@ import (
 
 	cid "github.com/ipfs/go-cid"
 	ipld "github.com/ipfs/go-ipld-format"
-	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.opencensus.io/stats"
 	"golang.org/x/xerrors"
 
@@ -79,7 +78,7 @@ func (s *SplitStore) PruneChain(opts api.PruneOpts) error {
 	switch {
 	case retainState > 0:
 		retainStateP = func(depth int64) bool {
-			return depth <= int64(CompactionBoundary)+retainState*int64(build.Finality)
+			return depth <= int64(CompactionBoundary)+retainState*int64(finality)
 		}
 	case retainState < 0:
 		retainStateP = func(_ int64) bool { return true }
@@ -118,6 +117,12 @@ func (s *SplitStore) pruneChain(retainStateP func(int64) bool, doGC func() error
 		return xerrors.Errorf("splitstore has not compacted yet")
 	}
 
+	// pruning needs disk space for the mark set and moving GC
+	if s.isDiskSpaceLow() {
+		atomic.StoreInt32(&s.compacting, 0)
+		return xerrors.Errorf("splitstore is low on disk space; prune paused")
+	}
+
 	// get the current tipset
 	curTs := s.chain.GetHeaviestTipSet()
 
@@ -146,9 +151,11 @@ func (s *SplitStore) prune(curTs *types.TipSet, retainStateP func(int64) bool, d
 	log.Debugw("waiting for active views done", "took", time.Since(start))
 
 	err := s.doPrune(curTs, retainStateP, doGC)
+	s.setCompactionPhase("")
 	if err != nil {
 		log.Errorf("PRUNE ERROR: %s", err)
 	}
+	s.recordResult("prune", err)
 }
 
 func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool, doGC func() error) error {
@@ -170,6 +177,7 @@ func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool,
 
 	// 0. track all protected references at beginning of compaction; anything added later should
 	//    be transactionally protected by the write
+	s.setCompactionPhase("protecting")
 	log.Info("protecting references with registered protectors")
 	err = s.applyProtectors()
 	if err != nil {
@@ -178,6 +186,7 @@ func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool,
 
 	// 1. mark reachable objects by walking the chain from the current epoch; we keep all messages
 	//    and chain headers; state and reciepts are retained only if it is within retention policy scope
+	s.setCompactionPhase("marking")
 	log.Info("marking reachable objects")
 	startMark := time.Now()
 
@@ -222,6 +231,7 @@ func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool,
 	}
 
 	// 2. iterate through the coldstore to collect dead objects
+	s.setCompactionPhase("collecting")
 	log.Info("collecting dead objects")
 	startCollect := time.Now()
 
@@ -297,6 +307,7 @@ func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool,
 	// again for new references created by the caller.
 	// After each batch we write a checkpoint to disk; if the process is interrupted before completion
 	// the process will continue from the checkpoint in the next recovery.
+	s.setCompactionPhase("purging")
 	if err := s.beginCriticalSection(markSet); err != nil {
 		return xerrors.Errorf("error beginning critical section: %w", err)
 	}
@@ -336,6 +347,7 @@ func (s *SplitStore) doPrune(curTs *types.TipSet, retainStateP func(int64) bool,
 
 	// we are done; do some housekeeping
 	s.endTxnProtect()
+	s.setCompactionPhase("gc")
 	err = doGC()
 	if err != nil {
 		log.Warnf("error garbage collecting cold store: %s", err)
@@ -567,7 +579,7 @@ func (s *SplitStore) walkObjectLax(c cid.Cid, f func(cid.Cid) error) error {
 
 	var links []cid.Cid
 	err := s.view(c, func(data []byte) error {
-		return cbg.ScanForLinks(bytes.NewReader(data), func(c cid.Cid) {
+		return scanLinks(data, func(c cid.Cid) {
 			links = append(links, c)
 		})
 	})
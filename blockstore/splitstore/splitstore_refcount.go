@@ -0,0 +1,484 @@
+package splitstore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// openRefs opens the reference tracker of the refcount compaction strategy; with the other
+// strategies, a tracker left by a previous run is removed, as the objects written since are not
+// tracked.
+func (s *SplitStore) openRefs() error {
+	if s.cfg.CompactionStrategy != CompactRefCount {
+		return os.RemoveAll(filepath.Join(s.path, "refs.badger"))
+	}
+
+	refs, err := openRefTracker(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.refs = refs
+	return nil
+}
+
+// trackRefs tracks the references of objects written to the hotstore, with the refcount
+// compaction strategy. It must be called before the objects are written: should the process
+// stop in between, an object tracked but not written only keeps its links in the hotstore, and
+// is purged when it's left without references, while an object written but not tracked would
+// never count as a reference, and the objects it links to could be purged while still live.
+func (s *SplitStore) trackRefs(blks []blocks.Block) error {
+	if s.refs == nil {
+		return nil
+	}
+
+	if err := s.refs.track(blks); err != nil {
+		return xerrors.Errorf("error tracking references: %w", err)
+	}
+
+	return nil
+}
+
+// doCompactRefs is the compaction of the refcount strategy. Instead of walking the chain to mark
+// the live objects, the block headers which fell below the compaction boundary release their
+// state roots (and below the message retention boundary, their messages and receipts), and the
+// objects left without references are purged. Purging an object drops its references, so
+// purging is repeated until no more objects are left without references; shared state nodes are
+// kept as long as a retained state links to them.
+//
+// Objects referenced by transactional i/o during compaction are kept, along with their DAGs,
+// which remain referenced by them; the next compaction purges them if they are still not
+// referenced then. Block headers are kept all the way to genesis, as with the epoch strategy.
+func (s *SplitStore) doCompactRefs(curTs *types.TipSet) error {
+	s.clearSizeMeasurements()
+
+	currentEpoch := curTs.Height()
+	boundaryEpoch := currentEpoch - CompactionBoundary
+
+	var inclMsgsEpoch abi.ChainEpoch
+	inclMsgsRange := abi.ChainEpoch(s.config().HotStoreMessageRetention) * finality
+	if inclMsgsRange < boundaryEpoch {
+		inclMsgsEpoch = boundaryEpoch - inclMsgsRange
+	}
+
+	log.Infow("running compaction", "currentEpoch", currentEpoch, "baseEpoch", s.baseEpoch, "boundaryEpoch", boundaryEpoch, "inclMsgsEpoch", inclMsgsEpoch, "compactionIndex", s.compactionIndex, "strategy", CompactRefCount)
+	s.debug.LogCompaction(curTs)
+
+	// the markset only holds the objects protected from purging; their DAGs are protected by
+	// their references
+	markSet, err := s.markSetEnv.New("live", 0)
+	if err != nil {
+		return xerrors.Errorf("error creating mark set: %w", err)
+	}
+	defer markSet.Close() //nolint:errcheck
+	defer s.debug.Flush()
+
+	s.setLiveMarkSet(markSet)
+	defer s.setLiveMarkSet(nil)
+
+	if err := s.checkClosing(); err != nil {
+		return err
+	}
+
+	// 0. track all protected references at beginning of compaction
+	s.setCompactionPhase("protecting")
+	log.Info("protecting references with registered protectors")
+	if err := s.applyProtectors(); err != nil {
+		return err
+	}
+
+	// 1. count the references of the objects in the hotstore, the first time around
+	initialized, err := s.refs.isInitialized()
+	if err != nil {
+		return err
+	}
+	if !initialized {
+		s.setCompactionPhase("counting")
+		if err := s.countHotRefs(); err != nil {
+			return xerrors.Errorf("error counting references: %w", err)
+		}
+	}
+
+	if err := s.checkClosing(); err != nil {
+		return err
+	}
+
+	// 2. release the state, messages and receipts of the headers below the boundaries
+	s.setCompactionPhase("releasing")
+	log.Info("releasing chain references")
+	startRelease := time.Now()
+	if err := s.releaseChainRefs(curTs, boundaryEpoch, inclMsgsEpoch); err != nil {
+		return xerrors.Errorf("error releasing chain references: %w", err)
+	}
+	log.Infow("releasing chain references done", "took", time.Since(startRelease))
+
+	// 3. the head has no references, keep it
+	hRef, err := curTs.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing cid reference to the head: %w", err)
+	}
+	for _, c := range append(curTs.Cids(), hRef) {
+		if err := markSet.Mark(c); err != nil {
+			return xerrors.Errorf("error marking head: %w", err)
+		}
+	}
+
+	if err := s.checkClosing(); err != nil {
+		return err
+	}
+
+	s.profileCompaction("middle")
+
+	// 4. purge the objects without references, moving them to the coldstore -- if we have one
+	s.setCompactionPhase("purging")
+	log.Info("purging objects without references")
+	startPurge := time.Now()
+	szPurged, err := s.purgeGarbage(markSet)
+	if err != nil {
+		return xerrors.Errorf("error purging objects: %w", err)
+	}
+	log.Infow("purging objects without references done", "took", time.Since(startPurge))
+
+	// the approximate size of the live objects, for choosing the hotstore gc
+	if sizer, ok := s.hot.(bstore.BlockstoreSize); ok {
+		if size, err := sizer.Size(); err == nil && size > szPurged {
+			s.szWalk = size - szPurged
+		}
+	}
+
+	// we are done; do some housekeeping
+	s.endTxnProtect()
+	s.setCompactionPhase("gc")
+	s.gcHotAfterCompaction()
+
+	if err := s.refs.Sync(); err != nil {
+		return xerrors.Errorf("error syncing reference tracker: %w", err)
+	}
+
+	if err := s.setBaseEpoch(boundaryEpoch); err != nil {
+		return xerrors.Errorf("error saving base epoch: %w", err)
+	}
+
+	s.compactionIndex++
+	if err := s.ds.Put(s.ctx, compactionIndexKey, int64ToBytes(s.compactionIndex)); err != nil {
+		return xerrors.Errorf("error saving compaction index: %w", err)
+	}
+
+	return nil
+}
+
+// countHotRefs tracks the objects already in the hotstore when the refcount strategy is
+// enabled; objects written meanwhile are tracked by the write.
+func (s *SplitStore) countHotRefs() error {
+	log.Info("counting references of hot objects")
+	start := time.Now()
+
+	// the keys are collected before reading the objects, as the hotstore can't be read while
+	// iterating its keys
+	path := filepath.Join(s.path, "refset")
+	defer os.Remove(path) //nolint:errcheck
+
+	keyw, err := NewColdSetWriter(path)
+	if err != nil {
+		return xerrors.Errorf("error creating refset: %w", err)
+	}
+
+	err = s.hot.ForEachKey(func(c cid.Cid) error {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+		return keyw.Write(c)
+	})
+	if err != nil {
+		_ = keyw.Close()
+		return xerrors.Errorf("error collecting hot keys: %w", err)
+	}
+	if err := keyw.Close(); err != nil {
+		return xerrors.Errorf("error closing refset: %w", err)
+	}
+
+	keyr, err := NewColdSetReader(path)
+	if err != nil {
+		return xerrors.Errorf("error opening refset: %w", err)
+	}
+	defer keyr.Close() //nolint:errcheck
+
+	var count int64
+	trackBatch := func(batch []cid.Cid) error {
+		blks, err := s.hot.GetMany(s.ctx, batch)
+		if err != nil {
+			return xerrors.Errorf("error retrieving batch from hotstore: %w", err)
+		}
+
+		found := blks[:0]
+		for _, blk := range blks {
+			if blk != nil {
+				found = append(found, blk)
+			}
+		}
+
+		count += int64(len(found))
+		return s.refs.track(found)
+	}
+
+	batch := make([]cid.Cid, 0, batchSize)
+	err = keyr.ForEach(func(c cid.Cid) error {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		batch = append(batch, c)
+		if len(batch) == batchSize {
+			if err := trackBatch(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		if err := trackBatch(batch); err != nil {
+			return err
+		}
+	}
+
+	// the headers in the hotstore link to their state all the way to genesis
+	if err := s.refs.setReleaseEpochs(0, 0); err != nil {
+		return err
+	}
+	if err := s.refs.setInitialized(); err != nil {
+		return err
+	}
+
+	log.Infow("counting references done", "took", time.Since(start), "objects", count)
+	return nil
+}
+
+// releaseChainRefs walks the chain down from the boundary epoch to the epochs released by the
+// previous compaction, releasing the state roots of the block headers below the boundary epoch,
+// and their messages and receipts below the message retention epoch.
+func (s *SplitStore) releaseChainRefs(curTs *types.TipSet, boundaryEpoch, inclMsgsEpoch abi.ChainEpoch) error {
+	stateEpoch, msgsEpoch, err := s.refs.releaseEpochs()
+	if err != nil {
+		return err
+	}
+
+	// released references can't be retained again, e.g. when the message retention grows
+	if boundaryEpoch < stateEpoch {
+		boundaryEpoch = stateEpoch
+	}
+	if inclMsgsEpoch < msgsEpoch {
+		inclMsgsEpoch = msgsEpoch
+	}
+	if boundaryEpoch == stateEpoch && inclMsgsEpoch == msgsEpoch {
+		return nil
+	}
+
+	lowEpoch := stateEpoch
+	if msgsEpoch < lowEpoch {
+		lowEpoch = msgsEpoch
+	}
+
+	ts, err := s.chain.GetTipsetByHeight(s.ctx, boundaryEpoch, curTs, true)
+	if err != nil {
+		return xerrors.Errorf("error retrieving tipset at boundary epoch: %w", err)
+	}
+
+	var count int64
+	toWalk := ts.Cids()
+	for len(toWalk) > 0 {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		// the blocks of a tipset share their parents
+		var parents []cid.Cid
+		var height abi.ChainEpoch
+		for _, c := range toWalk {
+			var hdr types.BlockHeader
+			err := s.view(c, func(data []byte) error {
+				return hdr.UnmarshalCBOR(bytes.NewReader(data))
+			})
+			if err != nil {
+				return xerrors.Errorf("error unmarshaling block header (cid: %s): %w", c, err)
+			}
+
+			var links []cid.Cid
+			if hdr.Height < boundaryEpoch && hdr.Height >= stateEpoch && hdr.Height > 0 {
+				links = append(links, hdr.ParentStateRoot)
+			}
+			if hdr.Height < inclMsgsEpoch && hdr.Height >= msgsEpoch {
+				links = append(links, hdr.Messages, hdr.ParentMessageReceipts)
+			}
+
+			if len(links) > 0 {
+				if err := s.refs.release(c, links); err != nil {
+					return xerrors.Errorf("error releasing references of %s: %w", c, err)
+				}
+				count++
+			}
+
+			parents = hdr.Parents
+			height = hdr.Height
+		}
+
+		if height <= lowEpoch {
+			break
+		}
+		toWalk = parents
+	}
+
+	log.Infow("released chain references", "headers", count)
+	return s.refs.setReleaseEpochs(boundaryEpoch, inclMsgsEpoch)
+}
+
+// purgeGarbage purges the objects without references from the hotstore, in passes over the purge
+// candidates until a pass purges nothing, as purging an object can leave its links without
+// references. It returns the size of the purged objects.
+func (s *SplitStore) purgeGarbage(markSet MarkSet) (int64, error) {
+	var purgeCnt, liveCnt int
+	var szPurged int64
+
+	batch := make([]cid.Cid, 0, batchSize)
+	for pass := 1; ; pass++ {
+		var passCnt, passLive int
+		purgeBatch := func() error {
+			pc, lc, sz, err := s.purgeGarbageBatch(batch, markSet)
+			passCnt += pc
+			passLive += lc
+			szPurged += sz
+			batch = batch[:0]
+			return err
+		}
+
+		err := s.refs.forEachGarbage(func(c cid.Cid) error {
+			mark, err := markSet.Has(c)
+			if err != nil {
+				return xerrors.Errorf("error checking markset for liveness: %w", err)
+			}
+			if mark {
+				passLive++
+				return nil
+			}
+
+			batch = append(batch, c)
+			if len(batch) == batchSize {
+				return purgeBatch()
+			}
+			return nil
+		})
+		if err == nil && len(batch) > 0 {
+			err = purgeBatch()
+		}
+		if err != nil {
+			return szPurged, err
+		}
+
+		log.Debugw("purge pass done", "pass", pass, "purged", passCnt, "live", passLive)
+		purgeCnt += passCnt
+		liveCnt = passLive
+		if passCnt == 0 {
+			break
+		}
+	}
+
+	log.Infow("purged objects without references", "purged", purgeCnt, "live", liveCnt, "size", szPurged)
+	stats.Record(s.ctx, metrics.SplitstoreCompactionCold.M(int64(purgeCnt)))
+	return szPurged, nil
+}
+
+// purgeGarbageBatch purges a batch of purge candidates from the hotstore, holding the transaction
+// lock, and drops their references; candidates referenced by writes since they were collected, or
+// protected by transactional i/o, are kept.
+func (s *SplitStore) purgeGarbageBatch(batch []cid.Cid, markSet MarkSet) (purgeCnt int, liveCnt int, szPurged int64, err error) {
+	if err := s.checkClosing(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	// copy the candidates first, so that the purged objects can still be read from the coldstore
+	if !s.config().DiscardColdBlocks {
+		if err := s.moveColdBatch(batch); err != nil {
+			return 0, 0, 0, xerrors.Errorf("error moving objects to the coldstore: %w", err)
+		}
+	}
+
+	s.txnLk.Lock()
+	defer s.txnLk.Unlock()
+
+	if err := s.protectTxnRefs(markSet); err != nil {
+		return 0, 0, 0, xerrors.Errorf("error protecting transactional refs: %w", err)
+	}
+
+	dead := make([]cid.Cid, 0, len(batch))
+	links := make([][]cid.Cid, 0, len(batch))
+	for _, c := range batch {
+		mark, err := markSet.Has(c)
+		if err != nil {
+			return 0, 0, 0, xerrors.Errorf("error checking markset for liveness: %w", err)
+		}
+		if mark {
+			liveCnt++
+			continue
+		}
+
+		garbage, err := s.refs.isGarbage(c)
+		if err != nil {
+			return 0, liveCnt, 0, err
+		}
+		if !garbage {
+			continue
+		}
+
+		var ls []cid.Cid
+		err = s.hot.View(s.ctx, c, func(data []byte) error {
+			szPurged += int64(len(data))
+			ls = objectLinks(data)
+			return nil
+		})
+		if err != nil && !ipld.IsNotFound(err) {
+			return 0, liveCnt, 0, xerrors.Errorf("error scanning links of %s: %w", c, err)
+		}
+
+		dead = append(dead, c)
+		links = append(links, ls)
+	}
+
+	if len(dead) == 0 {
+		return 0, liveCnt, 0, nil
+	}
+
+	// the objects are deleted before their references are dropped
+	if err := s.hot.DeleteMany(s.ctx, dead); err != nil {
+		return 0, liveCnt, 0, xerrors.Errorf("error purging objects: %w", err)
+	}
+
+	s.debug.LogDelete(dead)
+	if s.purgeHook != nil {
+		s.purgeHook(dead)
+	}
+
+	if err := s.refs.purge(dead, links); err != nil {
+		return len(dead), liveCnt, szPurged, xerrors.Errorf("error dropping references of purged objects: %w", err)
+	}
+
+	return len(dead), liveCnt, szPurged, nil
+}
@@ -0,0 +1,469 @@
+package splitstore
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	mh "github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func mkRefNode(t *testing.T, links ...cid.Cid) blocks.Block {
+	var buf bytes.Buffer
+	if err := cbg.WriteMajorTypeHeader(&buf, cbg.MajArray, uint64(len(links))); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range links {
+		if err := cbg.WriteCid(&buf, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := cid.Prefix{Version: 1, Codec: cid.DagCBOR, MhType: mh.SHA2_256, MhLength: -1}.Sum(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blk, err := blocks.NewBlockWithCid(buf.Bytes(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blk
+}
+
+func TestRefTracker(t *testing.T) {
+	refs, err := openRefTracker(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer refs.Close() //nolint:errcheck
+
+	expectCounts := func(expected map[blocks.Block]int64) {
+		t.Helper()
+		for blk, n := range expected {
+			count, err := refs.count(blk.Cid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != n {
+				t.Fatalf("expected %d references to %s, got %d", n, blk.Cid(), count)
+			}
+		}
+	}
+	expectGarbage := func(expected ...blocks.Block) {
+		t.Helper()
+		garbage := make(map[cid.Cid]struct{})
+		err := refs.forEachGarbage(func(c cid.Cid) error {
+			garbage[c] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(garbage) != len(expected) {
+			t.Fatalf("expected %d objects without references, got %d", len(expected), len(garbage))
+		}
+		for _, blk := range expected {
+			if _, ok := garbage[blk.Cid()]; !ok {
+				t.Fatalf("expected %s to have no references", blk.Cid())
+			}
+		}
+	}
+
+	// root -> (a -> leaf, b -> leaf)
+	leaf := blocks.NewBlock([]byte("leaf"))
+	a := mkRefNode(t, leaf.Cid())
+	b := mkRefNode(t, leaf.Cid(), blocks.NewBlock([]byte("b")).Cid())
+	root := mkRefNode(t, a.Cid(), b.Cid())
+
+	if err := refs.track([]blocks.Block{root, leaf, a, b}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 2, a: 1, b: 1, root: 0})
+	expectGarbage(root)
+
+	// objects are only tracked once
+	if err := refs.track([]blocks.Block{root, a}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 2, a: 1, b: 1, root: 0})
+
+	// purging the root leaves its links without references, the shared leaf is still linked
+	if err := refs.purge([]cid.Cid{root.Cid()}, [][]cid.Cid{objectLinks(root.RawData())}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 2, a: 0, b: 0})
+	expectGarbage(a, b)
+
+	if err := refs.purge([]cid.Cid{a.Cid()}, [][]cid.Cid{objectLinks(a.RawData())}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 1})
+	expectGarbage(b)
+
+	// links released by an object aren't dropped again when it is purged
+	if err := refs.release(b.Cid(), []cid.Cid{leaf.Cid()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := refs.release(b.Cid(), []cid.Cid{leaf.Cid()}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 0})
+	expectGarbage(b, leaf)
+
+	if err := refs.purge([]cid.Cid{b.Cid()}, [][]cid.Cid{objectLinks(b.RawData())}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 0})
+	expectGarbage(leaf)
+
+	// a purged object is tracked again when it is written again
+	if err := refs.track([]blocks.Block{a}); err != nil {
+		t.Fatal(err)
+	}
+	expectCounts(map[blocks.Block]int64{leaf: 1})
+	expectGarbage(a)
+}
+
+func TestRefTrackerHeaderLinks(t *testing.T) {
+	parent := mock.MkBlock(nil, 1, 1)
+	hdr := mock.MkBlock(mock.TipSet(parent), 1, 2)
+
+	sblk, err := hdr.ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pRef, err := types.NewTipSetKey(parent.Cid()).Cid()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := objectLinks(sblk.RawData())
+	expected := []cid.Cid{parent.Cid(), hdr.ParentStateRoot, hdr.ParentMessageReceipts, hdr.Messages, pRef}
+	if len(links) != len(expected) {
+		t.Fatalf("expected %d links, got %d", len(expected), len(links))
+	}
+	for i, c := range expected {
+		if links[i] != c {
+			t.Fatalf("expected link %d to be %s, got %s", i, c, links[i])
+		}
+	}
+
+	// not CBOR
+	if links := objectLinks([]byte{0xff, 0xff}); len(links) != 0 {
+		t.Fatalf("expected no links, got %d", len(links))
+	}
+}
+
+func TestSplitStoreRefCountStrategyConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"universal", Config{CompactionStrategy: CompactRefCount, UniversalColdBlocks: true}, true},
+		{"discard", Config{CompactionStrategy: CompactRefCount, DiscardColdBlocks: true}, true},
+		{"messages", Config{CompactionStrategy: CompactRefCount}, false},
+		{"ephemeral hotstore", Config{CompactionStrategy: CompactRefCount, UniversalColdBlocks: true, EphemeralHotStore: true}, false},
+		{"cold archives", Config{CompactionStrategy: CompactRefCount, UniversalColdBlocks: true, ColdArchivePath: "archive"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.MarkSetType = "map"
+			ss, err := Open(t.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), newMockStore(), bstore.NewMemory(), &tc.cfg)
+			if tc.ok != (err == nil) {
+				t.Fatalf("expected ok=%t, got error %v", tc.ok, err)
+			}
+			if err == nil {
+				_ = ss.Close()
+			}
+		})
+	}
+
+	// the reference counts are dropped when switching to another strategy, as the objects
+	// written meanwhile aren't tracked
+	path := t.TempDir()
+	hot, err := badgerbs.Open(badgerbs.DefaultOptions(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hot.Close() //nolint:errcheck
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ss, err := Open(path, ds, hot, bstore.NewMemory(), &Config{MarkSetType: "map", UniversalColdBlocks: true, CompactionStrategy: CompactRefCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "refs.badger")); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err = Open(path, ds, hot, bstore.NewMemory(), &Config{MarkSetType: "map", UniversalColdBlocks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint:errcheck
+	if _, err := os.Stat(filepath.Join(path, "refs.badger")); !os.IsNotExist(err) {
+		t.Fatalf("expected the reference tracker to be removed, got %v", err)
+	}
+}
+
+func TestSplitStoreRefCountCompaction(t *testing.T) {
+	ctx := context.Background()
+	chain := &mockChain{t: t}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := newMockStore()
+	cold := newMockStore()
+
+	garbage := blocks.NewBlock([]byte{1, 2, 3})
+	if err := cold.Put(ctx, garbage); err != nil {
+		t.Fatal(err)
+	}
+
+	genBlock := mock.MkBlock(nil, 0, 0)
+	genBlock.Messages = garbage.Cid()
+	genBlock.ParentMessageReceipts = garbage.Cid()
+	genBlock.ParentStateRoot = garbage.Cid()
+	genBlock.Timestamp = uint64(time.Now().Unix())
+
+	genTs := mock.TipSet(genBlock)
+	chain.push(genTs)
+
+	blk, err := genBlock.ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cold.Put(ctx, blk); err != nil {
+		t.Fatal(err)
+	}
+
+	// objects in the hotstore before the refcount strategy is enabled are counted
+	protected := blocks.NewBlock([]byte("protected!"))
+	unprotected := blocks.NewBlock([]byte("unprotected!"))
+	sharedLeaf := blocks.NewBlock([]byte("shared"))
+	shared := mkRefNode(t, sharedLeaf.Cid())
+	if err := hot.PutMany(ctx, []blocks.Block{protected, unprotected, sharedLeaf, shared}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{MarkSetType: "map", UniversalColdBlocks: true, CompactionStrategy: CompactRefCount}
+	ss, err := Open(t.TempDir(), ds, hot, cold, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	ss.AddProtector(func(protect func(cid.Cid) error) error {
+		return protect(protected.Cid())
+	})
+
+	if err := ss.Start(chain, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the state of every epoch links to a node shared by all states and a leaf of its own
+	var states, leaves []blocks.Block
+	mkBlock := func(curTs *types.TipSet, i int) *types.TipSet {
+		leaf := blocks.NewBlock([]byte{byte(i), 3, 3, 7})
+		stateRoot := mkRefNode(t, shared.Cid(), leaf.Cid())
+		states = append(states, stateRoot)
+		leaves = append(leaves, leaf)
+
+		blk := mock.MkBlock(curTs, uint64(i), uint64(i))
+		blk.Messages = garbage.Cid()
+		blk.ParentMessageReceipts = garbage.Cid()
+		blk.ParentStateRoot = stateRoot.Cid()
+		blk.Timestamp = uint64(time.Now().Unix())
+
+		sblk, err := blk.ToStorageBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ss.PutMany(ctx, []blocks.Block{leaf, stateRoot}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ss.Put(ctx, sblk); err != nil {
+			t.Fatal(err)
+		}
+		ts := mock.TipSet(blk)
+		chain.push(ts)
+
+		return ts
+	}
+
+	waitForCompaction := func() {
+		for atomic.LoadInt32(&ss.compacting) == 1 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	var headers []*types.TipSet
+	curTs := genTs
+	for i := 1; i < 10; i++ {
+		curTs = mkBlock(curTs, i)
+		headers = append(headers, curTs)
+		waitForCompaction()
+	}
+
+	if ss.compactionIndex != 1 {
+		t.Fatalf("expected 1 compaction, got %d", ss.compactionIndex)
+	}
+
+	expectHot := func(blk blocks.Block, inHot bool) {
+		t.Helper()
+		has, err := hot.Has(ctx, blk.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != inHot {
+			t.Fatalf("expected %s in hotstore: %t", blk.Cid(), inHot)
+		}
+		if inHot {
+			return
+		}
+
+		// purged objects are moved to the coldstore
+		has, err = cold.Has(ctx, blk.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Fatalf("expected purged object %s in coldstore", blk.Cid())
+		}
+	}
+
+	// compaction ran at epoch 6, with the boundary at epoch 4: the state of epochs 1 to 3 is
+	// purged, except for the node shared with the retained states
+	for i := range states {
+		epoch := i + 1
+		expectHot(states[i], epoch >= 4)
+		expectHot(leaves[i], epoch >= 4)
+	}
+	expectHot(shared, true)
+	expectHot(sharedLeaf, true)
+
+	// headers are kept all the way to genesis
+	for _, ts := range headers {
+		sblk, err := ts.Blocks()[0].ToStorageBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectHot(sblk, true)
+	}
+
+	expectHot(protected, true)
+	expectHot(unprotected, false)
+}
+
+// crashingStore fails writes while crashed, as if the process stopped before writing
+type crashingStore struct {
+	*mockStore
+	crashed bool
+}
+
+func (b *crashingStore) Put(ctx context.Context, blk blocks.Block) error {
+	if b.crashed {
+		return xerrors.Errorf("crashed")
+	}
+	return b.mockStore.Put(ctx, blk)
+}
+
+func (b *crashingStore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	if b.crashed {
+		return xerrors.Errorf("crashed")
+	}
+	return b.mockStore.PutMany(ctx, blks)
+}
+
+func TestSplitStoreRefCountCrashBeforeWrite(t *testing.T) {
+	ctx := context.Background()
+
+	path := t.TempDir()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := &crashingStore{mockStore: newMockStore()}
+	cfg := &Config{MarkSetType: "map", UniversalColdBlocks: true, CompactionStrategy: CompactRefCount}
+
+	ss, err := Open(path, ds, hot, bstore.NewMemory(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf := blocks.NewBlock([]byte("leaf"))
+	if err := ss.Put(ctx, leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	// the process stops while writing an object linking to the leaf
+	parent := mkRefNode(t, leaf.Cid())
+	hot.crashed = true
+	if err := ss.PutMany(ctx, []blocks.Block{parent}); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if err := ss.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	hot.crashed = false
+	ss, err = Open(path, ds, hot, bstore.NewMemory(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint:errcheck
+
+	expectRefs := func() {
+		t.Helper()
+
+		// the leaf is still referenced, so it isn't a purge candidate
+		n, err := ss.refs.count(leaf.Cid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Fatalf("expected 1 reference to the leaf, got %d", n)
+		}
+
+		var garbage []cid.Cid
+		err = ss.refs.forEachGarbage(func(c cid.Cid) error {
+			garbage = append(garbage, c)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(garbage) != 1 || !garbage[0].Equals(parent.Cid()) {
+			t.Fatalf("expected only the parent to be a purge candidate, got %v", garbage)
+		}
+	}
+	expectRefs()
+
+	// the parent is written again after the restart, without counting its links twice
+	if err := ss.Put(ctx, parent); err != nil {
+		t.Fatal(err)
+	}
+	expectRefs()
+
+	has, err := hot.Has(ctx, parent.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has {
+		t.Fatal("expected the parent in the hotstore")
+	}
+}
@@ -171,7 +171,10 @@ func (s *SplitStore) doReify(c cid.Cid) {
 	}
 
 	if len(batch) > 0 {
-		err = s.hot.PutMany(s.ctx, batch)
+		err = s.trackRefs(batch)
+		if err == nil {
+			err = s.hot.PutMany(s.ctx, batch)
+		}
 		if err != nil {
 			log.Warnf("error reifying cold object (cid: %s): %s", c, err)
 			return
@@ -0,0 +1,172 @@
+package splitstore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ReplayOp is an operation recorded in the debug log of a splitstore: either the
+// write of an object or the start of a compaction.
+type ReplayOp struct {
+	Time time.Time
+
+	// Write is the object written, for writes.
+	Write cid.Cid
+	// Compact is the head the compaction started at, for compactions.
+	Compact types.TipSetKey
+}
+
+// ReplayCompaction is the outcome of a replayed compaction.
+type ReplayCompaction struct {
+	Head *types.TipSet
+	// Purged are the objects purged from the hotstore by the compaction.
+	Purged []cid.Cid
+	// Missing are the objects reachable from the head within the compaction boundary
+	// which are missing from the splitstore after the compaction; if any of them has
+	// been purged, the compaction purged a live object.
+	Missing []cid.Cid
+	// Unavailable is the number of writes since the previous compaction that were
+	// skipped, as the objects were available neither in the source nor in the store.
+	Unavailable int
+}
+
+// Replay applies the recorded ops to the splitstore, which should be opened over a
+// copy of the stores and started, but not attached to a running chain. Objects written
+// are read from source, falling back to the store itself, and compactions run
+// synchronously at the recorded heads; writes made concurrently with a compaction are
+// applied after it completes, which makes the replay deterministic.
+// cb is called with the outcome of every compaction.
+func (s *SplitStore) Replay(ops []ReplayOp, source bstore.Blockstore, cb func(*ReplayCompaction) error) error {
+	var unavailable int
+	for _, op := range ops {
+		if err := s.checkClosing(); err != nil {
+			return err
+		}
+
+		switch {
+		case op.Write.Defined():
+			blk, err := s.replayGet(op.Write, source)
+			if ipld.IsNotFound(err) {
+				log.Warnw("object written is unavailable, skipping write", "cid", op.Write)
+				unavailable++
+				continue
+			}
+			if err != nil {
+				return xerrors.Errorf("error getting object %s: %w", op.Write, err)
+			}
+
+			if err := s.Put(s.ctx, blk); err != nil {
+				return xerrors.Errorf("error writing object %s: %w", op.Write, err)
+			}
+
+		case !op.Compact.IsEmpty():
+			res, err := s.replayCompaction(op.Compact, source)
+			if err != nil {
+				return xerrors.Errorf("error replaying compaction at %s: %w", op.Compact, err)
+			}
+			res.Unavailable = unavailable
+			unavailable = 0
+
+			if err := cb(res); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *SplitStore) replayGet(c cid.Cid, source bstore.Blockstore) (blocks.Block, error) {
+	blk, err := source.Get(s.ctx, c)
+	if ipld.IsNotFound(err) {
+		return s.get(c)
+	}
+	return blk, err
+}
+
+func (s *SplitStore) replayCompaction(key types.TipSetKey, source bstore.Blockstore) (*ReplayCompaction, error) {
+	var hdrs []*types.BlockHeader
+	for _, c := range key.Cids() {
+		blk, err := s.replayGet(c, source)
+		if err != nil {
+			return nil, xerrors.Errorf("error getting block header %s: %w", c, err)
+		}
+
+		hdr, err := types.DecodeBlock(blk.RawData())
+		if err != nil {
+			return nil, xerrors.Errorf("error decoding block header %s: %w", c, err)
+		}
+		hdrs = append(hdrs, hdr)
+	}
+
+	curTs, err := types.NewTipSet(hdrs)
+	if err != nil {
+		return nil, xerrors.Errorf("error creating tipset: %w", err)
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.compacting, 0, 1) {
+		return nil, xerrors.Errorf("background operation in progress")
+	}
+	defer atomic.StoreInt32(&s.compacting, 0)
+
+	res := &ReplayCompaction{Head: curTs}
+
+	s.beginTxnProtect()
+	s.compactType = hot
+	s.purgeHook = func(cids []cid.Cid) {
+		res.Purged = append(res.Purged, cids...)
+	}
+
+	// the replayed chain is synced by construction
+	s.txnSyncMx.Lock()
+	s.txnSync = true
+	s.txnSyncMx.Unlock()
+
+	s.viewWait()
+	err = s.doCompact(curTs)
+	s.setCompactionPhase("")
+	s.purgeHook = nil
+	s.endTxnProtect()
+	s.recordResult("compaction", err)
+	if err != nil {
+		return nil, err
+	}
+
+	// check that everything live at the head survived the compaction
+	var mx sync.Mutex
+	boundaryEpoch := curTs.Height() - CompactionBoundary
+	stopWalk := func(_ cid.Cid) error { return errStopWalk }
+	err = s.walkChain(curTs, boundaryEpoch, boundaryEpoch, newTmpVisitor(),
+		func(c cid.Cid) error {
+			if isUnitaryObject(c) {
+				return errStopWalk
+			}
+
+			has, err := s.has(c)
+			if err != nil {
+				return xerrors.Errorf("error checking for %s: %w", c, err)
+			}
+			if !has {
+				mx.Lock()
+				res.Missing = append(res.Missing, c)
+				mx.Unlock()
+				return errStopWalk
+			}
+			return nil
+		}, stopWalk)
+	// a missing block header ends the walk, as its parents can't be found
+	if err != nil && !xerrors.Is(err, errStopWalk) {
+		return nil, xerrors.Errorf("error checking live objects: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,92 @@
+package splitstore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+var (
+	// ShutdownProgressInterval is the interval at which Close logs the progress of the
+	// background operation it is waiting for.
+	ShutdownProgressInterval = 30 * time.Second
+	// ShutdownAbortTimeout is how long Close waits for the background operation to stop
+	// once it has been forcibly aborted at the end of the grace period.
+	ShutdownAbortTimeout = time.Minute
+
+	// shutdownPollInterval is the interval at which Close checks whether the background
+	// operation is done.
+	shutdownPollInterval = time.Second
+)
+
+var compactTypeNames = map[CompactType]string{
+	none:   "none",
+	warmup: "warmup",
+	hot:    "compaction",
+	cold:   "prune",
+	check:  "check",
+	backup: "backup",
+	dedup:  "dedup",
+}
+
+// setCompactionPhase records the phase of the running compaction or prune, so that it can
+// be reported while waiting for it to finish on shutdown.
+func (s *SplitStore) setCompactionPhase(phase string) {
+	s.compactPhase.Store(phase)
+	s.compactPhaseStart.Store(time.Now())
+}
+
+func (s *SplitStore) compactionPhase() (string, time.Duration) {
+	phase, _ := s.compactPhase.Load().(string)
+	start, _ := s.compactPhaseStart.Load().(time.Time)
+	if phase == "" || start.IsZero() {
+		return "", 0
+	}
+	return phase, time.Since(start)
+}
+
+// waitForCompaction waits for the background operation in progress to notice that the
+// splitstore is closing, for up to the shutdown grace period, logging its progress. Once
+// the grace period elapses, the operation is aborted by cancelling the splitstore context;
+// the operations either reach a checkpoint or only leave work which is redone by the next
+// one, so the store is recovered when it is opened again.
+// It returns an error if the operation still runs after ShutdownAbortTimeout.
+func (s *SplitStore) waitForCompaction() error {
+	grace := s.config().ShutdownGracePeriod
+	op := compactTypeNames[s.compactType]
+
+	log.Warnw("close with ongoing background operation in progress; waiting for it to finish...",
+		"operation", op, "grace period", grace)
+
+	start := time.Now()
+	lastProgress := start
+	aborted := false
+	for atomic.LoadInt32(&s.compacting) == 1 {
+		time.Sleep(shutdownPollInterval)
+
+		waited := time.Since(start)
+		if time.Since(lastProgress) >= ShutdownProgressInterval {
+			phase, inPhase := s.compactionPhase()
+			log.Warnw("waiting for background operation to finish", "operation", op,
+				"phase", phase, "in phase", inPhase.Truncate(time.Second), "waited", waited.Truncate(time.Second))
+			lastProgress = time.Now()
+		}
+
+		switch {
+		case !aborted && grace > 0 && waited >= grace:
+			phase, _ := s.compactionPhase()
+			log.Errorw("background operation didn't finish within the shutdown grace period; aborting it",
+				"operation", op, "phase", phase, "grace period", grace)
+			aborted = true
+			s.cancel()
+
+		case aborted && waited >= grace+ShutdownAbortTimeout:
+			phase, _ := s.compactionPhase()
+			return xerrors.Errorf("%s still running in phase %q after being aborted; the splitstore will be recovered when opened again", op, phase)
+		}
+	}
+
+	log.Infow("background operation finished", "operation", op, "waited", time.Since(start).Truncate(time.Second))
+	return nil
+}
@@ -0,0 +1,77 @@
+package splitstore
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+)
+
+func TestCloseWaitsForCompaction(t *testing.T) {
+	shutdownPollInterval = 10 * time.Millisecond
+	abortTimeout := ShutdownAbortTimeout
+	ShutdownAbortTimeout = 200 * time.Millisecond
+	defer func() {
+		shutdownPollInterval = time.Second
+		ShutdownAbortTimeout = abortTimeout
+	}()
+
+	open := func(grace time.Duration) *SplitStore {
+		ds := dssync.MutexWrap(datastore.NewMapDatastore())
+		ss, err := Open(t.TempDir(), ds, newMockStore(), newMockStore(), &Config{
+			MarkSetType:         "map",
+			ShutdownGracePeriod: grace,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ss
+	}
+
+	// a compaction noticing the closing flag finishes within the grace period
+	ss := open(time.Minute)
+	atomic.StoreInt32(&ss.compacting, 1)
+	ss.compactType = hot
+	ss.setCompactionPhase("marking")
+	var aborted error
+	go func() {
+		for atomic.LoadInt32(&ss.closing) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+		aborted = ss.ctx.Err()
+		atomic.StoreInt32(&ss.compacting, 0)
+	}()
+	if err := ss.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if aborted != nil {
+		t.Fatal("expected the compaction to finish without being aborted")
+	}
+
+	// a compaction blocked in a context aware operation is aborted at the end of the
+	// grace period
+	ss = open(50 * time.Millisecond)
+	atomic.StoreInt32(&ss.compacting, 1)
+	ss.compactType = hot
+	ss.setCompactionPhase("gc")
+	go func() {
+		<-ss.ctx.Done()
+		atomic.StoreInt32(&ss.compacting, 0)
+	}()
+	if err := ss.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a compaction which doesn't stop at all makes Close fail once aborting it times out
+	ss = open(50 * time.Millisecond)
+	atomic.StoreInt32(&ss.compacting, 1)
+	ss.compactType = hot
+	ss.setCompactionPhase("purging")
+	if err := ss.Close(); err == nil {
+		t.Fatal("expected Close to fail")
+	}
+	atomic.StoreInt32(&ss.compacting, 0)
+}
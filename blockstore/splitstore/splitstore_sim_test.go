@@ -0,0 +1,509 @@
+package splitstore
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// simConfig parameterizes the synthetic chain generated by simHarness.
+type simConfig struct {
+	// Seed of the generator; chains generated with the same config are identical.
+	Seed int64
+	// StateSize is the number of leaves of the state tree.
+	StateSize int
+	// Fanout is the number of children of the interior nodes of the state tree.
+	Fanout int
+	// Churn is the number of state leaves rewritten every epoch.
+	Churn int
+	// Blocks is the number of blocks of every tipset.
+	Blocks int
+	// Messages is the number of messages of every block.
+	Messages int
+}
+
+// simHarness generates a synthetic chain, with a state tree partially rewritten
+// every epoch, and drives the head changes of a splitstore with it. The generated
+// objects are dag-cbor, so that the splitstore walks them as it walks the objects
+// of a real chain.
+type simHarness struct {
+	t   testing.TB
+	ctx context.Context
+	cfg simConfig
+	rng *rand.Rand
+
+	hot   *mockStore
+	cold  *mockStore
+	chain *mockChain
+	ss    *SplitStore
+
+	leaves  []cid.Cid
+	head    *types.TipSet
+	written map[cid.Cid]struct{}
+
+	// src holds every generated object and ops records the writes and compactions,
+	// as they would be recorded in the debug log, for replaying the chain
+	src *mockStore
+	ops []ReplayOp
+}
+
+func newSimHarness(t testing.TB, cfg simConfig, ssCfg *Config) *simHarness {
+	return newSimHarnessWithColdstore(t, cfg, ssCfg, func(cold *mockStore) blockstore.Blockstore {
+		return cold
+	})
+}
+
+// newSimHarnessWithColdstore opens the splitstore with the coldstore returned by
+// wrap, such as a faulty view of the coldstore.
+func newSimHarnessWithColdstore(t testing.TB, cfg simConfig, ssCfg *Config, wrap func(*mockStore) blockstore.Blockstore) *simHarness {
+	checkSyncGap := CheckSyncGap
+	CheckSyncGap = false
+	t.Cleanup(func() {
+		CheckSyncGap = checkSyncGap
+	})
+
+	h := &simHarness{
+		t:       t,
+		ctx:     context.Background(),
+		cfg:     cfg,
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		hot:     newMockStore(),
+		cold:    newMockStore(),
+		chain:   &mockChain{t: t},
+		written: make(map[cid.Cid]struct{}),
+		src:     newMockStore(),
+	}
+
+	// the genesis state lives in the coldstore, as after a snapshot import
+	var genesis []blocks.Block
+	for i := 0; i < cfg.StateSize; i++ {
+		leaf := h.mkLeaf()
+		genesis = append(genesis, leaf)
+		h.leaves = append(h.leaves, leaf.Cid())
+	}
+	stateRoot, nodes := h.mkTree(h.leaves)
+	genesis = append(genesis, nodes...)
+	empty := h.mkNode(nil, nil)
+	genesis = append(genesis, empty)
+
+	genBlock := mock.MkBlock(nil, 0, 0)
+	genBlock.ParentStateRoot = stateRoot
+	genBlock.Messages = empty.Cid()
+	genBlock.ParentMessageReceipts = empty.Cid()
+	sblk, err := genBlock.ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis = append(genesis, sblk)
+
+	for _, blk := range genesis {
+		h.written[blk.Cid()] = struct{}{}
+	}
+	if err := h.cold.PutMany(h.ctx, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.src.PutMany(h.ctx, genesis); err != nil {
+		t.Fatal(err)
+	}
+
+	h.head = mock.TipSet(genBlock)
+	h.chain.push(h.head)
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	h.ss, err = Open(t.TempDir(), ds, h.hot, wrap(h.cold), ssCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = h.ss.Close()
+	})
+
+	if err := h.ss.Start(h.chain, nil); err != nil {
+		t.Fatal(err)
+	}
+	h.waitForCompaction()
+
+	return h
+}
+
+// mkNode returns a dag-cbor object linking to links.
+func (h *simHarness) mkNode(links []cid.Cid, payload []byte) blocks.Block {
+	var buf bytes.Buffer
+	if err := cbg.WriteMajorTypeHeader(&buf, cbg.MajArray, 2); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := cbg.WriteMajorTypeHeader(&buf, cbg.MajArray, uint64(len(links))); err != nil {
+		h.t.Fatal(err)
+	}
+	for _, l := range links {
+		if err := cbg.WriteCid(&buf, l); err != nil {
+			h.t.Fatal(err)
+		}
+	}
+	if err := cbg.WriteByteArray(&buf, payload); err != nil {
+		h.t.Fatal(err)
+	}
+
+	c, err := abi.CidBuilder.Sum(buf.Bytes())
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	blk, err := blocks.NewBlockWithCid(buf.Bytes(), c)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return blk
+}
+
+func (h *simHarness) mkLeaf() blocks.Block {
+	payload := make([]byte, 32)
+	_, _ = h.rng.Read(payload)
+	return h.mkNode(nil, payload)
+}
+
+// mkTree builds the interior nodes of the state tree over the leaves, returning
+// the root and the nodes. Subtrees with unchanged leaves hash to the same nodes,
+// so consecutive states share most of their objects, as in a real state tree.
+func (h *simHarness) mkTree(leaves []cid.Cid) (cid.Cid, []blocks.Block) {
+	var nodes []blocks.Block
+	level := leaves
+	for {
+		var next []cid.Cid
+		for i := 0; i < len(level); i += h.cfg.Fanout {
+			end := i + h.cfg.Fanout
+			if end > len(level) {
+				end = len(level)
+			}
+			node := h.mkNode(level[i:end], nil)
+			nodes = append(nodes, node)
+			next = append(next, node.Cid())
+		}
+		if len(next) == 1 {
+			return next[0], nodes
+		}
+		level = next
+	}
+}
+
+// put writes the objects not written yet to the splitstore, as the VM only
+// flushes new objects.
+func (h *simHarness) put(blks []blocks.Block) {
+	var fresh []blocks.Block
+	for _, blk := range blks {
+		if _, ok := h.written[blk.Cid()]; ok {
+			continue
+		}
+		h.written[blk.Cid()] = struct{}{}
+		fresh = append(fresh, blk)
+		h.ops = append(h.ops, ReplayOp{Write: blk.Cid()})
+	}
+
+	if err := h.ss.PutMany(h.ctx, fresh); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := h.src.PutMany(h.ctx, fresh); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// advance generates epochs tipsets, applying them to the splitstore one at a
+// time and waiting for the compactions they trigger.
+func (h *simHarness) advance(epochs int) {
+	for e := 0; e < epochs; e++ {
+		var objs []blocks.Block
+		for i := 0; i < h.cfg.Churn; i++ {
+			leaf := h.mkLeaf()
+			objs = append(objs, leaf)
+			h.leaves[h.rng.Intn(len(h.leaves))] = leaf.Cid()
+		}
+		stateRoot, nodes := h.mkTree(h.leaves)
+		objs = append(objs, nodes...)
+
+		var blks []*types.BlockHeader
+		for b := 0; b < h.cfg.Blocks; b++ {
+			var msgs []cid.Cid
+			for m := 0; m < h.cfg.Messages; m++ {
+				msg := h.mkLeaf()
+				objs = append(objs, msg)
+				msgs = append(msgs, msg.Cid())
+			}
+			meta := h.mkNode(msgs, nil)
+			receipts := h.mkNode(nil, []byte{byte(len(msgs))})
+			objs = append(objs, meta, receipts)
+
+			blk := mock.MkBlock(h.head, 1, uint64(h.head.Height())*uint64(h.cfg.Blocks)+uint64(b+1))
+			blk.ParentStateRoot = stateRoot
+			blk.Messages = meta.Cid()
+			blk.ParentMessageReceipts = receipts.Cid()
+			sblk, err := blk.ToStorageBlock()
+			if err != nil {
+				h.t.Fatal(err)
+			}
+			objs = append(objs, sblk)
+			blks = append(blks, blk)
+		}
+
+		h.put(objs)
+		h.head = mock.TipSet(blks...)
+		index := h.ss.compactionIndex
+		h.chain.push(h.head)
+		h.waitForCompaction()
+		if h.ss.compactionIndex != index {
+			h.ops = append(h.ops, ReplayOp{Compact: h.head.Key()})
+		}
+	}
+}
+
+// waitForCompaction waits for the background operation in progress; compactions
+// don't wait for the chain to sync, as the sync gap check is disabled.
+func (h *simHarness) waitForCompaction() {
+	for atomic.LoadInt32(&h.ss.compacting) == 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// walk calls f with every object reachable from c in bs.
+func (h *simHarness) walk(bs blockstore.Blockstore, c cid.Cid, f func(cid.Cid)) {
+	f(c)
+	var links []cid.Cid
+	err := bs.View(h.ctx, c, func(data []byte) error {
+		return cbg.ScanForLinks(bytes.NewReader(data), func(l cid.Cid) {
+			links = append(links, l)
+		})
+	})
+	if err != nil {
+		h.t.Fatalf("walking %s: %s", c, err)
+	}
+	for _, l := range links {
+		h.walk(bs, l, f)
+	}
+}
+
+func (h *simHarness) count(bs *mockStore) int {
+	count := 0
+	_ = bs.ForEachKey(func(_ cid.Cid) error {
+		count++
+		return nil
+	})
+	return count
+}
+
+func TestSplitStoreSimCompaction(t *testing.T) {
+	h := newSimHarness(t, simConfig{
+		Seed:      1,
+		StateSize: 1000,
+		Fanout:    8,
+		Churn:     20,
+		Blocks:    2,
+		Messages:  4,
+	}, &Config{MarkSetType: "map", UniversalColdBlocks: true})
+
+	h.advance(40)
+
+	if h.ss.compactionIndex == 0 {
+		t.Fatal("expected compactions to run")
+	}
+
+	// the state of the head is hot
+	h.walk(h.ss, h.head.ParentState(), func(c cid.Cid) {
+		has, err := h.hot.Has(h.ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Fatalf("object %s of the head state is missing from the hotstore", c)
+		}
+	})
+
+	// with a universal coldstore, nothing is lost
+	for c := range h.written {
+		has, err := h.ss.Has(h.ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !has {
+			t.Fatalf("object %s is missing from the splitstore", c)
+		}
+	}
+
+	// and old objects have been moved to the coldstore
+	hotCnt, coldCnt := h.count(h.hot), h.count(h.cold)
+	if hotCnt >= len(h.written) {
+		t.Fatalf("expected the hotstore to be compacted, but it has %d of the %d objects", hotCnt, len(h.written))
+	}
+	if coldCnt <= 1000 {
+		t.Fatalf("expected objects to be moved to the coldstore, but it only has %d objects", coldCnt)
+	}
+}
+
+func TestSplitStoreSimColdstoreFaults(t *testing.T) {
+	cfg := simConfig{
+		Seed:      3,
+		StateSize: 300,
+		Fanout:    4,
+		Churn:     10,
+		Blocks:    2,
+		Messages:  2,
+	}
+
+	for name, faults := range map[string]blockstore.FaultConfig{
+		"write": {WriteFailureRate: 1},
+		"read":  {NotFoundRate: 0.05, Seed: 1},
+	} {
+		faults := faults
+		t.Run(name, func(t *testing.T) {
+			h := newSimHarnessWithColdstore(t, cfg, &Config{MarkSetType: "map", UniversalColdBlocks: true},
+				func(cold *mockStore) blockstore.Blockstore {
+					return blockstore.NewFaulty(cold, faults)
+				})
+			h.advance(30)
+
+			if faults.WriteFailureRate == 1 {
+				// no compaction can move the cold objects
+				h.ss.errMx.Lock()
+				lastErr := h.ss.lastErr
+				h.ss.errMx.Unlock()
+				if h.ss.compactionIndex != 0 || !xerrors.Is(lastErr, blockstore.ErrInjectedFault) {
+					t.Fatalf("expected compactions to fail with injected faults, got %d compactions, last error: %v", h.ss.compactionIndex, lastErr)
+				}
+			}
+
+			// whether or not compactions failed, nothing written is lost
+			for c := range h.written {
+				hot, err := h.hot.Has(h.ctx, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cold, err := h.cold.Has(h.ctx, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !hot && !cold {
+					t.Fatalf("object %s has been lost", c)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitStoreSimDeterministic(t *testing.T) {
+	cfg := simConfig{
+		Seed:      42,
+		StateSize: 200,
+		Fanout:    4,
+		Churn:     10,
+		Blocks:    3,
+		Messages:  2,
+	}
+
+	// every run is a subtest, so that its splitstore is closed before the next one
+	run := func(name string) (head types.TipSetKey, hot, cold int) {
+		t.Run(name, func(t *testing.T) {
+			h := newSimHarness(t, cfg, &Config{MarkSetType: "map", UniversalColdBlocks: true})
+			h.advance(20)
+			head, hot, cold = h.head.Key(), h.count(h.hot), h.count(h.cold)
+		})
+		return head, hot, cold
+	}
+
+	head1, hot1, cold1 := run("first")
+	head2, hot2, cold2 := run("second")
+	if head1 != head2 {
+		t.Fatalf("expected the same chain, got heads %s and %s", head1, head2)
+	}
+	if hot1 != hot2 || cold1 != cold2 {
+		t.Fatalf("expected the same compaction results, got %d/%d and %d/%d hot/cold objects", hot1, cold1, hot2, cold2)
+	}
+}
+
+func TestSplitStoreReplay(t *testing.T) {
+	cfg := simConfig{
+		Seed:      7,
+		StateSize: 300,
+		Fanout:    4,
+		Churn:     10,
+		Blocks:    2,
+		Messages:  3,
+	}
+	ssCfg := &Config{MarkSetType: "map", UniversalColdBlocks: true}
+
+	// record a chain, together with the objects purged by its compactions
+	var (
+		ops                 []ReplayOp
+		src                 *mockStore
+		purged              []cid.Cid
+		hot, cold           int
+		recordedCompactions int
+	)
+	t.Run("record", func(t *testing.T) {
+		h := newSimHarness(t, cfg, ssCfg)
+		h.ss.purgeHook = func(cids []cid.Cid) {
+			purged = append(purged, cids...)
+		}
+		h.advance(30)
+
+		ops, src = h.ops, h.src
+		hot, cold = h.count(h.hot), h.count(h.cold)
+		recordedCompactions = int(h.ss.compactionIndex)
+	})
+	if recordedCompactions == 0 {
+		t.Fatal("expected compactions to run")
+	}
+
+	// and replay it against a fresh copy of the stores
+	t.Run("replay", func(t *testing.T) {
+		h := newSimHarness(t, cfg, ssCfg)
+
+		var replayed []cid.Cid
+		compactions := 0
+		err := h.ss.Replay(ops, src, func(res *ReplayCompaction) error {
+			compactions++
+			if len(res.Missing) > 0 {
+				t.Fatalf("compaction at %d lost %d live objects", res.Head.Height(), len(res.Missing))
+			}
+			if res.Unavailable > 0 {
+				t.Fatalf("%d objects unavailable", res.Unavailable)
+			}
+			replayed = append(replayed, res.Purged...)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if compactions != recordedCompactions {
+			t.Fatalf("expected %d compactions, replayed %d", recordedCompactions, compactions)
+		}
+		if len(replayed) != len(purged) {
+			t.Fatalf("expected %d objects purged, replay purged %d", len(purged), len(replayed))
+		}
+		recorded := make(map[cid.Cid]struct{}, len(purged))
+		for _, c := range purged {
+			recorded[c] = struct{}{}
+		}
+		for _, c := range replayed {
+			if _, ok := recorded[c]; !ok {
+				t.Fatalf("replay purged %s, which was not purged when recorded", c)
+			}
+		}
+		if h.count(h.hot) != hot || h.count(h.cold) != cold {
+			t.Fatalf("expected %d/%d hot/cold objects, replay has %d/%d", hot, cold, h.count(h.hot), h.count(h.cold))
+		}
+	})
+}
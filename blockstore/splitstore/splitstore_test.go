@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"sync"
 	"sync/atomic"
@@ -691,6 +692,22 @@ func (b *mockStore) Has(_ context.Context, cid cid.Cid) (bool, error) {
 	return ok, nil
 }
 
+func (b *mockStore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	res := make([]bool, len(cids))
+	for i, c := range cids {
+		res[i], _ = b.Has(ctx, c)
+	}
+	return res, nil
+}
+
+func (b *mockStore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	res := make([]blocks.Block, len(cids))
+	for i, c := range cids {
+		res[i], _ = b.Get(ctx, c)
+	}
+	return res, nil
+}
+
 func (b *mockStore) HashOnRead(hor bool) {}
 
 func (b *mockStore) Get(_ context.Context, cid cid.Cid) (blocks.Block, error) {
@@ -779,3 +796,99 @@ func (b *mockStore) ForEachKey(f func(cid.Cid) error) error {
 func (b *mockStore) Close() error {
 	return nil
 }
+
+func TestSplitStoreDiskSpaceWatchdog(t *testing.T) {
+	ctx := context.Background()
+	chain := &mockChain{t: t}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := newMockStore()
+	cold := newMockStore()
+
+	genBlock := mock.MkBlock(nil, 0, 0)
+	genBlock.Timestamp = uint64(time.Now().Unix())
+	chain.push(mock.TipSet(genBlock))
+
+	// no filesystem has that much free space, so the splitstore is always critically low
+	ss, err := Open(t.TempDir(), ds, hot, cold, &Config{
+		MarkSetType:                "map",
+		DiskSpaceLowThreshold:      math.MaxUint64,
+		DiskSpaceCriticalThreshold: math.MaxUint64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	var hookErr error
+	ss.AddOperationHook(func(op string, err error) {
+		if op == "disk-space" {
+			hookErr = err
+		}
+	})
+
+	err = ss.Start(chain, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hookErr == nil {
+		t.Fatal("expected disk space operation hook to report an error")
+	}
+
+	if state := ss.Info()["disk space"]; state != "critical" {
+		t.Fatalf("expected critical disk space state, got %v", state)
+	}
+
+	blk := blocks.NewBlock([]byte("non-consensus"))
+	if err := ss.Expose().Put(ctx, blk); !errors.Is(err, ErrDiskSpaceCritical) {
+		t.Fatalf("expected exposed write to be refused, got %v", err)
+	}
+
+	// writes through the splitstore itself are still accepted
+	if err := ss.Put(ctx, blk); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplitStoreLastError(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ss, err := Open(t.TempDir(), ds, newMockStore(), newMockStore(), &Config{MarkSetType: "map"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	ss.recordResult("compaction", errors.New("transient error"))
+	if _, ok := ss.Info()["last error"]; !ok {
+		t.Fatal("expected the compaction error to be reported")
+	}
+
+	// another operation succeeding doesn't clear the error
+	ss.recordResult("prune", nil)
+	if lastErr := ss.Info()["last error"]; lastErr != "compaction: transient error" {
+		t.Fatalf("expected the compaction error to be reported, got %v", lastErr)
+	}
+
+	// the next successful compaction does
+	ss.recordResult("compaction", nil)
+	if lastErr, ok := ss.Info()["last error"]; ok {
+		t.Fatalf("expected no error after a successful compaction, got %v", lastErr)
+	}
+}
+
+func TestSetFinality(t *testing.T) {
+	saved := []abi.ChainEpoch{finality, upgradeBoundary, CompactionThreshold, CompactionBoundary, PruneThreshold, WarmupBoundary}
+	defer func() {
+		finality, upgradeBoundary, CompactionThreshold, CompactionBoundary, PruneThreshold, WarmupBoundary =
+			saved[0], saved[1], saved[2], saved[3], saved[4], saved[5]
+	}()
+
+	SetFinality(10)
+	if CompactionThreshold != 50 || CompactionBoundary != 40 || PruneThreshold != 70 {
+		t.Fatalf("unexpected thresholds: compaction %d, boundary %d, prune %d", CompactionThreshold, CompactionBoundary, PruneThreshold)
+	}
+	if WarmupBoundary != 10 || upgradeBoundary != 10 || finality != 10 {
+		t.Fatalf("unexpected boundaries: warmup %d, upgrade %d, finality %d", WarmupBoundary, upgradeBoundary, finality)
+	}
+}
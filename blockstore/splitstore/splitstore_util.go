@@ -1,10 +1,12 @@
 package splitstore
 
 import (
+	"bytes"
 	"encoding/binary"
 
 	"github.com/ipfs/go-cid"
 	mh "github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -64,3 +66,16 @@ func decodeIdentityCid(c cid.Cid) ([]byte, error) {
 
 	return dmh.Digest, nil
 }
+
+// scanLinks scans the cbor encoded data for links; cbor-gen panics on some malformed
+// links, which is recovered as an error so that a corrupt object fails only the walk
+// that hit it.
+func scanLinks(data []byte, cb func(cid.Cid)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("malformed object: %v", r)
+		}
+	}()
+
+	return cbg.ScanForLinks(bytes.NewReader(data), cb)
+}
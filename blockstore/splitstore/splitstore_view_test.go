@@ -0,0 +1,106 @@
+package splitstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BenchmarkSplitStoreStateRead compares decoding state objects read as blocks with decoding
+// them in place through View, from a splitstore backed by badger.
+func BenchmarkSplitStoreStateRead(b *testing.B) {
+	ctx := context.Background()
+
+	openBadger := func() *badgerbs.Blockstore {
+		bs, err := badgerbs.Open(badgerbs.DefaultOptions(b.TempDir()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { _ = bs.Close() })
+		return bs
+	}
+
+	hot := openBadger()
+	cold := openBadger()
+
+	mkActors := func(bs bstore.Blockstore, n int) []cid.Cid {
+		code := blocks.NewBlock([]byte("code")).Cid()
+		cids := make([]cid.Cid, n)
+		blks := make([]blocks.Block, n)
+		for i := range blks {
+			act := &types.Actor{
+				Code:    code,
+				Head:    code,
+				Nonce:   uint64(i),
+				Balance: types.NewInt(uint64(i)),
+			}
+
+			var buf bytes.Buffer
+			if err := act.MarshalCBOR(&buf); err != nil {
+				b.Fatal(err)
+			}
+
+			c, err := abi.CidBuilder.WithCodec(cid.DagCBOR).Sum(buf.Bytes())
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			blks[i], err = blocks.NewBlockWithCid(buf.Bytes(), c)
+			if err != nil {
+				b.Fatal(err)
+			}
+			cids[i] = c
+		}
+
+		if err := bs.PutMany(ctx, blks); err != nil {
+			b.Fatal(err)
+		}
+		return cids
+	}
+
+	hotCids := mkActors(hot, 1024)
+	coldCids := mkActors(cold, 1024)
+
+	ss, err := Open(b.TempDir(), dssync.MutexWrap(datastore.NewMapDatastore()), hot, cold, &Config{MarkSetType: "map"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = ss.Close() })
+
+	for _, store := range []struct {
+		name string
+		cids []cid.Cid
+	}{{"hot", hotCids}, {"cold", coldCids}} {
+		for _, read := range []struct {
+			name string
+			cst  cbor.IpldStore
+		}{
+			{"Get", cbor.NewCborStore(ss)},
+			{"View", bstore.NewCborStore(ctx, ss)},
+		} {
+			b.Run(store.name+"/"+read.name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				var act types.Actor
+				for i := 0; i < b.N; i++ {
+					if err := read.cst.Get(ctx, store.cids[i%len(store.cids)], &act); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
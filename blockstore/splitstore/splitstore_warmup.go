@@ -38,10 +38,12 @@ func (s *SplitStore) warmup(curTs *types.TipSet) error {
 		err := s.doWarmup(curTs)
 		if err != nil {
 			log.Errorf("error warming up hotstore: %s", err)
+			s.recordResult("warmup", err)
 			return
 		}
 
 		log.Infow("warm up done", "took", time.Since(start))
+		s.recordResult("warmup", nil)
 	}()
 
 	return nil
@@ -100,7 +102,10 @@ func (s *SplitStore) doWarmup(curTs *types.TipSet) error {
 			mx.Lock()
 			batchHot = append(batchHot, blk)
 			if len(batchHot) == batchSize {
-				err = s.hot.PutMany(s.ctx, batchHot)
+				err = s.trackRefs(batchHot)
+				if err == nil {
+					err = s.hot.PutMany(s.ctx, batchHot)
+				}
 				if err != nil {
 					mx.Unlock()
 					return err
@@ -117,6 +122,9 @@ func (s *SplitStore) doWarmup(curTs *types.TipSet) error {
 	}
 
 	if len(batchHot) > 0 {
+		if err := s.trackRefs(batchHot); err != nil {
+			return err
+		}
 		err = s.hot.PutMany(s.ctx, batchHot)
 		if err != nil {
 			return err
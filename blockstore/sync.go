@@ -40,6 +40,18 @@ func (m *SyncBlockstore) Has(ctx context.Context, k cid.Cid) (bool, error) {
 	return m.bs.Has(ctx, k)
 }
 
+func (m *SyncBlockstore) HasMany(ctx context.Context, ks []cid.Cid) ([]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.bs.HasMany(ctx, ks)
+}
+
+func (m *SyncBlockstore) GetMany(ctx context.Context, ks []cid.Cid) ([]blocks.Block, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.bs.GetMany(ctx, ks)
+}
+
 func (m *SyncBlockstore) View(ctx context.Context, k cid.Cid, callback func([]byte) error) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -165,6 +165,26 @@ func (t *TimedCacheBlockstore) Has(ctx context.Context, k cid.Cid) (bool, error)
 	return t.inactive.Has(ctx, k)
 }
 
+func (t *TimedCacheBlockstore) HasMany(ctx context.Context, ks []cid.Cid) ([]bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res, err := t.active.HasMany(ctx, ks)
+	if err != nil {
+		return nil, err
+	}
+	return hasManyFrom(ctx, t.inactive, ks, res)
+}
+
+func (t *TimedCacheBlockstore) GetMany(ctx context.Context, ks []cid.Cid) ([]blocks.Block, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res, err := t.active.GetMany(ctx, ks)
+	if err != nil {
+		return nil, err
+	}
+	return getManyFrom(ctx, t.inactive, ks, res)
+}
+
 func (t *TimedCacheBlockstore) HashOnRead(_ bool) {
 	// no-op
 }
@@ -37,6 +37,26 @@ func (m unionBlockstore) Get(ctx context.Context, cid cid.Cid) (blk blocks.Block
 	return blk, err
 }
 
+func (m unionBlockstore) HasMany(ctx context.Context, cids []cid.Cid) (res []bool, err error) {
+	res = make([]bool, len(cids))
+	for _, bs := range m {
+		if res, err = hasManyFrom(ctx, bs, cids, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (m unionBlockstore) GetMany(ctx context.Context, cids []cid.Cid) (res []blocks.Block, err error) {
+	res = make([]blocks.Block, len(cids))
+	for _, bs := range m {
+		if res, err = getManyFrom(ctx, bs, cids, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 func (m unionBlockstore) View(ctx context.Context, cid cid.Cid, callback func([]byte) error) (err error) {
 	for _, bs := range m {
 		if err = bs.View(ctx, cid, callback); err == nil || !ipld.IsNotFound(err) {
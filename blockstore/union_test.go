@@ -5,6 +5,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/ipfs/go-cid"
 	blocks "github.com/ipfs/go-libipfs/blocks"
 	"github.com/stretchr/testify/require"
 )
@@ -108,3 +109,26 @@ func TestUnionBlockstore_Put_PutMany_Delete_AllKeysChan(t *testing.T) {
 	}
 	require.Equal(t, 4, i)
 }
+
+func TestUnionBlockstore_HasMany_GetMany(t *testing.T) {
+	ctx := context.Background()
+	m1 := NewMemory()
+	m2 := NewMemory()
+
+	_ = m1.Put(ctx, b1)
+	_ = m2.Put(ctx, b2)
+
+	u := Union(m1, m2)
+
+	cids := []cid.Cid{b2.Cid(), b3.Cid(), b1.Cid()}
+
+	has, err := u.HasMany(ctx, cids)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, true}, has)
+
+	blks, err := u.GetMany(ctx, cids)
+	require.NoError(t, err)
+	require.Equal(t, b2.RawData(), blks[0].RawData())
+	require.Nil(t, blks[1])
+	require.Equal(t, b1.RawData(), blks[2].RawData())
+}
@@ -0,0 +1,30 @@
+package blockstore
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+)
+
+// NewCborStore returns an IPLD store decoding the objects of bs in place, through its
+// zero-copy View, instead of reading them as blocks.
+//
+// go-ipld-cbor only detects viewers whose View takes no context, so the objects are viewed
+// with ctx; the store must only be used with ctx, as with adt.WrapStore.
+func NewCborStore(ctx context.Context, bs Blockstore) *cbor.BasicIpldStore {
+	cst := cbor.NewCborStore(bs)
+	cst.Viewer = &ctxViewer{ctx: ctx, v: bs}
+	return cst
+}
+
+type ctxViewer struct {
+	ctx context.Context
+	v   Viewer
+}
+
+var _ cbor.IpldBlockstoreViewer = (*ctxViewer)(nil)
+
+func (v *ctxViewer) View(c cid.Cid, cb func([]byte) error) error {
+	return v.v.View(v.ctx, c, cb)
+}
@@ -0,0 +1,31 @@
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+type viewOnlyStore struct {
+	Blockstore
+}
+
+func (viewOnlyStore) Get(context.Context, cid.Cid) (blocks.Block, error) {
+	return nil, xerrors.New("objects must be read with View")
+}
+
+func TestCborStoreViews(t *testing.T) {
+	ctx := context.Background()
+	bs := viewOnlyStore{NewMemory()}
+
+	c, err := NewCborStore(ctx, bs).Put(ctx, []string{"zero", "copy"})
+	require.NoError(t, err)
+
+	var out []string
+	require.NoError(t, NewCborStore(ctx, bs).Get(ctx, c, &out))
+	require.Equal(t, []string{"zero", "copy"}, out)
+}
@@ -169,12 +169,23 @@ func checkBlockMessages(ctx context.Context, sm *stmgr.StateManager, cs *store.C
 		var sigCids []cid.Cid // this is what we get for people not wanting the marshalcbor method on the cid type
 		var pubks [][]byte
 
+		// Blocks often carry many messages from the same senders, so resolve
+		// each sender's key only once. All the signatures of the block are then
+		// checked at once against its aggregate signature; aggregates of
+		// different blocks are not combined, as without random weights invalid
+		// aggregates could cancel each other out.
+		senderKeys := make(map[address.Address][]byte)
 		for _, m := range b.BlsMessages {
 			sigCids = append(sigCids, m.Cid())
 
-			pubk, err := sm.GetBlsPublicKey(ctx, m.From, baseTs)
-			if err != nil {
-				return xerrors.Errorf("failed to load bls public to validate block: %w", err)
+			pubk, ok := senderKeys[m.From]
+			if !ok {
+				var err error
+				pubk, err = sm.GetBlsPublicKey(ctx, m.From, baseTs)
+				if err != nil {
+					return xerrors.Errorf("failed to load bls public to validate block: %w", err)
+				}
+				senderKeys[m.From] = pubk
 			}
 
 			pubks = append(pubks, pubk)
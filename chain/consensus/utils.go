@@ -2,9 +2,12 @@ package consensus
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/ipfs/go-cid"
+	"github.com/minio/blake2b-simd"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.opencensus.io/trace"
 	"golang.org/x/xerrors"
@@ -16,6 +19,45 @@ import (
 
 var ErrTemporal = errors.New("temporal error")
 
+// blsAggregateCacheSize is the number of successfully verified aggregates
+// remembered, so that blocks validated again (e.g. after a reorg, or after a
+// validation failing for a temporary reason) don't pay for the pairings again.
+const blsAggregateCacheSize = 4096
+
+var blsAggregateCache, _ = lru.New[[32]byte, struct{}](blsAggregateCacheSize)
+
+// blsHashVerify verifies aggregate signatures; replaced in tests
+var blsHashVerify = ffi.HashVerify
+
+// blsAggregateKey identifies an aggregate signature along with everything it
+// was verified against. Every field is length-prefixed, so that different
+// inputs can't hash the same bytes.
+func blsAggregateKey(sig *crypto.Signature, msgs []cid.Cid, pubks [][]byte) [32]byte {
+	h := blake2b.New256()
+	buf := make([]byte, binary.MaxVarintLen64)
+	write := func(b []byte) {
+		n := binary.PutUvarint(buf, uint64(len(b)))
+		_, _ = h.Write(buf[:n])
+		_, _ = h.Write(b)
+	}
+
+	write([]byte{byte(sig.Type)})
+	write(sig.Data)
+	n := binary.PutUvarint(buf, uint64(len(msgs)))
+	_, _ = h.Write(buf[:n])
+	for i := range msgs {
+		write(msgs[i].Bytes())
+		write(pubks[i])
+	}
+
+	var k [32]byte
+	copy(k[:], h.Sum(nil))
+	return k
+}
+
+// VerifyBlsAggregate verifies the aggregate signature of the BLS messages of
+// a block with a single batched pairing check. Successful verifications are
+// cached.
 func VerifyBlsAggregate(ctx context.Context, sig *crypto.Signature, msgs []cid.Cid, pubks [][]byte) error {
 	_, span := trace.StartSpan(ctx, "syncer.VerifyBlsAggregate")
 	defer span.End()
@@ -23,6 +65,27 @@ func VerifyBlsAggregate(ctx context.Context, sig *crypto.Signature, msgs []cid.C
 		trace.Int64Attribute("msgCount", int64(len(msgs))),
 	)
 
+	if len(msgs) != len(pubks) {
+		return xerrors.Errorf("got %d public keys for %d messages", len(pubks), len(msgs))
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	if sig == nil || len(sig.Data) < ffi.SignatureBytes {
+		return xerrors.New("missing or malformed bls aggregate signature")
+	}
+	for i, pubk := range pubks {
+		if len(pubk) < ffi.PublicKeyBytes {
+			return xerrors.Errorf("malformed bls public key for message %s", msgs[i])
+		}
+	}
+
+	key := blsAggregateKey(sig, msgs, pubks)
+	if blsAggregateCache.Contains(key) {
+		span.AddAttributes(trace.BoolAttribute("cached", true))
+		return nil
+	}
+
 	msgsS := make([]ffi.Message, len(msgs))
 	pubksS := make([]ffi.PublicKey, len(msgs))
 	for i := 0; i < len(msgs); i++ {
@@ -33,14 +96,12 @@ func VerifyBlsAggregate(ctx context.Context, sig *crypto.Signature, msgs []cid.C
 	sigS := new(ffi.Signature)
 	copy(sigS[:], sig.Data[:ffi.SignatureBytes])
 
-	if len(msgs) == 0 {
-		return nil
-	}
-
-	valid := ffi.HashVerify(sigS, msgsS, pubksS)
+	valid := blsHashVerify(sigS, msgsS, pubksS)
 	if !valid {
 		return xerrors.New("bls aggregate signature failed to verify")
 	}
+
+	blsAggregateCache.Add(key, struct{}{})
 	return nil
 }
 
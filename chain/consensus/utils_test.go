@@ -0,0 +1,114 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/crypto"
+)
+
+func mockBlsVerify(t *testing.T, valid bool) *int {
+	var calls int
+	saved := blsHashVerify
+	blsHashVerify = func(*ffi.Signature, []ffi.Message, []ffi.PublicKey) bool {
+		calls++
+		return valid
+	}
+	blsAggregateCache.Purge()
+	t.Cleanup(func() {
+		blsHashVerify = saved
+		blsAggregateCache.Purge()
+	})
+	return &calls
+}
+
+func testBlsAggregate(seed byte, n int) (*crypto.Signature, []cid.Cid, [][]byte) {
+	sig := &crypto.Signature{
+		Type: crypto.SigTypeBLS,
+		Data: bytes.Repeat([]byte{seed}, ffi.SignatureBytes),
+	}
+
+	var msgs []cid.Cid
+	var pubks [][]byte
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, blocks.NewBlock([]byte{seed, byte(i)}).Cid())
+		pubks = append(pubks, bytes.Repeat([]byte{seed + byte(i)}, ffi.PublicKeyBytes))
+	}
+
+	return sig, msgs, pubks
+}
+
+func TestVerifyBlsAggregateCache(t *testing.T) {
+	ctx := context.Background()
+	calls := mockBlsVerify(t, true)
+
+	sig, msgs, pubks := testBlsAggregate(1, 3)
+	require.NoError(t, VerifyBlsAggregate(ctx, sig, msgs, pubks))
+	require.Equal(t, 1, *calls)
+
+	// hit
+	require.NoError(t, VerifyBlsAggregate(ctx, sig, msgs, pubks))
+	require.Equal(t, 1, *calls)
+
+	// the aggregate is verified again against anything else
+	sig2, msgs2, pubks2 := testBlsAggregate(2, 3)
+	require.NoError(t, VerifyBlsAggregate(ctx, sig2, msgs, pubks))
+	require.Equal(t, 2, *calls)
+	require.NoError(t, VerifyBlsAggregate(ctx, sig, msgs2, pubks))
+	require.Equal(t, 3, *calls)
+	require.NoError(t, VerifyBlsAggregate(ctx, sig, msgs, pubks2))
+	require.Equal(t, 4, *calls)
+	require.NoError(t, VerifyBlsAggregate(ctx, sig, msgs[:2], pubks[:2]))
+	require.Equal(t, 5, *calls)
+}
+
+func TestVerifyBlsAggregateInvalidNotCached(t *testing.T) {
+	ctx := context.Background()
+	calls := mockBlsVerify(t, false)
+
+	sig, msgs, pubks := testBlsAggregate(1, 3)
+	require.Error(t, VerifyBlsAggregate(ctx, sig, msgs, pubks))
+	require.Error(t, VerifyBlsAggregate(ctx, sig, msgs, pubks))
+	require.Equal(t, 2, *calls)
+	require.Zero(t, blsAggregateCache.Len())
+}
+
+func TestVerifyBlsAggregateGuards(t *testing.T) {
+	ctx := context.Background()
+	calls := mockBlsVerify(t, true)
+
+	sig, msgs, pubks := testBlsAggregate(1, 3)
+
+	// nothing to verify
+	require.NoError(t, VerifyBlsAggregate(ctx, nil, nil, nil))
+
+	require.Error(t, VerifyBlsAggregate(ctx, sig, msgs, pubks[:2]))
+	require.Error(t, VerifyBlsAggregate(ctx, nil, msgs, pubks))
+	require.Error(t, VerifyBlsAggregate(ctx, &crypto.Signature{Type: crypto.SigTypeBLS, Data: sig.Data[:10]}, msgs, pubks))
+
+	short := append([][]byte{}, pubks...)
+	short[1] = short[1][:10]
+	require.Error(t, VerifyBlsAggregate(ctx, sig, msgs, short))
+
+	require.Zero(t, *calls)
+}
+
+func TestBlsAggregateKeyFieldBoundaries(t *testing.T) {
+	sig, msgs, pubks := testBlsAggregate(1, 2)
+
+	// moving bytes from one field to the next doesn't produce the same key
+	moved := &crypto.Signature{
+		Type: sig.Type,
+		Data: append(append(append([]byte{}, sig.Data...), msgs[0].Bytes()...), pubks[0]...),
+	}
+	require.NotEqual(t, blsAggregateKey(sig, msgs[:1], pubks[:1]), blsAggregateKey(moved, nil, nil))
+
+	split := [][]byte{pubks[0][:10], append(append([]byte{}, pubks[0][10:]...), pubks[1]...)}
+	require.NotEqual(t, blsAggregateKey(sig, msgs, pubks), blsAggregateKey(sig, msgs, split))
+}
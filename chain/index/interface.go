@@ -7,6 +7,8 @@ import (
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
 )
 
 var ErrNotFound = errors.New("message not found")
@@ -43,3 +45,75 @@ func (dummyMsgIndex) Close() error {
 }
 
 var DummyMsgIndex MsgIndex = dummyMsgIndex{}
+
+// ReceiptInfo is the receipt metadata the receipt index tracks.
+type ReceiptInfo struct {
+	// the message this receipt refers to
+	Message cid.Cid
+	// the tipset where this message was included
+	TipSet cid.Cid
+	// the epoch where this message was included
+	Epoch abi.ChainEpoch
+	// the root of the receipts AMT in the headers of the execution tipset
+	ReceiptsRoot cid.Cid
+	// the index of the receipt in the receipts AMT
+	Index int
+	// the receipt of the message
+	Receipt types.MessageReceipt
+}
+
+// ReceiptIndex is the interface to the receipt index
+type ReceiptIndex interface {
+	// GetReceipt retrieves the receipt of an executed message through the index.
+	// The lookup is done using the onchain message Cid, as with the message index.
+	GetReceipt(ctx context.Context, m cid.Cid) (ReceiptInfo, error)
+	// GetReceipts retrieves the receipts in the receipts AMT with the given root, in order,
+	// without loading the AMT.
+	GetReceipts(ctx context.Context, root cid.Cid) ([]types.MessageReceipt, error)
+	// Close closes the index
+	Close() error
+}
+
+type dummyReceiptIndex struct{}
+
+func (dummyReceiptIndex) GetReceipt(ctx context.Context, m cid.Cid) (ReceiptInfo, error) {
+	return ReceiptInfo{}, ErrNotFound
+}
+
+func (dummyReceiptIndex) GetReceipts(ctx context.Context, root cid.Cid) ([]types.MessageReceipt, error) {
+	return nil, ErrNotFound
+}
+
+func (dummyReceiptIndex) Close() error {
+	return nil
+}
+
+var DummyReceiptIndex ReceiptIndex = dummyReceiptIndex{}
+
+// TipSetIndex is the interface to the tipset index, which maps the tipsets of the current chain
+// from key to height and back.
+type TipSetIndex interface {
+	// GetTipSetKey returns the key of the tipset at the given height in the current chain.
+	GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error)
+	// GetTipSetHeight returns the height of the tipset with the given key, if it is in the
+	// current chain. This validates a tipset key without loading any header.
+	GetTipSetHeight(ctx context.Context, tsk types.TipSetKey) (abi.ChainEpoch, error)
+	// Close closes the index
+	Close() error
+}
+
+type dummyTipSetIndex struct{}
+
+func (dummyTipSetIndex) GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error) {
+	return types.EmptyTSK, ErrNotFound
+}
+
+func (dummyTipSetIndex) GetTipSetHeight(ctx context.Context, tsk types.TipSetKey) (abi.ChainEpoch, error) {
+	return 0, ErrNotFound
+}
+
+func (dummyTipSetIndex) Close() error {
+	return nil
+}
+
+var DummyTipSetIndex TipSetIndex = dummyTipSetIndex{}
@@ -66,10 +66,25 @@ type ChainStore interface {
 	MessagesForTipset(ctx context.Context, ts *types.TipSet) ([]types.ChainMsg, error)
 	GetHeaviestTipSet() *types.TipSet
 	GetTipSetFromKey(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error)
+	GetTipsetByHeight(ctx context.Context, h abi.ChainEpoch, ts *types.TipSet, prev bool) (*types.TipSet, error)
 }
 
 var _ ChainStore = (*store.ChainStore)(nil)
 
+// isCanonical checks whether ts is in the current chain.
+func isCanonical(ctx context.Context, cs ChainStore, ts *types.TipSet) (bool, error) {
+	head := cs.GetHeaviestTipSet()
+	if ts.Height() > head.Height() {
+		return false, nil
+	}
+
+	cts, err := cs.GetTipsetByHeight(ctx, ts.Height(), head, false)
+	if err != nil {
+		return false, xerrors.Errorf("error looking up tipset at epoch %d: %w", ts.Height(), err)
+	}
+	return cts.Equals(ts), nil
+}
+
 type msgIndex struct {
 	cs ChainStore
 
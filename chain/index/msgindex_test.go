@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -183,14 +184,15 @@ func verifyMissing(t *testing.T, cs *mockChainStore, msgIndex MsgIndex, missing
 type mockChainStore struct {
 	notify store.ReorgNotifee
 
-	curTs   *types.TipSet
-	tipsets map[types.TipSetKey]*types.TipSet
-	msgs    map[types.TipSetKey][]types.ChainMsg
+	curTs    *types.TipSet
+	tipsets  map[types.TipSetKey]*types.TipSet
+	msgs     map[types.TipSetKey][]types.ChainMsg
+	receipts map[cid.Cid][]types.MessageReceipt
 
 	nonce uint64
 }
 
-var _ ChainStore = (*mockChainStore)(nil)
+var _ ReceiptChainStore = (*mockChainStore)(nil)
 
 var systemAddr address.Address
 var rng *rand.Rand
@@ -207,8 +209,9 @@ func init() {
 
 func newMockChainStore() *mockChainStore {
 	return &mockChainStore{
-		tipsets: make(map[types.TipSetKey]*types.TipSet),
-		msgs:    make(map[types.TipSetKey][]types.ChainMsg),
+		tipsets:  make(map[types.TipSetKey]*types.TipSet),
+		msgs:     make(map[types.TipSetKey][]types.ChainMsg),
+		receipts: make(map[cid.Cid][]types.MessageReceipt),
 	}
 }
 
@@ -253,6 +256,20 @@ func (cs *mockChainStore) makeBlk() *types.TipSet {
 	blk := mock.MkBlock(cs.curTs, uint64(height), uint64(height))
 	blk.Messages = cs.makeGarbageCid()
 
+	// the receipts of the parent messages, alternating between receipt versions
+	blk.ParentMessageReceipts = cs.makeGarbageCid()
+	var receipts []types.MessageReceipt
+	for _, m := range cs.msgs[cs.curTs.Key()] {
+		nonce := m.VMMessage().Nonce
+		if nonce%2 == 0 {
+			receipts = append(receipts, types.NewMessageReceiptV0(0, []byte{byte(nonce)}, int64(nonce)))
+		} else {
+			events := cs.makeGarbageCid()
+			receipts = append(receipts, types.NewMessageReceiptV1(1, nil, int64(nonce), &events))
+		}
+	}
+	cs.receipts[blk.ParentMessageReceipts] = receipts
+
 	ts := mock.TipSet(blk)
 	msg1 := cs.makeMsg()
 	msg2 := cs.makeMsg()
@@ -296,3 +313,25 @@ func (cs *mockChainStore) GetTipSetFromKey(ctx context.Context, tsk types.TipSet
 	}
 	return ts, nil
 }
+
+func (cs *mockChainStore) ReadReceipts(ctx context.Context, root cid.Cid) ([]types.MessageReceipt, error) {
+	receipts, ok := cs.receipts[root]
+	if !ok {
+		return nil, errors.New("unknown receipts")
+	}
+	return receipts, nil
+}
+
+func (cs *mockChainStore) GetTipsetByHeight(ctx context.Context, h abi.ChainEpoch, ts *types.TipSet, prev bool) (*types.TipSet, error) {
+	for ts.Height() > h {
+		pts, err := cs.GetTipSetFromKey(ctx, ts.Parents())
+		if err != nil {
+			return nil, err
+		}
+		if pts.Height() < h && !prev {
+			return ts, nil
+		}
+		ts = pts
+	}
+	return ts, nil
+}
@@ -0,0 +1,622 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var receiptDbName = "receiptindex.db"
+var receiptDbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS receipts (
+     exec_tipset_cid VARCHAR(80) NOT NULL,
+     idx INTEGER NOT NULL,
+     receipts_root VARCHAR(80) NOT NULL,
+     cid VARCHAR(80) NOT NULL,
+     tipset_cid VARCHAR(80) NOT NULL,
+     epoch INTEGER NOT NULL,
+     version INTEGER NOT NULL,
+     exit_code INTEGER NOT NULL,
+     return_value BLOB,
+     gas_used INTEGER NOT NULL,
+     events_root VARCHAR(80),
+     PRIMARY KEY (exec_tipset_cid, idx) ON CONFLICT REPLACE
+   )`,
+	`CREATE INDEX IF NOT EXISTS receipt_roots ON receipts (receipts_root)
+  `,
+	`CREATE INDEX IF NOT EXISTS receipt_cids ON receipts (cid)
+  `,
+	`CREATE INDEX IF NOT EXISTS receipt_epochs ON receipts (epoch)
+  `,
+	`CREATE TABLE IF NOT EXISTS _meta (
+    	version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	// prepared stmts
+	dbqGetReceipt            = "SELECT receipts_root, idx, tipset_cid, epoch, version, exit_code, return_value, gas_used, events_root FROM receipts WHERE cid = ? ORDER BY epoch DESC LIMIT 1"
+	dbqGetReceipts           = "SELECT version, exit_code, return_value, gas_used, events_root FROM receipts WHERE exec_tipset_cid = (SELECT exec_tipset_cid FROM receipts WHERE receipts_root = ? LIMIT 1) ORDER BY idx"
+	dbqInsertReceipt         = "INSERT INTO receipts VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	dbqDeleteReceipts        = "DELETE FROM receipts WHERE exec_tipset_cid = ?"
+	dbqCountTipSetReceipts   = "SELECT COUNT(*) FROM receipts WHERE exec_tipset_cid = ?"
+	dbqCountReceipts         = "SELECT COUNT(*) FROM receipts"
+	dbqMinReceiptEpoch       = "SELECT MIN(epoch) FROM receipts"
+	dbqDeleteReceiptsByEpoch = "DELETE FROM receipts WHERE epoch >= ?"
+)
+
+// ReceiptBackfillBatch is the number of tipsets indexed per transaction while backfilling
+// the receipt index.
+var ReceiptBackfillBatch = 100
+
+// ReceiptChainStore is the chain store interface used by the receipt index.
+type ReceiptChainStore interface {
+	ChainStore
+	ReadReceipts(ctx context.Context, root cid.Cid) ([]types.MessageReceipt, error)
+}
+
+type receiptIndex struct {
+	cs ReceiptChainStore
+
+	db                *sql.DB
+	selectReceiptStmt *sql.Stmt
+	selectRootStmt    *sql.Stmt
+	insertReceiptStmt *sql.Stmt
+	deleteTipSetStmt  *sql.Stmt
+	countTipSetStmt   *sql.Stmt
+
+	sema chan struct{}
+	mx   sync.Mutex
+	pend []headChange
+
+	cancel  func()
+	workers sync.WaitGroup
+	closeLk sync.RWMutex
+	closed  bool
+}
+
+var _ ReceiptIndex = (*receiptIndex)(nil)
+
+// NewReceiptIndex opens the receipt index, which indexes the receipts of the messages executed
+// on chain by message and by receipts root. The receipts are keyed by execution tipset, as
+// tipsets executing the same receipts share their receipts root. The receipts of the tipsets
+// preceding the first indexed tipset are backfilled in the background down to genesis, or as far
+// as the receipts are available.
+func NewReceiptIndex(lctx context.Context, basePath string, cs ReceiptChainStore) (ReceiptIndex, error) {
+	err := os.MkdirAll(basePath, 0755)
+	if err != nil {
+		return nil, xerrors.Errorf("error creating receipt index base directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", path.Join(basePath, receiptDbName))
+	if err != nil {
+		return nil, xerrors.Errorf("error opening receipt index database: %w", err)
+	}
+	// head changes and the backfill write concurrently; a single connection serializes them
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range receiptDbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("error executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	if err := reconcileReceiptIndex(db, cs); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("error reconciling receipt index database: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(lctx)
+
+	x := &receiptIndex{
+		db:     db,
+		cs:     cs,
+		sema:   make(chan struct{}, 1),
+		cancel: cancel,
+	}
+
+	if err := x.prepareStatements(); err != nil {
+		if err := db.Close(); err != nil {
+			log.Errorf("error closing receipt index database: %s", err)
+		}
+
+		return nil, xerrors.Errorf("error preparing receipt index database statements: %w", err)
+	}
+
+	// the backfill starts below the current head, which is indexed by the head change
+	// notifications to come
+	head := cs.GetHeaviestTipSet()
+
+	rnf := store.WrapHeadChangeCoalescer(
+		x.onHeadChange,
+		CoalesceMinDelay,
+		CoalesceMaxDelay,
+		CoalesceMergeInterval,
+	)
+	cs.SubscribeHeadChanges(rnf)
+
+	x.workers.Add(2)
+	go x.background(ctx)
+	go x.backfill(ctx, head)
+
+	return x, nil
+}
+
+func reconcileReceiptIndex(db *sql.DB, cs ReceiptChainStore) error {
+	// Invariant: after reconciliation, the receipts of every tipset in the index were executed in
+	// the current chain. Like the message index, we walk down from the current head until we find
+	// a tipset whose receipts are in the index, and delete the receipts of every tipset above it.
+	row := db.QueryRow(dbqCountReceipts)
+
+	var result int64
+	if err := row.Scan(&result); err != nil {
+		return xerrors.Errorf("error counting receipts: %w", err)
+	}
+
+	if result == 0 {
+		return nil
+	}
+
+	row = db.QueryRow(dbqMinReceiptEpoch)
+	if err := row.Scan(&result); err != nil {
+		return xerrors.Errorf("error finding boundary epoch: %w", err)
+	}
+
+	boundaryEpoch := abi.ChainEpoch(result)
+
+	countStmt, err := db.Prepare(dbqCountTipSetReceipts)
+	if err != nil {
+		return xerrors.Errorf("error preparing statement: %w", err)
+	}
+	defer countStmt.Close() //nolint:errcheck
+
+	// the receipts of a tipset are in the headers of its child, the execution tipset
+	curTs := cs.GetHeaviestTipSet()
+	for curTs != nil && curTs.Height() > boundaryEpoch {
+		tsCid, err := curTs.Key().Cid()
+		if err != nil {
+			return xerrors.Errorf("error computing tipset cid: %w", err)
+		}
+
+		row = countStmt.QueryRow(tsCid.String())
+		if err := row.Scan(&result); err != nil {
+			return xerrors.Errorf("error counting receipts: %w", err)
+		}
+
+		if result > 0 {
+			// found it!
+			boundaryEpoch = curTs.Height()
+			break
+		}
+
+		// walk up
+		curTs, err = cs.GetTipSetFromKey(context.TODO(), curTs.Parents())
+		if err != nil {
+			return xerrors.Errorf("error walking chain: %w", err)
+		}
+	}
+
+	if _, err = db.Exec(dbqDeleteReceiptsByEpoch, int64(boundaryEpoch)); err != nil {
+		return xerrors.Errorf("error deleting stale reorged out receipts: %w", err)
+	}
+
+	return nil
+}
+
+func (x *receiptIndex) prepareStatements() error {
+	for _, s := range []struct {
+		stmt **sql.Stmt
+		q    string
+	}{
+		{&x.selectReceiptStmt, dbqGetReceipt},
+		{&x.selectRootStmt, dbqGetReceipts},
+		{&x.insertReceiptStmt, dbqInsertReceipt},
+		{&x.deleteTipSetStmt, dbqDeleteReceipts},
+		{&x.countTipSetStmt, dbqCountTipSetReceipts},
+	} {
+		stmt, err := x.db.Prepare(s.q)
+		if err != nil {
+			return xerrors.Errorf("prepare %q: %w", s.q, err)
+		}
+		*s.stmt = stmt
+	}
+
+	return nil
+}
+
+// head change notifee
+func (x *receiptIndex) onHeadChange(rev, app []*types.TipSet) error {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return nil
+	}
+
+	// do it in the background to avoid blocking head change processing
+	x.mx.Lock()
+	x.pend = append(x.pend, headChange{rev: rev, app: app})
+	pendLen := len(x.pend)
+	x.mx.Unlock()
+
+	// complain loudly if this is building backlog
+	if pendLen > 10 {
+		log.Warnf("receipt index head change processing is building backlog: %d pending head changes", pendLen)
+	}
+
+	select {
+	case x.sema <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (x *receiptIndex) background(ctx context.Context) {
+	defer x.workers.Done()
+
+	for {
+		select {
+		case <-x.sema:
+			err := x.processHeadChanges(ctx)
+			if err != nil {
+				// we can't rely on an inconsistent index, so shut it down.
+				log.Errorf("error processing head change notifications: %s; shutting down receipt index", err)
+				go func() {
+					if err2 := x.Close(); err2 != nil {
+						log.Errorf("error shutting down receipt index: %s", err2)
+					}
+				}()
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (x *receiptIndex) processHeadChanges(ctx context.Context) error {
+	x.mx.Lock()
+	pend := x.pend
+	x.pend = nil
+	x.mx.Unlock()
+
+	tx, err := x.db.Begin()
+	if err != nil {
+		return xerrors.Errorf("error creating transaction: %w", err)
+	}
+
+	for _, hc := range pend {
+		for _, ts := range hc.rev {
+			if err := x.doRevert(tx, ts); err != nil {
+				if err2 := tx.Rollback(); err2 != nil {
+					log.Errorf("error rolling back transaction: %s", err2)
+				}
+				return xerrors.Errorf("error reverting %s: %w", ts, err)
+			}
+		}
+
+		for _, ts := range hc.app {
+			if err := x.doApply(ctx, tx, ts); err != nil {
+				if err2 := tx.Rollback(); err2 != nil {
+					log.Errorf("error rolling back transaction: %s", err2)
+				}
+				return xerrors.Errorf("error applying %s: %w", ts, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (x *receiptIndex) doRevert(tx *sql.Tx, ts *types.TipSet) error {
+	if ts.Height() == 0 {
+		return nil
+	}
+
+	tsCid, err := ts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	_, err = tx.Stmt(x.deleteTipSetStmt).Exec(tsCid.String())
+	return err
+}
+
+// doApply indexes the receipts in the headers of ts, which are the receipts of the messages
+// included in its parent.
+func (x *receiptIndex) doApply(ctx context.Context, tx *sql.Tx, ts *types.TipSet) error {
+	if ts.Height() == 0 {
+		return nil
+	}
+
+	tsCid, err := ts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	pts, err := x.cs.GetTipSetFromKey(ctx, ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("error retrieving parent tipset: %w", err)
+	}
+
+	ptscid, err := pts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	msgs, err := x.cs.MessagesForTipset(ctx, pts)
+	if err != nil {
+		return xerrors.Errorf("error retrieving messages for tipset %s: %w", pts, err)
+	}
+
+	root := ts.Blocks()[0].ParentMessageReceipts
+	receipts, err := x.cs.ReadReceipts(ctx, root)
+	if err != nil {
+		return xerrors.Errorf("error retrieving receipts for tipset %s: %w", pts, err)
+	}
+
+	if len(receipts) != len(msgs) {
+		return xerrors.Errorf("tipset %s has %d messages but %d receipts", pts, len(msgs), len(receipts))
+	}
+
+	insertStmt := tx.Stmt(x.insertReceiptStmt)
+	for i, r := range receipts {
+		var eventsRoot *string
+		if r.EventsRoot != nil {
+			s := r.EventsRoot.String()
+			eventsRoot = &s
+		}
+
+		if _, err := insertStmt.Exec(tsCid.String(), i, root.String(), msgs[i].Cid().String(), ptscid.String(), int64(pts.Height()),
+			int(r.Version()), int64(r.ExitCode), r.Return, r.GasUsed, eventsRoot); err != nil {
+			return xerrors.Errorf("error inserting receipt: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// backfill indexes the receipts of the tipsets below head which are not in the index yet: first
+// the tipsets down to the last indexed tipset, which were synced while the index wasn't running,
+// and then the tipsets below the first indexed tipset, down to genesis.
+//
+// head may have been reverted since, so tipsets are only indexed when they are in the current
+// chain. They are checked in the transaction indexing them, which holds the single database
+// connection: the head changes reverting them can only be written after it commits.
+func (x *receiptIndex) backfill(ctx context.Context, head *types.TipSet) {
+	defer x.workers.Done()
+
+	fill := func(ts *types.TipSet, stopIndexed bool) error {
+		for ts.Height() > 0 {
+			tx, err := x.db.Begin()
+			if err != nil {
+				return xerrors.Errorf("error creating transaction: %w", err)
+			}
+
+			// the ancestors of a tipset in the current chain are in it too
+			done, canonical := false, false
+			for i := 0; i < ReceiptBackfillBatch && ts.Height() > 0; i++ {
+				if err := ctx.Err(); err != nil {
+					_ = tx.Rollback()
+					return err
+				}
+
+				tsCid, err := ts.Key().Cid()
+				if err != nil {
+					_ = tx.Rollback()
+					return xerrors.Errorf("error computing tipset cid: %w", err)
+				}
+
+				var count int64
+				row := tx.Stmt(x.countTipSetStmt).QueryRow(tsCid.String())
+				if err := row.Scan(&count); err != nil {
+					_ = tx.Rollback()
+					return xerrors.Errorf("error counting receipts: %w", err)
+				}
+
+				if count > 0 && stopIndexed {
+					done = true
+					break
+				}
+
+				if !canonical {
+					canonical, err = isCanonical(ctx, x.cs, ts)
+					if err != nil {
+						_ = tx.Rollback()
+						return err
+					}
+				}
+
+				if count == 0 && canonical {
+					if err := x.doApply(ctx, tx, ts); err != nil {
+						_ = tx.Rollback()
+						return xerrors.Errorf("error indexing receipts at epoch %d: %w", ts.Height(), err)
+					}
+				}
+
+				ts, err = x.cs.GetTipSetFromKey(ctx, ts.Parents())
+				if err != nil {
+					_ = tx.Rollback()
+					return xerrors.Errorf("error walking chain: %w", err)
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				return xerrors.Errorf("error committing transaction: %w", err)
+			}
+
+			if done {
+				return nil
+			}
+		}
+
+		return nil
+	}
+
+	var min sql.NullInt64
+	if err := x.db.QueryRow(dbqMinReceiptEpoch).Scan(&min); err != nil {
+		log.Errorf("error finding the first indexed epoch: %s", err)
+		return
+	}
+
+	if min.Valid {
+		if err := fill(head, true); err != nil {
+			log.Infof("receipt index backfill stopped: %s", err)
+			return
+		}
+
+		// continue from the execution tipset of the first indexed tipset
+		var err error
+		head, err = x.cs.GetTipsetByHeight(ctx, abi.ChainEpoch(min.Int64)+1, head, false)
+		if err != nil {
+			log.Errorf("error looking up the first indexed tipset: %s", err)
+			return
+		}
+	}
+
+	log.Infow("backfilling receipt index", "from", head.Height())
+	if err := fill(head, false); err != nil {
+		// expected on nodes which don't keep the receipts of the whole chain
+		log.Infof("receipt index backfill stopped: %s", err)
+		return
+	}
+	log.Info("receipt index backfill done")
+}
+
+// interface
+func (x *receiptIndex) GetReceipt(ctx context.Context, m cid.Cid) (ReceiptInfo, error) {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return ReceiptInfo{}, ErrClosed
+	}
+
+	var (
+		root, tipset string
+		idx          int
+		epoch        int64
+	)
+
+	r, err := scanReceipt(x.selectReceiptStmt.QueryRowContext(ctx, m.String()), &root, &idx, &tipset, &epoch)
+	switch {
+	case err == sql.ErrNoRows:
+		return ReceiptInfo{}, ErrNotFound
+
+	case err != nil:
+		return ReceiptInfo{}, xerrors.Errorf("error querying receipt index database: %w", err)
+	}
+
+	rootCid, err := cid.Decode(root)
+	if err != nil {
+		return ReceiptInfo{}, xerrors.Errorf("error decoding receipts root cid: %w", err)
+	}
+
+	tipsetCid, err := cid.Decode(tipset)
+	if err != nil {
+		return ReceiptInfo{}, xerrors.Errorf("error decoding tipset cid: %w", err)
+	}
+
+	return ReceiptInfo{
+		Message:      m,
+		TipSet:       tipsetCid,
+		Epoch:        abi.ChainEpoch(epoch),
+		ReceiptsRoot: rootCid,
+		Index:        idx,
+		Receipt:      r,
+	}, nil
+}
+
+func (x *receiptIndex) GetReceipts(ctx context.Context, root cid.Cid) ([]types.MessageReceipt, error) {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return nil, ErrClosed
+	}
+
+	rows, err := x.selectRootStmt.QueryContext(ctx, root.String())
+	if err != nil {
+		return nil, xerrors.Errorf("error querying receipt index database: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var out []types.MessageReceipt
+	for rows.Next() {
+		r, err := scanReceipt(rows)
+		if err != nil {
+			return nil, xerrors.Errorf("error reading receipt: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, xerrors.Errorf("error querying receipt index database: %w", err)
+	}
+
+	if len(out) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return out, nil
+}
+
+// scanReceipt scans the leading columns into dest, followed by the receipt columns.
+func scanReceipt(row interface{ Scan(...any) error }, dest ...any) (types.MessageReceipt, error) {
+	var (
+		version    int
+		exitCode   int64
+		ret        []byte
+		gasUsed    int64
+		eventsRoot sql.NullString
+	)
+
+	if err := row.Scan(append(dest, &version, &exitCode, &ret, &gasUsed, &eventsRoot)...); err != nil {
+		return types.MessageReceipt{}, err
+	}
+
+	if types.MessageReceiptVersion(version) == types.MessageReceiptV0 {
+		return types.NewMessageReceiptV0(exitcode.ExitCode(exitCode), ret, gasUsed), nil
+	}
+
+	var root *cid.Cid
+	if eventsRoot.Valid {
+		c, err := cid.Decode(eventsRoot.String)
+		if err != nil {
+			return types.MessageReceipt{}, xerrors.Errorf("error decoding events root cid: %w", err)
+		}
+		root = &c
+	}
+
+	return types.NewMessageReceiptV1(exitcode.ExitCode(exitCode), ret, gasUsed, root), nil
+}
+
+func (x *receiptIndex) Close() error {
+	x.closeLk.Lock()
+	defer x.closeLk.Unlock()
+
+	if x.closed {
+		return nil
+	}
+
+	x.closed = true
+
+	x.cancel()
+	x.workers.Wait()
+
+	return x.db.Close()
+}
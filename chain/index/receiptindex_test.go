@@ -0,0 +1,258 @@
+package index
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestBasicReceiptIndex(t *testing.T) {
+	cs := newMockChainStore()
+	cs.genesis()
+
+	receiptIndex, err := NewReceiptIndex(context.Background(), t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer receiptIndex.Close() //nolint
+
+	for i := 0; i < 10; i++ {
+		t.Logf("advance to epoch %d", i+1)
+		err := cs.advance()
+		require.NoError(t, err)
+		// wait for the coalescer to notify
+		time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+	}
+
+	t.Log("verifying index")
+	verifyReceiptIndex(t, cs, receiptIndex)
+}
+
+func TestReorgReceiptIndex(t *testing.T) {
+	cs := newMockChainStore()
+	cs.genesis()
+
+	receiptIndex, err := NewReceiptIndex(context.Background(), t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer receiptIndex.Close() //nolint
+
+	for i := 0; i < 10; i++ {
+		err := cs.advance()
+		require.NoError(t, err)
+		time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+	}
+
+	// a simple reorg, executing the messages of the parent again in a sibling
+	t.Log("doing reorg")
+	reorgme := cs.curTs
+	reorgmeParent, err := cs.GetTipSetFromKey(context.Background(), reorgme.Parents())
+	require.NoError(t, err)
+	cs.setHead(reorgmeParent)
+	reorgmeSibling := cs.makeBlk()
+	err = cs.reorg([]*types.TipSet{reorgme}, []*types.TipSet{reorgmeSibling})
+	require.NoError(t, err)
+	time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+
+	t.Log("verifying index")
+	verifyReceiptIndex(t, cs, receiptIndex)
+
+	t.Log("verifying that reorged receipts are not present")
+	_, err = receiptIndex.GetReceipts(context.Background(), reorgme.Blocks()[0].ParentMessageReceipts)
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestBackfillReceiptIndex(t *testing.T) {
+	cs := newMockChainStore()
+	cs.genesis()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	tmp := t.TempDir()
+
+	// the first backfill indexes the chain down to genesis
+	receiptIndex, err := NewReceiptIndex(context.Background(), tmp, cs)
+	require.NoError(t, err)
+
+	waitBackfill(t, cs, receiptIndex)
+	verifyReceiptIndex(t, cs, receiptIndex)
+	require.NoError(t, receiptIndex.Close())
+
+	// the next one fills the gap above the tipsets indexed before
+	cs.notify = nil
+	for i := 0; i < 5; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	receiptIndex, err = NewReceiptIndex(context.Background(), tmp, cs)
+	require.NoError(t, err)
+
+	defer receiptIndex.Close() //nolint
+
+	waitBackfill(t, cs, receiptIndex)
+	verifyReceiptIndex(t, cs, receiptIndex)
+}
+
+func TestSharedRootReceiptIndex(t *testing.T) {
+	ctx := context.Background()
+
+	cs := newMockChainStore()
+	cs.genesis()
+
+	receiptIndex, err := NewReceiptIndex(ctx, t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer receiptIndex.Close() //nolint
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, cs.advance())
+		time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+	}
+
+	// the next tipset executes its parent messages with the same receipts as the head
+	head := cs.curTs
+	root := head.Blocks()[0].ParentMessageReceipts
+	blk := mock.MkBlock(head, uint64(head.Height()+1), uint64(head.Height()+1))
+	blk.Messages = cs.makeGarbageCid()
+	blk.ParentMessageReceipts = root
+	ts := mock.TipSet(blk)
+	cs.msgs[ts.Key()] = []types.ChainMsg{cs.makeMsg(), cs.makeMsg()}
+	require.NoError(t, cs.reorg(nil, []*types.TipSet{ts}))
+	time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+
+	hpts, err := cs.GetTipSetFromKey(ctx, head.Parents())
+	require.NoError(t, err)
+	expectIndexed := func(pts *types.TipSet, indexed bool) {
+		t.Helper()
+
+		ptsCid, err := pts.Key().Cid()
+		require.NoError(t, err)
+
+		msgs, err := cs.MessagesForTipset(ctx, pts)
+		require.NoError(t, err)
+		for i, m := range msgs {
+			rinfo, err := receiptIndex.GetReceipt(ctx, m.Cid())
+			if !indexed {
+				require.Equal(t, ErrNotFound, err)
+				continue
+			}
+			require.NoError(t, err)
+			require.Equal(t, ptsCid, rinfo.TipSet)
+			require.Equal(t, root, rinfo.ReceiptsRoot)
+			require.Equal(t, i, rinfo.Index)
+		}
+	}
+
+	// the messages of both tipsets are indexed
+	expectIndexed(hpts, true)
+	expectIndexed(head, true)
+
+	receipts, err := receiptIndex.GetReceipts(ctx, root)
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+
+	// and reverting one of them keeps the receipts of the other
+	require.NoError(t, cs.reorg([]*types.TipSet{ts}, nil))
+	time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+
+	expectIndexed(hpts, true)
+	expectIndexed(head, false)
+
+	receipts, err = receiptIndex.GetReceipts(ctx, root)
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+}
+
+func TestBackfillStaleHeadReceiptIndex(t *testing.T) {
+	ctx := context.Background()
+
+	cs := newMockChainStore()
+	cs.genesis()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	ri, err := NewReceiptIndex(ctx, t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer ri.Close() //nolint
+
+	waitBackfill(t, cs, ri)
+
+	reorgme := cs.curTs
+	reorgmeParent, err := cs.GetTipSetFromKey(ctx, reorgme.Parents())
+	require.NoError(t, err)
+	cs.setHead(reorgmeParent)
+	require.NoError(t, cs.reorg([]*types.TipSet{reorgme}, []*types.TipSet{cs.makeBlk()}))
+	time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+
+	// a backfill from the head before the reorg doesn't index the reverted tipset again
+	x := ri.(*receiptIndex)
+	x.workers.Add(1)
+	x.backfill(ctx, reorgme)
+
+	_, err = ri.GetReceipts(ctx, reorgme.Blocks()[0].ParentMessageReceipts)
+	require.Equal(t, ErrNotFound, err)
+	verifyReceiptIndex(t, cs, ri)
+}
+
+// waitBackfill waits until the receipts of the head are indexed by the backfill, which indexes
+// them first.
+func waitBackfill(t *testing.T, cs *mockChainStore, receiptIndex ReceiptIndex) {
+	ctx := context.Background()
+
+	// the messages at epoch 1 are executed at epoch 2, and indexed last
+	ts, err := cs.GetTipsetByHeight(ctx, 2, cs.curTs, false)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := receiptIndex.GetReceipts(ctx, ts.Blocks()[0].ParentMessageReceipts)
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func verifyReceiptIndex(t *testing.T, cs *mockChainStore, receiptIndex ReceiptIndex) {
+	ctx := context.Background()
+
+	for ts := cs.curTs; ts.Height() > 0; {
+		t.Logf("verify at height %d", ts.Height())
+
+		root := ts.Blocks()[0].ParentMessageReceipts
+		pts, err := cs.GetTipSetFromKey(ctx, ts.Parents())
+		require.NoError(t, err)
+
+		ptsCid, err := pts.Key().Cid()
+		require.NoError(t, err)
+
+		expected := cs.receipts[root]
+		if len(expected) > 0 {
+			receipts, err := receiptIndex.GetReceipts(ctx, root)
+			require.NoError(t, err)
+			require.Len(t, receipts, len(expected))
+			for i := range receipts {
+				require.True(t, receipts[i].Equals(&expected[i]))
+			}
+		}
+
+		msgs, err := cs.MessagesForTipset(ctx, pts)
+		require.NoError(t, err)
+		for i, m := range msgs {
+			rinfo, err := receiptIndex.GetReceipt(ctx, m.Cid())
+			require.NoError(t, err)
+			require.Equal(t, ptsCid, rinfo.TipSet)
+			require.Equal(t, pts.Height(), rinfo.Epoch)
+			require.Equal(t, root, rinfo.ReceiptsRoot)
+			require.Equal(t, i, rinfo.Index)
+			require.True(t, rinfo.Receipt.Equals(&expected[i]))
+		}
+
+		ts = pts
+	}
+}
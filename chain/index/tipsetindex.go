@@ -0,0 +1,509 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var tipsetDbName = "tipsetindex.db"
+var tipsetDbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS tipsets (
+     tipset_cid VARCHAR(80) PRIMARY KEY ON CONFLICT REPLACE,
+     tipset_key BLOB NOT NULL,
+     epoch INTEGER NOT NULL UNIQUE ON CONFLICT REPLACE
+   )`,
+	`CREATE TABLE IF NOT EXISTS _meta (
+    	version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	// prepared stmts
+	dbqGetTipSetKey         = "SELECT tipset_key FROM tipsets WHERE epoch = ?"
+	dbqGetTipSetHeight      = "SELECT epoch FROM tipsets WHERE tipset_cid = ?"
+	dbqInsertTipSet         = "INSERT INTO tipsets VALUES (?, ?, ?)"
+	dbqDeleteTipSet         = "DELETE FROM tipsets WHERE tipset_cid = ?"
+	dbqCountTipSets         = "SELECT COUNT(*) FROM tipsets"
+	dbqMinTipSetEpoch       = "SELECT MIN(epoch) FROM tipsets"
+	dbqDeleteTipSetsByEpoch = "DELETE FROM tipsets WHERE epoch >= ?"
+)
+
+// TipSetBackfillBatch is the number of tipsets indexed per transaction while backfilling
+// the tipset index.
+var TipSetBackfillBatch = 1000
+
+type tipsetIndex struct {
+	cs ChainStore
+
+	db               *sql.DB
+	selectKeyStmt    *sql.Stmt
+	selectHeightStmt *sql.Stmt
+	insertStmt       *sql.Stmt
+	deleteStmt       *sql.Stmt
+
+	sema chan struct{}
+	mx   sync.Mutex
+	pend []headChange
+
+	cancel  func()
+	workers sync.WaitGroup
+	closeLk sync.RWMutex
+	closed  bool
+}
+
+var _ TipSetIndex = (*tipsetIndex)(nil)
+
+// NewTipSetIndex opens the tipset index, which maps the tipsets of the current chain from key to
+// height and back. The tipsets preceding the first indexed tipset are backfilled in the
+// background down to genesis.
+func NewTipSetIndex(lctx context.Context, basePath string, cs ChainStore) (TipSetIndex, error) {
+	err := os.MkdirAll(basePath, 0755)
+	if err != nil {
+		return nil, xerrors.Errorf("error creating tipset index base directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", path.Join(basePath, tipsetDbName))
+	if err != nil {
+		return nil, xerrors.Errorf("error opening tipset index database: %w", err)
+	}
+	// head changes and the backfill write concurrently; a single connection serializes them
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range tipsetDbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("error executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	if err := reconcileTipSetIndex(db, cs); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("error reconciling tipset index database: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(lctx)
+
+	x := &tipsetIndex{
+		db:     db,
+		cs:     cs,
+		sema:   make(chan struct{}, 1),
+		cancel: cancel,
+	}
+
+	if err := x.prepareStatements(); err != nil {
+		if err := db.Close(); err != nil {
+			log.Errorf("error closing tipset index database: %s", err)
+		}
+
+		return nil, xerrors.Errorf("error preparing tipset index database statements: %w", err)
+	}
+
+	head := cs.GetHeaviestTipSet()
+
+	rnf := store.WrapHeadChangeCoalescer(
+		x.onHeadChange,
+		CoalesceMinDelay,
+		CoalesceMaxDelay,
+		CoalesceMergeInterval,
+	)
+	cs.SubscribeHeadChanges(rnf)
+
+	x.workers.Add(2)
+	go x.background(ctx)
+	go x.backfill(ctx, head)
+
+	return x, nil
+}
+
+func reconcileTipSetIndex(db *sql.DB, cs ChainStore) error {
+	// Invariant: after reconciliation, every tipset in the index is in the current chain.
+	// We walk down from the current head until we find a tipset in the index, and delete every
+	// tipset above it; if we don't find one, nothing in the index can be trusted.
+	row := db.QueryRow(dbqCountTipSets)
+
+	var result int64
+	if err := row.Scan(&result); err != nil {
+		return xerrors.Errorf("error counting tipsets: %w", err)
+	}
+
+	if result == 0 {
+		return nil
+	}
+
+	row = db.QueryRow(dbqMinTipSetEpoch)
+	if err := row.Scan(&result); err != nil {
+		return xerrors.Errorf("error finding boundary epoch: %w", err)
+	}
+
+	boundaryEpoch := abi.ChainEpoch(result)
+
+	heightStmt, err := db.Prepare(dbqGetTipSetHeight)
+	if err != nil {
+		return xerrors.Errorf("error preparing statement: %w", err)
+	}
+	defer heightStmt.Close() //nolint:errcheck
+
+	curTs := cs.GetHeaviestTipSet()
+	for curTs != nil && curTs.Height() >= boundaryEpoch {
+		tsCid, err := curTs.Key().Cid()
+		if err != nil {
+			return xerrors.Errorf("error computing tipset cid: %w", err)
+		}
+
+		err = heightStmt.QueryRow(tsCid.String()).Scan(&result)
+		if err == nil {
+			// found it!
+			boundaryEpoch = curTs.Height() + 1
+			break
+		}
+		if err != sql.ErrNoRows {
+			return xerrors.Errorf("error querying tipset: %w", err)
+		}
+
+		if curTs.Height() == 0 {
+			break
+		}
+
+		// walk up
+		curTs, err = cs.GetTipSetFromKey(context.TODO(), curTs.Parents())
+		if err != nil {
+			return xerrors.Errorf("error walking chain: %w", err)
+		}
+	}
+
+	if _, err = db.Exec(dbqDeleteTipSetsByEpoch, int64(boundaryEpoch)); err != nil {
+		return xerrors.Errorf("error deleting stale reorged out tipsets: %w", err)
+	}
+
+	return nil
+}
+
+func (x *tipsetIndex) prepareStatements() error {
+	for _, s := range []struct {
+		stmt **sql.Stmt
+		q    string
+	}{
+		{&x.selectKeyStmt, dbqGetTipSetKey},
+		{&x.selectHeightStmt, dbqGetTipSetHeight},
+		{&x.insertStmt, dbqInsertTipSet},
+		{&x.deleteStmt, dbqDeleteTipSet},
+	} {
+		stmt, err := x.db.Prepare(s.q)
+		if err != nil {
+			return xerrors.Errorf("prepare %q: %w", s.q, err)
+		}
+		*s.stmt = stmt
+	}
+
+	return nil
+}
+
+// head change notifee
+func (x *tipsetIndex) onHeadChange(rev, app []*types.TipSet) error {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return nil
+	}
+
+	// do it in the background to avoid blocking head change processing
+	x.mx.Lock()
+	x.pend = append(x.pend, headChange{rev: rev, app: app})
+	pendLen := len(x.pend)
+	x.mx.Unlock()
+
+	// complain loudly if this is building backlog
+	if pendLen > 10 {
+		log.Warnf("tipset index head change processing is building backlog: %d pending head changes", pendLen)
+	}
+
+	select {
+	case x.sema <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (x *tipsetIndex) background(ctx context.Context) {
+	defer x.workers.Done()
+
+	for {
+		select {
+		case <-x.sema:
+			err := x.processHeadChanges()
+			if err != nil {
+				// we can't rely on an inconsistent index, so shut it down.
+				log.Errorf("error processing head change notifications: %s; shutting down tipset index", err)
+				go func() {
+					if err2 := x.Close(); err2 != nil {
+						log.Errorf("error shutting down tipset index: %s", err2)
+					}
+				}()
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (x *tipsetIndex) processHeadChanges() error {
+	x.mx.Lock()
+	pend := x.pend
+	x.pend = nil
+	x.mx.Unlock()
+
+	tx, err := x.db.Begin()
+	if err != nil {
+		return xerrors.Errorf("error creating transaction: %w", err)
+	}
+
+	for _, hc := range pend {
+		for _, ts := range hc.rev {
+			if err := x.doRevert(tx, ts); err != nil {
+				if err2 := tx.Rollback(); err2 != nil {
+					log.Errorf("error rolling back transaction: %s", err2)
+				}
+				return xerrors.Errorf("error reverting %s: %w", ts, err)
+			}
+		}
+
+		for _, ts := range hc.app {
+			if err := x.doApply(tx, ts); err != nil {
+				if err2 := tx.Rollback(); err2 != nil {
+					log.Errorf("error rolling back transaction: %s", err2)
+				}
+				return xerrors.Errorf("error applying %s: %w", ts, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (x *tipsetIndex) doRevert(tx *sql.Tx, ts *types.TipSet) error {
+	tsCid, err := ts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	_, err = tx.Stmt(x.deleteStmt).Exec(tsCid.String())
+	return err
+}
+
+func (x *tipsetIndex) doApply(tx *sql.Tx, ts *types.TipSet) error {
+	tsCid, err := ts.Key().Cid()
+	if err != nil {
+		return xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	// a tipset replaces whichever tipset was indexed at its height
+	if _, err := tx.Stmt(x.insertStmt).Exec(tsCid.String(), ts.Key().Bytes(), int64(ts.Height())); err != nil {
+		return xerrors.Errorf("error inserting tipset: %w", err)
+	}
+
+	return nil
+}
+
+// backfill indexes the tipsets below head which are not in the index yet: first the tipsets down
+// to the last indexed tipset, which were synced while the index wasn't running, and then the
+// tipsets below the first indexed tipset, down to genesis.
+//
+// head may have been reverted since, and a reverted tipset would replace the tipset of the current
+// chain at its height, so tipsets are only indexed when they are in the current chain. As in the
+// receipt index, they are checked in the transaction indexing them.
+func (x *tipsetIndex) backfill(ctx context.Context, head *types.TipSet) {
+	defer x.workers.Done()
+
+	fill := func(ts *types.TipSet, stopIndexed bool) error {
+		for {
+			tx, err := x.db.Begin()
+			if err != nil {
+				return xerrors.Errorf("error creating transaction: %w", err)
+			}
+
+			// the ancestors of a tipset in the current chain are in it too
+			done, canonical := false, false
+			for i := 0; i < TipSetBackfillBatch; i++ {
+				if err := ctx.Err(); err != nil {
+					_ = tx.Rollback()
+					return err
+				}
+
+				tsCid, err := ts.Key().Cid()
+				if err != nil {
+					_ = tx.Rollback()
+					return xerrors.Errorf("error computing tipset cid: %w", err)
+				}
+
+				var epoch int64
+				err = tx.Stmt(x.selectHeightStmt).QueryRow(tsCid.String()).Scan(&epoch)
+				switch {
+				case err == nil:
+					if stopIndexed {
+						done = true
+					}
+
+				case err == sql.ErrNoRows:
+					if !canonical {
+						canonical, err = isCanonical(ctx, x.cs, ts)
+						if err != nil {
+							_ = tx.Rollback()
+							return err
+						}
+					}
+					if canonical {
+						if err := x.doApply(tx, ts); err != nil {
+							_ = tx.Rollback()
+							return xerrors.Errorf("error indexing tipset at epoch %d: %w", ts.Height(), err)
+						}
+					}
+
+				default:
+					_ = tx.Rollback()
+					return xerrors.Errorf("error querying tipset: %w", err)
+				}
+
+				if done || ts.Height() == 0 {
+					done = true
+					break
+				}
+
+				ts, err = x.cs.GetTipSetFromKey(ctx, ts.Parents())
+				if err != nil {
+					_ = tx.Rollback()
+					return xerrors.Errorf("error walking chain: %w", err)
+				}
+			}
+
+			if err := tx.Commit(); err != nil {
+				return xerrors.Errorf("error committing transaction: %w", err)
+			}
+
+			if done {
+				return nil
+			}
+		}
+	}
+
+	var min sql.NullInt64
+	if err := x.db.QueryRow(dbqMinTipSetEpoch).Scan(&min); err != nil {
+		log.Errorf("error finding the first indexed epoch: %s", err)
+		return
+	}
+
+	if min.Valid {
+		if err := fill(head, true); err != nil {
+			log.Infof("tipset index backfill stopped: %s", err)
+			return
+		}
+
+		if min.Int64 == 0 {
+			return
+		}
+
+		// continue from the first indexed tipset
+		var key []byte
+		if err := x.db.QueryRow(dbqGetTipSetKey, min.Int64).Scan(&key); err != nil {
+			log.Errorf("error looking up the first indexed tipset: %s", err)
+			return
+		}
+
+		tsk, err := types.TipSetKeyFromBytes(key)
+		if err != nil {
+			log.Errorf("error decoding the first indexed tipset key: %s", err)
+			return
+		}
+
+		head, err = x.cs.GetTipSetFromKey(ctx, tsk)
+		if err != nil {
+			log.Errorf("error loading the first indexed tipset: %s", err)
+			return
+		}
+	}
+
+	log.Infow("backfilling tipset index", "from", head.Height())
+	if err := fill(head, false); err != nil {
+		log.Infof("tipset index backfill stopped: %s", err)
+		return
+	}
+	log.Info("tipset index backfill done")
+}
+
+// interface
+func (x *tipsetIndex) GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error) {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return types.EmptyTSK, ErrClosed
+	}
+
+	var key []byte
+	err := x.selectKeyStmt.QueryRowContext(ctx, int64(h)).Scan(&key)
+	switch {
+	case err == sql.ErrNoRows:
+		return types.EmptyTSK, ErrNotFound
+
+	case err != nil:
+		return types.EmptyTSK, xerrors.Errorf("error querying tipset index database: %w", err)
+	}
+
+	return types.TipSetKeyFromBytes(key)
+}
+
+func (x *tipsetIndex) GetTipSetHeight(ctx context.Context, tsk types.TipSetKey) (abi.ChainEpoch, error) {
+	x.closeLk.RLock()
+	defer x.closeLk.RUnlock()
+
+	if x.closed {
+		return 0, ErrClosed
+	}
+
+	tsCid, err := tsk.Cid()
+	if err != nil {
+		return 0, xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	var epoch int64
+	err = x.selectHeightStmt.QueryRowContext(ctx, tsCid.String()).Scan(&epoch)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, ErrNotFound
+
+	case err != nil:
+		return 0, xerrors.Errorf("error querying tipset index database: %w", err)
+	}
+
+	return abi.ChainEpoch(epoch), nil
+}
+
+func (x *tipsetIndex) Close() error {
+	x.closeLk.Lock()
+	defer x.closeLk.Unlock()
+
+	if x.closed {
+		return nil
+	}
+
+	x.closed = true
+
+	x.cancel()
+	x.workers.Wait()
+
+	return x.db.Close()
+}
@@ -0,0 +1,166 @@
+package index
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestBasicTipSetIndex(t *testing.T) {
+	cs := newMockChainStore()
+	cs.genesis()
+
+	tipsetIndex, err := NewTipSetIndex(context.Background(), t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer tipsetIndex.Close() //nolint
+
+	for i := 0; i < 10; i++ {
+		t.Logf("advance to epoch %d", i+1)
+		err := cs.advance()
+		require.NoError(t, err)
+		// wait for the coalescer to notify
+		time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+	}
+
+	t.Log("verifying index")
+	verifyTipSetIndex(t, cs, tipsetIndex)
+}
+
+func TestReorgTipSetIndex(t *testing.T) {
+	cs := newMockChainStore()
+	cs.genesis()
+
+	tipsetIndex, err := NewTipSetIndex(context.Background(), t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer tipsetIndex.Close() //nolint
+
+	for i := 0; i < 10; i++ {
+		err := cs.advance()
+		require.NoError(t, err)
+		time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+	}
+
+	t.Log("doing reorg")
+	reorgme := cs.curTs
+	reorgmeParent, err := cs.GetTipSetFromKey(context.Background(), reorgme.Parents())
+	require.NoError(t, err)
+	cs.setHead(reorgmeParent)
+	reorgmeSibling := cs.makeBlk()
+	err = cs.reorg([]*types.TipSet{reorgme}, []*types.TipSet{reorgmeSibling})
+	require.NoError(t, err)
+	time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+
+	t.Log("verifying index")
+	verifyTipSetIndex(t, cs, tipsetIndex)
+
+	t.Log("verifying that the reorged tipset is not present")
+	_, err = tipsetIndex.GetTipSetHeight(context.Background(), reorgme.Key())
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestBackfillTipSetIndex(t *testing.T) {
+	cs := newMockChainStore()
+	cs.genesis()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	tmp := t.TempDir()
+
+	// the first backfill indexes the chain down to genesis
+	tipsetIndex, err := NewTipSetIndex(context.Background(), tmp, cs)
+	require.NoError(t, err)
+
+	waitTipSetBackfill(t, tipsetIndex)
+	verifyTipSetIndex(t, cs, tipsetIndex)
+	require.NoError(t, tipsetIndex.Close())
+
+	// the next one fills the gap above the tipsets indexed before
+	cs.notify = nil
+	for i := 0; i < 5; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	tipsetIndex, err = NewTipSetIndex(context.Background(), tmp, cs)
+	require.NoError(t, err)
+
+	defer tipsetIndex.Close() //nolint
+
+	require.Eventually(t, func() bool {
+		_, err := tipsetIndex.GetTipSetHeight(context.Background(), cs.curTs.Parents())
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	verifyTipSetIndex(t, cs, tipsetIndex)
+}
+
+func TestBackfillStaleHeadTipSetIndex(t *testing.T) {
+	ctx := context.Background()
+
+	cs := newMockChainStore()
+	cs.genesis()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, cs.advance())
+	}
+
+	tsi, err := NewTipSetIndex(ctx, t.TempDir(), cs)
+	require.NoError(t, err)
+
+	defer tsi.Close() //nolint
+
+	waitTipSetBackfill(t, tsi)
+
+	reorgme := cs.curTs
+	reorgmeParent, err := cs.GetTipSetFromKey(ctx, reorgme.Parents())
+	require.NoError(t, err)
+	cs.setHead(reorgmeParent)
+	require.NoError(t, cs.reorg([]*types.TipSet{reorgme}, []*types.TipSet{cs.makeBlk()}))
+	time.Sleep(CoalesceMinDelay + 10*time.Millisecond)
+
+	// a backfill from the head before the reorg doesn't replace its sibling with the reverted tipset
+	x := tsi.(*tipsetIndex)
+	x.workers.Add(1)
+	x.backfill(ctx, reorgme)
+
+	_, err = tsi.GetTipSetHeight(ctx, reorgme.Key())
+	require.Equal(t, ErrNotFound, err)
+	verifyTipSetIndex(t, cs, tsi)
+}
+
+// waitTipSetBackfill waits until genesis is indexed by the backfill, which indexes it last.
+func waitTipSetBackfill(t *testing.T, tipsetIndex TipSetIndex) {
+	require.Eventually(t, func() bool {
+		_, err := tipsetIndex.GetTipSetKey(context.Background(), 0)
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func verifyTipSetIndex(t *testing.T, cs *mockChainStore, tipsetIndex TipSetIndex) {
+	ctx := context.Background()
+
+	for ts := cs.curTs; ; {
+		t.Logf("verify at height %d", ts.Height())
+
+		h, err := tipsetIndex.GetTipSetHeight(ctx, ts.Key())
+		require.NoError(t, err)
+		require.Equal(t, ts.Height(), h)
+
+		tsk, err := tipsetIndex.GetTipSetKey(ctx, ts.Height())
+		require.NoError(t, err)
+		require.Equal(t, ts.Key(), tsk)
+
+		if ts.Height() == 0 {
+			break
+		}
+
+		ts, err = cs.GetTipSetFromKey(ctx, ts.Parents())
+		require.NoError(t, err)
+	}
+}
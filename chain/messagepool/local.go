@@ -0,0 +1,156 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// localRepubState is the persisted republish state of a locally published message
+type localRepubState struct {
+	Republishes     uint64
+	LastRepublished time.Time
+}
+
+func localKey(c cid.Cid) datastore.Key {
+	return datastore.NewKey(string(c.Bytes()))
+}
+
+// loadRepubState returns true if the local message has been republished before.
+func (mp *MessagePool) loadRepubState(ctx context.Context, c cid.Cid) bool {
+	st, err := mp.getRepubState(ctx, c)
+	if err != nil {
+		log.Warnf("error loading republish state of local message %s: %s", c, err)
+		return false
+	}
+
+	return st.Republishes > 0
+}
+
+func (mp *MessagePool) getRepubState(ctx context.Context, c cid.Cid) (localRepubState, error) {
+	var st localRepubState
+
+	b, err := mp.localRepub.Get(ctx, localKey(c))
+	switch {
+	case err == datastore.ErrNotFound:
+		return st, nil
+	case err != nil:
+		return st, err
+	}
+
+	if err := json.Unmarshal(b, &st); err != nil {
+		return st, xerrors.Errorf("unmarshaling republish state: %w", err)
+	}
+
+	return st, nil
+}
+
+// recordRepublished persists the republish state of the given local messages.
+func (mp *MessagePool) recordRepublished(ctx context.Context, msgs []cid.Cid) {
+	now := time.Now()
+	for _, c := range msgs {
+		st, err := mp.getRepubState(ctx, c)
+		if err != nil {
+			log.Warnf("error loading republish state of local message %s: %s", c, err)
+		}
+
+		st.Republishes++
+		st.LastRepublished = now
+
+		b, err := json.Marshal(&st)
+		if err != nil {
+			log.Warnf("error marshaling republish state of local message %s: %s", c, err)
+			continue
+		}
+
+		if err := mp.localRepub.Put(ctx, localKey(c), b); err != nil {
+			log.Warnf("error persisting republish state of local message %s: %s", c, err)
+		}
+	}
+}
+
+// forgetLocal removes a local message and its republish state from the datastore.
+func (mp *MessagePool) forgetLocal(ctx context.Context, c cid.Cid) {
+	if err := mp.localMsgs.Delete(ctx, localKey(c)); err != nil {
+		log.Warnf("error deleting local message: %s", err)
+	}
+	if err := mp.localRepub.Delete(ctx, localKey(c)); err != nil {
+		log.Warnf("error deleting local message republish state: %s", err)
+	}
+}
+
+// finalTipSet returns the tipset at finality below the current head, or nil if the chain
+// isn't that long yet.
+func (mp *MessagePool) finalTipSet(ctx context.Context) *types.TipSet {
+	ts := mp.curTs
+	if ts == nil || ts.Height() <= policy.ChainFinality {
+		return nil
+	}
+
+	final := ts.Height() - policy.ChainFinality
+	for ts.Height() > final {
+		pts, err := mp.api.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			log.Warnf("error loading tipset at finality: %s", err)
+			return nil
+		}
+		ts = pts
+	}
+
+	return ts
+}
+
+// localNonceFinal returns true if the nonce of the local message has been used by a message
+// included at the final tipset, so that the message can't be included anymore.
+func (mp *MessagePool) localNonceFinal(ctx context.Context, m *types.SignedMessage, finalTs *types.TipSet) bool {
+	if finalTs == nil {
+		return false
+	}
+
+	snonce, err := mp.getStateNonce(ctx, m.Message.From, finalTs)
+	if err != nil {
+		log.Warnf("error looking up final nonce of local message %s: %s", m.Cid(), err)
+		return false
+	}
+
+	return snonce > m.Message.Nonce
+}
+
+// MigrateLocalMessages moves the locally published messages (and their republish state,
+// and the messages held back because of a nonce gap) persisted in the from datastore to the to datastore, returning the number of migrated
+// entries.
+func MigrateLocalMessages(ctx context.Context, from, to datastore.Datastore) (int, error) {
+	var migrated int
+	for _, prefix := range []string{localMsgsDs, localRepubDs, heldMsgsDs} {
+		res, err := from.Query(ctx, query.Query{Prefix: prefix})
+		if err != nil {
+			return migrated, xerrors.Errorf("query %s: %w", prefix, err)
+		}
+
+		entries, err := res.Rest()
+		if err != nil {
+			return migrated, xerrors.Errorf("reading %s: %w", prefix, err)
+		}
+
+		for _, e := range entries {
+			k := datastore.NewKey(e.Key)
+			if err := to.Put(ctx, k, e.Value); err != nil {
+				return migrated, xerrors.Errorf("writing %s: %w", k, err)
+			}
+			if err := from.Delete(ctx, k); err != nil {
+				return migrated, xerrors.Errorf("deleting %s: %w", k, err)
+			}
+			migrated++
+		}
+	}
+
+	return migrated, nil
+}
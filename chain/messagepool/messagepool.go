@@ -84,7 +84,9 @@ var (
 )
 
 const (
-	localMsgsDs = "/mpool/local"
+	localMsgsDs  = "/mpool/local"
+	localRepubDs = "/mpool/repub"
+	heldMsgsDs   = "/mpool/held"
 
 	localUpdates = "update"
 )
@@ -142,8 +144,11 @@ type MessagePool struct {
 	curTsLk sync.Mutex // DO NOT LOCK INSIDE lk
 	curTs   *types.TipSet
 
-	cfgLk sync.RWMutex
-	cfg   *types.MpoolConfig
+	cfgLk        sync.RWMutex
+	cfg          *types.MpoolConfig
+	senderPolicy SenderPolicy
+
+	sendQueue sendQueue
 
 	api Provider
 
@@ -163,7 +168,8 @@ type MessagePool struct {
 
 	changes *lps.PubSub
 
-	localMsgs datastore.Datastore
+	localMsgs  datastore.Datastore
+	localRepub datastore.Datastore
 
 	netName dtypes.NetworkName
 
@@ -369,6 +375,12 @@ func (ms *msgSet) toSlice() []*types.SignedMessage {
 }
 
 func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.UpgradeSchedule, netName dtypes.NetworkName, j journal.Journal) (*MessagePool, error) {
+	return NewWithLocalStore(ctx, api, ds, ds, us, netName, j)
+}
+
+// NewWithLocalStore creates a message pool which persists locally published messages and
+// their republish state in localDs, instead of the metadata datastore.
+func NewWithLocalStore(ctx context.Context, api Provider, ds dtypes.MetadataDS, localDs datastore.Datastore, us stmgr.UpgradeSchedule, netName dtypes.NetworkName, j journal.Journal) (*MessagePool, error) {
 	cache, _ := lru.New2Q[cid.Cid, crypto.Signature](build.BlsSignatureCacheSize)
 	verifcache, _ := lru.New2Q[string, struct{}](build.VerifSigCacheSize)
 	noncecache, _ := lru.New[nonceCacheKey, uint64](256)
@@ -399,10 +411,12 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		sigValCache:    verifcache,
 		nonceCache:     noncecache,
 		changes:        lps.New(50),
-		localMsgs:      namespace.Wrap(ds, datastore.NewKey(localMsgsDs)),
+		localMsgs:      namespace.Wrap(localDs, datastore.NewKey(localMsgsDs)),
+		localRepub:     namespace.Wrap(localDs, datastore.NewKey(localRepubDs)),
 		api:            api,
 		netName:        netName,
 		cfg:            cfg,
+		senderPolicy:   ConfigSenderPolicy{},
 		evtTypes: [...]journal.EventType{
 			evtTypeMpoolAdd:    j.RegisterEventType("mpool", "add"),
 			evtTypeMpoolRemove: j.RegisterEventType("mpool", "remove"),
@@ -410,6 +424,7 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		},
 		journal: j,
 	}
+	mp.sendQueue.ds = namespace.Wrap(localDs, datastore.NewKey(heldMsgsDs))
 
 	// enable initial prunes
 	mp.pruneCooldown <- struct{}{}
@@ -422,6 +437,10 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		if err != nil {
 			log.Errorf("mpool head notif handler error: %+v", err)
 		}
+		// messages included on chain may have filled nonce gaps
+		if mp.sendQueue.size() > 0 {
+			go mp.releaseAllQueued(context.TODO())
+		}
 		return err
 	})
 
@@ -431,6 +450,9 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 	go func() {
 		defer cancel()
 		err := mp.loadLocal(ctx)
+		if herr := mp.loadHeld(ctx); herr != nil {
+			log.Errorf("loading held messages: %+v", herr)
+		}
 
 		mp.lk.Unlock()
 		mp.curTsLk.Unlock()
@@ -439,6 +461,11 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 			log.Errorf("loading local messages: %+v", err)
 		}
 
+		// held messages may no longer have a nonce gap
+		if mp.sendQueue.size() > 0 {
+			go mp.releaseAllQueued(context.TODO())
+		}
+
 		log.Info("mpool ready")
 
 		mp.runLoop(ctx)
@@ -617,7 +644,7 @@ func (mp *MessagePool) addLocal(ctx context.Context, m *types.SignedMessage) err
 		return xerrors.Errorf("error serializing message: %w", err)
 	}
 
-	if err := mp.localMsgs.Put(ctx, datastore.NewKey(string(m.Cid().Bytes())), msgb); err != nil {
+	if err := mp.localMsgs.Put(ctx, localKey(m.Cid()), msgb); err != nil {
 		return xerrors.Errorf("persisting local message: %w", err)
 	}
 
@@ -678,11 +705,24 @@ func (mp *MessagePool) verifyMsgBeforeAdd(ctx context.Context, m *types.SignedMe
 }
 
 // Push checks the signed message for any violations, adds the message to the message pool and
-// publishes the message if the publish flag is set
+// publishes the message if the publish flag is set. Messages with a nonce past the next
+// nonce of the sender are held in the send queue until the gap fills.
 func (mp *MessagePool) Push(ctx context.Context, m *types.SignedMessage, publish bool) (cid.Cid, error) {
 	done := metrics.Timer(ctx, metrics.MpoolPushDuration)
 	defer done()
 
+	c, err := mp.push(ctx, m, publish, true)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	// the message may fill a nonce gap
+	mp.releaseQueued(ctx, m.Message.From)
+
+	return c, nil
+}
+
+func (mp *MessagePool) push(ctx context.Context, m *types.SignedMessage, publish, hold bool) (cid.Cid, error) {
 	err := mp.checkMessage(ctx, m)
 	if err != nil {
 		return cid.Undef, err
@@ -695,6 +735,14 @@ func (mp *MessagePool) Push(ctx context.Context, m *types.SignedMessage, publish
 	}()
 
 	mp.curTsLk.Lock()
+	if hold {
+		held, err := mp.holdIfGapped(ctx, m, publish)
+		if err != nil || held {
+			mp.curTsLk.Unlock()
+			return m.Cid(), err
+		}
+	}
+
 	ok, err := mp.addTs(ctx, m, mp.curTs, true, false)
 	if err != nil {
 		mp.curTsLk.Unlock()
@@ -1519,6 +1567,8 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 		return xerrors.Errorf("query local messages: %w", err)
 	}
 
+	var finalTs *types.TipSet
+	var finalLoaded bool
 	for r := range res.Next() {
 		if r.Error != nil {
 			return xerrors.Errorf("r.Error: %w", r.Error)
@@ -1531,12 +1581,28 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 
 		if err := mp.addLoaded(ctx, &sm); err != nil {
 			if xerrors.Is(err, ErrNonceTooLow) {
-				continue // todo: drop the message from local cache (if above certain confidence threshold)
+				// the message (or a replacement) has been included at the current head, but
+				// it's only dropped once that's final, as a reorg may revert it
+				if !finalLoaded {
+					finalTs = mp.finalTipSet(ctx)
+					finalLoaded = true
+				}
+				if mp.localNonceFinal(ctx, &sm, finalTs) {
+					mp.forgetLocal(ctx, sm.Cid())
+				}
+				continue
 			}
 
 			log.Errorf("adding local message: %+v", err)
 		}
 
+		if mp.loadRepubState(ctx, sm.Cid()) {
+			if mp.republished == nil {
+				mp.republished = make(map[cid.Cid]struct{})
+			}
+			mp.republished[sm.Cid()] = struct{}{}
+		}
+
 		if err = mp.setLocal(ctx, sm.Message.From); err != nil {
 			log.Debugf("mpoolloadLocal errored: %s", err)
 			return err
@@ -1562,10 +1628,7 @@ func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 
 			if ok {
 				for _, m := range mset.msgs {
-					err := mp.localMsgs.Delete(ctx, datastore.NewKey(string(m.Cid().Bytes())))
-					if err != nil {
-						log.Warnf("error deleting local message: %s", err)
-					}
+					mp.forgetLocal(ctx, m.Cid())
 				}
 			}
 		})
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/stretchr/testify/assert"
 
@@ -20,6 +21,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/messagepool/gasguess"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -41,6 +43,7 @@ type testMpoolAPI struct {
 	balance    map[address.Address]types.BigInt
 
 	tipsets []*types.TipSet
+	head    *types.TipSet
 
 	published int
 
@@ -104,6 +107,9 @@ func (tma *testMpoolAPI) setBlockMessages(h *types.BlockHeader, msgs ...*types.S
 
 func (tma *testMpoolAPI) SubscribeHeadChanges(cb func(rev, app []*types.TipSet) error) *types.TipSet {
 	tma.cb = cb
+	if tma.head != nil {
+		return tma.head
+	}
 	return tma.tipsets[0]
 }
 
@@ -582,6 +588,153 @@ func TestLoadLocal(t *testing.T) {
 	}
 }
 
+func TestLoadLocalMigrated(t *testing.T) {
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+	lds := datastore.NewMapDatastore()
+
+	// messages pushed by a pool persisting local messages in the metadata datastore
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w2, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w2.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	for i := 0; i < 10; i++ {
+		m := makeTestMessage(w1, a1, a2, uint64(i), gasLimit, uint64(i+1))
+		if _, err := mp.Push(context.TODO(), m, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateLocalMessages(context.Background(), ds, lds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 10 {
+		t.Fatalf("expected 10 migrated messages, but got %d", migrated)
+	}
+
+	res, err := ds.Query(context.Background(), query.Query{Prefix: localMsgsDs, KeysOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, err := res.Rest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Fatalf("expected no local messages left in the metadata datastore, but got %d", len(left))
+	}
+
+	mp, err = NewWithLocalStore(context.Background(), tma, ds, lds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmsgs, _ := mp.Pending(context.TODO())
+	if len(pmsgs) != 10 {
+		t.Fatalf("expected %d messages, but got %d", 10, len(pmsgs))
+	}
+}
+
+func TestLoadLocalKeepsUnfinalNonces(t *testing.T) {
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a1, err := w1.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w2, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := w2.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tma.setBalance(a1, 1) // in FIL
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	var msgs []*types.SignedMessage
+	for i := 0; i < 4; i++ {
+		m := makeTestMessage(w1, a1, a2, uint64(i), gasLimit, uint64(i+1))
+		if _, err := mp.Push(context.TODO(), m, true); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, m)
+	}
+	if err := mp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first two messages are included at finality, all of them at the head
+	final := tma.nextBlockWithHeight(100)
+	tma.setBlockMessages(final, msgs[:2]...)
+	head := tma.nextBlockWithHeight(uint64(100 + policy.ChainFinality + 10))
+	tma.setBlockMessages(head, msgs...)
+	tma.head = mock.TipSet(head)
+
+	mp, err = New(context.Background(), tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmsgs, _ := mp.Pending(context.TODO())
+	if len(pmsgs) != 0 {
+		t.Fatalf("expected no pending messages, but got %d", len(pmsgs))
+	}
+
+	// the messages which could still be reverted are kept
+	for i, m := range msgs {
+		has, err := mp.localMsgs.Has(context.TODO(), localKey(m.Cid()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != (i >= 2) {
+			t.Fatalf("message with nonce %d: expected persisted %t, got %t", i, i >= 2, has)
+		}
+	}
+}
+
 func TestClearAll(t *testing.T) {
 	tma := newTestMpoolAPI()
 	ds := datastore.NewMapDatastore()
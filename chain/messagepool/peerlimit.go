@@ -0,0 +1,245 @@
+package messagepool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/raulk/clock"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var (
+	ErrPeerRateLimited = errors.New("peer exceeded the untrusted message rate limit")
+	ErrPeerBanned      = errors.New("peer sent too many invalid messages")
+)
+
+// maxTrackedPeers bounds the number of peers the PeerLimiter keeps counters for;
+// the least recently seen peers are forgotten first.
+const maxTrackedPeers = 4096
+
+// PeerLimits configures the PeerLimiter.
+type PeerLimits struct {
+	// Rate is the number of messages per second accepted from a single peer, 0
+	// disables rate limiting
+	Rate float64
+	// Burst is the number of messages a peer can send at once
+	Burst int
+	// MaxRejected is the number of invalid messages a peer can send before all
+	// its messages are refused for BanDuration, 0 disables banning
+	MaxRejected int
+	BanDuration time.Duration
+}
+
+// PeerStats are the counters kept for a peer sending untrusted messages.
+type PeerStats struct {
+	Peer string
+
+	Accepted    uint64
+	Rejected    uint64
+	RateLimited uint64
+
+	LastSeen    time.Time
+	BannedUntil time.Time
+}
+
+type peerState struct {
+	stats   PeerStats
+	limiter *rate.Limiter
+	// invalid messages sent since the peer was last banned
+	strikes int
+}
+
+// PeerLimiter rate limits and tracks the reputation of the peers sending untrusted
+// messages to the message pool, both over gossip and through MpoolPushUntrusted.
+// A nil PeerLimiter allows all messages.
+type PeerLimiter struct {
+	limits PeerLimits
+	clock  clock.Clock
+
+	lk    sync.Mutex
+	peers *lru.Cache[string, *peerState]
+}
+
+func NewPeerLimiter(limits PeerLimits) *PeerLimiter {
+	peers, err := lru.New[string, *peerState](maxTrackedPeers)
+	if err != nil {
+		// err only if parameter is bad
+		panic(err)
+	}
+
+	return &PeerLimiter{
+		limits: limits,
+		clock:  build.Clock,
+		peers:  peers,
+	}
+}
+
+// Allow checks whether a message from the peer should be processed. It returns
+// ErrPeerRateLimited or ErrPeerBanned if the message must be dropped.
+func (pl *PeerLimiter) Allow(peer string) error {
+	if pl == nil || peer == "" {
+		return nil
+	}
+
+	pl.lk.Lock()
+	defer pl.lk.Unlock()
+
+	now := pl.clock.Now()
+	ps := pl.peer(peer)
+	ps.stats.LastSeen = now
+
+	if now.Before(ps.stats.BannedUntil) {
+		return xerrors.Errorf("peer %s banned until %s: %w", peer, ps.stats.BannedUntil.Format(time.RFC3339), ErrPeerBanned)
+	}
+
+	if ps.limiter != nil && !ps.limiter.AllowN(now, 1) {
+		ps.stats.RateLimited++
+		return xerrors.Errorf("peer %s: %w", peer, ErrPeerRateLimited)
+	}
+
+	return nil
+}
+
+// Record accounts for the outcome of adding a message sent by the peer to the
+// message pool. Soft failures, such as nonce gaps or insufficient funds, which
+// an honest peer may run into, don't count against the peer.
+func (pl *PeerLimiter) Record(peer string, err error) {
+	if pl == nil || peer == "" {
+		return
+	}
+
+	pl.lk.Lock()
+	defer pl.lk.Unlock()
+
+	ps := pl.peer(peer)
+	switch {
+	case err == nil:
+		ps.stats.Accepted++
+	case isSoftFailure(err):
+	default:
+		ps.stats.Rejected++
+		ps.strikes++
+		if pl.limits.MaxRejected > 0 && ps.strikes >= pl.limits.MaxRejected {
+			log.Warnw("banning peer sending invalid messages", "peer", peer, "rejected", ps.strikes, "duration", pl.limits.BanDuration)
+			ps.stats.BannedUntil = pl.clock.Now().Add(pl.limits.BanDuration)
+			ps.strikes = 0
+		}
+	}
+}
+
+// SetLimits replaces the limits of the PeerLimiter. The rate limits of the tracked
+// peers are updated in place, keeping their counters and bans.
+func (pl *PeerLimiter) SetLimits(limits PeerLimits) {
+	if pl == nil {
+		return
+	}
+
+	pl.lk.Lock()
+	defer pl.lk.Unlock()
+
+	pl.limits = limits
+	for _, peer := range pl.peers.Keys() {
+		ps, ok := pl.peers.Peek(peer)
+		if !ok {
+			continue
+		}
+		ps.limiter = pl.newLimiter()
+	}
+}
+
+// Stats returns the counters of all the tracked peers, sorted by peer.
+func (pl *PeerLimiter) Stats() []PeerStats {
+	if pl == nil {
+		return nil
+	}
+
+	pl.lk.Lock()
+	defer pl.lk.Unlock()
+
+	out := make([]PeerStats, 0, pl.peers.Len())
+	for _, peer := range pl.peers.Keys() {
+		if ps, ok := pl.peers.Peek(peer); ok {
+			out = append(out, ps.stats)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Peer < out[j].Peer
+	})
+	return out
+}
+
+// Reset forgets the counters, rate limit and ban of a peer, or of all the peers
+// if peer is empty.
+func (pl *PeerLimiter) Reset(peer string) {
+	if pl == nil {
+		return
+	}
+
+	pl.lk.Lock()
+	defer pl.lk.Unlock()
+
+	if peer == "" {
+		pl.peers.Purge()
+		return
+	}
+	pl.peers.Remove(peer)
+}
+
+// must hold pl.lk
+func (pl *PeerLimiter) peer(peer string) *peerState {
+	ps, ok := pl.peers.Get(peer)
+	if ok {
+		return ps
+	}
+
+	ps = &peerState{stats: PeerStats{Peer: peer}, limiter: pl.newLimiter()}
+	pl.peers.Add(peer, ps)
+	return ps
+}
+
+// must hold pl.lk
+func (pl *PeerLimiter) newLimiter() *rate.Limiter {
+	if pl.limits.Rate <= 0 {
+		return nil
+	}
+
+	burst := pl.limits.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(pl.limits.Rate), burst)
+}
+
+func isSoftFailure(err error) bool {
+	switch {
+	case xerrors.Is(err, ErrSoftValidationFailure),
+		xerrors.Is(err, ErrRBFTooLowPremium),
+		xerrors.Is(err, ErrTooManyPendingMessages),
+		xerrors.Is(err, ErrNonceGap),
+		xerrors.Is(err, ErrNonceTooLow),
+		xerrors.Is(err, ErrExistingNonce):
+		return true
+	default:
+		return false
+	}
+}
+
+type peerCtxKey struct{}
+
+// WithPeer tags the context of an untrusted push with the peer it comes from.
+func WithPeer(ctx context.Context, peer string) context.Context {
+	return context.WithValue(ctx, peerCtxKey{}, peer)
+}
+
+// PeerFromContext returns the peer set with WithPeer, or an empty string.
+func PeerFromContext(ctx context.Context) string {
+	peer, _ := ctx.Value(peerCtxKey{}).(string)
+	return peer
+}
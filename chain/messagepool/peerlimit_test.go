@@ -0,0 +1,107 @@
+package messagepool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestPeerLimiterRateLimit(t *testing.T) {
+	pl := NewPeerLimiter(PeerLimits{Rate: 1, Burst: 2})
+	mock := clock.NewMock()
+	pl.clock = mock
+
+	require.NoError(t, pl.Allow("a"))
+	require.NoError(t, pl.Allow("a"))
+	require.ErrorIs(t, pl.Allow("a"), ErrPeerRateLimited)
+
+	// other peers have their own limit
+	require.NoError(t, pl.Allow("b"))
+
+	mock.Add(time.Second)
+	require.NoError(t, pl.Allow("a"))
+
+	stats := pl.Stats()
+	require.Len(t, stats, 2)
+	require.Equal(t, "a", stats[0].Peer)
+	require.Equal(t, uint64(1), stats[0].RateLimited)
+
+	// untagged pushes are never limited
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pl.Allow(""))
+	}
+}
+
+func TestPeerLimiterBan(t *testing.T) {
+	pl := NewPeerLimiter(PeerLimits{MaxRejected: 2, BanDuration: time.Minute})
+	mock := clock.NewMock()
+	pl.clock = mock
+
+	pl.Record("a", nil)
+	// soft failures don't count against the peer
+	pl.Record("a", xerrors.Errorf("gap: %w", ErrNonceGap))
+	pl.Record("a", errors.New("invalid signature"))
+	require.NoError(t, pl.Allow("a"))
+
+	pl.Record("a", errors.New("invalid signature"))
+	require.ErrorIs(t, pl.Allow("a"), ErrPeerBanned)
+
+	stats := pl.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, uint64(1), stats[0].Accepted)
+	require.Equal(t, uint64(2), stats[0].Rejected)
+
+	mock.Add(time.Minute)
+	require.NoError(t, pl.Allow("a"))
+
+	// reset lifts the ban
+	pl.Record("a", errors.New("invalid signature"))
+	pl.Record("a", errors.New("invalid signature"))
+	require.ErrorIs(t, pl.Allow("a"), ErrPeerBanned)
+	pl.Reset("a")
+	require.NoError(t, pl.Allow("a"))
+	require.Equal(t, uint64(0), pl.Stats()[0].Rejected)
+
+	pl.Reset("")
+	require.Empty(t, pl.Stats())
+}
+
+func TestPeerLimiterSetLimits(t *testing.T) {
+	pl := NewPeerLimiter(PeerLimits{Rate: 1, Burst: 1})
+	mock := clock.NewMock()
+	pl.clock = mock
+
+	require.NoError(t, pl.Allow("a"))
+	require.ErrorIs(t, pl.Allow("a"), ErrPeerRateLimited)
+
+	// raising the limit applies to the tracked peers
+	pl.SetLimits(PeerLimits{Rate: 1, Burst: 3})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pl.Allow("a"))
+	}
+	require.ErrorIs(t, pl.Allow("a"), ErrPeerRateLimited)
+	require.Equal(t, uint64(2), pl.Stats()[0].RateLimited)
+
+	// and disabling it lifts the limit
+	pl.SetLimits(PeerLimits{})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pl.Allow("a"))
+	}
+}
+
+func TestPeerLimiterNil(t *testing.T) {
+	var pl *PeerLimiter
+	require.NoError(t, pl.Allow("a"))
+	pl.Record("a", errors.New("invalid signature"))
+	require.Empty(t, pl.Stats())
+	pl.Reset("")
+	pl.SetLimits(PeerLimits{Rate: 1})
+
+	require.Equal(t, "a", PeerFromContext(WithPeer(context.Background(), "a")))
+	require.Equal(t, "", PeerFromContext(context.Background()))
+}
@@ -0,0 +1,52 @@
+package messagepool
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SenderPolicy decides which senders are prioritized by the message pool. Messages
+// from priority senders are never pruned when the pool grows over its size limit,
+// and are selected for block inclusion ahead of other messages.
+type SenderPolicy interface {
+	PrioritySenders(ctx context.Context, cfg *types.MpoolConfig) []address.Address
+}
+
+// ConfigSenderPolicy prioritizes the PriorityAddrs set in the mpool config.
+type ConfigSenderPolicy struct{}
+
+var _ SenderPolicy = ConfigSenderPolicy{}
+
+func (ConfigSenderPolicy) PrioritySenders(_ context.Context, cfg *types.MpoolConfig) []address.Address {
+	return cfg.PriorityAddrs
+}
+
+// StaticSenderPolicy prioritizes a fixed set of senders (e.g. the node operator's
+// miner worker addresses), in addition to the PriorityAddrs set in the mpool config.
+type StaticSenderPolicy []address.Address
+
+var _ SenderPolicy = StaticSenderPolicy(nil)
+
+func (p StaticSenderPolicy) PrioritySenders(_ context.Context, cfg *types.MpoolConfig) []address.Address {
+	out := make([]address.Address, 0, len(cfg.PriorityAddrs)+len(p))
+	out = append(out, cfg.PriorityAddrs...)
+	return append(out, p...)
+}
+
+// SetSenderPolicy replaces the policy deciding which senders are prioritized.
+func (mp *MessagePool) SetSenderPolicy(p SenderPolicy) {
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+	mp.senderPolicy = p
+}
+
+func (mp *MessagePool) prioritySenders(ctx context.Context, cfg *types.MpoolConfig) []address.Address {
+	mp.cfgLk.RLock()
+	p := mp.senderPolicy
+	mp.cfgLk.RUnlock()
+
+	return p.PrioritySenders(ctx, cfg)
+}
@@ -172,10 +172,15 @@ loop:
 
 	// track most recently republished messages
 	republished := make(map[cid.Cid]struct{})
+	repubCids := make([]cid.Cid, 0, count)
 	for _, m := range msgs[:count] {
 		republished[m.Cid()] = struct{}{}
+		repubCids = append(repubCids, m.Cid())
 	}
 
+	// and persist their republish state, so that it survives restarts
+	mp.recordRepublished(ctx, repubCids)
+
 	mp.lk.Lock()
 	// update the republished set so that we can trigger early republish from head changes
 	mp.republished = republished
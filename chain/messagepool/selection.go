@@ -598,7 +598,7 @@ func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[a
 
 	// 1. Get priority actor chains
 	var chains []*msgChain
-	priority := mpCfg.PriorityAddrs
+	priority := mp.prioritySenders(ctx, mpCfg)
 	for _, actor := range priority {
 		pk, err := mp.resolveToKey(ctx, actor)
 		if err != nil {
@@ -0,0 +1,350 @@
+package messagepool
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// maxQueuedPerSender bounds the number of local messages held back for a sender
+const maxQueuedPerSender = 100
+
+// sendQueue holds the local messages pushed with a nonce gap until the messages
+// filling the gap are pushed, or included on chain. Held messages are neither
+// added to the pending set nor published, so that they can't get stuck behind the
+// gap. They are persisted with the local messages, and held again on restart.
+type sendQueue struct {
+	lk sync.Mutex
+	// sender key address -> nonce -> message
+	held map[address.Address]map[uint64]queuedMsg
+
+	ds datastore.Datastore
+}
+
+type queuedMsg struct {
+	msg     *types.SignedMessage
+	publish bool
+}
+
+// put holds the message, persisting it
+func (sq *sendQueue) put(ctx context.Context, from address.Address, qm queuedMsg) error {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	return sq.hold(ctx, from, qm, true)
+}
+
+func (sq *sendQueue) hold(ctx context.Context, from address.Address, qm queuedMsg, persist bool) error {
+	if sq.held == nil {
+		sq.held = make(map[address.Address]map[uint64]queuedMsg)
+	}
+	held, ok := sq.held[from]
+	if !ok {
+		held = make(map[uint64]queuedMsg)
+		sq.held[from] = held
+	}
+
+	nonce := qm.msg.Message.Nonce
+	if _, replace := held[nonce]; !replace && len(held) >= maxQueuedPerSender {
+		return xerrors.Errorf("too many messages held back for %s: %w", from, ErrTooManyPendingMessages)
+	}
+
+	if persist {
+		msgb, err := qm.msg.Serialize()
+		if err != nil {
+			return xerrors.Errorf("error serializing message: %w", err)
+		}
+		if err := sq.ds.Put(ctx, localKey(qm.msg.Cid()), msgb); err != nil {
+			return xerrors.Errorf("persisting held message: %w", err)
+		}
+	}
+
+	held[nonce] = qm
+	return nil
+}
+
+// forget deletes the persisted copy of a message which is no longer held
+func (sq *sendQueue) forget(ctx context.Context, qm queuedMsg) {
+	if err := sq.ds.Delete(ctx, localKey(qm.msg.Cid())); err != nil {
+		log.Warnf("error deleting held message: %s", err)
+	}
+}
+
+// take removes the held message with the given nonce, dropping the messages with
+// lower nonces which can no longer be included. The caller must forget the taken
+// message once it is pushed or dropped.
+func (sq *sendQueue) take(ctx context.Context, from address.Address, nonce uint64) (queuedMsg, bool) {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	held := sq.held[from]
+	for n, qm := range held {
+		if n < nonce {
+			log.Infow("dropping held message superseded by another message", "from", from, "nonce", n, "cid", qm.msg.Cid())
+			delete(held, n)
+			sq.forget(ctx, qm)
+		}
+	}
+
+	qm, ok := held[nonce]
+	delete(held, nonce)
+	if len(held) == 0 {
+		delete(sq.held, from)
+	}
+	return qm, ok
+}
+
+// remove removes all the held messages of a sender, in nonce order. The caller
+// must forget the removed messages once they are pushed or dropped.
+func (sq *sendQueue) remove(from address.Address) []queuedMsg {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	out := sortedQueued(sq.held[from])
+	delete(sq.held, from)
+	return out
+}
+
+func (sq *sendQueue) list(from address.Address) []*types.SignedMessage {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	senders := []address.Address{from}
+	if from == address.Undef {
+		senders = senders[:0]
+		for s := range sq.held {
+			senders = append(senders, s)
+		}
+		sort.Slice(senders, func(i, j int) bool {
+			return senders[i].String() < senders[j].String()
+		})
+	}
+
+	var out []*types.SignedMessage
+	for _, s := range senders {
+		for _, qm := range sortedQueued(sq.held[s]) {
+			out = append(out, qm.msg)
+		}
+	}
+	return out
+}
+
+func (sq *sendQueue) has(from address.Address) bool {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	return len(sq.held[from]) > 0
+}
+
+// size returns the number of senders with held messages
+func (sq *sendQueue) size() int {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	return len(sq.held)
+}
+
+func (sq *sendQueue) senders() []address.Address {
+	sq.lk.Lock()
+	defer sq.lk.Unlock()
+
+	out := make([]address.Address, 0, len(sq.held))
+	for s := range sq.held {
+		out = append(out, s)
+	}
+	return out
+}
+
+func sortedQueued(held map[uint64]queuedMsg) []queuedMsg {
+	out := make([]queuedMsg, 0, len(held))
+	for _, qm := range held {
+		out = append(out, qm)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].msg.Message.Nonce < out[j].msg.Message.Nonce
+	})
+	return out
+}
+
+// holdIfGapped puts the message in the send queue if its nonce is past the next
+// nonce of the sender. Must be called with curTsLk held.
+func (mp *MessagePool) holdIfGapped(ctx context.Context, m *types.SignedMessage, publish bool) (bool, error) {
+	mp.lk.Lock()
+	next, err := mp.getNonceLocked(ctx, m.Message.From, mp.curTs)
+	var from address.Address
+	if err == nil {
+		from, err = mp.resolveToKey(ctx, m.Message.From)
+	}
+	if err != nil {
+		mp.lk.Unlock()
+		return false, xerrors.Errorf("getting next nonce: %s: %w", err, ErrSoftValidationFailure)
+	}
+
+	if m.Message.Nonce <= next {
+		mp.lk.Unlock()
+		return false, nil
+	}
+
+	// held messages are loaded with the local messages on restart
+	mp.localAddrs[from] = struct{}{}
+	mp.lk.Unlock()
+
+	if err := mp.sendQueue.put(ctx, from, queuedMsg{msg: m, publish: publish}); err != nil {
+		return false, err
+	}
+
+	log.Infow("holding message until nonce gap fills", "from", m.Message.From, "nonce", m.Message.Nonce, "next", next, "cid", m.Cid())
+	return true, nil
+}
+
+// releaseQueued pushes the held messages of a sender which no longer have a nonce gap
+func (mp *MessagePool) releaseQueued(ctx context.Context, addr address.Address) {
+	if mp.sendQueue.size() == 0 {
+		return
+	}
+
+	from, err := mp.queueKey(ctx, addr)
+	if err != nil {
+		log.Warnw("failed to resolve sender of held messages", "from", addr, "error", err)
+		return
+	}
+	if !mp.sendQueue.has(from) {
+		return
+	}
+
+	for {
+		next, err := mp.GetNonce(ctx, from, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("failed to get next nonce for held messages", "from", from, "error", err)
+			return
+		}
+
+		qm, ok := mp.sendQueue.take(ctx, from, next)
+		if !ok {
+			return
+		}
+
+		log.Infow("releasing held message", "from", from, "nonce", next, "cid", qm.msg.Cid())
+		if _, err := mp.push(ctx, qm.msg, qm.publish, false); err != nil {
+			if isSoftFailure(err) {
+				// try again on the next head change
+				if perr := mp.sendQueue.put(ctx, from, qm); perr != nil {
+					log.Errorw("failed to hold message again", "from", from, "nonce", next, "error", perr)
+				}
+			} else {
+				log.Errorw("dropping held message which failed to push", "from", from, "nonce", next, "cid", qm.msg.Cid(), "error", err)
+				mp.sendQueue.forget(ctx, qm)
+			}
+			return
+		}
+		mp.sendQueue.forget(ctx, qm)
+	}
+}
+
+func (mp *MessagePool) releaseAllQueued(ctx context.Context) {
+	for _, from := range mp.sendQueue.senders() {
+		mp.releaseQueued(ctx, from)
+	}
+}
+
+func (mp *MessagePool) queueKey(ctx context.Context, addr address.Address) (address.Address, error) {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	return mp.resolveToKey(ctx, addr)
+}
+
+// SendQueue returns the local messages held back because of a nonce gap, sent by
+// the given address, or by any address if addr is address.Undef.
+func (mp *MessagePool) SendQueue(ctx context.Context, addr address.Address) ([]*types.SignedMessage, error) {
+	if addr == address.Undef {
+		return mp.sendQueue.list(address.Undef), nil
+	}
+
+	from, err := mp.queueKey(ctx, addr)
+	if err != nil {
+		return nil, xerrors.Errorf("resolving sender: %w", err)
+	}
+	return mp.sendQueue.list(from), nil
+}
+
+// FlushSendQueue removes the messages of the address from the send queue. Unless
+// drop is set, they are pushed to the message pool despite the nonce gap. It
+// returns the flushed messages; if a message fails to push, it and the following
+// messages are kept in the queue.
+func (mp *MessagePool) FlushSendQueue(ctx context.Context, addr address.Address, drop bool) ([]*types.SignedMessage, error) {
+	from, err := mp.queueKey(ctx, addr)
+	if err != nil {
+		return nil, xerrors.Errorf("resolving sender: %w", err)
+	}
+
+	held := mp.sendQueue.remove(from)
+	out := make([]*types.SignedMessage, 0, len(held))
+	for i, qm := range held {
+		if !drop {
+			if _, err := mp.push(ctx, qm.msg, qm.publish, false); err != nil {
+				// keep holding the messages which weren't flushed
+				for _, rest := range held[i:] {
+					if perr := mp.sendQueue.put(ctx, from, rest); perr != nil {
+						log.Errorw("failed to hold message again", "from", from, "nonce", rest.msg.Message.Nonce, "error", perr)
+					}
+				}
+				return out, xerrors.Errorf("pushing held message with nonce %d: %w", qm.msg.Message.Nonce, err)
+			}
+		}
+		mp.sendQueue.forget(ctx, qm)
+		out = append(out, qm.msg)
+	}
+	return out, nil
+}
+
+// loadHeld holds the persisted held messages again. Messages which no longer have
+// a nonce gap are released on the next head change. Must be called with lk held.
+func (mp *MessagePool) loadHeld(ctx context.Context) error {
+	res, err := mp.sendQueue.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return xerrors.Errorf("query held messages: %w", err)
+	}
+
+	entries, err := res.Rest()
+	if err != nil {
+		return xerrors.Errorf("reading held messages: %w", err)
+	}
+
+	mp.sendQueue.lk.Lock()
+	defer mp.sendQueue.lk.Unlock()
+
+	for _, e := range entries {
+		var sm types.SignedMessage
+		if err := sm.UnmarshalCBOR(bytes.NewReader(e.Value)); err != nil {
+			return xerrors.Errorf("unmarshaling held message: %w", err)
+		}
+
+		from, err := mp.resolveToKey(ctx, sm.Message.From)
+		if err != nil {
+			log.Errorw("failed to resolve sender of held message", "from", sm.Message.From, "cid", sm.Cid(), "error", err)
+			continue
+		}
+
+		// only locally published messages are held; the publish flag isn't persisted
+		if err := mp.sendQueue.hold(ctx, from, queuedMsg{msg: &sm, publish: true}, false); err != nil {
+			log.Errorw("failed to hold message", "from", from, "cid", sm.Cid(), "error", err)
+			continue
+		}
+
+		if err := mp.setLocal(ctx, sm.Message.From); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
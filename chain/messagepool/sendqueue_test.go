@@ -0,0 +1,156 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/messagepool/gasguess"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+)
+
+func TestSendQueueNonceGap(t *testing.T) {
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+
+	mp, err := New(ctx, tma, datastore.NewMapDatastore(), filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	require.NoError(t, err)
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	a1, err := w1.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	w2, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	a2, err := w2.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	tma.setBalance(a1, 1) // in FIL
+
+	push := func(nonce uint64) {
+		_, err := mp.Push(ctx, makeTestMessage(w1, a1, a2, nonce, gasLimit, 1), true)
+		require.NoError(t, err)
+	}
+	nextNonce := func() uint64 {
+		n, err := mp.GetNonce(ctx, a1, types.EmptyTSK)
+		require.NoError(t, err)
+		return n
+	}
+	queued := func(addr address.Address) []uint64 {
+		msgs, err := mp.SendQueue(ctx, addr)
+		require.NoError(t, err)
+		var nonces []uint64
+		for _, m := range msgs {
+			nonces = append(nonces, m.Message.Nonce)
+		}
+		return nonces
+	}
+
+	push(0)
+	require.Equal(t, 1, tma.published)
+
+	// messages past the gap are held back
+	push(3)
+	push(2)
+	require.Equal(t, 1, tma.published)
+	require.Equal(t, uint64(1), nextNonce())
+	require.Equal(t, []uint64{2, 3}, queued(a1))
+	require.Equal(t, []uint64{2, 3}, queued(address.Undef))
+
+	// and released once the gap fills
+	push(1)
+	require.Equal(t, 4, tma.published)
+	require.Equal(t, uint64(4), nextNonce())
+	require.Empty(t, queued(a1))
+
+	// held messages can be dropped
+	push(6)
+	dropped, err := mp.FlushSendQueue(ctx, a1, true)
+	require.NoError(t, err)
+	require.Len(t, dropped, 1)
+	require.Empty(t, queued(a1))
+	require.Equal(t, 4, tma.published)
+
+	// or pushed despite the gap
+	push(6)
+	flushed, err := mp.FlushSendQueue(ctx, a1, false)
+	require.NoError(t, err)
+	require.Len(t, flushed, 1)
+	require.Empty(t, queued(a1))
+	require.Equal(t, 5, tma.published)
+	require.Equal(t, uint64(4), nextNonce())
+}
+
+func TestSendQueueRestart(t *testing.T) {
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+
+	mp, err := New(ctx, tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	require.NoError(t, err)
+
+	w1, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	a1, err := w1.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	w2, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	a2, err := w2.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+	tma.setBalance(a1, 1) // in FIL
+
+	push := func(mp *MessagePool, nonce uint64) {
+		_, err := mp.Push(ctx, makeTestMessage(w1, a1, a2, nonce, gasLimit, 1), true)
+		require.NoError(t, err)
+	}
+	queued := func(mp *MessagePool) []uint64 {
+		msgs, err := mp.SendQueue(ctx, a1)
+		require.NoError(t, err)
+		var nonces []uint64
+		for _, m := range msgs {
+			nonces = append(nonces, m.Message.Nonce)
+		}
+		return nonces
+	}
+
+	push(mp, 0)
+	push(mp, 2)
+	push(mp, 3)
+	require.Equal(t, []uint64{2, 3}, queued(mp))
+	require.NoError(t, mp.Close())
+
+	// held messages survive a restart
+	mp, err = New(ctx, tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	require.NoError(t, err)
+	defer mp.Close() //nolint:errcheck
+
+	require.Equal(t, []uint64{2, 3}, queued(mp))
+	pending, _ := mp.Pending(ctx)
+	require.Len(t, pending, 1)
+
+	// and are released once the gap fills
+	published := tma.published
+	push(mp, 1)
+	require.Empty(t, queued(mp))
+	require.Equal(t, published+3, tma.published)
+
+	// released messages are no longer persisted as held
+	mp2, err := New(ctx, tma, ds, filcns.DefaultUpgradeSchedule(), "mptest", nil)
+	require.NoError(t, err)
+	defer mp2.Close() //nolint:errcheck
+	require.Empty(t, queued(mp2))
+	pending, _ = mp2.Pending(ctx)
+	require.Len(t, pending, 4)
+}
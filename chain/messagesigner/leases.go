@@ -0,0 +1,179 @@
+package messagesigner
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// MaxNonceLeaseTTL bounds how long a nonce can be leased for; a leased nonce
+// which isn't used blocks the messages with higher nonces from being mined.
+const MaxNonceLeaseTTL = time.Hour
+
+type nonceLease struct {
+	id      uuid.UUID
+	nonce   uint64
+	expires time.Time
+}
+
+// nonceLeases tracks the leased nonces. Leases are only kept in memory, so the
+// nonces leased before a restart and never used are lost, leaving a gap which
+// has to be filled manually.
+type nonceLeases struct {
+	leases map[address.Address][]nonceLease
+	// free holds leased nonces which were released or expired without being
+	// used, and are handed out again before new nonces
+	free map[address.Address][]uint64
+}
+
+// LeaseNonce reserves a nonce of the given address for ttl. Until the lease
+// expires or is released the nonce isn't assigned to any other message.
+func (ms *MessageSigner) LeaseNonce(ctx context.Context, addr address.Address, ttl time.Duration) (*api.NonceLease, error) {
+	if ttl <= 0 || ttl > MaxNonceLeaseTTL {
+		return nil, xerrors.Errorf("lease duration must be positive and at most %s", MaxNonceLeaseTTL)
+	}
+
+	ms.lk.Lock()
+	defer ms.lk.Unlock()
+
+	nonce, fromFree, err := ms.nextLeasableNonce(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fromFree {
+		// move the datastore nonce past the leased one, so that messages signed
+		// by this node don't use it
+		if err := ms.SaveNonce(ctx, addr, nonce); err != nil {
+			return nil, xerrors.Errorf("failed to save nonce: %w", err)
+		}
+	}
+
+	l := nonceLease{
+		id:      uuid.New(),
+		nonce:   nonce,
+		expires: time.Now().Add(ttl),
+	}
+	if ms.nl.leases == nil {
+		ms.nl.leases = map[address.Address][]nonceLease{}
+	}
+	ms.nl.leases[addr] = append(ms.nl.leases[addr], l)
+
+	return &api.NonceLease{
+		ID:      l.id,
+		Address: addr,
+		Nonce:   l.nonce,
+		Expires: l.expires,
+	}, nil
+}
+
+// ReleaseNonce ends a lease whose nonce won't be used, so that the nonce can
+// be assigned to another message.
+func (ms *MessageSigner) ReleaseNonce(ctx context.Context, id uuid.UUID) error {
+	ms.lk.Lock()
+	defer ms.lk.Unlock()
+
+	for addr, leases := range ms.nl.leases {
+		for i, l := range leases {
+			if l.id != id {
+				continue
+			}
+
+			ms.nl.leases[addr] = append(leases[:i:i], leases[i+1:]...)
+			ms.addFreeNonce(addr, l.nonce)
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("nonce lease %s not found, it may have expired", id)
+}
+
+// nextLeasableNonce returns the lowest nonce which can be leased, and whether
+// it was leased before. Must be called with the lock held.
+func (ms *MessageSigner) nextLeasableNonce(ctx context.Context, addr address.Address) (uint64, bool, error) {
+	mpoolNonce, err := ms.mpool.GetNonce(ctx, addr, types.EmptyTSK)
+	if err != nil {
+		return 0, false, xerrors.Errorf("failed to get nonce from mempool: %w", err)
+	}
+	ms.pruneLeases(addr, mpoolNonce)
+
+	if nonce, ok := ms.takeFreeNonce(addr, mpoolNonce); ok {
+		return nonce, true, nil
+	}
+
+	nonce, err := ms.NextNonce(ctx, addr)
+	if err != nil {
+		return 0, false, err
+	}
+	for ms.isLeased(addr, nonce) {
+		nonce++
+	}
+
+	return nonce, false, nil
+}
+
+// pruneLeases drops the leases whose nonce is used by a message in the
+// mempool or on chain, and frees the nonces of the expired ones.
+func (ms *MessageSigner) pruneLeases(addr address.Address, mpoolNonce uint64) {
+	now := time.Now()
+
+	var kept []nonceLease
+	for _, l := range ms.nl.leases[addr] {
+		switch {
+		case l.nonce < mpoolNonce:
+		case now.After(l.expires):
+			ms.addFreeNonce(addr, l.nonce)
+		default:
+			kept = append(kept, l)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(ms.nl.leases, addr)
+		return
+	}
+	ms.nl.leases[addr] = kept
+}
+
+func (ms *MessageSigner) isLeased(addr address.Address, nonce uint64) bool {
+	for _, l := range ms.nl.leases[addr] {
+		if l.nonce == nonce {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms *MessageSigner) addFreeNonce(addr address.Address, nonce uint64) {
+	if ms.nl.free == nil {
+		ms.nl.free = map[address.Address][]uint64{}
+	}
+
+	free := append(ms.nl.free[addr], nonce)
+	sort.Slice(free, func(i, j int) bool { return free[i] < free[j] })
+	ms.nl.free[addr] = free
+}
+
+// takeFreeNonce returns the lowest freed nonce which isn't used yet.
+func (ms *MessageSigner) takeFreeNonce(addr address.Address, mpoolNonce uint64) (uint64, bool) {
+	free := ms.nl.free[addr]
+	for len(free) > 0 && free[0] < mpoolNonce {
+		free = free[1:]
+	}
+
+	if len(free) == 0 {
+		delete(ms.nl.free, addr)
+		return 0, false
+	}
+
+	ms.nl.free[addr] = free[1:]
+	return free[0], true
+}
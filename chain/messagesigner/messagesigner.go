@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ipfs/go-datastore"
@@ -32,6 +33,8 @@ type MsgSigner interface {
 	StoreSignedMessage(ctx context.Context, uuid uuid.UUID, message *types.SignedMessage) error
 	NextNonce(ctx context.Context, addr address.Address) (uint64, error)
 	SaveNonce(ctx context.Context, addr address.Address, nonce uint64) error
+	LeaseNonce(ctx context.Context, addr address.Address, ttl time.Duration) (*api.NonceLease, error)
+	ReleaseNonce(ctx context.Context, id uuid.UUID) error
 }
 
 // MessageSigner keeps track of nonces per address, and increments the nonce
@@ -41,6 +44,8 @@ type MessageSigner struct {
 	lk     sync.Mutex
 	mpool  messagepool.MpoolNonceAPI
 	ds     datastore.Batching
+
+	nl nonceLeases
 }
 
 func NewMessageSigner(wallet api.Wallet, mpool messagepool.MpoolNonceAPI, ds dtypes.MetadataDS) *MessageSigner {
@@ -58,8 +63,8 @@ func (ms *MessageSigner) SignMessage(ctx context.Context, msg *types.Message, sp
 	ms.lk.Lock()
 	defer ms.lk.Unlock()
 
-	// Get the next message nonce
-	nonce, err := ms.NextNonce(ctx, msg.From)
+	// Get the next message nonce, skipping the leased ones
+	nonce, fromFree, err := ms.nextLeasableNonce(ctx, msg.From)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to create nonce: %w", err)
 	}
@@ -93,12 +98,18 @@ func (ms *MessageSigner) SignMessage(ctx context.Context, msg *types.Message, sp
 
 	err = cb(smsg)
 	if err != nil {
+		if fromFree {
+			ms.addFreeNonce(msg.From, nonce)
+		}
 		return nil, err
 	}
 
-	// If the callback executed successfully, write the nonce to the datastore
-	if err := ms.SaveNonce(ctx, msg.From, nonce); err != nil {
-		return nil, xerrors.Errorf("failed to save nonce: %w", err)
+	// If the callback executed successfully, write the nonce to the datastore,
+	// unless it was a freed leased nonce, below the datastore nonce
+	if !fromFree {
+		if err := ms.SaveNonce(ctx, msg.From, nonce); err != nil {
+			return nil, xerrors.Errorf("failed to save nonce: %w", err)
+		}
 	}
 
 	return smsg, nil
@@ -5,6 +5,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-datastore"
 	ds_sync "github.com/ipfs/go-datastore/sync"
@@ -203,3 +204,61 @@ func TestMessageSignerSignMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageSignerLeaseNonce(t *testing.T) {
+	ctx := context.Background()
+
+	w, _ := wallet.NewWallet(wallet.NewMemKeyStore())
+	from, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	to, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	mpool := newMockMpool()
+	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
+	ms := NewMessageSigner(w, mpool, ds)
+
+	// the mempool nonce only moves past contiguous messages, so it's set
+	// explicitly below rather than on every push
+	sign := func() uint64 {
+		smsg, err := ms.SignMessage(ctx, &types.Message{To: to, From: from}, nil, func(*types.SignedMessage) error { return nil })
+		require.NoError(t, err)
+		return smsg.Message.Nonce
+	}
+
+	_, err = ms.LeaseNonce(ctx, from, 0)
+	require.Error(t, err)
+	_, err = ms.LeaseNonce(ctx, from, 2*MaxNonceLeaseTTL)
+	require.Error(t, err)
+
+	l0, err := ms.LeaseNonce(ctx, from, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, l0.Nonce)
+
+	l1, err := ms.LeaseNonce(ctx, from, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, l1.Nonce)
+
+	// signed messages skip the leased nonces
+	require.EqualValues(t, 2, sign())
+
+	// a released nonce is handed out again
+	require.NoError(t, ms.ReleaseNonce(ctx, l1.ID))
+	require.Error(t, ms.ReleaseNonce(ctx, l1.ID))
+
+	l1b, err := ms.LeaseNonce(ctx, from, time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, l1b.Nonce)
+
+	// leases end once their nonce reaches the mempool, and expired ones free
+	// their nonce for the next message
+	mpool.setNonce(from, 1)
+	l3, err := ms.LeaseNonce(ctx, from, time.Nanosecond)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, l3.Nonce)
+	time.Sleep(time.Millisecond)
+
+	mpool.setNonce(from, 3)
+	require.EqualValues(t, 3, sign())
+	require.EqualValues(t, 4, sign())
+}
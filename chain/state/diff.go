@@ -0,0 +1,303 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sort"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	amt "github.com/filecoin-project/go-amt-ipld/v4"
+	hamt "github.com/filecoin-project/go-hamt-ipld/v3"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ActorDiff is a change of an actor between two state trees.
+type ActorDiff struct {
+	Address address.Address
+	// Before is nil if the actor was created
+	Before *types.Actor
+	// After is nil if the actor was deleted
+	After *types.Actor
+}
+
+// DiffActors returns the actors created, deleted or modified between the two state
+// trees, ordered by address. The actors HAMTs are diffed structurally, skipping the
+// subtrees shared by both trees.
+func DiffActors(ctx context.Context, oldTree, newTree *StateTree) ([]ActorDiff, error) {
+	oldRoot, err := oldTree.root.Root()
+	if err != nil {
+		return nil, xerrors.Errorf("getting old actors root: %w", err)
+	}
+	newRoot, err := newTree.root.Root()
+	if err != nil {
+		return nil, xerrors.Errorf("getting new actors root: %w", err)
+	}
+
+	var out []ActorDiff
+	record := func(key string, before, after *cbg.Deferred) error {
+		addr, err := address.NewFromBytes([]byte(key))
+		if err != nil {
+			return xerrors.Errorf("address in state tree was not valid: %w", err)
+		}
+
+		d := ActorDiff{Address: addr}
+		if before != nil {
+			if d.Before, err = decodeActor(oldTree.version, before.Raw); err != nil {
+				return xerrors.Errorf("decoding actor %s: %w", addr, err)
+			}
+		}
+		if after != nil {
+			if d.After, err = decodeActor(newTree.version, after.Raw); err != nil {
+				return xerrors.Errorf("decoding actor %s: %w", addr, err)
+			}
+		}
+		out = append(out, d)
+		return nil
+	}
+
+	if oldTree.version >= types.StateTreeVersion2 && newTree.version >= types.StateTreeVersion2 {
+		// both actors HAMTs are go-hamt-ipld v3 HAMTs, which can be diffed structurally
+		changes, err := hamt.Diff(ctx, oldTree.Store, newTree.Store, oldRoot, newRoot, hamt.UseTreeBitWidth(builtin.DefaultHamtBitwidth))
+		if err != nil {
+			return nil, xerrors.Errorf("diffing actors: %w", err)
+		}
+		for _, ch := range changes {
+			switch ch.Type {
+			case hamt.Add:
+				err = record(ch.Key, nil, ch.After)
+			case hamt.Remove:
+				err = record(ch.Key, ch.Before, nil)
+			case hamt.Modify:
+				err = record(ch.Key, ch.Before, ch.After)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else if oldRoot != newRoot {
+		if err := adt.DiffAdtMap(oldTree.root, newTree.root, &actorMapDiff{record: record}); err != nil {
+			return nil, xerrors.Errorf("diffing actors: %w", err)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return addrLess(out[i].Address, out[j].Address)
+	})
+	return out, nil
+}
+
+type actorMapDiff struct {
+	record func(key string, before, after *cbg.Deferred) error
+}
+
+func (d *actorMapDiff) AsKey(key string) (abi.Keyer, error) {
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return abi.AddrKey(addr), nil
+}
+
+func (d *actorMapDiff) Add(key string, val *cbg.Deferred) error {
+	return d.record(key, nil, val)
+}
+
+func (d *actorMapDiff) Modify(key string, from, to *cbg.Deferred) error {
+	return d.record(key, from, to)
+}
+
+func (d *actorMapDiff) Remove(key string, val *cbg.Deferred) error {
+	return d.record(key, val, nil)
+}
+
+func decodeActor(version types.StateTreeVersion, raw []byte) (*types.Actor, error) {
+	if version <= types.StateTreeVersion4 {
+		var act types.ActorV4
+		if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+			return nil, err
+		}
+		return types.AsActorV5(&act), nil
+	}
+
+	var act types.Actor
+	if err := act.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	return &act, nil
+}
+
+// addrLess orders ID addresses by ID, and other addresses after them
+func addrLess(a, b address.Address) bool {
+	aid, aerr := address.IDFromAddress(a)
+	bid, berr := address.IDFromAddress(b)
+	switch {
+	case aerr == nil && berr == nil:
+		return aid < bid
+	case aerr == nil:
+		return true
+	case berr == nil:
+		return false
+	default:
+		return a.String() < b.String()
+	}
+}
+
+// FieldDiff is a change of a top-level field of an actor state.
+type FieldDiff struct {
+	// Field is the index of the field in the actor state tuple
+	Field int
+	// Before and After are the CBOR encoded values of the field, nil if the field
+	// doesn't exist in the state
+	Before, After []byte
+	// Entries are the changed entries of a field pointing to a HAMT or an AMT
+	Entries []EntryDiff
+}
+
+// EntryDiff is a change of a HAMT or AMT entry.
+type EntryDiff struct {
+	// Key is the hex encoded key of a HAMT entry, or the index of an AMT entry
+	Key string
+	// Before and After are the CBOR encoded values, nil if the entry was added or
+	// removed
+	Before, After []byte
+}
+
+// DiffActorState returns the fields which changed between two actor states. The
+// entries changed in fields pointing to a HAMT or an AMT are diffed structurally.
+// HAMTs are expected to use the default bit width; links which can't be loaded as
+// an AMT or a HAMT are only reported as changed fields.
+func DiffActorState(ctx context.Context, store cbor.IpldStore, before, after cid.Cid) ([]FieldDiff, error) {
+	if before == after {
+		return nil, nil
+	}
+
+	beforeFields, err := loadFields(ctx, store, before)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state %s: %w", before, err)
+	}
+	afterFields, err := loadFields(ctx, store, after)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state %s: %w", after, err)
+	}
+
+	n := len(beforeFields)
+	if len(afterFields) > n {
+		n = len(afterFields)
+	}
+
+	var out []FieldDiff
+	for i := 0; i < n; i++ {
+		var b, a []byte
+		if i < len(beforeFields) {
+			b = beforeFields[i]
+		}
+		if i < len(afterFields) {
+			a = afterFields[i]
+		}
+		if bytes.Equal(b, a) {
+			continue
+		}
+
+		fd := FieldDiff{Field: i, Before: b, After: a}
+		bc, berr := cbg.ReadCid(bytes.NewReader(b))
+		ac, aerr := cbg.ReadCid(bytes.NewReader(a))
+		if berr == nil && aerr == nil {
+			fd.Entries = diffCollection(ctx, store, bc, ac)
+		}
+		out = append(out, fd)
+	}
+	return out, nil
+}
+
+// loadFields returns the CBOR encoded fields of the tuple stored at c
+func loadFields(ctx context.Context, store cbor.IpldStore, c cid.Cid) ([][]byte, error) {
+	var raw cbg.Deferred
+	if err := store.Get(ctx, c, &raw); err != nil {
+		return nil, err
+	}
+
+	cr := cbg.NewCborReader(bytes.NewReader(raw.Raw))
+	maj, n, err := cr.ReadHeader()
+	if err != nil {
+		return nil, err
+	}
+	if maj != cbg.MajArray {
+		return nil, xerrors.Errorf("actor state is not a tuple")
+	}
+
+	var out [][]byte
+	for i := uint64(0); i < n; i++ {
+		var field cbg.Deferred
+		if err := field.UnmarshalCBOR(cr); err != nil {
+			return nil, xerrors.Errorf("reading field %d: %w", i, err)
+		}
+		out = append(out, field.Raw)
+	}
+	return out, nil
+}
+
+// diffCollection diffs the AMTs or HAMTs rooted at before and after, returning nil
+// if they are neither
+func diffCollection(ctx context.Context, store cbor.IpldStore, before, after cid.Cid) []EntryDiff {
+	var raw cbg.Deferred
+	if err := store.Get(ctx, before, &raw); err != nil {
+		return nil
+	}
+
+	cr := cbg.NewCborReader(bytes.NewReader(raw.Raw))
+	maj, n, err := cr.ReadHeader()
+	if err != nil || maj != cbg.MajArray {
+		return nil
+	}
+
+	var out []EntryDiff
+	switch n {
+	case 4: // AMT root: [bitWidth, height, count, node]
+		maj, bitWidth, err := cr.ReadHeader()
+		if err != nil || maj != cbg.MajUnsignedInt {
+			return nil
+		}
+		changes, err := amt.Diff(ctx, store, store, before, after, amt.UseTreeBitWidth(uint(bitWidth)))
+		if err != nil {
+			return nil
+		}
+		for _, ch := range changes {
+			out = append(out, EntryDiff{
+				Key:    strconv.FormatUint(ch.Key, 10),
+				Before: deferredRaw(ch.Before),
+				After:  deferredRaw(ch.After),
+			})
+		}
+	case 2: // HAMT node: [bitfield, pointers]
+		changes, err := hamt.Diff(ctx, store, store, before, after, hamt.UseTreeBitWidth(builtin.DefaultHamtBitwidth))
+		if err != nil {
+			return nil
+		}
+		for _, ch := range changes {
+			out = append(out, EntryDiff{
+				Key:    hex.EncodeToString([]byte(ch.Key)),
+				Before: deferredRaw(ch.Before),
+				After:  deferredRaw(ch.After),
+			})
+		}
+	}
+	return out
+}
+
+func deferredRaw(d *cbg.Deferred) []byte {
+	if d == nil {
+		return nil
+	}
+	return d.Raw
+}
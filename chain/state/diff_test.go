@@ -0,0 +1,83 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestDiffActors(t *testing.T) {
+	ctx := context.Background()
+	cst := cbor.NewMemCborStore()
+	sv, err := VersionForNetwork(build.TestNetworkVersion)
+	require.NoError(t, err)
+
+	id := func(i uint64) address.Address {
+		addr, err := address.NewIDAddress(i)
+		require.NoError(t, err)
+		return addr
+	}
+	actor := func(balance uint64) *types.Actor {
+		return &types.Actor{
+			Code:    builtin2.AccountActorCodeID,
+			Head:    builtin2.AccountActorCodeID,
+			Balance: types.NewInt(balance),
+		}
+	}
+
+	st, err := NewStateTree(cst, sv)
+	require.NoError(t, err)
+	for i := uint64(100); i < 200; i++ {
+		require.NoError(t, st.SetActor(id(i), actor(i)))
+	}
+	oldRoot, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, st.SetActor(id(150), actor(1)))
+	require.NoError(t, st.DeleteActor(id(120)))
+	require.NoError(t, st.SetActor(id(200), actor(200)))
+	newRoot, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	load := func(c cid.Cid) *StateTree {
+		tree, err := LoadStateTree(cst, c)
+		require.NoError(t, err)
+		return tree
+	}
+
+	diff, err := DiffActors(ctx, load(oldRoot), load(newRoot))
+	require.NoError(t, err)
+	require.Len(t, diff, 3)
+
+	require.Equal(t, id(120), diff[0].Address)
+	require.NotNil(t, diff[0].Before)
+	require.Nil(t, diff[0].After)
+
+	require.Equal(t, id(150), diff[1].Address)
+	require.Equal(t, types.NewInt(150), diff[1].Before.Balance)
+	require.Equal(t, types.NewInt(1), diff[1].After.Balance)
+
+	require.Equal(t, id(200), diff[2].Address)
+	require.Nil(t, diff[2].Before)
+	require.Equal(t, types.NewInt(200), diff[2].After.Balance)
+
+	diff, err = DiffActors(ctx, load(newRoot), load(newRoot))
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	// the state root is a tuple whose actors field points to a HAMT
+	fields, err := DiffActorState(ctx, cst, oldRoot, newRoot)
+	require.NoError(t, err)
+	require.Len(t, fields, 1)
+	require.Equal(t, 1, fields[0].Field)
+	require.Len(t, fields[0].Entries, 3)
+}
@@ -31,6 +31,11 @@ var ErrExpensiveFork = errors.New("refusing explicit call due to state fork at e
 // tipset's parent. In the presence of null blocks, the height at which the message is invoked may
 // be less than the specified tipset.
 func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.TipSet) (*api.InvocResult, error) {
+	return sm.callInternal(ctx, withCallDefaults(msg), nil, ts, cid.Undef, sm.GetNetworkVersion, false, false)
+}
+
+// withCallDefaults returns a copy of the message with the unset gas and value fields defaulted.
+func withCallDefaults(msg *types.Message) *types.Message {
 	// Copy the message as we modify it below.
 	msgCopy := *msg
 	msg = &msgCopy
@@ -47,8 +52,7 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 	if msg.Value == types.EmptyInt {
 		msg.Value = types.NewInt(0)
 	}
-
-	return sm.callInternal(ctx, msg, nil, ts, cid.Undef, sm.GetNetworkVersion, false, false)
+	return msg
 }
 
 // CallWithGas calculates the state for a given tipset, and then applies the given message on top of that state.
@@ -140,21 +144,7 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 	}
 
 	buffStore := blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync())
-	vmopt := &vm.VMOpts{
-		StateBase:      stateCid,
-		Epoch:          ts.Height(),
-		Timestamp:      ts.MinTimestamp(),
-		Rand:           rand.NewStateRand(sm.cs, ts.Cids(), sm.beacon, nvGetter),
-		Bstore:         buffStore,
-		Actors:         sm.tsExec.NewActorRegistry(),
-		Syscalls:       sm.Syscalls,
-		CircSupplyCalc: sm.GetVMCirculatingSupply,
-		NetworkVersion: nvGetter(ctx, ts.Height()),
-		BaseFee:        ts.Blocks()[0].ParentBaseFee,
-		LookbackState:  LookbackStateGetterForTipset(sm, ts),
-		TipSetGetter:   TipSetGetterForTipset(sm.cs, ts),
-		Tracing:        true,
-	}
+	vmopt := sm.callVMOpts(ctx, ts, stateCid, buffStore, nvGetter)
 	vmi, err := sm.newVM(ctx, vmopt)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to set up vm: %w", err)
@@ -205,30 +195,7 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 			return nil, xerrors.Errorf("could not resolve key: %w", err)
 		}
 
-		var msgApply types.ChainMsg
-
-		switch fromKey.Protocol() {
-		case address.BLS:
-			msgApply = msg
-		case address.SECP256K1:
-			msgApply = &types.SignedMessage{
-				Message: *msg,
-				Signature: crypto.Signature{
-					Type: crypto.SigTypeSecp256k1,
-					Data: make([]byte, 65),
-				},
-			}
-		case address.Delegated:
-			msgApply = &types.SignedMessage{
-				Message: *msg,
-				Signature: crypto.Signature{
-					Type: crypto.SigTypeDelegated,
-					Data: make([]byte, 65),
-				},
-			}
-		}
-
-		ret, err = vmi.ApplyMessage(ctx, msgApply)
+		ret, err = vmi.ApplyMessage(ctx, unsignedChainMsg(msg, fromKey))
 		if err != nil {
 			return nil, xerrors.Errorf("gas estimation failed: %w", err)
 		}
@@ -256,6 +223,160 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 	}, err
 }
 
+// BundleResult is the outcome of applying a bundle of messages with CallBundle.
+type BundleResult struct {
+	Results []*api.InvocResult
+	// Base is the state the messages were applied on, and Root the state after
+	// applying them
+	Base, Root cid.Cid
+	// Store reads the states produced by the bundle; nothing written to it is persisted
+	Store cbor.IpldStore
+}
+
+// CallBundle applies the given messages in order on top of the tipset's parent state, each
+// message seeing the changes made by the previous ones, and returns their results without
+// persisting anything. As with Call, the nonces of the messages are set from the state of their
+// senders and the unset gas fields are defaulted. Unlike Call, the messages are charged gas as if
+// they were signed, and gas is only free if none of them sets a fee cap. A failing message
+// doesn't stop the execution of the following ones.
+//
+// If executing the messages at the tipset or its parent would trigger an expensive migration,
+// the call fails with ErrExpensiveFork.
+func (sm *StateManager) CallBundle(ctx context.Context, msgs []*types.Message, ts *types.TipSet) (*BundleResult, error) {
+	ctx, span := trace.StartSpan(ctx, "statemanager.CallBundle")
+	defer span.End()
+
+	if ts == nil {
+		ts = sm.cs.GetHeaviestTipSet()
+	}
+	if ts.Height() > 0 {
+		pts, err := sm.cs.GetTipSetFromKey(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("failed to find a non-forking epoch: %w", err)
+		}
+		if sm.hasExpensiveForkBetween(pts.Height(), ts.Height()+1) {
+			return nil, ErrExpensiveFork
+		}
+	}
+
+	base, err := sm.HandleStateForks(ctx, ts.ParentState(), ts.Height(), nil, ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handle fork: %w", err)
+	}
+
+	free := true
+	for _, msg := range msgs {
+		if !msg.GasFeeCap.NilOrZero() {
+			free = false
+			break
+		}
+	}
+
+	buffStore := blockstore.NewTieredBstore(sm.cs.StateBlockstore(), blockstore.NewMemorySync())
+	cst := cbor.NewCborStore(buffStore)
+	vmopt := sm.callVMOpts(ctx, ts, base, buffStore, sm.GetNetworkVersion)
+	if free {
+		vmopt.BaseFee = big.Zero()
+	}
+	vmi, err := sm.newVM(ctx, vmopt)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to set up vm: %w", err)
+	}
+
+	out := &BundleResult{Base: base, Store: cst}
+	for i, msg := range msgs {
+		msg = withCallDefaults(msg)
+
+		// flush to read the nonce and key of the sender as left by the previous messages
+		root, err := vmi.Flush(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("flushing vm: %w", err)
+		}
+		stTree, err := state.LoadStateTree(cst, root)
+		if err != nil {
+			return nil, xerrors.Errorf("loading state tree: %w", err)
+		}
+		fromActor, err := stTree.GetActor(msg.From)
+		if err != nil {
+			return nil, xerrors.Errorf("getting sender of message %d: %w", i, err)
+		}
+		msg.Nonce = fromActor.Nonce
+
+		fromKey, err := vm.ResolveToDeterministicAddr(stTree, cst, msg.From)
+		if err != nil {
+			return nil, xerrors.Errorf("could not resolve key of sender of message %d: %w", i, err)
+		}
+
+		ret, err := vmi.ApplyMessage(ctx, unsignedChainMsg(msg, fromKey))
+		if err != nil {
+			return nil, xerrors.Errorf("applying message %d: %w", i, err)
+		}
+
+		var errs string
+		if ret.ActorErr != nil {
+			errs = ret.ActorErr.Error()
+		}
+		out.Results = append(out.Results, &api.InvocResult{
+			MsgCid:         msg.Cid(),
+			Msg:            msg,
+			MsgRct:         &ret.MessageReceipt,
+			GasCost:        MakeMsgGasCost(msg, ret),
+			ExecutionTrace: ret.ExecutionTrace,
+			Error:          errs,
+			Duration:       ret.Duration,
+		})
+	}
+
+	out.Root, err = vmi.Flush(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("flushing vm: %w", err)
+	}
+	return out, nil
+}
+
+func (sm *StateManager) callVMOpts(ctx context.Context, ts *types.TipSet, stateCid cid.Cid, bstore blockstore.Blockstore, nvGetter rand.NetworkVersionGetter) *vm.VMOpts {
+	return &vm.VMOpts{
+		StateBase:      stateCid,
+		Epoch:          ts.Height(),
+		Timestamp:      ts.MinTimestamp(),
+		Rand:           rand.NewStateRand(sm.cs, ts.Cids(), sm.beacon, nvGetter),
+		Bstore:         bstore,
+		Actors:         sm.tsExec.NewActorRegistry(),
+		Syscalls:       sm.Syscalls,
+		CircSupplyCalc: sm.GetVMCirculatingSupply,
+		NetworkVersion: nvGetter(ctx, ts.Height()),
+		BaseFee:        ts.Blocks()[0].ParentBaseFee,
+		LookbackState:  LookbackStateGetterForTipset(sm, ts),
+		TipSetGetter:   TipSetGetterForTipset(sm.cs, ts),
+		Tracing:        true,
+	}
+}
+
+// unsignedChainMsg wraps the message with an empty signature of the type used by
+// its sender, so that it is charged gas as if it were signed
+func unsignedChainMsg(msg *types.Message, fromKey address.Address) types.ChainMsg {
+	switch fromKey.Protocol() {
+	case address.SECP256K1:
+		return &types.SignedMessage{
+			Message: *msg,
+			Signature: crypto.Signature{
+				Type: crypto.SigTypeSecp256k1,
+				Data: make([]byte, 65),
+			},
+		}
+	case address.Delegated:
+		return &types.SignedMessage{
+			Message: *msg,
+			Signature: crypto.Signature{
+				Type: crypto.SigTypeDelegated,
+				Data: make([]byte, 65),
+			},
+		}
+	default:
+		return msg
+	}
+}
+
 var errHaltExecution = fmt.Errorf("halt")
 
 func (sm *StateManager) Replay(ctx context.Context, ts *types.TipSet, mcid cid.Cid) (*types.Message, *vm.ApplyRet, error) {
@@ -0,0 +1,82 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+func TestCallBundle(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	mts, err := cg.NextTipSet()
+	require.NoError(t, err)
+	ts := mts.TipSet.TipSet()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	receiver, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	banker := cg.Banker()
+	baseBanker, err := cg.StateManager().LoadActor(ctx, banker, ts)
+	require.NoError(t, err)
+
+	msgs := []*types.Message{
+		// creates the receiver
+		{From: banker, To: receiver, Value: big.NewInt(100)},
+		// fails, but still uses a nonce of the banker
+		{From: banker, To: receiver, Value: big.Mul(baseBanker.Balance, big.NewInt(2))},
+		{From: banker, To: receiver, Value: big.NewInt(50)},
+		// the receiver only exists in the state of the bundle
+		{From: receiver, To: banker, Value: big.NewInt(30)},
+	}
+
+	res, err := cg.StateManager().CallBundle(ctx, msgs, ts)
+	require.NoError(t, err)
+	require.Len(t, res.Results, len(msgs))
+
+	// every message sees the nonce left by the previous ones
+	require.Equal(t, baseBanker.Nonce, res.Results[0].Msg.Nonce)
+	require.Equal(t, exitcode.Ok, res.Results[0].MsgRct.ExitCode)
+	require.Equal(t, baseBanker.Nonce+1, res.Results[1].Msg.Nonce)
+	require.Equal(t, exitcode.SysErrInsufficientFunds, res.Results[1].MsgRct.ExitCode)
+	require.Equal(t, baseBanker.Nonce+2, res.Results[2].Msg.Nonce)
+	require.Equal(t, exitcode.Ok, res.Results[2].MsgRct.ExitCode)
+	require.Equal(t, uint64(0), res.Results[3].Msg.Nonce)
+	require.Equal(t, exitcode.Ok, res.Results[3].MsgRct.ExitCode)
+
+	// no fee cap is set, so gas is free and only the values are transferred
+	tree, err := state.LoadStateTree(res.Store, res.Root)
+	require.NoError(t, err)
+	act, err := tree.GetActor(receiver)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(120), act.Balance)
+	require.Equal(t, uint64(1), act.Nonce)
+
+	act, err = tree.GetActor(banker)
+	require.NoError(t, err)
+	require.Equal(t, baseBanker.Nonce+3, act.Nonce)
+	require.Equal(t, big.Sub(baseBanker.Balance, big.NewInt(120)), act.Balance)
+
+	// nothing is persisted
+	_, err = cg.StateManager().LoadActor(ctx, receiver, ts)
+	require.Error(t, err)
+	act, err = cg.StateManager().LoadActor(ctx, banker, ts)
+	require.NoError(t, err)
+	require.Equal(t, baseBanker.Nonce, act.Nonce)
+}
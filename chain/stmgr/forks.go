@@ -23,6 +23,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/adt"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	init_ "github.com/filecoin-project/lotus/chain/actors/builtin/init"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
@@ -231,7 +232,7 @@ func (sm *StateManager) hasExpensiveFork(height abi.ChainEpoch) bool {
 	return ok
 }
 
-func runPreMigration(ctx context.Context, sm *StateManager, fn PreMigrationFunc, cache *nv16.MemMigrationCache, ts *types.TipSet) {
+func runPreMigration(ctx context.Context, sm *StateManager, fn PreMigrationFunc, cache *nv16.MemMigrationCache, persisted *preMigrationCacheStore, ts *types.TipSet) {
 	height := ts.Height()
 	parent := ts.ParentState()
 
@@ -256,6 +257,38 @@ func runPreMigration(ctx context.Context, sm *StateManager, fn PreMigrationFunc,
 	// Finally, if everything worked, update the cache.
 	cache.Update(tmpCache)
 	log.Warnw("COMPLETED pre-migration", "duration", time.Since(startTime))
+
+	// And persist it, so that a restart before the upgrade doesn't lose this work.
+	if err := persisted.Store(ctx, cache); err != nil {
+		log.Errorw("failed to persist pre-migration cache", "error", err)
+	}
+}
+
+// loadPreMigrationCaches restores the persisted pre-migration caches of the upcoming upgrades,
+// and clears those of the upgrades which are final.
+func (sm *StateManager) loadPreMigrationCaches(ctx context.Context) {
+	head := sm.cs.GetHeaviestTipSet()
+	for upgradeEpoch, migration := range sm.stateMigrations {
+		if len(migration.preMigrations) == 0 {
+			continue
+		}
+
+		if head != nil && head.Height() > upgradeEpoch+policy.ChainFinality {
+			if err := migration.persistedCache.Clear(ctx); err != nil {
+				log.Errorw("failed to clear pre-migration cache", "height", upgradeEpoch, "error", err)
+			}
+			continue
+		}
+
+		n, err := migration.persistedCache.Load(ctx, migration.cache)
+		if err != nil {
+			log.Errorw("failed to load pre-migration cache", "height", upgradeEpoch, "error", err)
+			continue
+		}
+		if n > 0 {
+			log.Infow("loaded pre-migration cache", "height", upgradeEpoch, "entries", n)
+		}
+	}
 }
 
 func (sm *StateManager) preMigrationWorker(ctx context.Context) {
@@ -273,10 +306,26 @@ func (sm *StateManager) preMigrationWorker(ctx context.Context) {
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
+	sm.loadPreMigrationCaches(ctx)
+
 	// Turn each pre-migration into an operation in a schedule.
 	var schedule []op
 	for upgradeEpoch, migration := range sm.stateMigrations {
 		cache := migration.cache
+		persisted := migration.persistedCache
+		if len(migration.preMigrations) > 0 {
+			// Add an op to clear the persisted cache once the upgrade can't be reverted.
+			schedule = append(schedule, op{
+				after:    upgradeEpoch + policy.ChainFinality,
+				notAfter: -1,
+				run: func(ts *types.TipSet) {
+					if err := persisted.Clear(ctx); err != nil {
+						log.Errorw("failed to clear pre-migration cache", "error", err)
+					}
+				},
+			})
+		}
+
 		for _, prem := range migration.preMigrations {
 			preCtx, preCancel := context.WithCancel(ctx)
 			migrationFunc := prem.PreMigration
@@ -299,7 +348,7 @@ func (sm *StateManager) preMigrationWorker(ctx context.Context) {
 					wg.Add(1)
 					go func() {
 						defer wg.Done()
-						runPreMigration(preCtx, sm, migrationFunc, cache, ts)
+						runPreMigration(preCtx, sm, migrationFunc, cache, persisted, ts)
 					}()
 				},
 			})
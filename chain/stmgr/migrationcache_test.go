@@ -0,0 +1,63 @@
+package stmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-actors/v8/actors/migration/nv16"
+)
+
+func TestPreMigrationCacheStore(t *testing.T) {
+	ctx := context.Background()
+	ds := datastore.NewMapDatastore()
+
+	fooCid, err := abi.CidBuilder.Sum([]byte("foo"))
+	require.NoError(t, err)
+	barCid, err := abi.CidBuilder.Sum([]byte("bar"))
+	require.NoError(t, err)
+
+	nv17 := &preMigrationCacheStore{ds: ds, keyPrefix: "/migration-cache/nv17/pre-migration"}
+	nv18 := &preMigrationCacheStore{ds: ds, keyPrefix: "/migration-cache/nv18/pre-migration"}
+	results := &migrationResultCache{ds: ds, keyPrefix: "/migration-cache/nv17"}
+
+	cache := nv16.NewMemMigrationCache()
+	require.NoError(t, cache.Write("foo", fooCid))
+	require.NoError(t, cache.Write("bar-"+barCid.String(), barCid))
+	require.NoError(t, nv17.Store(ctx, cache))
+	require.NoError(t, results.Store(ctx, fooCid, barCid))
+
+	loaded := nv16.NewMemMigrationCache()
+	n, err := nv17.Load(ctx, loaded)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	found, c, err := loaded.Read("foo")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, fooCid, c)
+
+	found, c, err = loaded.Read("bar-" + barCid.String())
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, barCid, c)
+
+	// caches of other upgrades are kept apart
+	n, err = nv18.Load(ctx, nv16.NewMemMigrationCache())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// clearing keeps the migration results
+	require.NoError(t, nv17.Clear(ctx))
+	n, err = nv17.Load(ctx, nv16.NewMemMigrationCache())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	res, found, err := results.Get(ctx, fooCid)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, barCid, res)
+}
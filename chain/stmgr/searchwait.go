@@ -19,7 +19,6 @@ import (
 // happened, with an optional limit to how many epochs it will search. It guarantees that the message has been on
 // chain for at least confidence epochs without being reverted before returning.
 func (sm *StateManager) WaitForMessage(ctx context.Context, mcid cid.Cid, confidence uint64, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*types.TipSet, *types.MessageReceipt, cid.Cid, error) {
-	// TODO use the index to speed this up.
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -57,7 +56,7 @@ func (sm *StateManager) WaitForMessage(ctx context.Context, mcid cid.Cid, confid
 	var backFm cid.Cid
 	backSearchWait := make(chan struct{})
 	go func() {
-		fts, r, foundMsg, err := sm.searchBackForMsg(ctx, head[0].Val, msg, lookbackLimit, allowReplaced)
+		fts, r, foundMsg, err := sm.searchForMsg(ctx, head[0].Val, mcid, msg, lookbackLimit, allowReplaced)
 		if err != nil {
 			log.Warnf("failed to look back through chain for message: %v", err)
 			return
@@ -147,6 +146,37 @@ func (sm *StateManager) SearchForMessage(ctx context.Context, head *types.TipSet
 		return head, r, foundMsg, nil
 	}
 
+	fts, r, foundMsg, err := sm.searchForMsg(ctx, head, mcid, msg, lookbackLimit, allowReplaced)
+
+	if err != nil {
+		log.Warnf("failed to look back through chain for message %s", mcid)
+		return nil, nil, cid.Undef, err
+	}
+
+	if fts == nil {
+		return nil, nil, cid.Undef, nil
+	}
+
+	return fts, r, foundMsg, nil
+}
+
+// searchForMsg looks for the message in the receipt index and then in the message index, and
+// falls back to searching up to limit tipsets backwards from head. The lookback limit only applies
+// to the chain walk.
+func (sm *StateManager) searchForMsg(ctx context.Context, head *types.TipSet, mcid cid.Cid, msg types.ChainMsg, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*types.TipSet, *types.MessageReceipt, cid.Cid, error) {
+	// a message in the receipt index was executed, so it wasn't replaced
+	fts, r, err := sm.searchForIndexedReceipt(ctx, mcid)
+	switch {
+	case err == nil:
+		return fts, r, mcid, nil
+
+	case errors.Is(err, index.ErrNotFound):
+		// the receipt index is disabled, or still backfilling
+
+	default:
+		log.Warnf("error searching receipt index: %s", err)
+	}
+
 	fts, r, foundMsg, err := sm.searchForIndexedMsg(ctx, mcid, msg)
 
 	switch {
@@ -170,18 +200,41 @@ func (sm *StateManager) SearchForMessage(ctx context.Context, head *types.TipSet
 		log.Warnf("error searching message index: %s", err)
 	}
 
-	fts, r, foundMsg, err = sm.searchBackForMsg(ctx, head, msg, lookbackLimit, allowReplaced)
+	return sm.searchBackForMsg(ctx, head, msg, lookbackLimit, allowReplaced)
+}
 
+// searchForIndexedReceipt looks for the receipt of the message in the receipt index, without
+// loading the receipts of the execution tipset.
+func (sm *StateManager) searchForIndexedReceipt(ctx context.Context, mcid cid.Cid) (*types.TipSet, *types.MessageReceipt, error) {
+	rinfo, err := sm.receiptIndex.GetReceipt(ctx, mcid)
 	if err != nil {
-		log.Warnf("failed to look back through chain for message %s", mcid)
-		return nil, nil, cid.Undef, err
+		return nil, nil, xerrors.Errorf("error looking up receipt in index: %w", err)
 	}
 
-	if fts == nil {
-		return nil, nil, cid.Undef, nil
+	// as with the message index, the execution tipset must be in the current chain
+	curTs := sm.cs.GetHeaviestTipSet()
+	if curTs.Height() <= rinfo.Epoch+1 {
+		return nil, nil, xerrors.Errorf("indexed receipt does not appear before the current tipset; index epoch: %d, current epoch: %d", rinfo.Epoch, curTs.Height())
 	}
 
-	return fts, r, foundMsg, nil
+	xts, err := sm.cs.GetTipsetByHeight(ctx, rinfo.Epoch+1, curTs, false)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("error looking up execution tipset: %w", err)
+	}
+
+	parentCid, err := xts.Parents().Cid()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("error computing tipset cid: %w", err)
+	}
+
+	if !parentCid.Equals(rinfo.TipSet) {
+		return nil, nil, xerrors.Errorf("inclusion tipset mismatch: have %s, expected %s", parentCid, rinfo.TipSet)
+	}
+	if root := xts.Blocks()[0].ParentMessageReceipts; !root.Equals(rinfo.ReceiptsRoot) {
+		return nil, nil, xerrors.Errorf("receipts root mismatch: have %s, expected %s", root, rinfo.ReceiptsRoot)
+	}
+
+	return xts, &rinfo.Receipt, nil
 }
 
 func (sm *StateManager) searchForIndexedMsg(ctx context.Context, mcid cid.Cid, m types.ChainMsg) (*types.TipSet, *types.MessageReceipt, cid.Cid, error) {
@@ -216,7 +269,10 @@ func (sm *StateManager) searchForIndexedMsg(ctx context.Context, mcid cid.Cid, m
 	}
 
 	r, foundMsg, err := sm.tipsetExecutedMessage(ctx, xts, mcid, m.VMMessage(), false)
-	return xts, r, foundMsg, xerrors.Errorf("error in tipstExecutedMessage: %w", err)
+	if err != nil {
+		return nil, nil, cid.Undef, xerrors.Errorf("error in tipstExecutedMessage: %w", err)
+	}
+	return xts, r, foundMsg, nil
 }
 
 // searchBackForMsg searches up to limit tipsets backwards from the given
@@ -3,10 +3,12 @@ package stmgr
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/ipfs/go-cid"
 	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	ipld "github.com/ipfs/go-ipld-format"
 	logging "github.com/ipfs/go-log/v2"
@@ -58,6 +60,7 @@ type migration struct {
 	upgrade              MigrationFunc
 	preMigrations        []PreMigration
 	cache                *nv16.MemMigrationCache
+	persistedCache       *preMigrationCacheStore
 	migrationResultCache *migrationResultCache
 }
 
@@ -98,6 +101,85 @@ func (m *migrationResultCache) Store(ctx context.Context, root cid.Cid, resultCi
 	return nil
 }
 
+// preMigrationCacheStore persists the entries of a pre-migration cache, so that the work done by
+// pre-migrations isn't lost if the node restarts before the upgrade.
+type preMigrationCacheStore struct {
+	ds        dstore.Batching
+	keyPrefix string
+}
+
+// Load adds the persisted entries to the cache, returning the number of entries loaded.
+func (p *preMigrationCacheStore) Load(ctx context.Context, cache *nv16.MemMigrationCache) (int, error) {
+	res, err := p.ds.Query(ctx, query.Query{Prefix: p.keyPrefix})
+	if err != nil {
+		return 0, xerrors.Errorf("querying pre-migration cache: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var n int
+	for r := range res.Next() {
+		if r.Error != nil {
+			return n, xerrors.Errorf("iterating pre-migration cache: %w", r.Error)
+		}
+
+		c, err := cid.Cast(r.Value)
+		if err != nil {
+			return n, xerrors.Errorf("parsing pre-migration cache entry %s: %w", r.Key, err)
+		}
+		if err := cache.Write(strings.TrimPrefix(r.Key, p.keyPrefix+"/"), c); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Store persists all the entries of the cache.
+func (p *preMigrationCacheStore) Store(ctx context.Context, cache *nv16.MemMigrationCache) error {
+	batch, err := p.ds.Batch(ctx)
+	if err != nil {
+		return xerrors.Errorf("creating batch: %w", err)
+	}
+
+	cache.MigrationMap.Range(func(key, value interface{}) bool {
+		k, kok := key.(string)
+		c, cok := value.(cid.Cid)
+		if !kok || !cok {
+			return true
+		}
+		err = batch.Put(ctx, dstore.NewKey(p.keyPrefix).ChildString(k), c.Bytes())
+		return err == nil
+	})
+	if err != nil {
+		return xerrors.Errorf("writing pre-migration cache: %w", err)
+	}
+
+	return batch.Commit(ctx)
+}
+
+// Clear removes the persisted entries, once the upgrade is final.
+func (p *preMigrationCacheStore) Clear(ctx context.Context) error {
+	res, err := p.ds.Query(ctx, query.Query{Prefix: p.keyPrefix, KeysOnly: true})
+	if err != nil {
+		return xerrors.Errorf("querying pre-migration cache: %w", err)
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return xerrors.Errorf("iterating pre-migration cache: %w", err)
+	}
+
+	batch, err := p.ds.Batch(ctx)
+	if err != nil {
+		return xerrors.Errorf("creating batch: %w", err)
+	}
+	for _, e := range entries {
+		if err := batch.Delete(ctx, dstore.NewKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(ctx)
+}
+
 type Executor interface {
 	NewActorRegistry() *vm.ActorRegistry
 	ExecuteTipSet(ctx context.Context, sm *StateManager, ts *types.TipSet, em ExecMonitor, vmTracing bool) (stateroot cid.Cid, rectsroot cid.Cid, err error)
@@ -138,7 +220,8 @@ type StateManager struct {
 	tsExecMonitor ExecMonitor
 	beacon        beacon.Schedule
 
-	msgIndex index.MsgIndex
+	msgIndex     index.MsgIndex
+	receiptIndex index.ReceiptIndex
 }
 
 // Caches a single state tree
@@ -166,6 +249,10 @@ func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder,
 					upgrade:       upgrade.Migration,
 					preMigrations: upgrade.PreMigrations,
 					cache:         nv16.NewMemMigrationCache(),
+					persistedCache: &preMigrationCacheStore{
+						keyPrefix: fmt.Sprintf("/migration-cache/nv%d/pre-migration", upgrade.Network),
+						ds:        metadataDs,
+					},
 					migrationResultCache: &migrationResultCache{
 						keyPrefix: fmt.Sprintf("/migration-cache/nv%d", upgrade.Network),
 						ds:        metadataDs,
@@ -201,8 +288,9 @@ func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder,
 			root: cid.Undef,
 			tree: nil,
 		},
-		compWait: make(map[string]chan struct{}),
-		msgIndex: msgIndex,
+		compWait:     make(map[string]chan struct{}),
+		msgIndex:     msgIndex,
+		receiptIndex: index.DummyReceiptIndex,
 	}, nil
 }
 
@@ -439,6 +527,11 @@ func (sm *StateManager) ValidateChain(ctx context.Context, ts *types.TipSet) err
 	return nil
 }
 
+// SetReceiptIndex sets the receipt index used to look up the receipts of executed messages.
+func (sm *StateManager) SetReceiptIndex(ri index.ReceiptIndex) {
+	sm.receiptIndex = ri
+}
+
 func (sm *StateManager) SetVMConstructor(nvm func(context.Context, *vm.VMOpts) (vm.Interface, error)) {
 	sm.newVM = nvm
 }
@@ -0,0 +1,318 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var ancestorTableKeyPrefix = dstore.NewKey("/chain/ancestors")
+
+var DefaultAncestorTableCacheSize = 1 << 16
+
+// ancestorQueueSize bounds the tipsets waiting to be added to the table. When the table falls
+// behind the head, further tipsets are dropped, and lookups from them fall back to the chain index.
+const ancestorQueueSize = 1024
+
+// ancestorTableRetention is how far below the last added tipset the entries are kept; reorgs
+// don't go deeper than finality, and deeper lookups fall back to the chain index once they reach
+// a pruned entry.
+var ancestorTableRetention = abi.ChainEpoch(build.Finality)
+
+type ancestorEntry struct {
+	height abi.ChainEpoch
+	target types.TipSetKey
+}
+
+// ancestorTable is a persistent sparse table of ancestors: every tipset points to one of its
+// ancestors, at the height obtained by clearing the lowest set bits of its own height, so that
+// the tipsets at multiples of 2^k epochs point 2^k epochs back. Following these pointers finds the
+// ancestor of a tipset at any height, and the common ancestor of two tipsets, in a logarithmic
+// number of steps instead of walking the chain one tipset at a time.
+//
+// Tipsets are queued as they are applied to the head and added to the table in the background,
+// and the pointers never change. The entries of the tipsets more than ancestorTableRetention
+// epochs below the last added tipset are pruned. Lookups from tipsets which aren't in the table
+// fall back to the in-memory chain index.
+type ancestorTable struct {
+	ds       dstore.Batching
+	lts      loadTipSetFunc
+	fallback func(ctx context.Context, ts *types.TipSet, h abi.ChainEpoch) (*types.TipSet, error)
+
+	cache *lru.Cache[types.TipSetKey, ancestorEntry]
+
+	pending    chan *types.TipSet
+	lastPruned abi.ChainEpoch
+}
+
+func newAncestorTable(ds dstore.Batching, lts loadTipSetFunc, fallback func(context.Context, *types.TipSet, abi.ChainEpoch) (*types.TipSet, error)) *ancestorTable {
+	cache, _ := lru.New[types.TipSetKey, ancestorEntry](DefaultAncestorTableCacheSize)
+	return &ancestorTable{
+		ds:       ds,
+		lts:      lts,
+		fallback: fallback,
+		cache:    cache,
+		pending:  make(chan *types.TipSet, ancestorQueueSize),
+	}
+}
+
+// queue queues the tipsets, parents first, to be added to the table by run.
+func (at *ancestorTable) queue(tss []*types.TipSet) {
+	for _, ts := range tss {
+		select {
+		case at.pending <- ts:
+		default:
+			log.Debugf("ancestor table queue full, skipping tipset %s", ts.Key())
+		}
+	}
+}
+
+// run adds the queued tipsets to the table, and prunes the table every ancestorTableRetention
+// epochs, until the context is cancelled.
+func (at *ancestorTable) run(ctx context.Context) {
+	for {
+		select {
+		case ts := <-at.pending:
+			if err := at.add(ctx, ts); err != nil {
+				log.Warnf("error adding tipset %s to the ancestor table: %s", ts.Key(), err)
+				continue
+			}
+
+			if ts.Height()-at.lastPruned < ancestorTableRetention {
+				continue
+			}
+			if err := at.prune(ctx, ts.Height()-ancestorTableRetention); err != nil {
+				log.Warnf("error pruning the ancestor table: %s", err)
+			}
+			at.lastPruned = ts.Height()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// prune deletes the entries of the tipsets below height h.
+func (at *ancestorTable) prune(ctx context.Context, h abi.ChainEpoch) error {
+	res, err := at.ds.Query(ctx, query.Query{Prefix: ancestorTableKeyPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return xerrors.Errorf("querying ancestor table: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	batch, err := at.ds.Batch(ctx)
+	if err != nil {
+		return xerrors.Errorf("creating batch: %w", err)
+	}
+
+	var pruned int
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("iterating ancestor table: %w", r.Error)
+		}
+
+		key := dstore.RawKey(r.Key)
+		height, err := strconv.ParseInt(key.Parent().BaseNamespace(), 10, 64)
+		if err != nil {
+			log.Warnf("unexpected ancestor table key %s", key)
+			continue
+		}
+		if abi.ChainEpoch(height) >= h {
+			continue
+		}
+
+		if err := batch.Delete(ctx, key); err != nil {
+			return xerrors.Errorf("deleting ancestor table entry: %w", err)
+		}
+		pruned++
+	}
+
+	if err := batch.Commit(ctx); err != nil {
+		return xerrors.Errorf("committing ancestor table pruning: %w", err)
+	}
+
+	log.Debugw("pruned ancestor table", "below", h, "entries", pruned)
+	return nil
+}
+
+// skipHeight returns the height of the ancestor a tipset at height h points to. The heights of
+// odd tipsets are chosen so that pointers of consecutive tipsets don't overlap, as in bitcoin's
+// skip list.
+func skipHeight(h abi.ChainEpoch) abi.ChainEpoch {
+	if h < 2 {
+		return 0
+	}
+
+	invertLowestOne := func(n abi.ChainEpoch) abi.ChainEpoch { return n & (n - 1) }
+	if h&1 != 0 {
+		return invertLowestOne(invertLowestOne(h-1)) + 1
+	}
+	return invertLowestOne(h)
+}
+
+// ancestorTableKey keys the entry of a tipset by height first, so that it can be pruned by height.
+func ancestorTableKey(ts *types.TipSet) (dstore.Key, error) {
+	c, err := ts.Key().Cid()
+	if err != nil {
+		return dstore.Key{}, xerrors.Errorf("computing tipset cid: %w", err)
+	}
+	return ancestorTableKeyPrefix.ChildString(strconv.FormatInt(int64(ts.Height()), 10)).ChildString(c.String()), nil
+}
+
+func (at *ancestorTable) get(ctx context.Context, ts *types.TipSet) (ancestorEntry, bool, error) {
+	tsk := ts.Key()
+	if e, ok := at.cache.Get(tsk); ok {
+		return e, true, nil
+	}
+
+	key, err := ancestorTableKey(ts)
+	if err != nil {
+		return ancestorEntry{}, false, err
+	}
+
+	data, err := at.ds.Get(ctx, key)
+	switch {
+	case err == dstore.ErrNotFound:
+		return ancestorEntry{}, false, nil
+	case err != nil:
+		return ancestorEntry{}, false, xerrors.Errorf("reading ancestor table: %w", err)
+	}
+
+	h, n := binary.Uvarint(data)
+	if n <= 0 {
+		return ancestorEntry{}, false, xerrors.Errorf("corrupt ancestor table entry for %s", tsk)
+	}
+	target, err := types.TipSetKeyFromBytes(data[n:])
+	if err != nil {
+		return ancestorEntry{}, false, xerrors.Errorf("corrupt ancestor table entry for %s: %w", tsk, err)
+	}
+
+	e := ancestorEntry{height: abi.ChainEpoch(h), target: target}
+	at.cache.Add(tsk, e)
+	return e, true, nil
+}
+
+// add adds ts to the table, unless it is there already. It is cheapest when the parent of ts was
+// added before it.
+func (at *ancestorTable) add(ctx context.Context, ts *types.TipSet) error {
+	if ts.Height() == 0 {
+		return nil
+	}
+
+	if _, ok, err := at.get(ctx, ts); err != nil || ok {
+		return err
+	}
+
+	parent, err := at.lts(ctx, ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	anc, err := at.ancestor(ctx, parent, skipHeight(ts.Height()))
+	if err != nil {
+		return xerrors.Errorf("finding skip ancestor: %w", err)
+	}
+
+	key, err := ancestorTableKey(ts)
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(data, uint64(anc.Height()))
+	data = append(data[:n], anc.Key().Bytes()...)
+	if err := at.ds.Put(ctx, key, data); err != nil {
+		return xerrors.Errorf("writing ancestor table: %w", err)
+	}
+
+	at.cache.Add(ts.Key(), ancestorEntry{height: anc.Height(), target: anc.Key()})
+	return nil
+}
+
+// ancestor returns the ancestor of ts at the highest height not above h, which is ts itself when
+// it isn't above h.
+func (at *ancestorTable) ancestor(ctx context.Context, ts *types.TipSet, h abi.ChainEpoch) (*types.TipSet, error) {
+	for ts.Height() > h {
+		e, ok, err := at.get(ctx, ts)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return at.fallback(ctx, ts, h)
+		}
+
+		next := ts.Parents()
+		if e.height >= h {
+			next = e.target
+		}
+
+		ts, err = at.lts(ctx, next)
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset: %w", err)
+		}
+	}
+
+	return ts, nil
+}
+
+// commonAncestor returns the highest common ancestor of a and b, which is a or b itself when one
+// is an ancestor of the other.
+func (at *ancestorTable) commonAncestor(ctx context.Context, a, b *types.TipSet) (*types.TipSet, error) {
+	var err error
+	for !a.Equals(b) {
+		switch {
+		case a.Height() > b.Height():
+			a, err = at.ancestor(ctx, a, b.Height())
+			if err != nil {
+				return nil, err
+			}
+			continue
+
+		case b.Height() > a.Height():
+			b, err = at.ancestor(ctx, b, a.Height())
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if a.Height() == 0 {
+			return nil, xerrors.Errorf("tipsets %s and %s have different genesis", a.Key(), b.Key())
+		}
+
+		// at the same height, jump both tipsets together while their skip ancestors still differ,
+		// and otherwise step back to their parents
+		ea, oka, err := at.get(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		eb, okb, err := at.get(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+
+		nextA, nextB := a.Parents(), b.Parents()
+		if oka && okb && ea.target != eb.target {
+			nextA, nextB = ea.target, eb.target
+		}
+
+		if a, err = at.lts(ctx, nextA); err != nil {
+			return nil, xerrors.Errorf("loading tipset: %w", err)
+		}
+		if b, err = at.lts(ctx, nextB); err != nil {
+			return nil, xerrors.Errorf("loading tipset: %w", err)
+		}
+	}
+
+	return a, nil
+}
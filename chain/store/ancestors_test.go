@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestAncestorTable(t *testing.T) {
+	ctx := context.Background()
+
+	tipsets := make(map[types.TipSetKey]*types.TipSet)
+	lts := func(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+		ts, ok := tipsets[tsk]
+		if !ok {
+			return nil, xerrors.Errorf("tipset %s not found", tsk)
+		}
+		return ts, nil
+	}
+
+	walk := func(ctx context.Context, ts *types.TipSet, h abi.ChainEpoch) (*types.TipSet, error) {
+		for ts.Height() > h {
+			var err error
+			if ts, err = lts(ctx, ts.Parents()); err != nil {
+				return nil, err
+			}
+		}
+		return ts, nil
+	}
+
+	// the fallback walks the chain one tipset at a time
+	fallbacks := 0
+	fallback := func(ctx context.Context, ts *types.TipSet, h abi.ChainEpoch) (*types.TipSet, error) {
+		fallbacks++
+		return walk(ctx, ts, h)
+	}
+
+	// extend builds n tipsets on top of ts, with a null round every 7 epochs
+	extend := func(ts *types.TipSet, n int, nonce uint64) []*types.TipSet {
+		var out []*types.TipSet
+		for i := 0; i < n; i++ {
+			blk := mock.MkBlock(ts, 1, nonce)
+			if blk.Height%7 == 0 {
+				blk.Height++
+			}
+			ts = mock.TipSet(blk)
+			tipsets[ts.Key()] = ts
+			out = append(out, ts)
+		}
+		return out
+	}
+
+	gen := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	tipsets[gen.Key()] = gen
+
+	chain := append([]*types.TipSet{gen}, extend(gen, 500, 1)...)
+	head := chain[len(chain)-1]
+
+	at := newAncestorTable(syncds.MutexWrap(datastore.NewMapDatastore()), lts, fallback)
+	for _, ts := range chain {
+		require.NoError(t, at.add(ctx, ts))
+	}
+
+	// a fork off the main chain, which isn't in the table
+	forkPoint := chain[300]
+	fork := extend(forkPoint, 100, 2)
+	forkHead := fork[len(fork)-1]
+
+	fallbacks = 0
+	for h := abi.ChainEpoch(0); h <= head.Height(); h++ {
+		expected, err := walk(ctx, head, h)
+		require.NoError(t, err)
+
+		anc, err := at.ancestor(ctx, head, h)
+		require.NoError(t, err)
+		require.True(t, expected.Equals(anc), "ancestor at height %d", h)
+	}
+	require.Equal(t, 0, fallbacks)
+
+	anc, err := at.commonAncestor(ctx, head, forkHead)
+	require.NoError(t, err)
+	require.True(t, forkPoint.Equals(anc))
+
+	// once the fork is in the table, the common ancestor is found by jumping on both sides
+	for _, ts := range fork {
+		require.NoError(t, at.add(ctx, ts))
+	}
+	anc, err = at.commonAncestor(ctx, forkHead, head)
+	require.NoError(t, err)
+	require.True(t, forkPoint.Equals(anc))
+
+	anc, err = at.commonAncestor(ctx, chain[100], head)
+	require.NoError(t, err)
+	require.True(t, chain[100].Equals(anc))
+
+	// the table is persisted
+	at = newAncestorTable(at.ds, lts, fallback)
+	fallbacks = 0
+	anc, err = at.ancestor(ctx, head, 10)
+	require.NoError(t, err)
+	require.Equal(t, 0, fallbacks)
+	require.LessOrEqual(t, anc.Height(), abi.ChainEpoch(10))
+
+	// pruned entries are gone, and lookups reaching them fall back to the chain index
+	cut := head.Height() - 100
+	require.NoError(t, at.prune(ctx, cut))
+	at = newAncestorTable(at.ds, lts, fallback)
+	for _, ts := range chain {
+		_, ok, err := at.get(ctx, ts)
+		require.NoError(t, err)
+		require.Equal(t, ts.Height() >= cut, ok, "entry at height %d", ts.Height())
+	}
+
+	fallbacks = 0
+	anc, err = at.ancestor(ctx, head, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, fallbacks)
+	require.Equal(t, abi.ChainEpoch(10), anc.Height())
+
+	// queued tipsets are added in the background
+	at = newAncestorTable(syncds.MutexWrap(datastore.NewMapDatastore()), lts, fallback)
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go at.run(runCtx)
+
+	at.queue(chain)
+	require.Eventually(t, func() bool {
+		_, ok, err := at.get(ctx, head)
+		return err == nil && ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSkipHeight(t *testing.T) {
+	for h := abi.ChainEpoch(0); h < 1<<12; h++ {
+		s := skipHeight(h)
+		require.GreaterOrEqual(t, s, abi.ChainEpoch(0))
+		if h > 0 {
+			require.Less(t, s, h)
+		}
+	}
+
+	// tipsets at multiples of 2^k point 2^k epochs back
+	require.Equal(t, abi.ChainEpoch(0), skipHeight(1024))
+	require.Equal(t, abi.ChainEpoch(1024), skipHeight(1536))
+	require.Equal(t, abi.ChainEpoch(1536), skipHeight(1544))
+}
@@ -58,6 +58,78 @@ func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRo
 	})
 }
 
+// ExportState writes a CAR with the headers of the tipset and of its ancestors down to
+// ts.Height()-lookback, along with the complete parent states of these tipsets. Messages and
+// receipts are left out: the CAR holds enough to load and migrate the states, e.g. to test a
+// network upgrade against a real state, but not to execute the tipsets. The roots of the CAR are
+// the blocks of the tipset, so it can be read back with Import.
+func (cs *ChainStore) ExportState(ctx context.Context, ts *types.TipSet, lookback abi.ChainEpoch, w io.Writer) error {
+	h := &car.CarHeader{
+		Roots:   ts.Cids(),
+		Version: 1,
+	}
+
+	if err := car.WriteHeader(h, w); err != nil {
+		return xerrors.Errorf("failed to write car header: %s", err)
+	}
+
+	unionBs := cs.UnionStore()
+	write := func(c cid.Cid) error {
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("failed to write block to car output: %w", err)
+		}
+
+		return nil
+	}
+
+	log.Infow("state export started", "height", ts.Height(), "lookback", lookback)
+	exportStart := build.Clock.Now()
+
+	walked := cid.NewSet()
+	minHeight := ts.Height() - lookback
+	for cur := ts; ; {
+		for _, blk := range cur.Cids() {
+			if err := write(blk); err != nil {
+				return err
+			}
+		}
+
+		if root := cur.ParentState(); walked.Visit(root) {
+			cids, err := recurseLinks(ctx, cs.stateBlockstore, walked, root, []cid.Cid{root})
+			if err != nil {
+				return xerrors.Errorf("recursing state at height %d failed: %w", cur.Height(), err)
+			}
+
+			for _, c := range cids {
+				if !exportedCid(c) {
+					continue
+				}
+				if err := write(c); err != nil {
+					return err
+				}
+			}
+		}
+
+		if cur.Height() <= minHeight || cur.Height() == 0 {
+			break
+		}
+
+		var err error
+		if cur, err = cs.LoadTipSet(ctx, cur.Parents()); err != nil {
+			return xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	log.Infow("state export finished", "duration", build.Clock.Now().Sub(exportStart).Seconds())
+
+	return nil
+}
+
 func (cs *ChainStore) Import(ctx context.Context, r io.Reader) (*types.TipSet, error) {
 	// TODO: writing only to the state blockstore is incorrect.
 	//  At this time, both the state and chain blockstores are backed by the
@@ -630,18 +702,7 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 
 		for _, c := range out {
 			if seen.Visit(c) {
-				prefix := c.Prefix()
-
-				// Don't include identity CIDs.
-				if prefix.MhType == mh.IDENTITY {
-					continue
-				}
-
-				// We only include raw and dagcbor, for now.
-				// Raw for "code" CIDs.
-				switch prefix.Codec {
-				case cid.Raw, cid.DagCBOR:
-				default:
+				if !exportedCid(c) {
 					continue
 				}
 
@@ -671,6 +732,25 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 	return nil
 }
 
+// exportedCid returns whether the object should be included in exported CARs
+func exportedCid(c cid.Cid) bool {
+	prefix := c.Prefix()
+
+	// Don't include identity CIDs.
+	if prefix.MhType == mh.IDENTITY {
+		return false
+	}
+
+	// We only include raw and dagcbor, for now.
+	// Raw for "code" CIDs.
+	switch prefix.Codec {
+	case cid.Raw, cid.DagCBOR:
+		return true
+	default:
+		return false
+	}
+}
+
 func recurseLinks(ctx context.Context, bs bstore.Blockstore, walked *cid.Set, root cid.Cid, in []cid.Cid) ([]cid.Cid, error) {
 	if root.Prefix().Codec != cid.DagCBOR {
 		return in, nil
@@ -15,7 +15,6 @@ import (
 	"github.com/ipfs/go-cid"
 	dstore "github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/query"
-	cbor "github.com/ipfs/go-ipld-cbor"
 	block "github.com/ipfs/go-libipfs/blocks"
 	logging "github.com/ipfs/go-log/v2"
 	"go.opencensus.io/stats"
@@ -115,7 +114,8 @@ type ChainStore struct {
 	tstLk   sync.Mutex
 	tipsets map[abi.ChainEpoch][]cid.Cid
 
-	cindex *ChainIndex
+	cindex    *ChainIndex
+	ancestors *ancestorTable
 
 	reorgCh        chan<- reorg
 	reorgNotifeeCh chan ReorgNotifee
@@ -164,6 +164,9 @@ func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dsto
 	ci := NewChainIndex(cs.LoadTipSet)
 
 	cs.cindex = ci
+	cs.ancestors = newAncestorTable(ds, cs.LoadTipSet, func(ctx context.Context, ts *types.TipSet, h abi.ChainEpoch) (*types.TipSet, error) {
+		return cs.GetTipsetByHeight(ctx, h, ts, true)
+	})
 
 	hcnf := func(rev, app []*types.TipSet) error {
 		cs.pubLk.Lock()
@@ -198,6 +201,12 @@ func NewChainStore(chainBs bstore.Blockstore, stateBs bstore.Blockstore, ds dsto
 	cs.reorgNotifeeCh = make(chan ReorgNotifee)
 	cs.reorgCh = cs.reorgWorker(ctx, []ReorgNotifee{hcnf, hcmetric})
 
+	cs.wg.Add(1)
+	go func() {
+		defer cs.wg.Done()
+		cs.ancestors.run(ctx)
+	}()
+
 	return cs
 }
 
@@ -595,6 +604,9 @@ func (cs *ChainStore) reorgWorker(ctx context.Context, initialNotifees []ReorgNo
 					apply[i], apply[opp] = apply[opp], apply[i]
 				}
 
+				// add the new tipsets to the ancestor table, parents first
+				cs.ancestors.queue(apply)
+
 				var toremove map[int]struct{}
 				for i, hcf := range notifees {
 					err := hcf(revert, apply)
@@ -859,26 +871,16 @@ func (cs *ChainStore) IsAncestorOf(ctx context.Context, a, b *types.TipSet) (boo
 		return false, nil
 	}
 
-	cur := b
-	for !a.Equals(cur) && cur.Height() > a.Height() {
-		next, err := cs.LoadTipSet(ctx, cur.Parents())
-		if err != nil {
-			return false, err
-		}
-
-		cur = next
+	cur, err := cs.ancestors.ancestor(ctx, b, a.Height())
+	if err != nil {
+		return false, err
 	}
 
 	return cur.Equals(a), nil
 }
 
 func (cs *ChainStore) NearestCommonAncestor(ctx context.Context, a, b *types.TipSet) (*types.TipSet, error) {
-	l, _, err := cs.ReorgOps(ctx, a, b)
-	if err != nil {
-		return nil, err
-	}
-
-	return cs.LoadTipSet(ctx, l[len(l)-1].Parents())
+	return cs.ancestors.commonAncestor(ctx, a, b)
 }
 
 // ReorgOps takes two tipsets (which can be at different heights), and walks
@@ -1081,7 +1083,16 @@ func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to type
 	if err != nil {
 		return nil, xerrors.Errorf("loading to tipset %s: %w", to, err)
 	}
-	revert, apply, err := cs.ReorgOps(ctx, fts, tts)
+	// find the fork point through the ancestor table, and only load the tipsets on the path
+	anc, err := cs.ancestors.commonAncestor(ctx, fts, tts)
+	if err != nil {
+		return nil, xerrors.Errorf("error finding common ancestor: %w", err)
+	}
+	revert, err := cs.chainSegment(ctx, fts, anc)
+	if err != nil {
+		return nil, xerrors.Errorf("error getting tipset branches: %w", err)
+	}
+	apply, err := cs.chainSegment(ctx, tts, anc)
 	if err != nil {
 		return nil, xerrors.Errorf("error getting tipset branches: %w", err)
 	}
@@ -1096,6 +1107,27 @@ func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to type
 	return path, nil
 }
 
+// chainSegment returns the tipsets from ts down to its ancestor anc, excluding anc, in the same
+// order as ReorgOps.
+func (cs *ChainStore) chainSegment(ctx context.Context, ts, anc *types.TipSet) ([]*types.TipSet, error) {
+	var out []*types.TipSet
+	for cur := ts; !cur.Equals(anc); {
+		if cur.Height() <= anc.Height() {
+			return nil, xerrors.Errorf("%s is not an ancestor of %s", anc.Key(), ts.Key())
+		}
+
+		out = append(out, cur)
+
+		var err error
+		cur, err = cs.LoadTipSet(ctx, cur.Parents())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
 // ChainBlockstore returns the chain blockstore. Currently the chain and state
 // // stores are both backed by the same physical store, albeit with different
 // // caching policies, but in the future they will segregate.
@@ -1115,7 +1147,7 @@ func (cs *ChainStore) ChainLocalBlockstore() bstore.Blockstore {
 }
 
 func ActorStore(ctx context.Context, bs bstore.Blockstore) adt.Store {
-	return adt.WrapStore(ctx, cbor.NewCborStore(bs))
+	return adt.WrapStore(ctx, bstore.NewCborStore(ctx, bs))
 }
 
 func (cs *ChainStore) ActorStore(ctx context.Context) adt.Store {
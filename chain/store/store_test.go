@@ -19,6 +19,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -127,6 +128,48 @@ func TestChainExportImport(t *testing.T) {
 	}
 }
 
+func TestChainExportStateImport(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var last *types.TipSet
+	for i := 0; i < 20; i++ {
+		ts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		last = ts.TipSet.TipSet()
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, cg.ChainStore().ExportState(ctx, last, 5, buf))
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	root, err := cs.Import(ctx, buf)
+	require.NoError(t, err)
+	require.Truef(t, root.Equals(last), "imported chain differed from exported chain")
+
+	// the states down to the lookback can be loaded, but not the messages
+	ts := root
+	for {
+		_, err := state.LoadStateTree(cs.ActorStore(ctx), ts.ParentState())
+		require.NoError(t, err)
+
+		if ts.Height() == last.Height()-5 {
+			break
+		}
+		ts, err = cs.LoadTipSet(ctx, ts.Parents())
+		require.NoError(t, err)
+	}
+	_, err = cs.LoadTipSet(ctx, ts.Parents())
+	require.Error(t, err)
+
+	_, err = cs.MessagesForTipset(ctx, root)
+	require.Error(t, err)
+}
+
 // Test to check if tipset key cids are being stored on snapshot
 func TestChainImportTipsetKeyCid(t *testing.T) {
 
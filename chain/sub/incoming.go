@@ -314,12 +314,13 @@ func (brc *blockReceiptCache) add(bcid cid.Cid) int {
 }
 
 type MessageValidator struct {
-	self  peer.ID
-	mpool *messagepool.MessagePool
+	self    peer.ID
+	mpool   *messagepool.MessagePool
+	limiter *messagepool.PeerLimiter
 }
 
-func NewMessageValidator(self peer.ID, mp *messagepool.MessagePool) *MessageValidator {
-	return &MessageValidator{self: self, mpool: mp}
+func NewMessageValidator(self peer.ID, mp *messagepool.MessagePool, limiter *messagepool.PeerLimiter) *MessageValidator {
+	return &MessageValidator{self: self, mpool: mp, limiter: limiter}
 }
 
 func (mv *MessageValidator) Validate(ctx context.Context, pid peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
@@ -334,15 +335,25 @@ func (mv *MessageValidator) Validate(ctx context.Context, pid peer.ID, msg *pubs
 	}()
 
 	stats.Record(ctx, metrics.MessageReceived.M(1))
+
+	if err := mv.limiter.Allow(pid.String()); err != nil {
+		log.Debugf("dropping message from peer: %s", err)
+		recordFailure(ctx, metrics.MessageValidationFailure, "peer_limit")
+		return pubsub.ValidationIgnore
+	}
+
 	m, err := types.DecodeSignedMessage(msg.Message.GetData())
 	if err != nil {
 		log.Warnf("failed to decode incoming message: %s", err)
+		mv.limiter.Record(pid.String(), err)
 		ctx, _ = tag.New(ctx, tag.Insert(metrics.FailureType, "decode"))
 		stats.Record(ctx, metrics.MessageValidationFailure.M(1))
 		return pubsub.ValidationReject
 	}
 
-	if err := mv.mpool.Add(ctx, m); err != nil {
+	err = mv.mpool.Add(ctx, m)
+	mv.limiter.Record(pid.String(), err)
+	if err != nil {
 		log.Debugf("failed to add message from network to message pool (From: %s, To: %s, Nonce: %d, Value: %s): %s", m.Message.From, m.Message.To, m.Message.Nonce, types.FIL(m.Message.Value), err)
 		ctx, _ = tag.New(
 			ctx,
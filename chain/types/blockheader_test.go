@@ -139,3 +139,30 @@ func BenchmarkBlockHeaderMarshal(b *testing.B) {
 		}
 	}
 }
+
+// FuzzBlockHeader feeds arbitrary data to block header decoding, as done by the
+// chain walks of the splitstore; decoding must fail rather than crash on malformed
+// headers, and the headers it accepts must round trip.
+func FuzzBlockHeader(f *testing.F) {
+	data, err := testBlockHeader(f).Serialize()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add(data[:len(data)/2])
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bh, err := DecodeBlock(data)
+		if err != nil {
+			return
+		}
+
+		out, err := bh.Serialize()
+		require.NoError(t, err)
+
+		bh2, err := DecodeBlock(out)
+		require.NoError(t, err)
+		require.Equal(t, bh.Cid(), bh2.Cid())
+	})
+}
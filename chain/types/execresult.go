@@ -54,6 +54,46 @@ func SumGas(charges []*GasTrace) GasTrace {
 	return out
 }
 
+// GasChargeEvent is a gas charge of an execution trace, along with the call it was charged in.
+type GasChargeEvent struct {
+	Name       string
+	TotalGas   int64
+	ComputeGas int64
+	StorageGas int64
+	TimeTaken  time.Duration
+	// Depth is the depth of the call in the trace, 0 for the message itself
+	Depth int
+	// To and Method identify the call
+	To     address.Address
+	Method abi.MethodNum
+}
+
+// GasChargeEvents returns the gas charges of the trace, depth first: the charges of each call are
+// followed by those of its subcalls.
+func (et ExecutionTrace) GasChargeEvents() []GasChargeEvent {
+	var out []GasChargeEvent
+	et.appendGasChargeEvents(0, &out)
+	return out
+}
+
+func (et ExecutionTrace) appendGasChargeEvents(depth int, out *[]GasChargeEvent) {
+	for _, gc := range et.GasCharges {
+		*out = append(*out, GasChargeEvent{
+			Name:       gc.Name,
+			TotalGas:   gc.TotalGas,
+			ComputeGas: gc.ComputeGas,
+			StorageGas: gc.StorageGas,
+			TimeTaken:  gc.TimeTaken,
+			Depth:      depth,
+			To:         et.Msg.To,
+			Method:     et.Msg.Method,
+		})
+	}
+	for _, sub := range et.Subcalls {
+		sub.appendGasChargeEvents(depth+1, out)
+	}
+}
+
 func (gt *GasTrace) MarshalJSON() ([]byte, error) {
 	type GasTraceCopy GasTrace
 	cpy := (*GasTraceCopy)(gt)
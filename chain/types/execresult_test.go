@@ -0,0 +1,48 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+)
+
+func TestGasChargeEvents(t *testing.T) {
+	to := func(id uint64) address.Address {
+		addr, err := address.NewIDAddress(id)
+		require.NoError(t, err)
+		return addr
+	}
+
+	et := ExecutionTrace{
+		Msg: MessageTrace{To: to(100), Method: 2},
+		GasCharges: []*GasTrace{
+			{Name: "OnChainMessage", TotalGas: 10, ComputeGas: 4, StorageGas: 6},
+			{Name: "OnMethodInvocation", TotalGas: 5, ComputeGas: 5},
+		},
+		Subcalls: []ExecutionTrace{{
+			Msg:        MessageTrace{To: to(101), Method: 3},
+			GasCharges: []*GasTrace{{Name: "OnIpldGet", TotalGas: 7, ComputeGas: 7}},
+			Subcalls: []ExecutionTrace{{
+				Msg:        MessageTrace{To: to(102)},
+				GasCharges: []*GasTrace{{Name: "OnIpldPut", TotalGas: 3, StorageGas: 3}},
+			}},
+		}},
+	}
+
+	events := et.GasChargeEvents()
+	require.Len(t, events, 4)
+
+	require.Equal(t, GasChargeEvent{Name: "OnChainMessage", TotalGas: 10, ComputeGas: 4, StorageGas: 6, To: to(100), Method: 2}, events[0])
+	require.Equal(t, "OnMethodInvocation", events[1].Name)
+	require.Equal(t, 0, events[1].Depth)
+	require.Equal(t, GasChargeEvent{Name: "OnIpldGet", TotalGas: 7, ComputeGas: 7, Depth: 1, To: to(101), Method: 3}, events[2])
+	require.Equal(t, GasChargeEvent{Name: "OnIpldPut", TotalGas: 3, StorageGas: 3, Depth: 2, To: to(102)}, events[3])
+
+	var total int64
+	for _, e := range events {
+		total += e.TotalGas
+	}
+	require.Equal(t, int64(25), total)
+}
@@ -62,6 +62,19 @@ type KeyInfo struct {
 	PrivateKey []byte
 }
 
+// EncryptedKeyInfo is a KeyInfo encrypted with a key derived from a password,
+// for backups which can be stored outside of the KeyStore.
+type EncryptedKeyInfo struct {
+	Version int
+	// N, R and P are the scrypt parameters the encryption key is derived with
+	N, R, P int
+	Salt    []byte
+	// Nonce and Ciphertext are the XChaCha20-Poly1305 sealed JSON encoding of
+	// the KeyInfo
+	Nonce      []byte
+	Ciphertext []byte
+}
+
 // KeyStore is used for storing secret keys
 type KeyStore interface {
 	// List lists all the keys stored in the KeyStore
@@ -0,0 +1,69 @@
+// stm: #unit
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	block "github.com/ipfs/go-libipfs/blocks"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+type countingBlockstore struct {
+	blockstore.Blockstore
+	gets map[cid.Cid]int
+}
+
+func (bs *countingBlockstore) Get(ctx context.Context, c cid.Cid) (block.Block, error) {
+	bs.gets[c]++
+	return bs.Blockstore.Get(ctx, c)
+}
+
+func TestCopySharedSubtree(t *testing.T) {
+	ctx := context.Background()
+	from := &countingBlockstore{Blockstore: blockstore.NewMemory(), gets: map[cid.Cid]int{}}
+	to := blockstore.NewMemory()
+
+	put := func(obj interface{}) cid.Cid {
+		nd, err := cbor.WrapObject(obj, mh.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, from.Put(ctx, nd))
+		return nd.Cid()
+	}
+
+	leaf := put(map[string]interface{}{"leaf": 1})
+	shared := put(map[string]interface{}{"leaf": leaf})
+	a := put(map[string]interface{}{"a": shared})
+	b := put(map[string]interface{}{"b": shared, "again": shared})
+	root := put(map[string]interface{}{"a": a, "b": b})
+
+	// objects already in the destination aren't walked
+	present := put(map[string]interface{}{"present": 1})
+	require.NoError(t, to.Put(ctx, mustGet(t, from, present)))
+	top := put(map[string]interface{}{"root": root, "present": present})
+	from.gets = map[cid.Cid]int{}
+
+	require.NoError(t, Copy(ctx, from, to, top))
+
+	for _, c := range []cid.Cid{top, root, a, b, shared, leaf, present} {
+		has, err := to.Has(ctx, c)
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	// the shared subtree is only copied once
+	require.Equal(t, 1, from.gets[shared])
+	require.Equal(t, 1, from.gets[leaf])
+	require.Zero(t, from.gets[present])
+}
+
+func mustGet(t *testing.T, bs blockstore.Blockstore, c cid.Cid) block.Block {
+	blk, err := bs.Get(context.Background(), c)
+	require.NoError(t, err)
+	return blk
+}
@@ -762,7 +762,7 @@ func Copy(ctx context.Context, from, to blockstore.Blockstore, root cid.Cid) err
 		return nil
 	}
 
-	if err := copyRec(ctx, from, to, root, batchCp); err != nil {
+	if err := copyRec(ctx, from, to, root, batchCp, cid.NewSet()); err != nil {
 		return xerrors.Errorf("copyRec: %w", err)
 	}
 
@@ -787,7 +787,10 @@ func Copy(ctx context.Context, from, to blockstore.Blockstore, root cid.Cid) err
 	return nil
 }
 
-func copyRec(ctx context.Context, from, to blockstore.Blockstore, root cid.Cid, cp func(block.Block) error) error {
+// copyRec copies the DAG rooted at root, except for the subtrees already in to. The objects
+// visited, either copied or found in to, are added to visited, so that subtrees shared by
+// several objects are only checked and copied once.
+func copyRec(ctx context.Context, from, to blockstore.Blockstore, root cid.Cid, cp func(block.Block) error, visited *cid.Set) error {
 	if root.Prefix().MhType == 0 {
 		// identity cid, skip
 		return nil
@@ -798,15 +801,14 @@ func copyRec(ctx context.Context, from, to blockstore.Blockstore, root cid.Cid,
 		return xerrors.Errorf("get %s failed: %w", root, err)
 	}
 
-	var lerr error
+	var links, check []cid.Cid
 	err = linksForObj(blk, func(link cid.Cid) {
-		if lerr != nil {
-			// Theres no erorr return on linksForObj callback :(
+		prefix := link.Prefix()
+		if prefix.Codec == cid.FilCommitmentSealed || prefix.Codec == cid.FilCommitmentUnsealed {
 			return
 		}
 
-		prefix := link.Prefix()
-		if prefix.Codec == cid.FilCommitmentSealed || prefix.Codec == cid.FilCommitmentUnsealed {
+		if visited.Has(link) {
 			return
 		}
 
@@ -817,27 +819,37 @@ func copyRec(ctx context.Context, from, to blockstore.Blockstore, root cid.Cid,
 				return
 			}
 		} else {
-			// If we have an object, we already have its children, skip the object.
-			has, err := to.Has(ctx, link)
-			if err != nil {
-				lerr = xerrors.Errorf("has: %w", err)
-				return
-			}
-			if has {
-				return
-			}
+			check = append(check, link)
 		}
 
-		if err := copyRec(ctx, from, to, link, cp); err != nil {
-			lerr = err
-			return
-		}
+		links = append(links, link)
 	})
 	if err != nil {
 		return xerrors.Errorf("linksForObj (%x): %w", blk.RawData(), err)
 	}
-	if lerr != nil {
-		return lerr
+
+	// If we have an object, we already have its children, skip the object.
+	if len(check) > 0 {
+		has, err := to.HasMany(ctx, check)
+		if err != nil {
+			return xerrors.Errorf("has: %w", err)
+		}
+		for i, c := range check {
+			if has[i] {
+				visited.Add(c)
+			}
+		}
+	}
+
+	for _, link := range links {
+		// the link may have been copied as part of a previous sibling
+		if !visited.Visit(link) {
+			continue
+		}
+
+		if err := copyRec(ctx, from, to, link, cp, visited); err != nil {
+			return err
+		}
 	}
 
 	if err := cp(blk); err != nil {
@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const encryptedKeyInfoVersion = 1
+
+// scrypt parameters used when encrypting; they make deriving the key take
+// around a second and 256MiB of memory.
+const (
+	scryptN = 1 << 18
+	scryptR = 8
+	scryptP = 1
+)
+
+// maxScryptMem bounds the memory decrypting a key info may use, so that
+// crafted parameters can't exhaust it.
+const maxScryptMem = 1 << 30
+
+// EncryptKeyInfo encrypts a key info with a key derived from the password
+// with scrypt.
+func EncryptKeyInfo(ki *types.KeyInfo, password string) (*types.EncryptedKeyInfo, error) {
+	if password == "" {
+		return nil, xerrors.Errorf("empty password")
+	}
+
+	plain, err := json.Marshal(ki)
+	if err != nil {
+		return nil, xerrors.Errorf("marshaling key info: %w", err)
+	}
+
+	out := &types.EncryptedKeyInfo{
+		Version: encryptedKeyInfoVersion,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, 32),
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(out.Salt); err != nil {
+		return nil, xerrors.Errorf("generating salt: %w", err)
+	}
+	if _, err := rand.Read(out.Nonce); err != nil {
+		return nil, xerrors.Errorf("generating nonce: %w", err)
+	}
+
+	aead, err := encryptedKeyInfoAEAD(out, password)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Ciphertext = aead.Seal(nil, out.Nonce, plain, nil)
+	return out, nil
+}
+
+// DecryptKeyInfo decrypts a key info encrypted with EncryptKeyInfo.
+func DecryptKeyInfo(eki *types.EncryptedKeyInfo, password string) (*types.KeyInfo, error) {
+	if eki.Version != encryptedKeyInfoVersion {
+		return nil, xerrors.Errorf("unsupported encrypted key info version %d", eki.Version)
+	}
+	if eki.N <= 1 || eki.R <= 0 || eki.P <= 0 || uint64(128*eki.R)*uint64(eki.N+eki.P) > maxScryptMem {
+		return nil, xerrors.Errorf("invalid scrypt parameters N=%d, R=%d, P=%d", eki.N, eki.R, eki.P)
+	}
+	if len(eki.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, xerrors.Errorf("invalid nonce length %d", len(eki.Nonce))
+	}
+
+	aead, err := encryptedKeyInfoAEAD(eki, password)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := aead.Open(nil, eki.Nonce, eki.Ciphertext, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("decrypting key info (wrong password?): %w", err)
+	}
+
+	var ki types.KeyInfo
+	if err := json.Unmarshal(plain, &ki); err != nil {
+		return nil, xerrors.Errorf("unmarshaling key info: %w", err)
+	}
+
+	return &ki, nil
+}
+
+func encryptedKeyInfoAEAD(eki *types.EncryptedKeyInfo, password string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), eki.Salt, eki.N, eki.R, eki.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, xerrors.Errorf("deriving key: %w", err)
+	}
+
+	return chacha20poly1305.NewX(key)
+}
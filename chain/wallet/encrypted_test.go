@@ -0,0 +1,36 @@
+// stm: #unit
+package wallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestEncryptKeyInfo(t *testing.T) {
+	ki := &types.KeyInfo{
+		Type:       types.KTSecp256k1,
+		PrivateKey: []byte("not a very random private key.."),
+	}
+
+	eki, err := EncryptKeyInfo(ki, "hunter2")
+	require.NoError(t, err)
+	require.NotContains(t, string(eki.Ciphertext), string(ki.PrivateKey))
+
+	dki, err := DecryptKeyInfo(eki, "hunter2")
+	require.NoError(t, err)
+	require.Equal(t, ki, dki)
+
+	_, err = DecryptKeyInfo(eki, "hunter3")
+	require.Error(t, err)
+
+	_, err = EncryptKeyInfo(ki, "")
+	require.Error(t, err)
+
+	// parameters making scrypt use too much memory are rejected
+	eki.N = 1 << 30
+	_, err = DecryptKeyInfo(eki, "hunter2")
+	require.ErrorContains(t, err, "invalid scrypt parameters")
+}
@@ -0,0 +1,190 @@
+package ledgerwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// maxDisplayDepth bounds the nesting of rendered CBOR values; params nested
+// deeper are displayed as raw bytes.
+const maxDisplayDepth = 16
+
+// describeMessage renders a message in a human readable form, so that it can
+// be compared with what the ledger device displays before confirming it.
+// Method parameters are rendered as generic CBOR, with nested parameters
+// (e.g. the message proposed to a multisig) decoded in place.
+func describeMessage(msg *types.Message) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "To:         %s\n", msg.To)
+	fmt.Fprintf(&sb, "From:       %s\n", msg.From)
+	fmt.Fprintf(&sb, "Nonce:      %d\n", msg.Nonce)
+	fmt.Fprintf(&sb, "Value:      %s\n", types.FIL(msg.Value))
+	fmt.Fprintf(&sb, "GasLimit:   %d\n", msg.GasLimit)
+	fmt.Fprintf(&sb, "GasFeeCap:  %s\n", types.FIL(msg.GasFeeCap))
+	fmt.Fprintf(&sb, "GasPremium: %s\n", types.FIL(msg.GasPremium))
+	fmt.Fprintf(&sb, "Method:     %d", msg.Method)
+
+	if len(msg.Params) > 0 {
+		params, err := renderCBOR(msg.Params)
+		if err != nil {
+			params = "0x" + hex.EncodeToString(msg.Params)
+		}
+		fmt.Fprintf(&sb, "\nParams:     %s", params)
+	}
+
+	return sb.String()
+}
+
+// renderCBOR renders a single CBOR encoded value.
+func renderCBOR(b []byte) (string, error) {
+	br := bytes.NewReader(b)
+
+	var sb strings.Builder
+	if err := renderValue(&sb, cbg.NewCborReader(br), br, 0); err != nil {
+		return "", err
+	}
+	if br.Len() != 0 {
+		return "", xerrors.Errorf("%d trailing bytes after cbor value", br.Len())
+	}
+
+	return sb.String(), nil
+}
+
+func renderValue(sb *strings.Builder, cr *cbg.CborReader, br *bytes.Reader, depth int) error {
+	if depth > maxDisplayDepth {
+		return xerrors.Errorf("cbor value nested too deep")
+	}
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+
+	switch maj {
+	case cbg.MajUnsignedInt:
+		fmt.Fprintf(sb, "%d", extra)
+	case cbg.MajNegativeInt:
+		fmt.Fprintf(sb, "-%d", extra+1)
+	case cbg.MajByteString, cbg.MajTextString:
+		if extra > uint64(br.Len()) {
+			return xerrors.Errorf("string length %d exceeds the remaining %d bytes", extra, br.Len())
+		}
+		buf := make([]byte, extra)
+		if _, err := br.Read(buf); err != nil {
+			return err
+		}
+		if maj == cbg.MajTextString {
+			fmt.Fprintf(sb, "%q", buf)
+		} else {
+			sb.WriteString(renderBytes(buf, depth))
+		}
+	case cbg.MajArray, cbg.MajMap:
+		if extra > uint64(br.Len()) {
+			return xerrors.Errorf("%d items exceed the remaining %d bytes", extra, br.Len())
+		}
+		open, closing := "[", "]"
+		if maj == cbg.MajMap {
+			open, closing = "{", "}"
+		}
+		sb.WriteString(open)
+		for i := uint64(0); i < extra; i++ {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if err := renderValue(sb, cr, br, depth+1); err != nil {
+				return err
+			}
+			if maj == cbg.MajMap {
+				sb.WriteString(": ")
+				if err := renderValue(sb, cr, br, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		sb.WriteString(closing)
+	case cbg.MajTag:
+		if extra != 42 {
+			return xerrors.Errorf("unsupported cbor tag %d", extra)
+		}
+		maj, n, err := cr.ReadHeader()
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajByteString || n == 0 || n > uint64(br.Len()) {
+			return xerrors.Errorf("invalid cid link")
+		}
+		buf := make([]byte, n)
+		if _, err := br.Read(buf); err != nil {
+			return err
+		}
+		c, err := cid.Cast(buf[1:])
+		if err != nil {
+			return xerrors.Errorf("invalid cid link: %w", err)
+		}
+		sb.WriteString(c.String())
+	case cbg.MajOther:
+		switch extra {
+		case 20:
+			sb.WriteString("false")
+		case 21:
+			sb.WriteString("true")
+		case 22:
+			sb.WriteString("null")
+		default:
+			return xerrors.Errorf("unsupported cbor simple value %d", extra)
+		}
+	default:
+		return xerrors.Errorf("unknown cbor major type %d", maj)
+	}
+
+	return nil
+}
+
+// renderBytes renders a byte string, which in actor parameters is usually an
+// address, a big integer, or nested CBOR encoded parameters.
+func renderBytes(b []byte, depth int) string {
+	if len(b) == 0 {
+		return "0x"
+	}
+
+	// nested parameters are tuples, maps, or a single address
+	if maj := b[0] >> 5; maj == cbg.MajArray || maj == cbg.MajMap || maj == cbg.MajByteString {
+		br := bytes.NewReader(b)
+		var sb strings.Builder
+		if err := renderValue(&sb, cbg.NewCborReader(br), br, depth+1); err == nil && br.Len() == 0 {
+			return sb.String()
+		}
+	}
+
+	if addr, err := address.NewFromBytes(b); err == nil {
+		if addr.Protocol() != address.ID {
+			return addr.String()
+		}
+
+		// big integers are encoded as a sign byte followed by the magnitude,
+		// so small positive ones are also valid ID addresses
+		if bi, err := big.FromBytes(b); err == nil {
+			return fmt.Sprintf("%s (or %s)", addr, bi)
+		}
+		return addr.String()
+	}
+
+	if b[0] <= 1 {
+		if bi, err := big.FromBytes(b); err == nil {
+			return bi.String()
+		}
+	}
+
+	return "0x" + hex.EncodeToString(b)
+}
@@ -0,0 +1,52 @@
+package ledgerwallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v10/multisig"
+
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestDescribeMessage(t *testing.T) {
+	owner, err := address.NewSecp256k1Address([]byte("owner"))
+	require.NoError(t, err)
+	msig, err := address.NewIDAddress(1234)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+
+	inner, err := actors.SerializeParams(&owner)
+	require.NoError(t, err)
+
+	params, err := actors.SerializeParams(&multisig.ProposeParams{
+		To:     miner,
+		Value:  big.Zero(),
+		Method: builtin.MethodsMiner.ChangeOwnerAddress,
+		Params: inner,
+	})
+	require.NoError(t, err)
+
+	desc := describeMessage(&types.Message{
+		To:         msig,
+		From:       owner,
+		Value:      abi.NewTokenAmount(0),
+		GasFeeCap:  abi.NewTokenAmount(100),
+		GasPremium: abi.NewTokenAmount(10),
+		Method:     builtin.MethodsMultisig.Propose,
+		Params:     params,
+	})
+	require.Contains(t, desc, "Method:     2\n")
+	require.Contains(t, desc, "Params:     [f0100 (or 100), 0x, 23, "+owner.String()+"]")
+
+	// not cbor
+	_, err = renderCBOR([]byte{0xff})
+	require.Error(t, err)
+}
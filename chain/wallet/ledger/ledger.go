@@ -14,6 +14,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
 	"github.com/filecoin-project/go-state-types/crypto"
 
 	"github.com/filecoin-project/lotus/api"
@@ -44,33 +45,41 @@ func (lw LedgerWallet) WalletSign(ctx context.Context, signer address.Address, t
 		return nil, err
 	}
 
-	fl, err := ledgerfil.FindLedgerFilecoinApp()
-	if err != nil {
-		return nil, err
-	}
-	defer fl.Close() // nolint:errcheck
 	if meta.Type != api.MTChainMsg {
 		return nil, fmt.Errorf("ledger can only sign chain messages")
 	}
 
-	{
-		var cmsg types.Message
-		if err := cmsg.UnmarshalCBOR(bytes.NewReader(meta.Extra)); err != nil {
-			return nil, xerrors.Errorf("unmarshalling message: %w", err)
-		}
+	var cmsg types.Message
+	if err := cmsg.UnmarshalCBOR(bytes.NewReader(meta.Extra)); err != nil {
+		return nil, xerrors.Errorf("unmarshalling message: %w", err)
+	}
 
-		_, bc, err := cid.CidFromBytes(toSign)
-		if err != nil {
-			return nil, xerrors.Errorf("getting cid from signing bytes: %w", err)
-		}
+	_, bc, err := cid.CidFromBytes(toSign)
+	if err != nil {
+		return nil, xerrors.Errorf("getting cid from signing bytes: %w", err)
+	}
 
-		if !cmsg.Cid().Equals(bc) {
-			return nil, xerrors.Errorf("cid(meta.Extra).bytes() != toSign")
-		}
+	if !cmsg.Cid().Equals(bc) {
+		return nil, xerrors.Errorf("cid(meta.Extra).bytes() != toSign")
 	}
 
+	fl, err := ledgerfil.FindLedgerFilecoinApp()
+	if err != nil {
+		return nil, err
+	}
+	defer fl.Close() // nolint:errcheck
+
+	// The device displays the message decoded from the same CBOR bytes that
+	// are signed, but may show the params of non-send methods (multisig
+	// proposals, miner owner and worker changes, ...) only as raw bytes, so
+	// print them decoded for the user to check against.
+	log.Warnf("signing message %s, review and accept it on the ledger device:\n%s", bc, describeMessage(&cmsg))
+
 	sig, err := fl.SignSECP256K1(ki.Path, meta.Extra)
 	if err != nil {
+		if cmsg.Method != builtin.MethodSend {
+			return nil, xerrors.Errorf("ledger didn't sign method %d call to %s (the Filecoin app may need expert mode enabled for non-send methods): %w", cmsg.Method, cmsg.To, err)
+		}
 		return nil, err
 	}
 
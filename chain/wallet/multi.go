@@ -120,6 +120,11 @@ func (m MultiWallet) WalletSign(ctx context.Context, signer address.Address, toS
 		return nil, err
 	}
 	if w == nil {
+		if m.Local != nil {
+			if watched, err := m.Local.WatchedHas(signer); err == nil && watched {
+				return nil, xerrors.Errorf("can't sign with %s: %w", signer, ErrWatchOnly)
+			}
+		}
 		return nil, xerrors.Errorf("key not found for %s", signer)
 	}
 
@@ -2,10 +2,17 @@ package remotewallet
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
 
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-jsonrpc"
+
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/client"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
@@ -16,16 +23,82 @@ type RemoteWallet struct {
 	api.Wallet
 }
 
-func SetupRemoteWallet(info string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (*RemoteWallet, error) {
+// TLSConfig holds the paths of the PEM encoded files used to connect to the
+// remote wallet over TLS.
+type TLSConfig struct {
+	// Cert and Key are the client certificate and key presented to the remote
+	// wallet; setting them enables mutual TLS
+	Cert, Key string
+	// CA is the certificate the remote wallet certificate is verified against;
+	// the system roots are used if it's empty
+	CA string
+}
+
+func (c TLSConfig) enabled() bool {
+	return c.Cert != "" || c.Key != "" || c.CA != ""
+}
+
+func (c TLSConfig) load() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.Cert != "" || c.Key != "" {
+		if c.Cert == "" || c.Key == "" {
+			return nil, xerrors.Errorf("both the client certificate and key must be set")
+		}
+
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, xerrors.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CA != "" {
+		pem, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, xerrors.Errorf("reading CA certificate: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, xerrors.Errorf("no certificates found in %s", c.CA)
+		}
+	}
+
+	return cfg, nil
+}
+
+func SetupRemoteWallet(info string, tlsCfg TLSConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (*RemoteWallet, error) {
 	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle) (*RemoteWallet, error) {
 		ai := cliutil.ParseApiInfo(info)
 
-		url, err := ai.DialArgs("v0")
+		addr, err := ai.DialArgs("v0")
 		if err != nil {
 			return nil, err
 		}
 
-		wapi, closer, err := client.NewWalletRPCV0(mctx, url, ai.AuthHeader())
+		var opts []jsonrpc.Option
+		if tlsCfg.enabled() {
+			cfg, err := tlsCfg.load()
+			if err != nil {
+				return nil, xerrors.Errorf("remote wallet tls: %w", err)
+			}
+
+			// the http client is only used for http(s) addresses, so talk
+			// https to the wallet instead of websockets
+			u, err := url.Parse(addr)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing remote wallet address: %w", err)
+			}
+			u.Scheme = "https"
+			addr = u.String()
+
+			opts = append(opts, jsonrpc.WithHTTPClient(&http.Client{
+				Transport: &http.Transport{TLSClientConfig: cfg},
+			}))
+		}
+
+		wapi, closer, err := client.NewWalletRPCV0(mctx, addr, ai.AuthHeader(), opts...)
 		if err != nil {
 			return nil, xerrors.Errorf("creating jsonrpc client: %w", err)
 		}
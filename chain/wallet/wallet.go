@@ -67,6 +67,9 @@ func (w *LocalWallet) WalletSign(ctx context.Context, addr address.Address, msg
 		return nil, err
 	}
 	if ki == nil {
+		if watched, err := w.WatchedHas(addr); err == nil && watched {
+			return nil, xerrors.Errorf("signing using key '%s': %w", addr.String(), ErrWatchOnly)
+		}
 		return nil, xerrors.Errorf("signing using key '%s': %w", addr.String(), types.ErrKeyInfoNotFound)
 	}
 
@@ -160,6 +163,11 @@ func (w *LocalWallet) WalletImport(ctx context.Context, ki *types.KeyInfo) (addr
 		return address.Undef, xerrors.Errorf("saving to keystore: %w", err)
 	}
 
+	// the address is no longer watch-only now that we have its key
+	if err := w.keystore.Delete(KWatchPrefix + k.Address.String()); err != nil && !xerrors.Is(err, types.ErrKeyInfoNotFound) {
+		log.Warnf("failed to remove watch-only entry for %s: %s", k.Address, err)
+	}
+
 	return k.Address, nil
 }
 
@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const KWatchPrefix = "watch-"
+
+// ErrWatchOnly is returned when trying to sign with an address which is only
+// watched by the wallet, and so has no private key.
+var ErrWatchOnly = xerrors.New("address is watch-only")
+
+// Watched tracks addresses in the wallet which don't have private keys.
+// Watched addresses are listed alongside the wallet keys, but can't be used
+// for signing.
+type Watched interface {
+	WatchedList() ([]address.Address, error)
+	WatchedHas(a address.Address) (bool, error)
+	AddWatched(a address.Address) error
+	RemoveWatched(a address.Address) error
+}
+
+func (w *LocalWallet) WatchedList() ([]address.Address, error) {
+	if w.keystore == nil {
+		return []address.Address{}, nil
+	}
+
+	all, err := w.keystore.List()
+	if err != nil {
+		return nil, xerrors.Errorf("listing keystore: %w", err)
+	}
+
+	out := make([]address.Address, 0)
+	for _, a := range all {
+		if !strings.HasPrefix(a, KWatchPrefix) {
+			continue
+		}
+		addr, err := address.NewFromString(strings.TrimPrefix(a, KWatchPrefix))
+		if err != nil {
+			return nil, xerrors.Errorf("converting name to address: %w", err)
+		}
+		out = append(out, addr)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+
+	return out, nil
+}
+
+func (w *LocalWallet) WatchedHas(a address.Address) (bool, error) {
+	if w.keystore == nil {
+		return false, nil
+	}
+
+	_, err := w.keystore.Get(KWatchPrefix + a.String())
+	if err != nil {
+		if xerrors.Is(err, types.ErrKeyInfoNotFound) {
+			return false, nil
+		}
+		return false, xerrors.Errorf("getting from keystore: %w", err)
+	}
+
+	return true, nil
+}
+
+func (w *LocalWallet) AddWatched(a address.Address) error {
+	if a == address.Undef {
+		return xerrors.Errorf("can't watch an undefined address")
+	}
+
+	has, err := w.WalletHas(context.TODO(), a)
+	if err != nil {
+		return err
+	}
+	if has {
+		return xerrors.Errorf("the key for %s is already in the wallet", a)
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if err := w.keystore.Put(KWatchPrefix+a.String(), types.KeyInfo{}); err != nil && !xerrors.Is(err, types.ErrKeyExists) {
+		return xerrors.Errorf("saving to keystore: %w", err)
+	}
+
+	return nil
+}
+
+func (w *LocalWallet) RemoveWatched(a address.Address) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if err := w.keystore.Delete(KWatchPrefix + a.String()); err != nil && !xerrors.Is(err, types.ErrKeyInfoNotFound) {
+		return xerrors.Errorf("deleting from keystore: %w", err)
+	}
+
+	return nil
+}
+
+type nilWatched struct{}
+
+func (n nilWatched) WatchedList() ([]address.Address, error) {
+	return []address.Address{}, nil
+}
+
+func (n nilWatched) WatchedHas(a address.Address) (bool, error) {
+	return false, nil
+}
+
+func (n nilWatched) AddWatched(a address.Address) error {
+	return xerrors.Errorf("not supported; local wallet disabled")
+}
+
+func (n nilWatched) RemoveWatched(a address.Address) error {
+	return xerrors.Errorf("not supported; local wallet disabled")
+}
+
+var NilWatched nilWatched
+var _ Watched = NilWatched
+var _ Watched = &LocalWallet{}
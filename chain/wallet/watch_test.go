@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+)
+
+func TestWatched(t *testing.T) {
+	ctx := context.Background()
+
+	w, err := NewWallet(NewMemKeyStore())
+	require.NoError(t, err)
+
+	own, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+	watched := k.Address
+
+	require.NoError(t, w.AddWatched(watched))
+	require.NoError(t, w.AddWatched(watched))
+	require.Error(t, w.AddWatched(own), "keys in the wallet can't be watched")
+	require.Error(t, w.AddWatched(address.Undef))
+
+	has, err := w.WatchedHas(watched)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	list, err := w.WatchedList()
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{watched}, list)
+
+	// watched addresses aren't wallet keys
+	addrs, err := w.WalletList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{own}, addrs)
+
+	has, err = w.WalletHas(ctx, watched)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	_, err = w.WalletSign(ctx, watched, []byte("msg"), api.MsgMeta{})
+	require.True(t, xerrors.Is(err, ErrWatchOnly), err)
+
+	mw := MultiWallet{Local: w}
+	_, err = mw.WalletSign(ctx, watched, []byte("msg"), api.MsgMeta{})
+	require.True(t, xerrors.Is(err, ErrWatchOnly), err)
+
+	// importing the key turns the address into a regular one
+	_, err = w.WalletImport(ctx, &k.KeyInfo)
+	require.NoError(t, err)
+
+	list, err = w.WatchedList()
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	_, err = w.WalletSign(ctx, watched, []byte("msg"), api.MsgMeta{})
+	require.NoError(t, err)
+
+	other, err := key.GenerateKey(types.KTBLS)
+	require.NoError(t, err)
+	require.NoError(t, w.AddWatched(other.Address))
+	require.NoError(t, w.RemoveWatched(other.Address))
+	require.NoError(t, w.RemoveWatched(other.Address))
+
+	has, err = w.WatchedHas(other.Address)
+	require.NoError(t, err)
+	require.False(t, has)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
@@ -51,6 +52,16 @@ func BackupCmd(repoFlag string, rt repo.RepoType, getApi BackupApiFn) *cli.Comma
 			return xerrors.Errorf("getting metadata datastore: %w", err)
 		}
 
+		var extra []datastore.Read
+		if rt == repo.FullNode {
+			// locally published messages of a full node
+			lds, err := lr.Datastore(context.TODO(), "/mpool")
+			if err != nil {
+				return xerrors.Errorf("getting mpool datastore: %w", err)
+			}
+			extra = append(extra, lds)
+		}
+
 		bds, err := backupds.Wrap(mds, backupds.NoLogdir)
 		if err != nil {
 			return err
@@ -70,7 +81,7 @@ func BackupCmd(repoFlag string, rt repo.RepoType, getApi BackupApiFn) *cli.Comma
 			return xerrors.Errorf("opening backup file %s: %w", fpath, err)
 		}
 
-		if err := bds.Backup(cctx.Context, out); err != nil {
+		if err := bds.Backup(cctx.Context, out, extra...); err != nil {
 			if cerr := out.Close(); cerr != nil {
 				log.Errorw("error closing backup file while handling backup error", "closeErr", cerr, "backupErr", err)
 			}
@@ -1596,6 +1596,7 @@ var ChainPruneCmd = &cli.Command{
 		chainPruneColdCmd,
 		chainPruneHotGCCmd,
 		chainPruneHotMovingGCCmd,
+		chainPruneColdDedupCmd,
 	},
 }
 
@@ -1646,6 +1647,36 @@ var chainPruneHotMovingGCCmd = &cli.Command{
 	},
 }
 
+var chainPruneColdDedupCmd = &cli.Command{
+	Name:  "cold-dedup",
+	Usage: "rewrite the coldstore without duplicate objects and report the space reclaimed",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		start := time.Now()
+		res, err := api.ChainColdDedup(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Coldstore deduplication took %v\n", time.Since(start))
+		fmt.Printf("Objects: %d\n", res.Objects)
+		fmt.Printf("Duplicates: %d (%s)\n", res.Duplicates, types.SizeStr(types.NewInt(uint64(res.DuplicateSize))))
+		fmt.Printf("Size: %s -> %s\n", types.SizeStr(types.NewInt(uint64(res.SizeBefore))), types.SizeStr(types.NewInt(uint64(res.SizeAfter))))
+		if reclaimed := res.SizeBefore - res.SizeAfter; reclaimed > 0 {
+			fmt.Printf("Reclaimed: %s\n", types.SizeStr(types.NewInt(uint64(reclaimed))))
+		} else {
+			fmt.Println("Reclaimed: 0")
+		}
+		return nil
+	},
+}
+
 var chainPruneColdCmd = &cli.Command{
 	Name:  "compact-cold",
 	Usage: "force splitstore compaction on cold store state and run gc",
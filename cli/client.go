@@ -96,6 +96,9 @@ var clientCmd = &cli.Command{
 		WithCategory("retrieval", clientRetrieveLsCmd),
 		WithCategory("retrieval", clientCancelRetrievalDealCmd),
 		WithCategory("retrieval", clientListRetrievalsCmd),
+		WithCategory("retrieval", clientListResumableRetrievalsCmd),
+		WithCategory("retrieval", clientResumeRetrievalCmd),
+		WithCategory("retrieval", clientRetrievalStatsCmd),
 		WithCategory("util", clientCommPCmd),
 		WithCategory("util", clientCarGenCmd),
 		WithCategory("util", clientBalancesCmd),
@@ -348,7 +351,7 @@ The minimum value is 518400 (6 months).`,
 		&cli.BoolFlag{
 			Name:        "verified-deal",
 			Usage:       "indicate that the deal counts towards verified client total",
-			DefaultText: "true if client is verified, false otherwise",
+			DefaultText: "true if client is verified and has enough DataCap for the deal, false otherwise",
 		},
 		&cli.StringFlag{
 			Name:  "provider-collateral",
@@ -476,6 +479,28 @@ The minimum value is 518400 (6 months).`,
 			isVerified = verifiedDealParam
 		}
 
+		// A verified deal needs enough DataCap for the whole piece
+		if isVerified {
+			pieceSize := ref.PieceSize.Padded()
+			if ref.TransferType != storagemarket.TTManual {
+				ds, err := api.ClientDealPieceCID(ctx, data)
+				if err != nil {
+					return xerrors.Errorf("computing piece size: %w", err)
+				}
+				pieceSize = ds.PieceSize
+			}
+
+			if dcap.LessThan(types.NewInt(uint64(pieceSize))) {
+				if cctx.IsSet("verified-deal") {
+					return xerrors.Errorf("address %s has %s of DataCap, the deal needs %s", a, types.SizeStr(*dcap), types.SizeStr(types.NewInt(uint64(pieceSize))))
+				}
+
+				_, _ = fmt.Fprintf(cctx.App.ErrWriter, "Not enough DataCap for a verified deal (%s available, %s needed), making an unverified deal\n",
+					types.SizeStr(*dcap), types.SizeStr(types.NewInt(uint64(pieceSize))))
+				isVerified = false
+			}
+		}
+
 		sdParams := &lapi.StartDealParams{
 			Data:               ref,
 			Wallet:             a,
@@ -2214,6 +2239,189 @@ var clientCancelRetrievalDealCmd = &cli.Command{
 	},
 }
 
+var clientListResumableRetrievalsCmd = &cli.Command{
+	Name:  "list-resumable-retrievals",
+	Usage: "List retrieval deals which didn't complete yet, and whether they were interrupted",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "interrupted",
+			Usage: "only show interrupted retrievals",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		retrievals, err := api.ClientListResumableRetrievals(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tablewriter.New(tablewriter.Col("DealId"),
+			tablewriter.Col("Provider"),
+			tablewriter.Col("PayloadCID"),
+			tablewriter.Col("Status"),
+			tablewriter.Col("Received"),
+			tablewriter.Col("Blocks"),
+			tablewriter.Col("LastProgress"),
+			tablewriter.Col("Interrupted"),
+			tablewriter.NewLineCol("Message"))
+		for _, r := range retrievals {
+			if cctx.Bool("interrupted") && !r.Interrupted {
+				continue
+			}
+			w.Write(map[string]interface{}{
+				"DealId":       r.ID,
+				"Provider":     r.Provider,
+				"PayloadCID":   r.PayloadCID,
+				"Status":       retrievalStatusString(r.Status),
+				"Received":     types.SizeStr(types.NewInt(r.BytesReceived)),
+				"Blocks":       r.BlocksReceived,
+				"LastProgress": time.Since(r.LastProgress).Truncate(time.Second).String() + " ago",
+				"Interrupted":  r.Interrupted,
+				"Message":      r.Message,
+			})
+		}
+		return w.Flush(cctx.App.Writer)
+	},
+}
+
+var clientResumeRetrievalCmd = &cli.Command{
+	Name:  "resume-retrieval",
+	Usage: "Resume an interrupted retrieval deal from the last verified block received",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:     "deal-id",
+			Usage:    "specify retrieval deal by deal ID",
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		id := cctx.Int64("deal-id")
+		if id < 0 {
+			return errors.New("deal id cannot be negative")
+		}
+
+		if err := api.ClientResumeRetrieval(ctx, retrievalmarket.DealID(id)); err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Resumed retrieval deal %d, follow its progress with 'lotus client list-retrievals --watch'\n", id)
+		return nil
+	},
+}
+
+var clientRetrievalStatsCmd = &cli.Command{
+	Name:      "retrieval-stats",
+	Usage:     "Show the success rate, throughput and price of past retrievals per provider",
+	ArgsUsage: "[minerAddress]",
+	Description: `Summarize the retrieval deals made with each provider, to help pick providers to retrieve from.
+
+   With a miner address, list the retrieval deals made with that miner instead.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		since := func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return time.Since(t).Truncate(time.Second).String() + " ago"
+		}
+
+		if cctx.Args().Present() {
+			miner, err := address.NewFromString(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing miner address: %w", err)
+			}
+
+			attempts, err := api.ClientRetrievalHistory(ctx, miner)
+			if err != nil {
+				return err
+			}
+
+			w := tablewriter.New(tablewriter.Col("DealId"),
+				tablewriter.Col("PayloadCID"),
+				tablewriter.Col("Status"),
+				tablewriter.Col("Received"),
+				tablewriter.Col("Duration"),
+				tablewriter.Col("Paid"),
+				tablewriter.Col("Ended"),
+				tablewriter.NewLineCol("Message"))
+			for _, a := range attempts {
+				var duration string
+				if !a.Started.IsZero() && !a.Ended.IsZero() {
+					duration = a.Ended.Sub(a.Started).Truncate(time.Second).String()
+				}
+				w.Write(map[string]interface{}{
+					"DealId":     a.ID,
+					"PayloadCID": a.PayloadCID,
+					"Status":     retrievalStatusString(a.Status),
+					"Received":   types.SizeStr(types.NewInt(a.BytesReceived)),
+					"Duration":   duration,
+					"Paid":       types.FIL(a.Paid),
+					"Ended":      since(a.Ended),
+					"Message":    a.Message,
+				})
+			}
+			return w.Flush(cctx.App.Writer)
+		}
+
+		stats, err := api.ClientRetrievalProviderStats(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tablewriter.New(tablewriter.Col("Miner"),
+			tablewriter.Col("Provider"),
+			tablewriter.Col("Retrievals"),
+			tablewriter.Col("Success"),
+			tablewriter.Col("Received"),
+			tablewriter.Col("Throughput"),
+			tablewriter.Col("Paid"),
+			tablewriter.Col("Last"),
+			tablewriter.NewLineCol("LastError"))
+		for _, s := range stats {
+			miner := "-"
+			if s.Miner != address.Undef {
+				miner = s.Miner.String()
+			}
+			w.Write(map[string]interface{}{
+				"Miner":      miner,
+				"Provider":   s.Provider,
+				"Retrievals": s.Attempts,
+				"Success":    fmt.Sprintf("%.1f%%", 100*float64(s.Successes)/float64(s.Attempts)),
+				"Received":   types.SizeStr(types.NewInt(s.BytesReceived)),
+				"Throughput": types.SizeStr(types.NewInt(uint64(s.Throughput))) + "/s",
+				"Paid":       types.FIL(s.Paid),
+				"Last":       since(s.LastAttempt),
+				"LastError":  s.LastError,
+			})
+		}
+		return w.Flush(cctx.App.Writer)
+	},
+}
+
 var clientListTransfers = &cli.Command{
 	Name:  "list-transfers",
 	Usage: "List ongoing data transfers for deals",
@@ -41,13 +41,7 @@ import (
 const DefaultMaxRetrievePrice = "0"
 
 func retrieve(ctx context.Context, cctx *cli.Context, fapi lapi.FullNode, sel *lapi.Selector, printf func(string, ...interface{})) (*lapi.ExportRef, error) {
-	var payer address.Address
-	var err error
-	if cctx.String("from") != "" {
-		payer, err = address.NewFromString(cctx.String("from"))
-	} else {
-		payer, err = fapi.WalletDefaultAddress(ctx)
-	}
+	payer, err := retrievalPayer(ctx, cctx, fapi)
 	if err != nil {
 		return nil, err
 	}
@@ -129,83 +123,109 @@ func retrieve(ctx context.Context, cctx *cli.Context, fapi lapi.FullNode, sel *l
 			return nil, fmt.Errorf("offer error: %s", offer.Err)
 		}
 
-		maxPrice := types.MustParseFIL(DefaultMaxRetrievePrice)
-
-		if cctx.String("maxPrice") != "" {
-			maxPrice, err = types.ParseFIL(cctx.String("maxPrice"))
-			if err != nil {
-				return nil, xerrors.Errorf("parsing maxPrice: %w", err)
-			}
+		maxPrice, err := retrievalMaxPrice(cctx)
+		if err != nil {
+			return nil, err
 		}
 
 		if offer.MinPrice.GreaterThan(big.Int(maxPrice)) {
 			return nil, xerrors.Errorf("failed to find offer satisfying maxPrice: %s. Try increasing maxPrice", maxPrice)
 		}
 
-		o := offer.Order(payer)
-		o.DataSelector = sel
-
-		subscribeEvents, err := fapi.ClientGetRetrievalUpdates(ctx)
+		dealID, err := retrieveOffer(ctx, fapi, offer, payer, sel, printf)
 		if err != nil {
-			return nil, xerrors.Errorf("error setting up retrieval updates: %w", err)
+			return nil, err
 		}
-		retrievalRes, err := fapi.ClientRetrieve(ctx, o)
-		if err != nil {
-			return nil, xerrors.Errorf("error setting up retrieval: %w", err)
-		}
-
-		start := time.Now()
-	readEvents:
-		for {
-			var evt lapi.RetrievalInfo
-			select {
-			case <-ctx.Done():
-				return nil, xerrors.New("Retrieval Timed Out")
-			case evt = <-subscribeEvents:
-				if evt.ID != retrievalRes.DealID {
-					// we can't check the deal ID ahead of time because:
-					// 1. We need to subscribe before retrieving.
-					// 2. We won't know the deal ID until after retrieving.
-					continue
-				}
-			}
 
-			event := "New"
-			if evt.Event != nil {
-				event = retrievalmarket.ClientEvents[*evt.Event]
-			}
+		eref = &lapi.ExportRef{
+			Root:   file,
+			DealID: dealID,
+		}
+	}
+
+	return eref, nil
+}
+
+func retrievalPayer(ctx context.Context, cctx *cli.Context, fapi lapi.FullNode) (address.Address, error) {
+	if cctx.String("from") != "" {
+		return address.NewFromString(cctx.String("from"))
+	}
+	return fapi.WalletDefaultAddress(ctx)
+}
+
+func retrievalMaxPrice(cctx *cli.Context) (types.FIL, error) {
+	if cctx.String("maxPrice") == "" {
+		return types.MustParseFIL(DefaultMaxRetrievePrice), nil
+	}
+
+	maxPrice, err := types.ParseFIL(cctx.String("maxPrice"))
+	if err != nil {
+		return types.FIL{}, xerrors.Errorf("parsing maxPrice: %w", err)
+	}
+	return maxPrice, nil
+}
 
-			printf("Recv %s, Paid %s, %s (%s), %s [%d|%d]\n",
-				types.SizeStr(types.NewInt(evt.BytesReceived)),
-				types.FIL(evt.TotalPaid),
-				strings.TrimPrefix(event, "ClientEvent"),
-				strings.TrimPrefix(retrievalmarket.DealStatuses[evt.Status], "DealStatus"),
-				time.Now().Sub(start).Truncate(time.Millisecond),
-				evt.ID,
-				types.NewInt(evt.BytesReceived),
-			)
-
-			switch evt.Status {
-			case retrievalmarket.DealStatusCompleted:
-				break readEvents
-			case retrievalmarket.DealStatusRejected:
-				return nil, xerrors.Errorf("Retrieval Proposal Rejected: %s", evt.Message)
-			case retrievalmarket.DealStatusCancelled:
-				return nil, xerrors.Errorf("Retrieval Proposal Cancelled: %s", evt.Message)
-			case
-				retrievalmarket.DealStatusDealNotFound,
-				retrievalmarket.DealStatusErrored:
-				return nil, xerrors.Errorf("Retrieval Error: %s", evt.Message)
+// retrieveOffer makes a retrieval deal for the offer, and waits for it to
+// complete.
+func retrieveOffer(ctx context.Context, fapi lapi.FullNode, offer lapi.QueryOffer, payer address.Address, sel *lapi.Selector, printf func(string, ...interface{})) (retrievalmarket.DealID, error) {
+	o := offer.Order(payer)
+	o.DataSelector = sel
+
+	subscribeEvents, err := fapi.ClientGetRetrievalUpdates(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("error setting up retrieval updates: %w", err)
+	}
+	retrievalRes, err := fapi.ClientRetrieve(ctx, o)
+	if err != nil {
+		return 0, xerrors.Errorf("error setting up retrieval: %w", err)
+	}
+
+	start := time.Now()
+readEvents:
+	for {
+		var evt lapi.RetrievalInfo
+		select {
+		case <-ctx.Done():
+			return 0, xerrors.New("Retrieval Timed Out")
+		case evt = <-subscribeEvents:
+			if evt.ID != retrievalRes.DealID {
+				// we can't check the deal ID ahead of time because:
+				// 1. We need to subscribe before retrieving.
+				// 2. We won't know the deal ID until after retrieving.
+				continue
 			}
 		}
 
-		eref = &lapi.ExportRef{
-			Root:   file,
-			DealID: retrievalRes.DealID,
+		event := "New"
+		if evt.Event != nil {
+			event = retrievalmarket.ClientEvents[*evt.Event]
+		}
+
+		printf("Recv %s, Paid %s, %s (%s), %s [%d|%d]\n",
+			types.SizeStr(types.NewInt(evt.BytesReceived)),
+			types.FIL(evt.TotalPaid),
+			strings.TrimPrefix(event, "ClientEvent"),
+			strings.TrimPrefix(retrievalmarket.DealStatuses[evt.Status], "DealStatus"),
+			time.Now().Sub(start).Truncate(time.Millisecond),
+			evt.ID,
+			types.NewInt(evt.BytesReceived),
+		)
+
+		switch evt.Status {
+		case retrievalmarket.DealStatusCompleted:
+			break readEvents
+		case retrievalmarket.DealStatusRejected:
+			return 0, xerrors.Errorf("Retrieval Proposal Rejected: %s", evt.Message)
+		case retrievalmarket.DealStatusCancelled:
+			return 0, xerrors.Errorf("Retrieval Proposal Cancelled: %s", evt.Message)
+		case
+			retrievalmarket.DealStatusDealNotFound,
+			retrievalmarket.DealStatusErrored:
+			return 0, xerrors.Errorf("Retrieval Error: %s", evt.Message)
 		}
 	}
 
-	return eref, nil
+	return retrievalRes.DealID, nil
 }
 
 var retrFlagsCommon = []cli.Flag{
@@ -246,6 +266,10 @@ By default the data will be interpreted as DAG-PB UnixFSv1 File. Alternatively
 a CAR file containing the raw IPLD graph can be exported by setting the --car
 flag.
 
+With --car-v2 the CAR file is written as a CARv2 file with an index of its
+blocks, which can be served or used in storage deals as is, without
+re-chunking the data.
+
 Partial Retrieval:
 
 The --data-selector flag can be used to specify a sub-graph to fetch. The
@@ -267,6 +291,16 @@ Examples:
 
 - Retrieve a first file from a specified directory
 	$ lotus client retrieve --data-selector /Links/0/Hash Qm... my-file.txt
+
+Parallel Retrieval:
+
+With the --parallel flag, the parts of a UnixFS file are retrieved in parallel
+from up to the given number of providers offering the file, and written to the
+output path on the local machine. Parts failing to retrieve from one provider
+are retried with the others.
+
+- Retrieve a file from up to 4 providers
+	$ lotus client retrieve --parallel 4 Qm... my-file.txt
 `,
 	Flags: append([]cli.Flag{
 		&cli.BoolFlag{
@@ -278,10 +312,18 @@ Examples:
 			Aliases: []string{"datamodel-path-selector"},
 			Usage:   "IPLD datamodel text-path selector, or IPLD json selector",
 		},
+		&cli.BoolFlag{
+			Name:  "car-v2",
+			Usage: "(requires --car) Export to a CARv2 file with an index of its blocks",
+		},
 		&cli.BoolFlag{
 			Name:  "car-export-merkle-proof",
 			Usage: "(requires --data-selector and --car) Export data-selector merkle proof",
 		},
+		&cli.IntFlag{
+			Name:  "parallel",
+			Usage: "split the retrieval of a UnixFS file across up to this many providers, fetching its parts in parallel",
+		},
 	}, retrFlagsCommon...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 2 {
@@ -294,6 +336,18 @@ Examples:
 			}
 		}
 
+		if cctx.Bool("car-v2") && !cctx.Bool("car") {
+			return ShowHelp(cctx, fmt.Errorf("--car-v2 requires --car"))
+		}
+
+		if cctx.Int("parallel") > 1 {
+			for _, f := range []string{"car", "data-selector", "provider", "allow-local"} {
+				if cctx.IsSet(f) {
+					return ShowHelp(cctx, fmt.Errorf("--parallel can't be used with --%s", f))
+				}
+			}
+		}
+
 		fapi, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
@@ -302,6 +356,19 @@ Examples:
 		ctx := ReqContext(cctx)
 		afmt := NewAppFmt(cctx.App)
 
+		if cctx.Int("parallel") > 1 {
+			ainfo, err := GetAPIInfo(cctx, repo.FullNode)
+			if err != nil {
+				return xerrors.Errorf("could not get API info: %w", err)
+			}
+
+			if err := retrieveParallel(ctx, cctx, fapi, ainfo, cctx.Int("parallel"), afmt.Printf); err != nil {
+				return err
+			}
+			afmt.Println("Success")
+			return nil
+		}
+
 		var s *lapi.Selector
 		if sel := lapi.Selector(cctx.String("data-selector")); sel != "" {
 			s = &sel
@@ -322,6 +389,7 @@ Examples:
 		err = fapi.ClientExport(ctx, *eref, lapi.FileRef{
 			Path:  cctx.Args().Get(1),
 			IsCAR: cctx.Bool("car"),
+			CARv2: cctx.Bool("car-v2"),
 		})
 		if err != nil {
 			return err
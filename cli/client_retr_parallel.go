@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
+	unixfspb "github.com/ipfs/go-unixfs/pb"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
+)
+
+// rootBlockSelector matches only the root block of a DAG.
+const rootBlockSelector = lapi.Selector(`{".":{}}`)
+
+// parallelChunk is a part of a UnixFS file, retrieved separately from the
+// other parts.
+type parallelChunk struct {
+	Root   cid.Cid
+	Offset uint64
+	Size   uint64
+}
+
+// parallelChunks splits the UnixFS file with the given root block into the
+// subtrees linked from the root. The data inlined in the root block, which
+// precedes the data of the subtrees, is returned separately.
+func parallelChunks(root cid.Cid, raw []byte) ([]byte, []parallelChunk, error) {
+	switch root.Prefix().Codec {
+	case cid.Raw:
+		return raw, nil, nil
+	case cid.DagProtobuf:
+	default:
+		return nil, nil, xerrors.Errorf("parallel retrieval only supports UnixFS files, got codec 0x%x", root.Prefix().Codec)
+	}
+
+	pn, err := merkledag.DecodeProtobuf(raw)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("decoding root block: %w", err)
+	}
+	fsn, err := unixfs.FSNodeFromBytes(pn.Data())
+	if err != nil {
+		return nil, nil, xerrors.Errorf("decoding UnixFS root node: %w", err)
+	}
+	if fsn.Type() != unixfspb.Data_File && fsn.Type() != unixfspb.Data_Raw {
+		return nil, nil, xerrors.Errorf("parallel retrieval only supports UnixFS files, got %s", fsn.Type())
+	}
+
+	links := pn.Links()
+	if len(links) != fsn.NumChildren() {
+		return nil, nil, xerrors.Errorf("root block has %d links, but %d block sizes", len(links), fsn.NumChildren())
+	}
+
+	offset := uint64(len(fsn.Data()))
+	chunks := make([]parallelChunk, len(links))
+	for i, l := range links {
+		chunks[i] = parallelChunk{
+			Root:   l.Cid,
+			Offset: offset,
+			Size:   fsn.BlockSize(i),
+		}
+		offset += fsn.BlockSize(i)
+	}
+
+	return fsn.Data(), chunks, nil
+}
+
+// retrieveParallel retrieves the UnixFS file under the data CID into the output
+// path. The subtrees linked from the root of the file are retrieved in parallel
+// from up to the given number of providers offering the file, and written at
+// their offset in the output file.
+func retrieveParallel(ctx context.Context, cctx *cli.Context, fapi lapi.FullNode, ainfo cliutil.APIInfo, parallel int, printf func(string, ...interface{})) error {
+	payer, err := retrievalPayer(ctx, cctx, fapi)
+	if err != nil {
+		return err
+	}
+
+	file, err := cid.Parse(cctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	var pieceCid *cid.Cid
+	if cctx.String("pieceCid") != "" {
+		parsed, err := cid.Parse(cctx.String("pieceCid"))
+		if err != nil {
+			return err
+		}
+		pieceCid = &parsed
+	}
+
+	maxPrice, err := retrievalMaxPrice(cctx)
+	if err != nil {
+		return err
+	}
+
+	offers, err := fapi.ClientFindData(ctx, file, pieceCid)
+	if err != nil {
+		return err
+	}
+
+	// one offer per provider, cheapest first
+	sort.Slice(offers, func(i, j int) bool {
+		return offers[i].MinPrice.LessThan(offers[j].MinPrice)
+	})
+	seen := map[address.Address]struct{}{}
+	var providers []lapi.QueryOffer
+	for _, o := range offers {
+		if o.Err != "" || o.MinPrice.GreaterThan(big.Int(maxPrice)) {
+			continue
+		}
+		if _, ok := seen[o.Miner]; ok {
+			continue
+		}
+		seen[o.Miner] = struct{}{}
+		providers = append(providers, o)
+		if len(providers) == parallel {
+			break
+		}
+	}
+	if len(providers) == 0 {
+		return xerrors.Errorf("failed to find providers offering %s within maxPrice: %s", file, maxPrice)
+	}
+
+	printf("Retrieving %s from %d providers\n", file, len(providers))
+
+	// retrieve the root block to find the parts of the file
+	sel := rootBlockSelector
+	dealID, err := retrieveOffer(ctx, fapi, providers[0], payer, &sel, printf)
+	if err != nil {
+		return xerrors.Errorf("retrieving root block: %w", err)
+	}
+	raw, err := exportBlock(ainfo, lapi.ExportRef{
+		Root:   file,
+		DealID: dealID,
+		DAGs:   []lapi.DagSpec{{DataSelector: &sel}},
+	})
+	if err != nil {
+		return xerrors.Errorf("exporting root block: %w", err)
+	}
+
+	data, chunks, err := parallelChunks(file, raw)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(cctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	defer out.Close() //nolint:errcheck
+
+	if _, err := out.WriteAt(data, 0); err != nil {
+		return err
+	}
+
+	jobs := make(chan int, len(chunks))
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+
+	eg, ectx := errgroup.WithContext(ctx)
+	for w := range providers {
+		w := w
+		eg.Go(func() error {
+			for i := range jobs {
+				c := chunks[i]
+				cprintf := func(format string, args ...interface{}) {
+					printf("[%d/%d] "+format, append([]interface{}{i + 1, len(chunks)}, args...)...)
+				}
+
+				// try the providers in turn, starting with the one of this worker
+				var err error
+				for p := 0; p < len(providers); p++ {
+					prov := providers[(w+p)%len(providers)]
+					if err = retrieveChunk(ectx, fapi, ainfo, prov, payer, maxPrice, c, out, cprintf); err == nil {
+						break
+					}
+					cprintf("retrieval from %s failed: %s\n", prov.Miner, err)
+				}
+				if err != nil {
+					return xerrors.Errorf("retrieving part %d (%s): %w", i, c.Root, err)
+				}
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// retrieveChunk retrieves a part of a file from the provider, and writes it at
+// its offset in the output file.
+func retrieveChunk(ctx context.Context, fapi lapi.FullNode, ainfo cliutil.APIInfo, prov lapi.QueryOffer, payer address.Address, maxPrice types.FIL, c parallelChunk, out io.WriterAt, printf func(string, ...interface{})) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	offer, err := fapi.ClientMinerQueryOffer(ctx, prov.Miner, c.Root, prov.Piece)
+	if err != nil {
+		return err
+	}
+	if offer.Err != "" {
+		return xerrors.Errorf("offer error: %s", offer.Err)
+	}
+	if offer.MinPrice.GreaterThan(big.Int(maxPrice)) {
+		return xerrors.Errorf("offer price %s exceeds maxPrice %s", types.FIL(offer.MinPrice), maxPrice)
+	}
+
+	dealID, err := retrieveOffer(ctx, fapi, offer, payer, nil, printf)
+	if err != nil {
+		return err
+	}
+
+	rc, err := cliutil.ClientExportStream(ainfo.Addr, ainfo.AuthHeader(), lapi.ExportRef{Root: c.Root, DealID: dealID}, false)
+	if err != nil {
+		return xerrors.Errorf("export: %w", err)
+	}
+	defer rc.Close() //nolint:errcheck
+
+	n, err := io.Copy(&offsetWriter{w: out, off: int64(c.Offset)}, rc)
+	if err != nil {
+		return xerrors.Errorf("writing part: %w", err)
+	}
+	if uint64(n) != c.Size {
+		return xerrors.Errorf("expected %d bytes, got %d", c.Size, n)
+	}
+	return nil
+}
+
+// exportBlock returns the data of the root block of the exported DAG.
+func exportBlock(ainfo cliutil.APIInfo, eref lapi.ExportRef) ([]byte, error) {
+	rc, err := cliutil.ClientExportStream(ainfo.Addr, ainfo.AuthHeader(), eref, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck
+
+	br, err := carv2.NewBlockReader(rc)
+	if err != nil {
+		return nil, xerrors.Errorf("reading car: %w", err)
+	}
+	for {
+		blk, err := br.Next()
+		if err == io.EOF {
+			return nil, xerrors.Errorf("block %s not found in car", eref.Root)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("reading car: %w", err)
+		}
+		if blk.Cid().Equals(eref.Root) {
+			return blk.RawData(), nil
+		}
+	}
+}
+
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.off)
+	o.off += int64(n)
+	return n, err
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
+	unixfspb "github.com/ipfs/go-unixfs/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelChunks(t *testing.T) {
+	a := merkledag.NewRawNode([]byte("aaaa"))
+	b := merkledag.NewRawNode([]byte("bbbbbb"))
+
+	fsn := unixfs.NewFSNode(unixfspb.Data_File)
+	fsn.SetData([]byte("xy"))
+	fsn.AddBlockSize(4)
+	fsn.AddBlockSize(6)
+	fb, err := fsn.GetBytes()
+	require.NoError(t, err)
+
+	root := merkledag.NodeWithData(fb)
+	require.NoError(t, root.AddNodeLink("", a))
+	require.NoError(t, root.AddNodeLink("", b))
+
+	data, chunks, err := parallelChunks(root.Cid(), root.RawData())
+	require.NoError(t, err)
+	require.Equal(t, []byte("xy"), data)
+	require.Equal(t, []parallelChunk{
+		{Root: a.Cid(), Offset: 2, Size: 4},
+		{Root: b.Cid(), Offset: 6, Size: 6},
+	}, chunks)
+
+	// single block files have no parts
+	data, chunks, err = parallelChunks(a.Cid(), a.RawData())
+	require.NoError(t, err)
+	require.Equal(t, []byte("aaaa"), data)
+	require.Empty(t, chunks)
+
+	// directories can't be split
+	dir := merkledag.NodeWithData(unixfs.FolderPBData())
+	_, _, err = parallelChunks(dir.Cid(), dir.RawData())
+	require.Error(t, err)
+}
@@ -69,6 +69,7 @@ var CommonCommands = []*cli.Command{
 var Commands = []*cli.Command{
 	WithCategory("basic", sendCmd),
 	WithCategory("basic", walletCmd),
+	WithCategory("basic", offlineCmd),
 	WithCategory("basic", infoCmd),
 	WithCategory("basic", clientCmd),
 	WithCategory("basic", multisigCmd),
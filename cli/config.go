@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var ConfigReloadCmd = &cli.Command{
+	Name:  "reload",
+	Usage: "Reload the config of the running node",
+	Description: `Re-read the config file of the running node and apply the settings which can be
+changed without restarting it, as on SIGHUP. The reload is rejected when any
+other setting has changed.`,
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if err := api.ConfigReload(ctx); err != nil {
+			return xerrors.Errorf("reloading config: %w", err)
+		}
+
+		fmt.Println("config reloaded")
+		return nil
+	},
+}
@@ -40,6 +40,7 @@ var filplusCmd = &cli.Command{
 		filplusListNotariesCmd,
 		filplusListClientsCmd,
 		filplusCheckClientCmd,
+		filplusClientStatusCmd,
 		filplusCheckNotaryCmd,
 		filplusSignRemoveDataCapProposal,
 		filplusListAllocationsCmd,
@@ -618,6 +619,72 @@ var filplusCheckClientCmd = &cli.Command{
 	},
 }
 
+var filplusClientStatusCmd = &cli.Command{
+	Name:      "client-status",
+	Usage:     "show the DataCap and allocations of a client",
+	ArgsUsage: "clientAddress",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		caddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		ts, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		dcap, err := api.StateVerifiedClientStatus(ctx, caddr, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Client: %s\n", caddr)
+		if dcap == nil {
+			fmt.Println("Verified: no")
+			return nil
+		}
+		fmt.Println("Verified: yes")
+		fmt.Printf("DataCap: %s (%s bytes)\n", types.SizeStr(*dcap), *dcap)
+
+		allocations, err := api.StateGetAllocations(ctx, caddr, ts.Key())
+		if err != nil {
+			return xerrors.Errorf("getting allocations: %w", err)
+		}
+
+		var pending, expired int
+		var pendingSize, expiredSize abi.PaddedPieceSize
+		for _, a := range allocations {
+			if ts.Height() > a.Expiration {
+				expired++
+				expiredSize += a.Size
+			} else {
+				pending++
+				pendingSize += a.Size
+			}
+		}
+
+		fmt.Printf("Pending allocations: %d (%s)\n", pending, types.SizeStr(types.NewInt(uint64(pendingSize))))
+		fmt.Printf("Expired allocations: %d (%s)\n", expired, types.SizeStr(types.NewInt(uint64(expiredSize))))
+		if expired > 0 {
+			fmt.Println("The DataCap of expired allocations can be reclaimed with 'lotus filplus remove-expired-allocations'")
+		}
+
+		return nil
+	},
+}
+
 var filplusCheckNotaryCmd = &cli.Command{
 	Name:      "check-notary-datacap",
 	Usage:     "check a notary's remaining bytes",
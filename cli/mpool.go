@@ -6,6 +6,8 @@ import (
 	stdbig "math/big"
 	"sort"
 	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
@@ -31,9 +33,12 @@ var MpoolCmd = &cli.Command{
 		MpoolSub,
 		MpoolStat,
 		MpoolReplaceCmd,
+		MpoolReplaceBatchCmd,
 		MpoolFindCmd,
 		MpoolConfig,
 		MpoolGasPerfCmd,
+		MpoolPeersCmd,
+		MpoolSendQueueCmd,
 		mpoolManage,
 	},
 }
@@ -62,7 +67,7 @@ var MpoolPending = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -89,15 +94,9 @@ var MpoolPending = &cli.Command{
 
 		var filter map[address.Address]struct{}
 		if cctx.Bool("local") {
-			filter = map[address.Address]struct{}{}
-
-			addrss, err := api.WalletList(ctx)
+			filter, err = walletAddrs(ctx, api)
 			if err != nil {
-				return xerrors.Errorf("getting local addresses: %w", err)
-			}
-
-			for _, a := range addrss {
-				filter[a] = struct{}{}
+				return err
 			}
 		}
 
@@ -174,8 +173,26 @@ var MpoolClear = &cli.Command{
 var MpoolSub = &cli.Command{
 	Name:  "sub",
 	Usage: "Subscribe to mpool changes",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "from",
+			Usage: "only show changes of messages sent by the given addresses",
+		},
+		&cli.StringSliceFlag{
+			Name:  "to",
+			Usage: "only show changes of messages sent to the given addresses",
+		},
+		&cli.Int64SliceFlag{
+			Name:  "method",
+			Usage: "only show changes of messages calling the given methods",
+		},
+		&cli.StringFlag{
+			Name:  "min-value",
+			Usage: "only show changes of messages transferring at least the given value (FIL)",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -183,7 +200,33 @@ var MpoolSub = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		sub, err := api.MpoolSub(ctx)
+		var filter lapi.MpoolUpdateFilter
+		for _, s := range cctx.StringSlice("from") {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing from address: %w", err)
+			}
+			filter.From = append(filter.From, a)
+		}
+		for _, s := range cctx.StringSlice("to") {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing to address: %w", err)
+			}
+			filter.To = append(filter.To, a)
+		}
+		for _, m := range cctx.Int64Slice("method") {
+			filter.Methods = append(filter.Methods, abi.MethodNum(m))
+		}
+		if cctx.IsSet("min-value") {
+			v, err := types.ParseFIL(cctx.String("min-value"))
+			if err != nil {
+				return xerrors.Errorf("parsing min-value: %w", err)
+			}
+			filter.MinValue = abi.TokenAmount(v)
+		}
+
+		sub, err := api.MpoolSubFiltered(ctx, filter)
 		if err != nil {
 			return err
 		}
@@ -220,7 +263,7 @@ var MpoolStat = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -250,15 +293,9 @@ var MpoolStat = &cli.Command{
 
 		var filter map[address.Address]struct{}
 		if cctx.Bool("local") {
-			filter = map[address.Address]struct{}{}
-
-			addrss, err := api.WalletList(ctx)
+			filter, err = walletAddrs(ctx, api)
 			if err != nil {
-				return xerrors.Errorf("getting local addresses: %w", err)
-			}
-
-			for _, a := range addrss {
-				filter[a] = struct{}{}
+				return err
 			}
 		}
 
@@ -525,6 +562,73 @@ var MpoolReplaceCmd = &cli.Command{
 	},
 }
 
+var MpoolReplaceBatchCmd = &cli.Command{
+	Name:  "replace-batch",
+	Usage: "reprice all pending messages from a sender in nonce order",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "fee-limit",
+			Usage: "Spend up to X FIL for each message in units of FIL",
+		},
+	},
+	ArgsUsage: "<from>",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		from, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		var mss *lapi.MessageSendSpec
+		if cctx.IsSet("fee-limit") {
+			maxFee, err := types.ParseFIL(cctx.String("fee-limit"))
+			if err != nil {
+				return xerrors.Errorf("parsing fee-limit: %w", err)
+			}
+			mss = &lapi.MessageSendSpec{
+				MaxFee: abi.TokenAmount(maxFee),
+			}
+		}
+
+		res, err := api.MpoolReplaceBatch(ctx, from, mss)
+		if err != nil {
+			return xerrors.Errorf("replacing messages: %w", err)
+		}
+		if len(res) == 0 {
+			afmt.Printf("no pending messages from %s\n", from)
+			return nil
+		}
+
+		failed := 0
+		for _, r := range res {
+			if r.Replacement == nil {
+				afmt.Printf("failed to replace nonce %d: %s\n", r.Nonce, r.Error)
+				failed++
+				continue
+			}
+			afmt.Printf("replaced nonce %d: %s\n", r.Nonce, r.Replacement.Cid())
+		}
+		if failed > 0 {
+			return xerrors.Errorf("failed to replace %d of %d messages", failed, len(res))
+		}
+
+		return nil
+	},
+}
+
 var MpoolFindCmd = &cli.Command{
 	Name:  "find",
 	Usage: "find a message in the mempool",
@@ -657,6 +761,158 @@ var MpoolConfig = &cli.Command{
 	},
 }
 
+var MpoolPeersCmd = &cli.Command{
+	Name:  "peers",
+	Usage: "Inspect and reset the untrusted message counters of peers",
+	Subcommands: []*cli.Command{
+		MpoolPeersListCmd,
+		MpoolPeersResetCmd,
+	},
+}
+
+var MpoolPeersListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the untrusted message counters of peers",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		peers, err := api.MpoolPeerStats(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Peer\tAccepted\tRejected\tRateLimited\tLastSeen\tBanned\n")
+		for _, p := range peers {
+			banned := ""
+			if time.Now().Before(p.BannedUntil) {
+				banned = "until " + p.BannedUntil.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\n", p.Peer, p.Accepted, p.Rejected, p.RateLimited, p.LastSeen.Format(time.RFC3339), banned)
+		}
+		return w.Flush()
+	},
+}
+
+var MpoolPeersResetCmd = &cli.Command{
+	Name:      "reset",
+	Usage:     "reset the counters, rate limit and ban of a peer, or of all peers",
+	ArgsUsage: "[peer]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.MpoolPeerReset(ReqContext(cctx), cctx.Args().First())
+	},
+}
+
+var MpoolSendQueueCmd = &cli.Command{
+	Name:  "send-queue",
+	Usage: "Inspect and flush local messages held back because of a nonce gap",
+	Subcommands: []*cli.Command{
+		MpoolSendQueueListCmd,
+		MpoolSendQueueFlushCmd,
+	},
+}
+
+var MpoolSendQueueListCmd = &cli.Command{
+	Name:      "list",
+	Usage:     "list the messages held back, for a sender or for all senders",
+	ArgsUsage: "[from]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		from := address.Undef
+		if cctx.Args().Present() {
+			from, err = address.NewFromString(cctx.Args().First())
+			if err != nil {
+				return err
+			}
+		}
+
+		msgs, err := api.MpoolSendQueue(ctx, from)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			afmt.Printf("%s\t%d\t%s\n", msg.Message.From, msg.Message.Nonce, msg.Cid())
+		}
+		return nil
+	},
+}
+
+var MpoolSendQueueFlushCmd = &cli.Command{
+	Name:      "flush",
+	Usage:     "push the messages held back for a sender despite the nonce gap",
+	ArgsUsage: "<from>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "drop",
+			Usage: "drop the held messages instead of pushing them",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		from, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		cids, err := api.MpoolSendQueueFlush(ctx, from, cctx.Bool("drop"))
+		for _, c := range cids {
+			if cctx.Bool("drop") {
+				afmt.Printf("dropped %s\n", c)
+			} else {
+				afmt.Printf("pushed %s\n", c)
+			}
+		}
+		if err != nil {
+			return xerrors.Errorf("flushing send queue: %w", err)
+		}
+
+		return nil
+	},
+}
+
 var MpoolGasPerfCmd = &cli.Command{
 	Name:  "gas-perf",
 	Usage: "Check gas performance of messages in mempool",
@@ -669,7 +925,7 @@ var MpoolGasPerfCmd = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		afmt := NewAppFmt(cctx.App)
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -684,15 +940,9 @@ var MpoolGasPerfCmd = &cli.Command{
 
 		var filter map[address.Address]struct{}
 		if !cctx.Bool("all") {
-			filter = map[address.Address]struct{}{}
-
-			addrss, err := api.WalletList(ctx)
+			filter, err = walletAddrs(ctx, api)
 			if err != nil {
-				return xerrors.Errorf("getting local addresses: %w", err)
-			}
-
-			for _, a := range addrss {
-				filter[a] = struct{}{}
+				return err
 			}
 
 			var filtered []*types.SignedMessage
@@ -53,6 +53,7 @@ func TestStat(t *testing.T) {
 			mockApi.EXPECT().ChainHead(ctx).Return(head, nil),
 			mockApi.EXPECT().ChainGetTipSet(ctx, head.Parents()).Return(first, nil),
 			mockApi.EXPECT().WalletList(ctx).Return([]address.Address{senderAddr, toAddr}, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{}, nil),
 			mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return([]*types.SignedMessage{sm}, nil),
 			mockApi.EXPECT().StateGetActor(ctx, senderAddr, head.Key()).Return(&actor, nil),
 		)
@@ -159,6 +160,7 @@ func TestPending(t *testing.T) {
 
 		gomock.InOrder(
 			mockApi.EXPECT().WalletList(ctx).Return([]address.Address{senderAddr}, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{}, nil),
 			mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return([]*types.SignedMessage{sm}, nil),
 		)
 
@@ -169,6 +171,38 @@ func TestPending(t *testing.T) {
 		assert.Contains(t, buf.String(), sm.Cid().String())
 	})
 
+	t.Run("local watched", func(t *testing.T) {
+
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("mpool", MpoolPending))
+		defer done()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// create a signed message to be returned as a pending message
+		w, _ := wallet.NewWallet(wallet.NewMemKeyStore())
+		senderAddr, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		toAddr, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sm := mock.MkMessage(senderAddr, toAddr, 1, w)
+
+		gomock.InOrder(
+			mockApi.EXPECT().WalletList(ctx).Return([]address.Address{}, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{senderAddr}, nil),
+			mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return([]*types.SignedMessage{sm}, nil),
+		)
+
+		err = app.Run([]string{"mpool", "pending", "--local"})
+		assert.NoError(t, err)
+
+		assert.Contains(t, buf.String(), sm.Cid().String())
+	})
+
 	t.Run("to", func(t *testing.T) {
 
 		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("mpool", MpoolPending))
@@ -360,6 +394,58 @@ func TestReplace(t *testing.T) {
 	})
 }
 
+func TestReplaceBatch(t *testing.T) {
+	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("mpool", MpoolReplaceBatchCmd))
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// create signed messages to be returned as the replacements
+	w, _ := wallet.NewWallet(wallet.NewMemKeyStore())
+	senderAddr, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toAddr, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm1 := mock.MkMessage(senderAddr, toAddr, 1, w)
+	sm2 := mock.MkMessage(senderAddr, toAddr, 2, w)
+
+	maxFee := "1"
+	parsedFee, err := types.ParseFIL(maxFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mss := &api.MessageSendSpec{MaxFee: abi.TokenAmount(parsedFee)}
+
+	mockApi.EXPECT().MpoolReplaceBatch(ctx, senderAddr, mss).Return([]api.ReplaceResult{
+		{Nonce: 1, Replacement: sm1},
+		{Nonce: 2, Replacement: sm2},
+	}, nil)
+
+	err = app.Run([]string{"mpool", "replace-batch", "--fee-limit", maxFee, senderAddr.String()})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), sm1.Cid().String())
+	assert.Contains(t, buf.String(), sm2.Cid().String())
+
+	// failures are reported per message
+	buf.Reset()
+	mockApi.EXPECT().MpoolReplaceBatch(ctx, senderAddr, mss).Return([]api.ReplaceResult{
+		{Nonce: 1, Error: "premium capped by max fee"},
+		{Nonce: 2, Replacement: sm2},
+	}, nil)
+
+	err = app.Run([]string{"mpool", "replace-batch", "--fee-limit", maxFee, senderAddr.String()})
+
+	assert.ErrorContains(t, err, "failed to replace 1 of 2 messages")
+	assert.Contains(t, buf.String(), "failed to replace nonce 1: premium capped by max fee")
+	assert.Contains(t, buf.String(), sm2.Cid().String())
+}
+
 func TestFindMsg(t *testing.T) {
 	t.Run("from", func(t *testing.T) {
 		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("mpool", MpoolFindCmd))
@@ -515,6 +601,7 @@ func TestGasPerf(t *testing.T) {
 		gomock.InOrder(
 			mockApi.EXPECT().MpoolPending(ctx, types.EmptyTSK).Return([]*types.SignedMessage{sm}, nil),
 			mockApi.EXPECT().WalletList(ctx).Return([]address.Address{senderAddr}, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{}, nil),
 			mockApi.EXPECT().ChainHead(ctx).Return(head, nil),
 		)
 
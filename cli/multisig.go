@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/ipfs/go-cid"
@@ -22,6 +23,7 @@ import (
 	init2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/init"
 	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
 
+	lapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
@@ -29,6 +31,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
 	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -45,9 +48,12 @@ var multisigCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		msigCreateCmd,
 		msigInspectCmd,
+		msigPendingCmd,
+		msigWatchCmd,
 		msigProposeCmd,
 		msigRemoveProposeCmd,
 		msigApproveCmd,
+		msigApproveBatchCmd,
 		msigCancelCmd,
 		msigAddProposeCmd,
 		msigAddApproveCmd,
@@ -1821,3 +1827,286 @@ var msigProposeThresholdCmd = &cli.Command{
 		return nil
 	},
 }
+
+var msigPendingCmd = &cli.Command{
+	Name:      "pending",
+	Usage:     "List the pending transactions of a multisig wallet with their decoded parameters",
+	ArgsUsage: "<multisigAddress>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output the transactions as JSON",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		msig, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		pending, err := api.MsigGetPendingDecoded(ctx, msig, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			b, err := json.MarshalIndent(pending, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cctx.App.Writer, string(b))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "ID\tApprovals\tTo\tValue\tMethod\tParams\n")
+		for _, tx := range pending {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", tx.ID, fmtMsigApprovals(tx.Approved), tx.To, types.FIL(tx.Value), fmtMsigMethod(tx), fmtMsigParams(tx))
+		}
+		return w.Flush()
+	},
+}
+
+func fmtMsigApprovals(approved []address.Address) string {
+	s := make([]string, len(approved))
+	for i, a := range approved {
+		s[i] = a.String()
+	}
+	return fmt.Sprintf("%d (%s)", len(approved), strings.Join(s, ", "))
+}
+
+func fmtMsigMethod(tx *lapi.MsigDecodedTransaction) string {
+	if tx.MethodName == "" {
+		return fmt.Sprintf("unknown(%d)", tx.Method)
+	}
+	return fmt.Sprintf("%s(%d)", tx.MethodName, tx.Method)
+}
+
+func fmtMsigParams(tx *lapi.MsigDecodedTransaction) string {
+	if tx.DecodedParams != nil {
+		return string(tx.DecodedParams)
+	}
+	return fmt.Sprintf("%x", tx.Params)
+}
+
+var msigApproveBatchCmd = &cli.Command{
+	Name:      "approve-batch",
+	Usage:     "Approve several pending multisig transactions",
+	ArgsUsage: "<multisigAddress> [messageId...]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "account to send the approve messages from",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "approve all the pending transactions not yet approved by the sender",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("must specify address of multisig"))
+		}
+		if cctx.Bool("all") == (cctx.NArg() > 1) {
+			return ShowHelp(cctx, fmt.Errorf("must pass either message IDs or --all"))
+		}
+
+		srv, err := GetFullNodeServices(cctx)
+		if err != nil {
+			return err
+		}
+		defer srv.Close() //nolint:errcheck
+
+		api := srv.FullNodeAPI()
+		ctx := ReqContext(cctx)
+
+		msig, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		var from address.Address
+		if cctx.IsSet("from") {
+			f, err := address.NewFromString(cctx.String("from"))
+			if err != nil {
+				return err
+			}
+			from = f
+		} else {
+			defaddr, err := api.WalletDefaultAddress(ctx)
+			if err != nil {
+				return err
+			}
+			from = defaddr
+		}
+
+		var txids []uint64
+		if cctx.Bool("all") {
+			fromID, err := api.StateLookupID(ctx, from, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("looking up sender id: %w", err)
+			}
+
+			pending, err := api.MsigGetPendingDecoded(ctx, msig, types.EmptyTSK)
+			if err != nil {
+				return err
+			}
+
+		txns:
+			for _, tx := range pending {
+				for _, a := range tx.Approved {
+					if a == fromID {
+						continue txns
+					}
+				}
+				txids = append(txids, uint64(tx.ID))
+			}
+
+			if len(txids) == 0 {
+				fmt.Fprintln(cctx.App.Writer, "no pending transactions to approve")
+				return nil
+			}
+		} else {
+			for _, arg := range cctx.Args().Tail() {
+				txid, err := strconv.ParseUint(arg, 10, 64)
+				if err != nil {
+					return xerrors.Errorf("parsing message ID %q: %w", arg, err)
+				}
+				txids = append(txids, txid)
+			}
+		}
+
+		msgCids := make([]cid.Cid, len(txids))
+		for i, txid := range txids {
+			proto, err := api.MsigApprove(ctx, msig, txid, from)
+			if err != nil {
+				return xerrors.Errorf("approving transaction %d: %w", txid, err)
+			}
+
+			sm, err := InteractiveSend(ctx, cctx, srv, proto)
+			if err != nil {
+				return xerrors.Errorf("approving transaction %d: %w", txid, err)
+			}
+
+			msgCids[i] = sm.Cid()
+			fmt.Fprintf(cctx.App.Writer, "sent approval of transaction %d in message: %s\n", txid, sm.Cid())
+		}
+
+		var failed int
+		for i, msgCid := range msgCids {
+			wait, err := api.StateWaitMsg(ctx, msgCid, uint64(cctx.Int("confidence")), build.Finality, true)
+			if err != nil {
+				return err
+			}
+
+			if wait.Receipt.ExitCode.IsError() {
+				fmt.Fprintf(cctx.App.Writer, "approval of transaction %d returned exit %d\n", txids[i], wait.Receipt.ExitCode)
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return xerrors.Errorf("%d of %d approvals failed", failed, len(txids))
+		}
+
+		return nil
+	},
+}
+
+var msigWatchCmd = &cli.Command{
+	Name:      "watch",
+	Usage:     "Watch multisig wallets and print their new proposals as they appear on chain",
+	ArgsUsage: "<multisigAddress...>",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("must specify address of multisig to watch"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var msigs []address.Address
+		for _, arg := range cctx.Args().Slice() {
+			msig, err := address.NewFromString(arg)
+			if err != nil {
+				return err
+			}
+			msigs = append(msigs, msig)
+		}
+
+		notifs, err := api.ChainNotify(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the pending transactions of each msig, nil until it was first checked
+		known := make(map[address.Address]map[int64]*lapi.MsigDecodedTransaction)
+
+		check := func(ts *types.TipSet) {
+			for _, msig := range msigs {
+				pending, err := api.MsigGetPendingDecoded(ctx, msig, ts.Key())
+				if err != nil {
+					log.Warnf("getting pending transactions of %s at %d: %s", msig, ts.Height(), err)
+					continue
+				}
+
+				current := make(map[int64]*lapi.MsigDecodedTransaction, len(pending))
+				for _, tx := range pending {
+					current[tx.ID] = tx
+				}
+
+				prev, checked := known[msig]
+				known[msig] = current
+				if !checked {
+					fmt.Fprintf(cctx.App.Writer, "watching %s with %d pending transactions\n", msig, len(pending))
+					continue
+				}
+
+				for _, tx := range pending {
+					if _, ok := prev[tx.ID]; !ok {
+						fmt.Fprintf(cctx.App.Writer, "%d: %s: new proposal %d to %s, value %s, method %s, params %s, approved by %s\n",
+							ts.Height(), msig, tx.ID, tx.To, types.FIL(tx.Value), fmtMsigMethod(tx), fmtMsigParams(tx), fmtMsigApprovals(tx.Approved))
+					} else if len(tx.Approved) != len(prev[tx.ID].Approved) {
+						fmt.Fprintf(cctx.App.Writer, "%d: %s: proposal %d approved by %s\n", ts.Height(), msig, tx.ID, fmtMsigApprovals(tx.Approved))
+					}
+				}
+				for id := range prev {
+					if _, ok := current[id]; !ok {
+						fmt.Fprintf(cctx.App.Writer, "%d: %s: transaction %d is no longer pending (executed or cancelled)\n", ts.Height(), msig, id)
+					}
+				}
+			}
+		}
+
+		for changes := range notifs {
+			if len(changes) == 0 {
+				continue
+			}
+
+			// only the latest head matters, reverted proposals show up as
+			// no longer pending
+			last := changes[len(changes)-1]
+			if last.Type == store.HCRevert {
+				continue
+			}
+			check(last.Val)
+		}
+
+		return ctx.Err()
+	},
+}
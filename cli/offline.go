@@ -0,0 +1,362 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+var offlineCmd = &cli.Command{
+	Name:  "offline",
+	Usage: "Sign messages on a machine without access to a node",
+	Description: `Sending a message from a key kept on an air-gapped machine takes three steps:
+
+1. 'lotus offline prepare' on a machine with a node writes the unsigned message, with its
+   nonce, gas parameters and chain context, to a file.
+2. 'lotus offline sign' on the air-gapped machine signs the message in the file with a key
+   exported with 'lotus wallet export' or 'lotus wallet export-encrypted'. No node is needed.
+3. 'lotus offline publish' on a machine with a node pushes the signed message to the mempool.`,
+	Subcommands: []*cli.Command{
+		offlinePrepareCmd,
+		offlineSignCmd,
+		offlinePublishCmd,
+	},
+}
+
+var offlinePrepareCmd = &cli.Command{
+	Name:      "prepare",
+	Usage:     "Prepare an unsigned message for offline signing",
+	ArgsUsage: "[targetAddress] [amount]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "address to send the message from",
+			Required: true,
+		},
+		&cli.Uint64Flag{
+			Name:  "method",
+			Usage: "specify method to invoke",
+			Value: uint64(0),
+		},
+		&cli.StringFlag{
+			Name:  "params-hex",
+			Usage: "specify invocation parameters in hex",
+		},
+		&cli.Uint64Flag{
+			Name:  "nonce",
+			Usage: "specify the nonce to use",
+		},
+		&cli.StringFlag{
+			Name:  "gas-premium",
+			Usage: "specify gas price to use in AttoFIL",
+		},
+		&cli.StringFlag{
+			Name:  "gas-feecap",
+			Usage: "specify gas fee cap to use in AttoFIL",
+		},
+		&cli.Int64Flag{
+			Name:  "gas-limit",
+			Usage: "specify gas limit",
+		},
+		&cli.StringFlag{
+			Name:  "max-fee",
+			Usage: "maximum fee to pay for the message in FIL, used when the gas parameters are estimated",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "file to write the prepared message to, stdout if not set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		msg := &types.Message{
+			Method:     abi.MethodNum(cctx.Uint64("method")),
+			Nonce:      cctx.Uint64("nonce"),
+			GasLimit:   cctx.Int64("gas-limit"),
+			GasFeeCap:  types.NewInt(0),
+			GasPremium: types.NewInt(0),
+		}
+
+		if msg.To, err = address.NewFromString(cctx.Args().Get(0)); err != nil {
+			return ShowHelp(cctx, fmt.Errorf("failed to parse target address: %w", err))
+		}
+
+		val, err := types.ParseFIL(cctx.Args().Get(1))
+		if err != nil {
+			return ShowHelp(cctx, fmt.Errorf("failed to parse amount: %w", err))
+		}
+		msg.Value = abi.TokenAmount(val)
+
+		if msg.From, err = address.NewFromString(cctx.String("from")); err != nil {
+			return xerrors.Errorf("parsing from address: %w", err)
+		}
+
+		if cctx.IsSet("params-hex") {
+			if msg.Params, err = hex.DecodeString(cctx.String("params-hex")); err != nil {
+				return xerrors.Errorf("failed to decode hex params: %w", err)
+			}
+		}
+
+		if cctx.IsSet("gas-premium") {
+			if msg.GasPremium, err = types.BigFromString(cctx.String("gas-premium")); err != nil {
+				return err
+			}
+		}
+
+		if cctx.IsSet("gas-feecap") {
+			if msg.GasFeeCap, err = types.BigFromString(cctx.String("gas-feecap")); err != nil {
+				return err
+			}
+		}
+
+		spec := &lapi.MessageSendSpec{}
+		if cctx.IsSet("max-fee") {
+			maxFee, err := types.ParseFIL(cctx.String("max-fee"))
+			if err != nil {
+				return xerrors.Errorf("parsing max-fee: %w", err)
+			}
+			spec.MaxFee = abi.TokenAmount(maxFee)
+		}
+
+		om, err := api.MpoolPrepareOffline(ctx, msg, spec)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(om, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if !cctx.IsSet("output") {
+			fmt.Fprintln(cctx.App.Writer, string(b))
+			return nil
+		}
+
+		if err := ioutil.WriteFile(cctx.String("output"), b, 0644); err != nil {
+			return xerrors.Errorf("writing prepared message: %w", err)
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "Prepared message %s, sign it with 'lotus offline sign'\n", om.Message.Cid())
+		if om.Lease != nil {
+			fmt.Fprintf(cctx.App.Writer, "Nonce %d is reserved until %s, publish the message before then\n", om.Lease.Nonce, om.Lease.Expires.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+var offlineSignCmd = &cli.Command{
+	Name:      "sign",
+	Usage:     "Sign a prepared message, without connecting to a node",
+	ArgsUsage: "[preparedMessageFile]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "key-file",
+			Usage:    "file with the key exported with 'lotus wallet export' or 'lotus wallet export-encrypted'",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "encrypted",
+			Usage: "the key file was exported with 'lotus wallet export-encrypted'",
+		},
+		&cli.StringFlag{
+			Name:  "password-file",
+			Usage: "read the password of an encrypted key from a file instead of prompting for it",
+		},
+		&cli.BoolFlag{
+			Name:  "yes",
+			Usage: "sign without asking for confirmation",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "file to write the signed message to, defaults to overwriting the prepared message file",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		om, err := readOfflineMessage(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		ki, err := readOfflineKey(cctx)
+		if err != nil {
+			return err
+		}
+
+		k, err := key.NewKey(*ki)
+		if err != nil {
+			return xerrors.Errorf("loading key: %w", err)
+		}
+		if k.Address != om.SigningKey {
+			return xerrors.Errorf("the message must be signed by %s, the key file holds %s", om.SigningKey, k.Address)
+		}
+
+		printOfflineMessage(cctx, om)
+
+		if !cctx.Bool("yes") {
+			fmt.Fprint(cctx.App.ErrWriter, "Sign this message? [y/N] ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return xerrors.Errorf("reading confirmation: %w", err)
+			}
+			if strings.ToLower(strings.TrimSpace(line)) != "y" {
+				return xerrors.Errorf("not signing")
+			}
+		}
+
+		sb, err := messagesigner.SigningBytes(om.Message, k.Address.Protocol())
+		if err != nil {
+			return err
+		}
+		om.Signature, err = sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, sb)
+		if err != nil {
+			return xerrors.Errorf("signing message: %w", err)
+		}
+
+		b, err := json.MarshalIndent(om, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		out := cctx.Args().First()
+		if cctx.IsSet("output") {
+			out = cctx.String("output")
+		}
+		if err := ioutil.WriteFile(out, b, 0644); err != nil {
+			return xerrors.Errorf("writing signed message: %w", err)
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "Signed message written to %s, publish it with 'lotus offline publish'\n", out)
+		return nil
+	},
+}
+
+var offlinePublishCmd = &cli.Command{
+	Name:      "publish",
+	Usage:     "Push a message signed offline to the mempool",
+	ArgsUsage: "[signedMessageFile]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		om, err := readOfflineMessage(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		c, err := api.MpoolPushOffline(ctx, om)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cctx.App.Writer, c)
+		return nil
+	},
+}
+
+func readOfflineMessage(path string) (*lapi.OfflineMessage, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading message file: %w", err)
+	}
+
+	var om lapi.OfflineMessage
+	if err := json.Unmarshal(b, &om); err != nil {
+		return nil, xerrors.Errorf("parsing message file: %w", err)
+	}
+	if om.Message == nil {
+		return nil, xerrors.Errorf("message file %s doesn't contain a message", path)
+	}
+
+	return &om, nil
+}
+
+func readOfflineKey(cctx *cli.Context) (*types.KeyInfo, error) {
+	b, err := ioutil.ReadFile(cctx.String("key-file"))
+	if err != nil {
+		return nil, xerrors.Errorf("reading key file: %w", err)
+	}
+
+	if cctx.Bool("encrypted") {
+		var eki types.EncryptedKeyInfo
+		if err := json.Unmarshal(b, &eki); err != nil {
+			return nil, xerrors.Errorf("parsing encrypted key: %w", err)
+		}
+
+		password, err := readWalletPassword(cctx, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return wallet.DecryptKeyInfo(&eki, password)
+	}
+
+	data, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, xerrors.Errorf("decoding key: %w", err)
+	}
+
+	var ki types.KeyInfo
+	if err := json.Unmarshal(data, &ki); err != nil {
+		return nil, xerrors.Errorf("parsing key: %w", err)
+	}
+
+	return &ki, nil
+}
+
+func printOfflineMessage(cctx *cli.Context, om *lapi.OfflineMessage) {
+	w := cctx.App.ErrWriter
+	msg := om.Message
+
+	fmt.Fprintf(w, "Network:     %s (prepared at height %d)\n", om.NetworkName, om.Height)
+	fmt.Fprintf(w, "From:        %s (key %s, balance %s)\n", msg.From, om.SigningKey, types.FIL(om.Balance))
+	fmt.Fprintf(w, "To:          %s\n", msg.To)
+	fmt.Fprintf(w, "Value:       %s\n", types.FIL(msg.Value))
+	fmt.Fprintf(w, "Method:      %d\n", msg.Method)
+	if len(msg.Params) > 0 {
+		fmt.Fprintf(w, "Params:      %x\n", msg.Params)
+	}
+	fmt.Fprintf(w, "Nonce:       %d\n", msg.Nonce)
+	fmt.Fprintf(w, "Gas:         limit %d, fee cap %s, premium %s\n", msg.GasLimit, msg.GasFeeCap, msg.GasPremium)
+	fmt.Fprintf(w, "Max fee:     %s\n", types.FIL(msg.RequiredFunds()))
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+func TestOfflineSignAndPublish(t *testing.T) {
+	app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("offline", offlineCmd))
+	defer done()
+
+	dir := t.TempDir()
+
+	k, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	kb, err := json.Marshal(k.KeyInfo)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(kb)), 0600))
+
+	om := &api.OfflineMessage{
+		Message: &types.Message{
+			From:       k.Address,
+			To:         mock.Address(1000),
+			Value:      abi.NewTokenAmount(10),
+			Nonce:      3,
+			GasLimit:   1_000_000,
+			GasFeeCap:  abi.NewTokenAmount(100),
+			GasPremium: abi.NewTokenAmount(10),
+		},
+		SigningKey:  k.Address,
+		NetworkName: "testnet",
+		Height:      100,
+		Balance:     abi.NewTokenAmount(1_000_000_000),
+	}
+	ob, err := json.Marshal(om)
+	require.NoError(t, err)
+	msgFile := filepath.Join(dir, "msg.json")
+	require.NoError(t, os.WriteFile(msgFile, ob, 0644))
+
+	err = app.Run([]string{"lotus", "offline", "sign", "--key-file", keyFile, "--yes", msgFile})
+	require.NoError(t, err)
+
+	signed, err := readOfflineMessage(msgFile)
+	require.NoError(t, err)
+	require.NotNil(t, signed.Signature)
+	require.Equal(t, om.Message.Cid(), signed.Message.Cid())
+
+	sb, err := messagesigner.SigningBytes(signed.Message, k.Address.Protocol())
+	require.NoError(t, err)
+	require.NoError(t, sigs.Verify(signed.Signature, k.Address, sb))
+
+	// a message prepared for another key can't be signed
+	other, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+	om.SigningKey = other.Address
+	ob, err = json.Marshal(om)
+	require.NoError(t, err)
+	otherFile := filepath.Join(dir, "other.json")
+	require.NoError(t, os.WriteFile(otherFile, ob, 0644))
+
+	err = app.Run([]string{"lotus", "offline", "sign", "--key-file", keyFile, "--yes", otherFile})
+	require.Error(t, err)
+
+	mockApi.EXPECT().MpoolPushOffline(gomock.Any(), signed).Return(signed.Message.Cid(), nil)
+
+	err = app.Run([]string{"lotus", "offline", "publish", msgFile})
+	require.NoError(t, err)
+}
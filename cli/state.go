@@ -547,6 +547,10 @@ var StateReplayCmd = &cli.Command{
 			Name:  "detailed-gas",
 			Usage: "print out detailed gas costs for given message",
 		},
+		&cli.BoolFlag{
+			Name:  "gas-charges",
+			Usage: "print out the gas charges of each call of the execution trace",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 1 {
@@ -558,7 +562,7 @@ var StateReplayCmd = &cli.Command{
 			return fmt.Errorf("message cid was invalid: %s", err)
 		}
 
-		fapi, closer, err := GetFullNodeAPI(cctx)
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -566,7 +570,9 @@ var StateReplayCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		res, err := fapi.StateReplay(ctx, types.EmptyTSK, mcid)
+		res, err := fapi.StateReplayWithOptions(ctx, types.EmptyTSK, mcid, api.ReplayOptions{
+			GasCharges: cctx.Bool("gas-charges"),
+		})
 		if err != nil {
 			return xerrors.Errorf("replay call failed: %w", err)
 		}
@@ -594,6 +600,22 @@ var StateReplayCmd = &cli.Command{
 			printInternalExecutions("\t", res.ExecutionTrace.Subcalls)
 		}
 
+		if cctx.Bool("gas-charges") {
+			fmt.Println("Gas charges:")
+			tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(tw, "Call\tCharge\tTotal\tCompute\tStorage")
+			for _, gc := range res.GasCharges {
+				call := fmt.Sprintf("%s%s.%d", strings.Repeat("  ", gc.Depth), gc.To, gc.Method)
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", call, gc.Name, gc.TotalGas, gc.ComputeGas, gc.StorageGas)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			if len(res.GasCharges) == 0 {
+				fmt.Println("no gas charges in the execution trace")
+			}
+		}
+
 		return nil
 	},
 }
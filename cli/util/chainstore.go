@@ -0,0 +1,318 @@
+package cliutil
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// GetSharedChainstore connects to the chain blockstore served by the lotus
+// daemon, caching up to cacheSize of the blocks read from it. The returned
+// store is read-only; the closer closes the connection to the daemon.
+func GetSharedChainstore(ctx *cli.Context, cacheSize int) (blockstore.Blockstore, func(), error) {
+	ainfo, err := GetAPIInfo(ctx, repo.FullNode)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("could not get API info for FullNode: %w", err)
+	}
+
+	ns, err := DialChainstore(ainfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var closed atomic.Bool
+	ns.OnClose(func() {
+		if !closed.Load() {
+			log.Warnw("connection to the daemon chainstore closed; falling back to reading chain objects over the API", "addr", ainfo.Addr)
+		}
+	})
+	closer := func() {
+		closed.Store(true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ns.Stop(ctx); err != nil {
+			log.Warnf("closing the daemon chainstore connection: %s", err)
+		}
+	}
+
+	bs, err := blockstore.NewReadCache(blockstore.NewReadOnly(ns), cacheSize)
+	if err != nil {
+		closer()
+		return nil, nil, err
+	}
+	return bs, closer, nil
+}
+
+// DialChainstore connects to the chain blockstore served by the lotus node with
+// the API info. The chainstore is read-only, except that objects can be put when
+// the token of the API info has the admin permission.
+func DialChainstore(ainfo APIInfo) (*blockstore.NetworkStore, error) {
+	addr, err := ainfo.DialArgs("v1")
+	if err != nil {
+		return nil, xerrors.Errorf("could not get DialArgs: %w", err)
+	}
+	if !strings.HasSuffix(addr, "/rpc/v1") {
+		return nil, xerrors.Errorf("unexpected API address %s", addr)
+	}
+	addr = strings.TrimSuffix(addr, "/rpc/v1") + "/rest/v0/chainstore"
+	addr = strings.Replace(addr, "http://", "ws://", 1)
+	addr = strings.Replace(addr, "https://", "wss://", 1)
+
+	wc, _, err := websocket.DefaultDialer.Dial(addr, ainfo.AuthHeader())
+	if err != nil {
+		return nil, xerrors.Errorf("connecting to the chainstore at %s: %w", addr, err)
+	}
+
+	return blockstore.NewNetworkStoreWS(wc), nil
+}
+
+// ChainstoreReconnectBackoff is the longest delay between attempts to reconnect a
+// RemoteChainstore.
+var ChainstoreReconnectBackoff = time.Minute
+
+// RemoteChainstore is the chain blockstore served by a lotus node, which redials
+// the node in the background whenever the connection drops. Operations fail
+// while the node is unreachable.
+type RemoteChainstore struct {
+	ainfo APIInfo
+
+	mx sync.RWMutex
+	ns *blockstore.NetworkStore // nil while disconnected
+
+	ctx    context.Context
+	cancel func()
+}
+
+var _ blockstore.Blockstore = (*RemoteChainstore)(nil)
+
+// NewRemoteChainstore connects to the chain blockstore served by the lotus node
+// with the API info, as DialChainstore does.
+func NewRemoteChainstore(ainfo APIInfo) (*RemoteChainstore, error) {
+	ns, err := DialChainstore(ainfo)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rc := &RemoteChainstore{
+		ainfo:  ainfo,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	rc.attach(ns)
+
+	return rc, nil
+}
+
+// attach makes ns the current connection, unless the store was stopped.
+func (rc *RemoteChainstore) attach(ns *blockstore.NetworkStore) bool {
+	rc.mx.Lock()
+	if rc.ctx.Err() != nil {
+		rc.mx.Unlock()
+		return false
+	}
+	rc.ns = ns
+	rc.mx.Unlock()
+
+	ns.OnClose(func() {
+		go rc.disconnected(ns)
+	})
+	return true
+}
+
+func (rc *RemoteChainstore) disconnected(ns *blockstore.NetworkStore) {
+	rc.mx.Lock()
+	if rc.ns != ns {
+		// stopped
+		rc.mx.Unlock()
+		return
+	}
+	rc.ns = nil
+	rc.mx.Unlock()
+
+	log.Errorw("connection to the remote chainstore closed; reconnecting", "addr", rc.ainfo.Addr)
+
+	backoff := time.Second
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-rc.ctx.Done():
+			return
+		}
+
+		ns, err := DialChainstore(rc.ainfo)
+		if err != nil {
+			log.Warnw("error reconnecting to the remote chainstore", "addr", rc.ainfo.Addr, "error", err)
+			if backoff *= 2; backoff > ChainstoreReconnectBackoff {
+				backoff = ChainstoreReconnectBackoff
+			}
+			continue
+		}
+
+		if !rc.attach(ns) {
+			_ = ns.Stop(context.Background())
+			return
+		}
+
+		log.Infow("reconnected to the remote chainstore", "addr", rc.ainfo.Addr)
+		return
+	}
+}
+
+func (rc *RemoteChainstore) store() (*blockstore.NetworkStore, error) {
+	rc.mx.RLock()
+	defer rc.mx.RUnlock()
+
+	if rc.ns == nil {
+		return nil, xerrors.Errorf("remote chainstore at %s is disconnected", rc.ainfo.Addr)
+	}
+	return rc.ns, nil
+}
+
+func (rc *RemoteChainstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	ns, err := rc.store()
+	if err != nil {
+		return false, err
+	}
+	return ns.Has(ctx, c)
+}
+
+func (rc *RemoteChainstore) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	ns, err := rc.store()
+	if err != nil {
+		return nil, err
+	}
+	return ns.HasMany(ctx, cids)
+}
+
+func (rc *RemoteChainstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	ns, err := rc.store()
+	if err != nil {
+		return nil, err
+	}
+	return ns.Get(ctx, c)
+}
+
+func (rc *RemoteChainstore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	ns, err := rc.store()
+	if err != nil {
+		return nil, err
+	}
+	return ns.GetMany(ctx, cids)
+}
+
+func (rc *RemoteChainstore) View(ctx context.Context, c cid.Cid, cb func([]byte) error) error {
+	ns, err := rc.store()
+	if err != nil {
+		return err
+	}
+	return ns.View(ctx, c, cb)
+}
+
+func (rc *RemoteChainstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	ns, err := rc.store()
+	if err != nil {
+		return 0, err
+	}
+	return ns.GetSize(ctx, c)
+}
+
+func (rc *RemoteChainstore) Put(ctx context.Context, blk blocks.Block) error {
+	ns, err := rc.store()
+	if err != nil {
+		return err
+	}
+	return ns.Put(ctx, blk)
+}
+
+func (rc *RemoteChainstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	ns, err := rc.store()
+	if err != nil {
+		return err
+	}
+	return ns.PutMany(ctx, blks)
+}
+
+func (rc *RemoteChainstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	ns, err := rc.store()
+	if err != nil {
+		return err
+	}
+	return ns.DeleteBlock(ctx, c)
+}
+
+func (rc *RemoteChainstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	ns, err := rc.store()
+	if err != nil {
+		return err
+	}
+	return ns.DeleteMany(ctx, cids)
+}
+
+func (rc *RemoteChainstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	ns, err := rc.store()
+	if err != nil {
+		return nil, err
+	}
+	return ns.AllKeysChan(ctx)
+}
+
+func (rc *RemoteChainstore) HashOnRead(enabled bool) {
+	if ns, err := rc.store(); err == nil {
+		ns.HashOnRead(enabled)
+	}
+}
+
+func (rc *RemoteChainstore) Flush(context.Context) error { return nil }
+
+// Stop closes the connection and stops reconnecting.
+func (rc *RemoteChainstore) Stop(ctx context.Context) error {
+	rc.cancel()
+
+	rc.mx.Lock()
+	ns := rc.ns
+	rc.ns = nil
+	rc.mx.Unlock()
+
+	if ns == nil {
+		return nil
+	}
+	return ns.Stop(ctx)
+}
+
+// SharedChainstoreFullNode reads chain objects from a shared chainstore, and
+// falls back to the API when reading from the store fails.
+type SharedChainstoreFullNode struct {
+	v1api.FullNode
+
+	Chainstore blockstore.Blockstore
+}
+
+func (n *SharedChainstoreFullNode) ChainReadObj(ctx context.Context, c cid.Cid) ([]byte, error) {
+	blk, err := n.Chainstore.Get(ctx, c)
+	if err == nil {
+		return blk.RawData(), nil
+	}
+	return n.FullNode.ChainReadObj(ctx, c)
+}
+
+func (n *SharedChainstoreFullNode) ChainHasObj(ctx context.Context, c cid.Cid) (bool, error) {
+	has, err := n.Chainstore.Has(ctx, c)
+	if err == nil && has {
+		return true, nil
+	}
+	return n.FullNode.ChainHasObj(ctx, c)
+}
@@ -0,0 +1,84 @@
+package cliutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+func TestRemoteChainstoreReconnect(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemory()
+	blk := blocks.NewBlock([]byte("chain object"))
+	require.NoError(t, bs.Put(ctx, blk))
+
+	var (
+		lk    sync.Mutex
+		conns []*websocket.Conn
+		down  bool
+	)
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		defer lk.Unlock()
+
+		if down || r.URL.Path != "/rest/v0/chainstore" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns = append(conns, c)
+		blockstore.HandleNetBstoreWS(context.Background(), bs, c)
+	}))
+	defer srv.Close()
+
+	rc, err := NewRemoteChainstore(APIInfo{Addr: srv.URL})
+	require.NoError(t, err)
+	defer rc.Stop(ctx) //nolint:errcheck
+
+	has, err := rc.Has(ctx, blk.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// drop the connection while the node is unreachable
+	lk.Lock()
+	down = true
+	for _, c := range conns {
+		_ = c.Close()
+	}
+	lk.Unlock()
+
+	require.Eventually(t, func() bool {
+		_, err := rc.Has(ctx, blk.Cid())
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond, "operations must fail while disconnected")
+
+	lk.Lock()
+	down = false
+	lk.Unlock()
+
+	require.Eventually(t, func() bool {
+		has, err := rc.Has(ctx, blk.Cid())
+		return err == nil && has
+	}, 10*time.Second, 50*time.Millisecond, "the chainstore must reconnect")
+
+	// no reconnection after stopping
+	require.NoError(t, rc.Stop(ctx))
+	_, err = rc.Has(ctx, blk.Cid())
+	require.Error(t, err)
+}
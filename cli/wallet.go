@@ -3,14 +3,18 @@ package cli
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
@@ -19,7 +23,10 @@ import (
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/network"
 
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
 )
@@ -33,11 +40,16 @@ var walletCmd = &cli.Command{
 		walletBalance,
 		walletExport,
 		walletImport,
+		walletExportEncrypted,
+		walletImportEncrypted,
 		walletGetDefault,
 		walletSetDefault,
 		walletSign,
 		walletVerify,
 		walletDelete,
+		walletWatch,
+		walletUnwatch,
+		walletHistory,
 		walletMarket,
 	},
 }
@@ -93,7 +105,7 @@ var walletList = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -107,6 +119,16 @@ var walletList = &cli.Command{
 			return err
 		}
 
+		watched, err := api.WalletListWatched(ctx)
+		if err != nil {
+			return xerrors.Errorf("listing watch-only addresses: %w", err)
+		}
+		watchOnly := map[address.Address]struct{}{}
+		for _, a := range watched {
+			watchOnly[a] = struct{}{}
+		}
+		addrs = append(addrs, watched...)
+
 		// Assume an error means no default key is set
 		def, _ := api.WalletDefaultAddress(ctx)
 
@@ -118,6 +140,7 @@ var walletList = &cli.Command{
 			tablewriter.Col("Market(Locked)"),
 			tablewriter.Col("Nonce"),
 			tablewriter.Col("Default"),
+			tablewriter.Col("Watch-only"),
 			tablewriter.NewLineCol("Error"))
 
 		for _, addr := range addrs {
@@ -147,6 +170,9 @@ var walletList = &cli.Command{
 				if addr == def {
 					row["Default"] = "X"
 				}
+				if _, ok := watchOnly[addr]; ok {
+					row["Watch-only"] = "X"
+				}
 
 				if cctx.Bool("id") {
 					id, err := api.StateLookupID(ctx, addr, types.EmptyTSK)
@@ -400,6 +426,153 @@ var walletImport = &cli.Command{
 	},
 }
 
+var walletExportEncrypted = &cli.Command{
+	Name:      "export-encrypted",
+	Usage:     "export a key encrypted with a password",
+	ArgsUsage: "[address]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "password-file",
+			Usage: "read the password from a file instead of prompting for it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		afmt := NewAppFmt(cctx.App)
+
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		password, err := readWalletPassword(cctx, true)
+		if err != nil {
+			return err
+		}
+
+		eki, err := api.WalletExportEncrypted(ctx, addr, password)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(eki)
+		if err != nil {
+			return err
+		}
+
+		afmt.Println(string(b))
+		return nil
+	},
+}
+
+var walletImportEncrypted = &cli.Command{
+	Name:      "import-encrypted",
+	Usage:     "import a key exported with export-encrypted",
+	ArgsUsage: "[<path> (optional, will read from stdin if omitted)]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "password-file",
+			Usage: "read the password from a file instead of prompting for it",
+		},
+		&cli.BoolFlag{
+			Name:  "as-default",
+			Usage: "import the given key as your new default key",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var inpdata []byte
+		if !cctx.Args().Present() || cctx.Args().First() == "-" {
+			if !cctx.IsSet("password-file") {
+				return xerrors.Errorf("--password-file is required when reading the key from stdin")
+			}
+
+			inpdata, err = ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+		} else {
+			inpdata, err = ioutil.ReadFile(cctx.Args().First())
+			if err != nil {
+				return err
+			}
+		}
+
+		var eki types.EncryptedKeyInfo
+		if err := json.Unmarshal(inpdata, &eki); err != nil {
+			return xerrors.Errorf("parsing encrypted key: %w", err)
+		}
+
+		password, err := readWalletPassword(cctx, false)
+		if err != nil {
+			return err
+		}
+
+		addr, err := api.WalletImportEncrypted(ctx, &eki, password)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("as-default") {
+			if err := api.WalletSetDefault(ctx, addr); err != nil {
+				return fmt.Errorf("failed to set default key: %w", err)
+			}
+		}
+
+		fmt.Printf("imported key %s successfully!\n", addr)
+		return nil
+	},
+}
+
+// readWalletPassword reads the password from the --password-file flag, or
+// prompts for it, twice if confirm is set.
+func readWalletPassword(cctx *cli.Context, confirm bool) (string, error) {
+	if cctx.IsSet("password-file") {
+		b, err := ioutil.ReadFile(cctx.String("password-file"))
+		if err != nil {
+			return "", xerrors.Errorf("reading password file: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+
+	fmt.Fprint(cctx.App.ErrWriter, "Password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(cctx.App.ErrWriter)
+	if err != nil {
+		return "", xerrors.Errorf("reading password: %w", err)
+	}
+
+	if confirm {
+		fmt.Fprint(cctx.App.ErrWriter, "Repeat password: ")
+		repeated, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(cctx.App.ErrWriter)
+		if err != nil {
+			return "", xerrors.Errorf("reading password: %w", err)
+		}
+		if !bytes.Equal(password, repeated) {
+			return "", xerrors.Errorf("passwords don't match")
+		}
+	}
+
+	return string(password), nil
+}
+
 var walletSign = &cli.Command{
 	Name:      "sign",
 	Usage:     "sign a message",
@@ -524,6 +697,193 @@ var walletDelete = &cli.Command{
 	},
 }
 
+var walletWatch = &cli.Command{
+	Name:      "watch",
+	Usage:     "Add a watch-only address to the wallet",
+	ArgsUsage: "<address>",
+	Description: `Watch-only addresses are listed alongside the wallet keys, and messages sent from them are
+included in the local mpool views, but they can't be used to sign messages.`,
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return api.WalletWatch(ctx, addr)
+	},
+}
+
+var walletUnwatch = &cli.Command{
+	Name:      "unwatch",
+	Usage:     "Remove a watch-only address from the wallet",
+	ArgsUsage: "<address>",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return api.WalletUnwatch(ctx, addr)
+	},
+}
+
+var walletHistory = &cli.Command{
+	Name:      "history",
+	Usage:     "List the messages sent from and to wallet addresses",
+	ArgsUsage: "[address...]",
+	Description: `Lists the messages sent from or to the given addresses, or to all the addresses in the wallet,
+including the watch-only ones, if no address is given.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "lookback",
+			Usage: "number of epochs to search back from the chain head",
+			Value: int(builtin.EpochsInDay),
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var addrs []address.Address
+		for _, s := range cctx.Args().Slice() {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing address %q: %w", s, err)
+			}
+			addrs = append(addrs, a)
+		}
+		if len(addrs) == 0 {
+			filter, err := walletAddrs(ctx, api)
+			if err != nil {
+				return err
+			}
+			for a := range filter {
+				addrs = append(addrs, a)
+			}
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+		toHeight := head.Height() - abi.ChainEpoch(cctx.Int("lookback"))
+		if toHeight < 0 {
+			toHeight = 0
+		}
+
+		seen := map[cid.Cid]struct{}{}
+		var found []cid.Cid
+		for _, a := range addrs {
+			for _, match := range []*lapi.MessageMatch{{From: a}, {To: a}} {
+				cids, err := api.StateListMessages(ctx, match, head.Key(), toHeight)
+				if err != nil {
+					return xerrors.Errorf("listing messages of %s: %w", a, err)
+				}
+				for _, c := range cids {
+					if _, ok := seen[c]; ok {
+						continue
+					}
+					seen[c] = struct{}{}
+					found = append(found, c)
+				}
+			}
+		}
+
+		type historyEntry struct {
+			msg    *types.Message
+			lookup *lapi.MsgLookup
+		}
+		entries := make([]historyEntry, 0, len(found))
+		for _, c := range found {
+			msg, err := api.ChainGetMessage(ctx, c)
+			if err != nil {
+				return xerrors.Errorf("getting message %s: %w", c, err)
+			}
+			lookup, err := api.StateSearchMsg(ctx, head.Key(), c, lapi.LookbackNoLimit, true)
+			if err != nil {
+				return xerrors.Errorf("searching message %s: %w", c, err)
+			}
+			if lookup == nil {
+				continue
+			}
+			entries = append(entries, historyEntry{msg: msg, lookup: lookup})
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].lookup.Height > entries[j].lookup.Height
+		})
+
+		tw := tablewriter.New(
+			tablewriter.Col("Height"),
+			tablewriter.Col("Cid"),
+			tablewriter.Col("From"),
+			tablewriter.Col("To"),
+			tablewriter.Col("Value"),
+			tablewriter.Col("Method"),
+			tablewriter.Col("Exit"))
+
+		for _, e := range entries {
+			tw.Write(map[string]interface{}{
+				"Height": e.lookup.Height,
+				"Cid":    e.msg.Cid(),
+				"From":   e.msg.From,
+				"To":     e.msg.To,
+				"Value":  types.FIL(e.msg.Value),
+				"Method": e.msg.Method,
+				"Exit":   e.lookup.Receipt.ExitCode,
+			})
+		}
+
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+// walletAddrs returns the addresses in the wallet, including the watch-only ones.
+func walletAddrs(ctx context.Context, api v1api.FullNode) (map[address.Address]struct{}, error) {
+	addrs, err := api.WalletList(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting local addresses: %w", err)
+	}
+
+	watched, err := api.WalletListWatched(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting watch-only addresses: %w", err)
+	}
+
+	out := make(map[address.Address]struct{}, len(addrs)+len(watched))
+	for _, a := range append(addrs, watched...) {
+		out[a] = struct{}{}
+	}
+
+	return out, nil
+}
+
 var walletMarket = &cli.Command{
 	Name:  "market",
 	Usage: "Interact with market balances",
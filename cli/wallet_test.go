@@ -66,15 +66,20 @@ func TestWalletList(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
+		watched, err := address.NewIDAddress(5678)
+		assert.NoError(t, err)
+
 		gomock.InOrder(
 			mockApi.EXPECT().WalletList(ctx).Return(addresses, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{watched}, nil),
 			mockApi.EXPECT().WalletDefaultAddress(ctx).Return(addr, nil),
 		)
 
 		//stm: @CLI_WALLET_LIST_001
-		err := app.Run([]string{"wallet", "list", "--addr-only"})
+		err = app.Run([]string{"wallet", "list", "--addr-only"})
 		assert.NoError(t, err)
 		assert.Contains(t, buf.String(), addr.String())
+		assert.Contains(t, buf.String(), watched.String())
 	})
 	t.Run("wallet-list-id", func(t *testing.T) {
 
@@ -86,6 +91,7 @@ func TestWalletList(t *testing.T) {
 
 		gomock.InOrder(
 			mockApi.EXPECT().WalletList(ctx).Return(addresses, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{}, nil),
 			mockApi.EXPECT().WalletDefaultAddress(ctx).Return(addr, nil),
 			mockApi.EXPECT().StateGetActor(ctx, addr, key).Return(&actor, nil),
 			mockApi.EXPECT().StateLookupID(ctx, addr, key).Return(addr, nil),
@@ -110,6 +116,7 @@ func TestWalletList(t *testing.T) {
 
 		gomock.InOrder(
 			mockApi.EXPECT().WalletList(ctx).Return(addresses, nil),
+			mockApi.EXPECT().WalletListWatched(ctx).Return([]address.Address{}, nil),
 			mockApi.EXPECT().WalletDefaultAddress(ctx).Return(addr, nil),
 			mockApi.EXPECT().StateGetActor(ctx, addr, key).Return(&actor, nil),
 			mockApi.EXPECT().StateMarketBalance(ctx, addr, key).Return(balance, nil),
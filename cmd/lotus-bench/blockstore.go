@@ -0,0 +1,631 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/urfave/cli/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// stateObjectSizes approximates the size distribution of the objects of the
+// mainnet state tree: mostly small HAMT/AMT nodes and actor heads, with a tail of
+// large nodes.
+var stateObjectSizes = []struct {
+	weight   int
+	min, max int
+}{
+	{weight: 40, min: 32, max: 128},
+	{weight: 35, min: 128, max: 512},
+	{weight: 20, min: 512, max: 2048},
+	{weight: 5, min: 2048, max: 16384},
+}
+
+var blockstoreBenchCmd = &cli.Command{
+	Name:  "blockstore",
+	Usage: "Benchmark blockstore operations across backends and splitstore configurations",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "backend",
+			Usage: "backends to benchmark: memory, badger, splitstore",
+			Value: cli.NewStringSlice("memory", "badger", "splitstore"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "splitstore-markset",
+			Usage: "markset types of the splitstore configurations to benchmark: map, badger",
+			Value: cli.NewStringSlice("map"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "splitstore-coldstore",
+			Usage: "coldstore types of the splitstore configurations to benchmark: messages, universal, discard",
+			Value: cli.NewStringSlice("universal"),
+		},
+		&cli.IntFlag{
+			Name:  "objects",
+			Usage: "number of objects to write and read",
+			Value: 100000,
+		},
+		&cli.IntFlag{
+			Name:  "batch",
+			Usage: "number of objects per PutMany",
+			Value: 256,
+		},
+		&cli.IntFlag{
+			Name:  "parallel",
+			Usage: "number of concurrent workers",
+			Value: runtime.NumCPU(),
+		},
+		&cli.Int64Flag{
+			Name:  "seed",
+			Usage: "seed of the generated objects",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "directory of the on-disk backends (defaults to a temporary directory)",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := cctx.Context
+
+		n := cctx.Int("objects")
+		batch := cctx.Int("batch")
+		parallel := cctx.Int("parallel")
+		if n < 2 || batch < 1 || parallel < 1 {
+			return xerrors.Errorf("objects must be at least 2, batch and parallel at least 1")
+		}
+
+		dir := cctx.String("dir")
+		if dir == "" {
+			tmp, err := os.MkdirTemp("", "lotus-blockstore-bench")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmp) //nolint:errcheck
+			dir = tmp
+		}
+
+		log.Infow("generating objects", "objects", n)
+		objs, err := genStateObjects(rand.New(rand.NewSource(cctx.Int64("seed"))), n)
+		if err != nil {
+			return err
+		}
+
+		var configs []blockstoreBenchConfig
+		for _, backend := range cctx.StringSlice("backend") {
+			switch backend {
+			case "memory", "badger":
+				configs = append(configs, blockstoreBenchConfig{name: backend, backend: backend})
+			case "splitstore":
+				for _, markset := range cctx.StringSlice("splitstore-markset") {
+					for _, coldstore := range cctx.StringSlice("splitstore-coldstore") {
+						configs = append(configs, blockstoreBenchConfig{
+							name:      fmt.Sprintf("splitstore/%s/%s", markset, coldstore),
+							backend:   backend,
+							markset:   markset,
+							coldstore: coldstore,
+						})
+					}
+				}
+			default:
+				return xerrors.Errorf("unknown backend %s", backend)
+			}
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "BACKEND\tOP\tOPS\tOPS/S\tMB/S\tP50\tP99\n")
+
+		for i, cfg := range configs {
+			log.Infow("benchmarking", "backend", cfg.name)
+
+			st, err := cfg.open(ctx, filepath.Join(dir, fmt.Sprintf("%d", i)), objs)
+			if err != nil {
+				return xerrors.Errorf("opening %s: %w", cfg.name, err)
+			}
+
+			results, err := benchBlockstore(ctx, st, objs, batch, parallel)
+			if cerr := st.close(); cerr != nil {
+				log.Warnf("closing %s: %s", cfg.name, cerr)
+			}
+			if err != nil {
+				return xerrors.Errorf("benchmarking %s: %w", cfg.name, err)
+			}
+
+			for _, r := range results {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%.0f\t%.2f\t%s\t%s\n",
+					cfg.name, r.op, r.ops, r.opsPerSec(), r.mbPerSec(), r.percentile(0.5), r.percentile(0.99))
+			}
+		}
+
+		return tw.Flush()
+	},
+}
+
+type blockstoreBenchConfig struct {
+	name      string
+	backend   string
+	markset   string
+	coldstore string
+}
+
+// benchStore is a blockstore under benchmark.
+type benchStore struct {
+	bs    blockstore.Blockstore
+	close func() error
+	// compact, if set, compacts the store once the objects are written, and returns the
+	// objects still available afterwards.
+	compact func() ([]blocks.Block, error)
+}
+
+func (cfg blockstoreBenchConfig) open(ctx context.Context, path string, objs []blocks.Block) (*benchStore, error) {
+	switch cfg.backend {
+	case "memory":
+		return &benchStore{bs: blockstore.NewMemorySync(), close: func() error { return nil }}, nil
+
+	case "badger":
+		bs, err := openBenchBadger(repo.UniversalBlockstore, path)
+		if err != nil {
+			return nil, err
+		}
+		return &benchStore{bs: bs, close: bs.Close}, nil
+
+	case "splitstore":
+		return cfg.openSplitstore(ctx, path, objs)
+
+	default:
+		return nil, xerrors.Errorf("unknown backend %s", cfg.backend)
+	}
+}
+
+func openBenchBadger(domain repo.BlockstoreDomain, path string) (*badgerbs.Blockstore, error) {
+	opts, err := repo.BadgerBlockstoreOptions(domain, path, false)
+	if err != nil {
+		return nil, err
+	}
+	opts.SyncWrites = false
+	return badgerbs.Open(opts)
+}
+
+// openSplitstore opens a splitstore whose objects hang off a synthetic chain, so that
+// compacting it exercises the markset and the coldstore of the configuration: every other
+// object is linked from the state of the head and stays in the hotstore, while the rest is
+// linked from the messages of an old tipset, and moves to the coldstore unless it is
+// discarded.
+func (cfg blockstoreBenchConfig) openSplitstore(ctx context.Context, path string, objs []blocks.Block) (*benchStore, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	var live, old []cid.Cid
+	for i, blk := range objs {
+		if i%2 == 0 {
+			live = append(live, blk.Cid())
+		} else {
+			old = append(old, blk.Cid())
+		}
+	}
+
+	empty, err := cborLinks(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := mock.MkBlock(nil, 0, 0)
+	genesis.ParentStateRoot = empty.Cid()
+	genesis.Messages = empty.Cid()
+	genesis.ParentMessageReceipts = empty.Cid()
+	genesisBlk, err := genesis.ToStorageBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	universal, err := openBenchBadger(repo.UniversalBlockstore, filepath.Join(path, "cold"))
+	if err != nil {
+		return nil, err
+	}
+
+	// the genesis is in the coldstore, as it is after importing a snapshot
+	if err := universal.PutMany(ctx, []blocks.Block{empty, genesisBlk}); err != nil {
+		_ = universal.Close()
+		return nil, err
+	}
+
+	var cold blockstore.Blockstore = universal
+	if cfg.coldstore == "discard" {
+		cold = blockstore.NewDiscardStore(universal)
+	}
+
+	hot, err := openBenchBadger(repo.HotBlockstore, filepath.Join(path, "hot"))
+	if err != nil {
+		_ = universal.Close()
+		return nil, err
+	}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ss, err := splitstore.Open(filepath.Join(path, "splitstore"), ds, hot, cold, &splitstore.Config{
+		MarkSetType:         cfg.markset,
+		DiscardColdBlocks:   cfg.coldstore == "discard",
+		UniversalColdBlocks: cfg.coldstore == "universal",
+	})
+	if err != nil {
+		_ = hot.Close()
+		_ = universal.Close()
+		return nil, err
+	}
+
+	closer := func() error {
+		err := ss.Close()
+		if herr := hot.Close(); err == nil {
+			err = herr
+		}
+		if cerr := universal.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	warmedUp := make(chan error, 1)
+	ss.AddOperationHook(func(op string, err error) {
+		if op == "warmup" {
+			warmedUp <- err
+		}
+	})
+
+	if err := ss.Start(benchChain{head: mock.TipSet(genesis)}, nil); err != nil {
+		_ = closer()
+		return nil, xerrors.Errorf("starting splitstore: %w", err)
+	}
+	if err := <-warmedUp; err != nil {
+		_ = closer()
+		return nil, xerrors.Errorf("warming up splitstore: %w", err)
+	}
+
+	compact := func() ([]blocks.Block, error) {
+		liveBlks, liveRoot, err := linkObjects(live)
+		if err != nil {
+			return nil, err
+		}
+		oldBlks, oldRoot, err := linkObjects(old)
+		if err != nil {
+			return nil, err
+		}
+
+		// an old tipset below the compaction boundary with the messages, and the head
+		// with the state
+		oldHdr := mock.MkBlock(mock.TipSet(genesis), 1, 1)
+		oldHdr.ParentStateRoot = empty.Cid()
+		oldHdr.Messages = oldRoot
+		oldHdr.ParentMessageReceipts = empty.Cid()
+		oldHdrBlk, err := oldHdr.ToStorageBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		head := mock.MkBlock(mock.TipSet(oldHdr), 1, 2)
+		head.Height = splitstore.CompactionBoundary + 2
+		head.ParentStateRoot = liveRoot
+		head.Messages = empty.Cid()
+		head.ParentMessageReceipts = empty.Cid()
+		headBlk, err := head.ToStorageBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		chainBlks := append(append(liveBlks, oldBlks...), oldHdrBlk, headBlk)
+		if err := ss.PutMany(ctx, chainBlks); err != nil {
+			return nil, err
+		}
+
+		ops := []splitstore.ReplayOp{{Time: time.Now(), Compact: types.NewTipSetKey(head.Cid())}}
+		err = ss.Replay(ops, blockstore.NewMemory(), func(res *splitstore.ReplayCompaction) error {
+			if len(res.Missing) > 0 {
+				return xerrors.Errorf("compaction lost %d live objects", len(res.Missing))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.coldstore != "discard" {
+			return objs, nil
+		}
+
+		available := make([]blocks.Block, 0, len(live))
+		for i := 0; i < len(objs); i += 2 {
+			available = append(available, objs[i])
+		}
+		return available, nil
+	}
+
+	return &benchStore{bs: ss, close: closer, compact: compact}, nil
+}
+
+// benchChain is the chain of a benchmarked splitstore; compactions are driven by the
+// benchmark through Replay.
+type benchChain struct {
+	head *types.TipSet
+}
+
+func (c benchChain) GetTipsetByHeight(context.Context, abi.ChainEpoch, *types.TipSet, bool) (*types.TipSet, error) {
+	return nil, xerrors.Errorf("no chain in the benchmark")
+}
+
+func (c benchChain) GetHeaviestTipSet() *types.TipSet {
+	return c.head
+}
+
+func (benchChain) SubscribeHeadChanges(func(revert []*types.TipSet, apply []*types.TipSet) error) {}
+
+// linkObjects links the given objects from a tree of nodes of up to 1024 links each, and returns the nodes along with the root.
+func linkObjects(links []cid.Cid) ([]blocks.Block, cid.Cid, error) {
+	const linksPerNode = 1024
+
+	var nodes []blocks.Block
+	for len(links) > linksPerNode {
+		parents := make([]cid.Cid, 0, (len(links)+linksPerNode-1)/linksPerNode)
+		for start := 0; start < len(links); start += linksPerNode {
+			end := start + linksPerNode
+			if end > len(links) {
+				end = len(links)
+			}
+
+			nd, err := cborLinks(links[start:end])
+			if err != nil {
+				return nil, cid.Undef, err
+			}
+			nodes = append(nodes, nd)
+			parents = append(parents, nd.Cid())
+		}
+		links = parents
+	}
+
+	root, err := cborLinks(links)
+	if err != nil {
+		return nil, cid.Undef, err
+	}
+	return append(nodes, root), root.Cid(), nil
+}
+
+// cborLinks encodes the links as a CBOR list.
+func cborLinks(links []cid.Cid) (blocks.Block, error) {
+	var buf bytes.Buffer
+	if err := cbg.CborWriteHeader(&buf, cbg.MajArray, uint64(len(links))); err != nil {
+		return nil, err
+	}
+	for _, c := range links {
+		if err := cbg.WriteCid(&buf, c); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := abi.CidBuilder.Sum(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return blocks.NewBlockWithCid(buf.Bytes(), c)
+}
+
+// genStateObjects generates n objects with the size distribution of state objects. Objects
+// are CBOR byte strings of random data, so that compaction can walk them.
+func genStateObjects(rng *rand.Rand, n int) ([]blocks.Block, error) {
+	total := 0
+	for _, s := range stateObjectSizes {
+		total += s.weight
+	}
+
+	objs := make([]blocks.Block, 0, n)
+	for len(objs) < n {
+		w := rng.Intn(total)
+		for _, s := range stateObjectSizes {
+			if w >= s.weight {
+				w -= s.weight
+				continue
+			}
+
+			data := make([]byte, s.min+rng.Intn(s.max-s.min))
+			_, _ = rng.Read(data)
+
+			var buf bytes.Buffer
+			if err := cbg.WriteByteArray(&buf, data); err != nil {
+				return nil, err
+			}
+
+			c, err := abi.CidBuilder.Sum(buf.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			blk, err := blocks.NewBlockWithCid(buf.Bytes(), c)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, blk)
+			break
+		}
+	}
+
+	return objs, nil
+}
+
+type blockstoreBenchResult struct {
+	op        string
+	ops       int
+	bytes     int64
+	took      time.Duration
+	latencies []time.Duration
+}
+
+func (r *blockstoreBenchResult) opsPerSec() float64 {
+	return float64(r.ops) / r.took.Seconds()
+}
+
+func (r *blockstoreBenchResult) mbPerSec() float64 {
+	return float64(r.bytes) / (1 << 20) / r.took.Seconds()
+}
+
+func (r *blockstoreBenchResult) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+	return r.latencies[int(p*float64(len(r.latencies)-1))]
+}
+
+// benchBlockstore writes the first half of the objects with Put and the second half
+// with PutMany, compacts the store if it can be compacted, and then reads back all the
+// objects still available.
+func benchBlockstore(ctx context.Context, st *benchStore, objs []blocks.Block, batch, parallel int) ([]*blockstoreBenchResult, error) {
+	bs := st.bs
+	half := len(objs) / 2
+
+	// run calls f with every item of items in parallel, timing every call
+	run := func(op string, items int, f func(i int) (int, error)) (*blockstoreBenchResult, error) {
+		res := &blockstoreBenchResult{op: op, ops: items, latencies: make([]time.Duration, items)}
+		var mx sync.Mutex
+
+		work := make(chan int, items)
+		for i := 0; i < items; i++ {
+			work <- i
+		}
+		close(work)
+
+		start := time.Now()
+		var g errgroup.Group
+		for w := 0; w < parallel; w++ {
+			g.Go(func() error {
+				var bytes int64
+				for i := range work {
+					opStart := time.Now()
+					n, err := f(i)
+					if err != nil {
+						return xerrors.Errorf("%s: %w", op, err)
+					}
+					res.latencies[i] = time.Since(opStart)
+					bytes += int64(n)
+				}
+
+				mx.Lock()
+				res.bytes += bytes
+				mx.Unlock()
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+		res.took = time.Since(start)
+
+		return res, nil
+	}
+
+	var results []*blockstoreBenchResult
+	add := func(res *blockstoreBenchResult, err error) error {
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		return nil
+	}
+
+	err := add(run("Put", half, func(i int) (int, error) {
+		return len(objs[i].RawData()), bs.Put(ctx, objs[i])
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	batches := (len(objs) - half + batch - 1) / batch
+	err = add(run("PutMany", batches, func(i int) (int, error) {
+		start := half + i*batch
+		end := start + batch
+		if end > len(objs) {
+			end = len(objs)
+		}
+
+		n := 0
+		for _, blk := range objs[start:end] {
+			n += len(blk.RawData())
+		}
+		return n, bs.PutMany(ctx, objs[start:end])
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	// compaction is a single sequential op
+	if st.compact != nil {
+		start := time.Now()
+		objs, err = st.compact()
+		if err != nil {
+			return nil, xerrors.Errorf("Compact: %w", err)
+		}
+		took := time.Since(start)
+		results = append(results, &blockstoreBenchResult{op: "Compact", ops: 1, took: took, latencies: []time.Duration{took}})
+	}
+
+	// reads are in random order, as reads of the state are
+	order := rand.New(rand.NewSource(int64(len(objs)))).Perm(len(objs))
+
+	err = add(run("Get", len(objs), func(i int) (int, error) {
+		blk, err := bs.Get(ctx, objs[order[i]].Cid())
+		if err != nil {
+			return 0, err
+		}
+		return len(blk.RawData()), nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	err = add(run("Has", len(objs), func(i int) (int, error) {
+		has, err := bs.Has(ctx, objs[order[i]].Cid())
+		if err == nil && !has {
+			err = xerrors.Errorf("object %s not found", objs[order[i]].Cid())
+		}
+		return 0, err
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	// iteration is a single sequential op
+	start := time.Now()
+	ch, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("AllKeysChan: %w", err)
+	}
+	keys := 0
+	for range ch {
+		keys++
+	}
+	results = append(results, &blockstoreBenchResult{op: "AllKeysChan", ops: keys, took: time.Since(start)})
+	if keys < len(objs) {
+		return nil, xerrors.Errorf("AllKeysChan: expected at least %d keys, got %d", len(objs), keys)
+	}
+
+	return results, nil
+}
@@ -107,6 +107,7 @@ func main() {
 			sealBenchCmd,
 			simpleCmd,
 			importBenchCmd,
+			blockstoreBenchCmd,
 		},
 	}
 
@@ -48,6 +48,7 @@ var actorCmd = &cli.Command{
 		actorControl,
 		actorProposeChangeWorker,
 		actorConfirmChangeWorker,
+		actorRotateKeysCmd,
 		actorCompactAllocatedCmd,
 		actorProposeChangeBeneficiary,
 		actorConfirmChangeBeneficiary,
@@ -927,6 +928,185 @@ var actorProposeChangeWorker = &cli.Command{
 	},
 }
 
+var actorRotateKeysCmd = &cli.Command{
+	Name:  "rotate-keys",
+	Usage: "Change the owner and / or worker keys, sending the proposal and confirmation messages in order",
+	Description: `Plans the messages changing the owner and / or worker keys, dry-runs them and estimates their
+fees. With --really-do-it, the messages which can be sent right away are sent one after the other;
+the new owner key must be in the wallet of the node to confirm an owner change.
+
+A worker key change can only be confirmed a finality after it was proposed; run the command again
+with the same arguments at or after the printed height to send the confirmation.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "owner",
+			Usage: "new owner address",
+		},
+		&cli.StringFlag{
+			Name:  "worker",
+			Usage: "new worker address",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send the messages performing the action",
+			Value: false,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.IsSet("owner") && !cctx.IsSet("worker") {
+			return xerrors.Errorf("must pass --owner and / or --worker")
+		}
+
+		api, acloser, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		newOwner, newWorker := address.Undef, address.Undef
+		if cctx.IsSet("owner") {
+			if newOwner, err = address.NewFromString(cctx.String("owner")); err != nil {
+				return xerrors.Errorf("parsing owner address: %w", err)
+			}
+		}
+		if cctx.IsSet("worker") {
+			if newWorker, err = address.NewFromString(cctx.String("worker")); err != nil {
+				return xerrors.Errorf("parsing worker address: %w", err)
+			}
+		}
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		steps, err := api.StateMinerKeyRotation(ctx, maddr, newOwner, newWorker, head.Key())
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("#"),
+			tablewriter.Col("step"),
+			tablewriter.Col("from"),
+			tablewriter.Col("not before"),
+			tablewriter.Col("dry run"),
+			tablewriter.Col("max fee"),
+		)
+
+		var ready []*types.Message
+		var failed bool
+		for i, step := range steps {
+			row := map[string]interface{}{
+				"#":          i + 1,
+				"step":       step.Description,
+				"from":       step.Message.From,
+				"not before": step.NotBefore,
+				"dry run":    "-",
+				"max fee":    "-",
+			}
+
+			if step.DryRun != nil {
+				switch {
+				case step.DryRun.Error != "":
+					row["dry run"] = color.RedString("failed: %s", step.DryRun.Error)
+					failed = true
+				case step.DryRun.MsgRct.ExitCode.IsError():
+					row["dry run"] = color.RedString("exit %s", step.DryRun.MsgRct.ExitCode)
+					failed = true
+				default:
+					row["dry run"] = color.GreenString("ok, %d gas", step.DryRun.MsgRct.GasUsed)
+
+					msg := step.Message
+					msg.GasLimit = step.DryRun.MsgRct.GasUsed * 5 / 4
+					if msg.GasPremium, err = api.GasEstimateGasPremium(ctx, 10, msg.From, msg.GasLimit, types.EmptyTSK); err != nil {
+						return xerrors.Errorf("estimating gas premium: %w", err)
+					}
+					if msg.GasFeeCap, err = api.GasEstimateFeeCap(ctx, msg, 20, types.EmptyTSK); err != nil {
+						return xerrors.Errorf("estimating fee cap: %w", err)
+					}
+					row["max fee"] = types.FIL(msg.RequiredFunds())
+
+					ready = append(ready, msg)
+				}
+			}
+
+			tw.Write(row)
+		}
+
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		if failed {
+			return xerrors.Errorf("dry run failed, not sending anything")
+		}
+
+		// don't start a rotation which can't be completed from this node
+		for _, msg := range ready {
+			key, err := api.StateAccountKey(ctx, msg.From, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("looking up key of %s: %w", msg.From, err)
+			}
+			has, err := api.WalletHas(ctx, key)
+			if err != nil {
+				return err
+			}
+			if !has {
+				return xerrors.Errorf("key %s (%s) isn't in the wallet of the node", key, msg.From)
+			}
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		for i, msg := range ready {
+			smsg, err := api.MpoolPushMessage(ctx, msg, nil)
+			if err != nil {
+				return xerrors.Errorf("mpool push: %w", err)
+			}
+
+			fmt.Printf("%s: message %s\n", steps[i].Description, smsg.Cid())
+
+			// wait for it to get mined into a block before sending the next one
+			wait, err := api.StateWaitMsg(ctx, smsg.Cid(), build.MessageConfidence, build.Finality, true)
+			if err != nil {
+				return err
+			}
+
+			if wait.Receipt.ExitCode.IsError() {
+				return xerrors.Errorf("%s failed with exit %s", steps[i].Description, wait.Receipt.ExitCode)
+			}
+		}
+
+		if len(ready) < len(steps) {
+			next := steps[len(ready)]
+			if len(ready) > 0 {
+				mi, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+				if err != nil {
+					return err
+				}
+				next.NotBefore = mi.WorkerChangeEpoch
+			}
+
+			fmt.Printf("To %s, run this command again at or after height %d.\n", next.Description, next.NotBefore)
+			return nil
+		}
+
+		fmt.Println("key rotation complete!")
+		return nil
+	},
+}
+
 var actorProposeChangeBeneficiary = &cli.Command{
 	Name:      "propose-change-beneficiary",
 	Usage:     "Propose a beneficiary address change",
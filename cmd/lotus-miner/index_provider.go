@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
@@ -15,6 +18,8 @@ var indexProvCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		indexProvAnnounceCmd,
 		indexProvAnnounceAllCmd,
+		indexProvAnnounceEndpointCmd,
+		indexProvEndpointQueueCmd,
 	},
 }
 
@@ -60,3 +65,67 @@ var indexProvAnnounceAllCmd = &cli.Command{
 		return marketsApi.IndexerAnnounceAllDeals(ctx)
 	},
 }
+
+var indexProvAnnounceEndpointCmd = &cli.Command{
+	Name:      "announce-endpoint",
+	ArgsUsage: "[deal proposal cids...]",
+	Usage:     "Queue announcements of active deals to the configured indexer endpoint",
+	Description: `Queue announcements of the given active deals to the HTTP indexer endpoint
+configured in IndexProvider.AnnounceEndpoint. Without arguments all active
+deals are announced again.`,
+	Action: func(cctx *cli.Context) error {
+		var proposalCids []cid.Cid
+		for _, s := range cctx.Args().Slice() {
+			proposalCid, err := cid.Parse(s)
+			if err != nil {
+				return fmt.Errorf("invalid deal proposal CID %q: %w", s, err)
+			}
+			proposalCids = append(proposalCids, proposalCid)
+		}
+
+		marketsApi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		n, err := marketsApi.IndexerEndpointAnnounceDeals(ctx, proposalCids)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("queued %d announcements\n", n)
+		return nil
+	},
+}
+
+var indexProvEndpointQueueCmd = &cli.Command{
+	Name:  "endpoint-queue",
+	Usage: "List the announcements waiting to be delivered to the indexer endpoint",
+	Action: func(cctx *cli.Context) error {
+		marketsApi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		queue, err := marketsApi.IndexerEndpointQueue(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ProposalCid\tDealID\tPieceCid\tAttempts\tNext Attempt\tLast Error\n")
+		for _, p := range queue {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\t%s\n",
+				p.Announcement.ProposalCid, p.Announcement.DealID, p.Announcement.PieceCid,
+				p.Attempts, p.NextAttempt.Format(time.Stamp), p.LastError)
+		}
+
+		return w.Flush()
+	},
+}
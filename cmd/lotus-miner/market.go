@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -343,6 +345,7 @@ var storageDealsCmd = &cli.Command{
 	Usage: "Manage storage deals and related configuration",
 	Subcommands: []*cli.Command{
 		dealsImportDataCmd,
+		dealsImportDataBatchCmd,
 		dealsListCmd,
 		storageDealSelectionCmd,
 		setAskCmd,
@@ -353,6 +356,7 @@ var storageDealsCmd = &cli.Command{
 		setSealDurationCmd,
 		dealsPendingPublish,
 		dealsRetryPublish,
+		dealsQuotasCmd,
 	},
 }
 
@@ -385,6 +389,211 @@ var dealsImportDataCmd = &cli.Command{
 	},
 }
 
+var dealsImportDataBatchCmd = &cli.Command{
+	Name:      "import-data-batch",
+	Usage:     "Manually import data for many deals",
+	ArgsUsage: "<manifest file>",
+	Description: `The manifest is a CSV file with one deal per line, in the form:
+
+   <proposal CID or deal ID>,<file>
+
+Lines starting with # are ignored. Relative file paths are resolved against the
+directory of the manifest. Deals are imported concurrently; a failed import
+doesn't stop the others, and the failures are listed once all deals are done.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "parallel",
+			Usage: "number of deals to import at the same time",
+			Value: 4,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+		if cctx.Int("parallel") < 1 {
+			return xerrors.Errorf("--parallel must be at least 1")
+		}
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.DaemonContext(cctx)
+
+		entries, err := readImportManifest(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		// deal IDs have to be resolved to the proposal CIDs the import expects
+		var needDeals bool
+		for _, e := range entries {
+			needDeals = needDeals || e.propCid == cid.Undef
+		}
+		if needDeals {
+			deals, err := api.MarketListIncompleteDeals(ctx)
+			if err != nil {
+				return xerrors.Errorf("listing deals: %w", err)
+			}
+
+			props := map[abi.DealID]cid.Cid{}
+			for _, d := range deals {
+				if d.DealID != 0 {
+					props[d.DealID] = d.ProposalCid
+				}
+			}
+
+			for i, e := range entries {
+				if e.propCid != cid.Undef {
+					continue
+				}
+				p, ok := props[e.dealID]
+				if !ok {
+					return xerrors.Errorf("manifest line %d: deal %d not found among the incomplete deals", e.line, e.dealID)
+				}
+				entries[i].propCid = p
+			}
+		}
+
+		type result struct {
+			entry importManifestEntry
+			took  time.Duration
+			err   error
+		}
+
+		work := make(chan importManifestEntry)
+		results := make(chan result)
+
+		for i := 0; i < cctx.Int("parallel"); i++ {
+			go func() {
+				for e := range work {
+					start := time.Now()
+					err := api.DealsImportData(ctx, e.propCid, e.path)
+					results <- result{entry: e, took: time.Since(start), err: err}
+				}
+			}()
+		}
+
+		go func() {
+			defer close(work)
+			for _, e := range entries {
+				select {
+				case work <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		var failed []result
+		for done := 1; done <= len(entries); done++ {
+			var r result
+			select {
+			case r = <-results:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if r.err != nil {
+				failed = append(failed, r)
+				fmt.Printf("[%d/%d] %s: failed: %s\n", done, len(entries), r.entry.propCid, r.err)
+				continue
+			}
+			fmt.Printf("[%d/%d] %s: imported %s in %s\n", done, len(entries), r.entry.propCid, r.entry.path, r.took.Truncate(time.Millisecond))
+		}
+
+		if len(failed) == 0 {
+			fmt.Printf("imported data for %d deals\n", len(entries))
+			return nil
+		}
+
+		sort.Slice(failed, func(i, j int) bool { return failed[i].entry.line < failed[j].entry.line })
+
+		fmt.Printf("\nfailed to import data for %d of %d deals:\n", len(failed), len(entries))
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Line\tProposal CID\tFile\tError\n")
+		for _, r := range failed {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.entry.line, r.entry.propCid, r.entry.path, r.err)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		return xerrors.Errorf("%d imports failed", len(failed))
+	},
+}
+
+type importManifestEntry struct {
+	line    int
+	propCid cid.Cid
+	dealID  abi.DealID
+	path    string
+}
+
+func readImportManifest(manifest string) ([]importManifestEntry, error) {
+	f, err := os.Open(manifest)
+	if err != nil {
+		return nil, xerrors.Errorf("opening manifest: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	dir, err := filepath.Abs(filepath.Dir(manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	var entries []importManifestEntry
+	seen := map[string]int{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("reading manifest: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+
+		e := importManifestEntry{line: line}
+
+		key := strings.TrimSpace(rec[0])
+		if id, err := strconv.ParseUint(key, 10, 64); err == nil {
+			e.dealID = abi.DealID(id)
+		} else if e.propCid, err = cid.Decode(key); err != nil {
+			return nil, xerrors.Errorf("manifest line %d: %q is neither a deal ID nor a proposal CID", line, key)
+		}
+
+		if prev, ok := seen[key]; ok {
+			return nil, xerrors.Errorf("manifest line %d: deal %s already listed on line %d", line, key, prev)
+		}
+		seen[key] = line
+
+		e.path = strings.TrimSpace(rec[1])
+		if !filepath.IsAbs(e.path) {
+			e.path = filepath.Join(dir, e.path)
+		}
+		if _, err := os.Stat(e.path); err != nil {
+			return nil, xerrors.Errorf("manifest line %d: %w", line, err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if len(entries) == 0 {
+		return nil, xerrors.Errorf("manifest %s doesn't list any deals", manifest)
+	}
+
+	return entries, nil
+}
+
 var dealsListCmd = &cli.Command{
 	Name:  "list",
 	Usage: "List all deals for this miner",
@@ -636,6 +845,7 @@ var dataTransfersCmd = &cli.Command{
 	Usage: "Manage data transfers",
 	Subcommands: []*cli.Command{
 		transfersListCmd,
+		transfersProgressCmd,
 		marketRestartTransfer,
 		marketCancelTransfer,
 		transfersDiagnosticsCmd,
@@ -849,6 +1059,72 @@ var transfersListCmd = &cli.Command{
 	},
 }
 
+var transfersProgressCmd = &cli.Command{
+	Name:  "progress",
+	Usage: "Show the progress and transfer rate of ongoing data transfers",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "stalled",
+			Usage: "only show transfers which made no progress for at least this long",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		transfers, err := api.MarketDataTransferProgress(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		stalled := cctx.Duration("stalled")
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ID\tPeer\tDeal\tStatus\tTransferred\tRate\tRestarts\tIdle\n")
+		for _, t := range transfers {
+			idle := "-"
+			if !t.LastProgress.IsZero() {
+				d := now.Sub(t.LastProgress)
+				if stalled > 0 && d < stalled {
+					continue
+				}
+				idle = d.Truncate(time.Second).String()
+			} else if stalled > 0 {
+				// no events since the node started, so it's not known when
+				// the transfer last made progress
+				continue
+			}
+
+			deal := "-"
+			if t.ProposalCid != nil {
+				deal = t.ProposalCid.String()
+			}
+
+			transferred := units.BytesSize(float64(t.Transferred))
+			if t.TotalSize > 0 {
+				transferred += " / " + units.BytesSize(float64(t.TotalSize))
+			}
+
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s/s\t%d\t%s\n",
+				t.TransferID,
+				t.OtherPeer,
+				deal,
+				datatransfer.Statuses[t.Status],
+				transferred,
+				units.BytesSize(float64(t.Rate)),
+				t.Restarts,
+				idle)
+		}
+
+		return w.Flush()
+	},
+}
+
 var transfersDiagnosticsCmd = &cli.Command{
 	Name:  "diagnostics",
 	Usage: "Get detailed diagnostics on active transfers with a specific peer",
@@ -881,6 +1157,41 @@ var transfersDiagnosticsCmd = &cli.Command{
 	},
 }
 
+var dealsQuotasCmd = &cli.Command{
+	Name:  "quotas",
+	Usage: "Show the deal quotas of clients and their usage",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		usage, err := api.MarketClientQuotaUsage(ctx)
+		if err != nil {
+			return err
+		}
+
+		limit := func(used, max uint64, format func(uint64) string) string {
+			if max == 0 {
+				return format(used)
+			}
+			return fmt.Sprintf("%s / %s", format(used), format(max))
+		}
+		count := func(n uint64) string { return strconv.FormatUint(n, 10) }
+		size := func(n uint64) string { return types.SizeStr(types.NewInt(n)) }
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Client\tOpen Deals\tAccepted Last 24h\n")
+		for _, u := range usage {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", u.Client, limit(u.OpenDeals, u.MaxOpenDeals, count), limit(u.BytesLastDay, u.MaxBytesPerDay, size))
+		}
+
+		return w.Flush()
+	},
+}
+
 var dealsPendingPublish = &cli.Command{
 	Name:  "pending-publish",
 	Usage: "list deals waiting in publish queue",
@@ -2,14 +2,20 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"text/tabwriter"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
+	"github.com/filecoin-project/lotus/node/repo"
 )
 
 var piecesCmd = &cli.Command{
@@ -21,6 +27,7 @@ var piecesCmd = &cli.Command{
 		piecesListCidInfosCmd,
 		piecesInfoCmd,
 		piecesCidInfoCmd,
+		piecesReadCmd,
 	},
 }
 
@@ -191,3 +198,88 @@ var piecesCidInfoCmd = &cli.Command{
 		return w.Flush()
 	},
 }
+
+var piecesReadCmd = &cli.Command{
+	Name:      "read",
+	Usage:     "read the unsealed data of a local piece, or a byte range of it, from the markets node",
+	ArgsUsage: "<pieceCid> <outputFile|->",
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "offset",
+			Usage: "offset in the unpadded piece to start reading from",
+		},
+		&cli.Uint64Flag{
+			Name:  "length",
+			Usage: "number of bytes to read; reads to the end of the piece if not set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		c, err := cid.Decode(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing piece cid: %w", err)
+		}
+
+		ainfo, err := lcli.GetAPIInfo(cctx, repo.Markets)
+		if err != nil {
+			return xerrors.Errorf("could not get API info: %w", err)
+		}
+		addr, err := ainfo.Host()
+		if err != nil {
+			return err
+		}
+
+		q := url.Values{}
+		if cctx.IsSet("offset") {
+			q.Set("offset", strconv.FormatUint(cctx.Uint64("offset"), 10))
+		}
+		if cctx.IsSet("length") {
+			q.Set("length", strconv.FormatUint(cctx.Uint64("length"), 10))
+		}
+		u := "http://" + addr + "/piece/" + c.String()
+		if len(q) > 0 {
+			u += "?" + q.Encode()
+		}
+
+		req, err := http.NewRequestWithContext(lcli.ReqContext(cctx), http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range ainfo.AuthHeader() {
+			req.Header[k] = v
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return xerrors.Errorf("requesting piece: %w", err)
+		}
+		defer resp.Body.Close() //nolint:errcheck
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return xerrors.Errorf("reading piece failed: %s: %s", resp.Status, msg)
+		}
+
+		var out io.Writer = os.Stdout
+		if p := cctx.Args().Get(1); p != "-" {
+			f, err := os.Create(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck
+			out = f
+		}
+
+		n, err := io.Copy(out, resp.Body)
+		if err != nil {
+			return xerrors.Errorf("writing piece data: %w", err)
+		}
+		if cctx.Args().Get(1) != "-" {
+			fmt.Printf("wrote %d bytes\n", n)
+		}
+		return nil
+	},
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -19,15 +20,25 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
 	"github.com/filecoin-project/go-state-types/proof"
 
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/lib/strle"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -443,7 +454,18 @@ var provingCheckProvableCmd = &cli.Command{
 	Name:      "check",
 	Usage:     "Check sectors provable",
 	ArgsUsage: "<deadlineIdx>",
+	Description: `Checks that the sectors of a deadline can be proven. With --prove, the WindowPoSt
+proofs of the deadline are also generated, without being submitted, reporting the
+sectors which would be skipped and the time proving took.`,
 	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "deadline",
+			Usage: "deadline index to check, instead of the deadlineIdx argument",
+		},
+		&cli.BoolFlag{
+			Name:  "prove",
+			Usage: "generate the WindowPoSt proofs of the whole deadline, without submitting them",
+		},
 		&cli.BoolFlag{
 			Name:  "only-bad",
 			Usage: "print only bad sectors",
@@ -463,15 +485,20 @@ var provingCheckProvableCmd = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		if cctx.NArg() != 1 {
+		var dlIdx uint64
+		switch {
+		case cctx.NArg() == 1 && !cctx.IsSet("deadline"):
+			var err error
+			dlIdx, err = strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+			if err != nil {
+				return xerrors.Errorf("could not parse deadline index: %w", err)
+			}
+		case cctx.NArg() == 0 && cctx.IsSet("deadline"):
+			dlIdx = cctx.Uint64("deadline")
+		default:
 			return lcli.IncorrectNumArgs(cctx)
 		}
 
-		dlIdx, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
-		if err != nil {
-			return xerrors.Errorf("could not parse deadline index: %w", err)
-		}
-
 		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
@@ -556,6 +583,9 @@ var provingCheckProvableCmd = &cli.Command{
 			}
 		}
 
+		checkStart := time.Now()
+		var checked, bad int
+
 		for parIdx, par := range partitions {
 			sectors := make(map[abi.SectorNumber]struct{})
 
@@ -584,13 +614,16 @@ var provingCheckProvableCmd = &cli.Command{
 				})
 			}
 
-			bad, err := minerApi.CheckProvable(ctx, info.WindowPoStProofType, tocheck)
+			badSectors, err := minerApi.CheckProvable(ctx, info.WindowPoStProofType, tocheck)
 			if err != nil {
 				return err
 			}
 
+			checked += len(sectors)
+			bad += len(badSectors)
+
 			for s := range sectors {
-				if err, exist := bad[s]; exist {
+				if err, exist := badSectors[s]; exist {
 					_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", dlIdx, parIdx, s, color.RedString("bad")+fmt.Sprintf(" (%s)", err))
 				} else if !cctx.Bool("only-bad") {
 					_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", dlIdx, parIdx, s, color.GreenString("good"))
@@ -598,7 +631,51 @@ var provingCheckProvableCmd = &cli.Command{
 			}
 		}
 
-		return tw.Flush()
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Checked %d sectors in %s, %d bad\n", checked, time.Since(checkStart).Truncate(time.Millisecond), bad)
+
+		if !cctx.Bool("prove") {
+			return nil
+		}
+
+		di, err := api.StateMinerProvingDeadline(ctx, addr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting proving deadline: %w", err)
+		}
+
+		proveStart := time.Now()
+		res, err := minerApi.ComputeWindowPoSt(ctx, dlIdx, types.EmptyTSK)
+		took := time.Since(proveStart)
+		if err != nil {
+			return xerrors.Errorf("computing window post for deadline %d: %w", dlIdx, err)
+		}
+
+		var skipped, proven int
+		for _, params := range res {
+			for _, part := range params.Partitions {
+				proven++
+				err := part.Skipped.ForEach(func(s uint64) error {
+					skipped++
+					fmt.Printf("deadline %d partition %d: sector %d %s\n", dlIdx, part.Index, s, color.RedString("skipped"))
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		fmt.Printf("Proved %d partitions in %d messages in %s, %d sectors skipped\n", proven, len(res), took.Truncate(time.Millisecond), skipped)
+
+		window := time.Duration(di.WPoStChallengeWindow) * time.Duration(build.BlockDelaySecs) * time.Second
+		if took > window {
+			fmt.Printf("%s: proving took longer than the %s challenge window\n", color.RedString("WARNING"), window)
+		}
+
+		return nil
 	},
 }
 
@@ -689,27 +766,46 @@ It will not send any messages to the chain.`,
 var provingRecoverFaultsCmd = &cli.Command{
 	Name:      "recover-faults",
 	Usage:     "Manually recovers faulty sectors on chain",
-	ArgsUsage: "<faulty sectors>",
+	ArgsUsage: "<faulty sectors (e.g. 1 2 3 or 1-5,8)>",
+	Description: `Declares faulty sectors as recovered. Sectors which aren't faulty, are already
+recovering, fail the provable check, or are in a deadline past its fault declaration
+cutoff are skipped. With --dry-run, the recovery declarations are printed with the
+estimated fee of each of the messages they are split into, following the
+MaxPartitionsPerRecoveryMessage of the local miner config, without sending them.`,
 	Flags: []cli.Flag{
 		&cli.IntFlag{
 			Name:  "confidence",
 			Usage: "number of block confirmations to wait for",
 			Value: int(build.MessageConfidence),
 		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "recover all faulty sectors",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-check",
+			Usage: "don't check that the sectors are provable before declaring them recovered",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "print the recovery declarations and their estimated fee without sending them",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
-		if cctx.NArg() < 1 {
-			return lcli.ShowHelp(cctx, xerrors.Errorf("must pass at least 1 sector number"))
+		if cctx.NArg() < 1 && !cctx.Bool("all") {
+			return lcli.ShowHelp(cctx, xerrors.Errorf("must pass at least 1 sector number, or --all"))
+		}
+		if cctx.NArg() > 0 && cctx.Bool("all") {
+			return lcli.ShowHelp(cctx, xerrors.Errorf("sector numbers can't be passed with --all"))
 		}
 
-		arglist := cctx.Args().Slice()
-		var sectors []abi.SectorNumber
-		for _, v := range arglist {
-			s, err := strconv.ParseUint(v, 10, 64)
+		var requested *bitfield.BitField
+		if cctx.NArg() > 0 {
+			bf, err := strle.HumanRangesToBitField(strings.Join(cctx.Args().Slice(), ","))
 			if err != nil {
 				return xerrors.Errorf("failed to convert sectors, please check the arguments: %w", err)
 			}
-			sectors = append(sectors, abi.SectorNumber(s))
+			requested = &bf
 		}
 
 		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
@@ -726,6 +822,159 @@ var provingRecoverFaultsCmd = &cli.Command{
 
 		ctx := lcli.ReqContext(cctx)
 
+		maddr, err := minerApi.ActorAddress(ctx)
+		if err != nil {
+			return err
+		}
+
+		mid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return err
+		}
+
+		minfo, err := api.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		di, err := api.StateMinerProvingDeadline(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting proving deadline: %w", err)
+		}
+
+		var decls []minertypes.RecoveryDeclaration
+		var sectors []abi.SectorNumber
+
+		for dlIdx := uint64(0); dlIdx < di.WPoStPeriodDeadlines; dlIdx++ {
+			partitions, err := api.StateMinerPartitions(ctx, maddr, dlIdx, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("getting partitions for deadline %d: %w", dlIdx, err)
+			}
+
+			dl := dline.NewInfo(di.PeriodStart, dlIdx, di.CurrentEpoch, di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff).NextNotElapsed()
+
+			for parIdx, par := range partitions {
+				toRecover, err := bitfield.SubtractBitField(par.FaultySectors, par.RecoveringSectors)
+				if err != nil {
+					return err
+				}
+				if requested != nil {
+					toRecover, err = bitfield.IntersectBitField(toRecover, *requested)
+					if err != nil {
+						return err
+					}
+				}
+
+				n, err := toRecover.Count()
+				if err != nil {
+					return err
+				}
+				if n == 0 {
+					continue
+				}
+
+				if dl.FaultCutoffPassed() {
+					fmt.Printf("Skipping %d sectors in deadline %d partition %d: fault declaration cutoff passed, retry after the deadline closes\n", n, dlIdx, parIdx)
+					continue
+				}
+
+				if !cctx.Bool("skip-check") {
+					toRecover, err = checkRecoverable(ctx, api, minerApi, maddr, abi.ActorID(mid), minfo.WindowPoStProofType, toRecover)
+					if err != nil {
+						return err
+					}
+				}
+
+				err = toRecover.ForEach(func(s uint64) error {
+					sectors = append(sectors, abi.SectorNumber(s))
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+
+				if empty, err := toRecover.IsEmpty(); err != nil {
+					return err
+				} else if empty {
+					continue
+				}
+				decls = append(decls, minertypes.RecoveryDeclaration{
+					Deadline:  dlIdx,
+					Partition: uint64(parIdx),
+					Sectors:   toRecover,
+				})
+			}
+		}
+
+		if requested != nil {
+			nr, err := requested.Count()
+			if err != nil {
+				return err
+			}
+			if skipped := int(nr) - len(sectors); skipped > 0 {
+				fmt.Printf("Skipping %d sectors which aren't faulty, are already recovering, or can't be recovered now\n", skipped)
+			}
+		}
+
+		if len(sectors) == 0 {
+			return xerrors.Errorf("no sectors to recover")
+		}
+
+		for _, decl := range decls {
+			n, err := decl.Sectors.Count()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Recovering %d sectors in deadline %d partition %d\n", n, decl.Deadline, decl.Partition)
+		}
+
+		if cctx.Bool("dry-run") {
+			// RecoverFault splits the recoveries into messages of at most
+			// MaxPartitionsPerRecoveryMessage partitions, estimate each of them
+			limit, err := recoveryPartitionLimit(cctx)
+			if err != nil {
+				fmt.Printf("Estimating a single message: couldn't read the partition limit per message from the miner config: %s\n", err)
+			}
+
+			batches := [][]minertypes.RecoveryDeclaration{decls}
+			if limit > 0 {
+				batches = nil
+				for len(decls) > limit {
+					batches = append(batches, decls[:limit])
+					decls = decls[limit:]
+				}
+				batches = append(batches, decls)
+			}
+
+			total := big.Zero()
+			for i, batch := range batches {
+				params, aerr := actors.SerializeParams(&minertypes.DeclareFaultsRecoveredParams{
+					Recoveries: batch,
+				})
+				if aerr != nil {
+					return xerrors.Errorf("serializing params: %w", aerr)
+				}
+
+				msg, err := api.GasEstimateMessageGas(ctx, &types.Message{
+					From:   minfo.Worker,
+					To:     maddr,
+					Method: builtin.MethodsMiner.DeclareFaultsRecovered,
+					Value:  big.Zero(),
+					Params: params,
+				}, nil, types.EmptyTSK)
+				if err != nil {
+					return xerrors.Errorf("estimating gas of message %d: %w", i+1, err)
+				}
+
+				fee := big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit))
+				total = big.Add(total, fee)
+				fmt.Printf("Message %d: %d partitions, estimated max fee: %s (gas limit %d)\n", i+1, len(batch), types.FIL(fee), msg.GasLimit)
+			}
+
+			fmt.Printf("Estimated max fee: %s for %d messages\n", types.FIL(total), len(batches))
+			return nil
+		}
+
 		msgs, err := minerApi.RecoverFault(ctx, sectors)
 		if err != nil {
 			return err
@@ -764,3 +1013,72 @@ var provingRecoverFaultsCmd = &cli.Command{
 		return nil
 	},
 }
+
+// recoveryPartitionLimit returns the maximum number of partitions per recovery
+// message from the config of the local miner repo.
+func recoveryPartitionLimit(cctx *cli.Context) (int, error) {
+	r, err := repo.NewFS(cctx.String(FlagMinerRepo))
+	if err != nil {
+		return 0, err
+	}
+
+	ok, err := r.Exists()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, xerrors.Errorf("repo not initialized")
+	}
+
+	lr, err := r.LockRO(repo.StorageMiner)
+	if err != nil {
+		return 0, xerrors.Errorf("locking repo: %w", err)
+	}
+	defer lr.Close() //nolint:errcheck
+
+	cfg, err := lr.Config()
+	if err != nil {
+		return 0, xerrors.Errorf("getting node config: %w", err)
+	}
+
+	mcfg, ok := cfg.(*config.StorageMiner)
+	if !ok {
+		return 0, xerrors.Errorf("wrong config type: %T", cfg)
+	}
+	return mcfg.Proving.MaxPartitionsPerRecoveryMessage, nil
+}
+
+// checkRecoverable returns the sectors which pass the provable check, printing
+// the ones which don't.
+func checkRecoverable(ctx context.Context, napi v0api.FullNode, minerApi api.StorageMiner, maddr address.Address, mid abi.ActorID, pp abi.RegisteredPoStProof, sectors bitfield.BitField) (bitfield.BitField, error) {
+	infos, err := napi.StateMinerSectors(ctx, maddr, &sectors, types.EmptyTSK)
+	if err != nil {
+		return bitfield.BitField{}, xerrors.Errorf("getting sector infos: %w", err)
+	}
+
+	tocheck := make([]storiface.SectorRef, 0, len(infos))
+	for _, info := range infos {
+		tocheck = append(tocheck, storiface.SectorRef{
+			ProofType: info.SealProof,
+			ID: abi.SectorID{
+				Miner:  mid,
+				Number: info.SectorNumber,
+			},
+		})
+	}
+
+	bad, err := minerApi.CheckProvable(ctx, pp, tocheck)
+	if err != nil {
+		return bitfield.BitField{}, xerrors.Errorf("checking sectors: %w", err)
+	}
+
+	good := bitfield.New()
+	for _, ref := range tocheck {
+		if reason, isBad := bad[ref.ID.Number]; isBad {
+			fmt.Printf("Skipping sector %d: %s (%s)\n", ref.ID.Number, color.RedString("bad"), reason)
+			continue
+		}
+		good.Set(uint64(ref.ID.Number))
+	}
+	return good, nil
+}
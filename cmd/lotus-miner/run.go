@@ -17,6 +17,7 @@ import (
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/build"
 	lcli "github.com/filecoin-project/lotus/cli"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
@@ -137,6 +138,18 @@ var runCmd = &cli.Command{
 			return err
 		}
 
+		var fullApi v1api.FullNode = nodeApi
+		if cfg.SharedChainstore.Enable {
+			cs, csCloser, err := cliutil.GetSharedChainstore(cctx, cfg.SharedChainstore.CacheSize)
+			if err != nil {
+				return xerrors.Errorf("connecting to the shared chainstore: %w", err)
+			}
+			defer csCloser()
+
+			log.Info("Reading chain objects from the shared daemon chainstore")
+			fullApi = &cliutil.SharedChainstoreFullNode{FullNode: nodeApi, Chainstore: cs}
+		}
+
 		shutdownChan := make(chan struct{})
 
 		var minerapi api.StorageMiner
@@ -150,7 +163,7 @@ var runCmd = &cli.Command{
 				node.Override(new(dtypes.APIEndpoint), func() (dtypes.APIEndpoint, error) {
 					return multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" + cctx.String("miner-api"))
 				})),
-			node.Override(new(v1api.RawFullNodeAPI), nodeApi),
+			node.Override(new(v1api.RawFullNodeAPI), fullApi),
 		)
 		if err != nil {
 			return xerrors.Errorf("creating node: %w", err)
@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/go-padreader"
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/httpreader"
@@ -38,6 +39,11 @@ var sealingCmd = &cli.Command{
 		sealingSchedDiagCmd,
 		sealingAbortCmd,
 		sealingDataCidCmd,
+		sealingResourcesCmd,
+		sealingPauseCmd,
+		sealingResumeCmd,
+		sealingPausedCmd,
+		sealingStatsCmd,
 	},
 }
 
@@ -141,6 +147,21 @@ func workersCmd(sealing bool) *cli.Command {
 					fmt.Printf("\tTASK: %s\n", taskStr)
 				}
 
+				if len(stat.Info.TaskPriorities) > 0 {
+					var prios []sealtasks.TaskType
+					for tt := range stat.Info.TaskPriorities {
+						prios = append(prios, tt)
+					}
+					sort.Slice(prios, func(i, j int) bool {
+						return prios[i].Less(prios[j])
+					})
+					var prioStr string
+					for _, tt := range prios {
+						prioStr += fmt.Sprintf("%s(%d) ", color.BlueString(tt.Short()), stat.Info.TaskPriorities[tt])
+					}
+					fmt.Printf("\tPRIO: %s\n", prioStr)
+				}
+
 				// CPU use
 
 				fmt.Printf("\tCPU:  [%s] %d/%d core(s) in use\n",
@@ -489,3 +510,287 @@ var sealingDataCidCmd = &cli.Command{
 		return nil
 	},
 }
+
+var sealingResourcesCmd = &cli.Command{
+	Name:      "resources",
+	Usage:     "show the resources the scheduler assumes each task needs on a worker",
+	ArgsUsage: "[worker id]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "task",
+			Usage: "only show the given task type, e.g. PC1",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		wst, err := minerApi.WorkerStats(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting worker stats: %w", err)
+		}
+
+		var wid *uuid.UUID
+		for id := range wst {
+			if !strings.HasPrefix(id.String(), cctx.Args().First()) {
+				continue
+			}
+			if wid != nil {
+				return xerrors.Errorf("worker id prefix %s is ambiguous", cctx.Args().First())
+			}
+			id := id
+			wid = &id
+		}
+		if wid == nil {
+			return xerrors.Errorf("worker %s not found", cctx.Args().First())
+		}
+
+		table, err := minerApi.WorkerResourceTable(ctx, *wid)
+		if err != nil {
+			return xerrors.Errorf("getting worker resource table: %w", err)
+		}
+
+		tasks := make([]sealtasks.TaskType, 0, len(table))
+		for tt := range table {
+			if cctx.IsSet("task") && tt.Short() != cctx.String("task") {
+				continue
+			}
+			tasks = append(tasks, tt)
+		}
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].Less(tasks[j])
+		})
+
+		fmt.Printf("Worker %s, host %s\n", *wid, wst[*wid].Info.Hostname)
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Task\tSize\tMinMem\tMaxMem\tBaseMinMem\tGPU\tThreads\tThreadsGPU\tMaxConcurrent\n")
+
+		for _, tt := range tasks {
+			proofs := make([]abi.RegisteredSealProof, 0, len(table[tt]))
+			for spt := range table[tt] {
+				proofs = append(proofs, spt)
+			}
+			sort.Slice(proofs, func(i, j int) bool {
+				return proofs[i] < proofs[j]
+			})
+
+			// the V1 and V1_1 proofs of a sector size share their resources
+			seen := map[abi.SectorSize]struct{}{}
+			for _, spt := range proofs {
+				ssize, err := spt.SectorSize()
+				if err != nil {
+					return xerrors.Errorf("getting sector size: %w", err)
+				}
+				if _, ok := seen[ssize]; ok {
+					continue
+				}
+				seen[ssize] = struct{}{}
+
+				r := table[tt][spt]
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%g\t%d\t%d\t%d\n",
+					tt.Short(),
+					ssize.ShortString(),
+					types.SizeStr(types.NewInt(r.MinMemory)),
+					types.SizeStr(types.NewInt(r.MaxMemory)),
+					types.SizeStr(types.NewInt(r.BaseMinMemory)),
+					r.GPUUtilization,
+					r.MaxParallelism,
+					r.MaxParallelismGPU,
+					r.MaxConcurrent)
+			}
+		}
+
+		return tw.Flush()
+	},
+}
+
+var sealingPauseCmd = &cli.Command{
+	Name:  "pause",
+	Usage: "pause sector states: sectors entering them wait without scheduling any work",
+	Description: `Sectors entering a paused state wait in it, without scheduling any task,
+until the state is resumed with 'lotus-miner sealing resume'. Tasks already
+running in the state aren't interrupted. For example, to stop starting new PC2
+tasks while a GPU is down:
+
+lotus-miner sealing pause PreCommit2`,
+	ArgsUsage: "[state ...]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() == 0 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		states := make([]api.SectorState, cctx.NArg())
+		for i, st := range cctx.Args().Slice() {
+			states[i] = api.SectorState(st)
+		}
+
+		return minerApi.SealingPauseStates(ctx, states)
+	},
+}
+
+var sealingResumeCmd = &cli.Command{
+	Name:      "resume",
+	Usage:     "resume paused sector states, restarting the sectors waiting in them",
+	ArgsUsage: "[state ...]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "resume all the paused states",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() == 0 && !cctx.Bool("all") {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		var states []api.SectorState
+		if cctx.Bool("all") {
+			states, err = minerApi.SealingPausedStates(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting paused states: %w", err)
+			}
+		}
+		for _, st := range cctx.Args().Slice() {
+			states = append(states, api.SectorState(st))
+		}
+
+		return minerApi.SealingResumeStates(ctx, states)
+	},
+}
+
+var sealingPausedCmd = &cli.Command{
+	Name:  "paused",
+	Usage: "list the paused sector states, and the sectors waiting in them",
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		states, err := minerApi.SealingPausedStates(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting paused states: %w", err)
+		}
+
+		if len(states) == 0 {
+			fmt.Println("No paused states")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "State\tWaiting\tSectors\n")
+		for _, st := range states {
+			sectors, err := minerApi.SectorsListInStates(ctx, []api.SectorState{st})
+			if err != nil {
+				return xerrors.Errorf("listing sectors in state %s: %w", st, err)
+			}
+
+			numbers := make([]string, len(sectors))
+			for i, s := range sectors {
+				numbers[i] = fmt.Sprint(s)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\n", st, len(sectors), strings.Join(numbers, ","))
+		}
+
+		return tw.Flush()
+	},
+}
+
+var sealingStatsCmd = &cli.Command{
+	Name:  "stats",
+	Usage: "show sealing pipeline statistics",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output in json format",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := minerApi.SectorsPipelineStats(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting pipeline stats: %w", err)
+		}
+
+		if cctx.Bool("json") {
+			j, err := json.MarshalIndent(st, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(j))
+			return nil
+		}
+
+		states := make([]api.SectorState, 0, len(st.States))
+		for state := range st.States {
+			states = append(states, state)
+		}
+		sort.Slice(states, func(i, j int) bool {
+			return states[i] < states[j]
+		})
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "State\tSectors\tLeft\tAvg Time\tMax Time\n")
+		for _, state := range states {
+			s := st.States[state]
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", state, s.Sectors, s.Left, s.AvgDuration.Truncate(time.Second), s.MaxDuration.Truncate(time.Second))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nFailures: %d\n\n", st.Failures)
+
+		workers := make([]uuid.UUID, 0, len(st.Workers))
+		for id := range st.Workers {
+			workers = append(workers, id)
+		}
+		sort.Slice(workers, func(i, j int) bool {
+			return st.Workers[workers[i]].Hostname < st.Workers[workers[j]].Hostname
+		})
+
+		tw = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Worker\tHost\tUtilization\tTasks\n")
+		for _, id := range workers {
+			w := st.Workers[id]
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%.0f%%\t%d\n", id.String()[:8], w.Hostname, w.Utilization*100, w.Tasks)
+		}
+		return tw.Flush()
+	},
+}
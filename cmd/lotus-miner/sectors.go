@@ -39,6 +39,7 @@ import (
 	"github.com/filecoin-project/lotus/lib/strle"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 )
 
 const parallelSectorChecks = 300
@@ -854,7 +855,7 @@ var sectorsExtendCmd = &cli.Command{
 		},
 		&cli.BoolFlag{
 			Name:  "really-do-it",
-			Usage: "pass this flag to really extend sectors, otherwise will only print out json representation of parameters",
+			Usage: "pass this flag to really extend sectors, otherwise will only print out json representation of parameters, new expirations and estimated fees",
 		},
 	},
 	Action: func(cctx *cli.Context) error {
@@ -1164,7 +1165,7 @@ var sectorsExtendCmd = &cli.Command{
 				if scount+sectorsInDecl > addrSectors || len(p.Extensions) >= declMax {
 					params = append(params, p)
 					p = miner.ExtendSectorExpiration2Params{}
-					scount = sectorsInDecl
+					scount = 0
 				}
 
 				p.Extensions = append(p.Extensions, miner.ExpirationExtension2{
@@ -1174,7 +1175,7 @@ var sectorsExtendCmd = &cli.Command{
 					SectorsWithClaims: sectorsWithClaims,
 					NewExpiration:     newExp,
 				})
-
+				scount += sectorsInDecl
 			}
 		}
 
@@ -1194,6 +1195,7 @@ var sectorsExtendCmd = &cli.Command{
 		}
 
 		stotal := 0
+		totalFee := big.Zero()
 
 		for i := range params {
 			scount := 0
@@ -1207,6 +1209,19 @@ var sectorsExtendCmd = &cli.Command{
 			fmt.Printf("Extending %d sectors: ", scount)
 			stotal += scount
 
+			sp, aerr := actors.SerializeParams(&params[i])
+			if aerr != nil {
+				return xerrors.Errorf("serializing params: %w", aerr)
+			}
+
+			msg := &types.Message{
+				From:   mi.Worker,
+				To:     maddr,
+				Method: builtin.MethodsMiner.ExtendSectorExpiration2,
+				Value:  big.Zero(),
+				Params: sp,
+			}
+
 			if !cctx.Bool("really-do-it") {
 				pp, err := NewPseudoExtendParams(&params[i])
 				if err != nil {
@@ -1219,21 +1234,18 @@ var sectorsExtendCmd = &cli.Command{
 				}
 
 				fmt.Println("\n", string(data))
-				continue
-			}
 
-			sp, aerr := actors.SerializeParams(&params[i])
-			if aerr != nil {
-				return xerrors.Errorf("serializing params: %w", err)
+				emsg, err := fullApi.GasEstimateMessageGas(ctx, msg, spec, types.EmptyTSK)
+				if err != nil {
+					return xerrors.Errorf("estimating gas: %w", err)
+				}
+				fee := big.Mul(emsg.GasFeeCap, big.NewInt(emsg.GasLimit))
+				totalFee = big.Add(totalFee, fee)
+				fmt.Printf("Estimated max fee: %s (gas limit %d)\n", types.FIL(fee), emsg.GasLimit)
+				continue
 			}
 
-			smsg, err := fullApi.MpoolPushMessage(ctx, &types.Message{
-				From:   mi.Worker,
-				To:     maddr,
-				Method: builtin.MethodsMiner.ExtendSectorExpiration,
-				Value:  big.Zero(),
-				Params: sp,
-			}, spec)
+			smsg, err := fullApi.MpoolPushMessage(ctx, msg, spec)
 			if err != nil {
 				return xerrors.Errorf("mpool push message: %w", err)
 			}
@@ -1241,6 +1253,35 @@ var sectorsExtendCmd = &cli.Command{
 			fmt.Println(smsg.Cid())
 		}
 
+		if !cctx.Bool("really-do-it") {
+			newExps := map[abi.ChainEpoch]uint64{}
+			for _, p := range params {
+				for _, ext := range p.Extensions {
+					count, err := ext.Sectors.Count()
+					if err != nil {
+						return err
+					}
+					newExps[ext.NewExpiration] += count
+				}
+			}
+			exps := make([]abi.ChainEpoch, 0, len(newExps))
+			for exp := range newExps {
+				exps = append(exps, exp)
+			}
+			sort.Slice(exps, func(i, j int) bool {
+				return exps[i] < exps[j]
+			})
+
+			fmt.Println("New expirations:")
+			for _, exp := range exps {
+				fmt.Printf("  %d (%s): %d sectors\n", exp, cliutil.EpochTime(currEpoch, exp), newExps[exp])
+			}
+
+			fmt.Printf("%d sectors in %d messages would be extended, estimated max fee: %s\n", stotal, len(params), types.FIL(totalFee))
+			fmt.Println("Pass --really-do-it to actually extend the sectors")
+			return nil
+		}
+
 		fmt.Printf("%d sectors extended\n", stotal)
 
 		return nil
@@ -1396,9 +1437,11 @@ var sectorsRemoveCmd = &cli.Command{
 }
 
 var sectorsSnapUpCmd = &cli.Command{
-	Name:      "snap-up",
-	Usage:     "Mark a committed capacity sector to be filled with deals",
-	ArgsUsage: "<sectorNum>",
+	Name:  "snap-up",
+	Usage: "Mark committed capacity sectors to be filled with deals",
+	Description: `Sectors can be given as a single number or as ranges, e.g. 1,5-10.
+The sectors which can't be marked are reported, and the other ones are marked.`,
+	ArgsUsage: "<sectorNum|ranges>",
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 1 {
 			return lcli.IncorrectNumArgs(cctx)
@@ -1424,12 +1467,33 @@ var sectorsSnapUpCmd = &cli.Command{
 			return xerrors.Errorf("snap deals upgrades enabled in network v15")
 		}
 
-		id, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+		bf, err := strle.HumanRangesToBitField(cctx.Args().Get(0))
 		if err != nil {
-			return xerrors.Errorf("could not parse sector number: %w", err)
+			return xerrors.Errorf("could not parse sector numbers: %w", err)
+		}
+		sectors, err := bf.All(abi.MaxSectorNumber)
+		if err != nil {
+			return xerrors.Errorf("listing sector numbers: %w", err)
 		}
 
-		return minerAPI.SectorMarkForUpgrade(ctx, abi.SectorNumber(id), true)
+		if len(sectors) == 1 {
+			return minerAPI.SectorMarkForUpgrade(ctx, abi.SectorNumber(sectors[0]), true)
+		}
+
+		var failed int
+		for _, id := range sectors {
+			if err := minerAPI.SectorMarkForUpgrade(ctx, abi.SectorNumber(id), true); err != nil {
+				fmt.Printf("%d: %s\n", id, err)
+				failed++
+				continue
+			}
+			fmt.Printf("%d: marked for upgrade\n", id)
+		}
+
+		if failed > 0 {
+			return xerrors.Errorf("%d of %d sectors couldn't be marked for upgrade", failed, len(sectors))
+		}
+		return nil
 	},
 }
 
@@ -1857,6 +1921,7 @@ var sectorsBatching = &cli.Command{
 	Subcommands: []*cli.Command{
 		sectorsBatchingPendingCommit,
 		sectorsBatchingPendingPreCommit,
+		sectorsBatchingStatusCmd,
 	},
 }
 
@@ -1980,6 +2045,56 @@ var sectorsBatchingPendingPreCommit = &cli.Command{
 	},
 }
 
+var sectorsBatchingStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the sectors pending in the precommit and commit batches, and how and when they will be sent",
+	Action: func(cctx *cli.Context) error {
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := minerAPI.SectorBatchingStatus(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting batching status: %w", err)
+		}
+
+		for _, b := range []struct {
+			name string
+			st   sealiface.BatchStatus
+		}{
+			{"PreCommit", st.PreCommit},
+			{"Commit", st.Commit},
+		} {
+			fmt.Printf("%s batch:\n", b.name)
+			if len(b.st.Sectors) == 0 {
+				fmt.Printf("\tNo sectors queued\n")
+				continue
+			}
+
+			fmt.Printf("\tMode: %s\n", b.st.Mode)
+			if !b.st.BaseFee.Nil() {
+				fmt.Printf("\tBase fee: %s\n", types.FIL(b.st.BaseFee))
+			}
+			if !b.st.NextCheck.IsZero() {
+				fmt.Printf("\tNext check: %s (in %s)\n", b.st.NextCheck.Format(time.Stamp), time.Until(b.st.NextCheck).Truncate(time.Second))
+			}
+			fmt.Printf("\tSectors:\n")
+			for _, s := range b.st.Sectors {
+				if s.Cutoff.IsZero() {
+					fmt.Printf("\t\t%d\n", s.Number)
+					continue
+				}
+				fmt.Printf("\t\t%d\tcutoff %s\n", s.Number, s.Cutoff.Format(time.Stamp))
+			}
+		}
+
+		return nil
+	},
+}
+
 var sectorsRefreshPieceMatchingCmd = &cli.Command{
 	Name:  "match-pending-pieces",
 	Usage: "force a refreshed match of pending pieces to open sectors without manually waiting for more deals",
@@ -2010,6 +2125,9 @@ func yesno(b bool) string {
 var sectorsCompactPartitionsCmd = &cli.Command{
 	Name:  "compact-partitions",
 	Usage: "removes dead sectors from partitions and reduces the number of partitions used if possible",
+	Description: `Partitions can only be compacted when they have no faulty or unproven sectors, in a
+deadline which isn't open and isn't opening next. Without --really-do-it, the partitions
+are checked and the estimated fee is printed without sending any message.`,
 	Flags: []cli.Flag{
 		&cli.Uint64Flag{
 			Name:     "deadline",
@@ -2032,11 +2150,6 @@ var sectorsCompactPartitionsCmd = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		if !cctx.Bool("really-do-it") {
-			fmt.Println("Pass --really-do-it to actually execute this action")
-			return nil
-		}
-
 		api, acloser, err := lcli.GetFullNodeAPI(cctx)
 		if err != nil {
 			return err
@@ -2056,7 +2169,7 @@ var sectorsCompactPartitionsCmd = &cli.Command{
 		}
 
 		deadline := cctx.Uint64("deadline")
-		if deadline > miner.WPoStPeriodDeadlines {
+		if deadline >= miner.WPoStPeriodDeadlines {
 			return fmt.Errorf("deadline %d out of range", deadline)
 		}
 
@@ -2064,13 +2177,61 @@ var sectorsCompactPartitionsCmd = &cli.Command{
 		if len(parts) <= 0 {
 			return fmt.Errorf("must include at least one partition to compact")
 		}
-		fmt.Printf("compacting %d paritions\n", len(parts))
+
+		di, err := api.StateMinerProvingDeadline(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting proving deadline: %w", err)
+		}
+		if deadline == di.Index || deadline == (di.Index+1)%di.WPoStPeriodDeadlines {
+			return xerrors.Errorf("deadline %d can't be compacted while it is open or opening next (current deadline: %d)", deadline, di.Index)
+		}
+
+		dlParts, err := api.StateMinerPartitions(ctx, maddr, deadline, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting partitions: %w", err)
+		}
 
 		partitions := bitfield.New()
+		var live uint64
 		for _, partition := range parts {
+			if partition < 0 || int(partition) >= len(dlParts) {
+				return xerrors.Errorf("partition %d out of range, deadline %d has %d partitions", partition, deadline, len(dlParts))
+			}
+			p := dlParts[partition]
+
+			faulty, err := p.FaultySectors.Count()
+			if err != nil {
+				return err
+			}
+			if faulty > 0 {
+				return xerrors.Errorf("partition %d has %d faulty sectors, recover or terminate them before compacting", partition, faulty)
+			}
+
+			pLive, err := p.LiveSectors.Count()
+			if err != nil {
+				return err
+			}
+			pActive, err := p.ActiveSectors.Count()
+			if err != nil {
+				return err
+			}
+			if pLive != pActive {
+				return xerrors.Errorf("partition %d has %d unproven sectors, wait for them to be proven before compacting", partition, pLive-pActive)
+			}
+
+			pAll, err := p.AllSectors.Count()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("partition %d: %d sectors, %d live\n", partition, pAll, pLive)
+			live += pLive
 			partitions.Set(uint64(partition))
 		}
 
+		after := (live + minfo.WindowPoStPartitionSectors - 1) / minfo.WindowPoStPartitionSectors
+		fmt.Printf("compacting %d partitions with %d live sectors into %d partitions\n", len(parts), live, after)
+
 		params := miner.CompactPartitionsParams{
 			Deadline:   deadline,
 			Partitions: partitions,
@@ -2081,13 +2242,26 @@ var sectorsCompactPartitionsCmd = &cli.Command{
 			return xerrors.Errorf("serializing params: %w", err)
 		}
 
-		smsg, err := api.MpoolPushMessage(ctx, &types.Message{
+		msg := &types.Message{
 			From:   minfo.Worker,
 			To:     maddr,
 			Method: builtin.MethodsMiner.CompactPartitions,
 			Value:  big.Zero(),
 			Params: sp,
-		}, nil)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			emsg, err := api.GasEstimateMessageGas(ctx, msg, nil, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("estimating gas: %w", err)
+			}
+			fmt.Printf("Estimated max fee: %s (gas limit %d)\n", types.FIL(big.Mul(emsg.GasFeeCap, big.NewInt(emsg.GasLimit))), emsg.GasLimit)
+
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		smsg, err := api.MpoolPushMessage(ctx, msg, nil)
 		if err != nil {
 			return xerrors.Errorf("mpool push: %w", err)
 		}
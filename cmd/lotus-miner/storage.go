@@ -49,6 +49,7 @@ stored while moving through the sealing pipeline (references as 'seal').`,
 		storageRedeclareCmd,
 		storageListCmd,
 		storageFindCmd,
+		storageHealthCmd,
 		storageCleanupCmd,
 		storageLocks,
 	},
@@ -629,6 +630,74 @@ var storageFindCmd = &cli.Command{
 	},
 }
 
+var storageHealthCmd = &cli.Command{
+	Name:  "health",
+	Usage: "show the health of storage paths",
+	Description: `Paths which are slow to stat or low on filesystem space are read-only: no new
+sector files are allocated on them. Paths with heartbeat errors or missing heartbeats
+are offline: sector files are only read from them when no other path has a copy.`,
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		health, err := minerApi.StorageHealth(ctx)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]storiface.ID, 0, len(health))
+		for id := range health {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		tw := tablewriter.New(
+			tablewriter.Col("ID"),
+			tablewriter.Col("Health"),
+			tablewriter.Col("Heartbeat"),
+			tablewriter.Col("Latency"),
+			tablewriter.Col("Available"),
+			tablewriter.Col("Errors"),
+			tablewriter.NewLineCol("Reason"),
+		)
+
+		for _, id := range ids {
+			h := health[id]
+
+			col := color.FgGreen
+			switch h.State {
+			case storiface.PathHealthReadOnly:
+				col = color.FgYellow
+			case storiface.PathHealthOffline:
+				col = color.FgRed
+			}
+
+			m := map[string]interface{}{
+				"ID":        id,
+				"Health":    color.New(col).Sprint(h.State),
+				"Heartbeat": time.Since(h.LastHeartbeat).Truncate(time.Second).String() + " ago",
+				"Latency":   h.Latency.Truncate(time.Millisecond),
+				"Available": types.SizeStr(types.NewInt(uint64(h.Stat.FSAvailable))),
+			}
+			if h.Errors > 0 {
+				m["Errors"] = h.Errors
+			}
+			if h.Reason != "" {
+				m["Reason"] = h.Reason
+			}
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
 var storageListSectorsCmd = &cli.Command{
 	Name:  "sectors",
 	Usage: "get list of all sector files",
@@ -347,6 +347,14 @@ func (rc *rawCarb) Get(ctx context.Context, c cid.Cid) (block.Block, error) {
 	return b, nil
 }
 
+func (rc *rawCarb) HasMany(ctx context.Context, cids []cid.Cid) ([]bool, error) {
+	return blockstore.HasEach(ctx, rc, cids)
+}
+
+func (rc *rawCarb) GetMany(ctx context.Context, cids []cid.Cid) ([]block.Block, error) {
+	return blockstore.GetEach(ctx, rc, cids)
+}
+
 func (rc *rawCarb) GetSize(ctx context.Context, c cid.Cid) (int, error) {
 	b, has := rc.blocks[c]
 	if !has {
@@ -84,6 +84,8 @@ func main() {
 		gasTraceCmd,
 		replayOfflineCmd,
 		msgindexCmd,
+		stateSnapshotCmd,
+		snapshotCmd,
 	}
 
 	app := &cli.App{
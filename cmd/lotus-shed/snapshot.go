@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	carv2bs "github.com/ipld/go-car/v2/blockstore"
+	"github.com/urfave/cli/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var snapshotCmd = &cli.Command{
+	Name:  "snapshot",
+	Usage: "Tools for inspecting chain snapshots",
+	Subcommands: []*cli.Command{
+		snapshotDiffCmd,
+	},
+}
+
+var snapshotDiffCmd = &cli.Command{
+	Name:      "diff",
+	Usage:     "Report the objects present in one snapshot but not in the other, by type",
+	ArgsUsage: "<a.car> <b.car>",
+	Description: `The objects of each snapshot are classified by walking it from its roots: block
+   headers along the header chain, then the messages, message receipts and parent states
+   of every header, in that order. Objects not reachable from the roots are reported as
+   unreachable.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "list",
+			Usage: "list the objects present in only one of the snapshots, prefixed with - for a and + for b",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		ctx := lcli.ReqContext(cctx)
+
+		a, err := openSnapshot(ctx, cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		defer a.Close() //nolint:errcheck
+
+		b, err := openSnapshot(ctx, cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+		defer b.Close() //nolint:errcheck
+
+		onlyA := a.missingFrom(b)
+		onlyB := b.missingFrom(a)
+
+		typesA, err := a.classify(ctx, onlyA)
+		if err != nil {
+			return xerrors.Errorf("error classifying the objects of %s: %w", a.path, err)
+		}
+
+		typesB, err := b.classify(ctx, onlyB)
+		if err != nil {
+			return xerrors.Errorf("error classifying the objects of %s: %w", b.path, err)
+		}
+
+		fmt.Printf("a: %s, %d objects\n", a.path, len(a.keys))
+		fmt.Printf("b: %s, %d objects\n", b.path, len(b.keys))
+		fmt.Println()
+
+		countA := countSnapshotObjects(typesA)
+		countB := countSnapshotObjects(typesB)
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "type\tonly in a\tonly in b")
+		for _, typ := range snapshotObjectTypes {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\n", typ, countA[typ], countB[typ])
+		}
+		_, _ = fmt.Fprintf(tw, "total\t%d\t%d\n", len(onlyA), len(onlyB))
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if cctx.Bool("list") {
+			fmt.Println()
+			for _, c := range onlyA {
+				fmt.Printf("- %s %s\n", typesA[c], c)
+			}
+			for _, c := range onlyB {
+				fmt.Printf("+ %s %s\n", typesB[c], c)
+			}
+		}
+
+		return nil
+	},
+}
+
+type snapshotObjectType string
+
+const (
+	snapshotHeader      snapshotObjectType = "headers"
+	snapshotMessages    snapshotObjectType = "messages"
+	snapshotReceipts    snapshotObjectType = "receipts"
+	snapshotState       snapshotObjectType = "state"
+	snapshotUnreachable snapshotObjectType = "unreachable"
+)
+
+var snapshotObjectTypes = []snapshotObjectType{
+	snapshotHeader,
+	snapshotMessages,
+	snapshotReceipts,
+	snapshotState,
+	snapshotUnreachable,
+}
+
+func countSnapshotObjects(objs map[cid.Cid]snapshotObjectType) map[snapshotObjectType]int {
+	res := make(map[snapshotObjectType]int)
+	for _, typ := range objs {
+		res[typ]++
+	}
+	return res
+}
+
+type snapshot struct {
+	path  string
+	roots []cid.Cid
+	keys  map[cid.Cid]struct{}
+
+	car *carv2bs.ReadOnly
+	bs  blockstore.Blockstore
+}
+
+func openSnapshot(ctx context.Context, path string) (*snapshot, error) {
+	car, err := carv2bs.OpenReadOnly(path, carv2bs.UseWholeCIDs(true))
+	if err != nil {
+		return nil, xerrors.Errorf("error opening snapshot %s: %w", path, err)
+	}
+
+	roots, err := car.Roots()
+	if err != nil {
+		_ = car.Close()
+		return nil, xerrors.Errorf("error reading the roots of snapshot %s: %w", path, err)
+	}
+
+	ch, err := car.AllKeysChan(ctx)
+	if err != nil {
+		_ = car.Close()
+		return nil, xerrors.Errorf("error listing the objects of snapshot %s: %w", path, err)
+	}
+
+	keys := make(map[cid.Cid]struct{})
+	for c := range ch {
+		keys[c] = struct{}{}
+	}
+
+	return &snapshot{
+		path:  path,
+		roots: roots,
+		keys:  keys,
+		car:   car,
+		bs:    blockstore.Adapt(car),
+	}, nil
+}
+
+func (s *snapshot) Close() error {
+	return s.car.Close()
+}
+
+// missingFrom returns the objects of the snapshot which are missing from other.
+func (s *snapshot) missingFrom(other *snapshot) []cid.Cid {
+	var res []cid.Cid
+	for c := range s.keys {
+		if _, ok := other.keys[c]; !ok {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// classify determines the types of the given objects of the snapshot, by walking it
+// from its roots; an object reachable in several ways gets the type of the first.
+func (s *snapshot) classify(ctx context.Context, objs []cid.Cid) (map[cid.Cid]snapshotObjectType, error) {
+	res := make(map[cid.Cid]snapshotObjectType, len(objs))
+	for _, c := range objs {
+		res[c] = snapshotUnreachable
+	}
+
+	seen := cid.NewSet()
+	record := func(c cid.Cid, typ snapshotObjectType) {
+		if _, ok := res[c]; ok {
+			res[c] = typ
+		}
+	}
+
+	var hdrs []*types.BlockHeader
+	queue := append([]cid.Cid{}, s.roots...)
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		if !seen.Visit(c) {
+			continue
+		}
+
+		blk, err := s.bs.Get(ctx, c)
+		if ipld.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("error getting block header %s: %w", c, err)
+		}
+
+		hdr, err := types.DecodeBlock(blk.RawData())
+		if err != nil {
+			return nil, xerrors.Errorf("error decoding block header %s: %w", c, err)
+		}
+
+		record(c, snapshotHeader)
+		hdrs = append(hdrs, hdr)
+		queue = append(queue, hdr.Parents...)
+	}
+
+	walks := []struct {
+		typ  snapshotObjectType
+		root func(*types.BlockHeader) cid.Cid
+	}{
+		{snapshotMessages, func(h *types.BlockHeader) cid.Cid { return h.Messages }},
+		{snapshotReceipts, func(h *types.BlockHeader) cid.Cid { return h.ParentMessageReceipts }},
+		{snapshotState, func(h *types.BlockHeader) cid.Cid { return h.ParentStateRoot }},
+	}
+	for _, w := range walks {
+		for _, hdr := range hdrs {
+			if err := s.walk(ctx, w.root(hdr), seen, func(c cid.Cid) { record(c, w.typ) }); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// walk visits the objects of the snapshot reachable from root which haven't been
+// seen yet; objects missing from the snapshot are skipped.
+func (s *snapshot) walk(ctx context.Context, root cid.Cid, seen *cid.Set, f func(cid.Cid)) error {
+	stack := []cid.Cid{root}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if !seen.Visit(c) {
+			continue
+		}
+
+		has, err := s.bs.Has(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("error checking for %s: %w", c, err)
+		}
+		if !has {
+			continue
+		}
+
+		f(c)
+
+		if c.Prefix().Codec != cid.DagCBOR {
+			continue
+		}
+
+		err = s.bs.View(ctx, c, func(data []byte) error {
+			return cbg.ScanForLinks(bytes.NewReader(data), func(l cid.Cid) {
+				stack = append(stack, l)
+			})
+		})
+		if err != nil {
+			return xerrors.Errorf("error scanning links of %s: %w", c, err)
+		}
+	}
+
+	return nil
+}
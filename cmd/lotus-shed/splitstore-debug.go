@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var splitstoreDebugCmd = &cli.Command{
+	Name:        "debug-report",
+	Description: "analyze splitstore debug logs (read/write/delete/stack) and print diagnostic reports",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "path",
+			Usage: "path to the splitstore debug log directory",
+			Value: "~/.lotus/datastore/splitstore/debug",
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of entries to print in each report",
+			Value: 10,
+		},
+		&cli.BoolFlag{
+			Name:  "stacks",
+			Usage: "print the full stack traces of the top writers",
+		},
+		&cli.Int64Flag{
+			Name:  "genesis-time",
+			Usage: "genesis timestamp used to convert log timestamps to epochs",
+			Value: MAINNET_GENESIS_TIME,
+		},
+		&cli.Int64Flag{
+			Name:  "epoch-bucket",
+			Usage: "bucket width (in epochs) for the read-miss histogram",
+			Value: 1,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		path, err := homedir.Expand(cctx.String("path"))
+		if err != nil {
+			return err
+		}
+
+		top := cctx.Int("top")
+		bucket := abi.ChainEpoch(cctx.Int64("epoch-bucket"))
+		if bucket < 1 {
+			return xerrors.Errorf("epoch bucket must be positive")
+		}
+
+		toEpoch := func(ts time.Time) abi.ChainEpoch {
+			return abi.ChainEpoch((ts.Unix() - cctx.Int64("genesis-time")) / int64(build.BlockDelaySecs))
+		}
+
+		stacks, err := readDebugStacks(path)
+		if err != nil {
+			return xerrors.Errorf("error reading stack log: %w", err)
+		}
+
+		// writes: count by stack and remember the first write of each object
+		writesByStack := make(map[string]int)
+		firstWrite := make(map[string]debugLogEntry)
+		var writes int
+		err = readDebugLog(path, "write.log", func(e debugLogEntry) error {
+			writes++
+			writesByStack[e.Stack]++
+			if _, ok := firstWrite[e.Cid]; !ok {
+				firstWrite[e.Cid] = e
+			}
+			return nil
+		})
+		if err != nil {
+			return xerrors.Errorf("error reading write log: %w", err)
+		}
+
+		// deletes: objects that were written and subsequently purged
+		purgedByStack := make(map[string]int)
+		var deletes, purged int
+		err = readDebugLog(path, "delete.log", func(e debugLogEntry) error {
+			deletes++
+			w, ok := firstWrite[e.Cid]
+			if !ok || w.Time.After(e.Time) {
+				return nil
+			}
+			purged++
+			purgedByStack[w.Stack]++
+			delete(firstWrite, e.Cid)
+			return nil
+		})
+		if err != nil {
+			return xerrors.Errorf("error reading delete log: %w", err)
+		}
+
+		// read misses by epoch
+		missesByEpoch := make(map[abi.ChainEpoch]int)
+		missesByCid := make(map[string]int)
+		var misses int
+		err = readDebugLog(path, "read.log", func(e debugLogEntry) error {
+			misses++
+			epoch := toEpoch(e.Time)
+			missesByEpoch[epoch-epoch%bucket]++
+			missesByCid[e.Cid]++
+			return nil
+		})
+		if err != nil {
+			return xerrors.Errorf("error reading read log: %w", err)
+		}
+
+		fmt.Printf("writes: %d  deletes: %d  written then purged: %d  read misses: %d\n\n", writes, deletes, purged, misses)
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+
+		fmt.Println("Top writers by stack trace:")
+		_, _ = fmt.Fprintf(tw, "STACK\tWRITES\tPURGED\n")
+		topWriters := topCounts(writesByStack, top)
+		for _, sc := range topWriters {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\n", stackName(sc.key), sc.count, purgedByStack[sc.key])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if cctx.Bool("stacks") {
+			for _, sc := range topWriters {
+				fmt.Printf("\n%s:\n%s\n", stackName(sc.key), stacks[sc.key])
+			}
+		}
+
+		fmt.Println()
+		fmt.Println("Written then purged, by writer stack trace:")
+		_, _ = fmt.Fprintf(tw, "STACK\tPURGED\n")
+		for _, sc := range topCounts(purgedByStack, top) {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", stackName(sc.key), sc.count)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		fmt.Println("Most frequently missed objects:")
+		_, _ = fmt.Fprintf(tw, "CID\tMISSES\n")
+		for _, sc := range topCounts(missesByCid, top) {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", sc.key, sc.count)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		fmt.Println("Read misses by epoch:")
+		_, _ = fmt.Fprintf(tw, "EPOCH\tMISSES\n")
+		epochs := make([]abi.ChainEpoch, 0, len(missesByEpoch))
+		for epoch := range missesByEpoch {
+			epochs = append(epochs, epoch)
+		}
+		sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+		for _, epoch := range epochs {
+			_, _ = fmt.Fprintf(tw, "%d\t%d\n", epoch, missesByEpoch[epoch])
+		}
+
+		return tw.Flush()
+	},
+}
+
+type debugLogEntry struct {
+	Time  time.Time
+	Cid   string
+	Stack string
+}
+
+type stackCount struct {
+	key   string
+	count int
+}
+
+func topCounts(counts map[string]int, n int) []stackCount {
+	result := make([]stackCount, 0, len(counts))
+	for k, c := range counts {
+		result = append(result, stackCount{key: k, count: c})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].count == result[j].count {
+			return result[i].key < result[j].key
+		}
+		return result[i].count > result[j].count
+	})
+
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+
+	return result
+}
+
+func stackName(stack string) string {
+	if stack == "" {
+		return "<no trace>"
+	}
+	return stack
+}
+
+// debugLogFiles returns the rotated (and possibly compressed) archives of a debug log, followed
+// by the current log, in chronological order.
+func debugLogFiles(path, name string) ([]string, error) {
+	archives, err := filepath.Glob(filepath.Join(path, name+"-*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(archives)
+
+	current := filepath.Join(path, name)
+	if _, err := os.Stat(current); err == nil {
+		archives = append(archives, current)
+	}
+
+	return archives, nil
+}
+
+func openDebugLogFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, xerrors.Errorf("error opening gzip stream for %s: %w", path, err)
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, f}, nil
+}
+
+// readDebugLog parses every entry of the named debug log; each line has the format
+// `<timestamp> <cid> [<stack hash>]`.
+func readDebugLog(path, name string, cb func(debugLogEntry) error) error {
+	files, err := debugLogFiles(path, name)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err := func() error {
+			rd, err := openDebugLogFile(file)
+			if err != nil {
+				return err
+			}
+			defer rd.Close() //nolint:errcheck
+
+			scanner := bufio.NewScanner(rd)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) < 2 {
+					continue
+				}
+
+				var e debugLogEntry
+				if err := e.Time.UnmarshalText([]byte(fields[0])); err != nil {
+					log.Warnf("skipping malformed log line in %s: %s", file, err)
+					continue
+				}
+				e.Cid = fields[1]
+				if len(fields) > 2 {
+					e.Stack = fields[2]
+				}
+
+				if err := cb(e); err != nil {
+					return err
+				}
+			}
+
+			return scanner.Err()
+		}()
+		if err != nil {
+			return xerrors.Errorf("error reading %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// readDebugStacks loads the stack hash -> stack trace mapping from the stack log; each record
+// is the hex encoded hash on its own line, followed by the normalized stack trace.
+func readDebugStacks(path string) (map[string]string, error) {
+	files, err := debugLogFiles(path, "stack.log")
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make(map[string]string)
+	for _, file := range files {
+		err := func() error {
+			rd, err := openDebugLogFile(file)
+			if err != nil {
+				return err
+			}
+			defer rd.Close() //nolint:errcheck
+
+			var key string
+			var trace []string
+			flush := func() {
+				if key != "" {
+					stacks[key] = strings.Join(trace, "\n")
+				}
+				trace = trace[:0]
+			}
+
+			scanner := bufio.NewScanner(rd)
+			scanner.Buffer(make([]byte, 64<<10), 16<<20)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if isStackHash(line) {
+					flush()
+					key = line
+					continue
+				}
+				trace = append(trace, line)
+			}
+			flush()
+
+			return scanner.Err()
+		}()
+		if err != nil {
+			return nil, xerrors.Errorf("error reading %s: %w", file, err)
+		}
+	}
+
+	return stacks, nil
+}
+
+func isStackHash(line string) bool {
+	if len(line) != 64 {
+		return false
+	}
+	for _, c := range line {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
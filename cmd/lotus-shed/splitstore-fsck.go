@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var splitstoreFsckCmd = &cli.Command{
+	Name:        "fsck",
+	Description: "checks that every copy of the objects of the splitstore of an offline repo matches its hash; objects are checked once each in key order, so that an interrupted check can be resumed",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.StringFlag{
+			Name:  "after",
+			Usage: "resume an interrupted check after this key, the last one it reported",
+		},
+		&cli.IntFlag{
+			Name:  "progress",
+			Usage: "report the progress every this many objects",
+			Value: 1_000_000,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		var after cid.Cid
+		if cctx.IsSet("after") {
+			c, err := cid.Decode(cctx.String("after"))
+			if err != nil {
+				return xerrors.Errorf("error parsing key to resume after: %w", err)
+			}
+			after = c
+		}
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("error opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.Lock(repo.FullNode)
+		if err != nil {
+			return xerrors.Errorf("error locking repo: %w", err)
+		}
+		defer lr.Close() //nolint:errcheck
+
+		cfg, err := lr.Config()
+		if err != nil {
+			return xerrors.Errorf("error getting config: %w", err)
+		}
+
+		fncfg, ok := cfg.(*config.FullNode)
+		if !ok {
+			return xerrors.Errorf("wrong config type: %T", cfg)
+		}
+
+		if !fncfg.Chainstore.EnableSplitstore {
+			return xerrors.Errorf("splitstore is not enabled")
+		}
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return xerrors.Errorf("error opening metadata datastore: %w", err)
+		}
+		defer mds.Close() //nolint:errcheck
+
+		ss, hot, cold, closer, err := openOfflineSplitstore(ctx, lr, &fncfg.Chainstore, mds)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		total, _, err := ss.EstimateKeys(ctx)
+		if err != nil {
+			log.Warnf("error estimating objects: %s", err)
+		}
+
+		// the keys are ordered, so the keys up to the one to resume after are skipped
+		ch, err := ss.AllKeysChan(blockstore.WithOrderedKeys(ctx))
+		if err != nil {
+			return xerrors.Errorf("error listing keys: %w", err)
+		}
+
+		stores := []struct {
+			name string
+			bs   blockstore.Blockstore
+		}{
+			{"hotstore", hot},
+			{"coldstore", cold},
+		}
+
+		progress := cctx.Int("progress")
+		var checked, skipped, corrupt int
+		var last cid.Cid
+		for c := range ch {
+			if after.Defined() && blockstore.CompareKeys(c, after) <= 0 {
+				skipped++
+				continue
+			}
+
+			for _, st := range stores {
+				err := st.bs.View(ctx, c, func(data []byte) error {
+					sum, err := c.Prefix().Sum(data)
+					if err != nil {
+						return err
+					}
+					if !bytes.Equal(sum.Hash(), c.Hash()) {
+						fmt.Printf("corrupt copy of %s in the %s\n", c, st.name)
+						corrupt++
+					}
+					return nil
+				})
+				if err != nil && !ipld.IsNotFound(err) {
+					return xerrors.Errorf("error checking %s in the %s: %w", c, st.name, err)
+				}
+			}
+
+			checked++
+			last = c
+			if progress > 0 && checked%progress == 0 {
+				fmt.Printf("checked %d of ~%d objects, last key %s\n", checked+skipped, total, c)
+			}
+		}
+
+		if ctx.Err() != nil {
+			if last.Defined() {
+				fmt.Printf("interrupted; resume with --after %s\n", last)
+			}
+			return ctx.Err()
+		}
+
+		fmt.Printf("checked %d objects after skipping %d, found %d corrupt copies\n", checked, skipped, corrupt)
+		if corrupt > 0 {
+			return xerrors.Errorf("found %d corrupt copies", corrupt)
+		}
+		return nil
+	},
+}
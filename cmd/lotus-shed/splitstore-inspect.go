@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var splitstorePendingWritesCmd = &cli.Command{
+	Name:        "pending-writes",
+	Description: "list the objects written or referenced during the running compaction which are yet to be protected from purging",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+		pending, err := api.ChainBlockstorePendingWrites(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range pending {
+			fmt.Println(c)
+		}
+
+		return nil
+	},
+}
+
+var splitstoreDumpMarkSetCmd = &cli.Command{
+	Name:        "dump-markset",
+	Description: "dump the multihashes of the objects marked so far by the running compaction; the dump is written by the node, so the output path is on the node's host, inside the directory set with LOTUS_MARKSET_DUMP_BASE_PATH on the node",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "output",
+			Usage:    "path to write the dump to",
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		path, err := filepath.Abs(cctx.String("output"))
+		if err != nil {
+			return err
+		}
+
+		ctx := lcli.ReqContext(cctx)
+		if err := api.ChainBlockstoreDumpMarkSet(ctx, path); err != nil {
+			return err
+		}
+
+		fmt.Printf("markset dumped to %s\n", path)
+		return nil
+	},
+}
+
+var splitstoreCompareMarkSetsCmd = &cli.Command{
+	Name:        "compare-marksets",
+	Description: "compare two markset dumps, such as those of consecutive compactions, or of the same compaction on different nodes",
+	ArgsUsage:   "<dump-a> <dump-b>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "list",
+			Usage: "list the objects marked in only one of the dumps, prefixed with - for a and + for b",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		a, err := readMarkSetDump(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		b, err := readMarkSetDump(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		var onlyA, onlyB []string
+		var both int
+		for h := range a {
+			if _, ok := b[h]; ok {
+				both++
+			} else {
+				onlyA = append(onlyA, h)
+			}
+		}
+		for h := range b {
+			if _, ok := a[h]; !ok {
+				onlyB = append(onlyB, h)
+			}
+		}
+
+		fmt.Printf("marked in both: %d\n", both)
+		fmt.Printf("marked only in a: %d\n", len(onlyA))
+		fmt.Printf("marked only in b: %d\n", len(onlyB))
+
+		if cctx.Bool("list") {
+			sort.Strings(onlyA)
+			sort.Strings(onlyB)
+			for _, h := range onlyA {
+				fmt.Printf("- %s\n", h)
+			}
+			for _, h := range onlyB {
+				fmt.Printf("+ %s\n", h)
+			}
+		}
+
+		return nil
+	},
+}
+
+// readMarkSetDump reads the set of multihashes in a markset dump; dumps may list an
+// object more than once.
+func readMarkSetDump(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("error opening markset dump: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	res := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			res[line] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("error reading markset dump %s: %w", path, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var splitstoreLoadCmd = &cli.Command{
+	Name:  "load",
+	Usage: "generate state churn against a devnet, to fill the hotstore quickly",
+	Description: `Every epoch, a number of messages is sent, each either creating a new account
+actor, funded from the --from address, or transferring funds between the accounts
+created so far. Creations insert into the state tree and init actor HAMTs and
+transfers mutate the state tree HAMT, which fills the hotstore with the kind of
+garbage compaction and GC have to deal with.
+
+The keys of the created accounts are kept in memory only, so funds sent to them are
+lost; this is meant for devnets.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "address funding the created accounts; defaults to the default wallet address",
+		},
+		&cli.IntFlag{
+			Name:  "msgs-per-epoch",
+			Usage: "number of messages to send every epoch",
+			Value: 50,
+		},
+		&cli.Float64Flag{
+			Name:  "create-ratio",
+			Usage: "fraction of the messages creating new accounts, the rest being transfers between accounts",
+			Value: 0.2,
+		},
+		&cli.StringFlag{
+			Name:  "fund",
+			Usage: "amount of FIL to fund every created account with",
+			Value: "1",
+		},
+		&cli.IntFlag{
+			Name:  "epochs",
+			Usage: "number of epochs to generate load for; 0 runs until interrupted",
+		},
+		&cli.Int64Flag{
+			Name:  "seed",
+			Usage: "seed for choosing the messages to send; defaults to the current time",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		var from address.Address
+		if cctx.IsSet("from") {
+			from, err = address.NewFromString(cctx.String("from"))
+		} else {
+			from, err = api.WalletDefaultAddress(ctx)
+		}
+		if err != nil {
+			return xerrors.Errorf("error getting the funding address: %w", err)
+		}
+
+		fund, err := types.ParseFIL(cctx.String("fund"))
+		if err != nil {
+			return xerrors.Errorf("error parsing fund amount: %w", err)
+		}
+
+		ratio := cctx.Float64("create-ratio")
+		if ratio < 0 || ratio > 1 {
+			return xerrors.Errorf("create-ratio must be between 0 and 1")
+		}
+
+		seed := time.Now().UnixNano()
+		if cctx.IsSet("seed") {
+			seed = cctx.Int64("seed")
+		}
+
+		w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+		if err != nil {
+			return err
+		}
+
+		gen := &loadGen{
+			api:    api,
+			wallet: w,
+			rng:    rand.New(rand.NewSource(seed)),
+			from:   from,
+			fund:   abi.TokenAmount(fund),
+			nonces: make(map[address.Address]uint64),
+		}
+
+		notifs, err := api.ChainNotify(ctx)
+		if err != nil {
+			return xerrors.Errorf("chain notify error: %w", err)
+		}
+
+		perEpoch := cctx.Int("msgs-per-epoch")
+		epochs := cctx.Int("epochs")
+		for i := 0; epochs == 0 || i < epochs; {
+			var ts *types.TipSet
+			select {
+			case n, ok := <-notifs:
+				if !ok {
+					return xerrors.Errorf("chain notify channel closed")
+				}
+				for _, change := range n {
+					if change.Type == store.HCApply || change.Type == store.HCCurrent {
+						ts = change.Val
+					}
+				}
+			case <-ctx.Done():
+				return nil
+			}
+			if ts == nil {
+				continue
+			}
+
+			created, transferred, err := gen.round(ctx, ts, perEpoch, ratio)
+			if err != nil {
+				return xerrors.Errorf("error generating load at epoch %d: %w", ts.Height(), err)
+			}
+
+			fmt.Printf("epoch %d: %d accounts created, %d transfers; %d accounts ready, %d pending\n",
+				ts.Height(), created, transferred, len(gen.ready), len(gen.pending))
+			i++
+		}
+
+		return nil
+	},
+}
+
+// loadGen sends the messages of load generation; accounts are pending from their
+// creation until their funding lands on chain.
+type loadGen struct {
+	api    lapi.FullNode
+	wallet *wallet.LocalWallet
+	rng    *rand.Rand
+
+	from address.Address
+	fund abi.TokenAmount
+
+	pending []address.Address
+	ready   []address.Address
+	nonces  map[address.Address]uint64
+}
+
+func (g *loadGen) round(ctx context.Context, ts *types.TipSet, n int, ratio float64) (created, transferred int, err error) {
+	g.promote(ctx, ts)
+
+	var creates []*types.Message
+	var transfers []*types.SignedMessage
+	for i := 0; i < n; i++ {
+		if len(g.ready) < 2 || g.rng.Float64() < ratio {
+			to, err := g.wallet.WalletNew(ctx, types.KTSecp256k1)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			creates = append(creates, &types.Message{
+				From:  g.from,
+				To:    to,
+				Value: g.fund,
+			})
+			continue
+		}
+
+		smsg, err := g.transfer(ctx, ts)
+		if err != nil {
+			return 0, 0, err
+		}
+		transfers = append(transfers, smsg)
+	}
+
+	if len(creates) > 0 {
+		if _, err := g.api.MpoolBatchPushMessage(ctx, creates, nil); err != nil {
+			return 0, 0, xerrors.Errorf("error pushing account creations: %w", err)
+		}
+
+		for _, msg := range creates {
+			g.pending = append(g.pending, msg.To)
+		}
+	}
+
+	if len(transfers) > 0 {
+		if _, err := g.api.MpoolBatchPush(ctx, transfers); err != nil {
+			// the nonces of the senders can't be trusted anymore
+			for _, smsg := range transfers {
+				delete(g.nonces, smsg.Message.From)
+			}
+			return len(creates), 0, xerrors.Errorf("error pushing transfers: %w", err)
+		}
+	}
+
+	return len(creates), len(transfers), nil
+}
+
+// promote makes the pending accounts whose funding has landed ready to send.
+func (g *loadGen) promote(ctx context.Context, ts *types.TipSet) {
+	var pending []address.Address
+	for _, addr := range g.pending {
+		act, err := g.api.StateGetActor(ctx, addr, ts.Key())
+		if err != nil || act.Balance.IsZero() {
+			pending = append(pending, addr)
+			continue
+		}
+
+		g.ready = append(g.ready, addr)
+	}
+	g.pending = pending
+}
+
+// transfer signs a transfer of a small amount between two random ready accounts.
+func (g *loadGen) transfer(ctx context.Context, ts *types.TipSet) (*types.SignedMessage, error) {
+	i := g.rng.Intn(len(g.ready))
+	j := g.rng.Intn(len(g.ready) - 1)
+	if j >= i {
+		j++
+	}
+	from, to := g.ready[i], g.ready[j]
+
+	nonce, ok := g.nonces[from]
+	if !ok {
+		var err error
+		nonce, err = g.api.MpoolGetNonce(ctx, from)
+		if err != nil {
+			return nil, xerrors.Errorf("error getting nonce of %s: %w", from, err)
+		}
+	}
+
+	msg := &types.Message{
+		From:  from,
+		To:    to,
+		Value: big.NewInt(int64(1 + g.rng.Intn(1000))),
+		Nonce: nonce,
+	}
+
+	msg, err := g.api.GasEstimateMessageGas(ctx, msg, nil, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("error estimating gas: %w", err)
+	}
+
+	sb, err := messagesigner.SigningBytes(msg, from.Protocol())
+	if err != nil {
+		return nil, err
+	}
+	mb, err := msg.ToStorageBlock()
+	if err != nil {
+		return nil, xerrors.Errorf("serializing message: %w", err)
+	}
+
+	sig, err := g.wallet.WalletSign(ctx, from, sb, lapi.MsgMeta{
+		Type:  lapi.MTChainMsg,
+		Extra: mb.RawData(),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("error signing message: %w", err)
+	}
+
+	g.nonces[from] = nonce + 1
+
+	return &types.SignedMessage{
+		Message:   *msg,
+		Signature: *sig,
+	}, nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var errReplayDone = errors.New("replay done")
+
+var splitstoreReplayCmd = &cli.Command{
+	Name:        "replay",
+	Description: "replay the writes and compactions recorded in splitstore debug logs against a copy of a repo, to reproduce past compactions",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "repo",
+			Usage:    "path to the copy of the repo to replay against; it is modified by the replay",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "debug-path",
+			Usage:    "path to the splitstore debug log directory of the node the logs were recorded on",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "source",
+			Usage: "path to a badger blockstore to read the written objects from, such as the chain blockstore of the node the logs were recorded on",
+		},
+		&cli.TimestampFlag{
+			Name:   "since",
+			Usage:  "skip the operations recorded before this time, such as the time the copy of the repo was made",
+			Layout: time.RFC3339,
+		},
+		&cli.IntFlag{
+			Name:  "compactions",
+			Usage: "stop after replaying this many compactions; 0 replays all of them",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := context.Background()
+
+		debugPath, err := homedir.Expand(cctx.String("debug-path"))
+		if err != nil {
+			return err
+		}
+
+		var since time.Time
+		if ts := cctx.Timestamp("since"); ts != nil {
+			since = *ts
+		}
+
+		ops, err := readReplayOps(debugPath, since)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("replaying %d operations\n", len(ops))
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("error opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.Lock(repo.FullNode)
+		if err != nil {
+			return xerrors.Errorf("error locking repo: %w", err)
+		}
+		defer lr.Close() //nolint:errcheck
+
+		cfg, err := lr.Config()
+		if err != nil {
+			return xerrors.Errorf("error getting config: %w", err)
+		}
+
+		fncfg, ok := cfg.(*config.FullNode)
+		if !ok {
+			return xerrors.Errorf("wrong config type: %T", cfg)
+		}
+
+		if !fncfg.Chainstore.EnableSplitstore {
+			return xerrors.Errorf("splitstore is not enabled")
+		}
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return xerrors.Errorf("error opening metadata datastore: %w", err)
+		}
+		defer mds.Close() //nolint:errcheck
+
+		md, err := splitstore.LoadMetadata(ctx, mds)
+		if err != nil {
+			return xerrors.Errorf("error loading splitstore metadata: %w", err)
+		}
+		if md == nil || !md.WarmedUp {
+			return xerrors.Errorf("the splitstore of the repo hasn't been warmed up")
+		}
+
+		var source blockstore.Blockstore = blockstore.NewMemory()
+		if cctx.IsSet("source") {
+			path, err := homedir.Expand(cctx.String("source"))
+			if err != nil {
+				return err
+			}
+
+			opts, err := repo.BadgerBlockstoreOptions(repo.UniversalBlockstore, path, true)
+			if err != nil {
+				return xerrors.Errorf("error getting source badger options: %w", err)
+			}
+
+			src, err := badgerbs.Open(opts)
+			if err != nil {
+				return xerrors.Errorf("error opening source blockstore: %w", err)
+			}
+			defer src.Close() //nolint:errcheck
+
+			source = src
+		}
+
+		ss, _, _, closer, err := openOfflineSplitstore(ctx, lr, &fncfg.Chainstore, mds)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := ss.Start(replayChain{}, nil); err != nil {
+			return xerrors.Errorf("error starting splitstore: %w", err)
+		}
+
+		limit := cctx.Int("compactions")
+		var compactions, lost int
+		err = ss.Replay(ops, source, func(res *splitstore.ReplayCompaction) error {
+			compactions++
+
+			purged := make(map[cid.Cid]struct{}, len(res.Purged))
+			for _, c := range res.Purged {
+				purged[c] = struct{}{}
+			}
+
+			var livePurged []cid.Cid
+			for _, c := range res.Missing {
+				if _, ok := purged[c]; ok {
+					livePurged = append(livePurged, c)
+				}
+			}
+			lost += len(livePurged)
+
+			fmt.Printf("compaction at %d: purged %d objects, %d live objects missing, %d of them purged; %d writes unavailable\n",
+				res.Head.Height(), len(res.Purged), len(res.Missing), len(livePurged), res.Unavailable)
+			for _, c := range livePurged {
+				fmt.Printf("  purged live object %s\n", c)
+			}
+
+			if limit > 0 && compactions >= limit {
+				return errReplayDone
+			}
+			return nil
+		})
+		if err != nil && err != errReplayDone {
+			return xerrors.Errorf("error replaying: %w", err)
+		}
+
+		fmt.Printf("replayed %d compactions, %d live objects purged\n", compactions, lost)
+		return nil
+	},
+}
+
+// replayChain stands in for the chain of a replayed splitstore, whose head changes
+// are driven by the replay.
+type replayChain struct{}
+
+func (replayChain) GetTipsetByHeight(context.Context, abi.ChainEpoch, *types.TipSet, bool) (*types.TipSet, error) {
+	return nil, xerrors.Errorf("no chain while replaying")
+}
+
+func (replayChain) GetHeaviestTipSet() *types.TipSet {
+	return nil
+}
+
+func (replayChain) SubscribeHeadChanges(func(revert []*types.TipSet, apply []*types.TipSet) error) {}
+
+// readReplayOps reads the writes and compactions recorded in the debug logs at path
+// since the given time, in the order they were recorded.
+func readReplayOps(path string, since time.Time) ([]splitstore.ReplayOp, error) {
+	var ops []splitstore.ReplayOp
+
+	err := readDebugLog(path, "write.log", func(e debugLogEntry) error {
+		if e.Time.Before(since) {
+			return nil
+		}
+
+		c, err := cid.Decode(e.Cid)
+		if err != nil {
+			return xerrors.Errorf("error decoding cid %s: %w", e.Cid, err)
+		}
+
+		ops = append(ops, splitstore.ReplayOp{Time: e.Time, Write: c})
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("error reading write log: %w", err)
+	}
+
+	// compaction log lines have the block cids of the head, comma separated, in place of
+	// the cid of the other logs
+	err = readDebugLog(path, "compact.log", func(e debugLogEntry) error {
+		if e.Time.Before(since) {
+			return nil
+		}
+
+		var blks []cid.Cid
+		for _, s := range strings.Split(e.Cid, ",") {
+			c, err := cid.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("error decoding cid %s: %w", s, err)
+			}
+			blks = append(blks, c)
+		}
+
+		ops = append(ops, splitstore.ReplayOp{Time: e.Time, Compact: types.NewTipSetKey(blks...)})
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("error reading compaction log: %w", err)
+	}
+
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].Time.Before(ops[j].Time)
+	})
+
+	return ops, nil
+}
@@ -18,6 +18,9 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -31,6 +34,13 @@ var splitstoreCmd = &cli.Command{
 		splitstoreClearCmd,
 		splitstoreCheckCmd,
 		splitstoreInfoCmd,
+		splitstoreDebugCmd,
+		splitstoreReplayCmd,
+		splitstorePendingWritesCmd,
+		splitstoreDumpMarkSetCmd,
+		splitstoreCompareMarkSetsCmd,
+		splitstoreLoadCmd,
+		splitstoreFsckCmd,
 	},
 }
 
@@ -86,13 +96,13 @@ var splitstoreRollbackCmd = &cli.Command{
 		}
 
 		fmt.Println("copying hotstore to coldstore...")
-		err = copyHotstoreToColdstore(lr, cctx.Bool("gc-coldstore"))
+		err = copyHotstoreToColdstore(lr, &fncfg.Chainstore, cctx.Bool("gc-coldstore"))
 		if err != nil {
 			return xerrors.Errorf("error copying hotstore to coldstore: %w", err)
 		}
 
 		fmt.Println("clearing splitstore directory...")
-		err = clearSplitstoreDir(lr)
+		err = clearSplitstoreDir(lr, &fncfg.Chainstore)
 		if err != nil {
 			return xerrors.Errorf("error clearing splitstore directory: %w", err)
 		}
@@ -173,7 +183,7 @@ var splitstoreClearCmd = &cli.Command{
 
 		if !cctx.Bool("keys-only") {
 			fmt.Println("clearing splitstore directory...")
-			err = clearSplitstoreDir(lr)
+			err = clearSplitstoreDir(lr, &fncfg.Chainstore)
 			if err != nil {
 				return xerrors.Errorf("error clearing splitstore directory: %w", err)
 			}
@@ -189,11 +199,11 @@ var splitstoreClearCmd = &cli.Command{
 	},
 }
 
-func copyHotstoreToColdstore(lr repo.LockedRepo, gcColdstore bool) error {
-	repoPath := lr.Path()
-	dataPath := filepath.Join(repoPath, "datastore")
-	coldPath := filepath.Join(dataPath, "chain")
-	hotPath := filepath.Join(dataPath, "splitstore", "hot.badger")
+func copyHotstoreToColdstore(lr repo.LockedRepo, cfg *config.Chainstore, gcColdstore bool) error {
+	hotPath, coldPath, err := repo.ChainstorePaths(lr.Path(), cfg)
+	if err != nil {
+		return xerrors.Errorf("error getting chainstore paths: %w", err)
+	}
 
 	blog := &badgerLogger{
 		SugaredLogger: log.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar(),
@@ -295,15 +305,27 @@ func deleteSplitstoreDir(lr repo.LockedRepo) error {
 	return os.RemoveAll(path)
 }
 
-func clearSplitstoreDir(lr repo.LockedRepo) error {
+func clearSplitstoreDir(lr repo.LockedRepo, cfg *config.Chainstore) error {
 	path, err := lr.SplitstorePath()
 	if err != nil {
 		return xerrors.Errorf("error getting splitstore path: %w", err)
 	}
 
+	if cfg.Splitstore.HotStorePath != "" {
+		// the hotstore lives outside of the splitstore directory, in a directory
+		// which may be shared with other data
+		hotPath, _, err := repo.ChainstorePaths(lr.Path(), cfg)
+		if err != nil {
+			return xerrors.Errorf("error getting hotstore path: %w", err)
+		}
+		if err := clearBadgerDir(hotPath); err != nil {
+			return err
+		}
+	}
+
 	entries, err := os.ReadDir(path)
 	if err != nil {
-		return xerrors.Errorf("error reading splitstore directory %s: %W", path, err)
+		return xerrors.Errorf("error reading directory %s: %w", path, err)
 	}
 
 	var result error
@@ -319,6 +341,47 @@ func clearSplitstoreDir(lr repo.LockedRepo) error {
 	return result
 }
 
+// clearBadgerDir removes the badger files in dir, refusing to remove anything when
+// the directory contains any other file.
+func clearBadgerDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return xerrors.Errorf("error reading directory %s: %w", dir, err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !isBadgerFile(e.Name()) {
+			return xerrors.Errorf("refusing to clear %s: %s was not created by the hotstore; remove the hotstore files manually", dir, e.Name())
+		}
+	}
+
+	var result error
+	for _, e := range entries {
+		target := filepath.Join(dir, e.Name())
+		if err := os.Remove(target); err != nil {
+			log.Errorf("error removing %s: %s", target, err)
+			result = multierr.Append(result, err)
+		}
+	}
+
+	return result
+}
+
+// isBadgerFile returns true for the names of the files badger creates in its directory.
+func isBadgerFile(name string) bool {
+	switch name {
+	case "MANIFEST", "MANIFEST-REWRITE", "KEYREGISTRY", "KEYREGISTRY-REWRITE", "LOCK", "DISCARD":
+		return true
+	}
+
+	switch filepath.Ext(name) {
+	case ".sst", ".vlog", ".mem":
+		return true
+	}
+
+	return false
+}
+
 func deleteSplitstoreKeys(lr repo.LockedRepo) error {
 	ds, err := lr.Datastore(context.TODO(), "/metadata")
 	if err != nil {
@@ -403,3 +466,67 @@ var splitstoreInfoCmd = &cli.Command{
 		return nil
 	},
 }
+
+// openOfflineSplitstore opens the splitstore of a locked full node repo without starting it,
+// along with its hotstore and coldstore; closer closes them all.
+func openOfflineSplitstore(ctx context.Context, lr repo.LockedRepo, cfg *config.Chainstore, mds datastore.Batching) (ss *splitstore.SplitStore, hot, cold blockstore.Blockstore, closer func(), err error) {
+	cold, err = lr.Blockstore(ctx, repo.UniversalBlockstore)
+	if err != nil {
+		return nil, nil, nil, nil, xerrors.Errorf("error opening coldstore: %w", err)
+	}
+	closeCold := func() {
+		if c, ok := cold.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Warnf("error closing coldstore: %s", err)
+			}
+		}
+	}
+
+	hotPath, _, err := repo.ChainstorePaths(lr.Path(), cfg)
+	if err != nil {
+		closeCold()
+		return nil, nil, nil, nil, xerrors.Errorf("error getting chainstore paths: %w", err)
+	}
+
+	hotOpts, err := repo.BadgerBlockstoreOptions(repo.HotBlockstore, hotPath, false)
+	if err != nil {
+		closeCold()
+		return nil, nil, nil, nil, xerrors.Errorf("error getting hotstore badger options: %w", err)
+	}
+
+	hotbs, err := badgerbs.Open(hotOpts)
+	if err != nil {
+		closeCold()
+		return nil, nil, nil, nil, xerrors.Errorf("error opening hotstore: %w", err)
+	}
+
+	path, err := lr.SplitstorePath()
+	if err != nil {
+		_ = hotbs.Close()
+		closeCold()
+		return nil, nil, nil, nil, xerrors.Errorf("error getting splitstore path: %w", err)
+	}
+
+	ssCfg := &splitstore.Config{
+		MarkSetType:              cfg.Splitstore.MarkSetType,
+		DiscardColdBlocks:        cfg.Splitstore.ColdStoreType == "discard",
+		UniversalColdBlocks:      cfg.Splitstore.ColdStoreType == "universal" || cfg.Splitstore.ColdStoreType == "ipfs",
+		HotStoreMessageRetention: cfg.Splitstore.HotStoreMessageRetention,
+		HotStoreFullGCFrequency:  cfg.Splitstore.HotStoreFullGCFrequency,
+	}
+
+	ss, err = splitstore.Open(path, mds, hotbs, cold, ssCfg)
+	if err != nil {
+		_ = hotbs.Close()
+		closeCold()
+		return nil, nil, nil, nil, xerrors.Errorf("error opening splitstore: %w", err)
+	}
+
+	closer = func() {
+		_ = ss.Close()
+		_ = hotbs.Close()
+		closeCold()
+	}
+
+	return ss, hotbs, cold, closer, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/store"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var stateSnapshotCmd = &cli.Command{
+	Name:  "state-snapshot",
+	Usage: "Export and import the state tree at a given epoch, without the rest of the chain",
+	Description: `A state snapshot holds the headers of a tipset and of its recent ancestors, along with
+   their complete parent states. It is much smaller than a chain snapshot, and is enough to run
+   network upgrade migrations against a real state, e.g. with 'lotus-shed migrate-state'.`,
+	Subcommands: []*cli.Command{
+		stateSnapshotExportCmd,
+		stateSnapshotImportCmd,
+	},
+}
+
+var stateSnapshotExportCmd = &cli.Command{
+	Name:        "export",
+	Description: "Export the state at a tipset from repo (requires node to be offline)",
+	ArgsUsage:   "[outfile]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "tipset to export the state of, as a tipset key or @height",
+		},
+		&cli.Int64Flag{
+			Name:  "lookback",
+			Usage: "number of epochs before the tipset to include the headers and states of; migrate-state needs at least 61 to run the pre-migration",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		ctx := context.TODO()
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.Lock(repo.FullNode)
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+		if err != nil {
+			return fmt.Errorf("failed to open blockstore: %w", err)
+		}
+
+		defer func() {
+			if c, ok := bs.(io.Closer); ok {
+				if err := c.Close(); err != nil {
+					log.Warnf("failed to close blockstore: %s", err)
+				}
+			}
+		}()
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		cs := store.NewChainStore(bs, bs, mds, nil, nil)
+		defer cs.Close() //nolint:errcheck
+
+		if err := cs.Load(ctx); err != nil {
+			return err
+		}
+
+		ts, err := lcli.ParseTipSetRefOffline(ctx, cs, cctx.String("tipset"))
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Create(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("opening the output file: %w", err)
+		}
+
+		defer fi.Close() //nolint:errcheck
+
+		if err := cs.ExportState(ctx, ts, abi.ChainEpoch(cctx.Int64("lookback")), fi); err != nil {
+			return xerrors.Errorf("export failed: %w", err)
+		}
+
+		fmt.Printf("exported state %s of tipset %s at height %d\n", ts.ParentState(), ts.Key(), ts.Height())
+		return nil
+	},
+}
+
+var stateSnapshotImportCmd = &cli.Command{
+	Name:        "import",
+	Description: "Import a state snapshot into repo, initializing the repo if it doesn't exist (requires node to be offline)",
+	ArgsUsage:   "[infile]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Value: "~/.lotus",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		ctx := context.TODO()
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		if err := r.Init(repo.FullNode); err != nil && err != repo.ErrRepoExists {
+			return xerrors.Errorf("initializing repo: %w", err)
+		}
+
+		lr, err := r.Lock(repo.FullNode)
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		fi, err := os.Open(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("opening the input file: %w", err)
+		}
+
+		defer fi.Close() //nolint:errcheck
+
+		bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+		if err != nil {
+			return fmt.Errorf("failed to open blockstore: %w", err)
+		}
+
+		defer func() {
+			if c, ok := bs.(io.Closer); ok {
+				if err := c.Close(); err != nil {
+					log.Warnf("failed to close blockstore: %s", err)
+				}
+			}
+		}()
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		cs := store.NewChainStore(bs, bs, mds, filcns.Weight, nil)
+		defer cs.Close() //nolint:errcheck
+
+		// the head isn't set, as the imported chain is incomplete
+		ts, err := cs.Import(ctx, fi)
+		if err != nil {
+			return xerrors.Errorf("import failed: %w", err)
+		}
+
+		fmt.Printf("imported state %s of tipset %s at height %d\n", ts.ParentState(), ts.Key(), ts.Height())
+		return nil
+	},
+}
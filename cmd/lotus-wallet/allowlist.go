@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	markettypes "github.com/filecoin-project/go-state-types/builtin/v9/market"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// KeyPolicy lists what a key is allowed to sign.
+type KeyPolicy struct {
+	// Methods are the actor methods the key may sign chain messages calling
+	Methods []abi.MethodNum
+	// AllMethods allows signing chain messages calling any method
+	AllMethods bool
+	// MsgTypes are the other kinds of data the key may sign, e.g. "block" for
+	// a miner worker key. The signed bytes must decode as the type. Arbitrary
+	// bytes ("unknown") can be a chain message CID, so only keys allowed to sign
+	// all methods may sign them.
+	MsgTypes []api.MsgType
+}
+
+func (p KeyPolicy) allowsMethod(m abi.MethodNum) bool {
+	if p.AllMethods {
+		return true
+	}
+	for _, am := range p.Methods {
+		if am == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (p KeyPolicy) allowsType(t api.MsgType) bool {
+	for _, at := range p.MsgTypes {
+		if at == t {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAllowlist reads a JSON object mapping key addresses to their policy.
+func loadAllowlist(path string) (map[address.Address]KeyPolicy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]KeyPolicy
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, xerrors.Errorf("parsing allowlist: %w", err)
+	}
+
+	out := make(map[address.Address]KeyPolicy, len(raw))
+	for s, p := range raw {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing allowlist address %q: %w", s, err)
+		}
+		out[addr] = p
+	}
+
+	return out, nil
+}
+
+// AllowlistWallet only signs what the policy of the signing key allows; keys
+// without a policy can't sign anything.
+type AllowlistWallet struct {
+	api.Wallet
+
+	policies map[address.Address]KeyPolicy
+}
+
+func (c *AllowlistWallet) WalletSign(ctx context.Context, k address.Address, msg []byte, meta api.MsgMeta) (*crypto.Signature, error) {
+	p, ok := c.policies[k]
+	if !ok {
+		return nil, xerrors.Errorf("key %s is not in the signing allowlist", k)
+	}
+
+	if meta.Type != api.MTChainMsg {
+		if !p.allowsType(meta.Type) {
+			return nil, xerrors.Errorf("key %s is not allowed to sign %s data", k, meta.Type)
+		}
+		if err := checkSigningBytes(msg, meta, p); err != nil {
+			return nil, xerrors.Errorf("key %s can't sign the %s data: %w", k, meta.Type, err)
+		}
+		return c.Wallet.WalletSign(ctx, k, msg, meta)
+	}
+
+	// check that the message the policy is applied to is the one signed
+	var cmsg types.Message
+	if err := cmsg.UnmarshalCBOR(bytes.NewReader(meta.Extra)); err != nil {
+		return nil, xerrors.Errorf("unmarshalling message: %w", err)
+	}
+
+	_, bc, err := cid.CidFromBytes(msg)
+	if err != nil {
+		return nil, xerrors.Errorf("getting cid from signing bytes: %w", err)
+	}
+
+	if !cmsg.Cid().Equals(bc) {
+		return nil, xerrors.Errorf("cid(meta.Extra).bytes() != msg")
+	}
+
+	if !p.allowsMethod(cmsg.Method) {
+		return nil, xerrors.Errorf("key %s is not allowed to sign messages calling method %d", k, cmsg.Method)
+	}
+
+	return c.Wallet.WalletSign(ctx, k, msg, meta)
+}
+
+// checkSigningBytes checks that msg is an encoding of the type of data in meta,
+// so that allowing a type doesn't allow signing something else, e.g. the CID of
+// a chain message calling a method the policy doesn't allow.
+func checkSigningBytes(msg []byte, meta api.MsgMeta, p KeyPolicy) error {
+	var obj interface {
+		cbg.CBORUnmarshaler
+		cbg.CBORMarshaler
+	}
+
+	switch meta.Type {
+	case api.MTBlock:
+		obj = new(types.BlockHeader)
+	case api.MTDealProposal:
+		obj = new(markettypes.DealProposal)
+	case api.MTUnknown:
+		if !p.AllMethods {
+			return xerrors.Errorf("arbitrary bytes can only be signed by keys allowed to sign all methods")
+		}
+		return nil
+	default:
+		return xerrors.Errorf("unknown data type")
+	}
+
+	if err := obj.UnmarshalCBOR(bytes.NewReader(msg)); err != nil {
+		return xerrors.Errorf("signing bytes aren't %s data: %w", meta.Type, err)
+	}
+
+	// reject trailing or non-canonical bytes
+	var buf bytes.Buffer
+	if err := obj.MarshalCBOR(&buf); err != nil {
+		return xerrors.Errorf("re-encoding %s data: %w", meta.Type, err)
+	}
+	if !bytes.Equal(buf.Bytes(), msg) {
+		return xerrors.Errorf("signing bytes aren't canonical %s data", meta.Type)
+	}
+
+	if bh, ok := obj.(*types.BlockHeader); ok && bh.BlockSig != nil {
+		return xerrors.Errorf("signing bytes of a block must not include its signature")
+	}
+
+	return nil
+}
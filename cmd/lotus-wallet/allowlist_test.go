@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/wallet"
+)
+
+func setupAllowlist(t *testing.T, policy KeyPolicy) (*AllowlistWallet, address.Address, address.Address) {
+	ctx := context.Background()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+
+	allowed, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	unlisted, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	return &AllowlistWallet{
+		Wallet:   w,
+		policies: map[address.Address]KeyPolicy{allowed: policy},
+	}, allowed, unlisted
+}
+
+func signMessage(ctx context.Context, w api.Wallet, msg *types.Message) error {
+	mb, err := msg.ToStorageBlock()
+	if err != nil {
+		return err
+	}
+	_, err = w.WalletSign(ctx, msg.From, mb.Cid().Bytes(), api.MsgMeta{
+		Type:  api.MTChainMsg,
+		Extra: mb.RawData(),
+	})
+	return err
+}
+
+func testMessage(from address.Address, method abi.MethodNum) *types.Message {
+	return &types.Message{
+		From:       from,
+		To:         from,
+		Method:     method,
+		Value:      types.NewInt(1),
+		GasLimit:   1000,
+		GasFeeCap:  types.NewInt(1),
+		GasPremium: types.NewInt(1),
+	}
+}
+
+func TestAllowlistWalletChainMessages(t *testing.T) {
+	ctx := context.Background()
+	aw, allowed, unlisted := setupAllowlist(t, KeyPolicy{Methods: []abi.MethodNum{builtin.MethodSend}})
+
+	// allowed
+	require.NoError(t, signMessage(ctx, aw, testMessage(allowed, builtin.MethodSend)))
+
+	// denied method
+	require.Error(t, signMessage(ctx, aw, testMessage(allowed, 5)))
+
+	// keys without a policy can't sign
+	require.Error(t, signMessage(ctx, aw, testMessage(unlisted, builtin.MethodSend)))
+
+	// the signed bytes must be the CID of the checked message
+	okb, err := testMessage(allowed, builtin.MethodSend).ToStorageBlock()
+	require.NoError(t, err)
+	badb, err := testMessage(allowed, 5).ToStorageBlock()
+	require.NoError(t, err)
+	_, err = aw.WalletSign(ctx, allowed, badb.Cid().Bytes(), api.MsgMeta{Type: api.MTChainMsg, Extra: okb.RawData()})
+	require.Error(t, err)
+}
+
+func TestAllowlistWalletMsgTypes(t *testing.T) {
+	ctx := context.Background()
+	aw, allowed, _ := setupAllowlist(t, KeyPolicy{MsgTypes: []api.MsgType{api.MTBlock}})
+
+	// allowed
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.BlockSig = nil
+	sb, err := blk.SigningBytes()
+	require.NoError(t, err)
+	_, err = aw.WalletSign(ctx, allowed, sb, api.MsgMeta{Type: api.MTBlock})
+	require.NoError(t, err)
+
+	// a signed block header isn't signing bytes
+	signed := mock.MkBlock(nil, 1, 1)
+	b, err := signed.Serialize()
+	require.NoError(t, err)
+	_, err = aw.WalletSign(ctx, allowed, b, api.MsgMeta{Type: api.MTBlock})
+	require.Error(t, err)
+
+	// denied types
+	_, err = aw.WalletSign(ctx, allowed, sb, api.MsgMeta{Type: api.MTDealProposal})
+	require.Error(t, err)
+	_, err = aw.WalletSign(ctx, allowed, sb, api.MsgMeta{Type: api.MTUnknown})
+	require.Error(t, err)
+	require.Error(t, signMessage(ctx, aw, testMessage(allowed, builtin.MethodSend)))
+}
+
+func TestAllowlistWalletTypeConfusion(t *testing.T) {
+	ctx := context.Background()
+	aw, allowed, _ := setupAllowlist(t, KeyPolicy{
+		MsgTypes: []api.MsgType{api.MTBlock, api.MTDealProposal, api.MTUnknown},
+	})
+
+	// the CID of a chain message the key isn't allowed to sign, labelled as
+	// another type of data the key may sign
+	mb, err := testMessage(allowed, builtin.MethodSend).ToStorageBlock()
+	require.NoError(t, err)
+	for _, typ := range []api.MsgType{api.MTBlock, api.MTDealProposal, api.MTUnknown, "other"} {
+		_, err := aw.WalletSign(ctx, allowed, mb.Cid().Bytes(), api.MsgMeta{Type: typ})
+		require.Error(t, err, typ)
+	}
+
+	// arbitrary bytes are allowed with all methods
+	aw, allowed, _ = setupAllowlist(t, KeyPolicy{AllMethods: true, MsgTypes: []api.MsgType{api.MTUnknown}})
+	_, err = aw.WalletSign(ctx, allowed, mb.Cid().Bytes(), api.MsgMeta{Type: api.MTUnknown})
+	require.NoError(t, err)
+}
+
+func TestLoadAllowlist(t *testing.T) {
+	allowed, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	p := filepath.Join(t.TempDir(), "allowlist.json")
+	require.NoError(t, os.WriteFile(p, []byte(`{"`+allowed.String()+`": {"Methods": [0, 2], "MsgTypes": ["block"]}}`), 0600))
+
+	policies, err := loadAllowlist(p)
+	require.NoError(t, err)
+	require.Equal(t, map[address.Address]KeyPolicy{
+		allowed: {Methods: []abi.MethodNum{0, 2}, MsgTypes: []api.MsgType{api.MTBlock}},
+	}, policies)
+
+	require.NoError(t, os.WriteFile(p, []byte(`{"notanaddress": {}}`), 0600))
+	_, err = loadAllowlist(p)
+	require.Error(t, err)
+}
+
+func TestAuditedWallet(t *testing.T) {
+	ctx := context.Background()
+	aw, allowed, _ := setupAllowlist(t, KeyPolicy{Methods: []abi.MethodNum{builtin.MethodSend}})
+
+	p := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := openAuditLog(p)
+	require.NoError(t, err)
+	audit.Wallet = aw
+
+	cctx := withClient(ctx, "miner")
+	require.NoError(t, signMessage(cctx, audit, testMessage(allowed, builtin.MethodSend)))
+	require.Error(t, signMessage(cctx, audit, testMessage(allowed, 5)))
+	require.NoError(t, audit.Close())
+
+	f, err := os.Open(p)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	var entries []auditEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e auditEntry
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	require.NoError(t, sc.Err())
+	require.Len(t, entries, 2)
+
+	for _, e := range entries {
+		require.Equal(t, "miner", e.Client)
+		require.Equal(t, allowed, e.Address)
+		require.Equal(t, api.MsgType(api.MTChainMsg), e.Type)
+		require.NotNil(t, e.Message)
+		require.NotNil(t, e.Method)
+	}
+	require.Empty(t, entries[0].Error)
+	require.Equal(t, abi.MethodNum(5), *entries[1].Method)
+	require.NotEmpty(t, entries[1].Error)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type clientKey struct{}
+
+// withClient records the name of the client making the request in the
+// context, from the subject of its TLS certificate.
+func withClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// auditEntry is a line of the signing audit log.
+type auditEntry struct {
+	Time    time.Time
+	Client  string `json:",omitempty"`
+	Address address.Address
+	Type    api.MsgType
+
+	// set when signing chain messages
+	Message *cid.Cid         `json:",omitempty"`
+	To      *address.Address `json:",omitempty"`
+	Method  *abi.MethodNum   `json:",omitempty"`
+	Value   *abi.TokenAmount `json:",omitempty"`
+
+	// Error is why signing failed, e.g. because the allowlist denied it
+	Error string `json:",omitempty"`
+}
+
+// AuditedWallet appends every signing request, with its outcome, to a JSON
+// lines file.
+type AuditedWallet struct {
+	api.Wallet
+
+	lk  sync.Mutex
+	out *os.File
+}
+
+func openAuditLog(path string) (*AuditedWallet, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, xerrors.Errorf("opening audit log: %w", err)
+	}
+
+	return &AuditedWallet{out: f}, nil
+}
+
+func (c *AuditedWallet) WalletSign(ctx context.Context, k address.Address, msg []byte, meta api.MsgMeta) (*crypto.Signature, error) {
+	e := auditEntry{
+		Time:    time.Now(),
+		Address: k,
+		Type:    meta.Type,
+	}
+	if client, ok := ctx.Value(clientKey{}).(string); ok {
+		e.Client = client
+	}
+
+	if meta.Type == api.MTChainMsg {
+		var cmsg types.Message
+		if err := cmsg.UnmarshalCBOR(bytes.NewReader(meta.Extra)); err == nil {
+			mcid := cmsg.Cid()
+			e.Message = &mcid
+			e.To = &cmsg.To
+			e.Method = &cmsg.Method
+			e.Value = &cmsg.Value
+		}
+	}
+
+	sig, err := c.Wallet.WalletSign(ctx, k, msg, meta)
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	if lerr := c.write(&e); lerr != nil {
+		// don't hand out signatures which aren't audited
+		return nil, xerrors.Errorf("writing audit log: %w", lerr)
+	}
+
+	return sig, err
+}
+
+func (c *AuditedWallet) write(e *auditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if _, err := c.out.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return c.out.Sync()
+}
+
+func (c *AuditedWallet) Close() error {
+	return c.out.Close()
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
@@ -63,7 +65,14 @@ To configure your lotus node to use a remote wallet:
   * Find the '[Wallet]' section
   * Set 'RemoteBackend' to '[api key]:http://[wallet ip]:[wallet port]'
     (the default port is 1777)
-* Start (or restart) the lotus daemon`,
+* Start (or restart) the lotus daemon
+
+To keep keys on a dedicated signer host:
+* Serve the API over mutual TLS with 'lotus-wallet run --tls-cert --tls-key --tls-client-ca',
+  and set 'RemoteBackendTLSCert', 'RemoteBackendTLSKey' and 'RemoteBackendTLSCA' in the
+  '[Wallet]' section of the lotus config
+* Restrict what each key may sign with '--allowlist'
+* Record every signing request with '--audit-log'`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    FlagWalletRepo,
@@ -147,6 +156,26 @@ var runCmd = &cli.Command{
 			Name:  "http-server-timeout",
 			Value: "30s",
 		},
+		&cli.StringFlag{
+			Name:  "tls-cert",
+			Usage: "path to the PEM encoded certificate to serve the api over https with",
+		},
+		&cli.StringFlag{
+			Name:  "tls-key",
+			Usage: "path to the PEM encoded key of the tls certificate",
+		},
+		&cli.StringFlag{
+			Name:  "tls-client-ca",
+			Usage: "path to the PEM encoded CA certificate clients must present a certificate signed by (mutual TLS)",
+		},
+		&cli.StringFlag{
+			Name:  "allowlist",
+			Usage: "path to a JSON file mapping key addresses to what they may sign, e.g. {\"f1...\": {\"Methods\": [5, 6, 7], \"MsgTypes\": [\"block\"]}}; keys not listed can't sign",
+		},
+		&cli.StringFlag{
+			Name:  "audit-log",
+			Usage: "path to a file to append a JSON line to for every signing request",
+		},
 	},
 	Description: "Needs FULLNODE_API_INFO env-var to be set before running (see lotus-wallet --help for setup instructions)",
 	Action: func(cctx *cli.Context) error {
@@ -209,6 +238,27 @@ var runCmd = &cli.Command{
 			w = &LoggedWallet{under: w}
 		}
 
+		if cctx.IsSet("allowlist") {
+			policies, err := loadAllowlist(cctx.String("allowlist"))
+			if err != nil {
+				return xerrors.Errorf("loading allowlist: %w", err)
+			}
+
+			log.Infof("Signing restricted to %d allowlisted keys", len(policies))
+			w = &AllowlistWallet{Wallet: w, policies: policies}
+		}
+
+		if cctx.IsSet("audit-log") {
+			aw, err := openAuditLog(cctx.String("audit-log"))
+			if err != nil {
+				return err
+			}
+			defer aw.Close() // nolint
+
+			aw.Wallet = w
+			w = aw
+		}
+
 		rpcApi := proxy.MetricedWalletAPI(w)
 		if !cctx.Bool("disable-auth") {
 			rpcApi = api.PermissionedWalletAPI(rpcApi)
@@ -272,6 +322,39 @@ var runCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.IsSet("tls-cert") || cctx.IsSet("tls-key") {
+			tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+			if cctx.IsSet("tls-client-ca") {
+				pem, err := os.ReadFile(cctx.String("tls-client-ca"))
+				if err != nil {
+					return xerrors.Errorf("reading client CA certificate: %w", err)
+				}
+
+				tlsCfg.ClientCAs = x509.NewCertPool()
+				if !tlsCfg.ClientCAs.AppendCertsFromPEM(pem) {
+					return xerrors.Errorf("no certificates found in %s", cctx.String("tls-client-ca"))
+				}
+				tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+
+				// make the client certificate subject available to the audit log
+				next := srv.Handler
+				srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+						r = r.WithContext(withClient(r.Context(), r.TLS.PeerCertificates[0].Subject.String()))
+					}
+					next.ServeHTTP(w, r)
+				})
+
+				log.Info("Mutual TLS enabled, clients must present a certificate signed by the client CA")
+			}
+
+			srv.TLSConfig = tlsCfg
+			return srv.ServeTLS(nl, cctx.String("tls-cert"), cctx.String("tls-key"))
+		} else if cctx.IsSet("tls-client-ca") {
+			return xerrors.Errorf("--tls-client-ca requires --tls-cert and --tls-key")
+		}
+
 		return srv.Serve(nl)
 	},
 }
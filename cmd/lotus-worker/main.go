@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -248,6 +249,16 @@ var runCmd = &cli.Command{
 			Value:   false,
 			EnvVars: []string{"LOTUS_WORKER_NO_DEFAULT"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "deny-tasks",
+			Usage:   "task types the worker never takes, by short name, e.g. UNS,RU",
+			EnvVars: []string{"LOTUS_WORKER_DENY_TASKS"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "task-priority",
+			Usage:   "priority of the worker for a task type, e.g. PC2=10; tasks are only scheduled on the workers with the highest priority which can take them",
+			EnvVars: []string{"LOTUS_WORKER_TASK_PRIORITY"},
+		},
 		&cli.IntFlag{
 			Name:    "parallel-fetch-limit",
 			Usage:   "maximum fetch operations to run in parallel",
@@ -414,6 +425,41 @@ var runCmd = &cli.Command{
 			workerType = sealtasks.WorkerSealing
 		}
 
+		if cctx.IsSet("deny-tasks") {
+			denied := map[sealtasks.TaskType]struct{}{}
+			for _, short := range cctx.StringSlice("deny-tasks") {
+				tt, err := sealtasks.TaskTypeFromShort(short)
+				if err != nil {
+					return xerrors.Errorf("parsing denied tasks: %w", err)
+				}
+				denied[tt] = struct{}{}
+			}
+
+			allowed := taskTypes[:0]
+			for _, tt := range taskTypes {
+				if _, ok := denied[tt]; !ok {
+					allowed = append(allowed, tt)
+				}
+			}
+			taskTypes = allowed
+		}
+
+		taskPriorities := map[sealtasks.TaskType]int{}
+		for _, tp := range cctx.StringSlice("task-priority") {
+			short, prio, ok := strings.Cut(tp, "=")
+			if !ok {
+				return xerrors.Errorf("expected task priority as TASK=PRIORITY, got %s", tp)
+			}
+			tt, err := sealtasks.TaskTypeFromShort(short)
+			if err != nil {
+				return xerrors.Errorf("parsing task priority: %w", err)
+			}
+			taskPriorities[tt], err = strconv.Atoi(prio)
+			if err != nil {
+				return xerrors.Errorf("parsing %s task priority: %w", short, err)
+			}
+		}
+
 		if len(taskTypes) == 0 {
 			return xerrors.Errorf("no task types specified")
 		}
@@ -559,6 +605,7 @@ var runCmd = &cli.Command{
 				MaxParallelChallengeReads: cctx.Int("post-parallel-reads"),
 				ChallengeReadTimeout:      cctx.Duration("post-read-timeout"),
 				Name:                      cctx.String("name"),
+				TaskPriorities:            taskPriorities,
 			}, remote, localStore, nodeApi, nodeApi, wsts),
 			LocalStore: localStore,
 			Storage:    lr,
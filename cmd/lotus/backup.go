@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	dstore "github.com/ipfs/go-datastore"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/filecoin-project/go-jsonrpc"
 
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/chain/store"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/backupds"
@@ -117,5 +120,83 @@ func restore(cctx *cli.Context, r repo.Repo) error {
 		return xerrors.Errorf("clearing chain validation cache: %w", err)
 	}
 
+	if err := validateSplitstoreRestore(cctx.Context, lr, mds); err != nil {
+		return xerrors.Errorf("validating splitstore metadata: %w", err)
+	}
+
+	return nil
+}
+
+// validateSplitstoreRestore checks the restored splitstore metadata against the
+// hot and cold stores of the repo. The metadata only describes the stores it was
+// backed up with, so when they are missing it is cleared, and the splitstore
+// warms up again when the node starts.
+func validateSplitstoreRestore(ctx context.Context, lr repo.LockedRepo, mds dstore.Datastore) error {
+	md, err := splitstore.LoadMetadata(ctx, mds)
+	if err != nil {
+		return err
+	}
+	if md == nil {
+		return nil
+	}
+
+	c, err := lr.Config()
+	if err != nil {
+		return xerrors.Errorf("loading config: %w", err)
+	}
+	cfg, ok := c.(*config.FullNode)
+	if !ok {
+		return xerrors.Errorf("invalid config for repo, got: %T", c)
+	}
+
+	if !cfg.Chainstore.EnableSplitstore {
+		log.Warn("Backup holds splitstore metadata, but the splitstore is disabled; clearing it")
+		return splitstore.ClearMetadata(ctx, mds)
+	}
+
+	log.Infow("Restored splitstore metadata", "base epoch", md.BaseEpoch, "warmup epoch", md.WarmupEpoch,
+		"compaction index", md.CompactionIndex, "prune index", md.PruneIndex)
+
+	hotPath, coldPath, err := repo.ChainstorePaths(lr.Path(), &cfg.Chainstore)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	if md.WarmedUp && md.BaseEpoch < md.WarmupEpoch {
+		problems = append(problems, fmt.Sprintf("base epoch %d is before the warmup epoch %d", md.BaseEpoch, md.WarmupEpoch))
+	}
+
+	stores := map[string]string{"hotstore": hotPath}
+	if t := cfg.Chainstore.Splitstore.ColdStoreType; t != "discard" && t != "ipfs" {
+		stores["coldstore"] = coldPath
+	}
+	for name, path := range stores {
+		empty, err := isEmptyDir(path)
+		if err != nil {
+			return xerrors.Errorf("checking %s: %w", name, err)
+		}
+		if empty {
+			problems = append(problems, fmt.Sprintf("%s at %s is empty", name, path))
+		}
+	}
+
+	if len(problems) > 0 {
+		log.Warnw("Restored splitstore metadata doesn't match the hot and cold stores; clearing it, the splitstore will warm up again when the node starts",
+			"problems", problems)
+		return splitstore.ClearMetadata(ctx, mds)
+	}
+
 	return nil
 }
+
+func isEmptyDir(path string) (bool, error) {
+	ents, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(ents) == 0, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
 )
@@ -16,6 +17,7 @@ var configCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		configDefaultCmd,
 		configUpdateCmd,
+		lcli.ConfigReloadCmd,
 	},
 }
 
@@ -27,9 +29,16 @@ var configDefaultCmd = &cli.Command{
 			Name:  "no-comment",
 			Usage: "don't comment default values",
 		},
+		&cli.StringFlag{
+			Name:  "role",
+			Usage: "print the defaults of a node role (archival, pruned, lite)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
-		c := config.DefaultFullNode()
+		c, err := config.DefaultFullNodeForRole(cctx.String("role"))
+		if err != nil {
+			return err
+		}
 
 		cb, err := config.ConfigUpdate(c, nil, config.Commented(!cctx.Bool("no-comment")), config.DefaultKeepUncommented())
 		if err != nil {
@@ -81,7 +90,15 @@ var configUpdateCmd = &cli.Command{
 			return err
 		}
 
-		cfgDef := config.DefaultFullNode()
+		fullNode, ok := cfgNode.(*config.FullNode)
+		if !ok {
+			return xerrors.Errorf("invalid config type: %T", cfgNode)
+		}
+
+		cfgDef, err := config.DefaultFullNodeForRole(fullNode.Role)
+		if err != nil {
+			return err
+		}
 
 		updated, err := config.ConfigUpdate(cfgNode, cfgDef, config.Commented(!cctx.Bool("no-comment")), config.DefaultKeepUncommented())
 		if err != nil {
@@ -47,6 +47,7 @@ import (
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/testing"
@@ -126,6 +127,14 @@ var DaemonCmd = &cli.Command{
 			Name:  "lite",
 			Usage: "start lotus in lite mode",
 		},
+		&cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "serve the chain of the repo without syncing or writing to its chainstore and metadata",
+		},
+		&cli.StringFlag{
+			Name:  "read-only-car",
+			Usage: "serve the chain from the given CAR file, such as a chain snapshot, without syncing; implies --read-only",
+		},
 		&cli.StringFlag{
 			Name:  "pprof",
 			Usage: "specify name of file for writing cpu profile to",
@@ -237,6 +246,24 @@ var DaemonCmd = &cli.Command{
 		}
 		freshRepo := err != repo.ErrRepoExists
 
+		role, err := nodeRole(r)
+		if err != nil {
+			return err
+		}
+		if role == config.RoleLite {
+			isLite = true
+		}
+
+		readOnly := cctx.Bool("read-only") || cctx.IsSet("read-only-car")
+		if readOnly {
+			if isLite {
+				return xerrors.Errorf("read-only mode can't be used with lite mode")
+			}
+			if cctx.IsSet("import-chain") || cctx.IsSet("import-snapshot") {
+				return xerrors.Errorf("can't import a chain in read-only mode")
+			}
+		}
+
 		if !isLite {
 			if err := paramfetch.GetParams(lcli.ReqContext(cctx), build.ParametersJSON(), build.SrsJSON(), 0); err != nil {
 				return xerrors.Errorf("fetching proof parameters: %w", err)
@@ -309,6 +336,22 @@ var DaemonCmd = &cli.Command{
 			liteModeDeps = node.Override(new(lapi.Gateway), gapi)
 		}
 
+		// In read-only mode, serve the chain from the repo or a CAR file without
+		// syncing
+		readOnlyDeps := node.Options()
+		if readOnly {
+			carPath := cctx.String("read-only-car")
+			if carPath != "" {
+				carPath, err = homedir.Expand(carPath)
+				if err != nil {
+					return xerrors.Errorf("expanding car path: %w", err)
+				}
+			}
+
+			log.Warn("starting in read-only mode; the chain won't be synced")
+			readOnlyDeps = node.ReadOnly(carPath)
+		}
+
 		// some libraries like ipfs/go-ds-measure and ipfs/go-ipfs-blockstore
 		// use ipfs/go-metrics-interface. This injects a Prometheus exporter
 		// for those. Metrics are exported to the default registry.
@@ -328,6 +371,7 @@ var DaemonCmd = &cli.Command{
 
 			genesis,
 			liteModeDeps,
+			readOnlyDeps,
 
 			node.ApplyIf(func(s *node.Settings) bool { return cctx.IsSet("api") },
 				node.Override(node.SetApiEndpointKey, func(lr repo.LockedRepo) error {
@@ -395,6 +439,25 @@ var DaemonCmd = &cli.Command{
 	},
 }
 
+// nodeRole returns the Role set in the config of the repo.
+func nodeRole(r *repo.FsRepo) (string, error) {
+	lr, err := r.LockRO(repo.FullNode)
+	if err != nil {
+		return "", xerrors.Errorf("locking repo: %w", err)
+	}
+	defer lr.Close() //nolint:errcheck
+
+	c, err := lr.Config()
+	if err != nil {
+		return "", xerrors.Errorf("loading config: %w", err)
+	}
+	cfg, ok := c.(*config.FullNode)
+	if !ok {
+		return "", xerrors.Errorf("invalid config type: %T", c)
+	}
+	return cfg.Role, nil
+}
+
 func importKey(ctx context.Context, api lapi.FullNode, f string) error {
 	f, err := homedir.Expand(f)
 	if err != nil {
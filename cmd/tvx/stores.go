@@ -111,6 +111,10 @@ func (pb *proxyingBlockstore) FinishTracing() map[cid.Cid]struct{} {
 	return ret
 }
 
+func (pb *proxyingBlockstore) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, error) {
+	return blockstore.GetEach(ctx, pb, cids)
+}
+
 func (pb *proxyingBlockstore) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 	pb.lk.Lock()
 	if pb.tracing {
@@ -427,6 +427,9 @@ func (n *Ensemble) Start() *Ensemble {
 
 			// upgrades
 			node.Override(new(stmgr.UpgradeSchedule), n.options.upgradeSchedule),
+
+			// for driving the head through a HeadChanger
+			captureChainStore(full),
 		}
 
 		// append any node builder options.
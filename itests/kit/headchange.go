@@ -0,0 +1,219 @@
+package kit
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	adt0 "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// HeadChanger drives the head of a full node directly through its chainstore,
+// bypassing the syncer, so that tests can inject head changes, reverts and sync
+// gaps into chain consumers such as the splitstore in a deterministic order.
+//
+// Every head change returns only once it has been delivered to the head change
+// subscribers of the chainstore, including the splitstore.
+//
+// The tipsets it creates are synthetic: they consist of a single block with no
+// messages, which reuses the parent state of the tipset it extends, so they are
+// not valid for state computation. Block mining should be paused while driving
+// the head, as the syncer will take over any heavier chain it receives.
+type HeadChanger struct {
+	t   *testing.T
+	ctx context.Context
+	cs  *store.ChainStore
+
+	// emptyRoot is the root of an empty AMT, used for the messages and receipts of
+	// synthetic blocks
+	emptyRoot cid.Cid
+	msgs      cid.Cid
+
+	mx       sync.Mutex
+	cond     sync.Cond
+	notified types.TipSetKey
+
+	rng *rand.Rand
+}
+
+// captureChainStore constructs the chainstore of a full node as usual, keeping a
+// reference to it in the node for NewHeadChanger.
+func captureChainStore(full *TestFullNode) node.Option {
+	return node.Override(new(*store.ChainStore), func(lc fx.Lifecycle,
+		mctx helpers.MetricsCtx,
+		cbs dtypes.ChainBlockstore,
+		sbs dtypes.StateBlockstore,
+		ds dtypes.MetadataDS,
+		basebs dtypes.BaseBlockstore,
+		weight store.WeightFunc,
+		us stmgr.UpgradeSchedule,
+		j journal.Journal) *store.ChainStore {
+		full.chainStore = modules.ChainStore(lc, mctx, cbs, sbs, ds, basebs, weight, us, j)
+		return full.chainStore
+	})
+}
+
+// NewHeadChanger returns a HeadChanger for the given full node; the node must be
+// a full (non-lite) node.
+func NewHeadChanger(ctx context.Context, t *testing.T, full *TestFullNode) *HeadChanger {
+	require.NotNil(t, full.chainStore, "node has no chainstore")
+
+	h := &HeadChanger{
+		t:   t,
+		ctx: ctx,
+		cs:  full.chainStore,
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	h.cond.L = &h.mx
+
+	bs := full.chainStore.ChainBlockstore()
+	emptyRoot, err := adt0.MakeEmptyArray(adt.WrapStore(ctx, cbor.NewCborStore(bs))).Root()
+	require.NoError(t, err)
+	h.emptyRoot = emptyRoot
+
+	mm := &types.MsgMeta{
+		BlsMessages:   emptyRoot,
+		SecpkMessages: emptyRoot,
+	}
+	mmb, err := mm.ToStorageBlock()
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, mmb))
+	h.msgs = mmb.Cid()
+
+	h.cs.SubscribeHeadChanges(h.headChange)
+
+	return h
+}
+
+// headChange is subscribed after the subscribers present when the HeadChanger is
+// created, so by the time it sees a head change they have all processed it.
+func (h *HeadChanger) headChange(revert, apply []*types.TipSet) error {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	switch {
+	case len(apply) > 0:
+		h.notified = apply[len(apply)-1].Key()
+	case len(revert) > 0:
+		h.notified = revert[len(revert)-1].Parents()
+	}
+	h.cond.Broadcast()
+
+	return nil
+}
+
+// Head returns the current head of the chainstore.
+func (h *HeadChanger) Head() *types.TipSet {
+	return h.cs.GetHeaviestTipSet()
+}
+
+// SetHead sets the head of the chainstore to ts and waits for the head change to
+// be delivered.
+func (h *HeadChanger) SetHead(ts *types.TipSet) {
+	if ts.Equals(h.Head()) {
+		return
+	}
+
+	require.NoError(h.t, h.cs.SetHead(h.ctx, ts))
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	for h.notified != ts.Key() {
+		h.cond.Wait()
+	}
+}
+
+// Extend applies n synthetic tipsets on top of the current head, one head change
+// each, and returns the new head.
+func (h *HeadChanger) Extend(n int) *types.TipSet {
+	ts := h.Head()
+	for i := 0; i < n; i++ {
+		ts = h.mkTipSet(ts, time.Now())
+		h.SetHead(ts)
+	}
+	return ts
+}
+
+// Revert reverts the current head by n epochs, in a single head change, and
+// returns the new head.
+func (h *HeadChanger) Revert(n int) *types.TipSet {
+	ts := h.Head()
+	for i := 0; i < n; i++ {
+		parent, err := h.cs.LoadTipSet(h.ctx, ts.Parents())
+		require.NoError(h.t, err)
+		ts = parent
+	}
+
+	h.SetHead(ts)
+	return ts
+}
+
+// Fork reorgs the chain onto a fork of n synthetic tipsets branching off depth
+// epochs below the current head, in a single head change, and returns the new
+// head.
+func (h *HeadChanger) Fork(depth, n int) *types.TipSet {
+	ts := h.Head()
+	for i := 0; i < depth; i++ {
+		parent, err := h.cs.LoadTipSet(h.ctx, ts.Parents())
+		require.NoError(h.t, err)
+		ts = parent
+	}
+
+	for i := 0; i < n; i++ {
+		ts = h.mkTipSet(ts, time.Now())
+	}
+
+	h.SetHead(ts)
+	return ts
+}
+
+// SyncGap applies n synthetic tipsets on top of the current head, one head change
+// each, whose timestamps lie age in the past, as seen by a node catching up with
+// the chain; it returns the new head.
+func (h *HeadChanger) SyncGap(n int, age time.Duration) *types.TipSet {
+	ts := h.Head()
+	for i := 0; i < n; i++ {
+		ts = h.mkTipSet(ts, time.Now().Add(-age))
+		h.SetHead(ts)
+	}
+	return ts
+}
+
+func (h *HeadChanger) mkTipSet(parent *types.TipSet, timestamp time.Time) *types.TipSet {
+	// the ticket makes blocks at the same height, such as those of a fork, distinct
+	ticket := make([]byte, 32)
+	_, _ = h.rng.Read(ticket)
+
+	blk := *parent.Blocks()[0]
+	blk.Ticket = &types.Ticket{VRFProof: ticket}
+	blk.Parents = parent.Cids()
+	blk.ParentWeight = types.BigAdd(parent.ParentWeight(), types.NewInt(1))
+	blk.Height = parent.Height() + 1
+	blk.Messages = h.msgs
+	blk.ParentMessageReceipts = h.emptyRoot
+	blk.Timestamp = uint64(timestamp.Unix())
+
+	ts, err := types.NewTipSet([]*types.BlockHeader{&blk})
+	require.NoError(h.t, err)
+	require.NoError(h.t, h.cs.PersistTipset(h.ctx, ts))
+
+	return ts
+}
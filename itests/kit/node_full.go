@@ -19,6 +19,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/wallet/key"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
@@ -51,6 +52,8 @@ type TestFullNode struct {
 	// also use it for tests
 	EthSubRouter *gateway.EthSubHandler
 
+	chainStore *store.ChainStore
+
 	options nodeOpts
 }
 
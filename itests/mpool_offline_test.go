@@ -0,0 +1,83 @@
+// stm: #integration
+package itests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/itests/kit"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+func TestMpoolOfflineRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kit.QuietMiningLogs()
+
+	client, _, ens := kit.EnsembleMinimal(t, kit.MockProofs())
+	ens.InterconnectAll().BeginMining(10 * time.Millisecond)
+
+	from := client.DefaultKey.Address
+	to, err := client.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	om, err := client.MpoolPrepareOffline(ctx, &types.Message{
+		From:  from,
+		To:    to,
+		Value: big.NewInt(1000),
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, om.Lease)
+	require.Equal(t, om.Lease.Nonce, om.Message.Nonce)
+	require.Equal(t, from, om.SigningKey)
+
+	// the prepared nonce is leased, so messages signed by the node meanwhile don't use it
+	sm, err := client.MpoolPushMessage(ctx, &types.Message{
+		From:  from,
+		To:    to,
+		Value: big.NewInt(1),
+	}, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, om.Message.Nonce, sm.Message.Nonce)
+
+	// sign with the exported key, as a machine without access to the node would
+	ki, err := client.WalletExport(ctx, from)
+	require.NoError(t, err)
+	k, err := key.NewKey(*ki)
+	require.NoError(t, err)
+
+	sb, err := messagesigner.SigningBytes(om.Message, om.SigningKey.Protocol())
+	require.NoError(t, err)
+	om.Signature, err = sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, sb)
+	require.NoError(t, err)
+
+	// messages prepared for another network or key are rejected
+	wrongNet := *om
+	wrongNet.NetworkName = "othernet"
+	_, err = client.MpoolPushOffline(ctx, &wrongNet)
+	require.ErrorContains(t, err, "was prepared for network")
+
+	wrongKey := *om
+	wrongKey.SigningKey = to
+	_, err = client.MpoolPushOffline(ctx, &wrongKey)
+	require.ErrorContains(t, err, "the key of the sender is")
+
+	mcid, err := client.MpoolPushOffline(ctx, om)
+	require.NoError(t, err)
+	require.Equal(t, om.Message.Cid(), mcid)
+
+	res, err := client.StateWaitMsg(ctx, mcid, 1, api.LookbackNoLimit, true)
+	require.NoError(t, err)
+	require.Equal(t, exitcode.Ok, res.Receipt.ExitCode)
+}
@@ -292,6 +292,52 @@ func TestCompactRetainsTipSetRef(t *testing.T) {
 	bm.Stop()
 }
 
+// Drive the head through reverts and reorgs while compactions run, and check that
+// the chain within the compaction boundary survives
+func TestHotstoreCompactsAcrossReorgs(t *testing.T) {
+	ctx := context.Background()
+	// disable sync checking because efficient itests require that the node is out of sync : /
+	splitstore.CheckSyncGap = false
+	opts := []interface{}{kit.MockProofs(), kit.SplitstoreDiscard()}
+	full, genesisMiner, ens := kit.EnsembleMinimal(t, opts...)
+	bm := ens.InterconnectAll().BeginMining(4 * time.Millisecond)[0]
+	_ = genesisMiner
+
+	waitForCompaction(ctx, t, 1, full)
+
+	// from here on the head is driven synthetically
+	bm.Pause()
+	hc := kit.NewHeadChanger(ctx, t, full)
+
+	cIdx := splitStoreCompactionIndex(ctx, t, full) + 2
+	for i := 0; splitStoreCompactionIndex(ctx, t, full) < cIdx; i++ {
+		require.Less(t, i, 10*int(splitstore.CompactionThreshold), "compactions didn't run")
+
+		hc.Extend(3)
+		hc.Fork(2, 3)
+		hc.Revert(1)
+	}
+
+	// wait for the last compaction to finish before checking
+	for splitStoreCompacting(ctx, t, full) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	ts := hc.Head()
+	boundary := ts.Height() - splitstore.CompactionBoundary
+	for ts.Height() > boundary {
+		for _, c := range ts.Cids() {
+			assert.True(t, ipldExists(ctx, t, c, full), "block header %s missing after compaction", c)
+		}
+
+		parent, err := full.ChainGetTipSet(ctx, ts.Parents())
+		require.NoError(t, err)
+		ts = parent
+	}
+
+	bm.Stop()
+}
+
 func waitForCompaction(ctx context.Context, t *testing.T, cIdx int64, n *kit.TestFullNode) {
 	for {
 		if splitStoreCompactionIndex(ctx, t, n) >= cIdx {
@@ -0,0 +1,81 @@
+// stm: #integration
+package itests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+func TestStateCallBundle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kit.QuietMiningLogs()
+
+	client, _, ens := kit.EnsembleMinimal(t, kit.MockProofs())
+	ens.InterconnectAll().BeginMining(10 * time.Millisecond)
+
+	from := client.DefaultKey.Address
+	to, err := client.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	head, err := client.ChainHead(ctx)
+	require.NoError(t, err)
+	fromAct, err := client.StateGetActor(ctx, from, head.Key())
+	require.NoError(t, err)
+
+	msgs := []*types.Message{
+		{From: from, To: to, Value: big.NewInt(100)},
+		// fails without stopping the bundle
+		{From: from, To: to, Value: big.Mul(fromAct.Balance, big.NewInt(2))},
+		{From: from, To: to, Value: big.NewInt(50)},
+		// sent by the actor created by the first message
+		{From: to, To: from, Value: big.NewInt(30)},
+	}
+
+	res, err := client.StateCallBundle(ctx, msgs, head.Key())
+	require.NoError(t, err)
+	require.Len(t, res.Results, len(msgs))
+
+	require.Equal(t, fromAct.Nonce, res.Results[0].Msg.Nonce)
+	require.Equal(t, exitcode.Ok, res.Results[0].MsgRct.ExitCode)
+	require.Equal(t, fromAct.Nonce+1, res.Results[1].Msg.Nonce)
+	require.Equal(t, exitcode.SysErrInsufficientFunds, res.Results[1].MsgRct.ExitCode)
+	require.Equal(t, fromAct.Nonce+2, res.Results[2].Msg.Nonce)
+	require.Equal(t, exitcode.Ok, res.Results[2].MsgRct.ExitCode)
+	require.Equal(t, uint64(0), res.Results[3].Msg.Nonce)
+	require.Equal(t, exitcode.Ok, res.Results[3].MsgRct.ExitCode)
+
+	// the changes are those of the whole bundle
+	fromID, err := client.StateLookupID(ctx, from, head.Key())
+	require.NoError(t, err)
+
+	var fromDiff, created *api.ActorDiff
+	for i, c := range res.Changes {
+		switch {
+		case c.Address == fromID:
+			fromDiff = &res.Changes[i]
+		case c.Before == nil:
+			created = &res.Changes[i]
+		}
+	}
+	require.NotNil(t, fromDiff)
+	require.Equal(t, fromAct.Nonce+3, fromDiff.After.Nonce)
+	require.NotNil(t, created)
+	require.Equal(t, big.NewInt(120), created.After.Balance)
+	require.Equal(t, uint64(1), created.After.Nonce)
+
+	// nothing is persisted
+	_, err = client.StateGetActor(ctx, to, types.EmptyTSK)
+	require.Error(t, err)
+}
@@ -22,8 +22,14 @@ type Alerting struct {
 
 	lk     sync.Mutex
 	alerts map[AlertType]Alert
+	hooks  []Hook
 }
 
+// Hook is called after an alert has been raised or resolved, with the event
+// describing the state transition. Hooks are called synchronously, and must not
+// block.
+type Hook func(at AlertType, evt *AlertEvent)
+
 // AlertType is a unique alert identifier
 type AlertType struct {
 	System, Subsystem string
@@ -78,7 +84,15 @@ func (a *Alerting) AddAlertType(system, subsystem string) AlertType {
 	return at
 }
 
-func (a *Alerting) update(at AlertType, message interface{}, upd func(Alert, json.RawMessage) Alert) {
+// AddHook registers a hook which will be notified of all alert state transitions
+func (a *Alerting) AddHook(h Hook) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	a.hooks = append(a.hooks, h)
+}
+
+func (a *Alerting) update(at AlertType, message interface{}, upd func(Alert, json.RawMessage) Alert) (Alert, []Hook) {
 	a.lk.Lock()
 	defer a.lk.Unlock()
 
@@ -98,14 +112,17 @@ func (a *Alerting) update(at AlertType, message interface{}, upd func(Alert, jso
 		log.Errorw("marshaling marshaling error failed", "type", at, "error", err)
 	}
 
-	a.alerts[at] = upd(alert, rawMsg)
+	alert = upd(alert, rawMsg)
+	a.alerts[at] = alert
+
+	return alert, a.hooks
 }
 
 // Raise marks the alert condition as active and records related event in the journal
 func (a *Alerting) Raise(at AlertType, message interface{}) {
 	log.Errorw("alert raised", "type", at, "message", message)
 
-	a.update(at, message, func(alert Alert, rawMsg json.RawMessage) Alert {
+	alert, hooks := a.update(at, message, func(alert Alert, rawMsg json.RawMessage) Alert {
 		alert.Active = true
 		alert.LastActive = &AlertEvent{
 			Type:    "raised",
@@ -119,13 +136,17 @@ func (a *Alerting) Raise(at AlertType, message interface{}) {
 
 		return alert
 	})
+
+	for _, h := range hooks {
+		h(at, alert.LastActive)
+	}
 }
 
 // Resolve marks the alert condition as resolved and records related event in the journal
 func (a *Alerting) Resolve(at AlertType, message interface{}) {
 	log.Errorw("alert resolved", "type", at, "message", message)
 
-	a.update(at, message, func(alert Alert, rawMsg json.RawMessage) Alert {
+	alert, hooks := a.update(at, message, func(alert Alert, rawMsg json.RawMessage) Alert {
 		alert.Active = false
 		alert.LastResolved = &AlertEvent{
 			Type:    "resolved",
@@ -139,6 +160,10 @@ func (a *Alerting) Resolve(at AlertType, message interface{}) {
 
 		return alert
 	})
+
+	for _, h := range hooks {
+		h(at, alert.LastResolved)
+	}
 }
 
 // GetAlerts returns all registered (active and inactive) alerts
@@ -3,7 +3,10 @@ package alerting
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -61,3 +64,53 @@ func TestAlerting(t *testing.T) {
 	require.Nil(t, l[1].LastActive)
 	require.Nil(t, l[1].LastResolved)
 }
+
+func TestAlertingHooks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	j := mockjournal.NewMockJournal(mockCtrl)
+
+	a := NewAlertingSystem(j)
+
+	j.EXPECT().RegisterEventType("s1", "b1").Return(journal.EventType{System: "s1", Event: "b1"})
+	al1 := a.AddAlertType("s1", "b1")
+
+	var events []string
+	a.AddHook(func(at AlertType, evt *AlertEvent) {
+		require.Equal(t, al1, at)
+		events = append(events, evt.Type+" "+string(evt.Message))
+	})
+
+	j.EXPECT().RecordEvent(a.alerts[al1].journalType, gomock.Any()).Times(2)
+	a.Raise(al1, "down")
+	a.Resolve(al1, "up")
+
+	require.Equal(t, []string{`raised "down"`, `resolved "up"`}, events)
+}
+
+func TestWebhookHook(t *testing.T) {
+	received := make(chan WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		received <- p
+	}))
+	defer srv.Close()
+
+	hook := WebhookHook([]string{srv.URL}, time.Second)
+	hook(AlertType{System: "s1", Subsystem: "b1"}, &AlertEvent{
+		Type:    "raised",
+		Message: json.RawMessage(`"test"`),
+		Time:    time.Now(),
+	})
+
+	select {
+	case p := <-received:
+		require.Equal(t, "s1", p.System)
+		require.Equal(t, "b1", p.Subsystem)
+		require.Equal(t, "raised", p.Type)
+		require.Equal(t, json.RawMessage(`"test"`), p.Message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
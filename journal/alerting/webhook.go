@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// WebhookPayload is the JSON body posted to alert webhooks
+type WebhookPayload struct {
+	System    string
+	Subsystem string
+
+	Type    string // either 'raised' or 'resolved'
+	Message json.RawMessage
+	Time    time.Time
+}
+
+// WebhookHook returns a Hook which posts every alert state transition to the
+// given URLs. Requests are sent asynchronously; failures are only logged.
+func WebhookHook(urls []string, timeout time.Duration) Hook {
+	client := &http.Client{Timeout: timeout}
+
+	return func(at AlertType, evt *AlertEvent) {
+		body, err := json.Marshal(&WebhookPayload{
+			System:    at.System,
+			Subsystem: at.Subsystem,
+			Type:      evt.Type,
+			Message:   evt.Message,
+			Time:      evt.Time,
+		})
+		if err != nil {
+			log.Errorw("marshaling alert webhook payload failed", "type", at, "error", err)
+			return
+		}
+
+		for _, url := range urls {
+			go func(url string) {
+				if err := postWebhook(client, url, body); err != nil {
+					log.Errorw("sending alert webhook failed", "type", at, "url", url, "error", err)
+				}
+			}(url)
+		}
+	}
+}
+
+func postWebhook(client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return nil
+}
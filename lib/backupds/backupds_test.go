@@ -58,6 +58,25 @@ func TestNoLogRestore(t *testing.T) {
 	checkVals(t, ds2, 10, 20, false)
 }
 
+func TestExtraRestore(t *testing.T) {
+	ds1 := datastore.NewMapDatastore()
+	extra := datastore.NewMapDatastore()
+
+	putVals(t, ds1, 0, 10)
+	putVals(t, extra, 10, 20)
+
+	bds, err := Wrap(ds1, NoLogdir)
+	require.NoError(t, err)
+
+	var bup bytes.Buffer
+	require.NoError(t, bds.Backup(context.TODO(), &bup, extra))
+
+	ds2 := datastore.NewMapDatastore()
+	require.NoError(t, RestoreInto(&bup, ds2))
+
+	checkVals(t, ds2, 0, 20, true)
+}
+
 func TestLogRestore(t *testing.T) {
 	//stm: @OTHER_DATASTORE_RESTORE_001
 	logdir := t.TempDir()
@@ -52,7 +52,10 @@ func Wrap(child datastore.Batching, logdir string) (*Datastore, error) {
 
 // Writes a datastore dump into the provided writer as
 // [array(*) of [key, value] tuples, checksum]
-func (d *Datastore) Backup(ctx context.Context, out io.Writer) error {
+//
+// The entries of the extra datastores are written after the entries of the
+// wrapped datastore, so that they are restored into it.
+func (d *Datastore) Backup(ctx context.Context, out io.Writer, extra ...datastore.Read) error {
 	scratch := make([]byte, 9)
 
 	if err := cbg.WriteMajorTypeHeaderBuf(scratch, out, cbg.MajArray, 2); err != nil {
@@ -75,36 +78,9 @@ func (d *Datastore) Backup(ctx context.Context, out io.Writer) error {
 		log.Info("Starting datastore backup")
 		defer log.Info("Datastore backup done")
 
-		qr, err := d.child.Query(ctx, query.Query{})
-		if err != nil {
-			return xerrors.Errorf("query: %w", err)
-		}
-		defer func() {
-			if err := qr.Close(); err != nil {
-				log.Errorf("query close error: %+v", err)
-				return
-			}
-		}()
-
-		for result := range qr.Next() {
-			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajArray, 2); err != nil {
-				return xerrors.Errorf("writing tuple header: %w", err)
-			}
-
-			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len([]byte(result.Key)))); err != nil {
-				return xerrors.Errorf("writing key header: %w", err)
-			}
-
-			if _, err := hout.Write([]byte(result.Key)[:]); err != nil {
-				return xerrors.Errorf("writing key: %w", err)
-			}
-
-			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len(result.Value))); err != nil {
-				return xerrors.Errorf("writing value header: %w", err)
-			}
-
-			if _, err := hout.Write(result.Value[:]); err != nil {
-				return xerrors.Errorf("writing value: %w", err)
+		for _, ds := range append([]datastore.Read{d.child}, extra...) {
+			if err := writeEntries(ctx, ds, hout, scratch); err != nil {
+				return err
 			}
 		}
 
@@ -130,6 +106,43 @@ func (d *Datastore) Backup(ctx context.Context, out io.Writer) error {
 	return nil
 }
 
+func writeEntries(ctx context.Context, ds datastore.Read, hout io.Writer, scratch []byte) error {
+	qr, err := ds.Query(ctx, query.Query{})
+	if err != nil {
+		return xerrors.Errorf("query: %w", err)
+	}
+	defer func() {
+		if err := qr.Close(); err != nil {
+			log.Errorf("query close error: %+v", err)
+			return
+		}
+	}()
+
+	for result := range qr.Next() {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajArray, 2); err != nil {
+			return xerrors.Errorf("writing tuple header: %w", err)
+		}
+
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len([]byte(result.Key)))); err != nil {
+			return xerrors.Errorf("writing key header: %w", err)
+		}
+
+		if _, err := hout.Write([]byte(result.Key)[:]); err != nil {
+			return xerrors.Errorf("writing key: %w", err)
+		}
+
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len(result.Value))); err != nil {
+			return xerrors.Errorf("writing value header: %w", err)
+		}
+
+		if _, err := hout.Write(result.Value[:]); err != nil {
+			return xerrors.Errorf("writing value: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // proxy
 
 func (d *Datastore) Get(ctx context.Context, key datastore.Key) (value []byte, err error) {
@@ -0,0 +1,50 @@
+// Package ratelimit rate limits the requests of individual peers.
+package ratelimit
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/lotus/lib/must"
+)
+
+// maxTrackedPeers bounds the number of peers rate limiters are kept for; the
+// least recently seen peers are forgotten first.
+const maxTrackedPeers = 4096
+
+// PerPeer limits the rate of the requests of each peer separately. It is safe
+// for concurrent use.
+type PerPeer struct {
+	limit rate.Limit
+	burst int
+
+	limiters *lru.Cache[peer.ID, *rate.Limiter]
+}
+
+// NewPerPeer creates a limiter allowing limit requests per second to each
+// peer, in bursts of up to burst requests.
+func NewPerPeer(limit rate.Limit, burst int) *PerPeer {
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &PerPeer{
+		limit:    limit,
+		burst:    burst,
+		limiters: must.One(lru.New[peer.ID, *rate.Limiter](maxTrackedPeers)),
+	}
+}
+
+// Allow reports whether a request of p is allowed now.
+func (l *PerPeer) Allow(p peer.ID) bool {
+	lim, ok := l.limiters.Get(p)
+	if !ok {
+		// a concurrent request of the same peer may have added a limiter since
+		lim = rate.NewLimiter(l.limit, l.burst)
+		if prev, found, _ := l.limiters.PeekOrAdd(p, lim); found {
+			lim = prev
+		}
+	}
+	return lim.Allow()
+}
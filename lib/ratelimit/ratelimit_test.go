@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerPeer(t *testing.T) {
+	l := NewPerPeer(0.001, 2)
+
+	require.True(t, l.Allow(peer.ID("alice")))
+	require.True(t, l.Allow(peer.ID("alice")))
+	require.False(t, l.Allow(peer.ID("alice")))
+
+	// peers are limited separately
+	require.True(t, l.Allow(peer.ID("bob")))
+}
+
+func TestPerPeerConcurrent(t *testing.T) {
+	l := NewPerPeer(0.001, 10)
+
+	// the first requests of a peer share a single limiter
+	var allowed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Allow(peer.ID("alice")) {
+				atomic.AddInt64(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 10, allowed)
+}
@@ -0,0 +1,130 @@
+package bitswapserver
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/stores"
+)
+
+// ErrReadOnly is returned when writing to the Blockstore.
+var ErrReadOnly = xerrors.New("the unsealed deals blockstore is read-only")
+
+// PieceStore finds and loads the pieces containing a block.
+type PieceStore interface {
+	GetPiecesContainingBlock(blockCID cid.Cid) ([]cid.Cid, error)
+	LoadShard(ctx context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error)
+}
+
+// UnsealedChecker checks whether an unsealed copy of a piece exists.
+type UnsealedChecker interface {
+	IsUnsealed(ctx context.Context, pieceCid cid.Cid) (bool, error)
+}
+
+// Blockstore is a read-only blockstore of the payload blocks of the deals
+// with an unsealed copy. Pieces which are only sealed are skipped, so that
+// reading blocks never triggers unsealing.
+type Blockstore struct {
+	ps  PieceStore
+	uns UnsealedChecker
+}
+
+var _ bstore.Blockstore = (*Blockstore)(nil)
+
+func NewBlockstore(ps PieceStore, uns UnsealedChecker) *Blockstore {
+	return &Blockstore{ps: ps, uns: uns}
+}
+
+// withBlock calls cb with a blockstore of an unsealed piece containing the
+// block, or returns ipld.ErrNotFound if there is none.
+func (b *Blockstore) withBlock(ctx context.Context, c cid.Cid, cb func(bs bstore.Blockstore) error) error {
+	pieces, err := b.ps.GetPiecesContainingBlock(c)
+	if err != nil {
+		log.Debugw("finding pieces containing block", "cid", c, "error", err)
+		return ipld.ErrNotFound{Cid: c}
+	}
+
+	for _, piece := range pieces {
+		unsealed, err := b.uns.IsUnsealed(ctx, piece)
+		if err != nil {
+			log.Debugw("checking for unsealed piece", "piece", piece, "error", err)
+			continue
+		}
+		if !unsealed {
+			continue
+		}
+
+		bs, err := b.ps.LoadShard(ctx, piece)
+		if err != nil {
+			log.Warnw("loading piece", "piece", piece, "error", err)
+			continue
+		}
+		err = cb(bs)
+		if cerr := bs.Close(); cerr != nil {
+			log.Warnw("closing piece", "piece", piece, "error", cerr)
+		}
+		if ipld.IsNotFound(err) {
+			continue
+		}
+		return err
+	}
+
+	return ipld.ErrNotFound{Cid: c}
+}
+
+func (b *Blockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	err := b.withBlock(ctx, c, func(bs bstore.Blockstore) error {
+		has, err := bs.Has(ctx, c)
+		if err == nil && !has {
+			return ipld.ErrNotFound{Cid: c}
+		}
+		return err
+	})
+	if ipld.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *Blockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	var blk blocks.Block
+	err := b.withBlock(ctx, c, func(bs bstore.Blockstore) error {
+		var err error
+		blk, err = bs.Get(ctx, c)
+		return err
+	})
+	return blk, err
+}
+
+func (b *Blockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	var size int
+	err := b.withBlock(ctx, c, func(bs bstore.Blockstore) error {
+		var err error
+		size, err = bs.GetSize(ctx, c)
+		return err
+	})
+	return size, err
+}
+
+func (b *Blockstore) DeleteBlock(context.Context, cid.Cid) error {
+	return ErrReadOnly
+}
+
+func (b *Blockstore) Put(context.Context, blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (b *Blockstore) PutMany(context.Context, []blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (b *Blockstore) AllKeysChan(context.Context) (<-chan cid.Cid, error) {
+	return nil, xerrors.New("listing the blocks of the unsealed deals isn't supported")
+}
+
+func (b *Blockstore) HashOnRead(bool) {}
@@ -0,0 +1,90 @@
+package bitswapserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/stores"
+)
+
+type testShard struct {
+	bstore.Blockstore
+}
+
+func (testShard) Close() error {
+	return nil
+}
+
+type testPieces struct {
+	shards   map[cid.Cid]bstore.Blockstore
+	unsealed map[cid.Cid]bool
+}
+
+func (p *testPieces) GetPiecesContainingBlock(blockCID cid.Cid) ([]cid.Cid, error) {
+	var out []cid.Cid
+	for piece, bs := range p.shards {
+		if has, _ := bs.Has(context.Background(), blockCID); has {
+			out = append(out, piece)
+		}
+	}
+	return out, nil
+}
+
+func (p *testPieces) LoadShard(_ context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error) {
+	return testShard{p.shards[pieceCid]}, nil
+}
+
+func (p *testPieces) IsUnsealed(_ context.Context, pieceCid cid.Cid) (bool, error) {
+	return p.unsealed[pieceCid], nil
+}
+
+func TestBlockstore(t *testing.T) {
+	ctx := context.Background()
+
+	shard := func(blks ...blocks.Block) bstore.Blockstore {
+		bs := bstore.NewBlockstore(dssync.MutexWrap(datastore.NewMapDatastore()))
+		require.NoError(t, bs.PutMany(ctx, blks))
+		return bs
+	}
+
+	a := blocks.NewBlock([]byte("a"))
+	b := blocks.NewBlock([]byte("b"))
+	c := blocks.NewBlock([]byte("c"))
+
+	unsealedPiece := blocks.NewBlock([]byte("unsealed piece")).Cid()
+	sealedPiece := blocks.NewBlock([]byte("sealed piece")).Cid()
+
+	p := &testPieces{
+		shards: map[cid.Cid]bstore.Blockstore{
+			unsealedPiece: shard(a, b),
+			sealedPiece:   shard(b, c),
+		},
+		unsealed: map[cid.Cid]bool{unsealedPiece: true},
+	}
+	bs := NewBlockstore(p, p)
+
+	blk, err := bs.Get(ctx, a.Cid())
+	require.NoError(t, err)
+	require.Equal(t, a.RawData(), blk.RawData())
+
+	size, err := bs.GetSize(ctx, b.Cid())
+	require.NoError(t, err)
+	require.Equal(t, len(b.RawData()), size)
+
+	// blocks only in sealed pieces aren't served
+	has, err := bs.Has(ctx, c.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+	_, err = bs.Get(ctx, c.Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	require.ErrorIs(t, bs.Put(ctx, c), ErrReadOnly)
+}
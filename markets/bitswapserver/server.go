@@ -0,0 +1,128 @@
+// Package bitswapserver serves the payload blocks of the deals with an unsealed
+// copy over bitswap, as a free retrieval path alongside the retrieval market
+// protocol.
+package bitswapserver
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	bsnet "github.com/ipfs/go-libipfs/bitswap/network"
+	"github.com/ipfs/go-libipfs/bitswap/server"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/lotus/lib/ratelimit"
+)
+
+var log = logging.Logger("bitswapserver")
+
+type Config struct {
+	// AllowedPeers are the only peers served, all peers are served when empty.
+	AllowedPeers []peer.ID
+	// DeniedPeers are never served.
+	DeniedPeers []peer.ID
+
+	// Rate is the number of blocks per second served to a single peer, 0
+	// disables rate limiting.
+	Rate float64
+	// Burst is the number of blocks a peer can request at once.
+	Burst int
+
+	// MaxOutstandingBytesPerPeer bounds the size of the blocks queued for
+	// sending to a single peer, 0 uses the bitswap default.
+	MaxOutstandingBytesPerPeer int
+	// TaskWorkers is the number of workers sending blocks, 0 uses the
+	// bitswap default.
+	TaskWorkers int
+}
+
+// Server serves the blocks of a blockstore over bitswap, to the peers allowed
+// by its Config.
+type Server struct {
+	net bsnet.BitSwapNetwork
+	srv *server.Server
+}
+
+func NewServer(ctx context.Context, h host.Host, bs bstore.Blockstore, cfg Config) *Server {
+	net := bsnet.NewFromIpfsHost(h, nil)
+
+	opts := []server.Option{
+		server.ProvideEnabled(false),
+		server.WithPeerBlockRequestFilter(newRequestFilter(cfg).allow),
+	}
+	if cfg.TaskWorkers > 0 {
+		opts = append(opts, server.TaskWorkerCount(cfg.TaskWorkers))
+	}
+	if cfg.MaxOutstandingBytesPerPeer > 0 {
+		opts = append(opts, server.MaxOutstandingBytesPerPeer(cfg.MaxOutstandingBytesPerPeer))
+	}
+
+	srv := server.New(ctx, net, bs, opts...)
+	net.Start(receiver{srv})
+
+	return &Server{net: net, srv: srv}
+}
+
+func (s *Server) Close() error {
+	s.net.Stop()
+	return s.srv.Close()
+}
+
+// receiver passes the messages received by the network to the server.
+type receiver struct {
+	*server.Server
+}
+
+func (receiver) ReceiveError(err error) {
+	log.Debugw("bitswap network error", "error", err)
+}
+
+// requestFilter decides which block requests are served, based on the peer
+// lists and rate limits of the Config.
+type requestFilter struct {
+	allowed map[peer.ID]struct{}
+	denied  map[peer.ID]struct{}
+
+	// nil when rate limiting is disabled
+	limiter *ratelimit.PerPeer
+}
+
+func newRequestFilter(cfg Config) *requestFilter {
+	f := &requestFilter{
+		allowed: map[peer.ID]struct{}{},
+		denied:  map[peer.ID]struct{}{},
+	}
+	for _, p := range cfg.AllowedPeers {
+		f.allowed[p] = struct{}{}
+	}
+	for _, p := range cfg.DeniedPeers {
+		f.denied[p] = struct{}{}
+	}
+	if cfg.Rate > 0 {
+		f.limiter = ratelimit.NewPerPeer(rate.Limit(cfg.Rate), cfg.Burst)
+	}
+
+	return f
+}
+
+func (f *requestFilter) allow(p peer.ID, c cid.Cid) bool {
+	if _, ok := f.denied[p]; ok {
+		return false
+	}
+	if _, ok := f.allowed[p]; len(f.allowed) > 0 && !ok {
+		return false
+	}
+	if f.limiter == nil {
+		return true
+	}
+
+	if !f.limiter.Allow(p) {
+		log.Debugw("rate limiting bitswap request", "peer", p, "cid", c)
+		return false
+	}
+	return true
+}
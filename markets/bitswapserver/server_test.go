@@ -0,0 +1,38 @@
+package bitswapserver
+
+import (
+	"testing"
+
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestFilter(t *testing.T) {
+	alice, err := peer.Decode("12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf")
+	require.NoError(t, err)
+	bob, err := peer.Decode("12D3KooWFQTFuxEeWmEbA8o4b5jQYtH9PYUynkNcQX2FEg3jR6Ko")
+	require.NoError(t, err)
+
+	c := blocks.NewBlock([]byte("block")).Cid()
+
+	// all peers are served by default
+	f := newRequestFilter(Config{})
+	require.True(t, f.allow(alice, c))
+	require.True(t, f.allow(bob, c))
+
+	f = newRequestFilter(Config{AllowedPeers: []peer.ID{alice}})
+	require.True(t, f.allow(alice, c))
+	require.False(t, f.allow(bob, c))
+
+	f = newRequestFilter(Config{DeniedPeers: []peer.ID{alice}})
+	require.False(t, f.allow(alice, c))
+	require.True(t, f.allow(bob, c))
+
+	// rate limits apply per peer
+	f = newRequestFilter(Config{Rate: 0.001, Burst: 2})
+	require.True(t, f.allow(alice, c))
+	require.True(t, f.allow(alice, c))
+	require.False(t, f.allow(alice, c))
+	require.True(t, f.allow(bob, c))
+}
@@ -0,0 +1,345 @@
+// Package collateral keeps the market escrow of a storage provider funded, so
+// that publishing deals doesn't fail for lack of provider collateral.
+package collateral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var log = logging.Logger("collateral")
+
+// Window is the period over which the topped up amount is capped.
+const Window = 24 * time.Hour
+
+var stateKey = datastore.NewKey("state")
+
+type TopUpAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMarketBalance(context.Context, address.Address, types.TipSetKey) (api.MarketBalance, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	MpoolPending(context.Context, types.TipSetKey) ([]*types.SignedMessage, error)
+	WalletBalance(context.Context, address.Address) (types.BigInt, error)
+	MarketAddBalance(ctx context.Context, wallet, addr address.Address, amt types.BigInt) (cid.Cid, error)
+}
+
+type TopUpConfig struct {
+	// Threshold is the available escrow balance below which the escrow is
+	// topped up.
+	Threshold abi.TokenAmount
+	// Amount is added to the escrow on each top-up.
+	Amount abi.TokenAmount
+	// MaxPerDay caps the amount added within Window. Zero is unlimited.
+	MaxPerDay abi.TokenAmount
+	// Wallet the funds are sent from. The worker address if undefined.
+	Wallet address.Address
+	// MinWalletBalance is the balance left in the wallet after a top-up.
+	MinWalletBalance abi.TokenAmount
+	// CheckInterval is how often the escrow balance is checked.
+	CheckInterval time.Duration
+}
+
+type topUp struct {
+	At     time.Time
+	Amount abi.TokenAmount
+}
+
+// state is persisted, so that a restart neither sends a pending top-up again
+// nor resets the daily cap.
+type state struct {
+	Pending *cid.Cid
+	History []topUp
+}
+
+// TopUp adds funds to the market escrow of the miner whenever its available
+// balance drops below the configured threshold. It raises an alert when the
+// escrow can't be topped up, and resolves it once the balance recovers.
+type TopUp struct {
+	cfg   TopUpConfig
+	api   TopUpAPI
+	maddr address.Address
+	ds    datastore.Datastore
+
+	al    *alerting.Alerting
+	alert alerting.AlertType
+
+	// only accessed from the run loop
+	loaded  bool
+	pending cid.Cid
+	history []topUp
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func NewTopUp(cfg TopUpConfig, a TopUpAPI, maddr address.Address, ds datastore.Datastore, al *alerting.Alerting) (*TopUp, error) {
+	if !cfg.Amount.GreaterThan(big.Zero()) {
+		return nil, xerrors.Errorf("collateral top-up amount must be positive")
+	}
+	if cfg.MaxPerDay.Nil() {
+		cfg.MaxPerDay = big.Zero()
+	}
+	if cfg.MinWalletBalance.Nil() {
+		cfg.MinWalletBalance = big.Zero()
+	}
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = time.Minute
+	}
+
+	return &TopUp{
+		cfg:     cfg,
+		api:     a,
+		maddr:   maddr,
+		ds:      ds,
+		al:      al,
+		alert:   al.AddAlertType("market", "collateral-top-up"),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}, nil
+}
+
+func (t *TopUp) Start(ctx context.Context) {
+	go t.run(ctx)
+}
+
+func (t *TopUp) Stop() {
+	close(t.stop)
+	<-t.stopped
+}
+
+func (t *TopUp) run(ctx context.Context) {
+	defer close(t.stopped)
+
+	tick := build.Clock.Ticker(t.cfg.CheckInterval)
+	defer tick.Stop()
+
+	for {
+		if err := t.check(ctx); err != nil {
+			log.Errorw("checking market collateral", "error", err)
+		}
+
+		select {
+		case <-tick.C:
+		case <-t.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// load restores the persisted state. The node may have stopped after sending
+// a top-up but before recording it as pending, so without a pending top-up it
+// looks for one in the mpool.
+func (t *TopUp) load(ctx context.Context) error {
+	b, err := t.ds.Get(ctx, stateKey)
+	switch {
+	case err == nil:
+		var st state
+		if err := json.Unmarshal(b, &st); err != nil {
+			return xerrors.Errorf("unmarshaling top-up state: %w", err)
+		}
+		if st.Pending != nil {
+			t.pending = *st.Pending
+		}
+		t.history = st.History
+
+	case err != datastore.ErrNotFound:
+		return xerrors.Errorf("reading top-up state: %w", err)
+	}
+
+	if !t.pending.Defined() {
+		params, aerr := actors.SerializeParams(&t.maddr)
+		if aerr != nil {
+			return xerrors.Errorf("serializing add balance params: %w", aerr)
+		}
+
+		msgs, err := t.api.MpoolPending(ctx, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting pending messages: %w", err)
+		}
+		for _, m := range msgs {
+			if m.Message.To == builtin.StorageMarketActorAddr && m.Message.Method == builtin.MethodsMarket.AddBalance &&
+				bytes.Equal(m.Message.Params, params) {
+				log.Infow("found a market collateral top-up in the mpool", "miner", t.maddr, "message", m.Cid())
+				t.pending = m.Cid()
+				break
+			}
+		}
+	}
+
+	t.loaded = true
+	return nil
+}
+
+func (t *TopUp) save(ctx context.Context) error {
+	st := state{History: t.history}
+	if t.pending.Defined() {
+		st.Pending = &t.pending
+	}
+
+	b, err := json.Marshal(&st)
+	if err != nil {
+		return xerrors.Errorf("marshaling top-up state: %w", err)
+	}
+	if err := t.ds.Put(ctx, stateKey, b); err != nil {
+		return xerrors.Errorf("persisting top-up state: %w", err)
+	}
+	return nil
+}
+
+// check tops up the escrow if its available balance is below the threshold.
+func (t *TopUp) check(ctx context.Context) error {
+	if !t.loaded {
+		if err := t.load(ctx); err != nil {
+			return err
+		}
+	}
+
+	if t.pending.Defined() {
+		lookup, err := t.api.StateSearchMsg(ctx, types.EmptyTSK, t.pending, api.LookbackNoLimit, true)
+		if err != nil {
+			return xerrors.Errorf("looking up top-up message %s: %w", t.pending, err)
+		}
+		if lookup == nil {
+			// wait for the previous top-up to land
+			return nil
+		}
+		if lookup.Receipt.ExitCode.IsError() {
+			t.raise("market collateral top-up message failed", map[string]interface{}{
+				"message-cid": t.pending,
+				"exit-code":   lookup.Receipt.ExitCode,
+			})
+		}
+		t.pending = cid.Undef
+		if err := t.save(ctx); err != nil {
+			return err
+		}
+	}
+
+	head, err := t.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	bal, err := t.api.StateMarketBalance(ctx, t.maddr, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting market balance: %w", err)
+	}
+
+	available := big.Sub(bal.Escrow, bal.Locked)
+	if !available.LessThan(t.cfg.Threshold) {
+		if t.al.IsRaised(t.alert) {
+			t.al.Resolve(t.alert, map[string]interface{}{
+				"message":   "market collateral is above the top-up threshold",
+				"available": types.FIL(available).String(),
+			})
+		}
+		return nil
+	}
+
+	now := build.Clock.Now()
+	added := t.addedSince(now.Add(-Window))
+	if !t.cfg.MaxPerDay.IsZero() && big.Add(added, t.cfg.Amount).GreaterThan(t.cfg.MaxPerDay) {
+		t.raise("market collateral is low, but the daily top-up cap was reached", map[string]interface{}{
+			"available": types.FIL(available).String(),
+			"added":     types.FIL(added).String(),
+			"cap":       types.FIL(t.cfg.MaxPerDay).String(),
+		})
+		return nil
+	}
+
+	wallet := t.cfg.Wallet
+	if wallet == address.Undef {
+		mi, err := t.api.StateMinerInfo(ctx, t.maddr, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+		wallet = mi.Worker
+	}
+
+	wbal, err := t.api.WalletBalance(ctx, wallet)
+	if err != nil {
+		return xerrors.Errorf("getting balance of %s: %w", wallet, err)
+	}
+	if big.Sub(wbal, t.cfg.Amount).LessThan(t.cfg.MinWalletBalance) {
+		t.raise("market collateral is low, but the top-up wallet has insufficient funds", map[string]interface{}{
+			"available":      types.FIL(available).String(),
+			"wallet":         wallet,
+			"wallet-balance": types.FIL(wbal).String(),
+		})
+		return nil
+	}
+
+	// the top-up counts towards the cap before it is sent, so that it isn't
+	// exceeded if the node stops right after sending it
+	t.history = append(t.history, topUp{At: now, Amount: t.cfg.Amount})
+	if err := t.save(ctx); err != nil {
+		t.history = t.history[:len(t.history)-1]
+		return err
+	}
+
+	mcid, err := t.api.MarketAddBalance(ctx, wallet, t.maddr, t.cfg.Amount)
+	if err != nil {
+		t.history = t.history[:len(t.history)-1]
+		t.raise("failed to top up market collateral", map[string]interface{}{
+			"available": types.FIL(available).String(),
+			"error":     err.Error(),
+		})
+		return t.save(ctx)
+	}
+
+	t.pending = mcid
+	if err := t.save(ctx); err != nil {
+		return err
+	}
+
+	log.Infow("topping up market collateral", "miner", t.maddr, "wallet", wallet,
+		"available", types.FIL(available), "amount", types.FIL(t.cfg.Amount), "message", mcid)
+	return nil
+}
+
+// addedSince drops the top-ups before cutoff, and returns the sum of the
+// remaining ones.
+func (t *TopUp) addedSince(cutoff time.Time) abi.TokenAmount {
+	i := 0
+	for i < len(t.history) && !t.history[i].At.After(cutoff) {
+		i++
+	}
+	t.history = t.history[i:]
+
+	total := big.Zero()
+	for _, h := range t.history {
+		total = big.Add(total, h.Amount)
+	}
+	return total
+}
+
+func (t *TopUp) raise(msg string, info map[string]interface{}) {
+	log.Warnw(msg, "miner", t.maddr, "info", info)
+	if t.al.IsRaised(t.alert) {
+		return
+	}
+
+	info["message"] = msg
+	t.al.Raise(t.alert, info)
+}
@@ -0,0 +1,213 @@
+package collateral
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+type testTopUpAPI struct {
+	escrow, locked abi.TokenAmount
+	worker         address.Address
+	wallet         abi.TokenAmount
+
+	landed map[cid.Cid]bool
+	sent   []abi.TokenAmount
+	mpool  []*types.SignedMessage
+}
+
+func (a *testTopUpAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	return mock.TipSet(mock.MkBlock(nil, 1, 1)), nil
+}
+
+func (a *testTopUpAPI) StateMarketBalance(context.Context, address.Address, types.TipSetKey) (api.MarketBalance, error) {
+	return api.MarketBalance{Escrow: a.escrow, Locked: a.locked}, nil
+}
+
+func (a *testTopUpAPI) StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error) {
+	return api.MinerInfo{Worker: a.worker}, nil
+}
+
+func (a *testTopUpAPI) StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	if !a.landed[msg] {
+		return nil, nil
+	}
+	return &api.MsgLookup{Receipt: types.MessageReceipt{ExitCode: exitcode.Ok}}, nil
+}
+
+func (a *testTopUpAPI) MpoolPending(context.Context, types.TipSetKey) ([]*types.SignedMessage, error) {
+	return a.mpool, nil
+}
+
+func (a *testTopUpAPI) WalletBalance(context.Context, address.Address) (types.BigInt, error) {
+	return a.wallet, nil
+}
+
+func (a *testTopUpAPI) MarketAddBalance(ctx context.Context, wallet, addr address.Address, amt types.BigInt) (cid.Cid, error) {
+	a.sent = append(a.sent, amt)
+	return blocks.NewBlock([]byte{byte(len(a.sent))}).Cid(), nil
+}
+
+// land applies the pending top-up message.
+func (a *testTopUpAPI) land(t *TopUp) {
+	a.landed[t.pending] = true
+	a.escrow = big.Add(a.escrow, a.sent[len(a.sent)-1])
+	a.wallet = big.Sub(a.wallet, a.sent[len(a.sent)-1])
+}
+
+func TestTopUp(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+
+	a := &testTopUpAPI{
+		escrow: types.FromFil(5),
+		locked: types.FromFil(4),
+		worker: mock.Address(1001),
+		wallet: types.FromFil(10),
+		landed: map[cid.Cid]bool{},
+	}
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+
+	tu, err := NewTopUp(TopUpConfig{
+		Threshold:        types.FromFil(2),
+		Amount:           types.FromFil(3),
+		MaxPerDay:        types.FromFil(6),
+		MinWalletBalance: types.FromFil(2),
+	}, a, mock.Address(1000), datastore.NewMapDatastore(), al)
+	require.NoError(t, err)
+
+	// below the threshold
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 1)
+
+	// no new top-up until the previous one landed
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 1)
+
+	a.land(tu)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 1)
+
+	// more funds were locked, topped up again
+	a.locked = types.FromFil(7)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 2)
+	a.land(tu)
+
+	// the daily cap is reached
+	a.locked = types.FromFil(10)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 2)
+	require.True(t, al.IsRaised(tu.alert))
+
+	// the cap frees up after a day, but the wallet balance is too low now
+	mc.Add(Window)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 2)
+	require.True(t, al.IsRaised(tu.alert))
+
+	a.wallet = types.FromFil(10)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 3)
+	a.land(tu)
+
+	// resolved once the balance is above the threshold
+	require.NoError(t, tu.check(ctx))
+	require.False(t, al.IsRaised(tu.alert))
+}
+
+func TestTopUpRestart(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+
+	maddr := mock.Address(1000)
+	a := &testTopUpAPI{
+		escrow: types.FromFil(5),
+		locked: types.FromFil(4),
+		worker: mock.Address(1001),
+		wallet: types.FromFil(100),
+		landed: map[cid.Cid]bool{},
+	}
+	ds := datastore.NewMapDatastore()
+
+	restart := func() *TopUp {
+		tu, err := NewTopUp(TopUpConfig{
+			Threshold: types.FromFil(2),
+			Amount:    types.FromFil(3),
+			MaxPerDay: types.FromFil(6),
+		}, a, maddr, ds, alerting.NewAlertingSystem(journal.NilJournal()))
+		require.NoError(t, err)
+		return tu
+	}
+
+	tu := restart()
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 1)
+
+	// the pending top-up isn't sent again after a restart
+	tu = restart()
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 1)
+
+	a.land(tu)
+	a.locked = types.FromFil(7)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 2)
+	a.land(tu)
+
+	// nor does the daily cap reset
+	tu = restart()
+	a.locked = types.FromFil(10)
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 2)
+
+	// a top-up sent without being recorded as pending is found in the mpool
+	mc.Add(Window)
+	params, err := actors.SerializeParams(&maddr)
+	require.NoError(t, err)
+	a.mpool = []*types.SignedMessage{{
+		Message: types.Message{
+			To:     builtin.StorageMarketActorAddr,
+			From:   a.worker,
+			Method: builtin.MethodsMarket.AddBalance,
+			Params: params,
+			Value:  types.FromFil(3),
+		},
+	}}
+	require.NoError(t, ds.Put(ctx, stateKey, []byte(`{}`)))
+
+	tu = restart()
+	require.NoError(t, tu.check(ctx))
+	require.Len(t, a.sent, 2)
+	require.Equal(t, a.mpool[0].Cid(), tu.pending)
+}
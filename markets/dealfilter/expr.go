@@ -0,0 +1,551 @@
+package dealfilter
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math/big"
+	"strconv"
+
+	"github.com/docker/go-units"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// ExprStorageDealFilter returns a storage deal filter accepting the deals for
+// which expr evaluates to true.
+//
+// Expressions use Go syntax: integer and string literals, the arithmetic
+// operators + - * / %, comparisons, and the logical operators && || !.
+// Integers are arbitrary precision. The following variables describe the
+// deal:
+//
+//	Client, Provider, PieceCID            string
+//	PieceSize                             padded piece size in bytes
+//	Verified, Offline                     bool
+//	StartEpoch, EndEpoch, Duration        epochs
+//	PricePerEpoch, PricePerGiBEpoch       attoFIL
+//	TotalPrice                            attoFIL, price for the whole duration
+//	ProviderCollateral, ClientCollateral  attoFIL
+//
+// and the following functions are available:
+//
+//	in(x, a, b, ...)  true if x equals any of a, b, ...
+//	fil("0.5")        FIL amount in attoFIL
+//	size("32GiB")     size in bytes
+//
+// For example:
+//
+//	Verified || (PieceSize >= size("1GiB") && PricePerGiBEpoch >= fil("0.0000000005"))
+func ExprStorageDealFilter(expr string) (dtypes.StorageDealFilter, error) {
+	e, err := compileExpr(expr, storageDealExprTypes)
+	if err != nil {
+		return nil, xerrors.Errorf("compiling deal filter expression: %w", err)
+	}
+
+	return func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+		ok, err := e.eval(storageDealExprEnv(deal))
+		if err != nil {
+			return false, "filter expression error", err
+		}
+		if !ok {
+			return false, "deal rejected by the storage provider's deal filter", nil
+		}
+		return true, "", nil
+	}, nil
+}
+
+// ExprRetrievalDealFilter returns a retrieval deal filter accepting the deals
+// for which expr evaluates to true. Expressions have the same syntax and
+// functions as in ExprStorageDealFilter, with the following variables:
+//
+//	Client, PayloadCID, PieceCID  string, Client is the peer ID of the client
+//	PieceSize                     padded piece size in bytes, 0 if unknown
+//	PricePerByte, UnsealPrice     attoFIL
+//	PaymentInterval               bytes sent between payments
+//
+// For example:
+//
+//	PricePerByte > 0 || in(Client, "12D3KooW...")
+func ExprRetrievalDealFilter(expr string) (dtypes.RetrievalDealFilter, error) {
+	e, err := compileExpr(expr, retrievalDealExprTypes)
+	if err != nil {
+		return nil, xerrors.Errorf("compiling retrieval filter expression: %w", err)
+	}
+
+	return func(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error) {
+		ok, err := e.eval(retrievalDealExprEnv(deal))
+		if err != nil {
+			return false, "filter expression error", err
+		}
+		if !ok {
+			return false, "retrieval rejected by the storage provider's retrieval filter", nil
+		}
+		return true, "", nil
+	}, nil
+}
+
+// Chain returns a storage deal filter which accepts a deal only if all the
+// given filters accept it. The filters are evaluated in order.
+func Chain(filters ...dtypes.StorageDealFilter) dtypes.StorageDealFilter {
+	return func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+		for _, f := range filters {
+			ok, reason, err := f(ctx, deal)
+			if err != nil || !ok {
+				return ok, reason, err
+			}
+		}
+		return true, "", nil
+	}
+}
+
+// RetrievalChain is Chain for retrieval deal filters.
+func RetrievalChain(filters ...dtypes.RetrievalDealFilter) dtypes.RetrievalDealFilter {
+	return func(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error) {
+		for _, f := range filters {
+			ok, reason, err := f(ctx, deal)
+			if err != nil || !ok {
+				return ok, reason, err
+			}
+		}
+		return true, "", nil
+	}
+}
+
+type exprKind int
+
+const (
+	kindBool exprKind = iota
+	kindInt
+	kindString
+)
+
+func (k exprKind) String() string {
+	switch k {
+	case kindBool:
+		return "bool"
+	case kindInt:
+		return "int"
+	case kindString:
+		return "string"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+var storageDealExprTypes = map[string]exprKind{
+	"Client":             kindString,
+	"Provider":           kindString,
+	"PieceCID":           kindString,
+	"PieceSize":          kindInt,
+	"Verified":           kindBool,
+	"Offline":            kindBool,
+	"StartEpoch":         kindInt,
+	"EndEpoch":           kindInt,
+	"Duration":           kindInt,
+	"PricePerEpoch":      kindInt,
+	"PricePerGiBEpoch":   kindInt,
+	"TotalPrice":         kindInt,
+	"ProviderCollateral": kindInt,
+	"ClientCollateral":   kindInt,
+}
+
+func storageDealExprEnv(deal storagemarket.MinerDeal) map[string]interface{} {
+	p := deal.Proposal
+	duration := big.NewInt(int64(p.Duration()))
+
+	perGiB := new(big.Int)
+	if p.PieceSize > 0 {
+		perGiB.Mul(p.StoragePricePerEpoch.Int, big.NewInt(1<<30))
+		perGiB.Quo(perGiB, big.NewInt(int64(p.PieceSize)))
+	}
+
+	return map[string]interface{}{
+		"Client":             p.Client.String(),
+		"Provider":           p.Provider.String(),
+		"PieceCID":           p.PieceCID.String(),
+		"PieceSize":          big.NewInt(int64(p.PieceSize)),
+		"Verified":           p.VerifiedDeal,
+		"Offline":            deal.Ref != nil && deal.Ref.TransferType == storagemarket.TTManual,
+		"StartEpoch":         big.NewInt(int64(p.StartEpoch)),
+		"EndEpoch":           big.NewInt(int64(p.EndEpoch)),
+		"Duration":           duration,
+		"PricePerEpoch":      bigOrZero(p.StoragePricePerEpoch.Int),
+		"PricePerGiBEpoch":   perGiB,
+		"TotalPrice":         new(big.Int).Mul(bigOrZero(p.StoragePricePerEpoch.Int), duration),
+		"ProviderCollateral": bigOrZero(p.ProviderCollateral.Int),
+		"ClientCollateral":   bigOrZero(p.ClientCollateral.Int),
+	}
+}
+
+var retrievalDealExprTypes = map[string]exprKind{
+	"Client":          kindString,
+	"PayloadCID":      kindString,
+	"PieceCID":        kindString,
+	"PieceSize":       kindInt,
+	"PricePerByte":    kindInt,
+	"UnsealPrice":     kindInt,
+	"PaymentInterval": kindInt,
+}
+
+func retrievalDealExprEnv(deal retrievalmarket.ProviderDealState) map[string]interface{} {
+	var pieceCID string
+	var pieceSize int64
+	switch {
+	case deal.PieceInfo != nil && deal.PieceInfo.Defined():
+		pieceCID = deal.PieceInfo.PieceCID.String()
+		if len(deal.PieceInfo.Deals) > 0 {
+			pieceSize = int64(deal.PieceInfo.Deals[0].Length)
+		}
+	case deal.PieceCID != nil:
+		pieceCID = deal.PieceCID.String()
+	}
+
+	return map[string]interface{}{
+		"Client":          deal.Receiver.String(),
+		"PayloadCID":      deal.PayloadCID.String(),
+		"PieceCID":        pieceCID,
+		"PieceSize":       big.NewInt(pieceSize),
+		"PricePerByte":    bigOrZero(deal.PricePerByte.Int),
+		"UnsealPrice":     bigOrZero(deal.UnsealPrice.Int),
+		"PaymentInterval": new(big.Int).SetUint64(deal.PaymentInterval),
+	}
+}
+
+func bigOrZero(i *big.Int) *big.Int {
+	if i == nil {
+		return new(big.Int)
+	}
+	return i
+}
+
+// evalFn evaluates a compiled expression node. It returns a bool, a string or
+// a *big.Int depending on the kind of the node.
+type evalFn func(env map[string]interface{}) (interface{}, error)
+
+type compiledExpr struct {
+	fn evalFn
+}
+
+func (e *compiledExpr) eval(env map[string]interface{}) (bool, error) {
+	v, err := e.fn(env)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// compileExpr parses and type-checks expr, so that malformed expressions are
+// reported when the node starts instead of when the first deal arrives.
+func compileExpr(expr string, vars map[string]exprKind) (*compiledExpr, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, kind, err := compileNode(node, vars)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindBool {
+		return nil, xerrors.Errorf("expression must evaluate to a bool, not %s", kind)
+	}
+
+	return &compiledExpr{fn: fn}, nil
+}
+
+func compileNode(node ast.Expr, vars map[string]exprKind) (evalFn, exprKind, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return compileNode(n.X, vars)
+
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.INT:
+			i, ok := new(big.Int).SetString(n.Value, 0)
+			if !ok {
+				return nil, 0, xerrors.Errorf("invalid integer %s", n.Value)
+			}
+			return constFn(i), kindInt, nil
+		case token.STRING:
+			s, err := strconv.Unquote(n.Value)
+			if err != nil {
+				return nil, 0, xerrors.Errorf("invalid string %s: %w", n.Value, err)
+			}
+			return constFn(s), kindString, nil
+		default:
+			return nil, 0, xerrors.Errorf("unsupported literal %s, only integers and strings are allowed", n.Value)
+		}
+
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return constFn(true), kindBool, nil
+		case "false":
+			return constFn(false), kindBool, nil
+		}
+
+		kind, ok := vars[n.Name]
+		if !ok {
+			return nil, 0, xerrors.Errorf("unknown variable %s", n.Name)
+		}
+		name := n.Name
+		return func(env map[string]interface{}) (interface{}, error) {
+			v, ok := env[name]
+			if !ok {
+				return nil, xerrors.Errorf("variable %s not set", name)
+			}
+			return v, nil
+		}, kind, nil
+
+	case *ast.UnaryExpr:
+		x, kind, err := compileNode(n.X, vars)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch {
+		case n.Op == token.NOT && kind == kindBool:
+			return func(env map[string]interface{}) (interface{}, error) {
+				v, err := x(env)
+				if err != nil {
+					return nil, err
+				}
+				return !v.(bool), nil
+			}, kindBool, nil
+		case n.Op == token.SUB && kind == kindInt:
+			return func(env map[string]interface{}) (interface{}, error) {
+				v, err := x(env)
+				if err != nil {
+					return nil, err
+				}
+				return new(big.Int).Neg(v.(*big.Int)), nil
+			}, kindInt, nil
+		default:
+			return nil, 0, xerrors.Errorf("operator %s not defined on %s", n.Op, kind)
+		}
+
+	case *ast.BinaryExpr:
+		return compileBinary(n, vars)
+
+	case *ast.CallExpr:
+		return compileCall(n, vars)
+
+	default:
+		return nil, 0, xerrors.Errorf("unsupported expression at offset %d", node.Pos()-1)
+	}
+}
+
+func compileBinary(n *ast.BinaryExpr, vars map[string]exprKind) (evalFn, exprKind, error) {
+	x, xk, err := compileNode(n.X, vars)
+	if err != nil {
+		return nil, 0, err
+	}
+	y, yk, err := compileNode(n.Y, vars)
+	if err != nil {
+		return nil, 0, err
+	}
+	if xk != yk {
+		return nil, 0, xerrors.Errorf("mismatched types %s and %s for operator %s", xk, yk, n.Op)
+	}
+
+	switch n.Op {
+	case token.LAND, token.LOR:
+		if xk != kindBool {
+			return nil, 0, xerrors.Errorf("operator %s not defined on %s", n.Op, xk)
+		}
+		and := n.Op == token.LAND
+		return func(env map[string]interface{}) (interface{}, error) {
+			v, err := x(env)
+			if err != nil {
+				return nil, err
+			}
+			if v.(bool) != and {
+				return v, nil
+			}
+			return y(env)
+		}, kindBool, nil
+
+	case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+		if xk != kindInt {
+			return nil, 0, xerrors.Errorf("operator %s not defined on %s", n.Op, xk)
+		}
+		op := n.Op
+		return func(env map[string]interface{}) (interface{}, error) {
+			a, b, err := evalBoth(x, y, env)
+			if err != nil {
+				return nil, err
+			}
+			ai, bi := a.(*big.Int), b.(*big.Int)
+
+			switch op {
+			case token.ADD:
+				return new(big.Int).Add(ai, bi), nil
+			case token.SUB:
+				return new(big.Int).Sub(ai, bi), nil
+			case token.MUL:
+				return new(big.Int).Mul(ai, bi), nil
+			}
+
+			if bi.Sign() == 0 {
+				return nil, xerrors.Errorf("division by zero")
+			}
+			if op == token.QUO {
+				return new(big.Int).Quo(ai, bi), nil
+			}
+			return new(big.Int).Rem(ai, bi), nil
+		}, kindInt, nil
+
+	case token.EQL, token.NEQ:
+		eq := n.Op == token.EQL
+		return func(env map[string]interface{}) (interface{}, error) {
+			a, b, err := evalBoth(x, y, env)
+			if err != nil {
+				return nil, err
+			}
+			return valuesEqual(a, b) == eq, nil
+		}, kindBool, nil
+
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if xk == kindBool {
+			return nil, 0, xerrors.Errorf("operator %s not defined on %s", n.Op, xk)
+		}
+		op := n.Op
+		return func(env map[string]interface{}) (interface{}, error) {
+			a, b, err := evalBoth(x, y, env)
+			if err != nil {
+				return nil, err
+			}
+
+			var c int
+			if ai, ok := a.(*big.Int); ok {
+				c = ai.Cmp(b.(*big.Int))
+			} else {
+				as, bs := a.(string), b.(string)
+				switch {
+				case as < bs:
+					c = -1
+				case as > bs:
+					c = 1
+				}
+			}
+
+			switch op {
+			case token.LSS:
+				return c < 0, nil
+			case token.LEQ:
+				return c <= 0, nil
+			case token.GTR:
+				return c > 0, nil
+			default:
+				return c >= 0, nil
+			}
+		}, kindBool, nil
+
+	default:
+		return nil, 0, xerrors.Errorf("unsupported operator %s", n.Op)
+	}
+}
+
+func compileCall(n *ast.CallExpr, vars map[string]exprKind) (evalFn, exprKind, error) {
+	name, ok := n.Fun.(*ast.Ident)
+	if !ok {
+		return nil, 0, xerrors.Errorf("unsupported function call at offset %d", n.Pos()-1)
+	}
+
+	args := make([]evalFn, len(n.Args))
+	kinds := make([]exprKind, len(n.Args))
+	for i, a := range n.Args {
+		var err error
+		if args[i], kinds[i], err = compileNode(a, vars); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	switch name.Name {
+	case "in":
+		if len(args) < 2 {
+			return nil, 0, xerrors.Errorf("in() takes a value and at least one candidate")
+		}
+		for i, k := range kinds[1:] {
+			if k != kinds[0] {
+				return nil, 0, xerrors.Errorf("in() argument %d is %s, expected %s", i+2, k, kinds[0])
+			}
+		}
+		return func(env map[string]interface{}) (interface{}, error) {
+			x, err := args[0](env)
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range args[1:] {
+				v, err := a(env)
+				if err != nil {
+					return nil, err
+				}
+				if valuesEqual(x, v) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}, kindBool, nil
+
+	case "fil", "size":
+		if len(args) != 1 || kinds[0] != kindString {
+			return nil, 0, xerrors.Errorf("%s() takes a single string", name.Name)
+		}
+		// only allow constant arguments, so that they are validated here
+		v, err := args[0](nil)
+		if err != nil {
+			return nil, 0, xerrors.Errorf("%s() argument must be a string literal", name.Name)
+		}
+
+		if name.Name == "fil" {
+			f, err := types.ParseFIL(v.(string))
+			if err != nil {
+				return nil, 0, xerrors.Errorf("fil(): %w", err)
+			}
+			return constFn(new(big.Int).Set(f.Int)), kindInt, nil
+		}
+
+		s, err := units.RAMInBytes(v.(string))
+		if err != nil {
+			return nil, 0, xerrors.Errorf("size(): %w", err)
+		}
+		return constFn(big.NewInt(s)), kindInt, nil
+
+	default:
+		return nil, 0, xerrors.Errorf("unknown function %s", name.Name)
+	}
+}
+
+func constFn(v interface{}) evalFn {
+	return func(map[string]interface{}) (interface{}, error) {
+		return v, nil
+	}
+}
+
+func evalBoth(x, y evalFn, env map[string]interface{}) (interface{}, interface{}, error) {
+	a, err := x(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := y(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if ai, ok := a.(*big.Int); ok {
+		return ai.Cmp(b.(*big.Int)) == 0
+	}
+	return a == b
+}
@@ -0,0 +1,168 @@
+package dealfilter
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestExprStorageDealFilter(t *testing.T) {
+	ctx := context.Background()
+	client := mock.Address(1000).String()
+
+	deal := storagemarket.MinerDeal{
+		ClientDealProposal: market.ClientDealProposal{
+			Proposal: market.DealProposal{
+				PieceSize:            abi.PaddedPieceSize(32 << 30),
+				Client:               mock.Address(1000),
+				Provider:             mock.Address(2000),
+				StartEpoch:           100,
+				EndEpoch:             1100,
+				StoragePricePerEpoch: abi.NewTokenAmount(64),
+				ProviderCollateral:   abi.NewTokenAmount(0),
+				ClientCollateral:     abi.NewTokenAmount(0),
+			},
+		},
+		Ref: &storagemarket.DataRef{TransferType: storagemarket.TTManual},
+	}
+
+	for _, tc := range []struct {
+		expr   string
+		accept bool
+	}{
+		{`true`, true},
+		{`Verified`, false},
+		{`!Verified && Offline`, true},
+		{`Client == "` + client + `"`, true},
+		{`in(Client, "f01", "` + client + `")`, true},
+		{`in(Provider, "f01", "` + client + `")`, false},
+		{`PieceSize >= size("32GiB")`, true},
+		{`PieceSize > size("32GiB")`, false},
+		{`Duration == 1000 && EndEpoch - StartEpoch == Duration`, true},
+		{`PricePerGiBEpoch == 2`, true},
+		{`TotalPrice == 64 * 1000`, true},
+		{`PricePerEpoch >= fil("0.000000000000000064")`, true},
+		{`(Verified || PieceSize % 2 == 1) || StartEpoch < 50`, false},
+		// short-circuiting skips the division by zero
+		{`false && 1 / ProviderCollateral == 0`, false},
+	} {
+		f, err := ExprStorageDealFilter(tc.expr)
+		require.NoError(t, err, tc.expr)
+
+		ok, reason, err := f(ctx, deal)
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.accept, ok, tc.expr)
+		if !ok {
+			require.NotEmpty(t, reason)
+		}
+	}
+
+	f, err := ExprStorageDealFilter(`1 / ProviderCollateral == 0`)
+	require.NoError(t, err)
+	_, _, err = f(ctx, deal)
+	require.Error(t, err)
+
+	for _, expr := range []string{
+		``,
+		`PieceSize`,
+		`Unknown == 1`,
+		`Client == 1`,
+		`Verified + 1`,
+		`Client > 1.5`,
+		`in(Client)`,
+		`in(Client, 1)`,
+		`fil(Client) > 0`,
+		`size("lots") > 0`,
+		`Client.String() == ""`,
+		`exec("rm") == 0`,
+	} {
+		_, err := ExprStorageDealFilter(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestExprRetrievalDealFilter(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := peer.Decode("12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf")
+	require.NoError(t, err)
+	payload := blocks.NewBlock([]byte("payload")).Cid()
+	piece := blocks.NewBlock([]byte("piece")).Cid()
+
+	deal := retrievalmarket.ProviderDealState{
+		DealProposal: retrievalmarket.DealProposal{
+			PayloadCID: payload,
+			Params: retrievalmarket.Params{
+				PricePerByte:    abi.NewTokenAmount(0),
+				UnsealPrice:     abi.NewTokenAmount(100),
+				PaymentInterval: 1 << 20,
+			},
+		},
+		PieceInfo: &piecestore.PieceInfo{
+			PieceCID: piece,
+			Deals:    []piecestore.DealInfo{{Length: abi.PaddedPieceSize(2 << 30)}},
+		},
+		Receiver: client,
+	}
+
+	for _, tc := range []struct {
+		expr   string
+		accept bool
+	}{
+		{`true`, true},
+		{`Client == "` + client.String() + `"`, true},
+		{`in(Client, "12D3KooWFQTFuxEeWmEbA8o4b5jQYtH9PYUynkNcQX2FEg3jR6Ko")`, false},
+		{`PayloadCID == "` + payload.String() + `" && PieceCID == "` + piece.String() + `"`, true},
+		{`PieceSize <= size("1GiB")`, false},
+		{`PricePerByte > 0 || UnsealPrice >= 100`, true},
+		{`PaymentInterval == size("1MiB")`, true},
+	} {
+		f, err := ExprRetrievalDealFilter(tc.expr)
+		require.NoError(t, err, tc.expr)
+
+		ok, reason, err := f(ctx, deal)
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.accept, ok, tc.expr)
+		if !ok {
+			require.NotEmpty(t, reason)
+		}
+	}
+
+	// storage deal variables aren't defined for retrievals
+	_, err = ExprRetrievalDealFilter(`Verified`)
+	require.Error(t, err)
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+
+	accept := func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+		return true, "", nil
+	}
+	calls := 0
+	reject := func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+		calls++
+		return false, "nope", nil
+	}
+
+	ok, _, err := Chain(accept, accept)(ctx, storagemarket.MinerDeal{})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, reason, err := Chain(accept, reject, reject)(ctx, storagemarket.MinerDeal{})
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, "nope", reason)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,27 @@
+package dealfilter
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/lib/ratelimit"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// RetrievalRateLimitFilter returns a retrieval deal filter rejecting the
+// proposals of a client above perMinute proposals per minute, allowing bursts
+// of up to burst proposals.
+func RetrievalRateLimitFilter(perMinute float64, burst int) dtypes.RetrievalDealFilter {
+	limiter := ratelimit.NewPerPeer(rate.Limit(perMinute/60), burst)
+
+	return func(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error) {
+		if !limiter.Allow(deal.Receiver) {
+			log.Infow("rate limiting retrieval proposals", "client", deal.Receiver, "payload", deal.PayloadCID)
+			return false, "too many retrieval proposals, try again later", nil
+		}
+		return true, "", nil
+	}
+}
@@ -0,0 +1,37 @@
+package dealfilter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+)
+
+func TestRetrievalRateLimitFilter(t *testing.T) {
+	ctx := context.Background()
+
+	alice, err := peer.Decode("12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf")
+	require.NoError(t, err)
+	bob, err := peer.Decode("12D3KooWFQTFuxEeWmEbA8o4b5jQYtH9PYUynkNcQX2FEg3jR6Ko")
+	require.NoError(t, err)
+
+	f := RetrievalRateLimitFilter(0.01, 2)
+	propose := func(client peer.ID) bool {
+		ok, reason, err := f(ctx, retrievalmarket.ProviderDealState{Receiver: client})
+		require.NoError(t, err)
+		if !ok {
+			require.NotEmpty(t, reason)
+		}
+		return ok
+	}
+
+	require.True(t, propose(alice))
+	require.True(t, propose(alice))
+	require.False(t, propose(alice))
+
+	// clients are limited separately
+	require.True(t, propose(bob))
+}
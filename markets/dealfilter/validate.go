@@ -0,0 +1,111 @@
+package dealfilter
+
+import (
+	"context"
+	"fmt"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var log = logging.Logger("dealfilter")
+
+// Rejection codes of the proposals failing validation. The code prefixes the
+// rejection reason sent to the client, so that clients can tell the causes
+// apart without parsing the message.
+const (
+	RejectPieceSize = "invalid-piece-size"
+	RejectLabel     = "invalid-label"
+	RejectDuration  = "invalid-duration"
+	RejectFunds     = "insufficient-client-funds"
+)
+
+// Rejection is the reason a proposal failed validation.
+type Rejection struct {
+	Code    string
+	Message string
+}
+
+func (r *Rejection) String() string {
+	return fmt.Sprintf("%s: %s", r.Code, r.Message)
+}
+
+func reject(code, format string, args ...interface{}) *Rejection {
+	return &Rejection{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateProposal checks that the deal can make it on chain and into a
+// sector. It returns the reason the deal must be rejected, or nil.
+func ValidateProposal(ctx context.Context, spn storagemarket.StorageProviderNode, deal storagemarket.MinerDeal) (*Rejection, error) {
+	p := deal.Proposal
+
+	if err := p.PieceSize.Validate(); err != nil {
+		return reject(RejectPieceSize, "%s", err), nil
+	}
+
+	// labels decoded from the proposal are only limited by the CBOR decoder,
+	// the market actor rejects the ones longer than DealMaxLabelSize
+	if p.Label.Length() > market.DealMaxLabelSize {
+		return reject(RejectLabel, "label is %d bytes long, the maximum is %d", p.Label.Length(), market.DealMaxLabelSize), nil
+	}
+
+	if p.EndEpoch <= p.StartEpoch {
+		return reject(RejectDuration, "end epoch %d is not after start epoch %d", p.EndEpoch, p.StartEpoch), nil
+	}
+	minDuration, maxDuration := policy.DealDurationBounds(p.PieceSize)
+	if d := p.Duration(); d < minDuration || d > maxDuration {
+		return reject(RejectDuration, "duration of %d epochs is outside the allowed bounds [%d, %d]", d, minDuration, maxDuration), nil
+	}
+
+	tok, _, err := spn.GetChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	proof, err := spn.GetProofType(ctx, p.Provider, tok)
+	if err != nil {
+		return nil, xerrors.Errorf("getting provider seal proof type: %w", err)
+	}
+	ssize, err := proof.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+	if p.PieceSize > abi.PaddedPieceSize(ssize) {
+		return reject(RejectPieceSize, "piece size %d is larger than the sector size %d", p.PieceSize, ssize), nil
+	}
+
+	bal, err := spn.GetBalance(ctx, p.Client, tok)
+	if err != nil {
+		return nil, xerrors.Errorf("getting client market balance: %w", err)
+	}
+	if required := p.ClientBalanceRequirement(); bal.Available.LessThan(required) {
+		return reject(RejectFunds, "client has %s available in escrow, the deal requires %s",
+			types.FIL(bal.Available), types.FIL(required)), nil
+	}
+
+	return nil, nil
+}
+
+// ProposalValidator returns a storage deal filter rejecting the deals which
+// fail ValidateProposal.
+func ProposalValidator(spn storagemarket.StorageProviderNode) dtypes.StorageDealFilter {
+	return func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+		r, err := ValidateProposal(ctx, spn, deal)
+		if err != nil {
+			return false, "miner error", err
+		}
+		if r != nil {
+			log.Warnw("rejecting invalid storage deal proposal", "proposal", deal.ProposalCid, "client", deal.Client, "code", r.Code, "reason", r.Message)
+			return false, r.String(), nil
+		}
+		return true, "", nil
+	}
+}
@@ -0,0 +1,105 @@
+package dealfilter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/shared"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type testProviderNode struct {
+	storagemarket.StorageProviderNode
+
+	balance abi.TokenAmount
+}
+
+func (n *testProviderNode) GetChainHead(ctx context.Context) (shared.TipSetToken, abi.ChainEpoch, error) {
+	return nil, 100, nil
+}
+
+func (n *testProviderNode) GetProofType(ctx context.Context, addr address.Address, tok shared.TipSetToken) (abi.RegisteredSealProof, error) {
+	return abi.RegisteredSealProof_StackedDrg32GiBV1_1, nil
+}
+
+func (n *testProviderNode) GetBalance(ctx context.Context, addr address.Address, tok shared.TipSetToken) (storagemarket.Balance, error) {
+	return storagemarket.Balance{Locked: big.Zero(), Available: n.balance}, nil
+}
+
+func TestValidateProposal(t *testing.T) {
+	ctx := context.Background()
+	spn := &testProviderNode{balance: types.FromFil(1)}
+
+	valid := func() storagemarket.MinerDeal {
+		label, err := market.NewLabelFromString("label")
+		require.NoError(t, err)
+
+		return storagemarket.MinerDeal{
+			ClientDealProposal: market.ClientDealProposal{
+				Proposal: market.DealProposal{
+					PieceSize:            1 << 30,
+					Client:               mock.Address(1000),
+					Provider:             mock.Address(1001),
+					Label:                label,
+					StartEpoch:           1000,
+					EndEpoch:             1000 + 200*builtin.EpochsInDay,
+					StoragePricePerEpoch: big.NewInt(1000),
+					ProviderCollateral:   big.Zero(),
+					ClientCollateral:     big.Zero(),
+				},
+			},
+		}
+	}
+
+	validate := func(deal storagemarket.MinerDeal) *Rejection {
+		r, err := ValidateProposal(ctx, spn, deal)
+		require.NoError(t, err)
+		return r
+	}
+
+	require.Nil(t, validate(valid()))
+
+	for _, tc := range []struct {
+		name   string
+		modify func(d *storagemarket.MinerDeal)
+		code   string
+	}{
+		{"unaligned piece size", func(d *storagemarket.MinerDeal) { d.Proposal.PieceSize = 1000 }, RejectPieceSize},
+		{"piece larger than sector", func(d *storagemarket.MinerDeal) { d.Proposal.PieceSize = 64 << 30 }, RejectPieceSize},
+		{"label too long", func(d *storagemarket.MinerDeal) {
+			var buf bytes.Buffer
+			require.NoError(t, cbg.WriteMajorTypeHeader(&buf, cbg.MajTextString, market.DealMaxLabelSize+1))
+			buf.WriteString(strings.Repeat("x", market.DealMaxLabelSize+1))
+			require.NoError(t, d.Proposal.Label.UnmarshalCBOR(&buf))
+		}, RejectLabel},
+		{"end before start", func(d *storagemarket.MinerDeal) { d.Proposal.EndEpoch = d.Proposal.StartEpoch }, RejectDuration},
+		{"too short", func(d *storagemarket.MinerDeal) { d.Proposal.EndEpoch = d.Proposal.StartEpoch + builtin.EpochsInDay }, RejectDuration},
+		{"too long", func(d *storagemarket.MinerDeal) {
+			d.Proposal.EndEpoch = d.Proposal.StartEpoch + 2000*builtin.EpochsInDay
+		}, RejectDuration},
+		{"insufficient funds", func(d *storagemarket.MinerDeal) { d.Proposal.StoragePricePerEpoch = types.FromFil(1) }, RejectFunds},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := valid()
+			tc.modify(&d)
+
+			r := validate(d)
+			require.NotNil(t, r)
+			require.Equal(t, tc.code, r.Code)
+			require.True(t, strings.HasPrefix(r.String(), tc.code+": "))
+		})
+	}
+}
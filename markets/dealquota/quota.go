@@ -0,0 +1,345 @@
+// Package dealquota enforces per-client limits on the storage deals accepted
+// by a provider.
+package dealquota
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("dealquota")
+
+// Window is the period over which the accepted deal bytes are limited.
+const Window = 24 * time.Hour
+
+// Quota limits the deals of a client. Zero values are unlimited.
+type Quota struct {
+	// MaxOpenDeals is the number of deals which can be in progress, from
+	// acceptance until they are active or fail.
+	MaxOpenDeals uint64
+	// MaxBytesPerDay is the padded size of the deals which can be accepted
+	// within Window.
+	MaxBytesPerDay uint64
+}
+
+// StateAPI is the node API used to resolve client addresses.
+type StateAPI interface {
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+}
+
+type acceptedDeal struct {
+	proposal cid.Cid
+	at       time.Time
+	size     abi.PaddedPieceSize
+}
+
+type clientDeals struct {
+	open     map[cid.Cid]struct{}
+	accepted []acceptedDeal
+}
+
+// Enforcer tracks the open and recently accepted deals of each client, and
+// rejects the deals which would exceed the client's quota.
+type Enforcer struct {
+	api     StateAPI
+	def     Quota
+	clients map[address.Address]Quota
+
+	lk sync.Mutex
+	// ids caches the ID addresses of the clients; the deals are keyed by
+	// them, so that a client can't get another quota by switching between
+	// its ID and robust addresses
+	ids   map[address.Address]address.Address
+	deals map[address.Address]*clientDeals
+}
+
+// NewEnforcer creates an enforcer applying the given quotas to the listed
+// clients, and def to all other clients. Clients are matched by their ID
+// address, whichever address is used in the deal proposal or the quotas.
+func NewEnforcer(api StateAPI, def Quota, clients map[address.Address]Quota) *Enforcer {
+	return &Enforcer{
+		api:     api,
+		def:     def,
+		clients: clients,
+		ids:     map[address.Address]address.Address{},
+		deals:   map[address.Address]*clientDeals{},
+	}
+}
+
+// resolve returns the ID address of a client.
+func (e *Enforcer) resolve(ctx context.Context, client address.Address) (address.Address, error) {
+	if client.Protocol() == address.ID {
+		return client, nil
+	}
+
+	e.lk.Lock()
+	id, ok := e.ids[client]
+	e.lk.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := e.api.StateLookupID(ctx, client, types.EmptyTSK)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("looking up the ID address of client %s: %w", client, err)
+	}
+
+	e.lk.Lock()
+	e.ids[client] = id
+	e.lk.Unlock()
+
+	return id, nil
+}
+
+// resolveQuotas resolves the clients with a quota of their own. The clients
+// which aren't on chain yet are resolved again later.
+func (e *Enforcer) resolveQuotas(ctx context.Context) {
+	for c := range e.clients {
+		if _, err := e.resolve(ctx, c); err != nil {
+			log.Debugw("resolving client with a quota", "client", c, "error", err)
+		}
+	}
+}
+
+// cachedID returns the ID address of a client if it was resolved before,
+// and the client address otherwise.
+func (e *Enforcer) cachedID(client address.Address) address.Address {
+	if id, ok := e.ids[client]; ok {
+		return id
+	}
+	return client
+}
+
+func (e *Enforcer) quota(id address.Address) Quota {
+	for c, q := range e.clients {
+		if e.cachedID(c) == id {
+			return q
+		}
+	}
+	return e.def
+}
+
+// Load seeds the enforcer with the deals known to the provider.
+func (e *Enforcer) Load(ctx context.Context, deals []storagemarket.MinerDeal) {
+	e.resolveQuotas(ctx)
+
+	clients := map[address.Address]address.Address{}
+	for _, d := range deals {
+		c := d.Proposal.Client
+		if _, ok := clients[c]; ok {
+			continue
+		}
+
+		id, err := e.resolve(ctx, c)
+		if err != nil {
+			log.Warnw("resolving deal client", "client", c, "proposal", d.ProposalCid, "error", err)
+			id = c
+		}
+		clients[c] = id
+	}
+
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	cutoff := build.Clock.Now().Add(-Window)
+	for _, d := range deals {
+		if isClosed(d.State) && d.State != storagemarket.StorageDealActive {
+			// failed deals don't count towards the daily limit either
+			continue
+		}
+
+		cd := e.clientDeals(clients[d.Proposal.Client])
+		if !isClosed(d.State) && isAccepted(d.State) {
+			cd.open[d.ProposalCid] = struct{}{}
+		}
+		if created := d.CreationTime.Time(); created.After(cutoff) && isAccepted(d.State) {
+			cd.accepted = append(cd.accepted, acceptedDeal{proposal: d.ProposalCid, at: created, size: d.Proposal.PieceSize})
+		}
+	}
+
+	for _, cd := range e.deals {
+		sort.Slice(cd.accepted, func(i, j int) bool { return cd.accepted[i].at.Before(cd.accepted[j].at) })
+	}
+}
+
+// Reserve accepts the deal if it fits in the client's quota, and counts it
+// against the quota. It's a storage deal filter, and must run after all the
+// other filters accepted the deal.
+func (e *Enforcer) Reserve(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+	e.resolveQuotas(ctx)
+
+	client, err := e.resolve(ctx, deal.Proposal.Client)
+	if err != nil {
+		return false, "miner error", err
+	}
+
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	q := e.quota(client)
+	cd := e.clientDeals(client)
+
+	if _, ok := cd.open[deal.ProposalCid]; ok {
+		// already reserved, e.g. the filter runs again after a restart
+		return true, "", nil
+	}
+
+	now := build.Clock.Now()
+	cd.prune(now)
+
+	if q.MaxOpenDeals > 0 && uint64(len(cd.open)) >= q.MaxOpenDeals {
+		log.Warnw("rejecting storage deal, client has too many open deals", "client", client, "proposal", deal.ProposalCid, "open", len(cd.open), "max", q.MaxOpenDeals)
+		return false, fmt.Sprintf("client has too many deals in progress (limit %d)", q.MaxOpenDeals), nil
+	}
+
+	if q.MaxBytesPerDay > 0 && cd.acceptedBytes()+uint64(deal.Proposal.PieceSize) > q.MaxBytesPerDay {
+		log.Warnw("rejecting storage deal, client exceeded daily quota", "client", client, "proposal", deal.ProposalCid, "accepted", cd.acceptedBytes(), "size", deal.Proposal.PieceSize, "max", q.MaxBytesPerDay)
+		return false, fmt.Sprintf("deal would exceed the client's daily limit of %d bytes", q.MaxBytesPerDay), nil
+	}
+
+	cd.open[deal.ProposalCid] = struct{}{}
+	cd.accepted = append(cd.accepted, acceptedDeal{proposal: deal.ProposalCid, at: now, size: deal.Proposal.PieceSize})
+
+	return true, "", nil
+}
+
+// OnDealEvent is a storagemarket.ProviderSubscriber closing the deals which
+// became active or failed.
+func (e *Enforcer) OnDealEvent(_ storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+	if !isClosed(deal.State) {
+		return
+	}
+
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	cd, ok := e.deals[e.cachedID(deal.Proposal.Client)]
+	if !ok {
+		return
+	}
+	delete(cd.open, deal.ProposalCid)
+
+	if deal.State != storagemarket.StorageDealActive {
+		// deals which failed no longer count towards the daily limit
+		for i, a := range cd.accepted {
+			if a.proposal == deal.ProposalCid {
+				cd.accepted = append(cd.accepted[:i:i], cd.accepted[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Usage returns the quotas and usage of all clients with deals in the quota
+// window, or with a quota of their own.
+func (e *Enforcer) Usage() []api.ClientQuotaUsage {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+
+	now := build.Clock.Now()
+
+	clients := map[address.Address]struct{}{}
+	for c := range e.clients {
+		clients[e.cachedID(c)] = struct{}{}
+	}
+	for c, cd := range e.deals {
+		cd.prune(now)
+		if len(cd.open) > 0 || len(cd.accepted) > 0 {
+			clients[c] = struct{}{}
+		}
+	}
+
+	out := make([]api.ClientQuotaUsage, 0, len(clients))
+	for c := range clients {
+		q := e.quota(c)
+		u := api.ClientQuotaUsage{
+			Client:         c,
+			MaxOpenDeals:   q.MaxOpenDeals,
+			MaxBytesPerDay: q.MaxBytesPerDay,
+		}
+		if cd, ok := e.deals[c]; ok {
+			u.OpenDeals = uint64(len(cd.open))
+			u.BytesLastDay = cd.acceptedBytes()
+		}
+		out = append(out, u)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Client.String() < out[j].Client.String()
+	})
+	return out
+}
+
+func (e *Enforcer) clientDeals(client address.Address) *clientDeals {
+	cd, ok := e.deals[client]
+	if !ok {
+		cd = &clientDeals{open: map[cid.Cid]struct{}{}}
+		e.deals[client] = cd
+	}
+	return cd
+}
+
+// prune drops the deals accepted before the quota window.
+func (cd *clientDeals) prune(now time.Time) {
+	cutoff := now.Add(-Window)
+
+	i := 0
+	for i < len(cd.accepted) && !cd.accepted[i].at.After(cutoff) {
+		i++
+	}
+	cd.accepted = cd.accepted[i:]
+}
+
+func (cd *clientDeals) acceptedBytes() uint64 {
+	var total uint64
+	for _, a := range cd.accepted {
+		total += uint64(a.size)
+	}
+	return total
+}
+
+// isClosed returns whether a deal in the given state no longer takes up the
+// client's open deals quota.
+func isClosed(state storagemarket.StorageDealStatus) bool {
+	switch state {
+	case storagemarket.StorageDealActive,
+		storagemarket.StorageDealExpired,
+		storagemarket.StorageDealSlashed,
+		storagemarket.StorageDealRejecting,
+		storagemarket.StorageDealProposalRejected,
+		storagemarket.StorageDealProposalNotFound,
+		storagemarket.StorageDealFailing,
+		storagemarket.StorageDealError:
+		return true
+	default:
+		return false
+	}
+}
+
+// isAccepted returns whether a deal in the given state was accepted by the
+// deal filters.
+func isAccepted(state storagemarket.StorageDealStatus) bool {
+	switch state {
+	case storagemarket.StorageDealUnknown,
+		storagemarket.StorageDealValidating,
+		storagemarket.StorageDealAcceptWait:
+		return false
+	default:
+		return true
+	}
+}
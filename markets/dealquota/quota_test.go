@@ -0,0 +1,219 @@
+package dealquota
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type lookupAPI map[address.Address]address.Address
+
+func (l lookupAPI) StateLookupID(ctx context.Context, a address.Address, tsk types.TipSetKey) (address.Address, error) {
+	id, ok := l[a]
+	if !ok {
+		return address.Undef, xerrors.Errorf("actor not found")
+	}
+	return id, nil
+}
+
+func testDeal(client address.Address, n int, size abi.PaddedPieceSize, state storagemarket.StorageDealStatus) storagemarket.MinerDeal {
+	d := storagemarket.MinerDeal{
+		ClientDealProposal: market.ClientDealProposal{
+			Proposal: market.DealProposal{
+				Client:     client,
+				PieceSize:  size,
+				StartEpoch: abi.ChainEpoch(n),
+			},
+		},
+		State: state,
+	}
+	d.ProposalCid = blocks.NewBlock([]byte(fmt.Sprintf("%s-%d", client, n))).Cid()
+	return d
+}
+
+func TestEnforcer(t *testing.T) {
+	ctx := context.Background()
+
+	limited := mock.Address(1000)
+	trusted := mock.Address(1001)
+	other := mock.Address(1002)
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+	e := NewEnforcer(lookupAPI{}, Quota{MaxOpenDeals: 2, MaxBytesPerDay: 3 << 30}, map[address.Address]Quota{
+		trusted: {},
+	})
+
+	reserve := func(d storagemarket.MinerDeal) bool {
+		ok, reason, err := e.Reserve(ctx, d)
+		require.NoError(t, err)
+		if !ok {
+			require.NotEmpty(t, reason)
+		}
+		return ok
+	}
+
+	d1 := testDeal(limited, 1, 1<<30, storagemarket.StorageDealAcceptWait)
+	d2 := testDeal(limited, 2, 1<<30, storagemarket.StorageDealAcceptWait)
+	d3 := testDeal(limited, 3, 1<<30, storagemarket.StorageDealAcceptWait)
+
+	require.True(t, reserve(d1))
+	require.True(t, reserve(d1), "reserving a deal again is a no-op")
+	require.True(t, reserve(d2))
+	require.False(t, reserve(d3), "too many open deals")
+
+	// other clients have their own quota
+	require.True(t, reserve(testDeal(other, 1, 1<<30, storagemarket.StorageDealAcceptWait)))
+
+	// clients with an unlimited quota
+	for i := 0; i < 10; i++ {
+		require.True(t, reserve(testDeal(trusted, i, 32<<30, storagemarket.StorageDealAcceptWait)))
+	}
+
+	// an active deal frees an open deal slot, but still counts towards the
+	// daily limit
+	d1.State = storagemarket.StorageDealActive
+	e.OnDealEvent(storagemarket.ProviderEventDealActivated, d1)
+	require.True(t, reserve(d3))
+
+	d2.State = storagemarket.StorageDealFailing
+	e.OnDealEvent(storagemarket.ProviderEventFailed, d2)
+	d3.State = storagemarket.StorageDealActive
+	e.OnDealEvent(storagemarket.ProviderEventDealActivated, d3)
+
+	// d1 and d3 are still within the daily limit, d2 failed
+	require.False(t, reserve(testDeal(limited, 4, 2<<30, storagemarket.StorageDealAcceptWait)))
+	require.True(t, reserve(testDeal(limited, 5, 1<<30, storagemarket.StorageDealAcceptWait)))
+
+	usage := e.Usage()
+	require.Len(t, usage, 3)
+	require.Equal(t, limited, usage[0].Client)
+	require.EqualValues(t, 1, usage[0].OpenDeals)
+	require.EqualValues(t, 3<<30, usage[0].BytesLastDay)
+	require.EqualValues(t, 3<<30, usage[0].MaxBytesPerDay)
+	require.Equal(t, trusted, usage[1].Client)
+	require.EqualValues(t, 10, usage[1].OpenDeals)
+	require.Zero(t, usage[1].MaxOpenDeals)
+
+	// the daily limit frees up after a day
+	mc.Add(Window)
+	require.True(t, reserve(testDeal(limited, 6, 2<<30, storagemarket.StorageDealAcceptWait)))
+}
+
+func TestEnforcerLoad(t *testing.T) {
+	ctx := context.Background()
+
+	client := mock.Address(1000)
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+	now := mc.Now()
+
+	e := NewEnforcer(lookupAPI{}, Quota{MaxOpenDeals: 2, MaxBytesPerDay: 4 << 30}, nil)
+
+	deal := func(n int, state storagemarket.StorageDealStatus, created time.Time) storagemarket.MinerDeal {
+		d := testDeal(client, n, 1<<30, state)
+		d.CreationTime = cbg.CborTime(created)
+		return d
+	}
+
+	open := deal(1, storagemarket.StorageDealSealing, now.Add(-48*time.Hour))
+	pending := deal(2, storagemarket.StorageDealAcceptWait, now.Add(-time.Minute))
+	e.Load(ctx, []storagemarket.MinerDeal{
+		open,
+		pending,
+		deal(3, storagemarket.StorageDealActive, now.Add(-time.Hour)),
+		deal(4, storagemarket.StorageDealError, now.Add(-time.Hour)),
+		deal(5, storagemarket.StorageDealActive, now.Add(-48*time.Hour)),
+	})
+
+	usage := e.Usage()
+	require.Len(t, usage, 1)
+	require.EqualValues(t, 1, usage[0].OpenDeals)
+	require.EqualValues(t, 1<<30, usage[0].BytesLastDay)
+
+	// the deal waiting for acceptance when the node stopped goes through the
+	// filters again
+	ok, _, err := e.Reserve(ctx, pending)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, _, err = e.Reserve(ctx, deal(6, storagemarket.StorageDealAcceptWait, now))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestEnforcerClientAddresses(t *testing.T) {
+	ctx := context.Background()
+
+	id := mock.Address(1000)
+	robust, err := address.NewActorAddress([]byte("client"))
+	require.NoError(t, err)
+	trustedID := mock.Address(1001)
+	trusted, err := address.NewActorAddress([]byte("trusted"))
+	require.NoError(t, err)
+	unknown, err := address.NewActorAddress([]byte("unknown"))
+	require.NoError(t, err)
+
+	e := NewEnforcer(lookupAPI{robust: id, trusted: trustedID}, Quota{MaxOpenDeals: 2}, map[address.Address]Quota{
+		trusted: {},
+	})
+
+	reserve := func(d storagemarket.MinerDeal) bool {
+		ok, _, err := e.Reserve(ctx, d)
+		require.NoError(t, err)
+		return ok
+	}
+
+	// both addresses of a client share its quota
+	d1 := testDeal(id, 1, 1<<30, storagemarket.StorageDealAcceptWait)
+	require.True(t, reserve(d1))
+	require.True(t, reserve(testDeal(robust, 2, 1<<30, storagemarket.StorageDealAcceptWait)))
+	require.False(t, reserve(testDeal(robust, 3, 1<<30, storagemarket.StorageDealAcceptWait)))
+	require.False(t, reserve(testDeal(id, 4, 1<<30, storagemarket.StorageDealAcceptWait)))
+
+	// and deals closed under the other address free it up
+	d1.Proposal.Client = robust
+	d1.State = storagemarket.StorageDealActive
+	e.OnDealEvent(storagemarket.ProviderEventDealActivated, d1)
+	require.True(t, reserve(testDeal(id, 5, 1<<30, storagemarket.StorageDealAcceptWait)))
+
+	// quotas configured with a robust address apply to the ID address
+	for i := 0; i < 5; i++ {
+		require.True(t, reserve(testDeal(trustedID, i, 1<<30, storagemarket.StorageDealAcceptWait)))
+	}
+
+	usage := e.Usage()
+	require.Len(t, usage, 2)
+	require.Equal(t, id, usage[0].Client)
+	require.EqualValues(t, 2, usage[0].OpenDeals)
+	require.Equal(t, trustedID, usage[1].Client)
+	require.EqualValues(t, 5, usage[1].OpenDeals)
+
+	// deals from clients which can't be resolved are rejected
+	ok, _, err := e.Reserve(ctx, testDeal(unknown, 1, 1<<30, storagemarket.StorageDealAcceptWait))
+	require.Error(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,219 @@
+// Package dealwebhook posts the lifecycle events of storage deals to webhooks.
+package dealwebhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("dealwebhook")
+
+// Lifecycle events of storage deals.
+const (
+	EventAccepted  = "accepted"
+	EventRejected  = "rejected"
+	EventPublished = "published"
+	EventSealed    = "sealed"
+	EventActive    = "active"
+	EventSlashed   = "slashed"
+	EventExpired   = "expired"
+	EventFailed    = "failed"
+)
+
+// Events lists all the lifecycle events.
+var Events = []string{
+	EventAccepted,
+	EventRejected,
+	EventPublished,
+	EventSealed,
+	EventActive,
+	EventSlashed,
+	EventExpired,
+	EventFailed,
+}
+
+// Side values of the payload.
+const (
+	SideClient   = "client"
+	SideProvider = "provider"
+)
+
+var providerEvents = map[storagemarket.ProviderEvent]string{
+	storagemarket.ProviderEventDealAccepted:     EventAccepted,
+	storagemarket.ProviderEventDealRejected:     EventRejected,
+	storagemarket.ProviderEventDealPublished:    EventPublished,
+	storagemarket.ProviderEventDealPrecommitted: EventSealed,
+	storagemarket.ProviderEventDealActivated:    EventActive,
+	storagemarket.ProviderEventDealSlashed:      EventSlashed,
+	storagemarket.ProviderEventDealExpired:      EventExpired,
+	storagemarket.ProviderEventFailed:           EventFailed,
+}
+
+var clientEvents = map[storagemarket.ClientEvent]string{
+	storagemarket.ClientEventDealAccepted:     EventAccepted,
+	storagemarket.ClientEventDealRejected:     EventRejected,
+	storagemarket.ClientEventDealPublished:    EventPublished,
+	storagemarket.ClientEventDealPrecommitted: EventSealed,
+	storagemarket.ClientEventDealActivated:    EventActive,
+	storagemarket.ClientEventDealSlashed:      EventSlashed,
+	storagemarket.ClientEventDealExpired:      EventExpired,
+	storagemarket.ClientEventFailed:           EventFailed,
+}
+
+// Payload is the JSON body posted to deal webhooks
+type Payload struct {
+	Side  string // either 'client' or 'provider'
+	Event string // one of Events
+	State string // the storage market deal state
+
+	ProposalCid cid.Cid
+	DealID      abi.DealID
+	Client      address.Address
+	Provider    address.Address
+	PieceCid    cid.Cid
+	PieceSize   abi.PaddedPieceSize
+	Verified    bool
+	StartEpoch  abi.ChainEpoch
+	EndEpoch    abi.ChainEpoch
+	Message     string
+
+	Time time.Time
+}
+
+// Notifier posts the lifecycle events of deals to a list of URLs. Requests are
+// sent asynchronously; failures are only logged.
+type Notifier struct {
+	urls   []string
+	events map[string]struct{}
+	client *http.Client
+}
+
+// NewNotifier creates a notifier posting the given events to urls. All
+// lifecycle events are posted if events is empty.
+func NewNotifier(urls []string, timeout time.Duration, events []string) (*Notifier, error) {
+	n := &Notifier{
+		urls:   urls,
+		events: map[string]struct{}{},
+		client: &http.Client{Timeout: timeout},
+	}
+
+	if len(events) == 0 {
+		events = Events
+	}
+	for _, e := range events {
+		known := false
+		for _, k := range Events {
+			known = known || e == k
+		}
+		if !known {
+			return nil, xerrors.Errorf("unknown deal event %q, expected one of %v", e, Events)
+		}
+		n.events[e] = struct{}{}
+	}
+
+	return n, nil
+}
+
+// OnProviderEvent is a storagemarket.ProviderSubscriber posting the lifecycle
+// events of provider deals.
+func (n *Notifier) OnProviderEvent(evt storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+	e, ok := providerEvents[evt]
+	if !ok {
+		return
+	}
+
+	n.notify(&Payload{
+		Side:        SideProvider,
+		Event:       e,
+		State:       storagemarket.DealStates[deal.State],
+		ProposalCid: deal.ProposalCid,
+		DealID:      deal.DealID,
+		Client:      deal.Proposal.Client,
+		Provider:    deal.Proposal.Provider,
+		PieceCid:    deal.Proposal.PieceCID,
+		PieceSize:   deal.Proposal.PieceSize,
+		Verified:    deal.Proposal.VerifiedDeal,
+		StartEpoch:  deal.Proposal.StartEpoch,
+		EndEpoch:    deal.Proposal.EndEpoch,
+		Message:     deal.Message,
+	})
+}
+
+// OnClientEvent is a storagemarket.ClientSubscriber posting the lifecycle
+// events of client deals.
+func (n *Notifier) OnClientEvent(evt storagemarket.ClientEvent, deal storagemarket.ClientDeal) {
+	e, ok := clientEvents[evt]
+	if !ok {
+		return
+	}
+
+	n.notify(&Payload{
+		Side:        SideClient,
+		Event:       e,
+		State:       storagemarket.DealStates[deal.State],
+		ProposalCid: deal.ProposalCid,
+		DealID:      deal.DealID,
+		Client:      deal.Proposal.Client,
+		Provider:    deal.Proposal.Provider,
+		PieceCid:    deal.Proposal.PieceCID,
+		PieceSize:   deal.Proposal.PieceSize,
+		Verified:    deal.Proposal.VerifiedDeal,
+		StartEpoch:  deal.Proposal.StartEpoch,
+		EndEpoch:    deal.Proposal.EndEpoch,
+		Message:     deal.Message,
+	})
+}
+
+func (n *Notifier) notify(p *Payload) {
+	if _, ok := n.events[p.Event]; !ok {
+		return
+	}
+	p.Time = build.Clock.Now()
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		log.Errorw("marshaling deal webhook payload failed", "proposal", p.ProposalCid, "event", p.Event, "error", err)
+		return
+	}
+
+	for _, url := range n.urls {
+		go func(url string) {
+			if err := n.post(url, body); err != nil {
+				log.Errorw("sending deal webhook failed", "proposal", p.ProposalCid, "event", p.Event, "url", url, "error", err)
+			}
+		}(url)
+	}
+}
+
+func (n *Notifier) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return nil
+}
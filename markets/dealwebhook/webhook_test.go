@@ -0,0 +1,71 @@
+package dealwebhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestNotifier(t *testing.T) {
+	received := make(chan Payload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Payload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		received <- p
+	}))
+	defer srv.Close()
+
+	_, err := NewNotifier([]string{srv.URL}, time.Second, []string{"sealing"})
+	require.Error(t, err)
+
+	n, err := NewNotifier([]string{srv.URL}, time.Second, []string{EventPublished, EventActive})
+	require.NoError(t, err)
+
+	deal := storagemarket.MinerDeal{
+		ClientDealProposal: market.ClientDealProposal{
+			Proposal: market.DealProposal{
+				PieceCID: blocks.NewBlock([]byte("piece")).Cid(),
+				Client:   mock.Address(1000),
+				Provider: mock.Address(1001),
+			},
+		},
+		ProposalCid: blocks.NewBlock([]byte("proposal")).Cid(),
+		DealID:      5,
+	}
+
+	// not a lifecycle event
+	n.OnProviderEvent(storagemarket.ProviderEventDataTransferInitiated, deal)
+	// not one of the configured events
+	n.OnProviderEvent(storagemarket.ProviderEventDealAccepted, deal)
+
+	deal.State = storagemarket.StorageDealActive
+	n.OnProviderEvent(storagemarket.ProviderEventDealActivated, deal)
+
+	select {
+	case p := <-received:
+		require.Equal(t, SideProvider, p.Side)
+		require.Equal(t, EventActive, p.Event)
+		require.Equal(t, "StorageDealActive", p.State)
+		require.Equal(t, deal.ProposalCid, p.ProposalCid)
+		require.EqualValues(t, 5, p.DealID)
+		require.Equal(t, deal.Proposal.Client, p.Client)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+
+	select {
+	case p := <-received:
+		t.Fatalf("unexpected webhook call for %s", p.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
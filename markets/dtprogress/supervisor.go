@@ -0,0 +1,152 @@
+package dtprogress
+
+import (
+	"context"
+	"time"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+// SupervisorConfig configures the restarting of stalled data transfers.
+type SupervisorConfig struct {
+	// StallTimeout is how long a transfer can go without progress before it's
+	// restarted.
+	StallTimeout time.Duration
+	// MaxRestarts is how many times a transfer is restarted without making
+	// progress in between, before the supervisor gives up on it.
+	MaxRestarts int
+	// CheckInterval is how often the transfers are checked.
+	CheckInterval time.Duration
+}
+
+type restartState struct {
+	restarts    int
+	lastRestart time.Time
+	gaveUp      bool
+}
+
+// Supervisor restarts the data transfers which made no progress for a while.
+// The channel monitor of go-data-transfer only restarts channels after
+// errors; transfers which stall without an error would otherwise stay stuck
+// until restarted manually.
+type Supervisor struct {
+	cfg     SupervisorConfig
+	tracker *Tracker
+	dt      datatransfer.Manager
+
+	// only accessed from the run loop
+	state map[datatransfer.ChannelID]*restartState
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func NewSupervisor(cfg SupervisorConfig, tracker *Tracker, dt datatransfer.Manager) *Supervisor {
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = time.Minute
+	}
+
+	return &Supervisor{
+		cfg:     cfg,
+		tracker: tracker,
+		dt:      dt,
+		state:   map[datatransfer.ChannelID]*restartState{},
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *Supervisor) Start(ctx context.Context) {
+	go s.run(ctx)
+}
+
+func (s *Supervisor) Stop() {
+	close(s.stop)
+	<-s.stopped
+}
+
+func (s *Supervisor) run(ctx context.Context) {
+	defer close(s.stopped)
+
+	t := build.Clock.Ticker(s.cfg.CheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			s.check(ctx)
+		case <-s.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check restarts the stalled transfers, and returns the channels it
+// restarted.
+func (s *Supervisor) check(ctx context.Context) []datatransfer.ChannelID {
+	now := build.Clock.Now()
+
+	var restarted []datatransfer.ChannelID
+	seen := map[datatransfer.ChannelID]struct{}{}
+
+	for _, p := range s.tracker.List() {
+		seen[p.ChannelID] = struct{}{}
+
+		// paused and finalizing transfers are expected not to make progress
+		if p.Status != datatransfer.Ongoing {
+			continue
+		}
+
+		st, ok := s.state[p.ChannelID]
+		if !ok {
+			st = &restartState{}
+			s.state[p.ChannelID] = st
+		}
+
+		since := p.LastProgress
+		if st.lastRestart.After(since) {
+			since = st.lastRestart
+		} else {
+			// progress since the last restart
+			st.restarts = 0
+			st.gaveUp = false
+		}
+
+		if now.Sub(since) < s.cfg.StallTimeout {
+			continue
+		}
+
+		if st.restarts >= s.cfg.MaxRestarts {
+			if !st.gaveUp {
+				log.Errorw("data transfer still stalled after restarting it, not restarting it again",
+					"channel", p.ChannelID, "peer", p.OtherPeer, "restarts", st.restarts, "last-progress", p.LastProgress)
+				st.gaveUp = true
+			}
+			continue
+		}
+
+		st.restarts++
+		st.lastRestart = now
+
+		log.Warnw("restarting stalled data transfer",
+			"channel", p.ChannelID, "peer", p.OtherPeer, "attempt", st.restarts, "last-progress", p.LastProgress, "transferred", p.Transferred)
+
+		if err := s.dt.RestartDataTransferChannel(ctx, p.ChannelID); err != nil {
+			log.Errorw("failed to restart stalled data transfer", "channel", p.ChannelID, "error", err)
+			continue
+		}
+		restarted = append(restarted, p.ChannelID)
+	}
+
+	for id := range s.state {
+		if _, ok := seen[id]; !ok {
+			delete(s.state, id)
+		}
+	}
+
+	return restarted
+}
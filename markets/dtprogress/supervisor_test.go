@@ -0,0 +1,86 @@
+package dtprogress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-data-transfer/v2/testutil"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+type restartingManager struct {
+	datatransfer.Manager
+
+	restarted []datatransfer.ChannelID
+}
+
+func (m *restartingManager) RestartDataTransferChannel(ctx context.Context, chid datatransfer.ChannelID) error {
+	m.restarted = append(m.restarted, chid)
+	return nil
+}
+
+func TestSupervisor(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(1000, 0))
+	build.Clock = mc
+
+	self := peer.ID("self")
+	chid := datatransfer.ChannelID{Initiator: peer.ID("client"), Responder: self, ID: 1}
+
+	tr := NewTracker()
+
+	dt := &restartingManager{}
+	s := NewSupervisor(SupervisorConfig{StallTimeout: 10 * time.Minute, MaxRestarts: 2}, tr, dt)
+
+	st := channelState{testutil.NewMockChannelState(testutil.MockChannelStateParams{
+		ChannelID: chid,
+		Self:      self,
+	})}
+	progress := func() {
+		st.SetReceived(st.Received() + 1<<20)
+		tr.OnEvent(datatransfer.Event{Code: datatransfer.DataReceivedProgress}, st)
+	}
+
+	progress()
+
+	mc.Add(9 * time.Minute)
+	require.Empty(t, s.check(ctx))
+
+	// stalled for longer than the timeout
+	mc.Add(2 * time.Minute)
+	require.Equal(t, []datatransfer.ChannelID{chid}, s.check(ctx))
+
+	// not restarted again until it stalls for another timeout
+	mc.Add(5 * time.Minute)
+	require.Empty(t, s.check(ctx))
+	mc.Add(5 * time.Minute)
+	require.Equal(t, []datatransfer.ChannelID{chid}, s.check(ctx))
+
+	// gives up after the maximum number of restarts
+	mc.Add(time.Hour)
+	require.Empty(t, s.check(ctx))
+	require.Len(t, dt.restarted, 2)
+
+	// progress resets the restart count
+	progress()
+	mc.Add(11 * time.Minute)
+	require.Equal(t, []datatransfer.ChannelID{chid}, s.check(ctx))
+
+	// finished transfers are forgotten
+	st.SetComplete(true)
+	tr.OnEvent(datatransfer.Event{Code: datatransfer.Complete}, st)
+	mc.Add(time.Hour)
+	require.Empty(t, s.check(ctx))
+	require.Empty(t, s.state)
+}
@@ -0,0 +1,177 @@
+// Package dtprogress tracks the progress of data transfers, so that slow and
+// stuck transfers can be told apart.
+package dtprogress
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("dtprogress")
+
+// rateTimeConstant is the time constant of the moving average of the
+// transfer rate: progress made longer ago than this barely affects the rate.
+const rateTimeConstant = 10 * time.Second
+
+// ChannelProgress is a snapshot of the progress of a data transfer channel.
+type ChannelProgress struct {
+	ChannelID datatransfer.ChannelID
+	Status    datatransfer.Status
+	IsSender  bool
+	OtherPeer peer.ID
+	Message   string
+
+	// Transferred is the number of bytes sent or received so far, and
+	// TotalSize the size of the transfer if known, or 0.
+	Transferred uint64
+	TotalSize   uint64
+	// Rate is the recent transfer rate in bytes per second.
+	Rate uint64
+	// Restarts counts the restarts of the channel since the node started.
+	Restarts uint64
+
+	// Started is when the channel was first seen by the tracker, and
+	// LastProgress when the last data was transferred, or Started if no data
+	// was transferred yet.
+	Started      time.Time
+	LastProgress time.Time
+}
+
+type channel struct {
+	ChannelProgress
+
+	rate       float64
+	rateSample time.Time
+}
+
+// Tracker follows the events of a data transfer manager and keeps track of
+// the progress of the open channels. The state is only kept in memory.
+type Tracker struct {
+	lk       sync.Mutex
+	channels map[datatransfer.ChannelID]*channel
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		channels: map[datatransfer.ChannelID]*channel{},
+	}
+}
+
+// OnEvent is a datatransfer.Subscriber updating the tracked channels.
+func (t *Tracker) OnEvent(evt datatransfer.Event, st datatransfer.ChannelState) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	id := st.ChannelID()
+
+	switch st.Status() {
+	case datatransfer.Completed, datatransfer.Failed, datatransfer.Cancelled:
+		delete(t.channels, id)
+		return
+	}
+
+	now := build.Clock.Now()
+
+	c, ok := t.channels[id]
+	if !ok {
+		c = &channel{
+			ChannelProgress: ChannelProgress{
+				ChannelID:    id,
+				IsSender:     st.Sender() == st.SelfPeer(),
+				OtherPeer:    st.OtherPeer(),
+				Started:      now,
+				LastProgress: now,
+			},
+			rateSample: now,
+		}
+		t.channels[id] = c
+	}
+
+	c.Status = st.Status()
+	c.Message = st.Message()
+	c.TotalSize = st.TotalSize()
+
+	transferred := st.Received()
+	if c.IsSender {
+		transferred = st.Sent()
+	}
+
+	switch evt.Code {
+	case datatransfer.Restart:
+		c.Restarts++
+		log.Debugw("data transfer restarted", "channel", id, "restarts", c.Restarts)
+	case datatransfer.DataReceivedProgress, datatransfer.DataSentProgress:
+		if transferred > c.Transferred {
+			c.sampleRate(now, transferred-c.Transferred)
+			c.LastProgress = now
+		}
+	}
+
+	c.Transferred = transferred
+}
+
+// sampleRate folds the bytes transferred since the previous sample into the
+// moving average of the transfer rate.
+func (c *channel) sampleRate(now time.Time, bytes uint64) {
+	dt := now.Sub(c.rateSample).Seconds()
+	if dt <= 0 {
+		// several events at once; treat them as one sample
+		dt = 1e-3
+	}
+
+	w := 1 - math.Exp(-dt/rateTimeConstant.Seconds())
+	c.rate += w * (float64(bytes)/dt - c.rate)
+	c.rateSample = now
+}
+
+// Progress returns the progress of the given channel.
+func (t *Tracker) Progress(id datatransfer.ChannelID) (ChannelProgress, bool) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	c, ok := t.channels[id]
+	if !ok {
+		return ChannelProgress{}, false
+	}
+	return c.snapshot(build.Clock.Now()), true
+}
+
+// List returns the progress of all the tracked channels, oldest first.
+func (t *Tracker) List() []ChannelProgress {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := build.Clock.Now()
+	out := make([]ChannelProgress, 0, len(t.channels))
+	for _, c := range t.channels {
+		out = append(out, c.snapshot(now))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Started.Before(out[j].Started)
+	})
+	return out
+}
+
+func (c *channel) snapshot(now time.Time) ChannelProgress {
+	p := c.ChannelProgress
+
+	// the average is only updated when data arrives, decay it over the time
+	// without progress so that stalled transfers show a falling rate
+	idle := now.Sub(c.rateSample).Seconds()
+	if idle < 0 {
+		idle = 0
+	}
+	p.Rate = uint64(c.rate * math.Exp(-idle/rateTimeConstant.Seconds()))
+
+	return p
+}
@@ -0,0 +1,91 @@
+package dtprogress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-data-transfer/v2/testutil"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+// channelState fills in the methods the mock channel state doesn't implement.
+type channelState struct {
+	*testutil.MockChannelState
+}
+
+func (channelState) Message() string   { return "" }
+func (channelState) TotalSize() uint64 { return 0 }
+
+func TestTracker(t *testing.T) {
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(1000, 0))
+	build.Clock = mc
+
+	self := peer.ID("self")
+	client := peer.ID("client")
+	chid := datatransfer.ChannelID{Initiator: client, Responder: self, ID: 1}
+
+	tr := NewTracker()
+
+	// the client pushes data to us
+	st := channelState{testutil.NewMockChannelState(testutil.MockChannelStateParams{
+		ChannelID: chid,
+		Self:      self,
+	})}
+
+	tr.OnEvent(datatransfer.Event{Code: datatransfer.Open}, st)
+
+	p, ok := tr.Progress(chid)
+	require.True(t, ok)
+	require.False(t, p.IsSender)
+	require.Equal(t, client, p.OtherPeer)
+	require.Equal(t, mc.Now(), p.Started)
+	require.Zero(t, p.Transferred)
+	require.Zero(t, p.Rate)
+
+	// a steady 1MiB/s for a minute
+	for i := 0; i < 60; i++ {
+		mc.Add(time.Second)
+		st.SetReceived(st.Received() + 1<<20)
+		tr.OnEvent(datatransfer.Event{Code: datatransfer.DataReceivedProgress}, st)
+	}
+
+	p, ok = tr.Progress(chid)
+	require.True(t, ok)
+	require.EqualValues(t, 60<<20, p.Transferred)
+	require.Equal(t, mc.Now(), p.LastProgress)
+	require.InDelta(t, 1<<20, p.Rate, 1<<14)
+
+	// restarts are counted, and don't count as progress
+	mc.Add(time.Second)
+	tr.OnEvent(datatransfer.Event{Code: datatransfer.Restart}, st)
+
+	p, _ = tr.Progress(chid)
+	require.EqualValues(t, 1, p.Restarts)
+	require.Equal(t, mc.Now().Add(-time.Second), p.LastProgress)
+
+	// the rate decays while the transfer is stalled
+	mc.Add(time.Minute)
+	p, _ = tr.Progress(chid)
+	require.Less(t, p.Rate, uint64(1<<20)/100)
+
+	list := tr.List()
+	require.Len(t, list, 1)
+	require.Equal(t, chid, list[0].ChannelID)
+
+	// finished channels are dropped
+	st.SetComplete(true)
+	tr.OnEvent(datatransfer.Event{Code: datatransfer.Complete}, st)
+
+	_, ok = tr.Progress(chid)
+	require.False(t, ok)
+	require.Empty(t, tr.List())
+}
@@ -0,0 +1,284 @@
+// Package idxannounce announces the pieces of active storage deals to an
+// external content indexer over HTTP.
+package idxannounce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("idxannounce")
+
+const (
+	minRetryDelay = time.Minute
+	maxRetryDelay = time.Hour
+	pollInterval  = 10 * time.Second
+	postTimeout   = 30 * time.Second
+)
+
+type Config struct {
+	// Endpoint is the URL the announcements are POSTed to as JSON.
+	Endpoint string
+	// MaxAttempts is how many times an announcement is tried before it's
+	// dropped.
+	MaxAttempts int
+}
+
+// Announcer delivers piece announcements to the indexer endpoint. Queued
+// announcements are kept in the datastore, so they survive restarts, and
+// failed deliveries are retried with an exponential backoff.
+type Announcer struct {
+	cfg    Config
+	ds     datastore.Batching
+	client *http.Client
+
+	announcement func(deal storagemarket.MinerDeal) api.PieceAnnouncement
+
+	lk sync.Mutex
+
+	wake    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewAnnouncer creates an announcer storing its queue in ds. announcement
+// builds the announcement of a deal.
+func NewAnnouncer(cfg Config, ds datastore.Batching, announcement func(deal storagemarket.MinerDeal) api.PieceAnnouncement) *Announcer {
+	return &Announcer{
+		cfg:          cfg,
+		ds:           ds,
+		client:       &http.Client{Timeout: postTimeout},
+		announcement: announcement,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+}
+
+func (a *Announcer) Start(ctx context.Context) {
+	go a.run(ctx)
+}
+
+func (a *Announcer) Stop() {
+	close(a.stop)
+	<-a.stopped
+}
+
+// OnDealEvent is a storagemarket.ProviderSubscriber queueing the
+// announcement of the deals which became active.
+func (a *Announcer) OnDealEvent(evt storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+	if evt != storagemarket.ProviderEventDealActivated {
+		return
+	}
+
+	if err := a.Enqueue(context.TODO(), deal); err != nil {
+		log.Errorw("failed to queue piece announcement", "proposal", deal.ProposalCid, "error", err)
+	}
+}
+
+// Enqueue queues the announcement of a deal, replacing any queued
+// announcement of the same deal.
+func (a *Announcer) Enqueue(ctx context.Context, deal storagemarket.MinerDeal) error {
+	p := api.PendingPieceAnnouncement{
+		Announcement: a.announcement(deal),
+		NextAttempt:  build.Clock.Now(),
+	}
+
+	a.lk.Lock()
+	err := a.put(ctx, p)
+	a.lk.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Queue lists the queued announcements, the ones due first first.
+func (a *Announcer) Queue(ctx context.Context) ([]api.PendingPieceAnnouncement, error) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	return a.list(ctx)
+}
+
+func (a *Announcer) run(ctx context.Context) {
+	defer close(a.stopped)
+
+	t := build.Clock.Ticker(pollInterval)
+	defer t.Stop()
+
+	for {
+		a.deliverDue(ctx)
+
+		select {
+		case <-a.wake:
+		case <-t.C:
+		case <-a.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverDue sends the announcements whose next attempt is due.
+func (a *Announcer) deliverDue(ctx context.Context) {
+	a.lk.Lock()
+	queue, err := a.list(ctx)
+	a.lk.Unlock()
+	if err != nil {
+		log.Errorw("listing queued piece announcements", "error", err)
+		return
+	}
+
+	now := build.Clock.Now()
+	for _, p := range queue {
+		if p.NextAttempt.After(now) {
+			break
+		}
+
+		err := a.post(ctx, p.Announcement)
+
+		a.lk.Lock()
+		err = a.finish(ctx, p, err)
+		a.lk.Unlock()
+		if err != nil {
+			log.Errorw("updating queued piece announcement", "proposal", p.Announcement.ProposalCid, "error", err)
+		}
+	}
+}
+
+// finish records the outcome of a delivery attempt.
+func (a *Announcer) finish(ctx context.Context, p api.PendingPieceAnnouncement, postErr error) error {
+	// the announcement may have been queued again during the delivery
+	cur, err := a.get(ctx, p.Announcement.ProposalCid)
+	if err != nil {
+		return err
+	}
+	if cur == nil || cur.Attempts != p.Attempts || !cur.NextAttempt.Equal(p.NextAttempt) {
+		return nil
+	}
+
+	if postErr == nil {
+		log.Infow("announced piece to indexer", "proposal", p.Announcement.ProposalCid, "piece", p.Announcement.PieceCid)
+		return a.ds.Delete(ctx, key(p.Announcement.ProposalCid))
+	}
+
+	p.Attempts++
+	p.LastError = postErr.Error()
+
+	if a.cfg.MaxAttempts > 0 && p.Attempts >= a.cfg.MaxAttempts {
+		log.Errorw("giving up announcing piece to indexer", "proposal", p.Announcement.ProposalCid, "attempts", p.Attempts, "error", postErr)
+		return a.ds.Delete(ctx, key(p.Announcement.ProposalCid))
+	}
+
+	delay := minRetryDelay << (p.Attempts - 1)
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+	p.NextAttempt = build.Clock.Now().Add(delay)
+
+	log.Warnw("failed to announce piece to indexer, will retry", "proposal", p.Announcement.ProposalCid, "attempts", p.Attempts, "retry-in", delay, "error", postErr)
+	return a.put(ctx, p)
+}
+
+func (a *Announcer) post(ctx context.Context, ann api.PieceAnnouncement) error {
+	b, err := json.Marshal(ann)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.Endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return xerrors.Errorf("indexer returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func key(proposal cid.Cid) datastore.Key {
+	return datastore.NewKey(proposal.String())
+}
+
+func (a *Announcer) put(ctx context.Context, p api.PendingPieceAnnouncement) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return a.ds.Put(ctx, key(p.Announcement.ProposalCid), b)
+}
+
+func (a *Announcer) get(ctx context.Context, proposal cid.Cid) (*api.PendingPieceAnnouncement, error) {
+	b, err := a.ds.Get(ctx, key(proposal))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p api.PendingPieceAnnouncement
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, xerrors.Errorf("decoding queued announcement: %w", err)
+	}
+	return &p, nil
+}
+
+func (a *Announcer) list(ctx context.Context) ([]api.PendingPieceAnnouncement, error) {
+	res, err := a.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []api.PendingPieceAnnouncement
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+
+		var p api.PendingPieceAnnouncement
+		if err := json.Unmarshal(r.Value, &p); err != nil {
+			return nil, xerrors.Errorf("decoding queued announcement %s: %w", r.Key, err)
+		}
+		out = append(out, p)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].NextAttempt.Before(out[j].NextAttempt)
+	})
+	return out, nil
+}
@@ -0,0 +1,141 @@
+package idxannounce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestAnnouncer(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+
+	var lk sync.Mutex
+	var received []api.PieceAnnouncement
+	fail := true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		defer lk.Unlock()
+
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		var ann api.PieceAnnouncement
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&ann))
+		received = append(received, ann)
+	}))
+	defer srv.Close()
+
+	provider := mock.Address(1000)
+	pid, err := peer.Decode("12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf")
+	require.NoError(t, err)
+
+	a := NewAnnouncer(Config{Endpoint: srv.URL, MaxAttempts: 3}, dssync.MutexWrap(datastore.NewMapDatastore()),
+		func(deal storagemarket.MinerDeal) api.PieceAnnouncement {
+			return api.PieceAnnouncement{
+				Provider:    provider,
+				PeerID:      pid,
+				ProposalCid: deal.ProposalCid,
+				PieceCid:    deal.Proposal.PieceCID,
+				PayloadCid:  deal.Ref.Root,
+				Client:      deal.Proposal.Client,
+			}
+		})
+
+	deal := func(n string) storagemarket.MinerDeal {
+		d := storagemarket.MinerDeal{
+			ClientDealProposal: market.ClientDealProposal{
+				Proposal: market.DealProposal{
+					PieceCID: blocks.NewBlock([]byte("piece-" + n)).Cid(),
+					Client:   mock.Address(1001),
+				},
+			},
+			Ref:   &storagemarket.DataRef{Root: blocks.NewBlock([]byte("payload-" + n)).Cid()},
+			State: storagemarket.StorageDealActive,
+		}
+		d.ProposalCid = blocks.NewBlock([]byte("proposal-" + n)).Cid()
+		return d
+	}
+
+	// only deals which became active are announced
+	a.OnDealEvent(storagemarket.ProviderEventDealPublished, deal("a"))
+	a.OnDealEvent(storagemarket.ProviderEventDealActivated, deal("a"))
+
+	q, err := a.Queue(ctx)
+	require.NoError(t, err)
+	require.Len(t, q, 1)
+
+	// failed deliveries are retried with a backoff
+	a.deliverDue(ctx)
+	q, err = a.Queue(ctx)
+	require.NoError(t, err)
+	require.Len(t, q, 1)
+	require.Equal(t, 1, q[0].Attempts)
+	require.Contains(t, q[0].LastError, "503")
+	require.WithinDuration(t, mc.Now().Add(minRetryDelay), q[0].NextAttempt, 0)
+
+	mc.Add(minRetryDelay)
+	a.deliverDue(ctx)
+	q, err = a.Queue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, q[0].Attempts)
+	require.WithinDuration(t, mc.Now().Add(2*minRetryDelay), q[0].NextAttempt, 0)
+
+	// not retried before the backoff elapses
+	mc.Add(minRetryDelay)
+	a.deliverDue(ctx)
+	q, err = a.Queue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, q[0].Attempts)
+
+	// dropped after the maximum number of attempts
+	mc.Add(minRetryDelay)
+	a.deliverDue(ctx)
+	q, err = a.Queue(ctx)
+	require.NoError(t, err)
+	require.Empty(t, q)
+
+	// delivered announcements leave the queue
+	lk.Lock()
+	fail = false
+	lk.Unlock()
+
+	require.NoError(t, a.Enqueue(ctx, deal("b")))
+	require.NoError(t, a.Enqueue(ctx, deal("c")))
+	a.deliverDue(ctx)
+
+	q, err = a.Queue(ctx)
+	require.NoError(t, err)
+	require.Empty(t, q)
+
+	lk.Lock()
+	defer lk.Unlock()
+	require.Len(t, received, 2)
+	require.Equal(t, provider, received[0].Provider)
+}
@@ -0,0 +1,109 @@
+// Package piecehttp serves the unsealed data of pieces on the markets node API
+// endpoint, so that the operator, or tools holding an API token with read
+// permission, can read parts of large pieces without exporting the whole deal.
+// It is not part of the retrieval protocol: retrieval clients still retrieve
+// deals by payload root.
+package piecehttp
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/markets/dagstore"
+)
+
+var log = logging.Logger("piecehttp")
+
+// Handler serves pieces at <prefix>/{pieceCid}. A part of a piece is
+// retrieved with a Range header, or with the offset and length query
+// parameters. Only pieces with an unsealed copy are served.
+type Handler struct {
+	api dagstore.MinerAPI
+}
+
+func NewHandler(api dagstore.MinerAPI) *Handler {
+	return &Handler{api: api}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pieceCid, err := cid.Parse(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid piece CID: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := rangeFromQuery(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	unsealed, err := h.api.IsUnsealed(ctx, pieceCid)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("piece %s not found: %s", pieceCid, err), http.StatusNotFound)
+		return
+	}
+	if !unsealed {
+		http.Error(w, fmt.Sprintf("piece %s has no unsealed copy", pieceCid), http.StatusServiceUnavailable)
+		return
+	}
+
+	rd, err := h.api.FetchUnsealedPiece(ctx, pieceCid)
+	if err != nil {
+		log.Errorw("reading piece", "piece", pieceCid, "error", err)
+		http.Error(w, fmt.Sprintf("reading piece %s: %s", pieceCid, err), http.StatusInternalServerError)
+		return
+	}
+	defer rd.Close() //nolint:errcheck
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", time.Time{}, rd)
+}
+
+// rangeFromQuery turns the offset and length query parameters into a Range
+// header.
+func rangeFromQuery(r *http.Request) error {
+	q := r.URL.Query()
+	if !q.Has("offset") && !q.Has("length") {
+		return nil
+	}
+	if r.Header.Get("Range") != "" {
+		return xerrors.Errorf("offset and length can't be combined with a Range header")
+	}
+
+	var offset, length uint64
+	var err error
+	if s := q.Get("offset"); s != "" {
+		if offset, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return xerrors.Errorf("invalid offset: %w", err)
+		}
+	}
+	if s := q.Get("length"); s != "" {
+		if length, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return xerrors.Errorf("invalid length: %w", err)
+		}
+		if length == 0 {
+			return xerrors.Errorf("length must be positive")
+		}
+	}
+
+	if length == 0 {
+		r.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else {
+		r.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package piecehttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore/mount"
+)
+
+type testReader struct {
+	*bytes.Reader
+}
+
+func (testReader) Close() error { return nil }
+
+type testMinerAPI struct {
+	pieces   map[cid.Cid][]byte
+	unsealed map[cid.Cid]bool
+}
+
+func (a *testMinerAPI) FetchUnsealedPiece(ctx context.Context, pieceCid cid.Cid) (mount.Reader, error) {
+	return testReader{bytes.NewReader(a.pieces[pieceCid])}, nil
+}
+
+func (a *testMinerAPI) GetUnpaddedCARSize(ctx context.Context, pieceCid cid.Cid) (uint64, error) {
+	return uint64(len(a.pieces[pieceCid])), nil
+}
+
+func (a *testMinerAPI) IsUnsealed(ctx context.Context, pieceCid cid.Cid) (bool, error) {
+	if _, ok := a.pieces[pieceCid]; !ok {
+		return false, xerrors.Errorf("piece %s not found", pieceCid)
+	}
+	return a.unsealed[pieceCid], nil
+}
+
+func (a *testMinerAPI) Start(ctx context.Context) error {
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	data := []byte("0123456789abcdefghij")
+	unsealed := blocks.NewBlock([]byte("unsealed")).Cid()
+	sealed := blocks.NewBlock([]byte("sealed")).Cid()
+	missing := blocks.NewBlock([]byte("missing")).Cid()
+
+	srv := httptest.NewServer(http.StripPrefix("/piece", NewHandler(&testMinerAPI{
+		pieces:   map[cid.Cid][]byte{unsealed: data, sealed: data},
+		unsealed: map[cid.Cid]bool{unsealed: true},
+	})))
+	defer srv.Close()
+
+	get := func(path string, hdr http.Header) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		for k, v := range hdr {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get("/piece/"+unsealed.String(), nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, string(data), body)
+
+	code, body = get("/piece/"+unsealed.String()+"?offset=5&length=4", nil)
+	require.Equal(t, http.StatusPartialContent, code)
+	require.Equal(t, "5678", body)
+
+	code, body = get("/piece/"+unsealed.String()+"?offset=16", nil)
+	require.Equal(t, http.StatusPartialContent, code)
+	require.Equal(t, "ghij", body)
+
+	code, body = get("/piece/"+unsealed.String(), http.Header{"Range": {"bytes=10-11"}})
+	require.Equal(t, http.StatusPartialContent, code)
+	require.Equal(t, "ab", body)
+
+	code, _ = get("/piece/"+unsealed.String()+"?offset=30", nil)
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, code)
+
+	code, _ = get("/piece/"+unsealed.String()+"?offset=1", http.Header{"Range": {"bytes=10-11"}})
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = get("/piece/"+unsealed.String()+"?length=0", nil)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = get("/piece/notacid", nil)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = get("/piece/"+missing.String(), nil)
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, _ = get("/piece/"+sealed.String(), nil)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	resp, err := http.Post(srv.URL+"/piece/"+unsealed.String(), "", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,152 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var log = logging.Logger("pricing")
+
+// Rule prices the retrievals matching all of its non-empty criteria.
+type Rule struct {
+	PieceCIDs []cid.Cid
+	Clients   []peer.ID
+	// MinDataAge and MaxDataAge bound the time since the piece was first
+	// sealed into an active deal. Zero values are ignored.
+	MinDataAge time.Duration
+	MaxDataAge time.Duration
+
+	// PricePerByte and UnsealPrice replace the prices of the current ask.
+	// Nil values keep the price of the current ask.
+	PricePerByte abi.TokenAmount
+	UnsealPrice  abi.TokenAmount
+}
+
+type RulesConfig struct {
+	Rules []Rule
+	// VerifiedDealsFreeTransfer zeroes the transfer price of payloads which
+	// belong to a verified deal, whichever rule matched.
+	VerifiedDealsFreeTransfer bool
+}
+
+type RulesAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMarketStorageDeal(context.Context, abi.DealID, types.TipSetKey) (*api.MarketDeal, error)
+}
+
+// RulesRetrievalPricingFunc prices retrievals with the first rule matching the
+// query, falling back to the current ask when no rule matches. Like the default
+// policy, unsealing is free when an unsealed copy of the piece exists.
+func RulesRetrievalPricingFunc(cfg RulesConfig, a RulesAPI, ps piecestore.PieceStore) dtypes.RetrievalPricingFunc {
+	return func(ctx context.Context, in retrievalmarket.PricingInput) (retrievalmarket.Ask, error) {
+		ask := in.CurrentAsk
+
+		var age *time.Duration
+		for i, r := range cfg.Rules {
+			if len(r.PieceCIDs) > 0 && !containsCid(r.PieceCIDs, in.PieceCID) {
+				continue
+			}
+			if len(r.Clients) > 0 && !containsPeer(r.Clients, in.Client) {
+				continue
+			}
+			if r.MinDataAge > 0 || r.MaxDataAge > 0 {
+				if age == nil {
+					d, err := pieceAge(ctx, a, ps, in.PieceCID)
+					if err != nil {
+						return retrievalmarket.Ask{}, xerrors.Errorf("getting age of piece %s: %w", in.PieceCID, err)
+					}
+					age = &d
+				}
+				if *age < r.MinDataAge || (r.MaxDataAge > 0 && *age > r.MaxDataAge) {
+					continue
+				}
+			}
+
+			log.Debugw("retrieval pricing rule matched", "rule", i, "piece", in.PieceCID, "client", in.Client)
+			if !r.PricePerByte.Nil() {
+				ask.PricePerByte = r.PricePerByte
+			}
+			if !r.UnsealPrice.Nil() {
+				ask.UnsealPrice = r.UnsealPrice
+			}
+			break
+		}
+
+		if in.Unsealed {
+			ask.UnsealPrice = big.Zero()
+		}
+		if in.VerifiedDeal && cfg.VerifiedDealsFreeTransfer {
+			ask.PricePerByte = big.Zero()
+		}
+
+		return ask, nil
+	}
+}
+
+// pieceAge returns the time since the earliest activation of a deal storing the
+// piece, or zero if none of its deals is active yet.
+func pieceAge(ctx context.Context, a RulesAPI, ps piecestore.PieceStore, pieceCid cid.Cid) (time.Duration, error) {
+	pi, err := ps.GetPieceInfo(pieceCid)
+	if err != nil {
+		return 0, xerrors.Errorf("getting piece info: %w", err)
+	}
+
+	head, err := a.ChainHead(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	start := abi.ChainEpoch(-1)
+	for _, d := range pi.Deals {
+		md, err := a.StateMarketStorageDeal(ctx, d.DealID, head.Key())
+		if err != nil {
+			// the deal may have expired or been slashed
+			log.Debugw("getting market deal", "deal", d.DealID, "error", err)
+			continue
+		}
+		if md.State.SectorStartEpoch <= 0 {
+			continue
+		}
+		if start < 0 || md.State.SectorStartEpoch < start {
+			start = md.State.SectorStartEpoch
+		}
+	}
+
+	if start < 0 || start > head.Height() {
+		return 0, nil
+	}
+	return time.Duration(head.Height()-start) * time.Duration(build.BlockDelaySecs) * time.Second, nil
+}
+
+func containsCid(cids []cid.Cid, c cid.Cid) bool {
+	for _, cc := range cids {
+		if cc.Equals(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPeer(peers []peer.ID, p peer.ID) bool {
+	for _, pp := range peers {
+		if pp == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type testRulesAPI struct {
+	height abi.ChainEpoch
+	starts map[abi.DealID]abi.ChainEpoch
+}
+
+func (a *testRulesAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	blk := mock.MkBlock(nil, 1, 1)
+	blk.Height = a.height
+	return mock.TipSet(blk), nil
+}
+
+func (a *testRulesAPI) StateMarketStorageDeal(_ context.Context, id abi.DealID, _ types.TipSetKey) (*api.MarketDeal, error) {
+	return &api.MarketDeal{State: market.DealState{SectorStartEpoch: a.starts[id]}}, nil
+}
+
+type testPieceStore struct {
+	piecestore.PieceStore
+	pieces map[cid.Cid]piecestore.PieceInfo
+}
+
+func (ps *testPieceStore) GetPieceInfo(pieceCID cid.Cid) (piecestore.PieceInfo, error) {
+	return ps.pieces[pieceCID], nil
+}
+
+func TestRulesRetrievalPricingFunc(t *testing.T) {
+	ctx := context.Background()
+
+	hot := blocks.NewBlock([]byte("hot")).Cid()
+	cold := blocks.NewBlock([]byte("cold")).Cid()
+	other := blocks.NewBlock([]byte("other")).Cid()
+	friend := peer.ID("friend")
+
+	epochsPerDay := abi.ChainEpoch(24 * 60 * 60 / build.BlockDelaySecs)
+	a := &testRulesAPI{
+		height: 100 * epochsPerDay,
+		starts: map[abi.DealID]abi.ChainEpoch{1: 99 * epochsPerDay, 2: 10 * epochsPerDay, 3: 50 * epochsPerDay},
+	}
+	ps := &testPieceStore{pieces: map[cid.Cid]piecestore.PieceInfo{
+		hot:   {PieceCID: hot, Deals: []piecestore.DealInfo{{DealID: 1}}},
+		cold:  {PieceCID: cold, Deals: []piecestore.DealInfo{{DealID: 3}, {DealID: 2}}},
+		other: {PieceCID: other, Deals: []piecestore.DealInfo{{DealID: 4}}},
+	}}
+
+	price := RulesRetrievalPricingFunc(RulesConfig{
+		Rules: []Rule{
+			{Clients: []peer.ID{friend}, PricePerByte: big.Zero(), UnsealPrice: big.Zero()},
+			{PieceCIDs: []cid.Cid{hot}, PricePerByte: big.NewInt(10)},
+			{MinDataAge: 30 * 24 * time.Hour, PricePerByte: big.NewInt(1)},
+		},
+		VerifiedDealsFreeTransfer: true,
+	}, a, ps)
+
+	current := retrievalmarket.Ask{PricePerByte: big.NewInt(5), UnsealPrice: big.NewInt(100)}
+	ask := func(piece cid.Cid, client peer.ID, verified, unsealed bool) retrievalmarket.Ask {
+		res, err := price(ctx, retrievalmarket.PricingInput{
+			PieceCID:     piece,
+			Client:       client,
+			VerifiedDeal: verified,
+			Unsealed:     unsealed,
+			CurrentAsk:   current,
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	// per client
+	require.Equal(t, retrievalmarket.Ask{PricePerByte: big.Zero(), UnsealPrice: big.Zero()}, ask(hot, friend, false, false))
+
+	// per piece, the unseal price of the current ask is kept
+	res := ask(hot, "someone", false, false)
+	require.Equal(t, big.NewInt(10), res.PricePerByte)
+	require.Equal(t, big.NewInt(100), res.UnsealPrice)
+
+	// by data age, using the earliest deal activation
+	require.Equal(t, big.NewInt(1), ask(cold, "someone", false, false).PricePerByte)
+
+	// no rule matches, the deal of the piece isn't active
+	require.Equal(t, current, ask(other, "someone", false, false))
+
+	// free for verified deals, free unsealing for unsealed pieces
+	require.Equal(t, retrievalmarket.Ask{PricePerByte: big.Zero(), UnsealPrice: big.Zero()}, ask(hot, "someone", true, true))
+}
@@ -0,0 +1,139 @@
+// Package retrievalresume persists the progress of client retrieval deals, so
+// that retrievals interrupted by a restart or a stalled transfer can be
+// resumed from the last verified block instead of starting over.
+package retrievalresume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket/impl/clientstates"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("retrievalresume")
+
+// Session is the persisted progress of a retrieval deal.
+type Session struct {
+	DealID     retrievalmarket.DealID
+	PayloadCID cid.Cid
+	PieceCID   *cid.Cid
+	Provider   peer.ID
+
+	Status        retrievalmarket.DealStatus
+	Message       string
+	BytesReceived uint64
+	ChannelID     *datatransfer.ChannelID
+
+	// Updated is when the deal last made progress.
+	Updated time.Time
+}
+
+// Tracker records a session for each ongoing retrieval deal, and drops it once
+// the deal reaches a final state.
+type Tracker struct {
+	ds      datastore.Batching
+	started time.Time
+
+	lk sync.Mutex
+}
+
+func NewTracker(ds datastore.Batching) *Tracker {
+	return &Tracker{
+		ds:      ds,
+		started: build.Clock.Now(),
+	}
+}
+
+// OnClientEvent is a retrievalmarket.ClientSubscriber recording the progress
+// of retrieval deals.
+func (t *Tracker) OnClientEvent(evt retrievalmarket.ClientEvent, state retrievalmarket.ClientDealState) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	key := sessionKey(state.ID)
+
+	if clientstates.IsFinalityState(state.Status) {
+		if err := t.ds.Delete(context.TODO(), key); err != nil {
+			log.Errorw("removing retrieval session", "deal", state.ID, "error", err)
+		}
+		return
+	}
+
+	s := Session{
+		DealID:        state.ID,
+		PayloadCID:    state.PayloadCID,
+		PieceCID:      state.PieceCID,
+		Provider:      state.Sender,
+		Status:        state.Status,
+		Message:       state.Message,
+		BytesReceived: state.TotalReceived,
+		ChannelID:     state.ChannelID,
+		Updated:       build.Clock.Now(),
+	}
+
+	b, err := json.Marshal(&s)
+	if err != nil {
+		log.Errorw("marshaling retrieval session", "deal", state.ID, "error", err)
+		return
+	}
+	if err := t.ds.Put(context.TODO(), key, b); err != nil {
+		log.Errorw("persisting retrieval session", "deal", state.ID, "error", err)
+	}
+}
+
+// Sessions lists the sessions of the retrieval deals which didn't reach a
+// final state, oldest first.
+func (t *Tracker) Sessions(ctx context.Context) ([]Session, error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	res, err := t.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying retrieval sessions: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []Session
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading retrieval sessions: %w", r.Error)
+		}
+
+		var s Session
+		if err := json.Unmarshal(r.Value, &s); err != nil {
+			return nil, xerrors.Errorf("unmarshaling retrieval session %s: %w", r.Key, err)
+		}
+		out = append(out, s)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Updated.Before(out[j].Updated)
+	})
+	return out, nil
+}
+
+// Interrupted returns whether the session was interrupted, either because the
+// node restarted since its last progress, or because it made no progress for
+// longer than stallTimeout.
+func (t *Tracker) Interrupted(s Session, stallTimeout time.Duration) bool {
+	return s.Updated.Before(t.started) || build.Clock.Now().Sub(s.Updated) > stallTimeout
+}
+
+func sessionKey(id retrievalmarket.DealID) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(uint64(id)))
+}
@@ -0,0 +1,83 @@
+package retrievalresume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	client, err := peer.Decode("12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf")
+	require.NoError(t, err)
+	provider, err := peer.Decode("12D3KooWFQTFuxEeWmEbA8o4b5jQYtH9PYUynkNcQX2FEg3jR6Ko")
+	require.NoError(t, err)
+
+	tr := NewTracker(ds)
+	mc.Add(time.Minute)
+
+	deal := func(id retrievalmarket.DealID, status retrievalmarket.DealStatus, received uint64) retrievalmarket.ClientDealState {
+		return retrievalmarket.ClientDealState{
+			DealProposal: retrievalmarket.DealProposal{
+				PayloadCID: blocks.NewBlock([]byte("payload")).Cid(),
+				ID:         id,
+			},
+			ChannelID:     &datatransfer.ChannelID{ID: datatransfer.TransferID(id), Initiator: client, Responder: provider},
+			Status:        status,
+			Sender:        provider,
+			TotalReceived: received,
+		}
+	}
+
+	tr.OnClientEvent(retrievalmarket.ClientEventBlocksReceived, deal(1, retrievalmarket.DealStatusOngoing, 100))
+	tr.OnClientEvent(retrievalmarket.ClientEventBlocksReceived, deal(2, retrievalmarket.DealStatusOngoing, 200))
+
+	mc.Add(time.Minute)
+	tr.OnClientEvent(retrievalmarket.ClientEventBlocksReceived, deal(1, retrievalmarket.DealStatusOngoing, 300))
+
+	sessions, err := tr.Sessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.EqualValues(t, 2, sessions[0].DealID)
+	require.EqualValues(t, 1, sessions[1].DealID)
+	require.EqualValues(t, 300, sessions[1].BytesReceived)
+	require.Equal(t, retrievalmarket.DealStatusOngoing, sessions[1].Status)
+	require.NotNil(t, sessions[1].ChannelID)
+
+	require.False(t, tr.Interrupted(sessions[0], 5*time.Minute))
+	mc.Add(5 * time.Minute)
+	require.True(t, tr.Interrupted(sessions[0], 5*time.Minute))
+
+	// sessions recorded before a restart are interrupted
+	tr2 := NewTracker(ds)
+	require.True(t, tr2.Interrupted(sessions[1], time.Hour))
+
+	// final states drop the session
+	tr.OnClientEvent(retrievalmarket.ClientEventComplete, deal(1, retrievalmarket.DealStatusCompleted, 400))
+	tr.OnClientEvent(retrievalmarket.ClientEventCancelComplete, deal(2, retrievalmarket.DealStatusCancelled, 200))
+
+	sessions, err = tr.Sessions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
@@ -0,0 +1,327 @@
+// Package retrievalstats records the outcome of the client retrieval deals
+// made with each provider, so that clients can pick providers based on their
+// past success rate, throughput and price.
+package retrievalstats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket/impl/clientstates"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("retrievalstats")
+
+// Retention is how long attempts are kept for.
+const Retention = 90 * 24 * time.Hour
+
+// Attempt is the record of a retrieval deal.
+type Attempt struct {
+	DealID     retrievalmarket.DealID
+	Miner      address.Address // address.Undef when the deal wasn't made through the API
+	Provider   peer.ID
+	PayloadCID cid.Cid
+
+	Status  retrievalmarket.DealStatus
+	Message string
+
+	BytesReceived uint64
+	Paid          abi.TokenAmount
+
+	// Started is zero when the start of the deal wasn't recorded, Ended is
+	// zero while the deal is in progress.
+	Started time.Time
+	Ended   time.Time
+}
+
+func (a *Attempt) Done() bool {
+	return !a.Ended.IsZero()
+}
+
+func (a *Attempt) Succeeded() bool {
+	return a.Status == retrievalmarket.DealStatusCompleted
+}
+
+// Stats summarizes the completed attempts made with a provider.
+type Stats struct {
+	Miner    address.Address
+	Provider peer.ID
+
+	Attempts  int
+	Successes int
+
+	BytesReceived uint64
+	// Throughput is the average throughput of the successful attempts, in
+	// bytes per second.
+	Throughput float64
+	Paid       abi.TokenAmount
+
+	LastAttempt time.Time
+	LastError   string
+}
+
+// Recorder records an attempt for each retrieval deal, completing it once the
+// deal reaches a final state.
+type Recorder struct {
+	ds datastore.Batching
+
+	lk sync.Mutex
+}
+
+// NewRecorder returns a recorder persisting attempts in ds, and drops the
+// attempts which ended more than Retention ago.
+func NewRecorder(ctx context.Context, ds datastore.Batching) (*Recorder, error) {
+	r := &Recorder{
+		ds: ds,
+	}
+
+	if err := r.prune(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Started records the start of a retrieval deal with miner.
+func (r *Recorder) Started(ctx context.Context, id retrievalmarket.DealID, miner address.Address, provider peer.ID, payload cid.Cid) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	a, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		a = &Attempt{
+			DealID:     id,
+			Provider:   provider,
+			PayloadCID: payload,
+			Started:    build.Clock.Now(),
+		}
+	}
+	// the deal may already have ended
+	a.Miner = miner
+
+	return r.put(ctx, a)
+}
+
+// OnClientEvent is a retrievalmarket.ClientSubscriber completing the attempts
+// of the deals reaching a final state.
+func (r *Recorder) OnClientEvent(evt retrievalmarket.ClientEvent, state retrievalmarket.ClientDealState) {
+	if !clientstates.IsFinalityState(state.Status) {
+		return
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	ctx := context.TODO()
+
+	a, err := r.get(ctx, state.ID)
+	if err != nil {
+		log.Errorw("loading retrieval attempt", "deal", state.ID, "error", err)
+		return
+	}
+	if a == nil {
+		a = &Attempt{
+			DealID:     state.ID,
+			Provider:   state.Sender,
+			PayloadCID: state.PayloadCID,
+		}
+	}
+	if a.Done() {
+		return
+	}
+
+	a.Status = state.Status
+	a.Message = state.Message
+	a.BytesReceived = state.TotalReceived
+	a.Paid = state.FundsSpent
+	a.Ended = build.Clock.Now()
+
+	if err := r.put(ctx, a); err != nil {
+		log.Errorw("persisting retrieval attempt", "deal", state.ID, "error", err)
+	}
+}
+
+// Attempts lists the recorded attempts, most recent first.
+func (r *Recorder) Attempts(ctx context.Context) ([]Attempt, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	out, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].DealID > out[j].DealID
+	})
+	return out, nil
+}
+
+// Stats summarizes the completed attempts per provider, providers with the
+// most successes first.
+func (r *Recorder) Stats(ctx context.Context) ([]Stats, error) {
+	attempts, err := r.Attempts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type acc struct {
+		Stats
+		elapsed time.Duration
+		bytes   uint64
+	}
+
+	byProvider := map[string]*acc{}
+	var order []string
+	for _, a := range attempts {
+		if !a.Done() {
+			continue
+		}
+
+		key := a.Provider.String()
+		if a.Miner != address.Undef {
+			key = a.Miner.String()
+		}
+		s, ok := byProvider[key]
+		if !ok {
+			s = &acc{Stats: Stats{Miner: a.Miner, Provider: a.Provider, Paid: big.Zero()}}
+			byProvider[key] = s
+			order = append(order, key)
+		}
+
+		s.Attempts++
+		s.BytesReceived += a.BytesReceived
+		if !a.Paid.Nil() {
+			s.Paid = big.Add(s.Paid, a.Paid)
+		}
+		if a.Ended.After(s.LastAttempt) {
+			s.LastAttempt = a.Ended
+		}
+
+		if !a.Succeeded() {
+			if s.LastError == "" {
+				// attempts are sorted most recent first
+				s.LastError = fmt.Sprintf("%s: %s", retrievalmarket.DealStatuses[a.Status], a.Message)
+			}
+			continue
+		}
+
+		s.Successes++
+		if !a.Started.IsZero() && a.Ended.After(a.Started) {
+			s.elapsed += a.Ended.Sub(a.Started)
+			s.bytes += a.BytesReceived
+		}
+	}
+
+	out := make([]Stats, 0, len(order))
+	for _, key := range order {
+		s := byProvider[key]
+		if s.elapsed > 0 {
+			s.Throughput = float64(s.bytes) / s.elapsed.Seconds()
+		}
+		out = append(out, s.Stats)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Successes > out[j].Successes
+	})
+	return out, nil
+}
+
+// prune drops the attempts which ended more than Retention ago, and the ones
+// which started more than Retention ago and never ended.
+func (r *Recorder) prune(ctx context.Context) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	attempts, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range attempts {
+		last := a.Ended
+		if !a.Done() {
+			last = a.Started
+		}
+		if last.IsZero() || build.Clock.Now().Sub(last) <= Retention {
+			continue
+		}
+		if err := r.ds.Delete(ctx, attemptKey(a.DealID)); err != nil {
+			return xerrors.Errorf("removing expired retrieval attempt %d: %w", a.DealID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) get(ctx context.Context, id retrievalmarket.DealID) (*Attempt, error) {
+	b, err := r.ds.Get(ctx, attemptKey(id))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting retrieval attempt %d: %w", id, err)
+	}
+
+	var a Attempt
+	if err := json.Unmarshal(b, &a); err != nil {
+		return nil, xerrors.Errorf("unmarshaling retrieval attempt %d: %w", id, err)
+	}
+	return &a, nil
+}
+
+func (r *Recorder) put(ctx context.Context, a *Attempt) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return xerrors.Errorf("marshaling retrieval attempt %d: %w", a.DealID, err)
+	}
+	if err := r.ds.Put(ctx, attemptKey(a.DealID), b); err != nil {
+		return xerrors.Errorf("persisting retrieval attempt %d: %w", a.DealID, err)
+	}
+	return nil
+}
+
+func (r *Recorder) load(ctx context.Context) ([]Attempt, error) {
+	res, err := r.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying retrieval attempts: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []Attempt
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("reading retrieval attempts: %w", e.Error)
+		}
+
+		var a Attempt
+		if err := json.Unmarshal(e.Value, &a); err != nil {
+			return nil, xerrors.Errorf("unmarshaling retrieval attempt %s: %w", e.Key, err)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func attemptKey(id retrievalmarket.DealID) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(uint64(id)))
+}
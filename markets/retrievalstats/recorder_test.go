@@ -0,0 +1,113 @@
+package retrievalstats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	r, err := NewRecorder(ctx, ds)
+	require.NoError(t, err)
+
+	fast, err := peer.Decode("12D3KooWGzxzKZYveHXtpG6AsrUJBcWxHBFS2HsEoGTxrMLvKXtf")
+	require.NoError(t, err)
+	slow, err := peer.Decode("12D3KooWFQTFuxEeWmEbA8o4b5jQYtH9PYUynkNcQX2FEg3jR6Ko")
+	require.NoError(t, err)
+	fastMiner := mock.Address(1000)
+	payload := blocks.NewBlock([]byte("payload")).Cid()
+
+	end := func(id retrievalmarket.DealID, provider peer.ID, status retrievalmarket.DealStatus, received uint64) {
+		state := retrievalmarket.ClientDealState{
+			DealProposal:  retrievalmarket.DealProposal{ID: id, PayloadCID: payload},
+			Status:        status,
+			Sender:        provider,
+			TotalReceived: received,
+			FundsSpent:    abi.NewTokenAmount(int64(received)),
+			Message:       "oops",
+		}
+		r.OnClientEvent(retrievalmarket.ClientEventComplete, state)
+	}
+
+	// 1MiB in 1s, then 3MiB in 1s from the fast provider
+	require.NoError(t, r.Started(ctx, 1, fastMiner, fast, payload))
+	mc.Add(time.Second)
+	end(1, fast, retrievalmarket.DealStatusCompleted, 1<<20)
+	require.NoError(t, r.Started(ctx, 2, fastMiner, fast, payload))
+	mc.Add(time.Second)
+	end(2, fast, retrievalmarket.DealStatusCompleted, 3<<20)
+
+	// the slow provider, not retrieved from through the API, fails once
+	end(3, slow, retrievalmarket.DealStatusCompleted, 1<<20)
+	end(4, slow, retrievalmarket.DealStatusErrored, 0)
+	// final states are only recorded once
+	end(4, slow, retrievalmarket.DealStatusCompleted, 1<<20)
+
+	// in progress deals aren't summarized
+	require.NoError(t, r.Started(ctx, 5, fastMiner, fast, payload))
+
+	attempts, err := r.Attempts(ctx)
+	require.NoError(t, err)
+	require.Len(t, attempts, 5)
+	require.Equal(t, retrievalmarket.DealID(5), attempts[0].DealID)
+	require.False(t, attempts[0].Done())
+
+	stats, err := r.Stats(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	require.Equal(t, fastMiner, stats[0].Miner)
+	require.Equal(t, 2, stats[0].Attempts)
+	require.Equal(t, 2, stats[0].Successes)
+	require.Equal(t, uint64(4<<20), stats[0].BytesReceived)
+	require.Equal(t, float64(2<<20), stats[0].Throughput)
+	require.Equal(t, abi.NewTokenAmount(4<<20), stats[0].Paid)
+	require.Empty(t, stats[0].LastError)
+
+	require.Equal(t, address.Undef, stats[1].Miner)
+	require.Equal(t, slow, stats[1].Provider)
+	require.Equal(t, 2, stats[1].Attempts)
+	require.Equal(t, 1, stats[1].Successes)
+	require.Zero(t, stats[1].Throughput)
+	require.Contains(t, stats[1].LastError, "oops")
+
+	// attempts are kept across restarts
+	r, err = NewRecorder(ctx, ds)
+	require.NoError(t, err)
+	attempts, err = r.Attempts(ctx)
+	require.NoError(t, err)
+	require.Len(t, attempts, 5)
+
+	// until they are past the retention
+	mc.Add(Retention + time.Hour)
+	require.NoError(t, r.Started(ctx, 6, fastMiner, fast, payload))
+	require.NoError(t, r.prune(ctx))
+	attempts, err = r.Attempts(ctx)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	require.Equal(t, retrievalmarket.DealID(6), attempts[0].DealID)
+}
@@ -0,0 +1,302 @@
+// Package unsealedcache manages the unsealed sector copies made to serve
+// retrievals. Copies are kept for faster subsequent retrievals, and removed
+// once they weren't read for a while, or when their total size exceeds a
+// budget, least recently read first.
+package unsealedcache
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore/mount"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/metrics"
+	"github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var log = logging.Logger("unsealedcache")
+
+// sweepInterval is how often copies are checked for expiry.
+const sweepInterval = time.Minute
+
+type Config struct {
+	// MaxBytes is the maximum total size of the copies kept, 0 is unlimited.
+	MaxBytes int64
+	// TTL is the time after which a copy which wasn't read is removed, 0
+	// keeps copies until MaxBytes is exceeded.
+	TTL time.Duration
+}
+
+// Entry is an unsealed sector copy made to serve retrievals.
+type Entry struct {
+	Sector     storiface.SectorRef
+	Size       uint64
+	LastAccess time.Time
+}
+
+type entry struct {
+	Entry
+
+	// readers is the number of open readers of the copy, which is never
+	// removed while being read.
+	readers int
+}
+
+// Cache is a sealer.PieceProvider tracking the sectors it unseals to read
+// pieces, and removing their unsealed copies according to its Config. Unsealed
+// copies it didn't make, e.g. ones kept by the sealing pipeline, are left
+// alone.
+type Cache struct {
+	pp  sealer.PieceProvider
+	rel sealer.UnsealedReleaser
+	ds  datastore.Batching
+	cfg Config
+
+	lk      sync.Mutex
+	entries map[abi.SectorID]*entry
+
+	evictCh chan struct{}
+}
+
+var _ sealer.PieceProvider = (*Cache)(nil)
+
+// NewCache creates a Cache reading pieces from pp, loading the copies tracked
+// before a restart from ds.
+func NewCache(ctx context.Context, pp sealer.PieceProvider, rel sealer.UnsealedReleaser, ds datastore.Batching, cfg Config) (*Cache, error) {
+	c := &Cache{
+		pp:      pp,
+		rel:     rel,
+		ds:      ds,
+		cfg:     cfg,
+		entries: map[abi.SectorID]*entry{},
+		evictCh: make(chan struct{}, 1),
+	}
+
+	res, err := ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying unsealed copies: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading unsealed copies: %w", r.Error)
+		}
+
+		var e Entry
+		if err := json.Unmarshal(r.Value, &e); err != nil {
+			return nil, xerrors.Errorf("unmarshaling unsealed copy %s: %w", r.Key, err)
+		}
+		c.entries[e.Sector.ID] = &entry{Entry: e}
+	}
+
+	c.recordSize(ctx)
+	return c, nil
+}
+
+// Run removes the expired copies periodically, and the copies exceeding the
+// size budget as new ones are made, until the context is cancelled.
+func (c *Cache) Run(ctx context.Context) {
+	ticker := build.Clock.Ticker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		c.evict(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-c.evictCh:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Cache) IsUnsealed(ctx context.Context, sector storiface.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize) (bool, error) {
+	return c.pp.IsUnsealed(ctx, sector, offset, size)
+}
+
+func (c *Cache) ReadPiece(ctx context.Context, sector storiface.SectorRef, pieceOffset storiface.UnpaddedByteIndex, pieceSize abi.UnpaddedPieceSize, ticket abi.SealRandomness, unsealed cid.Cid) (mount.Reader, bool, error) {
+	ssize, err := sector.ProofType.SectorSize()
+	if err != nil {
+		return nil, false, xerrors.Errorf("getting sector size: %w", err)
+	}
+
+	r, uns, err := c.pp.ReadPiece(ctx, sector, pieceOffset, pieceSize, ticket, unsealed)
+	if err != nil {
+		return nil, uns, err
+	}
+
+	if uns {
+		stats.Record(ctx, metrics.UnsealedCacheMisses.M(1))
+	} else {
+		stats.Record(ctx, metrics.UnsealedCacheHits.M(1))
+	}
+
+	c.lk.Lock()
+	e, tracked := c.entries[sector.ID]
+	if !tracked && uns {
+		e = &entry{Entry: Entry{Sector: sector, Size: uint64(ssize)}}
+		c.entries[sector.ID] = e
+		tracked = true
+	}
+	if tracked {
+		e.readers++
+		c.touch(ctx, e)
+	}
+	c.lk.Unlock()
+
+	if !tracked {
+		return r, uns, nil
+	}
+
+	if uns {
+		c.recordSize(ctx)
+
+		select {
+		case c.evictCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return &reader{Reader: r, done: func() {
+		c.lk.Lock()
+		defer c.lk.Unlock()
+
+		e.readers--
+		if c.entries[sector.ID] == e {
+			c.touch(context.TODO(), e)
+		}
+	}}, uns, nil
+}
+
+// touch marks the copy as read now, must be called with lk held.
+func (c *Cache) touch(ctx context.Context, e *entry) {
+	e.LastAccess = build.Clock.Now()
+
+	b, err := json.Marshal(&e.Entry)
+	if err != nil {
+		log.Errorw("marshaling unsealed copy", "sector", e.Sector.ID, "error", err)
+		return
+	}
+	if err := c.ds.Put(ctx, entryKey(e.Sector.ID), b); err != nil {
+		log.Errorw("persisting unsealed copy", "sector", e.Sector.ID, "error", err)
+	}
+}
+
+// victims removes the copies to evict from the cache and returns them: the
+// copies which weren't read for longer than the TTL, then the least recently
+// read ones until the total size is within the budget. Must be called with lk
+// held.
+func (c *Cache) victims() []Entry {
+	now := build.Clock.Now()
+
+	var total uint64
+	idle := make([]*entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		total += e.Size
+		if e.readers == 0 {
+			idle = append(idle, e)
+		}
+	}
+
+	sort.Slice(idle, func(i, j int) bool {
+		return idle[i].LastAccess.Before(idle[j].LastAccess)
+	})
+
+	var out []Entry
+	for _, e := range idle {
+		expired := c.cfg.TTL > 0 && now.Sub(e.LastAccess) > c.cfg.TTL
+		over := c.cfg.MaxBytes > 0 && total > uint64(c.cfg.MaxBytes)
+		if !expired && !over {
+			continue
+		}
+
+		out = append(out, e.Entry)
+		total -= e.Size
+		delete(c.entries, e.Sector.ID)
+	}
+
+	return out
+}
+
+func (c *Cache) evict(ctx context.Context) {
+	c.lk.Lock()
+	victims := c.victims()
+	c.lk.Unlock()
+
+	if len(victims) == 0 {
+		return
+	}
+
+	for _, v := range victims {
+		log.Infow("removing unsealed copy", "sector", v.Sector.ID, "lastAccess", v.LastAccess)
+
+		if err := c.rel.SectorsReleaseUnsealed(ctx, v.Sector); err != nil {
+			log.Errorw("removing unsealed copy", "sector", v.Sector.ID, "error", err)
+
+			// keep tracking the copy to retry on the next sweep
+			c.lk.Lock()
+			if _, ok := c.entries[v.Sector.ID]; !ok {
+				c.entries[v.Sector.ID] = &entry{Entry: v}
+			}
+			c.lk.Unlock()
+			continue
+		}
+
+		stats.Record(ctx, metrics.UnsealedCacheEvictions.M(1))
+
+		c.lk.Lock()
+		// the sector may have been unsealed again in the meantime
+		if _, ok := c.entries[v.Sector.ID]; !ok {
+			if err := c.ds.Delete(ctx, entryKey(v.Sector.ID)); err != nil {
+				log.Errorw("removing unsealed copy record", "sector", v.Sector.ID, "error", err)
+			}
+		}
+		c.lk.Unlock()
+	}
+
+	c.recordSize(ctx)
+}
+
+func (c *Cache) recordSize(ctx context.Context) {
+	c.lk.Lock()
+	var total uint64
+	for _, e := range c.entries {
+		total += e.Size
+	}
+	n := len(c.entries)
+	c.lk.Unlock()
+
+	stats.Record(ctx, metrics.UnsealedCacheBytes.M(int64(total)), metrics.UnsealedCacheSectors.M(int64(n)))
+}
+
+func entryKey(id abi.SectorID) datastore.Key {
+	return datastore.NewKey(storiface.SectorName(id))
+}
+
+// reader notifies the cache when the reader of a copy is closed.
+type reader struct {
+	mount.Reader
+
+	once sync.Once
+	done func()
+}
+
+func (r *reader) Close() error {
+	r.once.Do(r.done)
+	return r.Reader.Close()
+}
@@ -0,0 +1,115 @@
+package unsealedcache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/dagstore/mount"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+type testReader struct {
+	*bytes.Reader
+}
+
+func (testReader) Close() error {
+	return nil
+}
+
+// testProvider unseals sectors on the first read, and tracks the sectors
+// released.
+type testProvider struct {
+	unsealed map[abi.SectorNumber]bool
+	released []abi.SectorNumber
+}
+
+func (p *testProvider) ReadPiece(_ context.Context, sector storiface.SectorRef, _ storiface.UnpaddedByteIndex, _ abi.UnpaddedPieceSize, _ abi.SealRandomness, _ cid.Cid) (mount.Reader, bool, error) {
+	uns := !p.unsealed[sector.ID.Number]
+	p.unsealed[sector.ID.Number] = true
+	return testReader{bytes.NewReader([]byte("piece"))}, uns, nil
+}
+
+func (p *testProvider) IsUnsealed(_ context.Context, sector storiface.SectorRef, _ storiface.UnpaddedByteIndex, _ abi.UnpaddedPieceSize) (bool, error) {
+	return p.unsealed[sector.ID.Number], nil
+}
+
+func (p *testProvider) SectorsReleaseUnsealed(_ context.Context, sector storiface.SectorRef) error {
+	delete(p.unsealed, sector.ID.Number)
+	p.released = append(p.released, sector.ID.Number)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	mc.Set(time.Unix(100000, 0))
+	build.Clock = mc
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	// sector 1 was unsealed by the sealing pipeline
+	p := &testProvider{unsealed: map[abi.SectorNumber]bool{1: true}}
+
+	ssize, err := abi.RegisteredSealProof_StackedDrg2KiBV1_1.SectorSize()
+	require.NoError(t, err)
+
+	c, err := NewCache(ctx, p, p, ds, Config{MaxBytes: 2 * int64(ssize), TTL: time.Hour})
+	require.NoError(t, err)
+
+	read := func(n abi.SectorNumber) mount.Reader {
+		r, _, err := c.ReadPiece(ctx, storiface.SectorRef{
+			ID:        abi.SectorID{Miner: 1000, Number: n},
+			ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+		}, 0, 127, nil, cid.Undef)
+		require.NoError(t, err)
+		return r
+	}
+
+	for _, n := range []abi.SectorNumber{1, 2, 3} {
+		require.NoError(t, read(n).Close())
+		mc.Add(time.Minute)
+	}
+
+	// within the budget, copies not made by the cache aren't tracked
+	c.evict(ctx)
+	require.Empty(t, p.released)
+	require.Len(t, c.entries, 2)
+
+	// the least recently read copy is evicted first, copies being read are kept
+	r3 := read(3)
+	require.NoError(t, read(2).Close())
+	mc.Add(time.Minute)
+	require.NoError(t, read(4).Close())
+	c.evict(ctx)
+	require.Equal(t, []abi.SectorNumber{2}, p.released)
+	require.NoError(t, r3.Close())
+
+	// copies are tracked across restarts
+	c, err = NewCache(ctx, p, p, ds, Config{TTL: time.Hour})
+	require.NoError(t, err)
+	require.Len(t, c.entries, 2)
+
+	// expired copies are evicted
+	mc.Add(2 * time.Hour)
+	c.evict(ctx)
+	require.ElementsMatch(t, []abi.SectorNumber{2, 3, 4}, p.released)
+	require.Empty(t, c.entries)
+
+	c, err = NewCache(ctx, p, p, ds, Config{})
+	require.NoError(t, err)
+	require.Empty(t, c.entries)
+}
@@ -11,6 +11,7 @@ import (
 	rpcmetrics "github.com/filecoin-project/go-jsonrpc/metrics"
 
 	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
 )
 
 // Distribution
@@ -22,6 +23,11 @@ var workMillisecondsDistribution = view.Distribution(
 	350*60_000, 400*60_000, 600*60_000, 800*60_000, 1000*60_000, 1300*60_000, 1800*60_000, 4000*60_000, 10000*60_000, // intel PC1 range
 )
 
+var stateSecondsDistribution = view.Distribution(
+	1, 10, 30, 60, 5*60, 10*60, 30*60, 60*60, 2*60*60, 3*60*60, 4*60*60, 6*60*60, 8*60*60, 12*60*60, 18*60*60,
+	24*60*60, 2*24*60*60, 3*24*60*60, 7*24*60*60, 14*24*60*60, 30*24*60*60,
+)
+
 var queueSizeDistribution = view.Distribution(0, 1, 2, 3, 5, 7, 10, 15, 25, 35, 50, 70, 90, 130, 200, 300, 500, 1000, 2000, 5000, 10000)
 
 // Global Tags
@@ -66,6 +72,7 @@ var (
 	LotusInfo          = stats.Int64("info", "Arbitrary counter to tag lotus info to", stats.UnitDimensionless)
 	PeerCount          = stats.Int64("peer/count", "Current number of FIL peers", stats.UnitDimensionless)
 	APIRequestDuration = stats.Float64("api/request_duration_ms", "Duration of API requests", stats.UnitMilliseconds)
+	APIRequestErrors   = stats.Int64("api/request_errors", "Counter of API requests that returned an error", stats.UnitDimensionless)
 
 	// graphsync
 
@@ -128,7 +135,12 @@ var (
 	WorkerCallsReturnedDuration  = stats.Float64("sealing/worker_calls_returned_ms", "Counter of returned worker tasks", stats.UnitMilliseconds)
 	WorkerUntrackedCallsReturned = stats.Int64("sealing/worker_untracked_calls_returned", "Counter of returned untracked worker tasks", stats.UnitDimensionless)
 
-	SectorStates = stats.Int64("sealing/states", "Number of sectors in each state", stats.UnitDimensionless)
+	SectorStates        = stats.Int64("sealing/states", "Number of sectors in each state", stats.UnitDimensionless)
+	SectorStateDuration = stats.Float64("sealing/state_duration_s", "Time sectors spent in a state", stats.UnitSeconds)
+	SectorFailures      = stats.Int64("sealing/failures", "Counter of sectors entering a failed state", stats.UnitDimensionless)
+
+	WorkerUtilization = stats.Float64("sealing/worker_utilization", "Fraction of the most used resource of a worker", stats.UnitDimensionless)
+	WorkerActiveTasks = stats.Int64("sealing/worker_active_tasks", "Number of tasks assigned to a worker", stats.UnitDimensionless)
 
 	StorageFSAvailable      = stats.Float64("storage/path_fs_available_frac", "Fraction of filesystem available storage", stats.UnitDimensionless)
 	StorageAvailable        = stats.Float64("storage/path_available_frac", "Fraction of available storage", stats.UnitDimensionless)
@@ -157,12 +169,19 @@ var (
 	DagStorePRSeekBackBytes    = stats.Int64("dagstore/pr_seek_back_bytes", "PieceReader seek back bytes", stats.UnitBytes)
 	DagStorePRSeekForwardBytes = stats.Int64("dagstore/pr_seek_forward_bytes", "PieceReader seek forward bytes", stats.UnitBytes)
 
+	UnsealedCacheBytes     = stats.Int64("unsealedcache/bytes", "Size of the unsealed sector copies kept for retrievals", stats.UnitBytes)
+	UnsealedCacheSectors   = stats.Int64("unsealedcache/sectors", "Number of unsealed sector copies kept for retrievals", stats.UnitDimensionless)
+	UnsealedCacheHits      = stats.Int64("unsealedcache/hits", "Counter of piece reads served from an existing unsealed copy", stats.UnitDimensionless)
+	UnsealedCacheMisses    = stats.Int64("unsealedcache/misses", "Counter of piece reads which required unsealing", stats.UnitDimensionless)
+	UnsealedCacheEvictions = stats.Int64("unsealedcache/evictions", "Counter of unsealed sector copies evicted", stats.UnitDimensionless)
+
 	// splitstore
 	SplitstoreMiss                  = stats.Int64("splitstore/miss", "Number of misses in hotstre access", stats.UnitDimensionless)
 	SplitstoreCompactionTimeSeconds = stats.Float64("splitstore/compaction_time", "Compaction time in seconds", stats.UnitSeconds)
 	SplitstoreCompactionHot         = stats.Int64("splitstore/hot", "Number of hot blocks in last compaction", stats.UnitDimensionless)
 	SplitstoreCompactionCold        = stats.Int64("splitstore/cold", "Number of cold blocks in last compaction", stats.UnitDimensionless)
 	SplitstoreCompactionDead        = stats.Int64("splitstore/dead", "Number of dead blocks in last compaction", stats.UnitDimensionless)
+	SplitstorePrefetched            = stats.Int64("splitstore/prefetched", "Number of cold blocks promoted to the hotstore on cold reads", stats.UnitDimensionless)
 
 	// rcmgr
 	RcmgrAllowConn      = stats.Int64("rcmgr/allow_conn", "Number of allowed connections", stats.UnitDimensionless)
@@ -327,6 +346,18 @@ var (
 		Aggregation: defaultMillisecondsDistribution,
 		TagKeys:     []tag.Key{APIInterface, Endpoint},
 	}
+	APIRequestCountView = &view.View{
+		Name:        "api/request_count",
+		Description: "Counter of API requests",
+		Measure:     APIRequestDuration,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{APIInterface, Endpoint},
+	}
+	APIRequestErrorsView = &view.View{
+		Measure:     APIRequestErrors,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{APIInterface, Endpoint},
+	}
 	VMFlushCopyDurationView = &view.View{
 		Measure:     VMFlushCopyDuration,
 		Aggregation: view.Sum(),
@@ -389,6 +420,26 @@ var (
 		Aggregation: view.LastValue(),
 		TagKeys:     []tag.Key{SectorState},
 	}
+	SectorStateDurationView = &view.View{
+		Measure:     SectorStateDuration,
+		Aggregation: stateSecondsDistribution,
+		TagKeys:     []tag.Key{SectorState},
+	}
+	SectorFailuresView = &view.View{
+		Measure:     SectorFailures,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{SectorState},
+	}
+	WorkerUtilizationView = &view.View{
+		Measure:     WorkerUtilization,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WorkerHostname},
+	}
+	WorkerActiveTasksView = &view.View{
+		Measure:     WorkerActiveTasks,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WorkerHostname},
+	}
 	StorageFSAvailableView = &view.View{
 		Measure:     StorageFSAvailable,
 		Aggregation: view.LastValue(),
@@ -497,6 +548,26 @@ var (
 		Measure:     DagStorePRSeekForwardBytes,
 		Aggregation: view.Sum(),
 	}
+	UnsealedCacheBytesView = &view.View{
+		Measure:     UnsealedCacheBytes,
+		Aggregation: view.LastValue(),
+	}
+	UnsealedCacheSectorsView = &view.View{
+		Measure:     UnsealedCacheSectors,
+		Aggregation: view.LastValue(),
+	}
+	UnsealedCacheHitsView = &view.View{
+		Measure:     UnsealedCacheHits,
+		Aggregation: view.Count(),
+	}
+	UnsealedCacheMissesView = &view.View{
+		Measure:     UnsealedCacheMisses,
+		Aggregation: view.Count(),
+	}
+	UnsealedCacheEvictionsView = &view.View{
+		Measure:     UnsealedCacheEvictions,
+		Aggregation: view.Count(),
+	}
 
 	// splitstore
 	SplitstoreMissView = &view.View{
@@ -519,6 +590,10 @@ var (
 		Measure:     SplitstoreCompactionDead,
 		Aggregation: view.Sum(),
 	}
+	SplitstorePrefetchedView = &view.View{
+		Measure:     SplitstorePrefetched,
+		Aggregation: view.Sum(),
+	}
 
 	// graphsync
 	GraphsyncReceivingPeersCountView = &view.View{
@@ -651,6 +726,8 @@ var DefaultViews = func() []*view.View {
 		InfoView,
 		PeerCountView,
 		APIRequestDurationView,
+		APIRequestCountView,
+		APIRequestErrorsView,
 
 		GraphsyncReceivingPeersCountView,
 		GraphsyncReceivingActiveCountView,
@@ -720,6 +797,7 @@ var ChainNodeViews = append([]*view.View{
 	SplitstoreCompactionHotView,
 	SplitstoreCompactionColdView,
 	SplitstoreCompactionDeadView,
+	SplitstorePrefetchedView,
 	VMApplyBlocksTotalView,
 	VMApplyMessagesView,
 	VMApplyEarlyView,
@@ -727,15 +805,19 @@ var ChainNodeViews = append([]*view.View{
 	VMApplyFlushView,
 	VMSendsView,
 	VMAppliedView,
-}, DefaultViews...)
+}, append(badgerbs.DefaultViews, DefaultViews...)...)
 
 var MinerNodeViews = append([]*view.View{
 	WorkerCallsStartedView,
 	WorkerCallsReturnedCountView,
 	WorkerUntrackedCallsReturnedView,
 	WorkerCallsReturnedDurationView,
+	WorkerUtilizationView,
+	WorkerActiveTasksView,
 
 	SectorStatesView,
+	SectorStateDurationView,
+	SectorFailuresView,
 	StorageFSAvailableView,
 	StorageAvailableView,
 	StorageReservedView,
@@ -762,6 +844,12 @@ var MinerNodeViews = append([]*view.View{
 	DagStorePRSeekForwardCountView,
 	DagStorePRSeekBackBytesView,
 	DagStorePRSeekForwardBytesView,
+
+	UnsealedCacheBytesView,
+	UnsealedCacheSectorsView,
+	UnsealedCacheHitsView,
+	UnsealedCacheMissesView,
+	UnsealedCacheEvictionsView,
 }, DefaultViews...)
 
 var GatewayNodeViews = append([]*view.View{
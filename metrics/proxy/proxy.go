@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 
+	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
 	"github.com/filecoin-project/lotus/api"
@@ -40,6 +41,8 @@ func MetricedGatewayAPI(a api.Gateway) api.Gateway {
 	return &out
 }
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 func proxy(in interface{}, outstr interface{}) {
 	outs := api.GetInternalStructs(outstr)
 	for _, out := range outs {
@@ -58,7 +61,17 @@ func proxy(in interface{}, outstr interface{}) {
 				defer stop()
 				// pass tagged ctx back into function call
 				args[0] = reflect.ValueOf(ctx)
-				return fn.Call(args)
+				results = fn.Call(args)
+
+				// count failed calls per endpoint; by convention the error is the last result
+				if len(results) > 0 {
+					last := results[len(results)-1]
+					if last.Type() == errorType && !last.IsNil() {
+						stats.Record(ctx, metrics.APIRequestErrors.M(1))
+					}
+				}
+
+				return results
 			}))
 		}
 	}
@@ -88,7 +88,9 @@ const (
 	InitMemoryWatchdog
 
 	// health checks
+	SetupAlertHooksKey
 	CheckFDLimit
+	CheckDiskSpaceKey
 
 	// libp2p
 	PstoreAddSelfKeysKey
@@ -97,6 +99,7 @@ const (
 
 	// filecoin
 	SetGenesisKey
+	SetReadOnlyHeadKey
 
 	RunHelloKey
 	RunChainExchangeKey
@@ -110,22 +113,37 @@ const (
 
 	RelayIndexerMessagesKey
 
+	HandleDealWebhooksKey
+	ServeClientHTTPGatewayKey
+
 	// miner
 	PreflightChecksKey
 	GetParamsKey
 	HandleMigrateProviderFundsKey
 	HandleDealsKey
 	HandleRetrievalKey
+	RestartStalledTransfersKey
+	HandlePieceAnnouncementsKey
+	TopUpMarketCollateralKey
 	RunSectorServiceKey
+	ServeBitswapKey
+	StagingRetentionKey
 
 	// daemon
 	ExtractApiKey
 	HeadMetricsKey
+	CheckSyncStallKey
+	SplitstoreAlertsKey
 	SettlePaymentChannelsKey
 	RunPeerTaggerKey
 	SetupFallbackBlockstoresKey
 	GoRPCServer
 
+	// config reload
+	ReloadLogLevelsKey
+	ReloadMpoolPeerLimitsKey
+	ReloadSplitstoreKey
+
 	SetApiEndpointKey
 
 	StoreEventsKey
@@ -266,6 +284,8 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 
 	return Options(
 		func(s *Settings) error { s.Config = true; return nil },
+		Override(new(*modules.ConfigReloader), modules.NewConfigReloader),
+		Override(ReloadLogLevelsKey, modules.ReloadLogLevels),
 		Override(new(dtypes.APIEndpoint), func() (dtypes.APIEndpoint, error) {
 			return multiaddr.NewMultiaddr(cfg.API.ListenAddress)
 		}),
@@ -308,6 +328,9 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 			If(!cfg.Libp2p.DisableNatPortMap, Override(NatPortMapKey, lp2p.NatPortMap)),
 		),
 		Override(new(dtypes.MetadataDS), modules.Datastore(cfg.Backup.DisableMetadataLog)),
+
+		Override(SetupAlertHooksKey, modules.AlertWebhooks(cfg.Alerting)),
+		Override(CheckDiskSpaceKey, modules.CheckDiskSpace(cfg.Alerting.MinFreeDiskRatio)),
 	)
 }
 
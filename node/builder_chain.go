@@ -2,6 +2,7 @@ package node
 
 import (
 	"os"
+	"time"
 
 	gorpc "github.com/libp2p/go-libp2p-gorpc"
 	"go.uber.org/fx"
@@ -34,6 +35,8 @@ import (
 	raftcns "github.com/filecoin-project/lotus/lib/consensus/raft"
 	"github.com/filecoin-project/lotus/lib/peermgr"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
+	"github.com/filecoin-project/lotus/markets/retrievalresume"
+	"github.com/filecoin-project/lotus/markets/retrievalstats"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/hello"
@@ -102,6 +105,10 @@ var ChainNode = Options(
 
 	// Service: Message Pool
 	Override(new(dtypes.DefaultMaxFeeFunc), modules.NewDefaultMaxFeeFunc),
+	Override(new(dtypes.FeeTiersWindowFunc), modules.NewFeeTiersWindowFunc),
+	Override(new(dtypes.MpoolDS), modules.MpoolDatastore),
+	Override(new(messagepool.SenderPolicy), messagepool.ConfigSenderPolicy{}),
+	Override(new(*messagepool.PeerLimiter), modules.MpoolPeerLimiter(config.MpoolConfig{})),
 	Override(new(*messagepool.MessagePool), modules.MessagePool),
 	Override(new(*dtypes.MpoolLocker), new(dtypes.MpoolLocker)),
 
@@ -113,6 +120,7 @@ var ChainNode = Options(
 	Override(new(messagesigner.MsgSigner), func(ms *messagesigner.MessageSigner) *messagesigner.MessageSigner { return ms }),
 	Override(new(*wallet.LocalWallet), wallet.NewWallet),
 	Override(new(wallet.Default), From(new(*wallet.LocalWallet))),
+	Override(new(wallet.Watched), From(new(*wallet.LocalWallet))),
 	Override(new(api.Wallet), From(new(wallet.MultiWallet))),
 
 	// Service: Payment channels
@@ -129,6 +137,8 @@ var ChainNode = Options(
 	Override(new(discovery.PeerResolver), modules.RetrievalResolver),
 	Override(new(retrievalmarket.BlockstoreAccessor), modules.RetrievalBlockstoreAccessor),
 	Override(new(retrievalmarket.RetrievalClient), modules.RetrievalClient(false)),
+	Override(new(*retrievalresume.Tracker), modules.RetrievalProgressTracker),
+	Override(new(*retrievalstats.Recorder), modules.RetrievalStatsRecorder),
 	Override(new(dtypes.ClientDataTransfer), modules.NewClientGraphsyncDataTransfer),
 
 	// Markets (storage)
@@ -141,6 +151,7 @@ var ChainNode = Options(
 	Override(HandleMigrateClientFundsKey, modules.HandleMigrateClientFunds),
 
 	Override(new(*full.GasPriceCache), full.NewGasPriceCache),
+	Override(new(*full.StateCallCache), full.NewStateCallCache),
 
 	Override(RelayIndexerMessagesKey, modules.RelayIndexerMessages),
 
@@ -175,6 +186,46 @@ var ChainNode = Options(
 	),
 )
 
+// ReadOnly serves the chain from the chainstore of the repo, or from the CAR file
+// at carPath when it is set, without syncing or writing to it. Objects written by
+// the node, such as the computed state of tipsets, are kept in memory, while
+// writes to the metadata of the repo fail. When serving a CAR file, the metadata
+// of the node is kept in memory instead, and the head is the tipset of the roots
+// of the CAR file.
+func ReadOnly(carPath string) Option {
+	return Options(
+		If(carPath == "",
+			Override(new(dtypes.UniversalBlockstore), modules.ReadOnlyUniversalBlockstore),
+			Override(new(dtypes.MetadataDS), modules.ReadOnlyMetadataDatastore),
+		),
+		If(carPath != "",
+			Override(new(dtypes.UniversalBlockstore), modules.CarUniversalBlockstore(carPath)),
+			Override(new(dtypes.MetadataDS), modules.MemoryMetadataDatastore),
+			Override(SetReadOnlyHeadKey, modules.SetCarHead(carPath)),
+		),
+
+		// the stores are served as they are, without compaction
+		Override(new(dtypes.BasicChainBlockstore), modules.ChainFlatBlockstore),
+		Override(new(dtypes.BasicStateBlockstore), modules.StateFlatBlockstore),
+		Override(new(dtypes.BaseBlockstore), From(new(dtypes.UniversalBlockstore))),
+		Override(new(dtypes.ExposedBlockstore), From(new(dtypes.UniversalBlockstore))),
+		Override(new(dtypes.GCReferenceProtector), modules.NoopGCReferenceProtector),
+		Unset(SplitstoreAlertsKey),
+		Unset(ReloadSplitstoreKey),
+		Override(new(index.MsgIndex), modules.DummyMsgIndex),
+		Override(new(index.ReceiptIndex), modules.DummyReceiptIndex),
+		Override(new(index.TipSetIndex), modules.DummyTipSetIndex),
+
+		// don't sync
+		Unset(RunHelloKey),
+		Unset(RunPeerMgrKey),
+		Unset(new(*peermgr.PeerMgr)),
+		Unset(HandleIncomingBlocksKey),
+		Unset(HandleIncomingMessagesKey),
+		Unset(CheckSyncStallKey),
+	)
+}
+
 func ConfigFullNode(c interface{}) Option {
 	cfg, ok := c.(*config.FullNode)
 	if !ok {
@@ -188,20 +239,32 @@ func ConfigFullNode(c interface{}) Option {
 		ConfigCommon(&cfg.Common, enableLibp2pNode),
 
 		Override(new(dtypes.UniversalBlockstore), modules.UniversalBlockstore),
+		If(cfg.Chainstore.RemoteBlockstore != "",
+			Override(new(dtypes.UniversalBlockstore), modules.RemoteUniversalBlockstore(&cfg.Chainstore))),
+
+		If(len(cfg.Mpool.PrioritySenders) > 0,
+			Override(new(messagepool.SenderPolicy), modules.MpoolSenderPolicy(cfg.Mpool)),
+		),
+		Override(new(*messagepool.PeerLimiter), modules.MpoolPeerLimiter(cfg.Mpool)),
+		Override(ReloadMpoolPeerLimitsKey, modules.ReloadMpoolPeerLimits),
 
 		If(cfg.Chainstore.EnableSplitstore,
 			If(cfg.Chainstore.Splitstore.ColdStoreType == "universal" || cfg.Chainstore.Splitstore.ColdStoreType == "messages",
 				Override(new(dtypes.ColdBlockstore), From(new(dtypes.UniversalBlockstore)))),
 			If(cfg.Chainstore.Splitstore.ColdStoreType == "discard",
 				Override(new(dtypes.ColdBlockstore), modules.DiscardColdBlockstore)),
+			If(cfg.Chainstore.Splitstore.ColdStoreType == "ipfs",
+				Override(new(dtypes.ColdBlockstore), modules.IpfsColdBlockstore(&cfg.Chainstore))),
 			If(cfg.Chainstore.Splitstore.HotStoreType == "badger",
-				Override(new(dtypes.HotBlockstore), modules.BadgerHotBlockstore)),
+				Override(new(dtypes.HotBlockstore), modules.BadgerHotBlockstore(&cfg.Chainstore))),
 			Override(new(dtypes.SplitBlockstore), modules.SplitBlockstore(&cfg.Chainstore)),
 			Override(new(dtypes.BasicChainBlockstore), modules.ChainSplitBlockstore),
 			Override(new(dtypes.BasicStateBlockstore), modules.StateSplitBlockstore),
 			Override(new(dtypes.BaseBlockstore), From(new(dtypes.SplitBlockstore))),
 			Override(new(dtypes.ExposedBlockstore), modules.ExposedSplitBlockstore),
 			Override(new(dtypes.GCReferenceProtector), modules.SplitBlockstoreGCReferenceProtector),
+			Override(SplitstoreAlertsKey, modules.SplitstoreAlerts),
+			Override(ReloadSplitstoreKey, modules.ReloadSplitstore),
 		),
 		If(!cfg.Chainstore.EnableSplitstore,
 			Override(new(dtypes.BasicChainBlockstore), modules.ChainFlatBlockstore),
@@ -219,6 +282,11 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(dtypes.StateBlockstore), modules.FallbackStateBlockstore),
 			Override(SetupFallbackBlockstoresKey, modules.InitFallbackBlockstores),
 		),
+		If(cfg.Chainstore.EnableIpfsFallback,
+			Override(new(dtypes.ChainBlockstore), modules.FallbackChainBlockstore),
+			Override(new(dtypes.StateBlockstore), modules.FallbackStateBlockstore),
+			Override(SetupFallbackBlockstoresKey, modules.InitIpfsFallbackBlockstores(&cfg.Chainstore, os.Getenv("LOTUS_ENABLE_CHAINSTORE_FALLBACK") == "1")),
+		),
 
 		// If the Eth JSON-RPC is enabled, enable storing events at the ChainStore.
 		// This is the case even if real-time and historic filtering are disabled,
@@ -239,9 +307,19 @@ func ConfigFullNode(c interface{}) Option {
 		Override(new(dtypes.Graphsync), modules.Graphsync(cfg.Client.SimultaneousTransfersForStorage, cfg.Client.SimultaneousTransfersForRetrieval)),
 
 		Override(new(retrievalmarket.RetrievalClient), modules.RetrievalClient(cfg.Client.OffChainRetrieval)),
+		If(len(cfg.Client.DealWebhooks.URLs) > 0,
+			Override(HandleDealWebhooksKey, modules.HandleClientDealWebhooks(cfg.Client.DealWebhooks)),
+		),
+		If(cfg.Client.HTTPGateway.ListenAddress != "",
+			Override(ServeClientHTTPGatewayKey, modules.ClientHTTPGateway(cfg.Client.HTTPGateway)),
+		),
 
 		If(cfg.Wallet.RemoteBackend != "",
-			Override(new(*remotewallet.RemoteWallet), remotewallet.SetupRemoteWallet(cfg.Wallet.RemoteBackend)),
+			Override(new(*remotewallet.RemoteWallet), remotewallet.SetupRemoteWallet(cfg.Wallet.RemoteBackend, remotewallet.TLSConfig{
+				Cert: cfg.Wallet.RemoteBackendTLSCert,
+				Key:  cfg.Wallet.RemoteBackendTLSKey,
+				CA:   cfg.Wallet.RemoteBackendTLSCA,
+			})),
 		),
 		If(cfg.Wallet.EnableLedger,
 			Override(new(*ledgerwallet.LedgerWallet), ledgerwallet.NewWallet),
@@ -249,6 +327,7 @@ func ConfigFullNode(c interface{}) Option {
 		If(cfg.Wallet.DisableLocal,
 			Unset(new(*wallet.LocalWallet)),
 			Override(new(wallet.Default), wallet.NilDefault),
+			Override(new(wallet.Watched), wallet.NilWatched),
 		),
 
 		// Chain node cluster enabled
@@ -265,6 +344,11 @@ func ConfigFullNode(c interface{}) Option {
 		// Actor event filtering support
 		Override(new(events.EventAPI), From(new(modules.EventAPI))),
 
+		// lite nodes don't sync the chain
+		ApplyIf(isFullNode,
+			Override(CheckSyncStallKey, modules.CheckSyncStall(time.Duration(cfg.Alerting.SyncStallTimeout))),
+		),
+
 		// in lite-mode Eth api is provided by gateway
 		ApplyIf(isFullNode,
 			If(cfg.Fevm.EnableEthRPC,
@@ -280,6 +364,11 @@ func ConfigFullNode(c interface{}) Option {
 		// enable message index for full node when configured by the user, otherwise use dummy.
 		If(cfg.Index.EnableMsgIndex, Override(new(index.MsgIndex), modules.MsgIndex)),
 		If(!cfg.Index.EnableMsgIndex, Override(new(index.MsgIndex), modules.DummyMsgIndex)),
+		If(cfg.Index.EnableReceiptIndex, Override(new(index.ReceiptIndex), modules.ReceiptIndex)),
+		If(!cfg.Index.EnableReceiptIndex, Override(new(index.ReceiptIndex), modules.DummyReceiptIndex)),
+		If(cfg.Index.EnableTipSetIndex, Override(new(index.TipSetIndex), modules.TipSetIndex)),
+		If(!cfg.Index.EnableTipSetIndex, Override(new(index.TipSetIndex), modules.DummyTipSetIndex)),
+		Override(new(dtypes.MaxMsgSearchLookback), dtypes.MaxMsgSearchLookback(cfg.Index.MaxMsgSearchLookback)),
 	)
 }
 
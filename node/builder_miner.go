@@ -21,6 +21,9 @@ import (
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/markets/dagstore"
 	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/dealquota"
+	"github.com/filecoin-project/lotus/markets/dtprogress"
+	"github.com/filecoin-project/lotus/markets/idxannounce"
 	"github.com/filecoin-project/lotus/markets/idxprov"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/sectoraccessor"
@@ -33,6 +36,7 @@ import (
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/msgagg"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	sectorstorage "github.com/filecoin-project/lotus/storage/sealer"
@@ -70,8 +74,12 @@ func ConfigStorageMiner(c interface{}) Option {
 		if pricingConfig.External.Path == "" {
 			return Error(xerrors.New("retrieval pricing policy has been to set to external but external script path is empty"))
 		}
+	} else if pricingConfig.Strategy == config.RetrievalPricingRulesMode {
+		if pricingConfig.Rules == nil {
+			return Error(xerrors.New("retrieval pricing policy has been to set to rules but rules policy config is nil"))
+		}
 	} else if pricingConfig.Strategy != config.RetrievalPricingDefaultMode {
-		return Error(xerrors.New("retrieval pricing policy must be either default or external"))
+		return Error(xerrors.New("retrieval pricing policy must be either default, external or rules"))
 	}
 
 	enableLibp2pNode := cfg.Subsystems.EnableMarkets // we enable libp2p nodes if the storage market subsystem is enabled, otherwise we don't
@@ -131,6 +139,7 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(paths.SectorIndex), From(new(*paths.Index))),
 			Override(new(*sectorstorage.Manager), modules.SectorStorage),
 			Override(new(sectorstorage.Unsealer), From(new(*sectorstorage.Manager))),
+			Override(new(sectorstorage.UnsealedReleaser), From(new(*sectorstorage.Manager))),
 			Override(new(sectorstorage.SectorManager), From(new(*sectorstorage.Manager))),
 			Override(new(storiface.WorkerReturn), From(new(sectorstorage.SectorManager))),
 		),
@@ -139,6 +148,7 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(sectorstorage.StorageAuth), modules.StorageAuthWithURL(cfg.Subsystems.SectorIndexApiInfo)),
 			Override(new(modules.MinerStorageService), modules.ConnectStorageService(cfg.Subsystems.SectorIndexApiInfo)),
 			Override(new(sectorstorage.Unsealer), From(new(modules.MinerStorageService))),
+			Override(new(sectorstorage.UnsealedReleaser), From(new(modules.MinerStorageService))),
 			Override(new(sectorblocks.SectorBuilder), From(new(modules.MinerStorageService))),
 		),
 		If(!cfg.Subsystems.EnableSealing,
@@ -148,13 +158,13 @@ func ConfigStorageMiner(c interface{}) Option {
 
 		If(cfg.Subsystems.EnableMarkets,
 			// Markets
-			Override(new(dtypes.StagingBlockstore), modules.StagingBlockstore),
+			Override(new(dtypes.StagingBlockstore), modules.StagingBlockstore(cfg.StagingBlockstore)),
 			Override(new(dtypes.StagingGraphsync), modules.StagingGraphsync(cfg.Dealmaking.SimultaneousTransfersForStorage, cfg.Dealmaking.SimultaneousTransfersForStoragePerClient, cfg.Dealmaking.SimultaneousTransfersForRetrieval)),
 			Override(new(dtypes.ProviderPieceStore), modules.NewProviderPieceStore),
 			Override(new(*sectorblocks.SectorBlocks), sectorblocks.NewSectorBlocks),
 
 			// Markets (retrieval deps)
-			Override(new(sectorstorage.PieceProvider), sectorstorage.NewPieceProvider),
+			Override(new(sectorstorage.PieceProvider), modules.UnsealedCache(cfg.Dealmaking.UnsealedCache)),
 			Override(new(dtypes.RetrievalPricingFunc), modules.RetrievalPricingFunc(config.DealmakingConfig{
 				RetrievalPricing: &config.RetrievalPricing{
 					Strategy: config.RetrievalPricingDefaultMode,
@@ -175,19 +185,38 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(retrievalmarket.RetrievalProvider), modules.RetrievalProvider),
 			Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(nil)),
 			Override(HandleRetrievalKey, modules.HandleRetrieval),
+			If(cfg.Bitswap.Enable,
+				Override(ServeBitswapKey, modules.BitswapServer(cfg.Bitswap)),
+			),
 
 			// Markets (storage)
 			Override(new(dtypes.ProviderTransferNetwork), modules.NewProviderTransferNetwork),
 			Override(new(dtypes.ProviderTransport), modules.NewProviderTransport),
 			Override(new(dtypes.ProviderDataTransfer), modules.NewProviderDataTransfer),
+			Override(new(*dtprogress.Tracker), modules.NewDataTransferTracker),
+			If(cfg.Dealmaking.StalledTransferTimeout > 0,
+				Override(RestartStalledTransfersKey, modules.RestartStalledTransfers(cfg.Dealmaking)),
+			),
 			Override(new(idxprov.MeshCreator), idxprov.NewMeshCreator),
 			Override(new(provider.Interface), modules.IndexProvider(cfg.IndexProvider)),
 			Override(new(*storedask.StoredAsk), modules.NewStorageAsk),
 			Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg.Dealmaking, nil)),
+			Override(new(*dealquota.Enforcer), modules.NewDealQuotaEnforcer(cfg.Dealmaking)),
 			Override(new(storagemarket.StorageProvider), modules.StorageProvider),
 			Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(nil, storageadapter.PublishMsgConfig{})),
 			Override(HandleMigrateProviderFundsKey, modules.HandleMigrateProviderFunds),
+			Override(TopUpMarketCollateralKey, modules.TopUpMarketCollateral(cfg.Dealmaking.CollateralTopUp)),
 			Override(HandleDealsKey, modules.HandleDeals),
+			If(len(cfg.Dealmaking.DealWebhooks.URLs) > 0,
+				Override(HandleDealWebhooksKey, modules.HandleProviderDealWebhooks(cfg.Dealmaking.DealWebhooks)),
+			),
+			If(cfg.StagingBlockstore.EnableRetention,
+				Override(StagingRetentionKey, modules.StagingRetention(cfg.StagingBlockstore)),
+			),
+			If(cfg.IndexProvider.AnnounceEndpoint != "",
+				Override(new(*idxannounce.Announcer), modules.NewPieceAnnouncer(cfg.IndexProvider)),
+				Override(HandlePieceAnnouncementsKey, modules.HandlePieceAnnouncements),
+			),
 
 			// Config (todo: get a real property system)
 			Override(new(dtypes.ConsiderOnlineStorageDealsConfigFunc), modules.NewConsiderOnlineStorageDealsConfigFunc),
@@ -209,12 +238,12 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(dtypes.SetMaxDealStartDelayFunc), modules.NewSetMaxDealStartDelayFunc),
 			Override(new(dtypes.GetMaxDealStartDelayFunc), modules.NewGetMaxDealStartDelayFunc),
 
-			If(cfg.Dealmaking.Filter != "",
-				Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg.Dealmaking, dealfilter.CliStorageDealFilter(cfg.Dealmaking.Filter))),
+			If(cfg.Dealmaking.Filter != "" || cfg.Dealmaking.FilterExpression != "",
+				userStorageDealFilter(cfg.Dealmaking),
 			),
 
-			If(cfg.Dealmaking.RetrievalFilter != "",
-				Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(dealfilter.CliRetrievalDealFilter(cfg.Dealmaking.RetrievalFilter))),
+			If(cfg.Dealmaking.RetrievalFilter != "" || cfg.Dealmaking.RetrievalFilterExpression != "" || cfg.Dealmaking.MaxRetrievalsPerClientPerMinute > 0,
+				userRetrievalDealFilter(cfg.Dealmaking),
 			),
 			Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(&cfg.Fees, storageadapter.PublishMsgConfig{
 				Period:                  time.Duration(cfg.Dealmaking.PublishMsgPeriod),
@@ -227,6 +256,7 @@ func ConfigStorageMiner(c interface{}) Option {
 		Override(new(config.SealerConfig), cfg.Storage),
 		Override(new(config.ProvingConfig), cfg.Proving),
 		Override(new(*ctladdr.AddressSelector), modules.AddressSelector(&cfg.Addresses)),
+		Override(new(*msgagg.Aggregator), msgagg.NewAggregator(cfg.MessageAggregation)),
 	)
 }
 
@@ -250,3 +280,47 @@ func StorageMiner(out *api.StorageMiner, subsystemsCfg config.MinerSubsystemConf
 		},
 	)
 }
+
+// userStorageDealFilter combines the storage deal filter expression and
+// command from the config, evaluating the expression first.
+func userStorageDealFilter(cfg config.DealmakingConfig) Option {
+	var filters []dtypes.StorageDealFilter
+
+	if cfg.FilterExpression != "" {
+		f, err := dealfilter.ExprStorageDealFilter(cfg.FilterExpression)
+		if err != nil {
+			return Error(err)
+		}
+		filters = append(filters, f)
+	}
+
+	if cfg.Filter != "" {
+		filters = append(filters, dealfilter.CliStorageDealFilter(cfg.Filter))
+	}
+
+	return Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg, dealfilter.Chain(filters...)))
+}
+
+// userRetrievalDealFilter combines the retrieval rate limit, filter expression
+// and command from the config, in that order.
+func userRetrievalDealFilter(cfg config.DealmakingConfig) Option {
+	var filters []dtypes.RetrievalDealFilter
+
+	if cfg.MaxRetrievalsPerClientPerMinute > 0 {
+		filters = append(filters, dealfilter.RetrievalRateLimitFilter(cfg.MaxRetrievalsPerClientPerMinute, cfg.RetrievalBurstPerClient))
+	}
+
+	if cfg.RetrievalFilterExpression != "" {
+		f, err := dealfilter.ExprRetrievalDealFilter(cfg.RetrievalFilterExpression)
+		if err != nil {
+			return Error(err)
+		}
+		filters = append(filters, f)
+	}
+
+	if cfg.RetrievalFilter != "" {
+		filters = append(filters, dealfilter.CliRetrievalDealFilter(cfg.RetrievalFilter))
+	}
+
+	return Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(dealfilter.RetrievalChain(filters...)))
+}
@@ -23,6 +23,9 @@ const (
 	// RetrievalPricingExternal configures the node to use the external retrieval pricing script
 	// configured by the user.
 	RetrievalPricingExternalMode = "external"
+	// RetrievalPricingRulesMode configures the node to price retrievals with the configured
+	// per piece, per client and data age rules.
+	RetrievalPricingRulesMode = "rules"
 )
 
 // MaxTraversalLinks configures the maximum number of links to traverse in a DAG while calculating
@@ -69,11 +72,18 @@ func defCommon() Common {
 			Bootstrapper: false,
 			DirectPeers:  nil,
 		},
+		Alerting: Alerting{
+			WebhookURLs:      []string{},
+			WebhookTimeout:   Duration(10 * time.Second),
+			MinFreeDiskRatio: 0.05,
+			SyncStallTimeout: Duration(10 * time.Minute),
+		},
 	}
 }
 
 var (
 	DefaultDefaultMaxFee         = types.MustParseFIL("0.07")
+	DefaultFeeTiersWindow        = uint64(20)
 	DefaultSimultaneousTransfers = uint64(20)
 )
 
@@ -82,11 +92,26 @@ func DefaultFullNode() *FullNode {
 	return &FullNode{
 		Common: defCommon(),
 		Fees: FeeConfig{
-			DefaultMaxFee: DefaultDefaultMaxFee,
+			DefaultMaxFee:  DefaultDefaultMaxFee,
+			FeeTiersWindow: DefaultFeeTiersWindow,
+		},
+		Mpool: MpoolConfig{
+			PrioritySenders: []string{},
+			PeerRateBurst:   100,
+			PeerBanDuration: Duration(10 * time.Minute),
 		},
 		Client: Client{
 			SimultaneousTransfersForStorage:   DefaultSimultaneousTransfers,
 			SimultaneousTransfersForRetrieval: DefaultSimultaneousTransfers,
+			DealWebhooks: DealWebhooks{
+				URLs:    []string{},
+				Timeout: Duration(10 * time.Second),
+				Events:  []string{},
+			},
+			HTTPGateway: ClientHTTPGateway{
+				MaxRetrievalPrice: types.MustParseFIL("0"),
+				RetrievalTimeout:  Duration(30 * time.Minute),
+			},
 		},
 		Chainstore: Chainstore{
 			EnableSplitstore: true,
@@ -98,6 +123,15 @@ func DefaultFullNode() *FullNode {
 				HotStoreFullGCFrequency:      20,
 				HotStoreMaxSpaceThreshold:    150_000_000_000,
 				HotstoreMaxSpaceSafetyBuffer: 50_000_000_000,
+				DiskSpaceLowThreshold:        20_000_000_000,
+				DiskSpaceCriticalThreshold:   5_000_000_000,
+				ShutdownGracePeriod:          Duration(10 * time.Minute),
+
+				ColdReadPromotion:       "never",
+				ColdReadPromotionHits:   3,
+				ColdReadPromotionWindow: 120,
+
+				CompactionStrategy: "epoch",
 			},
 		},
 		Cluster: *DefaultUserRaftConfig(),
@@ -149,6 +183,8 @@ func DefaultStorageMiner() *StorageMiner {
 
 			BatchPreCommitAboveBaseFee: types.FIL(types.BigMul(types.PicoFil, types.NewInt(320))), // 0.32 nFIL
 			AggregateAboveBaseFee:      types.FIL(types.BigMul(types.PicoFil, types.NewInt(320))), // 0.32 nFIL
+			HoldPreCommitsAboveBaseFee: types.FIL(big.Zero()),
+			HoldCommitsAboveBaseFee:    types.FIL(big.Zero()),
 
 			TerminateBatchMin:  1,
 			TerminateBatchMax:  100,
@@ -180,6 +216,9 @@ func DefaultStorageMiner() *StorageMiner {
 
 			// By default use the hardware resource filtering strategy.
 			ResourceFiltering: ResourceFilteringHardware,
+			ResourceOverrides: map[string]string{},
+
+			LocalWorkerTaskPriorities: map[string]int{},
 		},
 
 		Dealmaking: DealmakingConfig{
@@ -203,6 +242,29 @@ func DefaultStorageMiner() *StorageMiner {
 
 			StartEpochSealingBuffer: 480, // 480 epochs buffer == 4 hours from adding deal to sector to sector being sealed
 
+			StalledTransferTimeout:     Duration(10 * time.Minute),
+			StalledTransferMaxRestarts: 3,
+
+			DealWebhooks: DealWebhooks{
+				URLs:    []string{},
+				Timeout: Duration(10 * time.Second),
+				Events:  []string{},
+			},
+
+			CollateralTopUp: CollateralTopUpConfig{
+				Threshold:        types.MustParseFIL("0"),
+				Amount:           types.MustParseFIL("5"),
+				MaxPerDay:        types.MustParseFIL("20"),
+				MinWalletBalance: types.MustParseFIL("1"),
+			},
+
+			UnsealedCache: UnsealedCacheConfig{
+				MaxBytes: 0,
+				TTL:      Duration(24 * time.Hour),
+			},
+
+			RetrievalBurstPerClient: 10,
+
 			RetrievalPricing: &RetrievalPricing{
 				Strategy: RetrievalPricingDefaultMode,
 				Default: &RetrievalPricingDefault{
@@ -211,6 +273,10 @@ func DefaultStorageMiner() *StorageMiner {
 				External: &RetrievalPricingExternal{
 					Path: "",
 				},
+				Rules: &RetrievalPricingRules{
+					VerifiedDealsFreeTransfer: true,
+					Rules:                     []RetrievalPricingRule{},
+				},
 			},
 		},
 
@@ -220,8 +286,10 @@ func DefaultStorageMiner() *StorageMiner {
 			EntriesChunkSize:     16384,
 			// The default empty TopicName means it is inferred from network name, in the following
 			// format: "/indexer/ingest/<network-name>"
-			TopicName:         "",
-			PurgeCacheOnStart: false,
+			TopicName:           "",
+			PurgeCacheOnStart:   false,
+			AnnounceEndpoint:    "",
+			AnnounceMaxAttempts: 10,
 		},
 
 		Subsystems: MinerSubsystemConfig{
@@ -263,6 +331,27 @@ func DefaultStorageMiner() *StorageMiner {
 			MaxConcurrentUnseals:       5,
 			GCInterval:                 Duration(1 * time.Minute),
 		},
+
+		Bitswap: BitswapConfig{
+			AllowedPeers:               []string{},
+			DeniedPeers:                []string{},
+			MaxBlocksPerSecondPerPeer:  100,
+			BlocksBurstPerPeer:         500,
+			MaxOutstandingBytesPerPeer: 1 << 20,
+			TaskWorkers:                8,
+		},
+
+		MessageAggregation: MessageAggregationConfig{
+			Methods: []string{"WithdrawBalance"},
+		},
+
+		SharedChainstore: SharedChainstoreConfig{
+			CacheSize: 100000,
+		},
+
+		StagingBlockstore: StagingBlockstoreConfig{
+			CompactionInterval: Duration(time.Hour),
+		},
 	}
 
 	cfg.Common.API.ListenAddress = "/ip4/127.0.0.1/tcp/2345/http"
@@ -29,6 +29,37 @@ var Doc = map[string][]DocField{
 			Comment: ``,
 		},
 	},
+	"Alerting": []DocField{
+		{
+			Name: "WebhookURLs",
+			Type: "[]string",
+
+			Comment: `WebhookURLs is a list of URLs which receive a JSON POST request every time
+an alert is raised or resolved. The body contains the alert System,
+Subsystem, event Type ('raised' or 'resolved'), Message and Time.`,
+		},
+		{
+			Name: "WebhookTimeout",
+			Type: "Duration",
+
+			Comment: `WebhookTimeout is the timeout for a single webhook request`,
+		},
+		{
+			Name: "MinFreeDiskRatio",
+			Type: "float64",
+
+			Comment: `MinFreeDiskRatio is the fraction of free disk space in the repo below which
+the disk-space alert is raised. Set to 0 to disable the check.`,
+		},
+		{
+			Name: "SyncStallTimeout",
+			Type: "Duration",
+
+			Comment: `SyncStallTimeout is the duration for which the chain head can stay unchanged
+while being behind wall-clock time before the sync stall alert is raised.
+Only applies to full nodes. Set to 0 to disable the check.`,
+		},
+	},
 	"Backup": []DocField{
 		{
 			Name: "DisableMetadataLog",
@@ -55,6 +86,57 @@ your node if metadata log is disabled`,
 			Comment: ``,
 		},
 	},
+	"BitswapConfig": []DocField{
+		{
+			Name: "Enable",
+			Type: "bool",
+
+			Comment: `When enabled, the payload blocks of deals with an unsealed copy are
+served for free over bitswap, alongside the retrieval market protocol.
+Blocks of deals which are only sealed are never served, so requests
+can't trigger unsealing.`,
+		},
+		{
+			Name: "AllowedPeers",
+			Type: "[]string",
+
+			Comment: `Peer IDs of the only peers served, all peers are served when empty.`,
+		},
+		{
+			Name: "DeniedPeers",
+			Type: "[]string",
+
+			Comment: `Peer IDs of peers which are never served.`,
+		},
+		{
+			Name: "MaxBlocksPerSecondPerPeer",
+			Type: "float64",
+
+			Comment: `The maximum number of blocks served per second to a single peer. 0
+means unlimited.`,
+		},
+		{
+			Name: "BlocksBurstPerPeer",
+			Type: "int",
+
+			Comment: `The number of blocks a single peer can request at once, above
+MaxBlocksPerSecondPerPeer.`,
+		},
+		{
+			Name: "MaxOutstandingBytesPerPeer",
+			Type: "int",
+
+			Comment: `The maximum size in bytes of the blocks queued for sending to a single
+peer. 0 uses the bitswap default.`,
+		},
+		{
+			Name: "TaskWorkers",
+			Type: "int",
+
+			Comment: `The number of workers sending blocks to peers. 0 uses the bitswap
+default.`,
+		},
+	},
 	"Chainstore": []DocField{
 		{
 			Name: "EnableSplitstore",
@@ -68,6 +150,34 @@ your node if metadata log is disabled`,
 
 			Comment: ``,
 		},
+		{
+			Name: "IpfsMAddr",
+			Type: "string",
+
+			Comment: `IpfsMAddr is the multiaddress of the API of the IPFS node used as the
+coldstore when Splitstore.ColdStoreType is "ipfs", and for reading blocks
+missing from the chainstore when EnableIpfsFallback is set. When empty, the
+local IPFS node configured with IPFS_PATH is used.`,
+		},
+		{
+			Name: "EnableIpfsFallback",
+			Type: "bool",
+
+			Comment: `EnableIpfsFallback reads the blocks missing from the chainstore from the
+IPFS node, which only serves the blocks it already has.`,
+		},
+		{
+			Name: "RemoteBlockstore",
+			Type: "string",
+
+			Comment: `RemoteBlockstore is the API info, in the "token:multiaddr" format, of the
+lotus node whose chain blockstore is used as the chainstore of this node,
+instead of a local one, so that multiple nodes can share one storage
+backend. The token needs the admin permission, as the node puts objects;
+deleting objects from the remote node is never allowed. The node
+reconnects when the connection drops, and chain operations fail while the
+remote node is unreachable. The splitstore must be disabled when it is set.`,
+		},
 	},
 	"Client": []DocField{
 		{
@@ -116,6 +226,106 @@ and storage providers for retrieval deals`,
 without existing payment channels with available funds will fail instead
 of automatically performing on-chain operations.`,
 		},
+		{
+			Name: "DealWebhooks",
+			Type: "DealWebhooks",
+
+			Comment: `Webhooks notified of the lifecycle events of the storage deals made by
+the client.`,
+		},
+		{
+			Name: "HTTPGateway",
+			Type: "ClientHTTPGateway",
+
+			Comment: `A gateway serving the imported and retrieved content of the client over
+plain HTTP, so that applications can consume it without using the API.`,
+		},
+	},
+	"ClientHTTPGateway": []DocField{
+		{
+			Name: "ListenAddress",
+			Type: "string",
+
+			Comment: `Binding address of the gateway, which serves content under /ipfs/<cid>,
+as a UnixFS file, or as a CAR with ?format=car. The gateway doesn't
+require authentication. Empty disables the gateway.
+Format: multiaddress, e.g. /ip4/127.0.0.1/tcp/8080/http`,
+		},
+		{
+			Name: "RetrieveOnMiss",
+			Type: "bool",
+
+			Comment: `When enabled, the content which isn't available locally is retrieved
+from the storage providers offering it.`,
+		},
+		{
+			Name: "MaxRetrievalPrice",
+			Type: "types.FIL",
+
+			Comment: `The maximum price paid for a retrieval made by the gateway.`,
+		},
+		{
+			Name: "RetrievalTimeout",
+			Type: "Duration",
+
+			Comment: `Time after which a retrieval made by the gateway is abandoned.`,
+		},
+	},
+	"ClientQuota": []DocField{
+		{
+			Name: "Client",
+			Type: "string",
+
+			Comment: `Address of the client, either its ID or robust address, or "*"`,
+		},
+		{
+			Name: "MaxOpenDeals",
+			Type: "uint64",
+
+			Comment: `Maximum number of the client's deals in progress at the same time, from
+acceptance until the deal is active or fails. 0 is unlimited.`,
+		},
+		{
+			Name: "MaxBytesPerDay",
+			Type: "uint64",
+
+			Comment: `Maximum padded size in bytes of the client's deals accepted within 24
+hours. 0 is unlimited.`,
+		},
+	},
+	"CollateralTopUpConfig": []DocField{
+		{
+			Name: "Threshold",
+			Type: "types.FIL",
+
+			Comment: `When the available market balance of the miner drops below Threshold,
+Amount is added to it. 0 disables the top-ups.`,
+		},
+		{
+			Name: "Amount",
+			Type: "types.FIL",
+
+			Comment: `The amount added to the market balance on each top-up.`,
+		},
+		{
+			Name: "MaxPerDay",
+			Type: "types.FIL",
+
+			Comment: `The maximum amount added within 24 hours. 0 is unlimited.`,
+		},
+		{
+			Name: "Wallet",
+			Type: "string",
+
+			Comment: `The wallet the funds are sent from. Defaults to the worker address.`,
+		},
+		{
+			Name: "MinWalletBalance",
+			Type: "types.FIL",
+
+			Comment: `The balance left in the wallet after a top-up. Top-ups which would
+leave less are skipped and raise an alert.`,
+		},
 	},
 	"Common": []DocField{
 		{
@@ -146,6 +356,12 @@ of automatically performing on-chain operations.`,
 			Name: "Pubsub",
 			Type: "Pubsub",
 
+			Comment: ``,
+		},
+		{
+			Name: "Alerting",
+			Type: "Alerting",
+
 			Comment: ``,
 		},
 	},
@@ -206,6 +422,31 @@ representation, e.g. 1m, 5m, 1h.
 Default value: 1 minute.`,
 		},
 	},
+	"DealWebhooks": []DocField{
+		{
+			Name: "URLs",
+			Type: "[]string",
+
+			Comment: `URLs which receive a JSON POST request for every lifecycle event of a
+deal. The body contains the Side ('client' or 'provider'), the Event,
+the deal State, ProposalCid, DealID, Client, Provider, PieceCid,
+PieceSize, Verified, StartEpoch, EndEpoch, Message and Time.`,
+		},
+		{
+			Name: "Timeout",
+			Type: "Duration",
+
+			Comment: `The timeout for a single webhook request`,
+		},
+		{
+			Name: "Events",
+			Type: "[]string",
+
+			Comment: `The events to notify of, any of 'accepted', 'rejected', 'published',
+'sealed', 'active', 'slashed', 'expired' and 'failed'. Empty notifies
+of all the events.`,
+		},
+	},
 	"DealmakingConfig": []DocField{
 		{
 			Name: "ConsiderOnlineStorageDeals",
@@ -320,12 +561,68 @@ regardless of this number.`,
 
 			Comment: `Minimum start epoch buffer to give time for sealing of sector with deal.`,
 		},
+		{
+			Name: "ClientQuotas",
+			Type: "[]ClientQuota",
+
+			Comment: `Limits on the storage deals accepted from each client, checked after all
+the other deal filters. A quota whose Client is "*" applies to all the
+clients without a quota of their own.`,
+		},
+		{
+			Name: "StalledTransferTimeout",
+			Type: "Duration",
+
+			Comment: `Time after which a data transfer which made no progress is restarted.
+Set to 0 to disable restarting stalled transfers.`,
+		},
+		{
+			Name: "StalledTransferMaxRestarts",
+			Type: "int",
+
+			Comment: `The number of times a stalled data transfer is restarted without making
+progress before giving up on it.`,
+		},
+		{
+			Name: "DealWebhooks",
+			Type: "DealWebhooks",
+
+			Comment: `Webhooks notified of the lifecycle events of the storage deals made with
+the provider.`,
+		},
+		{
+			Name: "CollateralTopUp",
+			Type: "CollateralTopUpConfig",
+
+			Comment: `Automatic top-ups of the market escrow holding the provider collateral
+of deals, so that publishing deals doesn't fail for lack of collateral.`,
+		},
+		{
+			Name: "UnsealedCache",
+			Type: "UnsealedCacheConfig",
+
+			Comment: `The unsealed copies of sectors made to serve retrievals are kept for
+faster subsequent retrievals, within the limits set here.`,
+		},
 		{
 			Name: "Filter",
 			Type: "string",
 
 			Comment: `A command used for fine-grained evaluation of storage deals
 see https://lotus.filecoin.io/storage-providers/advanced-configurations/market/#using-filters-for-fine-grained-storage-and-retrieval-deal-acceptance for more details`,
+		},
+		{
+			Name: "FilterExpression",
+			Type: "string",
+
+			Comment: `An expression used for fine-grained evaluation of storage deals, evaluated
+before Filter. A deal is accepted when the expression evaluates to true.
+Expressions use Go syntax and can refer to Client, Provider, PieceCID,
+PieceSize, Verified, Offline, StartEpoch, EndEpoch, Duration,
+PricePerEpoch, PricePerGiBEpoch, TotalPrice, ProviderCollateral and
+ClientCollateral, with prices in attoFIL. The functions in(x, a, b, ...),
+fil("0.5") and size("32GiB") are available.
+e.g. 'Verified || (PieceSize >= size("1GiB") && PricePerGiBEpoch >= fil("0.0000000005"))'`,
 		},
 		{
 			Name: "RetrievalFilter",
@@ -333,6 +630,32 @@ see https://lotus.filecoin.io/storage-providers/advanced-configurations/market/#
 
 			Comment: `A command used for fine-grained evaluation of retrieval deals
 see https://lotus.filecoin.io/storage-providers/advanced-configurations/market/#using-filters-for-fine-grained-storage-and-retrieval-deal-acceptance for more details`,
+		},
+		{
+			Name: "RetrievalFilterExpression",
+			Type: "string",
+
+			Comment: `An expression used for fine-grained evaluation of retrieval deals,
+evaluated before RetrievalFilter. A retrieval is accepted when the
+expression evaluates to true. Expressions have the same syntax and
+functions as FilterExpression, and can refer to Client (peer ID),
+PayloadCID, PieceCID, PieceSize, PricePerByte, UnsealPrice and
+PaymentInterval.
+e.g. 'PricePerByte > 0 || PieceSize <= size("1GiB")'`,
+		},
+		{
+			Name: "MaxRetrievalsPerClientPerMinute",
+			Type: "float64",
+
+			Comment: `The maximum number of retrieval proposals accepted from a single client
+per minute, before the other retrieval filters. 0 is unlimited.`,
+		},
+		{
+			Name: "RetrievalBurstPerClient",
+			Type: "int",
+
+			Comment: `The number of retrieval proposals a single client can make at once,
+above MaxRetrievalsPerClientPerMinute.`,
 		},
 		{
 			Name: "RetrievalPricing",
@@ -401,6 +724,13 @@ relative to the CWD (current working directory).`,
 
 			Comment: ``,
 		},
+		{
+			Name: "FeeTiersWindow",
+			Type: "uint64",
+
+			Comment: `FeeTiersWindow is the number of recent epochs sampled by GasEstimateFeeTiers
+when computing the slow/medium/fast gas premium suggestions.`,
+		},
 	},
 	"FevmConfig": []DocField{
 		{
@@ -425,6 +755,17 @@ Set to 0 to keep all mappings`,
 		},
 	},
 	"FullNode": []DocField{
+		{
+			Name: "Role",
+			Type: "string",
+
+			Comment: `Role selects a preset of defaults for the node: "archival" keeps the whole
+chain, without the splitstore, and enables the message and eth transaction
+indexes; "pruned" runs the splitstore discarding cold blocks; "lite" doesn't
+sync the chain, and reads the chain state from the gateway set in the
+FULLNODE_API_INFO env var. The values set in this file override the preset.
+Leave empty to use the regular defaults.`,
+		},
 		{
 			Name: "Client",
 			Type: "Client",
@@ -443,6 +784,12 @@ Set to 0 to keep all mappings`,
 
 			Comment: ``,
 		},
+		{
+			Name: "Mpool",
+			Type: "MpoolConfig",
+
+			Comment: ``,
+		},
 		{
 			Name: "Chainstore",
 			Type: "Chainstore",
@@ -475,6 +822,34 @@ Set to 0 to keep all mappings`,
 
 			Comment: `EnableMsgIndex enables indexing of messages on chain.`,
 		},
+		{
+			Name: "EnableReceiptIndex",
+			Type: "bool",
+
+			Comment: `EnableReceiptIndex enables indexing the receipts of the messages on chain by
+message and by receipts root, so that ChainGetParentReceipts, StateSearchMsg and
+StateWaitMsg are served without loading receipt AMTs from the blockstore. The receipts of the tipsets synced before
+the index was enabled are backfilled in the background, down to genesis or as far
+as the blockstore keeps receipts; this is meant for archival nodes.`,
+		},
+		{
+			Name: "EnableTipSetIndex",
+			Type: "bool",
+
+			Comment: `EnableTipSetIndex enables indexing the tipsets of the current chain from key to
+height and back, so that ChainGetPath and ChainGetTipSetByHeight look up tipsets
+on the current chain without walking headers. The tipsets synced before the index
+was enabled are backfilled in the background, down to genesis.`,
+		},
+		{
+			Name: "MaxMsgSearchLookback",
+			Type: "int64",
+
+			Comment: `MaxMsgSearchLookback caps the number of epochs StateSearchMsg and StateWaitMsg
+walk back through the chain looking for a message which isn't in the message
+index, including when called without a lookback limit. Such walks can reach
+into the coldstore and take minutes. 0 means no cap.`,
+		},
 	},
 	"IndexProviderConfig": []DocField{
 		{
@@ -520,6 +895,21 @@ Defaults to empty, which implies the topic name is inferred from network name.`,
 starts. By default, the cache is rehydrated from previously cached entries stored in
 datastore if any is present.`,
 		},
+		{
+			Name: "AnnounceEndpoint",
+			Type: "string",
+
+			Comment: `AnnounceEndpoint is the URL of an external content indexer to which the pieces of deals are
+POSTed as JSON once the deals become active. Failed announcements are queued and retried.
+Defaults to empty, which disables the announcements.`,
+		},
+		{
+			Name: "AnnounceMaxAttempts",
+			Type: "int",
+
+			Comment: `AnnounceMaxAttempts is how many times an announcement to the AnnounceEndpoint is attempted
+before it's dropped.`,
+		},
 	},
 	"Libp2p": []DocField{
 		{
@@ -593,7 +983,26 @@ closed by the connection manager.`,
 			Name: "SubsystemLevels",
 			Type: "map[string]string",
 
-			Comment: `SubsystemLevels specify per-subsystem log levels`,
+			Comment: `SubsystemLevels specify per-subsystem log levels. They are reapplied when
+the config is reloaded, on SIGHUP or with 'lotus config reload'.`,
+		},
+	},
+	"MessageAggregationConfig": []DocField{
+		{
+			Name: "Window",
+			Type: "Duration",
+
+			Comment: `Window is the time to wait for more compatible miner control messages
+before pushing them as a single aggregated message. Aggregation is
+disabled when set to 0.`,
+		},
+		{
+			Name: "Methods",
+			Type: "[]string",
+
+			Comment: `Methods lists the miner actor methods whose messages are aggregated.
+WithdrawBalance messages are merged into a single withdrawal of the total
+requested amount.`,
 		},
 	},
 	"MinerAddressConfig": []DocField{
@@ -726,6 +1135,50 @@ over the worker address if this flag is set.`,
 			Comment: ``,
 		},
 	},
+	"MpoolConfig": []DocField{
+		{
+			Name: "PrioritySenders",
+			Type: "[]string",
+
+			Comment: `PrioritySenders is a list of addresses (e.g. the operator's miner worker and
+control addresses) whose messages are never pruned from the message pool and
+are selected for inclusion ahead of other messages. These are prioritized in
+addition to the PriorityAddrs set with 'lotus mpool config'.`,
+		},
+		{
+			Name: "PeerRateLimit",
+			Type: "float64",
+
+			Comment: `PeerRateLimit is the number of untrusted messages per second accepted from a
+single peer, either received over gossip or pushed with MpoolPushUntrusted
+by an API client. API clients are identified by their remote host, so all the
+messages relayed by a gateway count against the gateway. Messages over the
+limit are dropped. Set to 0 to disable rate limiting.
+The peer limits are reloaded on SIGHUP or with 'lotus config reload'.`,
+		},
+		{
+			Name: "PeerRateBurst",
+			Type: "int",
+
+			Comment: `PeerRateBurst is the number of untrusted messages a peer can send at once
+before being rate limited.`,
+		},
+		{
+			Name: "PeerMaxRejected",
+			Type: "int",
+
+			Comment: `PeerMaxRejected is the number of invalid messages a peer can send before all
+its messages are dropped for PeerBanDuration. Messages failing soft checks,
+such as nonce gaps or insufficient balance, don't count. Set to 0 to disable
+banning peers.`,
+		},
+		{
+			Name: "PeerBanDuration",
+			Type: "Duration",
+
+			Comment: `PeerBanDuration is how long the messages of a banned peer are dropped.`,
+		},
+	},
 	"ProvingConfig": []DocField{
 		{
 			Name: "ParallelCheckLimit",
@@ -930,6 +1383,12 @@ This property is used only if ElasticSearchTracer propery is set.`,
 			Name: "External",
 			Type: "*RetrievalPricingExternal",
 
+			Comment: ``,
+		},
+		{
+			Name: "Rules",
+			Type: "*RetrievalPricingRules",
+
 			Comment: ``,
 		},
 	},
@@ -953,6 +1412,64 @@ default value is true`,
 This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "external".`,
 		},
 	},
+	"RetrievalPricingRule": []DocField{
+		{
+			Name: "PieceCIDs",
+			Type: "[]string",
+
+			Comment: `PieceCIDs restricts the rule to retrievals from the listed pieces.`,
+		},
+		{
+			Name: "Clients",
+			Type: "[]string",
+
+			Comment: `Clients restricts the rule to retrievals by the listed client peer IDs.`,
+		},
+		{
+			Name: "MinDataAge",
+			Type: "Duration",
+
+			Comment: `MinDataAge restricts the rule to pieces which were first sealed into an active deal at least
+this long ago. Zero is ignored.`,
+		},
+		{
+			Name: "MaxDataAge",
+			Type: "Duration",
+
+			Comment: `MaxDataAge restricts the rule to pieces which were first sealed into an active deal at most
+this long ago. Zero is ignored.`,
+		},
+		{
+			Name: "PricePerByte",
+			Type: "types.FIL",
+
+			Comment: `PricePerByte is the price of transferring each byte. The price of the current ask is used if not set.`,
+		},
+		{
+			Name: "UnsealPrice",
+			Type: "types.FIL",
+
+			Comment: `UnsealPrice is the price of unsealing the piece. The price of the current ask is used if not set.`,
+		},
+	},
+	"RetrievalPricingRules": []DocField{
+		{
+			Name: "VerifiedDealsFreeTransfer",
+			Type: "bool",
+
+			Comment: `VerifiedDealsFreeTransfer configures zero fees for data transfer for a retrieval deal
+of a payloadCid that belongs to a verified storage deal, whichever rule matched.
+This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "rules".`,
+		},
+		{
+			Name: "Rules",
+			Type: "[]RetrievalPricingRule",
+
+			Comment: `Rules are evaluated in order when a retrieval is queried, and the first rule matching the query
+prices it. The current ask is used when no rule matches.
+This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "rules".`,
+		},
+	},
 	"SealerConfig": []DocField{
 		{
 			Name: "ParallelFetchLimit",
@@ -1020,6 +1537,16 @@ This parameter is ONLY applicable if the retrieval pricing policy strategy has b
 
 			Comment: `LocalWorkerName specifies a custom name for the builtin worker.
 If set to an empty string (default) os hostname will be used`,
+		},
+		{
+			Name: "LocalWorkerTaskPriorities",
+			Type: "map[string]int",
+
+			Comment: `LocalWorkerTaskPriorities sets the priorities of the builtin worker for
+task types, keyed by the task short names, e.g. { PC2 = 10 }. Tasks are
+only scheduled on the workers with the highest priority which can take
+them, tasks without a priority have priority 0. Remote workers set their
+priorities with 'lotus-worker run --task-priority'.`,
 		},
 		{
 			Name: "Assigner",
@@ -1053,6 +1580,16 @@ If you see stuck Finalize tasks after enabling this setting, check
 to use when evaluating tasks against this worker. An empty value defaults
 to "hardware".`,
 		},
+		{
+			Name: "ResourceOverrides",
+			Type: "map[string]string",
+
+			Comment: `ResourceOverrides overrides the resources the scheduler assumes tasks need
+on the builtin worker, e.g. { PC1_32G_MAX_MEMORY = "60000000000" }. Keys
+are the resource env var names listed by 'lotus-worker resources --all',
+and take precedence over the environment of the miner process.
+Remote workers are configured through the environment of the worker.`,
+		},
 	},
 	"SealingConfig": []DocField{
 		{
@@ -1243,6 +1780,22 @@ sending precommit messages to the chain individually`,
 
 			Comment: `network BaseFee below which to stop doing commit aggregation, instead
 submitting proofs to the chain individually`,
+		},
+		{
+			Name: "HoldPreCommitsAboveBaseFee",
+			Type: "types.FIL",
+
+			Comment: `network BaseFee above which precommit batches are held back, until a
+sector in the batch gets within PreCommitBatchSlack of its cutoff, or
+the batch is sent manually. 0 disables holding`,
+		},
+		{
+			Name: "HoldCommitsAboveBaseFee",
+			Type: "types.FIL",
+
+			Comment: `network BaseFee above which commit batches are held back, until a
+sector in the batch gets within CommitBatchSlack of its cutoff, or the
+batch is sent manually. 0 disables holding`,
 		},
 		{
 			Name: "TerminateBatchMax",
@@ -1263,13 +1816,32 @@ submitting proofs to the chain individually`,
 			Comment: ``,
 		},
 	},
+	"SharedChainstoreConfig": []DocField{
+		{
+			Name: "Enable",
+			Type: "bool",
+
+			Comment: `When enabled, chain objects are read directly from the blockstore of the
+lotus daemon over a websocket, instead of with a JSON-RPC call per object.
+The daemon API configured for the miner must be reachable.`,
+		},
+		{
+			Name: "CacheSize",
+			Type: "int",
+
+			Comment: `The number of chain objects cached by the miner. Chain objects are
+immutable, so cached objects never have to be refetched.`,
+		},
+	},
 	"Splitstore": []DocField{
 		{
 			Name: "ColdStoreType",
 			Type: "string",
 
 			Comment: `ColdStoreType specifies the type of the coldstore.
-It can be "messages" (default) to store only messages, "universal" to store all chain state or "discard" for discarding cold blocks.`,
+It can be "messages" (default) to store only messages, "universal" to store all chain state or "discard" for discarding cold blocks.
+It can also be "ipfs" to store all chain state in the blockstore of an IPFS node, see IpfsMAddr;
+the IPFS coldstore can't be pruned by lotus.`,
 		},
 		{
 			Name: "HotStoreType",
@@ -1284,6 +1856,35 @@ Only currently supported value is "badger".`,
 
 			Comment: `MarkSetType specifies the type of the markset.
 It can be "map" for in memory marking or "badger" (default) for on-disk marking.`,
+		},
+		{
+			Name: "HotStorePath",
+			Type: "string",
+
+			Comment: `HotStorePath is the directory of the hotstore, allowing it to be kept on a
+different (faster) filesystem than the rest of the repo.
+Defaults to datastore/splitstore/hot.badger in the repo.`,
+		},
+		{
+			Name: "EphemeralHotStore",
+			Type: "bool",
+
+			Comment: `EphemeralHotStore indicates that the hotstore is kept on volatile storage, such as
+a tmpfs or ramdisk mounted at HotStorePath, for maximum performance. All objects are
+also written to the coldstore, which must be "universal", and when the node starts
+with the hotstore lost it is automatically warmed up again from the coldstore.
+Enabling it on an existing node copies the hotstore to the coldstore on startup.`,
+		},
+		{
+			Name: "ColdStorePath",
+			Type: "string",
+
+			Comment: `ColdStorePath is the directory of the coldstore, which is also the chain
+blockstore when the splitstore is disabled.
+Defaults to datastore/chain in the repo.
+When either path changes, the existing store must be moved to the new path
+before starting the node; the node refuses to start with an empty store at
+the new path while the previous path still holds data.`,
 		},
 		{
 			Name: "HotStoreMessageRetention",
@@ -1329,6 +1930,158 @@ exceeds HotstoreMaxSpaceTarget - HotstoreMaxSpaceThreshold`,
 is set.  Moving GC will not occur when total moving size exceeds
 HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer`,
 		},
+		{
+			Name: "DiskSpaceLowThreshold",
+			Type: "uint64",
+
+			Comment: `DiskSpaceLowThreshold is the amount of free disk space (in bytes) on the hotstore
+or coldstore path below which compaction and pruning are paused and a warning is
+reported through the API. A value of 0 disables the check.`,
+		},
+		{
+			Name: "DiskSpaceCriticalThreshold",
+			Type: "uint64",
+
+			Comment: `DiskSpaceCriticalThreshold is the amount of free disk space (in bytes) below which
+the splitstore additionally refuses writes which are not required for consensus,
+such as objects imported through the API. A value of 0 disables the check.`,
+		},
+		{
+			Name: "EnableCompactionProfiling",
+			Type: "bool",
+
+			Comment: `EnableCompactionProfiling enables capturing CPU and heap profiles at the start,
+middle (before purging cold objects) and end of every compaction. Profiles are
+written to the profiles directory in the splitstore path; profiles of the last
+5 compactions are retained.`,
+		},
+		{
+			Name: "EnableColdReadPrefetch",
+			Type: "bool",
+
+			Comment: `EnableColdReadPrefetch enables promoting the objects below an object read from the
+coldstore to the hotstore in the background, as deep history queries tend to read
+clustered state. Reads through the API which explicitly reify cold objects are not
+affected.`,
+		},
+		{
+			Name: "ColdReadPromotion",
+			Type: "string",
+
+			Comment: `ColdReadPromotion is the policy for copying objects read from the coldstore back
+to the hotstore, so that frequently accessed historical objects stop paying the
+coldstore latency. It can be "never" (default), "always", or "hits" to promote
+objects read ColdReadPromotionHits times within ColdReadPromotionWindow epochs.
+It has no effect when EnableColdReadPrefetch is set, as prefetching promotes
+every object read from the coldstore.`,
+		},
+		{
+			Name: "ColdReadPromotionHits",
+			Type: "uint64",
+
+			Comment: `ColdReadPromotionHits is the number of reads promoting an object with the "hits"
+policy.`,
+		},
+		{
+			Name: "ColdReadPromotionWindow",
+			Type: "uint64",
+
+			Comment: `ColdReadPromotionWindow is the number of epochs within which the reads of an object
+are counted with the "hits" policy.`,
+		},
+		{
+			Name: "ColdStoreCacheSize",
+			Type: "uint64",
+
+			Comment: `ColdStoreCacheSize is the size in bytes of an on-disk read-through cache of the objects
+recently read from the coldstore, kept in a separate badger store in the splitstore
+directory, so that bursts of historical queries don't repeatedly read the same objects
+from a slow or remote coldstore. The cache is cleared when the node starts.
+A value of 0 (default) disables the cache.`,
+		},
+		{
+			Name: "ColdArchivePath",
+			Type: "string",
+
+			Comment: `ColdArchivePath, if set, is a directory where each compaction writes the objects it
+moves out of the hotstore as a CAR file, rooted at the compaction tipset, so that the
+history can be kept or restored elsewhere. A relative path is resolved against the
+splitstore directory.`,
+		},
+		{
+			Name: "ColdArchiveUploadURL",
+			Type: "string",
+
+			Comment: `ColdArchiveUploadURL, if set, is an http(s) URL under which the cold archives are
+uploaded with a single PUT request each after each compaction, to any HTTP server
+accepting uploads; only static headers are sent, so object stores needing signed
+requests are not supported. Uploads are verified by size, and by MD5 when the server
+returns it as the ETag; failed uploads are retried after the next compaction.`,
+		},
+		{
+			Name: "ColdArchiveUploadHeaders",
+			Type: "[]string",
+
+			Comment: `ColdArchiveUploadHeaders are extra headers sent with every upload request, in the
+"Name: value" format, e.g. for authorization.`,
+		},
+		{
+			Name: "ColdArchiveRetention",
+			Type: "string",
+
+			Comment: `ColdArchiveRetention is the policy for the local copy of uploaded cold archives:
+"keep" (default), or "drop" to delete it once the upload is verified.`,
+		},
+		{
+			Name: "CompactionStrategy",
+			Type: "string",
+
+			Comment: `CompactionStrategy decides when compaction runs and which objects it evicts from
+the hotstore. It can be "epoch" (default), compacting every finality once 5
+finalities have elapsed and keeping the objects reachable from the recent chain, or
+"capacity", compacting whenever the hotstore grows beyond HotStoreCapacity bytes,
+however many epochs have elapsed, and also keeping the most recently accessed
+objects until the capacity is filled. The "capacity" strategy suits API serving
+nodes whose reads don't follow the chain height; it requires a "universal"
+coldstore. "refcount" compacts every finality like "epoch", but tracks a reference
+count per hot object instead of walking the chain, and evicts the objects left
+without references; it requires a "universal" or "discard" coldstore. The strategy
+can't be changed while the node runs.`,
+		},
+		{
+			Name: "HotStoreCapacity",
+			Type: "uint64",
+
+			Comment: `HotStoreCapacity is the hotstore size in bytes above which the "capacity" strategy
+compacts, and which the objects kept in the hotstore are fitted into.`,
+		},
+		{
+			Name: "ShutdownGracePeriod",
+			Type: "Duration",
+
+			Comment: `ShutdownGracePeriod is how long shutting down the node waits for an ongoing
+compaction or prune to stop. After it elapses the operation is aborted; an
+interrupted purge is completed from its checkpoint when the node restarts.
+Set to 0 to wait indefinitely.`,
+		},
+	},
+	"StagingBlockstoreConfig": []DocField{
+		{
+			Name: "EnableRetention",
+			Type: "bool",
+
+			Comment: `When enabled, blocks are evicted from the staging blockstore, which holds the
+data of online storage deals transferred over graphsync, once no deal needs them.
+The data of a deal is retained until the deal is handed to sealing or fails, as
+the sealed sector holds it afterwards, and every block is retained for at least
+one CompactionInterval after it was written. Evicted blocks are discarded.`,
+		},
+		{
+			Name: "CompactionInterval",
+			Type: "Duration",
+
+			Comment: `How often blocks are evicted from the staging blockstore.`,
+		},
 	},
 	"StorageMiner": []DocField{
 		{
@@ -1385,6 +2138,47 @@ HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer`,
 
 			Comment: ``,
 		},
+		{
+			Name: "Bitswap",
+			Type: "BitswapConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "MessageAggregation",
+			Type: "MessageAggregationConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "SharedChainstore",
+			Type: "SharedChainstoreConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "StagingBlockstore",
+			Type: "StagingBlockstoreConfig",
+
+			Comment: ``,
+		},
+	},
+	"UnsealedCacheConfig": []DocField{
+		{
+			Name: "MaxBytes",
+			Type: "int64",
+
+			Comment: `The maximum total size in bytes of the unsealed copies kept. The least
+recently read copies are removed first once it is exceeded. 0 is
+unlimited.`,
+		},
+		{
+			Name: "TTL",
+			Type: "Duration",
+
+			Comment: `Time after which an unsealed copy which wasn't read is removed. Set to 0
+to keep unsealed copies until MaxBytes is exceeded.`,
+		},
 	},
 	"UserRaftConfig": []DocField{
 		{
@@ -1455,6 +2249,29 @@ copies that we keep as backups (renaming) after cleanup.`,
 
 			Comment: ``,
 		},
+		{
+			Name: "RemoteBackendTLSCert",
+			Type: "string",
+
+			Comment: `RemoteBackendTLSCert and RemoteBackendTLSKey are the paths of the PEM encoded
+client certificate and key presented to the remote wallet, for wallets
+requiring mutual TLS (see 'lotus-wallet run --tls-client-ca').`,
+		},
+		{
+			Name: "RemoteBackendTLSKey",
+			Type: "string",
+
+			Comment: ``,
+		},
+		{
+			Name: "RemoteBackendTLSCA",
+			Type: "string",
+
+			Comment: `RemoteBackendTLSCA is the path of the PEM encoded CA certificate the remote
+wallet certificate is verified against. The system roots are used if empty.
+Setting any of the RemoteBackendTLS options makes the node connect to the
+remote wallet over https.`,
+		},
 		{
 			Name: "EnableLedger",
 			Type: "bool",
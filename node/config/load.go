@@ -60,10 +60,22 @@ func FromFile(path string, opts ...LoadCfgOpt) (interface{}, error) {
 	return FromReader(buf, def)
 }
 
-// FromReader loads config from a reader instance.
+// FromReader loads config from a reader instance. When loading a full node
+// config, the preset of its Role is applied to the defaults first.
 func FromReader(reader io.Reader, def interface{}) (interface{}, error) {
+	cfgBs, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if fn, ok := def.(*FullNode); ok {
+		if err := applyRoleDefaults(string(cfgBs), fn); err != nil {
+			return nil, err
+		}
+	}
+
 	cfg := def
-	_, err := toml.NewDecoder(reader).Decode(cfg)
+	_, err = toml.NewDecoder(bytes.NewReader(cfgBs)).Decode(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -115,9 +127,15 @@ func MatchEnableSplitstoreField(s string) bool {
 	return enableSplitstoreRx.MatchString(s)
 }
 
+// Match the Role field, set to a non-empty value
+func MatchRoleField(s string) bool {
+	roleRx := regexp.MustCompile(`(?m)^\s*Role\s*=\s*"[^"]+"`)
+	return roleRx.MatchString(s)
+}
+
 func ValidateSplitstoreSet(cfgRaw string) error {
-	if !MatchEnableSplitstoreField(cfgRaw) {
-		return xerrors.Errorf("Config does not contain explicit set of EnableSplitstore field, refusing to load. Please explicitly set EnableSplitstore. Set it to false if you are running a full archival node")
+	if !MatchEnableSplitstoreField(cfgRaw) && !MatchRoleField(cfgRaw) {
+		return xerrors.Errorf("Config does not contain explicit set of EnableSplitstore or Role field, refusing to load. Please explicitly set EnableSplitstore, or a Role. Set EnableSplitstore to false, or Role to \"archival\", if you are running a full archival node")
 	}
 	return nil
 }
@@ -147,7 +165,9 @@ func Commented(commented bool) UpdateCfgOpt {
 }
 
 func DefaultKeepUncommented() UpdateCfgOpt {
-	return KeepUncommented(MatchEnableSplitstoreField)
+	return KeepUncommented(func(s string) bool {
+		return MatchEnableSplitstoreField(s) || MatchRoleField(s)
+	})
 }
 
 // ConfigUpdate takes in a config and a default config and optionally comments out default values
@@ -237,7 +257,11 @@ func ConfigUpdate(cfgCur, cfgDef interface{}, opts ...UpdateCfgOpt) ([]byte, err
 						outLines = append(outLines, pad+"# type: "+doc.Type)
 					}
 
-					outLines = append(outLines, pad+"# env var: LOTUS_"+strings.ToUpper(strings.ReplaceAll(section, ".", "_"))+"_"+strings.ToUpper(lf[0]))
+					envVar := "LOTUS_" + strings.ToUpper(lf[0])
+					if section != "" {
+						envVar = "LOTUS_" + strings.ToUpper(strings.ReplaceAll(section, ".", "_")) + "_" + strings.ToUpper(lf[0])
+					}
+					outLines = append(outLines, pad+"# env var: "+envVar)
 				}
 			}
 
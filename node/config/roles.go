@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// RoleArchival keeps the whole chain, without the splitstore, and maintains
+	// all the indexes.
+	RoleArchival = "archival"
+	// RolePruned runs the splitstore, discarding the blocks moved out of the
+	// hotstore.
+	RolePruned = "pruned"
+	// RoleLite doesn't sync the chain, and reads the chain state from a gateway.
+	RoleLite = "lite"
+)
+
+// roleEnvVar overrides the Role set in the config file, as envconfig does for
+// the other fields.
+const roleEnvVar = "LOTUS_ROLE"
+
+// DefaultFullNodeForRole returns the default full node config with the preset
+// of the role applied. An empty role returns DefaultFullNode.
+func DefaultFullNodeForRole(role string) (*FullNode, error) {
+	cfg := DefaultFullNode()
+	if err := applyRole(cfg, role); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func applyRole(cfg *FullNode, role string) error {
+	switch role {
+	case "":
+		return nil
+	case RoleArchival:
+		cfg.Chainstore.EnableSplitstore = false
+		cfg.Index.EnableMsgIndex = true
+		cfg.Index.EnableReceiptIndex = true
+		cfg.Index.EnableTipSetIndex = true
+		cfg.Fevm.EnableEthRPC = true
+		cfg.Fevm.EthTxHashMappingLifetimeDays = 0
+	case RolePruned:
+		cfg.Chainstore.EnableSplitstore = true
+		cfg.Chainstore.Splitstore.ColdStoreType = "discard"
+	case RoleLite:
+		cfg.Chainstore.EnableSplitstore = false
+		cfg.Index.EnableMsgIndex = false
+		cfg.Index.EnableReceiptIndex = false
+		cfg.Index.EnableTipSetIndex = false
+		cfg.Fevm.EnableEthRPC = false
+	default:
+		return xerrors.Errorf("unknown node role %q, expected %q, %q or %q", role, RoleArchival, RolePruned, RoleLite)
+	}
+
+	cfg.Role = role
+	return nil
+}
+
+// applyRoleDefaults applies the preset of the role set in the raw config, or in
+// the LOTUS_ROLE env var, to def, so that the values set in the config file
+// override the preset.
+func applyRoleDefaults(cfgRaw string, def *FullNode) error {
+	var r struct {
+		Role string
+	}
+	if _, err := toml.Decode(cfgRaw, &r); err != nil {
+		return err
+	}
+	if env, ok := os.LookupEnv(roleEnvVar); ok {
+		r.Role = env
+	}
+
+	return applyRole(def, r.Role)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleDefaults(t *testing.T) {
+	cfg, err := FromReader(strings.NewReader(`Role = "archival"`), DefaultFullNode())
+	require.NoError(t, err)
+	fn := cfg.(*FullNode)
+	require.Equal(t, RoleArchival, fn.Role)
+	require.False(t, fn.Chainstore.EnableSplitstore)
+	require.True(t, fn.Index.EnableMsgIndex)
+	require.True(t, fn.Index.EnableReceiptIndex)
+	require.True(t, fn.Index.EnableTipSetIndex)
+	require.True(t, fn.Fevm.EnableEthRPC)
+
+	// values set in the file override the preset
+	cfg, err = FromReader(strings.NewReader(`
+Role = "archival"
+[Fevm]
+  EnableEthRPC = false
+`), DefaultFullNode())
+	require.NoError(t, err)
+	fn = cfg.(*FullNode)
+	require.True(t, fn.Index.EnableMsgIndex)
+	require.False(t, fn.Fevm.EnableEthRPC)
+
+	// the env var overrides the role of the file
+	t.Setenv("LOTUS_ROLE", RolePruned)
+	cfg, err = FromReader(strings.NewReader(`Role = "archival"`), DefaultFullNode())
+	require.NoError(t, err)
+	fn = cfg.(*FullNode)
+	require.Equal(t, RolePruned, fn.Role)
+	require.True(t, fn.Chainstore.EnableSplitstore)
+	require.Equal(t, "discard", fn.Chainstore.Splitstore.ColdStoreType)
+
+	t.Setenv("LOTUS_ROLE", "bogus")
+	_, err = FromReader(strings.NewReader(``), DefaultFullNode())
+	require.Error(t, err)
+}
+
+func TestRoleConfigRoundtrip(t *testing.T) {
+	def, err := DefaultFullNodeForRole(RoleLite)
+	require.NoError(t, err)
+
+	cfgStr, err := ConfigUpdate(def, def, Commented(true), DefaultKeepUncommented())
+	require.NoError(t, err)
+	require.True(t, MatchRoleField(string(cfgStr)))
+	require.NoError(t, ValidateSplitstoreSet(string(cfgStr)))
+
+	cfg, err := FromReader(strings.NewReader(string(cfgStr)), DefaultFullNode())
+	require.NoError(t, err)
+	require.Equal(t, def, cfg)
+}
@@ -12,19 +12,29 @@ import (
 
 // Common is common config between full node and miner
 type Common struct {
-	API     API
-	Backup  Backup
-	Logging Logging
-	Libp2p  Libp2p
-	Pubsub  Pubsub
+	API      API
+	Backup   Backup
+	Logging  Logging
+	Libp2p   Libp2p
+	Pubsub   Pubsub
+	Alerting Alerting
 }
 
 // FullNode is a full node config
 type FullNode struct {
 	Common
+	// Role selects a preset of defaults for the node: "archival" keeps the whole
+	// chain, without the splitstore, and enables the message and eth transaction
+	// indexes; "pruned" runs the splitstore discarding cold blocks; "lite" doesn't
+	// sync the chain, and reads the chain state from the gateway set in the
+	// FULLNODE_API_INFO env var. The values set in this file override the preset.
+	// Leave empty to use the regular defaults.
+	Role string
+
 	Client     Client
 	Wallet     Wallet
 	Fees       FeeConfig
+	Mpool      MpoolConfig
 	Chainstore Chainstore
 	Cluster    UserRaftConfig
 	Fevm       FevmConfig
@@ -44,10 +54,46 @@ type Backup struct {
 
 // Logging is the logging system config
 type Logging struct {
-	// SubsystemLevels specify per-subsystem log levels
+	// SubsystemLevels specify per-subsystem log levels. They are reapplied when
+	// the config is reloaded, on SIGHUP or with 'lotus config reload'.
 	SubsystemLevels map[string]string
 }
 
+// Alerting configures notifications and checks for conditions which require
+// operator attention
+type Alerting struct {
+	// WebhookURLs is a list of URLs which receive a JSON POST request every time
+	// an alert is raised or resolved. The body contains the alert System,
+	// Subsystem, event Type ('raised' or 'resolved'), Message and Time.
+	WebhookURLs []string
+	// WebhookTimeout is the timeout for a single webhook request
+	WebhookTimeout Duration
+
+	// MinFreeDiskRatio is the fraction of free disk space in the repo below which
+	// the disk-space alert is raised. Set to 0 to disable the check.
+	MinFreeDiskRatio float64
+
+	// SyncStallTimeout is the duration for which the chain head can stay unchanged
+	// while being behind wall-clock time before the sync stall alert is raised.
+	// Only applies to full nodes. Set to 0 to disable the check.
+	SyncStallTimeout Duration
+}
+
+// DealWebhooks configures the notifications of storage deal lifecycle events
+type DealWebhooks struct {
+	// URLs which receive a JSON POST request for every lifecycle event of a
+	// deal. The body contains the Side ('client' or 'provider'), the Event,
+	// the deal State, ProposalCid, DealID, Client, Provider, PieceCid,
+	// PieceSize, Verified, StartEpoch, EndEpoch, Message and Time.
+	URLs []string
+	// The timeout for a single webhook request
+	Timeout Duration
+	// The events to notify of, any of 'accepted', 'rejected', 'published',
+	// 'sealed', 'active', 'slashed', 'expired' and 'failed'. Empty notifies
+	// of all the events.
+	Events []string
+}
+
 // StorageMiner is a miner config
 type StorageMiner struct {
 	Common
@@ -61,6 +107,11 @@ type StorageMiner struct {
 	Fees          MinerFeeConfig
 	Addresses     MinerAddressConfig
 	DAGStore      DAGStoreConfig
+	Bitswap       BitswapConfig
+
+	MessageAggregation MessageAggregationConfig
+	SharedChainstore   SharedChainstoreConfig
+	StagingBlockstore  StagingBlockstoreConfig
 }
 
 type DAGStoreConfig struct {
@@ -102,6 +153,56 @@ type DAGStoreConfig struct {
 	GCInterval Duration
 }
 
+type BitswapConfig struct {
+	// When enabled, the payload blocks of deals with an unsealed copy are
+	// served for free over bitswap, alongside the retrieval market protocol.
+	// Blocks of deals which are only sealed are never served, so requests
+	// can't trigger unsealing.
+	Enable bool
+
+	// Peer IDs of the only peers served, all peers are served when empty.
+	AllowedPeers []string
+	// Peer IDs of peers which are never served.
+	DeniedPeers []string
+
+	// The maximum number of blocks served per second to a single peer. 0
+	// means unlimited.
+	MaxBlocksPerSecondPerPeer float64
+	// The number of blocks a single peer can request at once, above
+	// MaxBlocksPerSecondPerPeer.
+	BlocksBurstPerPeer int
+
+	// The maximum size in bytes of the blocks queued for sending to a single
+	// peer. 0 uses the bitswap default.
+	MaxOutstandingBytesPerPeer int
+	// The number of workers sending blocks to peers. 0 uses the bitswap
+	// default.
+	TaskWorkers int
+}
+
+type SharedChainstoreConfig struct {
+	// When enabled, chain objects are read directly from the blockstore of the
+	// lotus daemon over a websocket, instead of with a JSON-RPC call per object.
+	// The daemon API configured for the miner must be reachable.
+	Enable bool
+
+	// The number of chain objects cached by the miner. Chain objects are
+	// immutable, so cached objects never have to be refetched.
+	CacheSize int
+}
+
+type StagingBlockstoreConfig struct {
+	// When enabled, blocks are evicted from the staging blockstore, which holds the
+	// data of online storage deals transferred over graphsync, once no deal needs them.
+	// The data of a deal is retained until the deal is handed to sealing or fails, as
+	// the sealed sector holds it afterwards, and every block is retained for at least
+	// one CompactionInterval after it was written. Evicted blocks are discarded.
+	EnableRetention bool
+
+	// How often blocks are evicted from the staging blockstore.
+	CompactionInterval Duration
+}
+
 type MinerSubsystemConfig struct {
 	EnableMining        bool
 	EnableSealing       bool
@@ -159,16 +260,99 @@ type DealmakingConfig struct {
 	// Minimum start epoch buffer to give time for sealing of sector with deal.
 	StartEpochSealingBuffer uint64
 
+	// Limits on the storage deals accepted from each client, checked after all
+	// the other deal filters. A quota whose Client is "*" applies to all the
+	// clients without a quota of their own.
+	ClientQuotas []ClientQuota
+
+	// Time after which a data transfer which made no progress is restarted.
+	// Set to 0 to disable restarting stalled transfers.
+	StalledTransferTimeout Duration
+	// The number of times a stalled data transfer is restarted without making
+	// progress before giving up on it.
+	StalledTransferMaxRestarts int
+
+	// Webhooks notified of the lifecycle events of the storage deals made with
+	// the provider.
+	DealWebhooks DealWebhooks
+
+	// Automatic top-ups of the market escrow holding the provider collateral
+	// of deals, so that publishing deals doesn't fail for lack of collateral.
+	CollateralTopUp CollateralTopUpConfig
+
+	// The unsealed copies of sectors made to serve retrievals are kept for
+	// faster subsequent retrievals, within the limits set here.
+	UnsealedCache UnsealedCacheConfig
+
 	// A command used for fine-grained evaluation of storage deals
 	// see https://lotus.filecoin.io/storage-providers/advanced-configurations/market/#using-filters-for-fine-grained-storage-and-retrieval-deal-acceptance for more details
 	Filter string
+	// An expression used for fine-grained evaluation of storage deals, evaluated
+	// before Filter. A deal is accepted when the expression evaluates to true.
+	// Expressions use Go syntax and can refer to Client, Provider, PieceCID,
+	// PieceSize, Verified, Offline, StartEpoch, EndEpoch, Duration,
+	// PricePerEpoch, PricePerGiBEpoch, TotalPrice, ProviderCollateral and
+	// ClientCollateral, with prices in attoFIL. The functions in(x, a, b, ...),
+	// fil("0.5") and size("32GiB") are available.
+	// e.g. 'Verified || (PieceSize >= size("1GiB") && PricePerGiBEpoch >= fil("0.0000000005"))'
+	FilterExpression string
 	// A command used for fine-grained evaluation of retrieval deals
 	// see https://lotus.filecoin.io/storage-providers/advanced-configurations/market/#using-filters-for-fine-grained-storage-and-retrieval-deal-acceptance for more details
 	RetrievalFilter string
+	// An expression used for fine-grained evaluation of retrieval deals,
+	// evaluated before RetrievalFilter. A retrieval is accepted when the
+	// expression evaluates to true. Expressions have the same syntax and
+	// functions as FilterExpression, and can refer to Client (peer ID),
+	// PayloadCID, PieceCID, PieceSize, PricePerByte, UnsealPrice and
+	// PaymentInterval.
+	// e.g. 'PricePerByte > 0 || PieceSize <= size("1GiB")'
+	RetrievalFilterExpression string
+	// The maximum number of retrieval proposals accepted from a single client
+	// per minute, before the other retrieval filters. 0 is unlimited.
+	MaxRetrievalsPerClientPerMinute float64
+	// The number of retrieval proposals a single client can make at once,
+	// above MaxRetrievalsPerClientPerMinute.
+	RetrievalBurstPerClient int
 
 	RetrievalPricing *RetrievalPricing
 }
 
+type CollateralTopUpConfig struct {
+	// When the available market balance of the miner drops below Threshold,
+	// Amount is added to it. 0 disables the top-ups.
+	Threshold types.FIL
+	// The amount added to the market balance on each top-up.
+	Amount types.FIL
+	// The maximum amount added within 24 hours. 0 is unlimited.
+	MaxPerDay types.FIL
+	// The wallet the funds are sent from. Defaults to the worker address.
+	Wallet string
+	// The balance left in the wallet after a top-up. Top-ups which would
+	// leave less are skipped and raise an alert.
+	MinWalletBalance types.FIL
+}
+
+type UnsealedCacheConfig struct {
+	// The maximum total size in bytes of the unsealed copies kept. The least
+	// recently read copies are removed first once it is exceeded. 0 is
+	// unlimited.
+	MaxBytes int64
+	// Time after which an unsealed copy which wasn't read is removed. Set to 0
+	// to keep unsealed copies until MaxBytes is exceeded.
+	TTL Duration
+}
+
+type ClientQuota struct {
+	// Address of the client, either its ID or robust address, or "*"
+	Client string
+	// Maximum number of the client's deals in progress at the same time, from
+	// acceptance until the deal is active or fails. 0 is unlimited.
+	MaxOpenDeals uint64
+	// Maximum padded size in bytes of the client's deals accepted within 24
+	// hours. 0 is unlimited.
+	MaxBytesPerDay uint64
+}
+
 type IndexProviderConfig struct {
 	// Enable set whether to enable indexing announcement to the network and expose endpoints that
 	// allow indexer nodes to process announcements. Enabled by default.
@@ -197,13 +381,23 @@ type IndexProviderConfig struct {
 	// starts. By default, the cache is rehydrated from previously cached entries stored in
 	// datastore if any is present.
 	PurgeCacheOnStart bool
+
+	// AnnounceEndpoint is the URL of an external content indexer to which the pieces of deals are
+	// POSTed as JSON once the deals become active. Failed announcements are queued and retried.
+	// Defaults to empty, which disables the announcements.
+	AnnounceEndpoint string
+
+	// AnnounceMaxAttempts is how many times an announcement to the AnnounceEndpoint is attempted
+	// before it's dropped.
+	AnnounceMaxAttempts int
 }
 
 type RetrievalPricing struct {
-	Strategy string // possible values: "default", "external"
+	Strategy string // possible values: "default", "external", "rules"
 
 	Default  *RetrievalPricingDefault
 	External *RetrievalPricingExternal
+	Rules    *RetrievalPricingRules
 }
 
 type RetrievalPricingExternal struct {
@@ -220,6 +414,36 @@ type RetrievalPricingDefault struct {
 	VerifiedDealsFreeTransfer bool
 }
 
+type RetrievalPricingRules struct {
+	// VerifiedDealsFreeTransfer configures zero fees for data transfer for a retrieval deal
+	// of a payloadCid that belongs to a verified storage deal, whichever rule matched.
+	// This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "rules".
+	VerifiedDealsFreeTransfer bool
+
+	// Rules are evaluated in order when a retrieval is queried, and the first rule matching the query
+	// prices it. The current ask is used when no rule matches.
+	// This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "rules".
+	Rules []RetrievalPricingRule
+}
+
+type RetrievalPricingRule struct {
+	// PieceCIDs restricts the rule to retrievals from the listed pieces.
+	PieceCIDs []string
+	// Clients restricts the rule to retrievals by the listed client peer IDs.
+	Clients []string
+	// MinDataAge restricts the rule to pieces which were first sealed into an active deal at least
+	// this long ago. Zero is ignored.
+	MinDataAge Duration
+	// MaxDataAge restricts the rule to pieces which were first sealed into an active deal at most
+	// this long ago. Zero is ignored.
+	MaxDataAge Duration
+
+	// PricePerByte is the price of transferring each byte. The price of the current ask is used if not set.
+	PricePerByte types.FIL
+	// UnsealPrice is the price of unsealing the piece. The price of the current ask is used if not set.
+	UnsealPrice types.FIL
+}
+
 type ProvingConfig struct {
 	// Maximum number of sector checks to run in parallel. (0 = unlimited)
 	//
@@ -422,6 +646,16 @@ type SealingConfig struct {
 	// submitting proofs to the chain individually
 	AggregateAboveBaseFee types.FIL
 
+	// network BaseFee above which precommit batches are held back, until a
+	// sector in the batch gets within PreCommitBatchSlack of its cutoff, or
+	// the batch is sent manually. 0 disables holding
+	HoldPreCommitsAboveBaseFee types.FIL
+
+	// network BaseFee above which commit batches are held back, until a
+	// sector in the batch gets within CommitBatchSlack of its cutoff, or the
+	// batch is sent manually. 0 disables holding
+	HoldCommitsAboveBaseFee types.FIL
+
 	TerminateBatchMax  uint64
 	TerminateBatchMin  uint64
 	TerminateBatchWait Duration
@@ -449,6 +683,13 @@ type SealerConfig struct {
 	// If set to an empty string (default) os hostname will be used
 	LocalWorkerName string
 
+	// LocalWorkerTaskPriorities sets the priorities of the builtin worker for
+	// task types, keyed by the task short names, e.g. { PC2 = 10 }. Tasks are
+	// only scheduled on the workers with the highest priority which can take
+	// them, tasks without a priority have priority 0. Remote workers set their
+	// priorities with 'lotus-worker run --task-priority'.
+	LocalWorkerTaskPriorities map[string]int
+
 	// Assigner specifies the worker assigner to use when scheduling tasks.
 	// "utilization" (default) - assign tasks to workers with lowest utilization.
 	// "spread" - assign tasks to as many distinct workers as possible.
@@ -471,6 +712,13 @@ type SealerConfig struct {
 	// to use when evaluating tasks against this worker. An empty value defaults
 	// to "hardware".
 	ResourceFiltering ResourceFilteringStrategy
+
+	// ResourceOverrides overrides the resources the scheduler assumes tasks need
+	// on the builtin worker, e.g. { PC1_32G_MAX_MEMORY = "60000000000" }. Keys
+	// are the resource env var names listed by 'lotus-worker resources --all',
+	// and take precedence over the environment of the miner process.
+	// Remote workers are configured through the environment of the worker.
+	ResourceOverrides map[string]string
 }
 
 type BatchFeeConfig struct {
@@ -493,6 +741,17 @@ type MinerFeeConfig struct {
 	MaxMarketBalanceAddFee types.FIL
 }
 
+type MessageAggregationConfig struct {
+	// Window is the time to wait for more compatible miner control messages
+	// before pushing them as a single aggregated message. Aggregation is
+	// disabled when set to 0.
+	Window Duration
+	// Methods lists the miner actor methods whose messages are aggregated.
+	// WithdrawBalance messages are merged into a single withdrawal of the total
+	// requested amount.
+	Methods []string
+}
+
 type MinerAddressConfig struct {
 	// Addresses to send PreCommit messages from
 	PreCommitControl []string
@@ -582,11 +841,34 @@ type Pubsub struct {
 type Chainstore struct {
 	EnableSplitstore bool
 	Splitstore       Splitstore
+
+	// IpfsMAddr is the multiaddress of the API of the IPFS node used as the
+	// coldstore when Splitstore.ColdStoreType is "ipfs", and for reading blocks
+	// missing from the chainstore when EnableIpfsFallback is set. When empty, the
+	// local IPFS node configured with IPFS_PATH is used.
+	IpfsMAddr string
+	// EnableIpfsFallback reads the blocks missing from the chainstore from the
+	// IPFS node, which only serves the blocks it already has.
+	EnableIpfsFallback bool
+
+	// RemoteBlockstore is the API info, in the "token:multiaddr" format, of the
+	// lotus node whose chain blockstore is used as the chainstore of this node,
+	// instead of a local one, so that multiple nodes can share one storage
+	// backend. The token needs the admin permission, as the node puts objects;
+	// deleting objects from the remote node is never allowed. The node
+	// reconnects when the connection drops, and chain operations fail while the
+	// remote node is unreachable. The splitstore must be disabled when it is set.
+	RemoteBlockstore string
 }
 
+// Splitstore configures the splitstore. The message retention, GC, disk space,
+// profiling and prefetching settings are reloaded on SIGHUP or with
+// 'lotus config reload'.
 type Splitstore struct {
 	// ColdStoreType specifies the type of the coldstore.
 	// It can be "messages" (default) to store only messages, "universal" to store all chain state or "discard" for discarding cold blocks.
+	// It can also be "ipfs" to store all chain state in the blockstore of an IPFS node, see IpfsMAddr;
+	// the IPFS coldstore can't be pruned by lotus.
 	ColdStoreType string
 	// HotStoreType specifies the type of the hotstore.
 	// Only currently supported value is "badger".
@@ -595,6 +877,24 @@ type Splitstore struct {
 	// It can be "map" for in memory marking or "badger" (default) for on-disk marking.
 	MarkSetType string
 
+	// HotStorePath is the directory of the hotstore, allowing it to be kept on a
+	// different (faster) filesystem than the rest of the repo.
+	// Defaults to datastore/splitstore/hot.badger in the repo.
+	HotStorePath string
+	// EphemeralHotStore indicates that the hotstore is kept on volatile storage, such as
+	// a tmpfs or ramdisk mounted at HotStorePath, for maximum performance. All objects are
+	// also written to the coldstore, which must be "universal", and when the node starts
+	// with the hotstore lost it is automatically warmed up again from the coldstore.
+	// Enabling it on an existing node copies the hotstore to the coldstore on startup.
+	EphemeralHotStore bool
+	// ColdStorePath is the directory of the coldstore, which is also the chain
+	// blockstore when the splitstore is disabled.
+	// Defaults to datastore/chain in the repo.
+	// When either path changes, the existing store must be moved to the new path
+	// before starting the node; the node refuses to start with an empty store at
+	// the new path while the previous path still holds data.
+	ColdStorePath string
+
 	// HotStoreMessageRetention specifies the retention policy for messages, in finalities beyond
 	// the compaction boundary; default is 0.
 	HotStoreMessageRetention uint64
@@ -621,6 +921,88 @@ type Splitstore struct {
 	// is set.  Moving GC will not occur when total moving size exceeds
 	// HotstoreMaxSpaceTarget - HotstoreMaxSpaceSafetyBuffer
 	HotstoreMaxSpaceSafetyBuffer uint64
+
+	// DiskSpaceLowThreshold is the amount of free disk space (in bytes) on the hotstore
+	// or coldstore path below which compaction and pruning are paused and a warning is
+	// reported through the API. A value of 0 disables the check.
+	DiskSpaceLowThreshold uint64
+	// DiskSpaceCriticalThreshold is the amount of free disk space (in bytes) below which
+	// the splitstore additionally refuses writes which are not required for consensus,
+	// such as objects imported through the API. A value of 0 disables the check.
+	DiskSpaceCriticalThreshold uint64
+
+	// EnableCompactionProfiling enables capturing CPU and heap profiles at the start,
+	// middle (before purging cold objects) and end of every compaction. Profiles are
+	// written to the profiles directory in the splitstore path; profiles of the last
+	// 5 compactions are retained.
+	EnableCompactionProfiling bool
+
+	// EnableColdReadPrefetch enables promoting the objects below an object read from the
+	// coldstore to the hotstore in the background, as deep history queries tend to read
+	// clustered state. Reads through the API which explicitly reify cold objects are not
+	// affected.
+	EnableColdReadPrefetch bool
+
+	// ColdReadPromotion is the policy for copying objects read from the coldstore back
+	// to the hotstore, so that frequently accessed historical objects stop paying the
+	// coldstore latency. It can be "never" (default), "always", or "hits" to promote
+	// objects read ColdReadPromotionHits times within ColdReadPromotionWindow epochs.
+	// It has no effect when EnableColdReadPrefetch is set, as prefetching promotes
+	// every object read from the coldstore.
+	ColdReadPromotion string
+	// ColdReadPromotionHits is the number of reads promoting an object with the "hits"
+	// policy.
+	ColdReadPromotionHits uint64
+	// ColdReadPromotionWindow is the number of epochs within which the reads of an object
+	// are counted with the "hits" policy.
+	ColdReadPromotionWindow uint64
+
+	// ColdStoreCacheSize is the size in bytes of an on-disk read-through cache of the objects
+	// recently read from the coldstore, kept in a separate badger store in the splitstore
+	// directory, so that bursts of historical queries don't repeatedly read the same objects
+	// from a slow or remote coldstore. The cache is cleared when the node starts.
+	// A value of 0 (default) disables the cache.
+	ColdStoreCacheSize uint64
+
+	// ColdArchivePath, if set, is a directory where each compaction writes the objects it
+	// moves out of the hotstore as a CAR file, rooted at the compaction tipset, so that the
+	// history can be kept or restored elsewhere. A relative path is resolved against the
+	// splitstore directory.
+	ColdArchivePath string
+	// ColdArchiveUploadURL, if set, is an http(s) URL under which the cold archives are
+	// uploaded with a single PUT request each after each compaction, to any HTTP server
+	// accepting uploads; only static headers are sent, so object stores needing signed
+	// requests are not supported. Uploads are verified by size, and by MD5 when the server
+	// returns it as the ETag; failed uploads are retried after the next compaction.
+	ColdArchiveUploadURL string
+	// ColdArchiveUploadHeaders are extra headers sent with every upload request, in the
+	// "Name: value" format, e.g. for authorization.
+	ColdArchiveUploadHeaders []string
+	// ColdArchiveRetention is the policy for the local copy of uploaded cold archives:
+	// "keep" (default), or "drop" to delete it once the upload is verified.
+	ColdArchiveRetention string
+
+	// CompactionStrategy decides when compaction runs and which objects it evicts from
+	// the hotstore. It can be "epoch" (default), compacting every finality once 5
+	// finalities have elapsed and keeping the objects reachable from the recent chain, or
+	// "capacity", compacting whenever the hotstore grows beyond HotStoreCapacity bytes,
+	// however many epochs have elapsed, and also keeping the most recently accessed
+	// objects until the capacity is filled. The "capacity" strategy suits API serving
+	// nodes whose reads don't follow the chain height; it requires a "universal"
+	// coldstore. "refcount" compacts every finality like "epoch", but tracks a reference
+	// count per hot object instead of walking the chain, and evicts the objects left
+	// without references; it requires a "universal" or "discard" coldstore. The strategy
+	// can't be changed while the node runs.
+	CompactionStrategy string
+	// HotStoreCapacity is the hotstore size in bytes above which the "capacity" strategy
+	// compacts, and which the objects kept in the hotstore are fitted into.
+	HotStoreCapacity uint64
+
+	// ShutdownGracePeriod is how long shutting down the node waits for an ongoing
+	// compaction or prune to stop. After it elapses the operation is aborted; an
+	// interrupted purge is completed from its checkpoint when the node restarts.
+	// Set to 0 to wait indefinitely.
+	ShutdownGracePeriod Duration
 }
 
 // // Full Node
@@ -640,16 +1022,79 @@ type Client struct {
 	// without existing payment channels with available funds will fail instead
 	// of automatically performing on-chain operations.
 	OffChainRetrieval bool
+
+	// Webhooks notified of the lifecycle events of the storage deals made by
+	// the client.
+	DealWebhooks DealWebhooks
+
+	// A gateway serving the imported and retrieved content of the client over
+	// plain HTTP, so that applications can consume it without using the API.
+	HTTPGateway ClientHTTPGateway
+}
+
+type ClientHTTPGateway struct {
+	// Binding address of the gateway, which serves content under /ipfs/<cid>,
+	// as a UnixFS file, or as a CAR with ?format=car. The gateway doesn't
+	// require authentication. Empty disables the gateway.
+	// Format: multiaddress, e.g. /ip4/127.0.0.1/tcp/8080/http
+	ListenAddress string
+	// When enabled, the content which isn't available locally is retrieved
+	// from the storage providers offering it.
+	RetrieveOnMiss bool
+	// The maximum price paid for a retrieval made by the gateway.
+	MaxRetrievalPrice types.FIL
+	// Time after which a retrieval made by the gateway is abandoned.
+	RetrievalTimeout Duration
 }
 
 type Wallet struct {
 	RemoteBackend string
-	EnableLedger  bool
-	DisableLocal  bool
+	// RemoteBackendTLSCert and RemoteBackendTLSKey are the paths of the PEM encoded
+	// client certificate and key presented to the remote wallet, for wallets
+	// requiring mutual TLS (see 'lotus-wallet run --tls-client-ca').
+	RemoteBackendTLSCert string
+	RemoteBackendTLSKey  string
+	// RemoteBackendTLSCA is the path of the PEM encoded CA certificate the remote
+	// wallet certificate is verified against. The system roots are used if empty.
+	// Setting any of the RemoteBackendTLS options makes the node connect to the
+	// remote wallet over https.
+	RemoteBackendTLSCA string
+	EnableLedger       bool
+	DisableLocal       bool
 }
 
 type FeeConfig struct {
 	DefaultMaxFee types.FIL
+
+	// FeeTiersWindow is the number of recent epochs sampled by GasEstimateFeeTiers
+	// when computing the slow/medium/fast gas premium suggestions.
+	FeeTiersWindow uint64
+}
+
+type MpoolConfig struct {
+	// PrioritySenders is a list of addresses (e.g. the operator's miner worker and
+	// control addresses) whose messages are never pruned from the message pool and
+	// are selected for inclusion ahead of other messages. These are prioritized in
+	// addition to the PriorityAddrs set with 'lotus mpool config'.
+	PrioritySenders []string
+
+	// PeerRateLimit is the number of untrusted messages per second accepted from a
+	// single peer, either received over gossip or pushed with MpoolPushUntrusted
+	// by an API client. API clients are identified by their remote host, so all the
+	// messages relayed by a gateway count against the gateway. Messages over the
+	// limit are dropped. Set to 0 to disable rate limiting.
+	// The peer limits are reloaded on SIGHUP or with 'lotus config reload'.
+	PeerRateLimit float64
+	// PeerRateBurst is the number of untrusted messages a peer can send at once
+	// before being rate limited.
+	PeerRateBurst int
+	// PeerMaxRejected is the number of invalid messages a peer can send before all
+	// its messages are dropped for PeerBanDuration. Messages failing soft checks,
+	// such as nonce gaps or insufficient balance, don't count. Set to 0 to disable
+	// banning peers.
+	PeerMaxRejected int
+	// PeerBanDuration is how long the messages of a banned peer are dropped.
+	PeerBanDuration Duration
 }
 
 type UserRaftConfig struct {
@@ -731,4 +1176,23 @@ type Events struct {
 type IndexConfig struct {
 	// EnableMsgIndex enables indexing of messages on chain.
 	EnableMsgIndex bool
+
+	// EnableReceiptIndex enables indexing the receipts of the messages on chain by
+	// message and by receipts root, so that ChainGetParentReceipts, StateSearchMsg and
+	// StateWaitMsg are served without loading receipt AMTs from the blockstore. The receipts of the tipsets synced before
+	// the index was enabled are backfilled in the background, down to genesis or as far
+	// as the blockstore keeps receipts; this is meant for archival nodes.
+	EnableReceiptIndex bool
+
+	// EnableTipSetIndex enables indexing the tipsets of the current chain from key to
+	// height and back, so that ChainGetPath and ChainGetTipSetByHeight look up tipsets
+	// on the current chain without walking headers. The tipsets synced before the index
+	// was enabled are backfilled in the background, down to genesis.
+	EnableTipSetIndex bool
+
+	// MaxMsgSearchLookback caps the number of epochs StateSearchMsg and StateWaitMsg
+	// walk back through the chain looking for a message which isn't in the message
+	// index, including when called without a lookback limit. Such walks can reach
+	// into the coldstore and take minutes. 0 means no cap.
+	MaxMsgSearchLookback int64
 }
@@ -2,7 +2,10 @@ package node
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -116,3 +119,73 @@ func NewReadyHandler(api lapi.FullNode) *HealthHandler {
 	}()
 	return &h
 }
+
+// NewHealthReportHandler serves the aggregated NodeHealth report as JSON. The response status is
+// 200 when the node is healthy and 503 otherwise, so it can be used directly as a probe. The
+// endpoint is unauthenticated, so storage paths are stripped from the report; the NodeHealth API
+// returns the complete report.
+func NewHealthReportHandler(api lapi.FullNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health, err := api.NodeHealth(r.Context())
+		if err != nil {
+			healthlog.Warnf("failed to build health report: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		health = redactHealthPaths(health)
+
+		w.Header().Set("Content-Type", "application/json")
+		if !health.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if err := json.NewEncoder(w).Encode(health); err != nil {
+			healthlog.Warnf("failed to write health report: %s", err)
+		}
+	})
+}
+
+// redactHealthPaths replaces the storage paths in the health report, including the paths under
+// them mentioned in errors, with the name of their role, e.g. "<splitstore>".
+func redactHealthPaths(health lapi.NodeHealth) lapi.NodeHealth {
+	storage := make([]lapi.NodeStorageHealth, len(health.Storage))
+	copy(storage, health.Storage)
+
+	// replace nested paths, e.g. the datastore in the repo, first
+	sort.SliceStable(storage, func(i, j int) bool {
+		return len(storage[i].Path) > len(storage[j].Path)
+	})
+	var oldnew []string
+	for _, sh := range storage {
+		if sh.Path != "" {
+			oldnew = append(oldnew, sh.Path, "<"+sh.Name+">")
+		}
+	}
+	repl := strings.NewReplacer(oldnew...)
+
+	out := health
+	out.Storage = make([]lapi.NodeStorageHealth, len(health.Storage))
+	for i, sh := range health.Storage {
+		sh.Path = ""
+		sh.Error = repl.Replace(sh.Error)
+		out.Storage[i] = sh
+	}
+
+	out.Errors = make([]string, len(health.Errors))
+	for i, e := range health.Errors {
+		out.Errors[i] = repl.Replace(e)
+	}
+
+	if health.Blockstore != nil {
+		out.Blockstore = make(map[string]interface{}, len(health.Blockstore))
+		for k, v := range health.Blockstore {
+			if sv, ok := v.(string); ok {
+				v = repl.Replace(sv)
+			}
+			out.Blockstore[k] = v
+		}
+	}
+
+	return out
+}
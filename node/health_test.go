@@ -0,0 +1,54 @@
+// stm: #unit
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/mocks"
+)
+
+func TestHealthReportHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fn := mocks.NewMockFullNode(ctrl)
+	fn.EXPECT().NodeHealth(gomock.Any()).Return(api.NodeHealth{
+		Healthy: false,
+		Blockstore: map[string]interface{}{
+			"compactions": 3,
+			"last error":  "disk-space: /secret/repo/splitstore/hot.badger is low on disk space (10 bytes free); compaction paused",
+		},
+		Storage: []api.NodeStorageHealth{
+			{Name: "repo", Path: "/secret/repo", Capacity: 100, Available: 10},
+			{Name: "datastore", Path: "/secret/repo/datastore", Error: "stat /secret/repo/datastore: permission denied"},
+			{Name: "splitstore", Path: "/secret/repo/splitstore", Capacity: 100, Available: 1, LowSpace: true},
+		},
+		Errors: []string{
+			"splitstore path /secret/repo/splitstore is low on disk space (1 bytes available)",
+		},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	NewHealthReportHandler(fn).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	body := rec.Body.String()
+	require.NotContains(t, body, "/secret")
+	require.NotContains(t, body, `"Path"`)
+
+	var health api.NodeHealth
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+	require.Equal(t, "disk-space: <splitstore>/hot.badger is low on disk space (10 bytes free); compaction paused", health.Blockstore["last error"])
+	require.Equal(t, float64(3), health.Blockstore["compactions"])
+	require.Equal(t, "stat <datastore>: permission denied", health.Storage[1].Error)
+	require.Equal(t, []string{"splitstore path <splitstore> is low on disk space (1 bytes available)"}, health.Errors)
+	require.True(t, health.Storage[2].LowSpace)
+}
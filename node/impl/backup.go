@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/xerrors"
 
@@ -13,7 +14,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
-func backup(ctx context.Context, mds dtypes.MetadataDS, fpath string) error {
+func backup(ctx context.Context, mds dtypes.MetadataDS, fpath string, extra ...datastore.Read) error {
 	bb, ok := os.LookupEnv("LOTUS_BACKUP_BASE_PATH")
 	if !ok {
 		return xerrors.Errorf("LOTUS_BACKUP_BASE_PATH env var not set")
@@ -53,7 +54,7 @@ func backup(ctx context.Context, mds dtypes.MetadataDS, fpath string) error {
 		return xerrors.Errorf("open %s: %w", fpath, err)
 	}
 
-	if err := bds.Backup(ctx, out); err != nil {
+	if err := bds.Backup(ctx, out, extra...); err != nil {
 		if cerr := out.Close(); cerr != nil {
 			log.Errorw("error closing backup file while handling backup error", "closeErr", cerr, "backupErr", err)
 		}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -47,6 +48,7 @@ import (
 	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
 	"github.com/filecoin-project/go-fil-markets/discovery"
 	rm "github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket/impl/clientstates"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket/network"
 	"github.com/filecoin-project/go-fil-markets/stores"
@@ -63,6 +65,8 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/unixfs"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
+	"github.com/filecoin-project/lotus/markets/retrievalresume"
+	"github.com/filecoin-project/lotus/markets/retrievalstats"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/markets/utils"
 	"github.com/filecoin-project/lotus/node/config"
@@ -77,6 +81,10 @@ var log = logging.Logger("client")
 
 var DefaultHashFunction = unixfs.DefaultHashFunction
 
+// retrievalStallTimeout is how long a retrieval can go without progress before
+// it's considered interrupted
+const retrievalStallTimeout = 10 * time.Minute
+
 // 8 days ~=  SealDuration + PreCommit + MaxProveCommitDuration + 8 hour buffer
 const dealStartBufferHours uint64 = 8 * 24
 const DefaultDAGStoreDir = "dagstore"
@@ -100,6 +108,9 @@ type API struct {
 	RtvlBlockstoreAccessor    rm.BlockstoreAccessor
 	ApiBlockstoreAccessor     *retrievaladapter.APIBlockstoreAccessor
 
+	RetrievalProgress *retrievalresume.Tracker `optional:"true"`
+	RetrievalStats    *retrievalstats.Recorder `optional:"true"`
+
 	DataTransfer dtypes.ClientDataTransfer
 	Host         host.Host
 
@@ -769,6 +780,122 @@ func (a *API) ClientCancelRetrievalDeal(ctx context.Context, dealID rm.DealID) e
 	}
 }
 
+func (a *API) ClientListResumableRetrievals(ctx context.Context) ([]api.ResumableRetrieval, error) {
+	if a.RetrievalProgress == nil {
+		return nil, xerrors.Errorf("retrieval progress is not tracked by this node")
+	}
+
+	sessions, err := a.RetrievalProgress.Sessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.ResumableRetrieval, 0, len(sessions))
+	for _, s := range sessions {
+		rr := api.ResumableRetrieval{
+			ID:                s.DealID,
+			PayloadCID:        s.PayloadCID,
+			PieceCID:          s.PieceCID,
+			Provider:          s.Provider,
+			Status:            s.Status,
+			Message:           s.Message,
+			BytesReceived:     s.BytesReceived,
+			TransferChannelID: s.ChannelID,
+			LastProgress:      s.Updated,
+			Interrupted:       a.RetrievalProgress.Interrupted(s, retrievalStallTimeout),
+		}
+		if s.ChannelID != nil {
+			if cs, err := a.DataTransfer.ChannelState(ctx, *s.ChannelID); err == nil {
+				rr.BlocksReceived = cs.ReceivedCidsTotal()
+			}
+		}
+		out = append(out, rr)
+	}
+
+	return out, nil
+}
+
+func (a *API) ClientResumeRetrieval(ctx context.Context, dealID rm.DealID) error {
+	deal, err := a.Retrieval.GetDeal(dealID)
+	if err != nil {
+		return xerrors.Errorf("getting retrieval deal %d: %w", dealID, err)
+	}
+	if clientstates.IsFinalityState(deal.Status) {
+		return xerrors.Errorf("retrieval deal %d is in final state %s, and can't be resumed", dealID, rm.DealStatuses[deal.Status])
+	}
+	if deal.ChannelID == nil {
+		return xerrors.Errorf("the data transfer of retrieval deal %d hasn't started yet", dealID)
+	}
+
+	// restarting the channel asks the provider to skip the blocks which were
+	// already received and verified
+	if err := a.DataTransfer.RestartDataTransferChannel(ctx, *deal.ChannelID); err != nil {
+		return xerrors.Errorf("restarting data transfer of retrieval deal %d: %w", dealID, err)
+	}
+
+	return nil
+}
+
+func (a *API) ClientRetrievalProviderStats(ctx context.Context) ([]api.RetrievalProviderStats, error) {
+	if a.RetrievalStats == nil {
+		return nil, xerrors.Errorf("retrieval stats are not recorded by this node")
+	}
+
+	stats, err := a.RetrievalStats.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.RetrievalProviderStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, api.RetrievalProviderStats{
+			Miner:         s.Miner,
+			Provider:      s.Provider,
+			Attempts:      s.Attempts,
+			Successes:     s.Successes,
+			BytesReceived: s.BytesReceived,
+			Throughput:    s.Throughput,
+			Paid:          s.Paid,
+			LastAttempt:   s.LastAttempt,
+			LastError:     s.LastError,
+		})
+	}
+
+	return out, nil
+}
+
+func (a *API) ClientRetrievalHistory(ctx context.Context, miner address.Address) ([]api.RetrievalAttempt, error) {
+	if a.RetrievalStats == nil {
+		return nil, xerrors.Errorf("retrieval stats are not recorded by this node")
+	}
+
+	attempts, err := a.RetrievalStats.Attempts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.RetrievalAttempt, 0, len(attempts))
+	for _, at := range attempts {
+		if miner != address.Undef && at.Miner != miner {
+			continue
+		}
+		out = append(out, api.RetrievalAttempt{
+			ID:            at.DealID,
+			Miner:         at.Miner,
+			Provider:      at.Provider,
+			PayloadCID:    at.PayloadCID,
+			Status:        at.Status,
+			Message:       at.Message,
+			BytesReceived: at.BytesReceived,
+			Paid:          at.Paid,
+			Started:       at.Started,
+			Ended:         at.Ended,
+		})
+	}
+
+	return out, nil
+}
+
 func getDataSelector(dps *api.Selector, matchPath bool) (datamodel.Node, error) {
 	sel := selectorparse.CommonSelector_ExploreAllRecursively
 	if dps != nil {
@@ -869,6 +996,12 @@ func (a *API) doRetrieval(ctx context.Context, order api.RetrievalOrder, sel dat
 		return 0, xerrors.Errorf("Retrieve failed: %w", err)
 	}
 
+	if a.RetrievalStats != nil {
+		if err := a.RetrievalStats.Started(ctx, id, order.Miner, order.MinerPeer.ID, order.Root); err != nil {
+			log.Warnw("recording retrieval attempt", "deal", id, "error", err)
+		}
+	}
+
 	return id, nil
 }
 
@@ -946,9 +1079,38 @@ func (ed *ExportDest) doWrite(cb func(io.Writer) error) error {
 }
 
 func (a *API) ClientExport(ctx context.Context, exportRef api.ExportRef, ref api.FileRef) error {
+	if ref.CARv2 {
+		if !ref.IsCAR {
+			return xerrors.Errorf("CARv2 output requires a CAR export")
+		}
+		return a.exportCARv2(ctx, exportRef, ref.Path)
+	}
+
 	return a.ClientExportInto(ctx, exportRef, ref.IsCAR, ExportDest{Path: ref.Path})
 }
 
+// exportCARv2 exports a CARv1 into a temporary file next to the output path,
+// and wraps it into a CARv2 file indexing its blocks at the output path.
+func (a *API) exportCARv2(ctx context.Context, exportRef api.ExportRef, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".carv1-*")
+	if err != nil {
+		return xerrors.Errorf("creating temporary CARv1 file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if err := a.ClientExportInto(ctx, exportRef, true, ExportDest{Path: tmp.Name()}); err != nil {
+		return err
+	}
+
+	if err := carv2.WrapV1File(tmp.Name(), path); err != nil {
+		return xerrors.Errorf("writing CARv2 file: %w", err)
+	}
+	return nil
+}
+
 func (a *API) ClientExportInto(ctx context.Context, exportRef api.ExportRef, car bool, dest ExportDest) error {
 	proxyBss, retrieveIntoIPFS := a.RtvlBlockstoreAccessor.(*retrievaladapter.ProxyBlockstoreAccessor)
 	carBss, retrieveIntoCAR := a.RtvlBlockstoreAccessor.(*retrievaladapter.CARBlockstoreAccessor)
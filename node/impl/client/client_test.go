@@ -133,4 +133,35 @@ func TestImportLocal(t *testing.T) {
 
 	// compare original file to recreated unixfs file.
 	require.Equal(t, b, exportedBytes)
+
+	// retrieve as an indexed CARv2.
+	out3 := filepath.Join(dir, "retrieval3.data")
+	err = a.ClientExport(ctx, order, api.FileRef{
+		Path:  out3,
+		IsCAR: true,
+		CARv2: true,
+	})
+	require.NoError(t, err)
+
+	exportedV2, err := carv2.OpenReader(out3)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, exportedV2.Version)
+	require.True(t, exportedV2.Header.HasIndex())
+
+	exportedV2Roots, err := exportedV2.Roots()
+	require.NoError(t, err)
+	require.EqualValues(t, origRoots, exportedV2Roots)
+
+	// only the output file is left behind
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.False(t, strings.HasPrefix(e.Name(), ".carv1-"))
+	}
+
+	err = a.ClientExport(ctx, order, api.FileRef{
+		Path:  out3,
+		CARv2: true,
+	})
+	require.Error(t, err)
 }
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	selectorparse "github.com/ipld/go-ipld-prime/traversal/selector/parse"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/xerrors"
+
+	rm "github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var errGatewayNotFound = errors.New("content not found")
+
+type GatewayConfig struct {
+	// RetrieveOnMiss enables retrieving the content which isn't available
+	// locally from the storage providers offering it.
+	RetrieveOnMiss bool
+	// MaxPrice is the maximum total price of a retrieval.
+	MaxPrice big.Int
+	// RetrievalTimeout is the time after which a retrieval is abandoned, 0
+	// doesn't limit it.
+	RetrievalTimeout time.Duration
+}
+
+// Gateway serves the content of the client over plain HTTP, under
+// /ipfs/<cid>. Content is exported from the local imports and the completed
+// retrievals, as a UnixFS file by default, or as a CAR with ?format=car.
+type Gateway struct {
+	api *API
+	cfg GatewayConfig
+
+	// ctx bounds the retrievals, which are shared between the requests of the
+	// same content, and outlive the request starting them.
+	ctx context.Context
+	sf  singleflight.Group
+}
+
+func NewGateway(ctx context.Context, a *API, cfg GatewayConfig) *Gateway {
+	return &Gateway{
+		api: a,
+		cfg: cfg,
+		ctx: ctx,
+	}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "only GET and HEAD allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/ipfs/")
+	if path == r.URL.Path || strings.Contains(path, "/") {
+		http.Error(w, "expected a /ipfs/<cid> path", http.StatusBadRequest)
+		return
+	}
+	root, err := cid.Parse(path)
+	if err != nil {
+		http.Error(w, xerrors.Errorf("parsing cid: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	var car bool
+	switch r.URL.Query().Get("format") {
+	case "":
+	case "car":
+		car = true
+	default:
+		http.Error(w, "unsupported format", http.StatusBadRequest)
+		return
+	}
+
+	eref, err := g.exportRef(r.Context(), root)
+	if errors.Is(err, errGatewayNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Warnw("gateway: finding content", "root", root, "error", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if car {
+		w.Header().Set("Content-Type", "application/vnd.ipld.car; version=1")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Etag", `"`+root.String()+`"`)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if err := g.api.ClientExportInto(r.Context(), *eref, car, ExportDest{Writer: w}); err != nil {
+		log.Warnw("gateway: exporting content", "root", root, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// exportRef returns a reference to the local copy of the content, retrieving
+// it if it isn't available locally and retrievals are enabled.
+func (g *Gateway) exportRef(ctx context.Context, root cid.Cid) (*api.ExportRef, error) {
+	eref, err := g.localRef(ctx, root)
+	if err != nil || eref != nil {
+		return eref, err
+	}
+
+	if !g.cfg.RetrieveOnMiss {
+		return nil, errGatewayNotFound
+	}
+
+	res := g.sf.DoChan(root.String(), func() (interface{}, error) {
+		return g.retrieve(root)
+	})
+	select {
+	case r := <-res:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(*api.ExportRef), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// localRef returns a reference to the content in an import or a completed
+// retrieval of the whole DAG, or nil.
+func (g *Gateway) localRef(ctx context.Context, root cid.Cid) (*api.ExportRef, error) {
+	imports, err := g.api.ClientListImports(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing imports: %w", err)
+	}
+	for _, imp := range imports {
+		if imp.Root != nil && imp.Root.Equals(root) && imp.CARPath != "" {
+			return &api.ExportRef{Root: root, FromLocalCAR: imp.CARPath}, nil
+		}
+	}
+
+	if g.api.Retrieval == nil {
+		return nil, nil
+	}
+
+	deals, err := g.api.Retrieval.ListDeals()
+	if err != nil {
+		return nil, xerrors.Errorf("listing retrievals: %w", err)
+	}
+	for _, d := range deals {
+		if d.Status != rm.DealStatusCompleted || !d.PayloadCID.Equals(root) {
+			continue
+		}
+		if d.Selector.Node == nil || !datamodel.DeepEqual(d.Selector.Node, selectorparse.CommonSelector_ExploreAllRecursively) {
+			continue
+		}
+		return &api.ExportRef{Root: root, DealID: d.ID}, nil
+	}
+
+	return nil, nil
+}
+
+// retrieve retrieves the content from the cheapest provider offering it within
+// the max price, trying the next ones on failure.
+func (g *Gateway) retrieve(root cid.Cid) (*api.ExportRef, error) {
+	ctx := g.ctx
+	if g.cfg.RetrievalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.cfg.RetrievalTimeout)
+		defer cancel()
+	}
+
+	offers, err := g.api.ClientFindData(ctx, root, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("finding providers: %w", err)
+	}
+
+	sort.Slice(offers, func(i, j int) bool {
+		return offers[i].MinPrice.LessThan(offers[j].MinPrice)
+	})
+
+	payer, err := g.api.WalletDefaultAddress(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting default wallet address: %w", err)
+	}
+
+	var tried int
+	for _, o := range offers {
+		if o.Err != "" || o.MinPrice.GreaterThan(g.cfg.MaxPrice) {
+			continue
+		}
+		tried++
+
+		log.Infow("gateway: retrieving content", "root", root, "provider", o.Miner, "price", o.MinPrice)
+
+		res, err := g.api.ClientRetrieve(ctx, o.Order(payer))
+		if err != nil {
+			log.Warnw("gateway: starting retrieval", "root", root, "provider", o.Miner, "error", err)
+			continue
+		}
+		if err := g.api.ClientRetrieveWait(ctx, res.DealID); err != nil {
+			log.Warnw("gateway: retrieval failed", "root", root, "provider", o.Miner, "deal", res.DealID, "error", err)
+			if err := g.api.ClientCancelRetrievalDeal(g.ctx, res.DealID); err != nil {
+				log.Debugw("gateway: cancelling retrieval", "deal", res.DealID, "error", err)
+			}
+			continue
+		}
+
+		return &api.ExportRef{Root: root, DealID: res.DealID}, nil
+	}
+
+	if tried == 0 {
+		return nil, errGatewayNotFound
+	}
+	return nil, xerrors.Errorf("retrieval failed from all the %d providers offering the content", tried)
+}
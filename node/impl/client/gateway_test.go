@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/markets/storageadapter"
+	"github.com/filecoin-project/lotus/node/repo/imports"
+)
+
+func TestGateway(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	im := imports.NewManager(ds, t.TempDir())
+	ctx := context.Background()
+
+	a := &API{
+		Imports:                   im,
+		StorageBlockstoreAccessor: storageadapter.NewImportsBlockstoreAccessor(im),
+	}
+
+	b, err := testdata.ReadFile("testdata/payload.txt")
+	require.NoError(t, err)
+
+	root, err := a.ClientImportLocal(ctx, bytes.NewReader(b))
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(NewGateway(ctx, a, GatewayConfig{}))
+	defer srv.Close()
+
+	get := func(method, path string) (int, []byte) {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, body
+	}
+
+	// as a UnixFS file
+	code, body := get(http.MethodGet, "/ipfs/"+root.String())
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, b, body)
+
+	// as a CAR
+	code, body = get(http.MethodGet, "/ipfs/"+root.String()+"?format=car")
+	require.Equal(t, http.StatusOK, code)
+	cr, err := carv2.NewBlockReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, root, cr.Roots[0])
+
+	code, body = get(http.MethodHead, "/ipfs/"+root.String())
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, body)
+
+	// missing content isn't retrieved unless enabled
+	missing := blocks.NewBlock([]byte("missing")).Cid()
+	code, _ = get(http.MethodGet, "/ipfs/"+missing.String())
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, _ = get(http.MethodGet, "/ipfs/"+root.String()+"/sub")
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(http.MethodGet, "/ipfs/"+root.String()+"?format=tar")
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = get(http.MethodPost, "/ipfs/"+root.String())
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+}
@@ -16,6 +16,7 @@ import (
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -28,6 +29,8 @@ type CommonAPI struct {
 	APISecret    *dtypes.APIAlg
 	ShutdownChan dtypes.ShutdownChan
 
+	ConfigReloader *modules.ConfigReloader `optional:"true"`
+
 	Start dtypes.NodeStartTime
 }
 
@@ -82,6 +85,13 @@ func (a *CommonAPI) LogAlerts(ctx context.Context) ([]alerting.Alert, error) {
 	return a.Alerting.GetAlerts(), nil
 }
 
+func (a *CommonAPI) ConfigReload(ctx context.Context) error {
+	if a.ConfigReloader == nil {
+		return xerrors.Errorf("the node has no config file to reload")
+	}
+	return a.ConfigReloader.Reload()
+}
+
 func (a *CommonAPI) Shutdown(ctx context.Context) error {
 	a.ShutdownChan <- struct{}{}
 	return nil
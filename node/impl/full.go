@@ -2,10 +2,14 @@ package impl
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"time"
 
+	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
@@ -17,6 +21,7 @@ import (
 	"github.com/filecoin-project/lotus/node/impl/paych"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/lp2p"
+	"github.com/filecoin-project/lotus/node/repo"
 )
 
 var log = logging.Logger("node")
@@ -38,11 +43,27 @@ type FullNodeAPI struct {
 	full.EthAPI
 
 	DS          dtypes.MetadataDS
+	MpoolDS     dtypes.MpoolDS
 	NetworkName dtypes.NetworkName
+	Repo        repo.LockedRepo
 }
 
 func (n *FullNodeAPI) CreateBackup(ctx context.Context, fpath string) error {
-	return backup(ctx, n.DS, fpath)
+	// blockstores with compaction (i.e. the splitstore) keep their metadata in the
+	// metadata datastore; don't let it change while the backup is taken
+	if locker, ok := n.BaseBlockstore.(interface {
+		LockCompaction(context.Context) (func(), error)
+	}); ok {
+		unlock, err := locker.LockCompaction(ctx)
+		if err != nil {
+			return xerrors.Errorf("locking splitstore compaction: %w", err)
+		}
+		defer unlock()
+	}
+
+	// locally published messages are kept in their own datastore; they are restored into the
+	// metadata datastore, from which they are migrated back when the node starts
+	return backup(ctx, n.DS, fpath, n.MpoolDS)
 }
 
 func (n *FullNodeAPI) NodeStatus(ctx context.Context, inclChainStatus bool) (status api.NodeStatus, err error) {
@@ -119,6 +140,85 @@ func (n *FullNodeAPI) NodeStatus(ctx context.Context, inclChainStatus bool) (sta
 	return status, nil
 }
 
+const (
+	// healthSyncTolerance is the number of epochs the node may lag behind the
+	// wall clock before it is reported as unhealthy
+	healthSyncTolerance = uint64(5)
+	// healthMinFreeRatio is the fraction of free space below which a store path
+	// is reported as low on space
+	healthMinFreeRatio = 0.05
+)
+
+func (n *FullNodeAPI) NodeHealth(ctx context.Context) (health api.NodeHealth, err error) {
+	health.Healthy = true
+	degraded := func(format string, args ...interface{}) {
+		health.Healthy = false
+		health.Errors = append(health.Errors, fmt.Sprintf(format, args...))
+	}
+
+	status, err := n.NodeStatus(ctx, false)
+	if err != nil {
+		degraded("getting sync status: %s", err)
+	} else {
+		health.SyncStatus = status.SyncStatus
+		if status.SyncStatus.Behind >= healthSyncTolerance {
+			degraded("node is %d epochs behind", status.SyncStatus.Behind)
+		}
+	}
+
+	paths := map[string]string{
+		"repo":      n.Repo.Path(),
+		"datastore": filepath.Join(n.Repo.Path(), "datastore"),
+	}
+
+	// only blockstores with compaction (i.e. the splitstore) provide info
+	if info, err := n.ChainBlockstoreInfo(ctx); err == nil {
+		health.Blockstore = info
+		if lastErr, ok := info["last error"]; ok {
+			degraded("blockstore: %s", lastErr)
+		}
+		if space, ok := info["disk space"]; ok && space != "ok" {
+			degraded("blockstore disk space is %s (%v bytes free)", space, info["disk free"])
+		}
+
+		ssPath, err := n.Repo.SplitstorePath()
+		if err != nil {
+			degraded("getting splitstore path: %s", err)
+		} else {
+			paths["splitstore"] = ssPath
+		}
+	}
+
+	for _, name := range []string{"repo", "datastore", "splitstore"} {
+		path, ok := paths[name]
+		if !ok {
+			continue
+		}
+
+		sh := api.NodeStorageHealth{Name: name, Path: path}
+		st, err := n.Repo.Stat(path)
+		if err != nil {
+			sh.Error = err.Error()
+			degraded("stat %s path: %s", name, err)
+		} else {
+			sh.Capacity = st.Capacity
+			sh.Available = st.FSAvailable
+			if st.Capacity > 0 && float64(st.FSAvailable)/float64(st.Capacity) < healthMinFreeRatio {
+				sh.LowSpace = true
+				degraded("%s path %s is low on disk space (%d bytes available)", name, path, st.FSAvailable)
+			}
+		}
+
+		health.Storage = append(health.Storage, sh)
+	}
+
+	if _, err := n.DS.Has(ctx, datastore.NewKey("/health")); err != nil {
+		degraded("metadata datastore: %s", err)
+	}
+
+	return health, nil
+}
+
 func (n *FullNodeAPI) RaftState(ctx context.Context) (*api.RaftStateData, error) {
 	return n.RaftAPI.GetRaftState(ctx)
 }
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -23,6 +24,7 @@ import (
 	blocks "github.com/ipfs/go-libipfs/blocks"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/ipfs/go-merkledag"
+	"github.com/mitchellh/go-homedir"
 	mh "github.com/multiformats/go-multihash"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.uber.org/fx"
@@ -35,6 +37,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -68,7 +71,8 @@ var _ ChainModuleAPI = *new(api.FullNode)
 type ChainModule struct {
 	fx.In
 
-	Chain *store.ChainStore
+	Chain       *store.ChainStore
+	TipSetIndex index.TipSetIndex `optional:"true"`
 
 	// ExposedBlockstore is the global monolith blockstore that is safe to
 	// expose externally. In the future, this will be segregated into two
@@ -84,8 +88,10 @@ type ChainAPI struct {
 	WalletAPI
 	ChainModuleAPI
 
-	Chain  *store.ChainStore
-	TsExec stmgr.Executor
+	Chain        *store.ChainStore
+	TsExec       stmgr.Executor
+	ReceiptIndex index.ReceiptIndex `optional:"true"`
+	TipSetIndex  index.TipSetIndex  `optional:"true"`
 
 	// ExposedBlockstore is the global monolith blockstore that is safe to
 	// expose externally. In the future, this will be segregated into two
@@ -115,7 +121,7 @@ func (m *ChainModule) ChainGetTipSet(ctx context.Context, key types.TipSetKey) (
 }
 
 func (m *ChainModule) ChainGetPath(ctx context.Context, from, to types.TipSetKey) ([]*api.HeadChange, error) {
-	return m.Chain.GetPath(ctx, from, to)
+	return chainGetPath(ctx, m.Chain, m.TipSetIndex, from, to)
 }
 
 func (m *ChainModule) ChainGetBlockMessages(ctx context.Context, msg cid.Cid) (*api.BlockMessages, error) {
@@ -147,7 +153,64 @@ func (m *ChainModule) ChainGetBlockMessages(ctx context.Context, msg cid.Cid) (*
 }
 
 func (a *ChainAPI) ChainGetPath(ctx context.Context, from types.TipSetKey, to types.TipSetKey) ([]*api.HeadChange, error) {
-	return a.Chain.GetPath(ctx, from, to)
+	return chainGetPath(ctx, a.Chain, a.TipSetIndex, from, to)
+}
+
+// chainGetPath computes the path between two tipsets through the tipset index when both are in
+// the current chain, loading only the tipsets on the path, and otherwise by walking the chain
+// from both ends to their common ancestor.
+func chainGetPath(ctx context.Context, cs *store.ChainStore, tsi index.TipSetIndex, from, to types.TipSetKey) ([]*api.HeadChange, error) {
+	if tsi == nil {
+		return cs.GetPath(ctx, from, to)
+	}
+
+	fh, err := tsi.GetTipSetHeight(ctx, from)
+	if err != nil {
+		return cs.GetPath(ctx, from, to)
+	}
+	th, err := tsi.GetTipSetHeight(ctx, to)
+	if err != nil {
+		return cs.GetPath(ctx, from, to)
+	}
+
+	// one tipset is an ancestor of the other, so the path only reverts or only applies
+	low, high, typ := from, to, store.HCApply
+	if fh > th {
+		low, high, typ = to, from, store.HCRevert
+		fh, th = th, fh
+	}
+
+	// the index only holds the current chain, so low is the ancestor of high at its height
+	if k, err := tsi.GetTipSetKey(ctx, fh); err != nil || k != low {
+		// the head changed in the meantime
+		return cs.GetPath(ctx, from, to)
+	}
+
+	ts, err := cs.LoadTipSet(ctx, high)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", high, err)
+	}
+
+	var path []*api.HeadChange
+	for ts.Height() > fh {
+		path = append(path, &api.HeadChange{Type: typ, Val: ts})
+		pts, err := cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %w", ts.Parents(), err)
+		}
+		ts = pts
+	}
+	if ts.Key() != low {
+		return cs.GetPath(ctx, from, to)
+	}
+
+	if typ == store.HCApply {
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+	}
+
+	return path, nil
 }
 
 func (a *ChainAPI) ChainGetParentMessages(ctx context.Context, bcid cid.Cid) ([]api.Message, error) {
@@ -193,9 +256,23 @@ func (a *ChainAPI) ChainGetParentReceipts(ctx context.Context, bcid cid.Cid) ([]
 		return nil, nil
 	}
 
-	receipts, err := a.Chain.ReadReceipts(ctx, b.ParentMessageReceipts)
-	if err != nil {
-		return nil, err
+	var receipts []types.MessageReceipt
+	if a.ReceiptIndex != nil {
+		receipts, err = a.ReceiptIndex.GetReceipts(ctx, b.ParentMessageReceipts)
+		switch {
+		case err == nil:
+		case errors.Is(err, index.ErrNotFound):
+			// ok for the index to have incomplete data
+		default:
+			log.Warnf("error searching receipt index: %s", err)
+		}
+	}
+
+	if receipts == nil {
+		receipts, err = a.Chain.ReadReceipts(ctx, b.ParentMessageReceipts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	out := make([]*types.MessageReceipt, len(receipts))
@@ -234,6 +311,10 @@ func (a *ChainAPI) ChainGetMessagesInTipset(ctx context.Context, tsk types.TipSe
 }
 
 func (m *ChainModule) ChainGetTipSetByHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
+	if ts, err := m.indexedTipSetByHeight(ctx, h, tsk); ts != nil || err != nil {
+		return ts, err
+	}
+
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
@@ -242,6 +323,10 @@ func (m *ChainModule) ChainGetTipSetByHeight(ctx context.Context, h abi.ChainEpo
 }
 
 func (m *ChainModule) ChainGetTipSetAfterHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
+	if ts, err := m.indexedTipSetByHeight(ctx, h, tsk); ts != nil || err != nil {
+		return ts, err
+	}
+
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
@@ -249,6 +334,45 @@ func (m *ChainModule) ChainGetTipSetAfterHeight(ctx context.Context, h abi.Chain
 	return m.Chain.GetTipsetByHeight(ctx, h, ts, false)
 }
 
+// indexedTipSetByHeight looks up the tipset at height h below tsk through the tipset index. It
+// returns nil when tsk is not in the current chain as indexed, or when h is a null round, in which
+// case the chain store picks the tipset around it.
+func (m *ChainModule) indexedTipSetByHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
+	if m.TipSetIndex == nil {
+		return nil, nil
+	}
+
+	if tsk.IsEmpty() {
+		tsk = m.Chain.GetHeaviestTipSet().Key()
+	}
+
+	th, err := m.TipSetIndex.GetTipSetHeight(ctx, tsk)
+	if err != nil || h > th {
+		return nil, nil
+	}
+
+	key, err := m.TipSetIndex.GetTipSetKey(ctx, h)
+	if err != nil {
+		return nil, nil
+	}
+
+	// a reorg between the two lookups may have replaced the tipset at h; it is only an ancestor
+	// of tsk if tsk is still in the current chain
+	if nh, err := m.TipSetIndex.GetTipSetHeight(ctx, tsk); err != nil || nh != th {
+		return nil, nil
+	}
+
+	ts, err := m.Chain.LoadTipSet(ctx, key)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", key, err)
+	}
+	if ts.Height() != h {
+		return nil, nil
+	}
+
+	return ts, nil
+}
+
 func (m *ChainModule) ChainReadObj(ctx context.Context, obj cid.Cid) ([]byte, error) {
 	blk, err := m.ExposedBlockstore.Get(ctx, obj)
 	if err != nil {
@@ -682,6 +806,17 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 	return out, nil
 }
 
+func (a *ChainAPI) ChainColdDedup(ctx context.Context) (api.ColdDedupResult, error) {
+	deduper, ok := a.BaseBlockstore.(interface {
+		DedupColdStore(context.Context) (api.ColdDedupResult, error)
+	})
+	if !ok {
+		return api.ColdDedupResult{}, xerrors.Errorf("base blockstore does not support coldstore deduplication (%T)", a.BaseBlockstore)
+	}
+
+	return deduper.DedupColdStore(ctx)
+}
+
 func (a *ChainAPI) ChainCheckBlockstore(ctx context.Context) error {
 	checker, ok := a.BaseBlockstore.(interface{ Check() error })
 	if !ok {
@@ -700,6 +835,64 @@ func (a *ChainAPI) ChainBlockstoreInfo(ctx context.Context) (map[string]interfac
 	return info.Info(), nil
 }
 
+func (a *ChainAPI) ChainBlockstorePendingWrites(ctx context.Context) ([]cid.Cid, error) {
+	inspector, ok := a.BaseBlockstore.(interface{ PendingWrites() []cid.Cid })
+	if !ok {
+		return nil, xerrors.Errorf("base blockstore does not track pending writes (%T)", a.BaseBlockstore)
+	}
+
+	return inspector.PendingWrites(), nil
+}
+
+func (a *ChainAPI) ChainBlockstoreDumpMarkSet(ctx context.Context, path string) error {
+	dumper, ok := a.BaseBlockstore.(interface{ DumpMarkSet(string) error })
+	if !ok {
+		return xerrors.Errorf("base blockstore does not support dumping marksets (%T)", a.BaseBlockstore)
+	}
+
+	path, err := markSetDumpPath(path)
+	if err != nil {
+		return err
+	}
+
+	return dumper.DumpMarkSet(path)
+}
+
+// markSetDumpPath checks that a markset dump is written inside the directory set with
+// LOTUS_MARKSET_DUMP_BASE_PATH, as the node would otherwise create any file it can write.
+func markSetDumpPath(fpath string) (string, error) {
+	bb, ok := os.LookupEnv("LOTUS_MARKSET_DUMP_BASE_PATH")
+	if !ok {
+		return "", xerrors.Errorf("LOTUS_MARKSET_DUMP_BASE_PATH env var not set")
+	}
+
+	bb, err := homedir.Expand(bb)
+	if err != nil {
+		return "", xerrors.Errorf("expanding base path: %w", err)
+	}
+
+	bb, err = filepath.Abs(bb)
+	if err != nil {
+		return "", xerrors.Errorf("getting absolute base path: %w", err)
+	}
+
+	fpath, err = homedir.Expand(fpath)
+	if err != nil {
+		return "", xerrors.Errorf("expanding file path: %w", err)
+	}
+
+	fpath, err = filepath.Abs(fpath)
+	if err != nil {
+		return "", xerrors.Errorf("getting absolute file path: %w", err)
+	}
+
+	if !strings.HasPrefix(fpath, bb+string(filepath.Separator)) {
+		return "", xerrors.Errorf("markset dump file name (%s) must be inside base path (%s)", fpath, bb)
+	}
+
+	return fpath, nil
+}
+
 // ChainGetEvents returns the events under an event AMT root CID.
 //
 // TODO (raulk) make copies of this logic elsewhere use this (e.g. itests, CLI, events filter).
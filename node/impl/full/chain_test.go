@@ -0,0 +1,32 @@
+// stm: #unit
+package full
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkSetDumpPath(t *testing.T) {
+	base := t.TempDir()
+
+	_, err := markSetDumpPath(filepath.Join(base, "markset"))
+	require.ErrorContains(t, err, "LOTUS_MARKSET_DUMP_BASE_PATH")
+
+	t.Setenv("LOTUS_MARKSET_DUMP_BASE_PATH", base)
+
+	p, err := markSetDumpPath(filepath.Join(base, "markset"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(base, "markset"), p)
+
+	for _, p := range []string{
+		"/etc/passwd",
+		base,
+		base + "-other/markset",
+		filepath.Join(base, "..", "markset"),
+	} {
+		_, err := markSetDumpPath(p)
+		require.Error(t, err, p)
+	}
+}
@@ -56,7 +56,8 @@ type GasAPI struct {
 	Chain *store.ChainStore
 	Mpool *messagepool.MessagePool
 
-	PriceCache *GasPriceCache
+	PriceCache     *GasPriceCache
+	FeeTiersWindow dtypes.FeeTiersWindowFunc
 }
 
 func NewGasPriceCache() *GasPriceCache {
@@ -234,6 +235,180 @@ func gasEstimateGasPremium(ctx context.Context, cstore *store.ChainStore, cache
 	return premium, nil
 }
 
+// gasPremiumPercentile returns the premium below which the given percentage of the
+// sampled gas was priced, weighting each message by its gas limit
+func gasPremiumPercentile(prices []GasMeta, pct int) abi.TokenAmount {
+	if len(prices) == 0 {
+		return big.Zero()
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		// sort asc by price
+		return prices[i].Price.LessThan(prices[j].Price)
+	})
+
+	var total int64
+	for _, price := range prices {
+		total += price.Limit
+	}
+
+	at := total / 100 * int64(pct)
+	for _, price := range prices {
+		at -= price.Limit
+		if at < 0 {
+			return price.Price
+		}
+	}
+
+	return prices[len(prices)-1].Price
+}
+
+// feeTiers maps the fee tiers to the percentile of included gas they target and the
+// number of epochs their fee cap should survive base fee increases for
+var feeTiers = []struct {
+	percentile  int
+	maxqueueblk int64
+}{
+	{percentile: 25, maxqueueblk: 20}, // slow
+	{percentile: 50, maxqueueblk: 10}, // medium
+	{percentile: 90, maxqueueblk: 5},  // fast
+}
+
+func (a *GasAPI) GasEstimateFeeTiers(ctx context.Context, tsk types.TipSetKey) (*api.FeeTiers, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset: %w", err)
+	}
+
+	window, err := a.FeeTiersWindow()
+	if err != nil {
+		return nil, xerrors.Errorf("getting fee tiers window: %w", err)
+	}
+	if window == 0 {
+		window = 1
+	}
+
+	out := &api.FeeTiers{
+		Height:  ts.Height(),
+		BaseFee: ts.Blocks()[0].ParentBaseFee,
+	}
+
+	var prices []GasMeta
+	for i := uint64(0); i < window; i++ {
+		if ts.Height() == 0 {
+			break // genesis
+		}
+
+		pts, err := a.Chain.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+
+		meta, err := a.PriceCache.GetTSGasStats(ctx, a.Chain, pts)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, meta...)
+		out.Epochs++
+
+		ts = pts
+	}
+
+	tiers := []*api.FeeTier{&out.Slow, &out.Medium, &out.Fast}
+	for i, t := range feeTiers {
+		premium := gasPremiumPercentile(prices, t.percentile)
+		if types.BigCmp(premium, types.NewInt(MinGasPremium)) < 0 {
+			premium = types.NewInt(MinGasPremium)
+		}
+
+		increaseFactor := math.Pow(1.+1./float64(build.BaseFeeMaxChangeDenom), float64(t.maxqueueblk))
+		feeCap := types.BigMul(out.BaseFee, types.NewInt(uint64(increaseFactor*(1<<8))))
+		feeCap = types.BigDiv(feeCap, types.NewInt(1<<8))
+
+		*tiers[i] = api.FeeTier{
+			Percentile: t.percentile,
+			GasPremium: premium,
+			GasFeeCap:  types.BigAdd(feeCap, premium),
+		}
+	}
+
+	return out, nil
+}
+
+// maxBaseFeeHistory caps the number of epochs GasBaseFeeHistory looks back at and projects
+const maxBaseFeeHistory = 1024
+
+func (a *GasAPI) GasBaseFeeHistory(ctx context.Context, lookback, projection uint64, tsk types.TipSetKey) (*api.BaseFeeHistory, error) {
+	if lookback > maxBaseFeeHistory || projection > maxBaseFeeHistory {
+		return nil, xerrors.Errorf("lookback and projection should be at most %d", maxBaseFeeHistory)
+	}
+	if lookback == 0 {
+		lookback = 1
+	}
+
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset: %w", err)
+	}
+	head := ts
+
+	out := &api.BaseFeeHistory{}
+	var totalGasPerBlock int64
+	for i := uint64(0); i < lookback; i++ {
+		meta, err := a.PriceCache.GetTSGasStats(ctx, a.Chain, ts)
+		if err != nil {
+			return nil, err
+		}
+		var gasLimit int64
+		for _, m := range meta {
+			gasLimit += m.Limit
+		}
+		gasPerBlock := gasLimit / int64(len(ts.Blocks()))
+		totalGasPerBlock += gasPerBlock
+
+		out.History = append(out.History, api.BaseFeePoint{
+			Height:   ts.Height(),
+			BaseFee:  ts.Blocks()[0].ParentBaseFee,
+			Fullness: float64(gasPerBlock) / float64(build.BlockGasTarget),
+		})
+
+		if ts.Height() == 0 {
+			break // genesis
+		}
+		ts, err = a.Chain.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	// oldest first
+	for i, j := 0, len(out.History)-1; i < j; i, j = i+1, j-1 {
+		out.History[i], out.History[j] = out.History[j], out.History[i]
+	}
+
+	avgGasPerBlock := totalGasPerBlock / int64(len(out.History))
+	out.Fullness = float64(avgGasPerBlock) / float64(build.BlockGasTarget)
+	out.Projected = projectBaseFee(head.Blocks()[0].ParentBaseFee, avgGasPerBlock, head.Height(), projection)
+
+	return out, nil
+}
+
+// projectBaseFee computes the base fee of the `epochs` epochs following `height`, at which
+// the base fee was `baseFee`, assuming every block includes `gasPerBlock` gas.
+func projectBaseFee(baseFee types.BigInt, gasPerBlock int64, height abi.ChainEpoch, epochs uint64) []api.BaseFeePoint {
+	out := make([]api.BaseFeePoint, 0, epochs)
+	for i := uint64(0); i < epochs; i++ {
+		baseFee = store.ComputeNextBaseFee(baseFee, gasPerBlock, 1, height)
+		height++
+
+		out = append(out, api.BaseFeePoint{
+			Height:  height,
+			BaseFee: baseFee,
+		})
+	}
+	return out
+}
+
 func (a *GasAPI) GasEstimateGasLimit(ctx context.Context, msgIn *types.Message, tsk types.TipSetKey) (int64, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
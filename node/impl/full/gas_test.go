@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/build"
@@ -40,3 +41,50 @@ func TestMedian(t *testing.T) {
 		{big.NewInt(30), build.BlockGasTarget / 2},
 	}, 2))
 }
+
+func TestGasPremiumPercentile(t *testing.T) {
+	require.Equal(t, big.Zero(), gasPremiumPercentile(nil, 50))
+
+	prices := []GasMeta{
+		{big.NewInt(40), build.BlockGasTarget},
+		{big.NewInt(10), build.BlockGasTarget},
+		{big.NewInt(30), build.BlockGasTarget},
+		{big.NewInt(20), build.BlockGasTarget},
+	}
+
+	require.Equal(t, types.NewInt(10), gasPremiumPercentile(prices, 0))
+	require.Equal(t, types.NewInt(20), gasPremiumPercentile(prices, 25))
+	require.Equal(t, types.NewInt(30), gasPremiumPercentile(prices, 50))
+	require.Equal(t, types.NewInt(40), gasPremiumPercentile(prices, 90))
+	require.Equal(t, types.NewInt(40), gasPremiumPercentile(prices, 100))
+
+	// weighted by gas limit
+	require.Equal(t, types.NewInt(10), gasPremiumPercentile([]GasMeta{
+		{big.NewInt(10), 3 * build.BlockGasTarget},
+		{big.NewInt(20), build.BlockGasTarget},
+	}, 50))
+}
+
+func TestProjectBaseFee(t *testing.T) {
+	baseFee := types.NewInt(1000 * build.MinimumBaseFee)
+	height := abi.ChainEpoch(build.UpgradeSmokeHeight + 10)
+
+	// blocks at target keep the base fee stable
+	projected := projectBaseFee(baseFee, build.BlockGasTarget, height, 3)
+	require.Len(t, projected, 3)
+	for i, p := range projected {
+		require.Equal(t, height+abi.ChainEpoch(i+1), p.Height)
+		require.Equal(t, baseFee, p.BaseFee)
+	}
+
+	// full blocks increase the base fee by 12.5% every epoch
+	projected = projectBaseFee(baseFee, 2*build.BlockGasTarget, height, 2)
+	require.Equal(t, types.NewInt(1125*build.MinimumBaseFee), projected[0].BaseFee)
+	require.Equal(t, types.BigDiv(types.NewInt(1125*1125*build.MinimumBaseFee), types.NewInt(1000)), projected[1].BaseFee)
+
+	// empty blocks decrease the base fee down to the minimum
+	projected = projectBaseFee(types.NewInt(build.MinimumBaseFee), 0, height, 1)
+	require.Equal(t, types.NewInt(build.MinimumBaseFee), projected[0].BaseFee)
+
+	require.Empty(t, projectBaseFee(baseFee, 0, height, 0))
+}
@@ -3,6 +3,7 @@ package full
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
@@ -16,6 +17,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/messagesigner"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/sigs"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -47,8 +49,11 @@ type MpoolAPI struct {
 	RaftAPI
 
 	MessageSigner messagesigner.MsgSigner
+	GetMaxFee     dtypes.DefaultMaxFeeFunc
+	NetName       dtypes.NetworkName
 
-	PushLocks *dtypes.MpoolLocker
+	PushLocks   *dtypes.MpoolLocker
+	PeerLimiter *messagepool.PeerLimiter `optional:"true"`
 }
 
 func (a *MpoolAPI) MpoolGetConfig(context.Context) (*types.MpoolConfig, error) {
@@ -137,7 +142,48 @@ func (m *MpoolModule) MpoolPush(ctx context.Context, smsg *types.SignedMessage)
 }
 
 func (a *MpoolAPI) MpoolPushUntrusted(ctx context.Context, smsg *types.SignedMessage) (cid.Cid, error) {
-	return a.Mpool.PushUntrusted(ctx, smsg)
+	peer := messagepool.PeerFromContext(ctx)
+	if err := a.PeerLimiter.Allow(peer); err != nil {
+		return cid.Undef, err
+	}
+
+	c, err := a.Mpool.PushUntrusted(ctx, smsg)
+	a.PeerLimiter.Record(peer, err)
+	return c, err
+}
+
+func (a *MpoolAPI) MpoolSendQueue(ctx context.Context, addr address.Address) ([]*types.SignedMessage, error) {
+	return a.Mpool.SendQueue(ctx, addr)
+}
+
+func (a *MpoolAPI) MpoolSendQueueFlush(ctx context.Context, from address.Address, drop bool) ([]cid.Cid, error) {
+	smsgs, err := a.Mpool.FlushSendQueue(ctx, from, drop)
+	out := make([]cid.Cid, 0, len(smsgs))
+	for _, smsg := range smsgs {
+		out = append(out, smsg.Cid())
+	}
+	return out, err
+}
+
+func (a *MpoolAPI) MpoolPeerStats(context.Context) ([]api.MpoolPeerInfo, error) {
+	stats := a.PeerLimiter.Stats()
+	out := make([]api.MpoolPeerInfo, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, api.MpoolPeerInfo{
+			Peer:        s.Peer,
+			Accepted:    s.Accepted,
+			Rejected:    s.Rejected,
+			RateLimited: s.RateLimited,
+			LastSeen:    s.LastSeen,
+			BannedUntil: s.BannedUntil,
+		})
+	}
+	return out, nil
+}
+
+func (a *MpoolAPI) MpoolPeerReset(_ context.Context, peer string) error {
+	a.PeerLimiter.Reset(peer)
+	return nil
 }
 
 func (a *MpoolAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
@@ -253,7 +299,7 @@ func (a *MpoolAPI) MpoolBatchPush(ctx context.Context, smsgs []*types.SignedMess
 func (a *MpoolAPI) MpoolBatchPushUntrusted(ctx context.Context, smsgs []*types.SignedMessage) ([]cid.Cid, error) {
 	var messageCids []cid.Cid
 	for _, smsg := range smsgs {
-		smsgCid, err := a.Mpool.PushUntrusted(ctx, smsg)
+		smsgCid, err := a.MpoolPushUntrusted(ctx, smsg)
 		if err != nil {
 			return messageCids, err
 		}
@@ -274,6 +320,176 @@ func (a *MpoolAPI) MpoolBatchPushMessage(ctx context.Context, msgs []*types.Mess
 	return smsgs, nil
 }
 
+func (a *MpoolAPI) MpoolPrepareOffline(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (_ *api.OfflineMessage, err error) {
+	cp := *msg
+	msg = &cp
+
+	ts := a.Chain.GetHeaviestTipSet()
+
+	fromA, err := a.Stmgr.ResolveToDeterministicAddress(ctx, msg.From, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("getting key address: %w", err)
+	}
+	if msg.From.Protocol() == address.ID {
+		msg.From = fromA
+	}
+
+	var lease *api.NonceLease
+	if msg.Nonce == 0 {
+		// lease the nonce, so that it isn't used by the messages signed by this node until the
+		// message is signed and pushed
+		lease, err = a.MessageSigner.LeaseNonce(ctx, fromA, messagesigner.MaxNonceLeaseTTL)
+		if err != nil {
+			return nil, xerrors.Errorf("leasing nonce: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				if rerr := a.MessageSigner.ReleaseNonce(ctx, lease.ID); rerr != nil {
+					log.Warnf("releasing nonce lease %s: %s", lease.ID, rerr)
+				}
+			}
+		}()
+
+		msg.Nonce = lease.Nonce
+	}
+
+	msg, err = a.GasAPI.GasEstimateMessageGas(ctx, msg, spec, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("GasEstimateMessageGas error: %w", err)
+	}
+	if msg.GasPremium.GreaterThan(msg.GasFeeCap) {
+		return nil, xerrors.Errorf("after estimation, GasPremium (%s) is greater than GasFeeCap (%s)", msg.GasPremium, msg.GasFeeCap)
+	}
+
+	act, err := a.Stmgr.LoadActor(ctx, fromA, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading sender actor: %w", err)
+	}
+
+	requiredFunds := big.Add(msg.Value, msg.RequiredFunds())
+	if act.Balance.LessThan(requiredFunds) {
+		return nil, xerrors.Errorf("not enough funds: %s < %s", act.Balance, requiredFunds)
+	}
+
+	return &api.OfflineMessage{
+		Message:     msg,
+		SigningKey:  fromA,
+		NetworkName: a.NetName,
+		Height:      ts.Height(),
+		TipSet:      ts.Key(),
+		Balance:     act.Balance,
+		Lease:       lease,
+	}, nil
+}
+
+func (a *MpoolAPI) MpoolPushOffline(ctx context.Context, om *api.OfflineMessage) (cid.Cid, error) {
+	if om.Message == nil {
+		return cid.Undef, xerrors.Errorf("no message to push")
+	}
+	if om.Signature == nil {
+		return cid.Undef, xerrors.Errorf("message %s is not signed", om.Message.Cid())
+	}
+	if om.NetworkName != a.NetName {
+		return cid.Undef, xerrors.Errorf("message was prepared for network %q, this node is on %q", om.NetworkName, a.NetName)
+	}
+
+	fromA, err := a.Stmgr.ResolveToDeterministicAddress(ctx, om.Message.From, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting key address: %w", err)
+	}
+	if fromA != om.SigningKey {
+		return cid.Undef, xerrors.Errorf("the key of the sender is %s, not %s", fromA, om.SigningKey)
+	}
+
+	sb, err := messagesigner.SigningBytes(om.Message, fromA.Protocol())
+	if err != nil {
+		return cid.Undef, err
+	}
+	if err := sigs.Verify(om.Signature, fromA, sb); err != nil {
+		return cid.Undef, xerrors.Errorf("invalid signature: %w", err)
+	}
+
+	return a.MpoolModuleAPI.MpoolPush(ctx, &types.SignedMessage{
+		Message:   *om.Message,
+		Signature: *om.Signature,
+	})
+}
+
+func (a *MpoolAPI) MpoolLeaseNonce(ctx context.Context, addr address.Address, ttl time.Duration) (*api.NonceLease, error) {
+	// messages are signed with the key address, so lease the nonces of that
+	fromA, err := a.Stmgr.ResolveToDeterministicAddress(ctx, addr, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("getting key address: %w", err)
+	}
+
+	return a.MessageSigner.LeaseNonce(ctx, fromA, ttl)
+}
+
+func (a *MpoolAPI) MpoolReleaseNonce(ctx context.Context, lease uuid.UUID) error {
+	return a.MessageSigner.ReleaseNonce(ctx, lease)
+}
+
+func (a *MpoolAPI) MpoolReplaceBatch(ctx context.Context, from address.Address, spec *api.MessageSendSpec) ([]api.ReplaceResult, error) {
+	fromA, err := a.Stmgr.ResolveToDeterministicAddress(ctx, from, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("getting key address: %w", err)
+	}
+	{
+		done, err := a.PushLocks.TakeLock(ctx, fromA)
+		if err != nil {
+			return nil, xerrors.Errorf("taking lock: %w", err)
+		}
+		defer done()
+	}
+
+	cfg := a.Mpool.GetConfig()
+	pending, _ := a.Mpool.PendingFor(ctx, fromA)
+
+	res := make([]api.ReplaceResult, 0, len(pending))
+	for _, p := range pending {
+		r := api.ReplaceResult{Nonce: p.Message.Nonce}
+		smsg, err := a.replaceMessage(ctx, p.Message, spec, cfg.ReplaceByFeeRatio)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Replacement = smsg
+		}
+		res = append(res, r)
+	}
+
+	return res, nil
+}
+
+// replaceMessage reprices a pending message and pushes the replacement.
+func (a *MpoolAPI) replaceMessage(ctx context.Context, msg types.Message, spec *api.MessageSendSpec, rbfRatio types.Percent) (*types.SignedMessage, error) {
+	minPremium := messagepool.ComputeRBF(msg.GasPremium, rbfRatio)
+
+	msg.GasFeeCap = big.Zero()
+	msg.GasPremium = big.Zero()
+	est, err := a.GasAPI.GasEstimateMessageGas(ctx, &msg, spec, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("estimating gas: %w", err)
+	}
+
+	msg.GasPremium = big.Max(est.GasPremium, minPremium)
+	msg.GasFeeCap = big.Max(est.GasFeeCap, msg.GasPremium)
+	messagepool.CapGasFee(a.GetMaxFee, &msg, spec)
+
+	if msg.GasPremium.LessThan(minPremium) {
+		return nil, xerrors.Errorf("premium capped at %s by max fee, below the replace-by-fee minimum %s", msg.GasPremium, minPremium)
+	}
+
+	smsg, err := a.WalletSignMessage(ctx, msg.From, &msg)
+	if err != nil {
+		return nil, xerrors.Errorf("signing: %w", err)
+	}
+
+	if _, err := a.MpoolModuleAPI.MpoolPush(ctx, smsg); err != nil {
+		return nil, xerrors.Errorf("pushing replacement: %w", err)
+	}
+	return smsg, nil
+}
+
 func (a *MpoolAPI) MpoolCheckMessages(ctx context.Context, protos []*api.MessagePrototype) ([][]api.MessageCheckStatus, error) {
 	return a.Mpool.CheckMessages(ctx, protos)
 }
@@ -293,3 +509,50 @@ func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uin
 func (a *MpoolAPI) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
 	return a.Mpool.Updates(ctx)
 }
+
+func (a *MpoolAPI) MpoolSubFiltered(ctx context.Context, filter api.MpoolUpdateFilter) (<-chan api.MpoolUpdate, error) {
+	// messages may use either the ID or the key address of the sender and recipient,
+	// so match both forms of the filtered addresses
+	ts := a.Chain.GetHeaviestTipSet()
+	filter.From = a.addrForms(ctx, filter.From, ts)
+	filter.To = a.addrForms(ctx, filter.To, ts)
+
+	sub, err := a.Mpool.Updates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan api.MpoolUpdate, 20)
+	go func() {
+		defer close(out)
+
+		for u := range sub {
+			if !filter.Matches(&u.Message.Message) {
+				continue
+			}
+
+			select {
+			case out <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (a *MpoolAPI) addrForms(ctx context.Context, addrs []address.Address, ts *types.TipSet) []address.Address {
+	out := make([]address.Address, 0, 2*len(addrs))
+	for _, addr := range addrs {
+		out = append(out, addr)
+
+		if idAddr, err := a.Stmgr.LookupID(ctx, addr, ts); err == nil && idAddr != addr {
+			out = append(out, idAddr)
+		}
+		if keyAddr, err := a.Stmgr.ResolveToDeterministicAddress(ctx, addr, ts); err == nil && keyAddr != addr {
+			out = append(out, keyAddr)
+		}
+	}
+	return out
+}
@@ -6,9 +6,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/ipfs/go-cid"
+	ipldcbor "github.com/ipfs/go-ipld-cbor"
+	ipld "github.com/ipfs/go-ipld-format"
 	"github.com/libp2p/go-libp2p/core/peer"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.uber.org/fx"
@@ -19,6 +25,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	actorstypes "github.com/filecoin-project/go-state-types/actors"
 	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
 	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
 	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
 	"github.com/filecoin-project/go-state-types/cbor"
@@ -83,6 +90,8 @@ type StateModule struct {
 
 	StateManager *stmgr.StateManager
 	Chain        *store.ChainStore
+
+	MaxMsgSearchLookback dtypes.MaxMsgSearchLookback `optional:"true"`
 }
 
 var _ StateModuleAPI = (*StateModule)(nil)
@@ -94,6 +103,7 @@ type StateAPI struct {
 	// API attached to the state API. It probably should live somewhere better
 	Wallet    api.Wallet
 	DefWallet wallet.Default
+	Watched   wallet.Watched
 
 	StateModuleAPI
 
@@ -103,6 +113,7 @@ type StateAPI struct {
 	Beacon        beacon.Schedule
 	Consensus     consensus.Consensus
 	TsExec        stmgr.Executor
+	CallCache     *StateCallCache
 }
 
 func (a *StateAPI) StateNetworkName(ctx context.Context) (dtypes.NetworkName, error) {
@@ -401,11 +412,151 @@ func (m *StateModule) StateMinerPower(ctx context.Context, addr address.Address,
 	}, nil
 }
 
+// StateCallCache caches the results of StateCall and StateCompute. Explorers and
+// gas estimators repeatedly simulate identical calls at the same tipset, each of
+// which would otherwise re-execute the VM. Results are kept encoded, bounded by
+// their total size as execution traces can be very large, and every caller gets
+// its own copy.
+type StateCallCache struct {
+	calls    *encodedCache[stateCallKey]
+	computes *encodedCache[stateComputeKey]
+}
+
+type stateCallKey struct {
+	tsk types.TipSetKey
+	msg cid.Cid
+}
+
+type stateComputeKey struct {
+	tsk    types.TipSetKey
+	height abi.ChainEpoch
+	// concatenated message cids
+	msgs string
+}
+
+const (
+	stateCallCacheSize    = 64 << 20
+	stateComputeCacheSize = 256 << 20
+)
+
+func NewStateCallCache() *StateCallCache {
+	return &StateCallCache{
+		calls:    newEncodedCache[stateCallKey](1024, stateCallCacheSize),
+		computes: newEncodedCache[stateComputeKey](64, stateComputeCacheSize),
+	}
+}
+
+func (c *StateCallCache) getCall(key stateCallKey) (*api.InvocResult, bool) {
+	var res api.InvocResult
+	if !c.calls.get(key, &res) {
+		return nil, false
+	}
+	return &res, true
+}
+
+func (c *StateCallCache) addCall(key stateCallKey, res *api.InvocResult) {
+	c.calls.add(key, res)
+}
+
+func (c *StateCallCache) getCompute(key stateComputeKey) (*api.ComputeStateOutput, bool) {
+	var out api.ComputeStateOutput
+	if !c.computes.get(key, &out) {
+		return nil, false
+	}
+	return &out, true
+}
+
+func (c *StateCallCache) addCompute(key stateComputeKey, out *api.ComputeStateOutput) {
+	c.computes.add(key, out)
+}
+
+// encodedCache is an LRU cache of JSON encoded values, bounded by both their count
+// and total size. Values larger than the size bound aren't cached.
+type encodedCache[K comparable] struct {
+	lk      sync.Mutex
+	cache   *lru.Cache[K, []byte]
+	size    int
+	maxSize int
+}
+
+func newEncodedCache[K comparable](count, maxSize int) *encodedCache[K] {
+	c := &encodedCache[K]{maxSize: maxSize}
+	cache, err := lru.NewWithEvict(count, func(_ K, v []byte) {
+		c.size -= len(v)
+	})
+	if err != nil {
+		// err only if parameter is bad
+		panic(err)
+	}
+	c.cache = cache
+	return c
+}
+
+func (c *encodedCache[K]) get(key K, out interface{}) bool {
+	c.lk.Lock()
+	b, ok := c.cache.Get(key)
+	c.lk.Unlock()
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(b, out); err != nil {
+		log.Errorf("decoding cached state call result: %s", err)
+		return false
+	}
+	return true
+}
+
+func (c *encodedCache[K]) add(key K, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Errorf("encoding state call result: %s", err)
+		return
+	}
+	if len(b) > c.maxSize {
+		return
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.cache.Remove(key)
+	c.cache.Add(key, b)
+	c.size += len(b)
+	for c.size > c.maxSize {
+		c.cache.RemoveOldest()
+	}
+}
+
+// messageCid returns the cid of the message, or false if the message can't be
+// serialized (e.g. it has an undefined address), in which case it isn't cached
+func messageCid(msg *types.Message) (cid.Cid, bool) {
+	blk, err := msg.ToStorageBlock()
+	if err != nil {
+		return cid.Undef, false
+	}
+	return blk.Cid(), true
+}
+
 func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.TipSetKey) (res *api.InvocResult, err error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
+
+	mc, cacheable := messageCid(msg)
+	key := stateCallKey{tsk: ts.Key(), msg: mc}
+	if cacheable {
+		if res, ok := a.CallCache.getCall(key); ok {
+			return res, nil
+		}
+		defer func() {
+			if err == nil {
+				a.CallCache.addCall(key, res)
+			}
+		}()
+	}
+
 	for {
 		res, err = a.StateManager.Call(ctx, msg, ts)
 		if err != stmgr.ErrExpensiveFork {
@@ -419,6 +570,47 @@ func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.
 	return res, err
 }
 
+func (a *StateAPI) StateCallBundle(ctx context.Context, msgs []*types.Message, tsk types.TipSetKey) (*api.CallBundleResult, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	var res *stmgr.BundleResult
+	for {
+		res, err = a.StateManager.CallBundle(ctx, msgs, ts)
+		if err != stmgr.ErrExpensiveFork {
+			break
+		}
+		ts, err = a.Chain.GetTipSetFromKey(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("getting parent tipset: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	oldTree, err := state.LoadStateTree(res.Store, res.Base)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load base state tree: %w", err)
+	}
+	newTree, err := state.LoadStateTree(res.Store, res.Root)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load resulting state tree: %w", err)
+	}
+	changes, err := diffActors(ctx, res.Store, oldTree, newTree, true)
+	if err != nil {
+		return nil, xerrors.Errorf("diffing state: %w", err)
+	}
+
+	return &api.CallBundleResult{
+		Results: res.Results,
+		Root:    res.Root,
+		Changes: changes,
+	}, nil
+}
+
 func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
 	msgToReplay := mc
 	var ts *types.TipSet
@@ -471,6 +663,18 @@ func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.
 	}, nil
 }
 
+func (a *StateAPI) StateReplayWithOptions(ctx context.Context, tsk types.TipSetKey, mc cid.Cid, opts api.ReplayOptions) (*api.InvocResult, error) {
+	res, err := a.StateReplay(ctx, tsk, mc)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.GasCharges {
+		res.GasCharges = res.ExecutionTrace.GasChargeEvents()
+	}
+	return res, nil
+}
+
 func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (a *types.Actor, err error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -479,6 +683,204 @@ func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address,
 	return m.StateManager.LoadActor(ctx, actor, ts)
 }
 
+func (a *StateAPI) StateSubscribeActorChanges(ctx context.Context, actors []address.Address) (<-chan api.ActorChange, error) {
+	if len(actors) == 0 {
+		return nil, xerrors.Errorf("no actors to watch")
+	}
+
+	notifs := a.Chain.SubHeadChanges(ctx)
+	out := make(chan api.ActorChange, 16)
+
+	go func() {
+		defer close(out)
+
+		var prevRoot cid.Cid
+		var prev []*types.Actor
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case changes, ok := <-notifs:
+				if !ok {
+					return
+				}
+
+				for _, hc := range changes {
+					if hc.Type == store.HCRevert {
+						continue
+					}
+					ts := hc.Val
+
+					// consecutive heads often share the parent state
+					if ts.ParentState() == prevRoot {
+						continue
+					}
+
+					cur, err := a.loadActors(ts, actors)
+					if err != nil {
+						log.Errorw("loading watched actors", "height", ts.Height(), "error", err)
+						return
+					}
+
+					if prev != nil {
+						for i, addr := range actors {
+							if !actorChanged(prev[i], cur[i]) {
+								continue
+							}
+							select {
+							case out <- api.ActorChange{
+								Address:  addr,
+								TipSet:   ts.Key(),
+								Height:   ts.Height(),
+								Actor:    cur[i],
+								Previous: prev[i],
+							}:
+							case <-ctx.Done():
+								return
+							}
+						}
+					}
+
+					prevRoot, prev = ts.ParentState(), cur
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// loadActors loads the actors from the parent state of the tipset, leaving the
+// actors which don't exist nil
+func (a *StateAPI) loadActors(ts *types.TipSet, addrs []address.Address) ([]*types.Actor, error) {
+	st, err := a.StateManager.ParentState(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.Actor, len(addrs))
+	for i, addr := range addrs {
+		act, err := st.GetActor(addr)
+		if err != nil {
+			if xerrors.Is(err, types.ErrActorNotFound) {
+				continue
+			}
+			return nil, xerrors.Errorf("getting actor %s: %w", addr, err)
+		}
+		out[i] = act
+	}
+	return out, nil
+}
+
+func actorChanged(prev, cur *types.Actor) bool {
+	if prev == nil || cur == nil {
+		return prev != cur
+	}
+	return prev.Head != cur.Head || prev.Code != cur.Code || prev.Nonce != cur.Nonce || !prev.Balance.Equals(cur.Balance)
+}
+
+func (a *StateAPI) WalletSubscribeBalances(ctx context.Context) (<-chan api.WalletBalanceChange, error) {
+	notifs := a.Chain.SubHeadChanges(ctx)
+	out := make(chan api.WalletBalanceChange, 16)
+
+	go func() {
+		defer close(out)
+
+		var prevRoot cid.Cid
+		prev := map[address.Address]types.BigInt{}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case changes, ok := <-notifs:
+				if !ok {
+					return
+				}
+
+				for _, hc := range changes {
+					if hc.Type == store.HCRevert {
+						continue
+					}
+					ts := hc.Val
+
+					if ts.ParentState() == prevRoot {
+						continue
+					}
+
+					// the wallet may have changed since the last head, so list it again
+					addrs, watchOnly, err := a.walletAddrs(ctx)
+					if err != nil {
+						log.Errorw("listing wallet addresses", "error", err)
+						return
+					}
+
+					acts, err := a.loadActors(ts, addrs)
+					if err != nil {
+						log.Errorw("loading wallet actors", "height", ts.Height(), "error", err)
+						return
+					}
+
+					cur := make(map[address.Address]types.BigInt, len(addrs))
+					for i, addr := range addrs {
+						bal := big.Zero()
+						if acts[i] != nil {
+							bal = acts[i].Balance
+						}
+						cur[addr] = bal
+
+						// addresses which just appeared in the wallet have nothing to compare to
+						pb, ok := prev[addr]
+						if !ok || pb.Equals(bal) {
+							continue
+						}
+
+						_, wo := watchOnly[addr]
+						select {
+						case out <- api.WalletBalanceChange{
+							Address:   addr,
+							WatchOnly: wo,
+							TipSet:    ts.Key(),
+							Height:    ts.Height(),
+							Balance:   bal,
+							Previous:  pb,
+						}:
+						case <-ctx.Done():
+							return
+						}
+					}
+
+					prevRoot, prev = ts.ParentState(), cur
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// walletAddrs lists the wallet addresses along with the watch-only ones, which
+// are also returned as a set.
+func (a *StateAPI) walletAddrs(ctx context.Context) ([]address.Address, map[address.Address]struct{}, error) {
+	addrs, err := a.Wallet.WalletList(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watched, err := a.Watched.WatchedList()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watchOnly := make(map[address.Address]struct{}, len(watched))
+	for _, w := range watched {
+		watchOnly[w] = struct{}{}
+	}
+
+	return append(addrs, watched...), watchOnly, nil
+}
+
 func (m *StateModule) StateLookupID(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -602,7 +1004,7 @@ func (a *StateAPI) MinerCreateBlock(ctx context.Context, bt *api.BlockTemplate)
 }
 
 func (m *StateModule) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence uint64, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
-	ts, recpt, found, err := m.StateManager.WaitForMessage(ctx, msg, confidence, lookbackLimit, allowReplaced)
+	ts, recpt, found, err := m.StateManager.WaitForMessage(ctx, msg, confidence, m.msgSearchLookback(lookbackLimit), allowReplaced)
 	if err != nil {
 		return nil, err
 	}
@@ -649,7 +1051,7 @@ func (m *StateModule) StateSearchMsg(ctx context.Context, tsk types.TipSetKey, m
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
 
-	ts, recpt, found, err := m.StateManager.SearchForMessage(ctx, fromTs, msg, lookbackLimit, allowReplaced)
+	ts, recpt, found, err := m.StateManager.SearchForMessage(ctx, fromTs, msg, m.msgSearchLookback(lookbackLimit), allowReplaced)
 	if err != nil {
 		return nil, err
 	}
@@ -665,6 +1067,17 @@ func (m *StateModule) StateSearchMsg(ctx context.Context, tsk types.TipSetKey, m
 	return nil, nil
 }
 
+// msgSearchLookback caps the lookback limit of a message search to the configured maximum.
+// Messages found in the message index are returned regardless of the limit, so this only
+// bounds the walk through the chain.
+func (m *StateModule) msgSearchLookback(limit abi.ChainEpoch) abi.ChainEpoch {
+	maxLookback := abi.ChainEpoch(m.MaxMsgSearchLookback)
+	if maxLookback > 0 && (limit == api.LookbackNoLimit || limit > maxLookback) {
+		return maxLookback
+	}
+	return limit
+}
+
 func (m *StateModule) StateListMiners(ctx context.Context, tsk types.TipSetKey) ([]address.Address, error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -986,6 +1399,69 @@ func (a *StateAPI) StateChangedActors(ctx context.Context, old cid.Cid, new cid.
 	return state.Diff(ctx, oldTree, newTree)
 }
 
+func (a *StateAPI) StateDiff(ctx context.Context, from, to types.TipSetKey, withState bool) ([]api.ActorDiff, error) {
+	fromTs, err := a.Chain.GetTipSetFromKey(ctx, from)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", from, err)
+	}
+	toTs, err := a.Chain.GetTipSetFromKey(ctx, to)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", to, err)
+	}
+
+	store := a.Chain.ActorStore(ctx)
+
+	oldTree, err := state.LoadStateTree(store, fromTs.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load old state tree: %w", err)
+	}
+	newTree, err := state.LoadStateTree(store, toTs.ParentState())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load new state tree: %w", err)
+	}
+
+	return diffActors(ctx, store, oldTree, newTree, withState)
+}
+
+// diffActors returns the changes of actors between the two state trees, reading the
+// actor states from store
+func diffActors(ctx context.Context, store ipldcbor.IpldStore, oldTree, newTree *state.StateTree, withState bool) ([]api.ActorDiff, error) {
+	diffs, err := state.DiffActors(ctx, oldTree, newTree)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.ActorDiff, 0, len(diffs))
+	for _, d := range diffs {
+		ad := api.ActorDiff{
+			Address: d.Address,
+			Before:  d.Before,
+			After:   d.After,
+		}
+
+		if withState && d.Before != nil && d.After != nil {
+			fields, err := state.DiffActorState(ctx, store, d.Before.Head, d.After.Head)
+			if err != nil {
+				return nil, xerrors.Errorf("diffing state of actor %s: %w", d.Address, err)
+			}
+			for _, f := range fields {
+				fd := api.ActorStateFieldDiff{
+					Field:  f.Field,
+					Before: f.Before,
+					After:  f.After,
+				}
+				for _, e := range f.Entries {
+					fd.Entries = append(fd.Entries, api.ActorStateEntryDiff(e))
+				}
+				ad.State = append(ad.State, fd)
+			}
+		}
+
+		out = append(out, ad)
+	}
+	return out, nil
+}
+
 func (a *StateAPI) StateMinerSectorCount(ctx context.Context, addr address.Address, tsk types.TipSetKey) (api.MinerSectors, error) {
 	act, err := a.StateManager.LoadActorTsk(ctx, addr, tsk)
 	if err != nil {
@@ -1167,15 +1643,39 @@ func (a *StateAPI) StateCompute(ctx context.Context, height abi.ChainEpoch, msgs
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
+
+	key := stateComputeKey{tsk: ts.Key(), height: height}
+	cacheable := true
+	var msgCids strings.Builder
+	for _, msg := range msgs {
+		mc, ok := messageCid(msg)
+		if !ok {
+			cacheable = false
+			break
+		}
+		msgCids.WriteString(mc.KeyString())
+	}
+	key.msgs = msgCids.String()
+
+	if cacheable {
+		if out, ok := a.CallCache.getCompute(key); ok {
+			return out, nil
+		}
+	}
+
 	st, t, err := stmgr.ComputeState(ctx, a.StateManager, height, msgs, ts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &api.ComputeStateOutput{
+	out := &api.ComputeStateOutput{
 		Root:  st,
 		Trace: t,
-	}, nil
+	}
+	if cacheable {
+		a.CallCache.addCompute(key, out)
+	}
+	return out, nil
 }
 
 func (m *StateModule) MsigGetAvailableBalance(ctx context.Context, addr address.Address, tsk types.TipSetKey) (types.BigInt, error) {
@@ -1311,6 +1811,198 @@ func (m *StateModule) MsigGetPending(ctx context.Context, addr address.Address,
 	return out, nil
 }
 
+func (a *StateAPI) MsigGetPendingDecoded(ctx context.Context, addr address.Address, tsk types.TipSetKey) ([]*api.MsigDecodedTransaction, error) {
+	pending, err := a.MsigGetPending(ctx, addr, tsk)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+
+	registry := a.TsExec.NewActorRegistry()
+
+	out := make([]*api.MsigDecodedTransaction, 0, len(pending))
+	for _, txn := range pending {
+		dt := &api.MsigDecodedTransaction{MsigTransaction: *txn}
+		out = append(out, dt)
+
+		if txn.Method == builtin.MethodSend {
+			dt.MethodName = "Send"
+			continue
+		}
+
+		act, err := a.StateGetActor(ctx, txn.To, tsk)
+		if err != nil {
+			if xerrors.Is(err, types.ErrActorNotFound) {
+				continue
+			}
+			return nil, xerrors.Errorf("loading target actor of transaction %d: %w", txn.ID, err)
+		}
+
+		method, ok := registry.Methods[act.Code][txn.Method]
+		if !ok {
+			continue
+		}
+		dt.MethodName = method.Name
+
+		params, err := stmgr.GetParamType(registry, act.Code, txn.Method)
+		if err != nil {
+			continue
+		}
+		if err := params.UnmarshalCBOR(bytes.NewReader(txn.Params)); err != nil {
+			log.Warnw("failed to decode multisig transaction params", "msig", addr, "txn", txn.ID, "error", err)
+			continue
+		}
+
+		dt.DecodedParams, err = json.Marshal(params)
+		if err != nil {
+			return nil, xerrors.Errorf("marshaling params of transaction %d: %w", txn.ID, err)
+		}
+	}
+
+	return out, nil
+}
+
+func (a *StateAPI) StateMinerKeyRotation(ctx context.Context, maddr, newOwner, newWorker address.Address, tsk types.TipSetKey) ([]api.MinerKeyRotationStep, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	act, err := a.StateManager.LoadActor(ctx, maddr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor: %w", err)
+	}
+	mas, err := miner.Load(a.StateManager.ChainStore().ActorStore(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load miner actor state: %w", err)
+	}
+	info, err := mas.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	// the guided rotation only handles keys, multisig owners have to go through
+	// the multisig proposal flow
+	keyID := func(addr address.Address, what string) (address.Address, error) {
+		id, err := a.StateLookupID(ctx, addr, ts.Key())
+		if err != nil {
+			return address.Undef, xerrors.Errorf("looking up %s %s: %w", what, addr, err)
+		}
+		key, err := a.StateAccountKey(ctx, id, ts.Key())
+		if err != nil {
+			return address.Undef, xerrors.Errorf("%s %s must be an account: %w", what, addr, err)
+		}
+		if key.Protocol() != address.SECP256K1 && key.Protocol() != address.BLS {
+			return address.Undef, xerrors.Errorf("%s %s must be a secp256k1 or bls key, got %s", what, addr, key)
+		}
+		return id, nil
+	}
+
+	if _, err := keyID(info.Owner, "current owner"); err != nil {
+		return nil, err
+	}
+
+	pendingWorker, confirmWorkerAt := address.Undef, abi.ChainEpoch(0)
+	if info.PendingWorkerKey != nil {
+		pendingWorker, confirmWorkerAt = info.PendingWorkerKey.NewWorker, info.PendingWorkerKey.EffectiveAt
+	}
+
+	owner := info.Owner
+	if newOwner != address.Undef {
+		if newOwner, err = keyID(newOwner, "new owner"); err != nil {
+			return nil, err
+		}
+		if newOwner == info.Owner {
+			newOwner = address.Undef
+		}
+	}
+	if newWorker != address.Undef {
+		if newWorker, err = keyID(newWorker, "new worker"); err != nil {
+			return nil, err
+		}
+		if newWorker == info.Worker && pendingWorker == address.Undef {
+			newWorker = address.Undef
+		}
+	}
+	if newOwner == address.Undef && newWorker == address.Undef {
+		return nil, xerrors.Errorf("the miner already uses the given keys")
+	}
+
+	var steps []api.MinerKeyRotationStep
+	step := func(desc string, from address.Address, method abi.MethodNum, params cbg.CBORMarshaler, notBefore abi.ChainEpoch) error {
+		var enc []byte
+		if params != nil {
+			if enc, err = actors.SerializeParams(params); err != nil {
+				return xerrors.Errorf("serializing params: %w", err)
+			}
+		}
+		steps = append(steps, api.MinerKeyRotationStep{
+			Description: desc,
+			Message: &types.Message{
+				From:   from,
+				To:     maddr,
+				Method: method,
+				Value:  big.Zero(),
+				Params: enc,
+			},
+			NotBefore: notBefore,
+		})
+		return nil
+	}
+
+	if newWorker != address.Undef && pendingWorker != newWorker {
+		if err := step("propose the worker key change", owner, builtintypes.MethodsMiner.ChangeWorkerAddress, &minertypes.ChangeWorkerAddressParams{
+			NewWorker:       newWorker,
+			NewControlAddrs: info.ControlAddresses,
+		}, ts.Height()); err != nil {
+			return nil, err
+		}
+		// the change is effective a finality after the epoch the proposal lands in
+		confirmWorkerAt = ts.Height() + 1 + policy.ChainFinality
+	}
+
+	if newOwner != address.Undef {
+		if info.PendingOwnerAddress == nil || *info.PendingOwnerAddress != newOwner {
+			if err := step("propose the owner change from the current owner", owner, builtintypes.MethodsMiner.ChangeOwnerAddress, &newOwner, ts.Height()); err != nil {
+				return nil, err
+			}
+		}
+		if err := step("confirm the owner change from the new owner", newOwner, builtintypes.MethodsMiner.ChangeOwnerAddress, &newOwner, ts.Height()); err != nil {
+			return nil, err
+		}
+		owner = newOwner
+	}
+
+	if newWorker != address.Undef {
+		if err := step("confirm the worker key change", owner, builtintypes.MethodsMiner.ConfirmChangeWorkerAddress, nil, confirmWorkerAt); err != nil {
+			return nil, err
+		}
+	}
+
+	var msgs []*types.Message
+	for _, s := range steps {
+		if s.NotBefore > ts.Height() {
+			break
+		}
+		msgs = append(msgs, s.Message)
+	}
+
+	if len(msgs) > 0 {
+		res, err := a.StateCallBundle(ctx, msgs, ts.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("dry-running the messages: %w", err)
+		}
+		for i, r := range res.Results {
+			steps[i].DryRun = r
+		}
+	}
+
+	return steps, nil
+}
+
 var initialPledgeNum = types.NewInt(110)
 var initialPledgeDen = types.NewInt(100)
 
@@ -1687,6 +2379,24 @@ func (a *StateAPI) StateCirculatingSupply(ctx context.Context, tsk types.TipSetK
 func (a *StateAPI) StateVMCirculatingSupplyInternal(ctx context.Context, tsk types.TipSetKey) (api.CirculatingSupply, error) {
 	return stateVMCirculatingSupplyInternal(ctx, tsk, a.Chain, a.StateManager)
 }
+func (a *StateAPI) StateVMCirculatingSupplyAt(ctx context.Context, epoch abi.ChainEpoch) (api.CirculatingSupply, error) {
+	head := a.Chain.GetHeaviestTipSet()
+	if epoch < 0 || epoch > head.Height() {
+		return api.CirculatingSupply{}, xerrors.Errorf("epoch %d is out of the chain range [0, %d]", epoch, head.Height())
+	}
+
+	ts, err := a.Chain.GetTipsetByHeight(ctx, epoch, head, false)
+	if err != nil {
+		return api.CirculatingSupply{}, xerrors.Errorf("loading tipset at epoch %d: %w", epoch, err)
+	}
+
+	cs, err := stateVMCirculatingSupplyInternal(ctx, ts.Key(), a.Chain, a.StateManager)
+	if ipld.IsNotFound(err) {
+		return api.CirculatingSupply{}, xerrors.Errorf("state at epoch %d is not available, the node may not keep historical state: %w", epoch, err)
+	}
+	return cs, err
+}
+
 func stateVMCirculatingSupplyInternal(
 	ctx context.Context,
 	tsk types.TipSetKey,
@@ -1745,6 +2455,69 @@ func (a *StateAPI) StateActorManifestCID(ctx context.Context, nv network.Version
 	return c, nil
 }
 
+func (a *StateAPI) StateActorRegistry(ctx context.Context) ([]api.ActorCodeInfo, error) {
+	registry := a.TsExec.NewActorRegistry()
+
+	var out []api.ActorCodeInfo
+	byCode := make(map[cid.Cid]int)
+	for nv := network.Version0; nv <= build.TestNetworkVersion; nv++ {
+		av, err := actorstypes.VersionForNetwork(nv)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid network version %d: %w", nv, err)
+		}
+
+		codes, err := actors.GetActorCodeIDs(av)
+		if err != nil {
+			return nil, xerrors.Errorf("could not find cids for network version %d, actors version %d: %w", nv, av, err)
+		}
+
+		for name, code := range codes {
+			if i, ok := byCode[code]; ok {
+				if nvs := out[i].NetworkVersions; nvs[len(nvs)-1] != nv {
+					out[i].NetworkVersions = append(nvs, nv)
+				}
+				continue
+			}
+
+			byCode[code] = len(out)
+			out = append(out, api.ActorCodeInfo{
+				Code:            code,
+				Name:            name,
+				ActorsVersion:   av,
+				NetworkVersions: []network.Version{nv},
+				Methods:         actorMethods(registry.Methods[code]),
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ActorsVersion != out[j].ActorsVersion {
+			return out[i].ActorsVersion < out[j].ActorsVersion
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
+
+func actorMethods(methods map[abi.MethodNum]vm.MethodMeta) []api.ActorMethodInfo {
+	out := make([]api.ActorMethodInfo, 0, len(methods))
+	for num, m := range methods {
+		out = append(out, api.ActorMethodInfo{
+			Num:    num,
+			Name:   m.Name,
+			Params: m.Params.String(),
+			Return: m.Ret.String(),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Num < out[j].Num
+	})
+
+	return out
+}
+
 func (a *StateAPI) StateGetRandomnessFromTickets(ctx context.Context, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte, tsk types.TipSetKey) (abi.Randomness, error) {
 	return a.StateManager.GetRandomnessFromTickets(ctx, personalization, randEpoch, entropy, tsk)
 }
@@ -0,0 +1,160 @@
+// stm: #unit
+package full
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/manifest"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestMsgSearchLookback(t *testing.T) {
+	uncapped := &StateModule{}
+	require.Equal(t, api.LookbackNoLimit, uncapped.msgSearchLookback(api.LookbackNoLimit))
+	require.Equal(t, abi.ChainEpoch(5000), uncapped.msgSearchLookback(5000))
+
+	capped := &StateModule{MaxMsgSearchLookback: 2000}
+	require.Equal(t, abi.ChainEpoch(2000), capped.msgSearchLookback(api.LookbackNoLimit))
+	require.Equal(t, abi.ChainEpoch(2000), capped.msgSearchLookback(5000))
+	require.Equal(t, abi.ChainEpoch(20), capped.msgSearchLookback(20))
+	require.Equal(t, abi.ChainEpoch(0), capped.msgSearchLookback(0))
+}
+
+func TestStateActorRegistry(t *testing.T) {
+	a := &StateAPI{TsExec: consensus.NewTipSetExecutor(nil)}
+	infos, err := a.StateActorRegistry(context.Background())
+	require.NoError(t, err)
+
+	minerCode, ok := actors.GetActorCodeID(actorstypes.Version8, manifest.MinerKey)
+	require.True(t, ok)
+
+	seen := make(map[string]bool)
+	var found bool
+	for _, info := range infos {
+		require.False(t, seen[info.Code.String()], "duplicate code %s", info.Code)
+		seen[info.Code.String()] = true
+		require.NotEmpty(t, info.NetworkVersions)
+		require.NotEmpty(t, info.Methods)
+
+		if info.Code != minerCode {
+			continue
+		}
+		found = true
+
+		require.Equal(t, manifest.MinerKey, info.Name)
+		require.Equal(t, actorstypes.Version8, info.ActorsVersion)
+		require.Equal(t, []network.Version{network.Version16}, info.NetworkVersions)
+		require.Equal(t, abi.MethodNum(0), info.Methods[0].Num)
+		require.Equal(t, "Send", info.Methods[0].Name)
+		require.Equal(t, "Constructor", info.Methods[1].Name)
+	}
+	require.True(t, found)
+}
+
+func TestStateCallCache(t *testing.T) {
+	c := NewStateCallCache()
+
+	trace := func(name string) types.ExecutionTrace {
+		return types.ExecutionTrace{
+			Msg:        types.MessageTrace{From: builtin.SystemActorAddr, To: builtin.SystemActorAddr, Value: big.Zero()},
+			GasCharges: []*types.GasTrace{{Name: name, TotalGas: 1}},
+		}
+	}
+	res := &api.InvocResult{
+		MsgCid: testCid(1),
+		GasCost: api.MsgGasCost{
+			Message:            testCid(1),
+			GasUsed:            big.NewInt(10),
+			BaseFeeBurn:        big.Zero(),
+			OverEstimationBurn: big.Zero(),
+			MinerPenalty:       big.Zero(),
+			MinerTip:           big.Zero(),
+			Refund:             big.Zero(),
+			TotalCost:          big.Zero(),
+		},
+		ExecutionTrace: trace("call"),
+		Error:          "out of gas",
+	}
+	res.ExecutionTrace.Subcalls = []types.ExecutionTrace{trace("subcall")}
+	key := stateCallKey{tsk: types.NewTipSetKey(testCid(2)), msg: testCid(1)}
+
+	_, ok := c.getCall(key)
+	require.False(t, ok)
+
+	c.addCall(key, res)
+	got, ok := c.getCall(key)
+	require.True(t, ok)
+	require.Equal(t, res, got)
+
+	// callers get their own copy
+	got.Error = "changed"
+	got.ExecutionTrace.Subcalls[0].GasCharges[0].Name = "changed"
+	again, ok := c.getCall(key)
+	require.True(t, ok)
+	require.Equal(t, res, again)
+
+	// a different tipset misses
+	_, ok = c.getCall(stateCallKey{tsk: types.NewTipSetKey(testCid(3)), msg: testCid(1)})
+	require.False(t, ok)
+
+	out := &api.ComputeStateOutput{Root: testCid(4), Trace: []*api.InvocResult{res}}
+	ckey := stateComputeKey{tsk: types.NewTipSetKey(testCid(2)), height: 10, msgs: testCid(1).KeyString()}
+	c.addCompute(ckey, out)
+	gotOut, ok := c.getCompute(ckey)
+	require.True(t, ok)
+	require.Equal(t, out, gotOut)
+	gotOut.Trace[0].Error = "changed"
+	again2, _ := c.getCompute(ckey)
+	require.Equal(t, "out of gas", again2.Trace[0].Error)
+}
+
+func TestEncodedCacheSizeBound(t *testing.T) {
+	value := func(n int) string {
+		return strings.Repeat("x", n)
+	}
+
+	// every encoded value takes its length plus the two quotes
+	c := newEncodedCache[int](100, 100)
+
+	// values larger than the bound aren't cached
+	c.add(0, value(200))
+	var s string
+	require.False(t, c.get(0, &s))
+	require.Zero(t, c.size)
+
+	for i := 1; i <= 4; i++ {
+		c.add(i, value(30))
+	}
+	require.LessOrEqual(t, c.size, 100)
+
+	// the oldest value was evicted
+	require.False(t, c.get(1, &s))
+	for i := 2; i <= 4; i++ {
+		require.True(t, c.get(i, &s))
+		require.Equal(t, value(30), s)
+	}
+
+	// replacing a value accounts for its new size
+	c.add(4, value(10))
+	require.Equal(t, 32+32+12, c.size)
+}
+
+func testCid(i int) cid.Cid {
+	return blocks.NewBlock([]byte(fmt.Sprintf("test %d", i))).Cid()
+}
@@ -23,6 +23,7 @@ type WalletAPI struct {
 
 	StateManagerAPI stmgr.StateManagerAPI
 	Default         wallet.Default
+	Watched         wallet.Watched
 	api.Wallet
 }
 
@@ -87,6 +88,36 @@ func (a *WalletAPI) WalletSetDefault(ctx context.Context, addr address.Address)
 	return a.Default.SetDefault(addr)
 }
 
+func (a *WalletAPI) WalletExportEncrypted(ctx context.Context, addr address.Address, password string) (*types.EncryptedKeyInfo, error) {
+	ki, err := a.Wallet.WalletExport(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet.EncryptKeyInfo(ki, password)
+}
+
+func (a *WalletAPI) WalletImportEncrypted(ctx context.Context, eki *types.EncryptedKeyInfo, password string) (address.Address, error) {
+	ki, err := wallet.DecryptKeyInfo(eki, password)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return a.Wallet.WalletImport(ctx, ki)
+}
+
 func (a *WalletAPI) WalletValidateAddress(ctx context.Context, str string) (address.Address, error) {
 	return address.NewFromString(str)
 }
+
+func (a *WalletAPI) WalletWatch(ctx context.Context, addr address.Address) error {
+	return a.Watched.AddWatched(addr)
+}
+
+func (a *WalletAPI) WalletUnwatch(ctx context.Context, addr address.Address) error {
+	return a.Watched.RemoveWatched(addr)
+}
+
+func (a *WalletAPI) WalletListWatched(ctx context.Context) ([]address.Address, error) {
+	return a.Watched.WatchedList()
+}
@@ -46,16 +46,22 @@ import (
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/types"
 	mktsdagstore "github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/dealquota"
+	"github.com/filecoin-project/lotus/markets/dtprogress"
+	"github.com/filecoin-project/lotus/markets/idxannounce"
+	"github.com/filecoin-project/lotus/markets/piecehttp"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/msgagg"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 	"github.com/filecoin-project/lotus/storage/sectorblocks"
 	"github.com/filecoin-project/lotus/storage/wdpost"
@@ -86,6 +92,10 @@ type StorageMinerAPI struct {
 	Host              host.Host                         `optional:"true"`
 	DAGStore          *dagstore.DAGStore                `optional:"true"`
 	DAGStoreWrapper   *mktsdagstore.Wrapper             `optional:"true"`
+	TransferTracker   *dtprogress.Tracker               `optional:"true"`
+	DealQuota         *dealquota.Enforcer               `optional:"true"`
+	PieceAnnouncer    *idxannounce.Announcer            `optional:"true"`
+	PieceReader       mktsdagstore.MinerAPI             `optional:"true"`
 
 	// Miner / storage
 	Miner       *sealing.Sealing     `optional:"true"`
@@ -95,6 +105,7 @@ type StorageMinerAPI struct {
 	paths.SectorIndex
 	storiface.WorkerReturn `optional:"true"`
 	AddrSel                *ctladdr.AddressSelector
+	MsgAggregator          *msgagg.Aggregator `optional:"true"`
 
 	WdPoSt *wdpost.WindowPoStScheduler `optional:"true"`
 
@@ -148,6 +159,25 @@ func (sm *StorageMinerAPI) ServeRemote(perm bool) func(w http.ResponseWriter, r
 	}
 }
 
+func (sm *StorageMinerAPI) ServePiece(perm bool) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if perm == true {
+			if !auth.HasPerm(r.Context(), nil, api.PermRead) {
+				w.WriteHeader(401)
+				_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing read permission"})
+				return
+			}
+		}
+
+		if sm.PieceReader == nil {
+			http.Error(w, "markets subsystem not enabled", http.StatusNotFound)
+			return
+		}
+
+		http.StripPrefix("/piece", piecehttp.NewHandler(sm.PieceReader)).ServeHTTP(w, r)
+	}
+}
+
 func (sm *StorageMinerAPI) WorkerStats(ctx context.Context) (map[uuid.UUID]storiface.WorkerStats, error) {
 	return sm.StorageMgr.WorkerStats(ctx), nil
 }
@@ -156,6 +186,10 @@ func (sm *StorageMinerAPI) WorkerJobs(ctx context.Context) (map[uuid.UUID][]stor
 	return sm.StorageMgr.WorkerJobs(), nil
 }
 
+func (sm *StorageMinerAPI) WorkerResourceTable(ctx context.Context, worker uuid.UUID) (map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) {
+	return sm.StorageMgr.WorkerResourceTable(ctx, worker)
+}
+
 func (sm *StorageMinerAPI) ActorAddress(context.Context) (address.Address, error) {
 	return sm.Miner.Address(), nil
 }
@@ -254,6 +288,10 @@ func (sm *StorageMinerAPI) SectorsUnsealPiece(ctx context.Context, sector storif
 	return sm.StorageMgr.SectorsUnsealPiece(ctx, sector, offset, size, randomness, commd)
 }
 
+func (sm *StorageMinerAPI) SectorsReleaseUnsealed(ctx context.Context, sector storiface.SectorRef) error {
+	return sm.StorageMgr.SectorsReleaseUnsealed(ctx, sector)
+}
+
 // List all staged sectors
 func (sm *StorageMinerAPI) SectorsList(context.Context) ([]abi.SectorNumber, error) {
 	sectors, err := sm.Miner.ListSectors()
@@ -315,6 +353,26 @@ func (sm *StorageMinerAPI) SectorsSummary(ctx context.Context) (map[api.SectorSt
 	return out, nil
 }
 
+func (sm *StorageMinerAPI) SectorsPipelineStats(ctx context.Context) (api.SectorsPipelineStats, error) {
+	out := sm.Miner.PipelineStats()
+
+	out.Workers = map[uuid.UUID]api.WorkerUtilization{}
+	for id, ws := range sm.StorageMgr.WorkerStats(ctx) {
+		tasks := 0
+		for _, n := range ws.TaskCounts {
+			tasks += n
+		}
+
+		out.Workers[id] = api.WorkerUtilization{
+			Hostname:    ws.Info.Hostname,
+			Utilization: ws.Utilization,
+			Tasks:       tasks,
+		}
+	}
+
+	return out, nil
+}
+
 func (sm *StorageMinerAPI) StorageLocal(ctx context.Context) (map[storiface.ID]string, error) {
 	l, err := sm.LocalStore.Local(ctx)
 	if err != nil {
@@ -428,6 +486,20 @@ func (sm *StorageMinerAPI) SectorCommitPending(ctx context.Context) ([]abi.Secto
 	return sm.Miner.CommitPending(ctx)
 }
 
+func (sm *StorageMinerAPI) SectorBatchingStatus(ctx context.Context) (api.SectorBatchingStatus, error) {
+	pc, err := sm.Miner.SectorPreCommitBatchStatus(ctx)
+	if err != nil {
+		return api.SectorBatchingStatus{}, xerrors.Errorf("getting precommit batch status: %w", err)
+	}
+
+	c, err := sm.Miner.CommitBatchStatus(ctx)
+	if err != nil {
+		return api.SectorBatchingStatus{}, xerrors.Errorf("getting commit batch status: %w", err)
+	}
+
+	return api.SectorBatchingStatus{PreCommit: pc, Commit: c}, nil
+}
+
 func (sm *StorageMinerAPI) SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error {
 	return sm.Miner.SectorMatchPendingPiecesToOpenSectors(ctx)
 }
@@ -503,6 +575,31 @@ func (sm *StorageMinerAPI) SealingRemoveRequest(ctx context.Context, schedId uui
 	return sm.StorageMgr.RemoveSchedRequest(ctx, schedId)
 }
 
+func (sm *StorageMinerAPI) SealingPauseStates(ctx context.Context, states []api.SectorState) error {
+	sts := make([]sealing.SectorState, len(states))
+	for i, st := range states {
+		sts[i] = sealing.SectorState(st)
+	}
+	return sm.Miner.PauseStates(ctx, sts)
+}
+
+func (sm *StorageMinerAPI) SealingResumeStates(ctx context.Context, states []api.SectorState) error {
+	sts := make([]sealing.SectorState, len(states))
+	for i, st := range states {
+		sts[i] = sealing.SectorState(st)
+	}
+	return sm.Miner.ResumeStates(ctx, sts)
+}
+
+func (sm *StorageMinerAPI) SealingPausedStates(ctx context.Context) ([]api.SectorState, error) {
+	paused := sm.Miner.PausedStates()
+	out := make([]api.SectorState, len(paused))
+	for i, st := range paused {
+		out[i] = api.SectorState(st)
+	}
+	return out, nil
+}
+
 func (sm *StorageMinerAPI) MarketImportDealData(ctx context.Context, propCid cid.Cid, path string) error {
 	fi, err := os.Open(path)
 	if err != nil {
@@ -599,6 +696,65 @@ func (sm *StorageMinerAPI) MarketListDataTransfers(ctx context.Context) ([]api.D
 	return apiChannels, nil
 }
 
+func (sm *StorageMinerAPI) MarketDataTransferProgress(ctx context.Context) ([]api.DataTransferProgress, error) {
+	if sm.TransferTracker == nil {
+		return nil, xerrors.Errorf("data transfer progress is not tracked by this node")
+	}
+
+	inProgressChannels, err := sm.DataTransfer.InProgressChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deals, err := sm.StorageProvider.ListLocalDeals()
+	if err != nil {
+		return nil, xerrors.Errorf("listing deals: %w", err)
+	}
+	dealsByChannel := map[datatransfer.ChannelID]cid.Cid{}
+	for _, d := range deals {
+		if d.TransferChannelId != nil {
+			dealsByChannel[*d.TransferChannelId] = d.ProposalCid
+		}
+	}
+
+	self := sm.Host.ID()
+	out := make([]api.DataTransferProgress, 0, len(inProgressChannels))
+	for chid, st := range inProgressChannels {
+		p := api.DataTransferProgress{
+			TransferID:  chid.ID,
+			Status:      st.Status(),
+			IsInitiator: chid.Initiator == self,
+			IsSender:    st.Sender() == self,
+			OtherPeer:   chid.OtherParty(self),
+			Message:     st.Message(),
+			TotalSize:   st.TotalSize(),
+			Transferred: st.Received(),
+		}
+		if p.IsSender {
+			p.Transferred = st.Sent()
+		}
+
+		if propCid, ok := dealsByChannel[chid]; ok {
+			p.ProposalCid = &propCid
+		}
+
+		if tp, ok := sm.TransferTracker.Progress(chid); ok {
+			p.Rate = tp.Rate
+			p.Restarts = tp.Restarts
+			p.Started = tp.Started
+			p.LastProgress = tp.LastProgress
+		}
+
+		out = append(out, p)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].TransferID < out[j].TransferID
+	})
+
+	return out, nil
+}
+
 func (sm *StorageMinerAPI) MarketRestartDataTransfer(ctx context.Context, transferID datatransfer.TransferID, otherPeer peer.ID, isInitiator bool) error {
 	selfPeer := sm.Host.ID()
 	if isInitiator {
@@ -798,6 +954,13 @@ func (sm *StorageMinerAPI) MarketPendingDeals(ctx context.Context) (api.PendingD
 	return sm.DealPublisher.PendingDeals(), nil
 }
 
+func (sm *StorageMinerAPI) MarketClientQuotaUsage(ctx context.Context) ([]api.ClientQuotaUsage, error) {
+	if sm.DealQuota == nil {
+		return nil, xerrors.Errorf("client deal quotas are not enforced by this node")
+	}
+	return sm.DealQuota.Usage(), nil
+}
+
 func (sm *StorageMinerAPI) MarketRetryPublishDeal(ctx context.Context, propcid cid.Cid) error {
 	return sm.StorageProvider.RetryDealPublishing(propcid)
 }
@@ -1110,6 +1273,55 @@ func (sm *StorageMinerAPI) IndexerAnnounceAllDeals(ctx context.Context) error {
 	return sm.StorageProvider.AnnounceAllDealsToIndexer(ctx)
 }
 
+func (sm *StorageMinerAPI) IndexerEndpointAnnounceDeals(ctx context.Context, proposalCids []cid.Cid) (int, error) {
+	if sm.PieceAnnouncer == nil {
+		return 0, xerrors.Errorf("no indexer announce endpoint is configured")
+	}
+
+	deals, err := sm.StorageProvider.ListLocalDeals()
+	if err != nil {
+		return 0, xerrors.Errorf("listing deals: %w", err)
+	}
+
+	byProposal := make(map[cid.Cid]storagemarket.MinerDeal, len(deals))
+	for _, d := range deals {
+		byProposal[d.ProposalCid] = d
+	}
+
+	if len(proposalCids) == 0 {
+		for _, d := range deals {
+			if d.State == storagemarket.StorageDealActive {
+				proposalCids = append(proposalCids, d.ProposalCid)
+			}
+		}
+	}
+
+	for _, pcid := range proposalCids {
+		d, ok := byProposal[pcid]
+		if !ok {
+			return 0, xerrors.Errorf("deal %s not found", pcid)
+		}
+		if d.State != storagemarket.StorageDealActive {
+			return 0, xerrors.Errorf("deal %s is not active (%s)", pcid, storagemarket.DealStates[d.State])
+		}
+	}
+
+	for i, pcid := range proposalCids {
+		if err := sm.PieceAnnouncer.Enqueue(ctx, byProposal[pcid]); err != nil {
+			return i, xerrors.Errorf("queueing announcement of deal %s: %w", pcid, err)
+		}
+	}
+
+	return len(proposalCids), nil
+}
+
+func (sm *StorageMinerAPI) IndexerEndpointQueue(ctx context.Context) ([]api.PendingPieceAnnouncement, error) {
+	if sm.PieceAnnouncer == nil {
+		return nil, xerrors.Errorf("no indexer announce endpoint is configured")
+	}
+	return sm.PieceAnnouncer.Queue(ctx)
+}
+
 func (sm *StorageMinerAPI) DagstoreLookupPieces(ctx context.Context, cid cid.Cid) ([]api.DagstoreShardInfo, error) {
 	if sm.DAGStore == nil {
 		return nil, fmt.Errorf("dagstore not available on this node")
@@ -1387,13 +1599,20 @@ func (sm *StorageMinerAPI) withdrawBalance(ctx context.Context, amount abi.Token
 		sender = mi.Beneficiary
 	}
 
-	smsg, err := sm.Full.MpoolPushMessage(ctx, &types.Message{
+	msg := &types.Message{
 		To:     sm.Miner.Address(),
 		From:   sender,
 		Value:  types.NewInt(0),
 		Method: builtintypes.MethodsMiner.WithdrawBalance,
 		Params: params,
-	}, nil)
+	}
+
+	var smsg *types.SignedMessage
+	if sm.MsgAggregator != nil {
+		smsg, err = sm.MsgAggregator.Push(ctx, msg, nil)
+	} else {
+		smsg, err = sm.Full.MpoolPushMessage(ctx, msg, nil)
+	}
 	if err != nil {
 		return cid.Undef, err
 	}
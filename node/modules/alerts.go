@@ -1,10 +1,37 @@
 package modules
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/lib/ulimit"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
 )
 
+// alertCheckInterval is the interval at which periodic alert conditions are evaluated
+var alertCheckInterval = time.Minute
+
+// AlertWebhooks registers the configured webhooks as alerting hooks
+func AlertWebhooks(cfg config.Alerting) func(al *alerting.Alerting) {
+	return func(al *alerting.Alerting) {
+		if len(cfg.WebhookURLs) == 0 {
+			return
+		}
+
+		al.AddHook(alerting.WebhookHook(cfg.WebhookURLs, time.Duration(cfg.WebhookTimeout)))
+	}
+}
+
 func CheckFdLimit(min uint64) func(al *alerting.Alerting) {
 	return func(al *alerting.Alerting) {
 		soft, _, err := ulimit.GetLimit()
@@ -32,8 +59,170 @@ func CheckFdLimit(min uint64) func(al *alerting.Alerting) {
 	}
 }
 
+// CheckDiskSpace periodically checks the free space available to the repo, raising
+// an alert when it drops below minFreeRatio of the filesystem capacity.
+func CheckDiskSpace(minFreeRatio float64) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, al *alerting.Alerting, lr repo.LockedRepo) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, al *alerting.Alerting, lr repo.LockedRepo) {
+		if minFreeRatio <= 0 {
+			return
+		}
+
+		alert := al.AddAlertType("repo", "disk-space")
+		check := func() {
+			st, err := lr.Stat(lr.Path())
+			if err != nil {
+				log.Warnf("failed to stat repo path: %s", err)
+				return
+			}
+			if st.Capacity <= 0 {
+				return
+			}
+
+			ratio := float64(st.FSAvailable) / float64(st.Capacity)
+			switch {
+			case ratio < minFreeRatio && !al.IsRaised(alert):
+				al.Raise(alert, map[string]interface{}{
+					"message":   "repo is running out of disk space",
+					"path":      lr.Path(),
+					"capacity":  st.Capacity,
+					"available": st.FSAvailable,
+				})
+			case ratio >= minFreeRatio && al.IsRaised(alert):
+				al.Resolve(alert, map[string]interface{}{
+					"message":   "repo disk space recovered",
+					"path":      lr.Path(),
+					"capacity":  st.Capacity,
+					"available": st.FSAvailable,
+				})
+			}
+		}
+
+		runAlertCheck(lc, helpers.LifecycleCtx(mctx, lc), check)
+	}
+}
+
+// CheckSyncStall periodically checks that the chain head is advancing, raising an
+// alert when it stayed unchanged for longer than timeout while being behind wall-clock
+// time.
+func CheckSyncStall(timeout time.Duration) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, al *alerting.Alerting, cs *store.ChainStore) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, al *alerting.Alerting, cs *store.ChainStore) {
+		if timeout <= 0 {
+			return
+		}
+
+		alert := al.AddAlertType("chain", "sync-stall")
+
+		var lastHead *types.TipSet
+		var lastChange time.Time
+		check := func() {
+			ts := cs.GetHeaviestTipSet()
+			if ts == nil {
+				return
+			}
+
+			if lastHead == nil || !lastHead.Equals(ts) {
+				lastHead = ts
+				lastChange = time.Now()
+
+				if al.IsRaised(alert) {
+					al.Resolve(alert, map[string]interface{}{
+						"message": "chain head is advancing",
+						"height":  ts.Height(),
+					})
+				}
+				return
+			}
+
+			headTime := time.Unix(int64(ts.MinTimestamp()), 0)
+			if time.Since(lastChange) > timeout && time.Since(headTime) > timeout && !al.IsRaised(alert) {
+				al.Raise(alert, map[string]interface{}{
+					"message":   "chain head is not advancing",
+					"height":    ts.Height(),
+					"head_time": headTime,
+					"since":     lastChange,
+				})
+			}
+		}
+
+		runAlertCheck(lc, helpers.LifecycleCtx(mctx, lc), check)
+	}
+}
+
+// SplitstoreAlerts raises alerts when splitstore background operations fail, when
+// the splitstore health check finds missing objects, and when the splitstore runs
+// low on disk space.
+func SplitstoreAlerts(al *alerting.Alerting, bs dtypes.SplitBlockstore) {
+	ss, ok := bs.(*splitstore.SplitStore)
+	if !ok {
+		return
+	}
+
+	alerts := map[string]alerting.AlertType{}
+	for _, op := range []string{"compaction", "prune", "warmup", "check", "corruption", "disk-space"} {
+		alerts[op] = al.AddAlertType("splitstore", op)
+	}
+
+	ss.AddOperationHook(func(op string, err error) {
+		alert, ok := alerts[op]
+		if !ok {
+			return
+		}
+
+		if op == "check" {
+			corruption := alerts["corruption"]
+			switch {
+			case errors.Is(err, splitstore.ErrMissingReferences):
+				al.Raise(corruption, map[string]string{
+					"message": "splitstore health check found missing objects",
+					"error":   err.Error(),
+				})
+				return
+			case err == nil && al.IsRaised(corruption):
+				al.Resolve(corruption, map[string]string{
+					"message": "splitstore health check passed",
+				})
+			}
+		}
+
+		switch {
+		case err != nil:
+			al.Raise(alert, map[string]string{
+				"message":   "splitstore operation failed",
+				"operation": op,
+				"error":     err.Error(),
+			})
+		case al.IsRaised(alert):
+			al.Resolve(alert, map[string]string{
+				"message":   "splitstore operation succeeded",
+				"operation": op,
+			})
+		}
+	})
+}
+
+func runAlertCheck(lc fx.Lifecycle, ctx context.Context, check func()) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				ticker := time.NewTicker(alertCheckInterval)
+				defer ticker.Stop()
+
+				for {
+					check()
+
+					select {
+					case <-ticker.C:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return nil
+		},
+	})
+}
+
 // TODO: More things:
-//  * Space in repo dirs (taking into account mounts)
 //  * Miner
 //    * Faulted partitions
 //    * Low balances
@@ -41,4 +230,4 @@ func CheckFdLimit(min uint64) func(al *alerting.Alerting) {
 //    * Reachability
 //    * on-chain config
 //  * Low memory (maybe)
-//  * Network / sync issues
+//  * Network issues
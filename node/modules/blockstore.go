@@ -5,14 +5,22 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/ipfs/go-cid"
 	bstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/multiformats/go-multiaddr"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/blockstore"
 	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
@@ -27,6 +35,15 @@ func UniversalBlockstore(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.Locked
 	if err != nil {
 		return nil, err
 	}
+	// for exercising the error paths of the chainstore and the splitstore
+	if faults := os.Getenv("LOTUS_CHAINSTORE_FAULTS"); faults != "" {
+		cfg, err := blockstore.ParseFaultConfig(faults)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing LOTUS_CHAINSTORE_FAULTS: %w", err)
+		}
+		log.Warnw("injecting faults into the universal blockstore", "faults", faults)
+		bs = blockstore.NewFaulty(bs, cfg)
+	}
 	if c, ok := bs.(io.Closer); ok {
 		lc.Append(fx.Hook{
 			OnStop: func(_ context.Context) error {
@@ -37,6 +54,27 @@ func UniversalBlockstore(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.Locked
 	return bs, err
 }
 
+// RemoteUniversalBlockstore returns a universal blockstore backed by the chain
+// blockstore of another lotus node, reconnecting to it when the connection drops.
+func RemoteUniversalBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle) (dtypes.UniversalBlockstore, error) {
+	return func(lc fx.Lifecycle) (dtypes.UniversalBlockstore, error) {
+		if cfg.EnableSplitstore {
+			return nil, xerrors.Errorf("the splitstore can't be used with a remote blockstore")
+		}
+
+		bs, err := cliutil.NewRemoteChainstore(cliutil.ParseApiInfo(cfg.RemoteBlockstore))
+		if err != nil {
+			return nil, xerrors.Errorf("connecting to the remote blockstore: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: bs.Stop,
+		})
+
+		return bs, nil
+	}
+}
+
 func MemoryBlockstore() dtypes.UniversalBlockstore {
 	return blockstore.NewMemory()
 }
@@ -45,33 +83,72 @@ func DiscardColdBlockstore(lc fx.Lifecycle, bs dtypes.UniversalBlockstore) (dtyp
 	return blockstore.NewDiscardStore(bs), nil
 }
 
-func BadgerHotBlockstore(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.HotBlockstore, error) {
-	path, err := r.SplitstorePath()
-	if err != nil {
-		return nil, err
+// IpfsColdBlockstore returns a coldstore backed by the blockstore of an IPFS
+// node. The node is used offline, so that chain objects are never fetched from
+// the IPFS network.
+func IpfsColdBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, mctx helpers.MetricsCtx) (dtypes.ColdBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx) (dtypes.ColdBlockstore, error) {
+		return ipfsChainBlockstore(helpers.LifecycleCtx(mctx, lc), cfg.IpfsMAddr)
 	}
+}
 
-	path = filepath.Join(path, "hot.badger")
-	if err := os.MkdirAll(path, 0755); err != nil {
-		return nil, err
+func ipfsChainBlockstore(ctx context.Context, ipfsMaddr string) (blockstore.Blockstore, error) {
+	var bs blockstore.Blockstore
+	var err error
+	if ipfsMaddr != "" {
+		var ma multiaddr.Multiaddr
+		ma, err = multiaddr.NewMultiaddr(ipfsMaddr)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing ipfs multiaddr: %w", err)
+		}
+		bs, err = blockstore.NewRemoteIPFSBlockstore(ctx, ma, false)
+	} else {
+		bs, err = blockstore.NewLocalIPFSBlockstore(ctx, false)
 	}
-
-	opts, err := repo.BadgerBlockstoreOptions(repo.HotBlockstore, path, r.Readonly())
 	if err != nil {
-		return nil, err
+		return nil, xerrors.Errorf("constructing ipfs blockstore: %w", err)
 	}
+	return bs, nil
+}
 
-	bs, err := badgerbs.Open(opts)
-	if err != nil {
-		return nil, err
-	}
+func BadgerHotBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.HotBlockstore, error) {
+	return func(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.HotBlockstore, error) {
+		def, _, err := repo.ChainstorePaths(r.Path(), nil)
+		if err != nil {
+			return nil, err
+		}
+		path, _, err := repo.ChainstorePaths(r.Path(), cfg)
+		if err != nil {
+			return nil, err
+		}
 
-	lc.Append(fx.Hook{
-		OnStop: func(_ context.Context) error {
-			return bs.Close()
-		}})
+		if !r.Readonly() {
+			if err := repo.CheckStorePath(r.Path(), repo.HotStoreName, def, path); err != nil {
+				return nil, err
+			}
+		}
 
-	return bs, nil
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return nil, err
+		}
+
+		opts, err := repo.BadgerBlockstoreOptions(repo.HotBlockstore, path, r.Readonly())
+		if err != nil {
+			return nil, err
+		}
+
+		bs, err := badgerbs.Open(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return bs.Close()
+			}})
+
+		return bs, nil
+	}
 }
 
 func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.LockedRepo, ds dtypes.MetadataDS, cold dtypes.ColdBlockstore, hot dtypes.HotBlockstore) (dtypes.SplitBlockstore, error) {
@@ -81,17 +158,47 @@ func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.Locked
 			return nil, err
 		}
 
-		cfg := &splitstore.Config{
-			MarkSetType:                  cfg.Splitstore.MarkSetType,
-			DiscardColdBlocks:            cfg.Splitstore.ColdStoreType == "discard",
-			UniversalColdBlocks:          cfg.Splitstore.ColdStoreType == "universal",
-			HotStoreMessageRetention:     cfg.Splitstore.HotStoreMessageRetention,
-			HotStoreFullGCFrequency:      cfg.Splitstore.HotStoreFullGCFrequency,
-			HotstoreMaxSpaceTarget:       cfg.Splitstore.HotStoreMaxSpaceTarget,
-			HotstoreMaxSpaceThreshold:    cfg.Splitstore.HotStoreMaxSpaceThreshold,
-			HotstoreMaxSpaceSafetyBuffer: cfg.Splitstore.HotstoreMaxSpaceSafetyBuffer,
+		hotPath, coldPath, err := repo.ChainstorePaths(r.Path(), cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		ssCfg := splitstoreConfig(cfg)
+		if cfg.Splitstore.HotStorePath != "" {
+			ssCfg.HotStorePath = hotPath
 		}
-		ss, err := splitstore.Open(path, ds, hot, cold, cfg)
+		if !ssCfg.DiscardColdBlocks && cfg.Splitstore.ColdStoreType != "ipfs" {
+			// the coldstore is the universal blockstore
+			ssCfg.ColdStorePath = coldPath
+		}
+		if cfg.Splitstore.ColdStoreCacheSize > 0 && !ssCfg.DiscardColdBlocks {
+			cc, err := splitstore.OpenColdCache(filepath.Join(path, "coldcache.badger"), cold, int64(cfg.Splitstore.ColdStoreCacheSize))
+			if err != nil {
+				return nil, err
+			}
+			lc.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					return cc.Close()
+				},
+			})
+			cold = cc
+		}
+
+		if cfg.Splitstore.ColdArchivePath != "" {
+			ssCfg.ColdArchivePath = cfg.Splitstore.ColdArchivePath
+			if !filepath.IsAbs(ssCfg.ColdArchivePath) {
+				ssCfg.ColdArchivePath = filepath.Join(path, ssCfg.ColdArchivePath)
+			}
+			ssCfg.ColdArchiveRetention = cfg.Splitstore.ColdArchiveRetention
+		}
+		if cfg.Splitstore.ColdArchiveUploadURL != "" {
+			ssCfg.ColdArchiveUploader, err = splitstore.NewHTTPArchiveUploader(cfg.Splitstore.ColdArchiveUploadURL, cfg.Splitstore.ColdArchiveUploadHeaders)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ss, err := splitstore.Open(path, ds, hot, cold, ssCfg)
 		if err != nil {
 			return nil, err
 		}
@@ -105,6 +212,30 @@ func SplitBlockstore(cfg *config.Chainstore) func(lc fx.Lifecycle, r repo.Locked
 	}
 }
 
+func splitstoreConfig(cfg *config.Chainstore) *splitstore.Config {
+	return &splitstore.Config{
+		MarkSetType:                  cfg.Splitstore.MarkSetType,
+		DiscardColdBlocks:            cfg.Splitstore.ColdStoreType == "discard",
+		UniversalColdBlocks:          cfg.Splitstore.ColdStoreType == "universal" || cfg.Splitstore.ColdStoreType == "ipfs",
+		EphemeralHotStore:            cfg.Splitstore.EphemeralHotStore,
+		HotStoreMessageRetention:     cfg.Splitstore.HotStoreMessageRetention,
+		HotStoreFullGCFrequency:      cfg.Splitstore.HotStoreFullGCFrequency,
+		HotstoreMaxSpaceTarget:       cfg.Splitstore.HotStoreMaxSpaceTarget,
+		HotstoreMaxSpaceThreshold:    cfg.Splitstore.HotStoreMaxSpaceThreshold,
+		HotstoreMaxSpaceSafetyBuffer: cfg.Splitstore.HotstoreMaxSpaceSafetyBuffer,
+		DiskSpaceLowThreshold:        cfg.Splitstore.DiskSpaceLowThreshold,
+		DiskSpaceCriticalThreshold:   cfg.Splitstore.DiskSpaceCriticalThreshold,
+		CompactionProfiling:          cfg.Splitstore.EnableCompactionProfiling,
+		ColdReadPrefetch:             cfg.Splitstore.EnableColdReadPrefetch,
+		ColdReadPromotion:            cfg.Splitstore.ColdReadPromotion,
+		ColdReadPromotionHits:        int(cfg.Splitstore.ColdReadPromotionHits),
+		ColdReadPromotionWindow:      abi.ChainEpoch(cfg.Splitstore.ColdReadPromotionWindow),
+		CompactionStrategy:           cfg.Splitstore.CompactionStrategy,
+		HotStoreCapacity:             cfg.Splitstore.HotStoreCapacity,
+		ShutdownGracePeriod:          time.Duration(cfg.Splitstore.ShutdownGracePeriod),
+	}
+}
+
 func SplitBlockstoreGCReferenceProtector(_ fx.Lifecycle, s dtypes.SplitBlockstore) dtypes.GCReferenceProtector {
 	return s.(dtypes.GCReferenceProtector)
 }
@@ -151,3 +282,35 @@ func InitFallbackBlockstores(cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockst
 	}
 	return nil
 }
+
+// InitIpfsFallbackBlockstores reads the blocks missing from the chain and state
+// blockstores from an IPFS node, and then from the network with bitswap when
+// withBitswap is set.
+func InitIpfsFallbackBlockstores(cfg *config.Chainstore, withBitswap bool) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockstore, rem dtypes.ChainBitswap) error {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockstore, rem dtypes.ChainBitswap) error {
+		ipfsbs, err := ipfsChainBlockstore(helpers.LifecycleCtx(mctx, lc), cfg.IpfsMAddr)
+		if err != nil {
+			return err
+		}
+
+		fallback := func(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+			blk, err := ipfsbs.Get(ctx, c)
+			if err == nil || !withBitswap {
+				return blk, err
+			}
+			if !ipld.IsNotFound(err) {
+				log.Warnf("reading block %s from ipfs: %s", c, err)
+			}
+			return rem.GetBlock(ctx, c)
+		}
+
+		for _, bs := range []bstore.Blockstore{cbs, sbs} {
+			if fbs, ok := bs.(*blockstore.FallbackStore); ok {
+				fbs.SetFallback(fallback)
+				continue
+			}
+			return xerrors.Errorf("expected a FallbackStore")
+		}
+		return nil
+	}
+}
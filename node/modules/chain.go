@@ -12,6 +12,8 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
+
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/build"
@@ -27,6 +29,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
@@ -60,11 +63,12 @@ func ChainBlockService(bs dtypes.ExposedBlockstore, rem dtypes.ChainBitswap) dty
 	return blockservice.New(bs, rem)
 }
 
-func MessagePool(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSchedule, mpp messagepool.Provider, ds dtypes.MetadataDS, nn dtypes.NetworkName, j journal.Journal, protector dtypes.GCReferenceProtector) (*messagepool.MessagePool, error) {
-	mp, err := messagepool.New(helpers.LifecycleCtx(mctx, lc), mpp, ds, us, nn, j)
+func MessagePool(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSchedule, mpp messagepool.Provider, ds dtypes.MetadataDS, lds dtypes.MpoolDS, policy messagepool.SenderPolicy, nn dtypes.NetworkName, j journal.Journal, protector dtypes.GCReferenceProtector) (*messagepool.MessagePool, error) {
+	mp, err := messagepool.NewWithLocalStore(helpers.LifecycleCtx(mctx, lc), mpp, ds, lds, us, nn, j)
 	if err != nil {
 		return nil, xerrors.Errorf("constructing mpool: %w", err)
 	}
+	mp.SetSenderPolicy(policy)
 	lc.Append(fx.Hook{
 		OnStop: func(_ context.Context) error {
 			return mp.Close()
@@ -74,6 +78,29 @@ func MessagePool(lc fx.Lifecycle, mctx helpers.MetricsCtx, us stmgr.UpgradeSched
 	return mp, nil
 }
 
+// MpoolPeerLimiter limits the untrusted messages accepted from each peer according
+// to the Mpool config section
+func MpoolPeerLimiter(cfg config.MpoolConfig) func() *messagepool.PeerLimiter {
+	return func() *messagepool.PeerLimiter {
+		return messagepool.NewPeerLimiter(mpoolPeerLimits(cfg))
+	}
+}
+
+// MpoolSenderPolicy prioritizes the senders listed in the Mpool config section
+func MpoolSenderPolicy(cfg config.MpoolConfig) func() (messagepool.SenderPolicy, error) {
+	return func() (messagepool.SenderPolicy, error) {
+		policy := make(messagepool.StaticSenderPolicy, 0, len(cfg.PrioritySenders))
+		for _, s := range cfg.PrioritySenders {
+			addr, err := address.NewFromString(s)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing mpool priority sender %q: %w", s, err)
+			}
+			policy = append(policy, addr)
+		}
+		return policy, nil
+	}
+}
+
 func ChainStore(lc fx.Lifecycle,
 	mctx helpers.MetricsCtx,
 	cbs dtypes.ChainBlockstore,
@@ -3,6 +3,7 @@ package modules
 import (
 	"bytes"
 	"context"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/namespace"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
@@ -26,15 +29,20 @@ import (
 	storageimpl "github.com/filecoin-project/go-fil-markets/storagemarket/impl"
 	smnet "github.com/filecoin-project/go-fil-markets/storagemarket/network"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/markets"
+	"github.com/filecoin-project/lotus/markets/dealwebhook"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
+	"github.com/filecoin-project/lotus/markets/retrievalresume"
+	"github.com/filecoin-project/lotus/markets/retrievalstats"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/client"
 	"github.com/filecoin-project/lotus/node/impl/full"
 	payapi "github.com/filecoin-project/lotus/node/impl/paych"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -162,6 +170,79 @@ func RetrievalBlockstoreAccessor(r repo.LockedRepo) (retrievalmarket.BlockstoreA
 	return retrievaladapter.NewCARBlockstoreAccessor(dir), nil
 }
 
+// HandleClientDealWebhooks posts the lifecycle events of the client storage
+// deals to the configured webhooks
+func HandleClientDealWebhooks(cfg config.DealWebhooks) func(c storagemarket.StorageClient) error {
+	return func(c storagemarket.StorageClient) error {
+		n, err := dealwebhook.NewNotifier(cfg.URLs, time.Duration(cfg.Timeout), cfg.Events)
+		if err != nil {
+			return xerrors.Errorf("configuring deal webhooks: %w", err)
+		}
+		c.SubscribeToEvents(n.OnClientEvent)
+		return nil
+	}
+}
+
+// RetrievalProgressTracker persists the progress of retrieval deals, so that
+// interrupted retrievals can be resumed.
+func RetrievalProgressTracker(ds dtypes.MetadataDS, c retrievalmarket.RetrievalClient) *retrievalresume.Tracker {
+	t := retrievalresume.NewTracker(namespace.Wrap(ds, datastore.NewKey("/retrievals/progress")))
+	c.SubscribeToEvents(t.OnClientEvent)
+	return t
+}
+
+// ClientHTTPGateway serves the content of the client over plain HTTP on the
+// configured address.
+func ClientHTTPGateway(cfg config.ClientHTTPGateway) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, a client.API) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, a client.API) error {
+		maddr, err := multiaddr.NewMultiaddr(cfg.ListenAddress)
+		if err != nil {
+			return xerrors.Errorf("parsing http gateway listen address: %w", err)
+		}
+
+		gw := client.NewGateway(helpers.LifecycleCtx(mctx, lc), &a, client.GatewayConfig{
+			RetrieveOnMiss:   cfg.RetrieveOnMiss,
+			MaxPrice:         big.Int(cfg.MaxRetrievalPrice),
+			RetrievalTimeout: time.Duration(cfg.RetrievalTimeout),
+		})
+		srv := &http.Server{
+			Handler:           gw,
+			ReadHeaderTimeout: 30 * time.Second,
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				lst, err := manet.Listen(maddr)
+				if err != nil {
+					return xerrors.Errorf("http gateway could not listen: %w", err)
+				}
+
+				go func() {
+					if err := srv.Serve(manet.NetListener(lst)); err != http.ErrServerClosed {
+						log.Warnf("http gateway failed: %s", err)
+					}
+				}()
+
+				log.Infof("http gateway listening on %s", maddr)
+				return nil
+			},
+			OnStop: srv.Shutdown,
+		})
+		return nil
+	}
+}
+
+// RetrievalStatsRecorder records the outcome of the retrieval deals made with
+// each provider.
+func RetrievalStatsRecorder(mctx helpers.MetricsCtx, ds dtypes.MetadataDS, c retrievalmarket.RetrievalClient) (*retrievalstats.Recorder, error) {
+	r, err := retrievalstats.NewRecorder(mctx, namespace.Wrap(ds, datastore.NewKey("/retrievals/stats")))
+	if err != nil {
+		return nil, xerrors.Errorf("creating retrieval stats recorder: %w", err)
+	}
+	c.SubscribeToEvents(r.OnClientEvent)
+	return r, nil
+}
+
 func StorageClient(lc fx.Lifecycle, h host.Host, dataTransfer dtypes.ClientDataTransfer, discovery *discoveryimpl.Local,
 	deals dtypes.ClientDatastore, scn storagemarket.StorageClientNode, accessor storagemarket.BlockstoreAccessor, j journal.Journal) (storagemarket.StorageClient, error) {
 	// go-fil-markets protocol retries:
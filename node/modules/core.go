@@ -214,6 +214,15 @@ func NewDefaultMaxFeeFunc(r repo.LockedRepo) dtypes.DefaultMaxFeeFunc {
 	}
 }
 
+func NewFeeTiersWindowFunc(r repo.LockedRepo) dtypes.FeeTiersWindowFunc {
+	return func() (out uint64, err error) {
+		err = readNodeCfg(r, func(cfg *config.FullNode) {
+			out = cfg.Fees.FeeTiersWindow
+		})
+		return
+	}
+}
+
 func readNodeCfg(r repo.LockedRepo, accessor func(node *config.FullNode)) error {
 	raw, err := r.Config()
 	if err != nil {
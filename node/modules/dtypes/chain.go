@@ -1,4 +1,9 @@
 package dtypes
 
+import "github.com/filecoin-project/go-state-types/abi"
+
 type NetworkName string
 type AfterGenesisSet struct{}
+
+// MaxMsgSearchLookback caps the lookback of message searches walking the chain, 0 means no cap.
+type MaxMsgSearchLookback abi.ChainEpoch
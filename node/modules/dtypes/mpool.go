@@ -36,3 +36,5 @@ func (ml *MpoolLocker) TakeLock(ctx context.Context, a address.Address) (func(),
 }
 
 type DefaultMaxFeeFunc func() (abi.TokenAmount, error)
+
+type FeeTiersWindowFunc func() (uint64, error)
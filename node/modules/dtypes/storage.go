@@ -20,6 +20,10 @@ import (
 // main repo datastore.
 type MetadataDS datastore.Batching
 
+// MpoolDS stores locally published messages and their republish state. By
+// default it's namespaced under /mpool in main repo datastore.
+type MpoolDS datastore.Batching
+
 type (
 	// UniversalBlockstore is the universal blockstore backend.
 	UniversalBlockstore blockstore.Blockstore
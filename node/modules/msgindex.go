@@ -34,3 +34,51 @@ func MsgIndex(lc fx.Lifecycle, mctx helpers.MetricsCtx, cs *store.ChainStore, r
 func DummyMsgIndex() index.MsgIndex {
 	return index.DummyMsgIndex
 }
+
+func ReceiptIndex(lc fx.Lifecycle, mctx helpers.MetricsCtx, cs *store.ChainStore, r repo.LockedRepo) (index.ReceiptIndex, error) {
+	basePath, err := r.SqlitePath()
+	if err != nil {
+		return nil, err
+	}
+
+	receiptIndex, err := index.NewReceiptIndex(helpers.LifecycleCtx(mctx, lc), basePath, cs)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			return receiptIndex.Close()
+		},
+	})
+
+	return receiptIndex, nil
+}
+
+func DummyReceiptIndex() index.ReceiptIndex {
+	return index.DummyReceiptIndex
+}
+
+func TipSetIndex(lc fx.Lifecycle, mctx helpers.MetricsCtx, cs *store.ChainStore, r repo.LockedRepo) (index.TipSetIndex, error) {
+	basePath, err := r.SqlitePath()
+	if err != nil {
+		return nil, err
+	}
+
+	tipsetIndex, err := index.NewTipSetIndex(helpers.LifecycleCtx(mctx, lc), basePath, cs)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			return tipsetIndex.Close()
+		},
+	})
+
+	return tipsetIndex, nil
+}
+
+func DummyTipSetIndex() index.TipSetIndex {
+	return index.DummyTipSetIndex
+}
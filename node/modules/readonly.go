@@ -0,0 +1,162 @@
+package modules
+
+import (
+	"context"
+	"os"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	carv2 "github.com/ipld/go-car/v2"
+	carv2bs "github.com/ipld/go-car/v2/blockstore"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// readOnlyOverlay refuses writes to bs; objects written by the node, such as
+// the computed state of tipsets, are kept in memory instead.
+func readOnlyOverlay(bs blockstore.Blockstore) blockstore.Blockstore {
+	return blockstore.NewTieredBstore(blockstore.NewReadOnly(bs), blockstore.NewMemorySync())
+}
+
+// ReadOnlyUniversalBlockstore opens the chain blockstore of the repo read-only.
+// When the splitstore is enabled, the hotstore is opened read-only as well and
+// served together with the coldstore.
+func ReadOnlyUniversalBlockstore(lc fx.Lifecycle, r repo.LockedRepo) (dtypes.UniversalBlockstore, error) {
+	c, err := r.Config()
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+	cfg, ok := c.(*config.FullNode)
+	if !ok {
+		return nil, xerrors.Errorf("invalid config from repo, got: %T", c)
+	}
+
+	hotPath, coldPath, err := repo.ChainstorePaths(r.Path(), &cfg.Chainstore)
+	if err != nil {
+		return nil, err
+	}
+
+	open := func(domain repo.BlockstoreDomain, path string) (blockstore.Blockstore, error) {
+		opts, err := repo.BadgerBlockstoreOptions(domain, path, true)
+		if err != nil {
+			return nil, err
+		}
+
+		bs, err := badgerbs.Open(opts)
+		if err != nil {
+			return nil, xerrors.Errorf("opening %s read-only: %w", path, err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return bs.Close()
+			}})
+		return bs, nil
+	}
+
+	bs, err := open(repo.UniversalBlockstore, coldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Chainstore.EnableSplitstore {
+		if _, err := os.Stat(hotPath); err == nil {
+			hot, err := open(repo.HotBlockstore, hotPath)
+			if err != nil {
+				return nil, err
+			}
+			bs = blockstore.Union(hot, bs)
+		}
+	}
+
+	return readOnlyOverlay(bs), nil
+}
+
+// CarUniversalBlockstore serves the blocks of a CAR file, such as a chain
+// snapshot, read-only.
+func CarUniversalBlockstore(path string) func(lc fx.Lifecycle) (dtypes.UniversalBlockstore, error) {
+	return func(lc fx.Lifecycle) (dtypes.UniversalBlockstore, error) {
+		bs, err := carv2bs.OpenReadOnly(path, carv2bs.UseWholeCIDs(true))
+		if err != nil {
+			return nil, xerrors.Errorf("opening car file %s: %w", path, err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				return bs.Close()
+			}})
+
+		return readOnlyOverlay(blockstore.Adapt(bs)), nil
+	}
+}
+
+// errReadOnlyDatastore is returned when writing to the metadata datastore of a
+// read-only node.
+var errReadOnlyDatastore = xerrors.New("metadata datastore is read-only")
+
+// readOnlyDatastore refuses writes to the wrapped datastore.
+type readOnlyDatastore struct {
+	datastore.Batching
+}
+
+func (d *readOnlyDatastore) Put(context.Context, datastore.Key, []byte) error {
+	return errReadOnlyDatastore
+}
+
+func (d *readOnlyDatastore) Delete(context.Context, datastore.Key) error {
+	return errReadOnlyDatastore
+}
+
+func (d *readOnlyDatastore) Batch(context.Context) (datastore.Batch, error) {
+	return nil, errReadOnlyDatastore
+}
+
+// ReadOnlyMetadataDatastore opens the metadata datastore of the repo, refusing
+// writes to it.
+func ReadOnlyMetadataDatastore(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo) (dtypes.MetadataDS, error) {
+	mds, err := r.Datastore(helpers.LifecycleCtx(mctx, lc), "/metadata")
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyDatastore{mds}, nil
+}
+
+// MemoryMetadataDatastore keeps the metadata of the node in memory, for nodes
+// serving a CAR file, which doesn't come with the metadata of a repo.
+func MemoryMetadataDatastore() dtypes.MetadataDS {
+	return dssync.MutexWrap(datastore.NewMapDatastore())
+}
+
+// SetCarHead sets the chain head to the tipset of the roots of the CAR file.
+func SetCarHead(path string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, cs *store.ChainStore) error {
+		cr, err := carv2.OpenReader(path)
+		if err != nil {
+			return xerrors.Errorf("opening car file %s: %w", path, err)
+		}
+		defer cr.Close() //nolint:errcheck
+
+		roots, err := cr.Roots()
+		if err != nil {
+			return xerrors.Errorf("reading car roots: %w", err)
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		ts, err := cs.LoadTipSet(ctx, types.NewTipSetKey(roots...))
+		if err != nil {
+			return xerrors.Errorf("loading the tipset of the car roots: %w", err)
+		}
+
+		log.Infow("serving chain from car file", "path", path, "head", ts.Key(), "height", ts.Height())
+		return cs.ForceHeadSilent(ctx, ts)
+	}
+}
@@ -0,0 +1,74 @@
+package modules_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	blocks "github.com/ipfs/go-libipfs/blocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func TestReadOnlyRepo(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := repo.NewFS(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, r.Init(repo.FullNode))
+
+	key := datastore.NewKey("/head")
+	blk := blocks.NewBlock([]byte("block"))
+
+	// the chain and metadata written by the node before it is run read-only
+	lr, err := r.Lock(repo.FullNode)
+	require.NoError(t, err)
+	bs, err := lr.Blockstore(ctx, repo.UniversalBlockstore)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(ctx, blk))
+	mds, err := lr.Datastore(ctx, "/metadata")
+	require.NoError(t, err)
+	require.NoError(t, mds.Put(ctx, key, []byte("head")))
+	require.NoError(t, lr.Close())
+
+	lr, err = r.Lock(repo.FullNode)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lr.Close() })
+
+	lc := fxtest.NewLifecycle(t)
+	rbs, err := modules.ReadOnlyUniversalBlockstore(lc, lr)
+	require.NoError(t, err)
+	rmds, err := modules.ReadOnlyMetadataDatastore(helpers.MetricsCtx(ctx), lc, lr)
+	require.NoError(t, err)
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	has, err := rbs.Has(ctx, blk.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+	v, err := rmds.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("head"), v)
+
+	// writes to the metadata fail
+	require.Error(t, rmds.Put(ctx, key, []byte("other")))
+	require.Error(t, rmds.Delete(ctx, key))
+	_, err = rmds.Batch(ctx)
+	require.Error(t, err)
+
+	v, err = rmds.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("head"), v)
+
+	// while the blocks written by the node are kept in memory
+	written := blocks.NewBlock([]byte("written"))
+	require.NoError(t, rbs.Put(ctx, written))
+	has, err = rbs.Has(ctx, written.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+	require.Error(t, rbs.DeleteBlock(ctx, blk.Cid()))
+}
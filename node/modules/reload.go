@@ -0,0 +1,247 @@
+package modules
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// ConfigReloadHook applies the settings of a reloaded config, which is a
+// *config.FullNode or a *config.StorageMiner.
+type ConfigReloadHook func(cfg interface{}) error
+
+// ConfigReloader re-reads the config file of the repo when the node receives
+// SIGHUP or the ConfigReload API method is called, and passes it to the hooks
+// applying the settings which can be changed without restarting the node.
+// Reloads changing any other setting are rejected.
+type ConfigReloader struct {
+	r repo.LockedRepo
+
+	lk         sync.Mutex
+	cfg        interface{} // the config last applied
+	hooks      []ConfigReloadHook
+	reloadable []string
+}
+
+func NewConfigReloader(lc fx.Lifecycle, r repo.LockedRepo) (*ConfigReloader, error) {
+	cr, err := newConfigReloader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(sigCh, syscall.SIGHUP)
+			go func() {
+				for {
+					select {
+					case <-sigCh:
+						log.Info("received SIGHUP, reloading config")
+						if err := cr.Reload(); err != nil {
+							log.Errorf("reloading config: %s", err)
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(sigCh)
+			close(done)
+			return nil
+		},
+	})
+
+	return cr, nil
+}
+
+func newConfigReloader(r repo.LockedRepo) (*ConfigReloader, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+
+	return &ConfigReloader{r: r, cfg: cfg}, nil
+}
+
+// OnReload registers a hook called with the config on every reload. fields are
+// the paths of the settings applied by the hook, such as "Mpool.PeerRateLimit";
+// they may be changed by a reload.
+func (cr *ConfigReloader) OnReload(hook ConfigReloadHook, fields ...string) {
+	cr.lk.Lock()
+	defer cr.lk.Unlock()
+
+	cr.hooks = append(cr.hooks, hook)
+	cr.reloadable = append(cr.reloadable, fields...)
+}
+
+// Reload reads the config file and calls all the hooks, even when some of them
+// fail. Nothing is applied when a setting which no hook applies has changed.
+func (cr *ConfigReloader) Reload() error {
+	cr.lk.Lock()
+	defer cr.lk.Unlock()
+
+	cfg, err := cr.r.Config()
+	if err != nil {
+		return xerrors.Errorf("loading config: %w", err)
+	}
+
+	var fixed []string
+	for _, field := range changedFields("", reflect.ValueOf(cr.cfg), reflect.ValueOf(cfg)) {
+		if !cr.isReloadable(field) {
+			fixed = append(fixed, field)
+		}
+	}
+	if len(fixed) > 0 {
+		return xerrors.Errorf("changing %s requires restarting the node", strings.Join(fixed, ", "))
+	}
+
+	var errs error
+	for _, hook := range cr.hooks {
+		errs = multierr.Append(errs, hook(cfg))
+	}
+	cr.cfg = cfg
+	return errs
+}
+
+// must hold cr.lk
+func (cr *ConfigReloader) isReloadable(field string) bool {
+	for _, r := range cr.reloadable {
+		if field == r || strings.HasPrefix(field, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFields returns the paths of the settings which differ between a and b.
+// The fields of embedded structs are at the level of the embedding struct, as
+// in the config file.
+func changedFields(path string, a, b reflect.Value) []string {
+	if a.Kind() == reflect.Ptr && b.Kind() == reflect.Ptr && !a.IsNil() && !b.IsNil() {
+		return changedFields(path, a.Elem(), b.Elem())
+	}
+	if a.Kind() != reflect.Struct || a.Type() != b.Type() {
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return nil
+		}
+		return []string{path}
+	}
+
+	var changed []string
+	for i := 0; i < a.NumField(); i++ {
+		f := a.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		fpath := path
+		if !f.Anonymous {
+			fpath = f.Name
+			if path != "" {
+				fpath = path + "." + f.Name
+			}
+		}
+		changed = append(changed, changedFields(fpath, a.Field(i), b.Field(i))...)
+	}
+	return changed
+}
+
+// ReloadLogLevels applies the log levels of the reloaded config. Subsystems
+// removed from the config keep their current level.
+func ReloadLogLevels(cr *ConfigReloader) {
+	cr.OnReload(func(cfg interface{}) error {
+		var levels map[string]string
+		switch c := cfg.(type) {
+		case *config.FullNode:
+			levels = c.Logging.SubsystemLevels
+		case *config.StorageMiner:
+			levels = c.Logging.SubsystemLevels
+		default:
+			return xerrors.Errorf("unexpected config type: %T", cfg)
+		}
+
+		lotuslog.SetLevelsFromConfig(levels)
+		return nil
+	}, "Logging.SubsystemLevels")
+}
+
+// ReloadMpoolPeerLimits applies the untrusted message rate limits of the
+// reloaded config.
+func ReloadMpoolPeerLimits(cr *ConfigReloader, pl *messagepool.PeerLimiter) {
+	cr.OnReload(func(cfg interface{}) error {
+		c, ok := cfg.(*config.FullNode)
+		if !ok {
+			return xerrors.Errorf("unexpected config type: %T", cfg)
+		}
+
+		pl.SetLimits(mpoolPeerLimits(c.Mpool))
+		return nil
+	}, "Mpool.PeerRateLimit", "Mpool.PeerRateBurst", "Mpool.PeerMaxRejected", "Mpool.PeerBanDuration")
+}
+
+// splitstoreReloadable are the splitstore settings which SplitStore.UpdateConfig
+// applies.
+var splitstoreReloadable = []string{
+	"Chainstore.Splitstore.HotStoreMessageRetention",
+	"Chainstore.Splitstore.HotStoreFullGCFrequency",
+	"Chainstore.Splitstore.HotStoreMaxSpaceTarget",
+	"Chainstore.Splitstore.HotStoreMaxSpaceThreshold",
+	"Chainstore.Splitstore.HotstoreMaxSpaceSafetyBuffer",
+	"Chainstore.Splitstore.HotStoreCapacity",
+	"Chainstore.Splitstore.DiskSpaceLowThreshold",
+	"Chainstore.Splitstore.DiskSpaceCriticalThreshold",
+	"Chainstore.Splitstore.EnableCompactionProfiling",
+	"Chainstore.Splitstore.EnableColdReadPrefetch",
+	"Chainstore.Splitstore.ColdReadPromotion",
+	"Chainstore.Splitstore.ColdReadPromotionHits",
+	"Chainstore.Splitstore.ColdReadPromotionWindow",
+	"Chainstore.Splitstore.ShutdownGracePeriod",
+}
+
+// ReloadSplitstore applies the compaction, GC and disk space settings of the
+// reloaded config to the splitstore.
+func ReloadSplitstore(cr *ConfigReloader, bs dtypes.SplitBlockstore) {
+	ss, ok := bs.(*splitstore.SplitStore)
+	if !ok {
+		return
+	}
+
+	cr.OnReload(func(cfg interface{}) error {
+		c, ok := cfg.(*config.FullNode)
+		if !ok {
+			return xerrors.Errorf("unexpected config type: %T", cfg)
+		}
+
+		return ss.UpdateConfig(splitstoreConfig(&c.Chainstore))
+	}, splitstoreReloadable...)
+}
+
+func mpoolPeerLimits(cfg config.MpoolConfig) messagepool.PeerLimits {
+	return messagepool.PeerLimits{
+		Rate:        cfg.PeerRateLimit,
+		Burst:       cfg.PeerRateBurst,
+		MaxRejected: cfg.PeerMaxRejected,
+		BanDuration: time.Duration(cfg.PeerBanDuration),
+	}
+}
@@ -0,0 +1,76 @@
+package modules_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func TestConfigReload(t *testing.T) {
+	r, err := repo.NewFS(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, r.Init(repo.FullNode))
+	lr, err := r.Lock(repo.FullNode)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lr.Close() })
+
+	update := func(f func(*config.FullNode)) {
+		require.NoError(t, lr.SetConfig(func(c interface{}) {
+			f(c.(*config.FullNode))
+		}))
+	}
+
+	update(func(c *config.FullNode) {
+		c.Mpool.PeerRateLimit = 1
+		c.Mpool.PeerRateBurst = 1
+	})
+
+	cr, err := modules.NewConfigReloader(fxtest.NewLifecycle(t), lr)
+	require.NoError(t, err)
+
+	pl := messagepool.NewPeerLimiter(messagepool.PeerLimits{Rate: 1, Burst: 1})
+	modules.ReloadMpoolPeerLimits(cr, pl)
+
+	allowed := func(peer string) int {
+		n := 0
+		for pl.Allow(peer) == nil {
+			n++
+		}
+		return n
+	}
+	require.Equal(t, 1, allowed("a"))
+
+	// reloadable settings are applied
+	update(func(c *config.FullNode) {
+		c.Mpool.PeerRateBurst = 3
+	})
+	require.NoError(t, cr.Reload())
+	require.Equal(t, 3, allowed("b"))
+
+	// changing other settings rejects the whole reload
+	update(func(c *config.FullNode) {
+		c.Mpool.PeerRateBurst = 5
+		c.Libp2p.ListenAddresses = []string{"/ip4/127.0.0.1/tcp/1234"}
+		c.Chainstore.Splitstore.HotStoreMessageRetention = 2
+	})
+	err = cr.Reload()
+	require.ErrorContains(t, err, "Libp2p.ListenAddresses")
+	// the splitstore settings are only reloadable when the splitstore is reloaded
+	require.ErrorContains(t, err, "Chainstore.Splitstore.HotStoreMessageRetention")
+	require.NotContains(t, err.Error(), "Mpool.PeerRateBurst")
+	require.Equal(t, 3, allowed("c"))
+
+	// and the reload succeeds once they are reverted
+	update(func(c *config.FullNode) {
+		c.Libp2p.ListenAddresses = config.DefaultFullNode().Libp2p.ListenAddresses
+		c.Chainstore.Splitstore.HotStoreMessageRetention = config.DefaultFullNode().Chainstore.Splitstore.HotStoreMessageRetention
+	})
+	require.NoError(t, cr.Reload())
+	require.Equal(t, 5, allowed("d"))
+}
@@ -170,10 +170,10 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx,
 	go sub.HandleIncomingBlocks(ctx, blocksub, s, bserv, h.ConnManager())
 }
 
-func HandleIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper) {
+func HandleIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, limiter *messagepool.PeerLimiter, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 
-	v := sub.NewMessageValidator(h.ID(), mpool)
+	v := sub.NewMessageValidator(h.ID(), mpool, limiter)
 
 	if err := ps.RegisterTopicValidator(build.MessagesTopic(nn), v.Validate); err != nil {
 		panic(err)
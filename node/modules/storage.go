@@ -7,6 +7,7 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/backupds"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -57,3 +58,24 @@ func Datastore(disableLog bool) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r
 		return bds, nil
 	}
 }
+
+// MpoolDatastore opens the datastore holding locally published messages, migrating
+// any messages persisted in the metadata datastore by previous versions, or restored
+// into it from a backup.
+func MpoolDatastore(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo, mds dtypes.MetadataDS) (dtypes.MpoolDS, error) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lds, err := r.Datastore(ctx, "/mpool")
+	if err != nil {
+		return nil, err
+	}
+
+	migrated, err := messagepool.MigrateLocalMessages(ctx, mds, lds)
+	if err != nil {
+		return nil, xerrors.Errorf("migrating local mpool messages: %w", err)
+	}
+	if migrated > 0 {
+		log.Infof("migrated %d local mpool entries to the mpool datastore", migrated)
+	}
+
+	return lds, nil
+}
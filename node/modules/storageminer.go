@@ -20,6 +20,7 @@ import (
 	"github.com/ipfs/go-graphsync/storeutil"
 	provider "github.com/ipni/index-provider"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"go.uber.org/fx"
 	"go.uber.org/multierr"
 	"golang.org/x/xerrors"
@@ -48,6 +49,7 @@ import (
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/events"
@@ -55,11 +57,18 @@ import (
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/markets"
+	"github.com/filecoin-project/lotus/markets/collateral"
 	"github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/dealquota"
+	"github.com/filecoin-project/lotus/markets/dealwebhook"
+	"github.com/filecoin-project/lotus/markets/dtprogress"
 	"github.com/filecoin-project/lotus/markets/idxprov"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/markets/pricing"
+	"github.com/filecoin-project/lotus/markets/unsealedcache"
 	lotusminer "github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -350,13 +359,20 @@ func HandleRetrieval(host host.Host, lc fx.Lifecycle, m retrievalmarket.Retrieva
 	})
 }
 
-func HandleDeals(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, h storagemarket.StorageProvider, j journal.Journal) {
+func HandleDeals(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, h storagemarket.StorageProvider, j journal.Journal, quota *dealquota.Enforcer) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 	h.OnReady(marketevents.ReadyLogger("storage provider"))
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
 			h.SubscribeToEvents(marketevents.StorageProviderLogger)
 
+			deals, err := h.ListLocalDeals()
+			if err != nil {
+				return xerrors.Errorf("listing deals for client quotas: %w", err)
+			}
+			quota.Load(ctx, deals)
+			h.SubscribeToEvents(quota.OnDealEvent)
+
 			evtType := j.RegisterEventType("markets/storage/provider", "state_change")
 			h.SubscribeToEvents(markets.StorageProviderJournaler(j, evtType))
 
@@ -368,6 +384,19 @@ func HandleDeals(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, h sto
 	})
 }
 
+// HandleProviderDealWebhooks posts the lifecycle events of the provider
+// storage deals to the configured webhooks
+func HandleProviderDealWebhooks(cfg config.DealWebhooks) func(h storagemarket.StorageProvider) error {
+	return func(h storagemarket.StorageProvider) error {
+		n, err := dealwebhook.NewNotifier(cfg.URLs, time.Duration(cfg.Timeout), cfg.Events)
+		if err != nil {
+			return xerrors.Errorf("configuring deal webhooks: %w", err)
+		}
+		h.SubscribeToEvents(n.OnProviderEvent)
+		return nil
+	}
+}
+
 func HandleMigrateProviderFunds(lc fx.Lifecycle, ds dtypes.MetadataDS, node api.FullNode, minerAddress dtypes.MinerAddress) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -439,6 +468,141 @@ func NewProviderDataTransfer(lc fx.Lifecycle, net dtypes.ProviderTransferNetwork
 	return dt, nil
 }
 
+// NewDataTransferTracker tracks the progress of the provider data transfers
+func NewDataTransferTracker(lc fx.Lifecycle, dt dtypes.ProviderDataTransfer) *dtprogress.Tracker {
+	tr := dtprogress.NewTracker()
+	unsub := dt.SubscribeToEvents(tr.OnEvent)
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			unsub()
+			return nil
+		},
+	})
+	return tr
+}
+
+// RestartStalledTransfers starts a supervisor restarting the provider data
+// transfers which stop making progress
+func RestartStalledTransfers(cfg config.DealmakingConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, tr *dtprogress.Tracker, dt dtypes.ProviderDataTransfer) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, tr *dtprogress.Tracker, dt dtypes.ProviderDataTransfer) {
+		s := dtprogress.NewSupervisor(dtprogress.SupervisorConfig{
+			StallTimeout: time.Duration(cfg.StalledTransferTimeout),
+			MaxRestarts:  cfg.StalledTransferMaxRestarts,
+		}, tr, dt)
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				s.Start(ctx)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				s.Stop()
+				return nil
+			},
+		})
+	}
+}
+
+// UnsealedCache reads pieces through a cache tracking the sectors unsealed to
+// serve retrievals, and removing their unsealed copies as configured.
+func UnsealedCache(cfg config.UnsealedCacheConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, storage *paths.Remote, index paths.SectorIndex, uns sealer.Unsealer, rel sealer.UnsealedReleaser) (sealer.PieceProvider, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, storage *paths.Remote, index paths.SectorIndex, uns sealer.Unsealer, rel sealer.UnsealedReleaser) (sealer.PieceProvider, error) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		c, err := unsealedcache.NewCache(ctx, sealer.NewPieceProvider(storage, index, uns), rel, namespace.Wrap(ds, datastore.NewKey("/unsealedcache")), unsealedcache.Config{
+			MaxBytes: cfg.MaxBytes,
+			TTL:      time.Duration(cfg.TTL),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go c.Run(ctx)
+				return nil
+			},
+		})
+
+		return c, nil
+	}
+}
+
+// TopUpMarketCollateral keeps the market balance of the miner above the
+// configured threshold
+func TopUpMarketCollateral(cfg config.CollateralTopUpConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, full api.FullNode, maddr dtypes.MinerAddress, ds dtypes.MetadataDS, al *alerting.Alerting) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, full api.FullNode, maddr dtypes.MinerAddress, ds dtypes.MetadataDS, al *alerting.Alerting) error {
+		if !big.Int(cfg.Threshold).GreaterThan(big.Zero()) {
+			return nil
+		}
+
+		var wallet address.Address
+		if cfg.Wallet != "" {
+			var err error
+			wallet, err = address.NewFromString(cfg.Wallet)
+			if err != nil {
+				return xerrors.Errorf("parsing collateral top-up wallet: %w", err)
+			}
+		}
+
+		tu, err := collateral.NewTopUp(collateral.TopUpConfig{
+			Threshold:        abi.TokenAmount(cfg.Threshold),
+			Amount:           abi.TokenAmount(cfg.Amount),
+			MaxPerDay:        abi.TokenAmount(cfg.MaxPerDay),
+			Wallet:           wallet,
+			MinWalletBalance: abi.TokenAmount(cfg.MinWalletBalance),
+		}, full, address.Address(maddr), namespace.Wrap(ds, datastore.NewKey("/market/collateral-topup")), al)
+		if err != nil {
+			return err
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				tu.Start(ctx)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				tu.Stop()
+				return nil
+			},
+		})
+		return nil
+	}
+}
+
+// NewDealQuotaEnforcer creates the enforcer of the per-client deal quotas
+func NewDealQuotaEnforcer(cfg config.DealmakingConfig) func(full v1api.FullNode) (*dealquota.Enforcer, error) {
+	return func(full v1api.FullNode) (*dealquota.Enforcer, error) {
+		var def dealquota.Quota
+		clients := map[address.Address]dealquota.Quota{}
+
+		for _, q := range cfg.ClientQuotas {
+			quota := dealquota.Quota{
+				MaxOpenDeals:   q.MaxOpenDeals,
+				MaxBytesPerDay: q.MaxBytesPerDay,
+			}
+
+			if q.Client == "*" {
+				def = quota
+				continue
+			}
+
+			a, err := address.NewFromString(q.Client)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing client quota address %q: %w", q.Client, err)
+			}
+			if _, ok := clients[a]; ok {
+				return nil, xerrors.Errorf("duplicate quota for client %s", a)
+			}
+			clients[a] = quota
+		}
+
+		return dealquota.NewEnforcer(full, def, clients), nil
+	}
+}
+
 // NewProviderPieceStore creates a statestore for storing metadata about pieces
 // shared by the storage and retrieval providers
 func NewProviderPieceStore(lc fx.Lifecycle, ds dtypes.MetadataDS) (dtypes.ProviderPieceStore, error) {
@@ -457,14 +621,110 @@ func NewProviderPieceStore(lc fx.Lifecycle, ds dtypes.MetadataDS) (dtypes.Provid
 
 // StagingBlockstore creates a blockstore for staging blocks for a miner
 // in a storage deal, prior to sealing
-func StagingBlockstore(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.StagingBlockstore, error) {
-	ctx := helpers.LifecycleCtx(mctx, lc)
-	stagingds, err := r.Datastore(ctx, "/staging")
-	if err != nil {
-		return nil, err
+func StagingBlockstore(cfg config.StagingBlockstoreConfig) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.StagingBlockstore, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo) (dtypes.StagingBlockstore, error) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		stagingds, err := r.Datastore(ctx, "/staging")
+		if err != nil {
+			return nil, err
+		}
+
+		bs := blockstore.FromDatastore(stagingds)
+		if !cfg.EnableRetention {
+			return bs, nil
+		}
+
+		// evicted blocks are discarded, as the deal data is kept in the sealed sector
+		rs, err := splitstore.OpenRetention(filepath.Join(r.Path(), "staging-retention"), bs, nil, &splitstore.RetentionConfig{
+			MarkSetType: "map",
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("opening staging retention store: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return rs.Close()
+			},
+		})
+		return rs, nil
 	}
+}
+
+// storage deals in these states no longer need their data in the staging blockstore, as
+// it was handed to sealing or the deal failed
+var stagingReleasedStates = map[storagemarket.StorageDealStatus]struct{}{
+	storagemarket.StorageDealProposalNotFound:  {},
+	storagemarket.StorageDealProposalRejected:  {},
+	storagemarket.StorageDealStaged:            {},
+	storagemarket.StorageDealAwaitingPreCommit: {},
+	storagemarket.StorageDealSealing:           {},
+	storagemarket.StorageDealFinalizing:        {},
+	storagemarket.StorageDealActive:            {},
+	storagemarket.StorageDealExpired:           {},
+	storagemarket.StorageDealSlashed:           {},
+	storagemarket.StorageDealRejecting:         {},
+	storagemarket.StorageDealFailing:           {},
+	storagemarket.StorageDealError:             {},
+}
+
+// StagingRetention periodically compacts the staging blockstore, retaining the data of the
+// storage deals which haven't been handed to sealing yet
+func StagingRetention(cfg config.StagingBlockstoreConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, bs dtypes.StagingBlockstore, sp storagemarket.StorageProvider) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, bs dtypes.StagingBlockstore, sp storagemarket.StorageProvider) error {
+		rs, ok := bs.(*splitstore.RetentionStore)
+		if !ok {
+			return xerrors.Errorf("staging blockstore doesn't support retention: %T", bs)
+		}
+
+		compact := func(ctx context.Context) error {
+			deals, err := sp.ListLocalDeals()
+			if err != nil {
+				return xerrors.Errorf("listing deals: %w", err)
+			}
 
-	return blockstore.FromDatastore(stagingds), nil
+			var roots []cid.Cid
+			for _, deal := range deals {
+				if _, released := stagingReleasedStates[deal.State]; released || deal.Ref == nil {
+					continue
+				}
+				roots = append(roots, deal.Ref.Root)
+			}
+
+			return rs.Compact(ctx, roots)
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+
+					ticker := time.NewTicker(time.Duration(cfg.CompactionInterval))
+					defer ticker.Stop()
+
+					for {
+						select {
+						case <-ticker.C:
+							if err := compact(ctx); err != nil {
+								log.Errorf("compacting staging blockstore: %s", err)
+							}
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				cancel()
+				<-done
+				return nil
+			},
+		})
+		return nil
+	}
 }
 
 // StagingGraphsync creates a graphsync instance which reads and writes blocks
@@ -538,6 +798,7 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 	startDelay dtypes.GetMaxDealStartDelayFunc,
 	spn storagemarket.StorageProviderNode,
 	r repo.LockedRepo,
+	quota *dealquota.Enforcer,
 ) dtypes.StorageDealFilter {
 	return func(onlineOk dtypes.ConsiderOnlineStorageDealsConfigFunc,
 		offlineOk dtypes.ConsiderOfflineStorageDealsConfigFunc,
@@ -548,9 +809,18 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 		startDelay dtypes.GetMaxDealStartDelayFunc,
 		spn storagemarket.StorageProviderNode,
 		r repo.LockedRepo,
+		quota *dealquota.Enforcer,
 	) dtypes.StorageDealFilter {
+		validate := dealfilter.ProposalValidator(spn)
 
 		return func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+			// reject the proposals which can't make it on chain or into a
+			// sector before anything else
+			ok, reason, err := validate(ctx, deal)
+			if err != nil || !ok {
+				return ok, reason, err
+			}
+
 			b, err := onlineOk()
 			if err != nil {
 				return false, "miner error", err
@@ -643,10 +913,15 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 			}
 
 			if user != nil {
-				return user(ctx, deal)
+				ok, reason, err := user(ctx, deal)
+				if err != nil || !ok {
+					return ok, reason, err
+				}
 			}
 
-			return true, "", nil
+			// the quota is checked last, as accepting the deal counts it
+			// against the client's quota
+			return quota.Reserve(ctx, deal)
 		}
 	}
 }
@@ -736,18 +1011,60 @@ func RetrievalNetwork(h host.Host) rmnet.RetrievalMarketNetwork {
 
 // RetrievalPricingFunc configures the pricing function to use for retrieval deals.
 func RetrievalPricingFunc(cfg config.DealmakingConfig) func(_ dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-	_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalPricingFunc {
+	_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc, full v1api.FullNode, ps dtypes.ProviderPieceStore) (dtypes.RetrievalPricingFunc, error) {
 
 	return func(_ dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-		_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalPricingFunc {
-		if cfg.RetrievalPricing.Strategy == config.RetrievalPricingExternalMode {
-			return pricing.ExternalRetrievalPricingFunc(cfg.RetrievalPricing.External.Path)
+		_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc, full v1api.FullNode, ps dtypes.ProviderPieceStore) (dtypes.RetrievalPricingFunc, error) {
+		switch cfg.RetrievalPricing.Strategy {
+		case config.RetrievalPricingExternalMode:
+			return pricing.ExternalRetrievalPricingFunc(cfg.RetrievalPricing.External.Path), nil
+		case config.RetrievalPricingRulesMode:
+			rcfg, err := retrievalPricingRules(cfg.RetrievalPricing.Rules)
+			if err != nil {
+				return nil, err
+			}
+			return pricing.RulesRetrievalPricingFunc(rcfg, full, ps), nil
 		}
 
-		return retrievalimpl.DefaultPricingFunc(cfg.RetrievalPricing.Default.VerifiedDealsFreeTransfer)
+		return retrievalimpl.DefaultPricingFunc(cfg.RetrievalPricing.Default.VerifiedDealsFreeTransfer), nil
 	}
 }
 
+func retrievalPricingRules(cfg *config.RetrievalPricingRules) (pricing.RulesConfig, error) {
+	rcfg := pricing.RulesConfig{
+		VerifiedDealsFreeTransfer: cfg.VerifiedDealsFreeTransfer,
+	}
+
+	for i, r := range cfg.Rules {
+		rule := pricing.Rule{
+			MinDataAge:   time.Duration(r.MinDataAge),
+			MaxDataAge:   time.Duration(r.MaxDataAge),
+			PricePerByte: abi.TokenAmount(r.PricePerByte),
+			UnsealPrice:  abi.TokenAmount(r.UnsealPrice),
+		}
+		for _, s := range r.PieceCIDs {
+			c, err := cid.Parse(s)
+			if err != nil {
+				return pricing.RulesConfig{}, xerrors.Errorf("retrieval pricing rule %d: parsing piece cid %s: %w", i, s, err)
+			}
+			rule.PieceCIDs = append(rule.PieceCIDs, c)
+		}
+		for _, s := range r.Clients {
+			p, err := peer.Decode(s)
+			if err != nil {
+				return pricing.RulesConfig{}, xerrors.Errorf("retrieval pricing rule %d: parsing client peer id %s: %w", i, s, err)
+			}
+			rule.Clients = append(rule.Clients, p)
+		}
+		if rule.MaxDataAge > 0 && rule.MaxDataAge < rule.MinDataAge {
+			return pricing.RulesConfig{}, xerrors.Errorf("retrieval pricing rule %d: MaxDataAge is lower than MinDataAge", i)
+		}
+		rcfg.Rules = append(rcfg.Rules, rule)
+	}
+
+	return rcfg, nil
+}
+
 // RetrievalProvider creates a new retrieval provider attached to the provider blockstore
 func RetrievalProvider(
 	maddr dtypes.MinerAddress,
@@ -1013,6 +1330,8 @@ func NewSetSealConfigFunc(r repo.LockedRepo) (dtypes.SetSealingConfigFunc, error
 				CommitBatchSlack:           config.Duration(cfg.CommitBatchSlack),
 				AggregateAboveBaseFee:      types.FIL(cfg.AggregateAboveBaseFee),
 				BatchPreCommitAboveBaseFee: types.FIL(cfg.BatchPreCommitAboveBaseFee),
+				HoldPreCommitsAboveBaseFee: types.FIL(cfg.HoldPreCommitsAboveBaseFee),
+				HoldCommitsAboveBaseFee:    types.FIL(cfg.HoldCommitsAboveBaseFee),
 
 				TerminateBatchMax:  cfg.TerminateBatchMax,
 				TerminateBatchMin:  cfg.TerminateBatchMin,
@@ -1058,6 +1377,8 @@ func ToSealingConfig(dealmakingCfg config.DealmakingConfig, sealingCfg config.Se
 		CommitBatchSlack:           time.Duration(sealingCfg.CommitBatchSlack),
 		AggregateAboveBaseFee:      types.BigInt(sealingCfg.AggregateAboveBaseFee),
 		BatchPreCommitAboveBaseFee: types.BigInt(sealingCfg.BatchPreCommitAboveBaseFee),
+		HoldPreCommitsAboveBaseFee: types.BigInt(sealingCfg.HoldPreCommitsAboveBaseFee),
+		HoldCommitsAboveBaseFee:    types.BigInt(sealingCfg.HoldCommitsAboveBaseFee),
 
 		TerminateBatchMax:  sealingCfg.TerminateBatchMax,
 		TerminateBatchMin:  sealingCfg.TerminateBatchMin,
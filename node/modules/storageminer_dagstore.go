@@ -8,14 +8,17 @@ import (
 	"strconv"
 
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/dagstore"
 
+	"github.com/filecoin-project/lotus/markets/bitswapserver"
 	mdagstore "github.com/filecoin-project/lotus/markets/dagstore"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
@@ -92,3 +95,53 @@ func DAGStore(cfg config.DAGStoreConfig) func(lc fx.Lifecycle, r repo.LockedRepo
 		return dagst, w, nil
 	}
 }
+
+// BitswapServer serves the payload blocks of the deals with an unsealed copy
+// over bitswap, from the DAG store shards.
+func BitswapServer(cfg config.BitswapConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, w *mdagstore.Wrapper, minerAPI mdagstore.MinerAPI) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, w *mdagstore.Wrapper, minerAPI mdagstore.MinerAPI) error {
+		decode := func(ids []string) ([]peer.ID, error) {
+			out := make([]peer.ID, 0, len(ids))
+			for _, s := range ids {
+				p, err := peer.Decode(s)
+				if err != nil {
+					return nil, xerrors.Errorf("decoding peer ID %q: %w", s, err)
+				}
+				out = append(out, p)
+			}
+			return out, nil
+		}
+
+		allowed, err := decode(cfg.AllowedPeers)
+		if err != nil {
+			return xerrors.Errorf("parsing bitswap allowed peers: %w", err)
+		}
+		denied, err := decode(cfg.DeniedPeers)
+		if err != nil {
+			return xerrors.Errorf("parsing bitswap denied peers: %w", err)
+		}
+
+		bs := bitswapserver.NewBlockstore(w, minerAPI)
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		var srv *bitswapserver.Server
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				srv = bitswapserver.NewServer(ctx, h, bs, bitswapserver.Config{
+					AllowedPeers:               allowed,
+					DeniedPeers:                denied,
+					Rate:                       cfg.MaxBlocksPerSecondPerPeer,
+					Burst:                      cfg.BlocksBurstPerPeer,
+					MaxOutstandingBytesPerPeer: cfg.MaxOutstandingBytesPerPeer,
+					TaskWorkers:                cfg.TaskWorkers,
+				})
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				return srv.Close()
+			},
+		})
+
+		return nil
+	}
+}
@@ -13,10 +13,14 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/markets/idxannounce"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
 
 type IdxProv struct {
@@ -115,3 +119,59 @@ func IndexProvider(cfg config.IndexProviderConfig) func(params IdxProv, marketHo
 		return e, nil
 	}
 }
+
+// NewPieceAnnouncer creates the announcer delivering the pieces of active deals
+// to the external indexer endpoint
+func NewPieceAnnouncer(cfg config.IndexProviderConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, marketHost host.Host, maddr dtypes.MinerAddress) *idxannounce.Announcer {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, marketHost host.Host, maddr dtypes.MinerAddress) *idxannounce.Announcer {
+		announcement := func(deal storagemarket.MinerDeal) api.PieceAnnouncement {
+			addrs := marketHost.Addrs()
+			addrsString := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				addrsString = append(addrsString, addr.String())
+			}
+
+			ann := api.PieceAnnouncement{
+				Provider:     address.Address(maddr),
+				PeerID:       marketHost.ID(),
+				Addrs:        addrsString,
+				ProposalCid:  deal.ProposalCid,
+				DealID:       deal.DealID,
+				PieceCid:     deal.Proposal.PieceCID,
+				PieceSize:    deal.Proposal.PieceSize,
+				Client:       deal.Proposal.Client,
+				VerifiedDeal: deal.Proposal.VerifiedDeal,
+				StartEpoch:   deal.Proposal.StartEpoch,
+				EndEpoch:     deal.Proposal.EndEpoch,
+			}
+			if deal.Ref != nil {
+				ann.PayloadCid = deal.Ref.Root
+			}
+			return ann
+		}
+
+		a := idxannounce.NewAnnouncer(idxannounce.Config{
+			Endpoint:    cfg.AnnounceEndpoint,
+			MaxAttempts: cfg.AnnounceMaxAttempts,
+		}, namespace.Wrap(ds, datastore.NewKey("/index-announce/queue")), announcement)
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				a.Start(ctx)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				a.Stop()
+				return nil
+			},
+		})
+		return a
+	}
+}
+
+// HandlePieceAnnouncements queues the announcements of the deals activated by
+// the storage provider
+func HandlePieceAnnouncements(a *idxannounce.Announcer, h storagemarket.StorageProvider) {
+	h.SubscribeToEvents(a.OnDealEvent)
+}
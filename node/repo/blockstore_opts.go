@@ -17,6 +17,10 @@ func BadgerBlockstoreOptions(domain BlockstoreDomain, path string, readonly bool
 	// in order to shorten keys, but it'll require a migration.
 	opts.Prefix = "/blocks/"
 
+	// Report badger internals (LSM levels, value log size, caches) tagged by
+	// the blockstore domain.
+	opts.MetricsName = string(domain)
+
 	// Blockstore values are immutable; therefore we do not expect any
 	// conflicts to emerge.
 	opts.DetectConflicts = false
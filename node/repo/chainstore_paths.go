@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// fsStorePaths records the paths the chain stores were last opened at, so that
+// path changes without moving the stores are detected.
+const fsStorePaths = "storepaths.json"
+
+const (
+	HotStoreName  = "hotstore"
+	ColdStoreName = "coldstore"
+)
+
+// ChainstorePaths returns the paths of the splitstore hotstore and of the
+// coldstore, which is the chain blockstore, as configured in cfg.
+func ChainstorePaths(repoPath string, cfg *config.Chainstore) (hot string, cold string, err error) {
+	hot = filepath.Join(repoPath, fsDatastore, "splitstore", "hot.badger")
+	cold = filepath.Join(repoPath, fsDatastore, "chain")
+
+	if cfg == nil {
+		return hot, cold, nil
+	}
+
+	if cfg.Splitstore.HotStorePath != "" {
+		hot, err = expandStorePath(HotStoreName, cfg.Splitstore.HotStorePath)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if cfg.Splitstore.ColdStorePath != "" {
+		cold, err = expandStorePath(ColdStoreName, cfg.Splitstore.ColdStorePath)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return hot, cold, nil
+}
+
+func expandStorePath(name, path string) (string, error) {
+	p, err := homedir.Expand(path)
+	if err != nil {
+		return "", xerrors.Errorf("expanding %s path %s: %w", name, path, err)
+	}
+	if !filepath.IsAbs(p) {
+		return "", xerrors.Errorf("%s path %s must be absolute", name, path)
+	}
+	return filepath.Clean(p), nil
+}
+
+// CheckStorePath checks that the store with the given name can be opened at
+// path, and records the path in the repo. When the path changed, and only the
+// previous path holds data, an error explaining how to move the store is
+// returned instead of letting the node start with an empty store.
+func CheckStorePath(repoPath, name, defPath, path string) error {
+	if st, err := os.Stat(path); err == nil && !st.IsDir() {
+		return xerrors.Errorf("%s path %s is not a directory", name, path)
+	}
+
+	recFile := filepath.Join(repoPath, fsDatastore, fsStorePaths)
+
+	recorded := map[string]string{}
+	b, err := os.ReadFile(recFile)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &recorded); err != nil {
+			return xerrors.Errorf("decoding %s: %w", recFile, err)
+		}
+	case !os.IsNotExist(err):
+		return xerrors.Errorf("reading %s: %w", recFile, err)
+	}
+
+	prev, ok := recorded[name]
+	if !ok {
+		prev = defPath
+	}
+	if ok && prev == path {
+		return nil
+	}
+
+	if prev != path {
+		prevEmpty, err := dirEmpty(prev)
+		if err != nil {
+			return xerrors.Errorf("checking previous %s path: %w", name, err)
+		}
+		newEmpty, err := dirEmpty(path)
+		if err != nil {
+			return xerrors.Errorf("checking %s path: %w", name, err)
+		}
+
+		if !prevEmpty && newEmpty {
+			return xerrors.Errorf("%s path changed from %s to %s, but only the previous path holds data; "+
+				"with the node stopped, move the contents of %s to %s, or remove %s to start with an empty %s",
+				name, prev, path, prev, path, prev, name)
+		}
+
+		log.Infow("store path changed", "store", name, "from", prev, "to", path)
+	}
+
+	recorded[name] = path
+
+	b, err = json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("encoding store paths: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(recFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(recFile, b, 0644); err != nil {
+		return xerrors.Errorf("writing %s: %w", recFile, err)
+	}
+
+	return nil
+}
+
+func dirEmpty(path string) (bool, error) {
+	entries, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(entries) == 0, nil
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestChainstorePaths(t *testing.T) {
+	repoPath := t.TempDir()
+
+	hot, cold, err := ChainstorePaths(repoPath, nil)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(repoPath, "datastore", "splitstore", "hot.badger"), hot)
+	require.Equal(t, filepath.Join(repoPath, "datastore", "chain"), cold)
+
+	cfg := config.DefaultFullNode().Chainstore
+	cfg.Splitstore.HotStorePath = "/fast/hot/"
+	hot, cold, err = ChainstorePaths(repoPath, &cfg)
+	require.NoError(t, err)
+	require.Equal(t, "/fast/hot", hot)
+	require.Equal(t, filepath.Join(repoPath, "datastore", "chain"), cold)
+
+	cfg.Splitstore.ColdStorePath = "relative/cold"
+	_, _, err = ChainstorePaths(repoPath, &cfg)
+	require.Error(t, err)
+}
+
+func TestCheckStorePath(t *testing.T) {
+	repoPath := t.TempDir()
+	def := filepath.Join(repoPath, "datastore", "chain")
+	moved := filepath.Join(t.TempDir(), "chain")
+
+	// a fresh repo can use any path
+	require.NoError(t, CheckStorePath(repoPath, ColdStoreName, def, def))
+
+	// changing the path while the data is left behind fails
+	require.NoError(t, os.MkdirAll(def, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(def, "000001.vlog"), []byte{1}, 0644))
+	require.Error(t, CheckStorePath(repoPath, ColdStoreName, def, moved))
+
+	// once moved, the new path is recorded
+	require.NoError(t, os.Rename(def, moved))
+	require.NoError(t, CheckStorePath(repoPath, ColdStoreName, def, moved))
+	require.NoError(t, CheckStorePath(repoPath, ColdStoreName, def, moved))
+
+	// the path must be a directory
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	require.Error(t, CheckStorePath(repoPath, ColdStoreName, def, file))
+}
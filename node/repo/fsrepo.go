@@ -466,7 +466,11 @@ func (fsr *fsLockedRepo) Blockstore(ctx context.Context, domain BlockstoreDomain
 	}
 
 	fsr.bsOnce.Do(func() {
-		path := fsr.join(filepath.Join(fsDatastore, "chain"))
+		path, err := fsr.coldStorePath()
+		if err != nil {
+			fsr.bsErr = err
+			return
+		}
 		readonly := fsr.readonly
 
 		if err := os.MkdirAll(path, 0755); err != nil {
@@ -495,6 +499,40 @@ func (fsr *fsLockedRepo) Blockstore(ctx context.Context, domain BlockstoreDomain
 	return fsr.bs, fsr.bsErr
 }
 
+// coldStorePath returns the path of the universal blockstore, which is the
+// splitstore coldstore, checking it against the previously used path.
+func (fsr *fsLockedRepo) coldStorePath() (string, error) {
+	_, def, err := ChainstorePaths(fsr.path, nil)
+	if err != nil {
+		return "", err
+	}
+	if fsr.repoType != FullNode {
+		return def, nil
+	}
+
+	c, err := fsr.Config()
+	if err != nil {
+		return "", xerrors.Errorf("loading config: %w", err)
+	}
+	cfg, ok := c.(*config.FullNode)
+	if !ok {
+		return "", xerrors.Errorf("invalid config for repo, got: %T", c)
+	}
+
+	_, path, err := ChainstorePaths(fsr.path, &cfg.Chainstore)
+	if err != nil {
+		return "", err
+	}
+
+	if !fsr.readonly {
+		if err := CheckStorePath(fsr.path, ColdStoreName, def, path); err != nil {
+			return "", err
+		}
+	}
+
+	return path, nil
+}
+
 func (fsr *fsLockedRepo) SplitstorePath() (string, error) {
 	fsr.ssOnce.Do(func() {
 		path := fsr.join(filepath.Join(fsDatastore, "splitstore"))
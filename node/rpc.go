@@ -28,6 +28,7 @@ import (
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/lib/rpcenc"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/metrics/proxy"
@@ -70,6 +71,17 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 	return srv.Shutdown, err
 }
 
+// peerTagHandler tags API requests with the remote host, identifying the peer
+// pushing untrusted messages for the mpool peer limiter.
+func peerTagHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			r = r.WithContext(messagepool.WithPeer(r.Context(), host))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // FullNodeHandler returns a full node handler, to be mounted as-is on the server.
 func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.ServerOption) (http.Handler, error) {
 	m := mux.NewRouter()
@@ -81,9 +93,9 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 
 		api.CreateEthRPCAliases(rpcServer)
 
-		var handler http.Handler = rpcServer
+		var handler http.Handler = peerTagHandler(rpcServer)
 		if permissioned {
-			handler = &auth.Handler{Verify: a.AuthVerify, Next: rpcServer.ServeHTTP}
+			handler = &auth.Handler{Verify: a.AuthVerify, Next: handler.ServeHTTP}
 		}
 
 		m.Handle(path, handler)
@@ -102,6 +114,7 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	handleImportFunc := handleImport(a.(*impl.FullNodeAPI))
 	handleExportFunc := handleExport(a.(*impl.FullNodeAPI))
 	handleRemoteStoreFunc := handleRemoteStore(a.(*impl.FullNodeAPI))
+	handleChainstoreFunc := handleChainstore(a.(*impl.FullNodeAPI))
 	if permissioned {
 		importAH := &auth.Handler{
 			Verify: a.AuthVerify,
@@ -119,10 +132,17 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 			Next:   handleRemoteStoreFunc,
 		}
 		m.Handle("/rest/v0/store/{uuid}", storeAH)
+
+		chainstoreAH := &auth.Handler{
+			Verify: a.AuthVerify,
+			Next:   handleChainstoreFunc,
+		}
+		m.Handle("/rest/v0/chainstore", chainstoreAH)
 	} else {
 		m.HandleFunc("/rest/v0/import", handleImportFunc)
 		m.HandleFunc("/rest/v0/export", handleExportFunc)
 		m.HandleFunc("/rest/v0/store/{uuid}", handleRemoteStoreFunc)
+		m.HandleFunc("/rest/v0/chainstore", handleChainstoreFunc)
 	}
 
 	// debugging
@@ -133,6 +153,7 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	}))
 	m.Handle("/health/livez", NewLiveHandler(a))
 	m.Handle("/health/readyz", NewReadyHandler(a))
+	m.Handle("/healthz", NewHealthReportHandler(a))
 	m.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
 
 	return m, nil
@@ -173,6 +194,8 @@ func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 		m := mux.NewRouter()
 		m.Handle("/rpc/v0", rpcServer)
 		m.Handle("/rpc/streams/v0/push/{uuid}", readerHandler)
+		// piece data
+		m.PathPrefix("/piece/").HandlerFunc(a.(*impl.StorageMinerAPI).ServePiece(permissioned))
 		// debugging
 		m.Handle("/debug/metrics", metrics.Exporter())
 		m.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
@@ -303,3 +326,33 @@ func handleRemoteStore(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.
 		}
 	}
 }
+
+// handleChainstore serves the chain blockstore of the node over the websocket
+// blockstore protocol, so that local processes such as lotus-miner can read chain
+// objects without a JSON-RPC round trip per object, and other nodes can use it as
+// their chainstore. The blockstore is read-only, except with the admin permission, which
+// allows putting objects; deleting chain objects is never allowed.
+func handleChainstore(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.HasPerm(r.Context(), nil, api.PermRead) {
+			w.WriteHeader(401)
+			_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing read permission"})
+			return
+		}
+
+		bs := bstore.NewReadOnly(a.ChainAPI.ExposedBlockstore)
+		if auth.HasPerm(r.Context(), nil, api.PermAdmin) {
+			bs = bstore.NewPutOnly(a.ChainAPI.ExposedBlockstore)
+		}
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(500)
+			return
+		}
+
+		// the handler runs until the connection is closed, past the request
+		bstore.HandleNetBstoreWS(context.Background(), bs, c)
+	}
+}
@@ -0,0 +1,267 @@
+package msgagg
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	lbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+var log = logging.Logger("msgagg")
+
+type aggregatorAPI interface {
+	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error)
+	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error)
+}
+
+// Rule decides which messages calling a miner actor method can be coalesced, and
+// how their params are merged into the params of a single message.
+type Rule struct {
+	Method abi.MethodNum
+
+	// Key groups the messages which can be merged. Messages are only coalesced
+	// with messages to the same miner, from the same sender, with the same key.
+	Key func(params []byte) (string, error)
+	// Merge returns the params of the message replacing the batch.
+	Merge func(params [][]byte) ([]byte, error)
+}
+
+// Rules are the aggregation rules supported by the aggregator, keyed by method name
+var Rules = map[string]Rule{
+	// withdrawals are merged into a single withdrawal of the total requested amount
+	"WithdrawBalance": {
+		Method: builtin.MethodsMiner.WithdrawBalance,
+		Key: func([]byte) (string, error) {
+			return "", nil
+		},
+		Merge: func(params [][]byte) ([]byte, error) {
+			total := big.Zero()
+			for _, p := range params {
+				var wp minertypes.WithdrawBalanceParams
+				if err := wp.UnmarshalCBOR(bytes.NewReader(p)); err != nil {
+					return nil, xerrors.Errorf("unmarshaling withdraw balance params: %w", err)
+				}
+				total = big.Add(total, wp.AmountRequested)
+			}
+			enc, aerr := actors.SerializeParams(&minertypes.WithdrawBalanceParams{AmountRequested: total})
+			if aerr != nil {
+				return nil, aerr
+			}
+			return enc, nil
+		},
+	},
+}
+
+// Aggregator coalesces compatible miner control messages sent within a
+// configurable window into a single message, saving the gas otherwise spent on
+// messages which tend to be sent in bursts.
+// When a message calling a method with an enabled Rule is pushed, the Aggregator
+// waits for the window to elapse, merges the params of all the compatible
+// messages pushed in the meantime and pushes a single message. Messages without
+// a rule, or not sent to a miner actor, are pushed immediately.
+type Aggregator struct {
+	api aggregatorAPI
+
+	ctx      context.Context
+	Shutdown context.CancelFunc
+
+	window time.Duration
+	rules  map[abi.MethodNum]Rule
+
+	lk      sync.Mutex
+	pending map[batchKey][]*pendingMsg
+}
+
+type batchKey struct {
+	to     address.Address
+	from   address.Address
+	method abi.MethodNum
+	key    string
+}
+
+// A message that is queued to be aggregated
+type pendingMsg struct {
+	ctx    context.Context
+	msg    *types.Message
+	spec   *api.MessageSendSpec
+	result chan pushResult
+}
+
+// The result of pushing an aggregated message
+type pushResult struct {
+	smsg *types.SignedMessage
+	err  error
+}
+
+func NewAggregator(cfg config.MessageAggregationConfig) func(lc fx.Lifecycle, full api.FullNode) (*Aggregator, error) {
+	return func(lc fx.Lifecycle, full api.FullNode) (*Aggregator, error) {
+		a, err := newAggregator(full, cfg)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				a.Shutdown()
+				return nil
+			},
+		})
+		return a, nil
+	}
+}
+
+func newAggregator(aapi aggregatorAPI, cfg config.MessageAggregationConfig) (*Aggregator, error) {
+	rules := make(map[abi.MethodNum]Rule, len(cfg.Methods))
+	for _, name := range cfg.Methods {
+		rule, ok := Rules[name]
+		if !ok {
+			return nil, xerrors.Errorf("no aggregation rule for method %q", name)
+		}
+		rules[rule.Method] = rule
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Aggregator{
+		api:      aapi,
+		ctx:      ctx,
+		Shutdown: cancel,
+		window:   time.Duration(cfg.Window),
+		rules:    rules,
+		pending:  make(map[batchKey][]*pendingMsg),
+	}, nil
+}
+
+// Push pushes the message to the mpool, possibly merged with other compatible
+// messages. It returns the signed message which was pushed once the aggregation
+// window elapses.
+func (a *Aggregator) Push(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	rule, ok := a.rules[msg.Method]
+	if !ok || a.window == 0 {
+		return a.api.MpoolPushMessage(ctx, msg, spec)
+	}
+
+	act, err := a.api.StateGetActor(ctx, msg.To, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting actor %s: %w", msg.To, err)
+	}
+	if !lbuiltin.IsStorageMinerActor(act.Code) {
+		return a.api.MpoolPushMessage(ctx, msg, spec)
+	}
+
+	key, err := rule.Key(msg.Params)
+	if err != nil {
+		return nil, xerrors.Errorf("computing aggregation key: %w", err)
+	}
+
+	pm := &pendingMsg{
+		ctx:    ctx,
+		msg:    msg,
+		spec:   spec,
+		result: make(chan pushResult),
+	}
+	a.add(batchKey{to: msg.To, from: msg.From, method: msg.Method, key: key}, pm)
+
+	// Wait for the batch to be pushed
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-a.ctx.Done():
+		return nil, xerrors.Errorf("aggregator shut down: %w", a.ctx.Err())
+	case res := <-pm.result:
+		return res.smsg, res.err
+	}
+}
+
+func (a *Aggregator) add(bk batchKey, pm *pendingMsg) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	batch, waiting := a.pending[bk]
+	a.pending[bk] = append(batch, pm)
+	if waiting {
+		log.Infow("aggregating message", "to", bk.to, "method", bk.method, "batch", len(batch)+1)
+		return
+	}
+
+	timer := build.Clock.Timer(a.window)
+	go func() {
+		select {
+		case <-a.ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+			a.push(bk, a.rules[bk.method])
+		}
+	}()
+}
+
+// push merges the batch and pushes the resulting message
+func (a *Aggregator) push(bk batchKey, rule Rule) {
+	a.lk.Lock()
+	batch := a.pending[bk]
+	delete(a.pending, bk)
+	a.lk.Unlock()
+
+	// Filter out messages that have been cancelled
+	ready := batch[:0]
+	for _, pm := range batch {
+		if pm.ctx.Err() == nil {
+			ready = append(ready, pm)
+		}
+	}
+	if len(ready) == 0 {
+		return
+	}
+
+	var res pushResult
+	res.smsg, res.err = a.pushMerged(ready, rule)
+
+	for _, pm := range ready {
+		go func(pm *pendingMsg) {
+			select {
+			case <-a.ctx.Done():
+			case <-pm.ctx.Done():
+			case pm.result <- res:
+			}
+		}(pm)
+	}
+}
+
+func (a *Aggregator) pushMerged(batch []*pendingMsg, rule Rule) (*types.SignedMessage, error) {
+	params := make([][]byte, 0, len(batch))
+	value := big.Zero()
+	for _, pm := range batch {
+		params = append(params, pm.msg.Params)
+		if pm.msg.Value.Int != nil {
+			value = big.Add(value, pm.msg.Value)
+		}
+	}
+
+	merged, err := rule.Merge(params)
+	if err != nil {
+		return nil, xerrors.Errorf("merging params: %w", err)
+	}
+
+	msg := *batch[0].msg
+	msg.Params = merged
+	msg.Value = value
+
+	log.Infow("pushing aggregated message", "to", msg.To, "method", msg.Method, "messages", len(batch))
+
+	return a.api.MpoolPushMessage(a.ctx, &msg, batch[0].spec)
+}
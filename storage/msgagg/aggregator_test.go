@@ -0,0 +1,185 @@
+package msgagg
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestAggregateWithdrawBalance(t *testing.T) {
+	ctx := context.Background()
+	tapi := newAggregatorAPIMock()
+	a, err := newAggregator(tapi, config.MessageAggregationConfig{
+		Window:  config.Duration(100 * time.Millisecond),
+		Methods: []string{"WithdrawBalance"},
+	})
+	require.NoError(t, err)
+	defer a.Shutdown()
+
+	var wg sync.WaitGroup
+	cids := make([]string, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			smsg, err := a.Push(ctx, withdrawMsg(t, abi.NewTokenAmount(int64(10*(i+1)))), nil)
+			require.NoError(t, err)
+			cids[i] = smsg.Cid().String()
+		}(i)
+	}
+	wg.Wait()
+
+	// a single withdrawal of the total requested amount is pushed
+	pushed := tapi.getPushed()
+	require.Len(t, pushed, 1)
+
+	var params minertypes.WithdrawBalanceParams
+	require.NoError(t, params.UnmarshalCBOR(bytes.NewReader(pushed[0].Params)))
+	require.Equal(t, abi.NewTokenAmount(60), params.AmountRequested)
+
+	require.Equal(t, cids[0], cids[1])
+	require.Equal(t, cids[0], cids[2])
+}
+
+func TestAggregateByKey(t *testing.T) {
+	ctx := context.Background()
+
+	// duplicate messages are sent once
+	Rules["Dedup"] = Rule{
+		Method: builtin.MethodsMiner.ChangePeerID,
+		Key: func(params []byte) (string, error) {
+			return string(params), nil
+		},
+		Merge: func(params [][]byte) ([]byte, error) {
+			return params[0], nil
+		},
+	}
+	defer delete(Rules, "Dedup")
+
+	tapi := newAggregatorAPIMock()
+	a, err := newAggregator(tapi, config.MessageAggregationConfig{
+		Window:  config.Duration(100 * time.Millisecond),
+		Methods: []string{"Dedup"},
+	})
+	require.NoError(t, err)
+	defer a.Shutdown()
+
+	type result struct {
+		smsg *types.SignedMessage
+		err  error
+	}
+
+	params := []string{"a", "b", "a"}
+	results := make([]chan result, len(params))
+	for i, p := range params {
+		results[i] = make(chan result, 1)
+		go func(p string, res chan result) {
+			msg := withdrawMsg(t, abi.NewTokenAmount(0))
+			msg.Method = builtin.MethodsMiner.ChangePeerID
+			msg.Params = []byte(p)
+
+			smsg, err := a.Push(ctx, msg, nil)
+			res <- result{smsg, err}
+		}(p, results[i])
+	}
+
+	cids := make([]string, len(params))
+	for i, res := range results {
+		r := <-res
+		require.NoError(t, r.err)
+		cids[i] = r.smsg.Cid().String()
+	}
+
+	// messages with different keys are pushed separately
+	pushed := tapi.getPushed()
+	require.Len(t, pushed, 2)
+	require.Equal(t, cids[0], cids[2])
+	require.NotEqual(t, cids[0], cids[1])
+}
+
+func TestAggregateDisabled(t *testing.T) {
+	ctx := context.Background()
+	tapi := newAggregatorAPIMock()
+	a, err := newAggregator(tapi, config.MessageAggregationConfig{
+		Methods: []string{"WithdrawBalance"},
+	})
+	require.NoError(t, err)
+	defer a.Shutdown()
+
+	for i := 0; i < 2; i++ {
+		_, err := a.Push(ctx, withdrawMsg(t, abi.NewTokenAmount(10)), nil)
+		require.NoError(t, err)
+	}
+
+	// without a window messages are pushed as they come
+	require.Len(t, tapi.getPushed(), 2)
+}
+
+func TestAggregateUnknownMethod(t *testing.T) {
+	_, err := newAggregator(newAggregatorAPIMock(), config.MessageAggregationConfig{
+		Methods: []string{"SubmitWindowedPoSt"},
+	})
+	require.Error(t, err)
+}
+
+func withdrawMsg(t *testing.T, amount abi.TokenAmount) *types.Message {
+	params, aerr := actors.SerializeParams(&minertypes.WithdrawBalanceParams{AmountRequested: amount})
+	require.NoError(t, aerr)
+
+	to, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	from, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+
+	return &types.Message{
+		To:     to,
+		From:   from,
+		Value:  types.NewInt(0),
+		Method: builtin.MethodsMiner.WithdrawBalance,
+		Params: params,
+	}
+}
+
+type aggregatorAPIMock struct {
+	lk     sync.Mutex
+	pushed []*types.Message
+}
+
+func newAggregatorAPIMock() *aggregatorAPIMock {
+	return &aggregatorAPIMock{}
+}
+
+func (m *aggregatorAPIMock) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	msg.Nonce = uint64(len(m.pushed))
+	m.pushed = append(m.pushed, msg)
+	return &types.SignedMessage{Message: *msg}, nil
+}
+
+func (m *aggregatorAPIMock) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	return &types.Actor{Code: builtin2.StorageMinerActorCodeID}, nil
+}
+
+func (m *aggregatorAPIMock) getPushed() []*types.Message {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return append([]*types.Message{}, m.pushed...)
+}
@@ -26,6 +26,13 @@ import (
 var HeartbeatInterval = 10 * time.Second
 var SkippedHeartbeatThresh = HeartbeatInterval * 5
 
+// SlowStatThresh is the stat latency above which paths are read-only.
+var SlowStatThresh = 5 * time.Second
+
+// LowSpaceThresh is the share of free filesystem space below which paths are
+// read-only.
+var LowSpaceThresh = 0.01
+
 //go:generate go run github.com/golang/mock/mockgen -destination=mocks/index.go -package=mocks . SectorIndex
 
 type SectorIndex interface { // part of storage-miner api
@@ -46,6 +53,7 @@ type SectorIndex interface { // part of storage-miner api
 	StorageGetLocks(ctx context.Context) (storiface.SectorLocks, error)
 
 	StorageList(ctx context.Context) (map[storiface.ID][]storiface.Decl, error)
+	StorageHealth(ctx context.Context) (map[storiface.ID]storiface.PathHealth, error)
 }
 
 type declMeta struct {
@@ -59,6 +67,25 @@ type storageEntry struct {
 
 	lastHeartbeat time.Time
 	heartbeatErr  error
+	heartbeatErrs int // consecutive
+	latency       time.Duration
+}
+
+// health returns the health of the path, and why it isn't ok.
+func (e *storageEntry) health() (storiface.PathHealthState, string) {
+	if since := time.Since(e.lastHeartbeat); since > SkippedHeartbeatThresh {
+		return storiface.PathHealthOffline, fmt.Sprintf("no heartbeat for %s", since.Truncate(time.Second))
+	}
+	if e.heartbeatErr != nil {
+		return storiface.PathHealthOffline, fmt.Sprintf("heartbeat error: %s", e.heartbeatErr)
+	}
+	if e.latency > SlowStatThresh {
+		return storiface.PathHealthReadOnly, fmt.Sprintf("slow stat: %s", e.latency.Truncate(time.Millisecond))
+	}
+	if e.fsi.Capacity > 0 && float64(e.fsi.FSAvailable)/float64(e.fsi.Capacity) < LowSpaceThresh {
+		return storiface.PathHealthReadOnly, "low filesystem space"
+	}
+	return storiface.PathHealthOK, ""
 }
 
 type Index struct {
@@ -116,6 +143,31 @@ func (i *Index) StorageList(ctx context.Context) (map[storiface.ID][]storiface.D
 	return out, nil
 }
 
+func (i *Index) StorageHealth(ctx context.Context) (map[storiface.ID]storiface.PathHealth, error) {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	out := map[storiface.ID]storiface.PathHealth{}
+	for id, ent := range i.stores {
+		state, reason := ent.health()
+
+		h := storiface.PathHealth{
+			State:         state,
+			Reason:        reason,
+			LastHeartbeat: ent.lastHeartbeat,
+			Latency:       ent.latency,
+			Errors:        ent.heartbeatErrs,
+			Stat:          ent.fsi,
+		}
+		if ent.heartbeatErr != nil {
+			h.Err = ent.heartbeatErr.Error()
+		}
+		out[id] = h
+	}
+
+	return out, nil
+}
+
 func (i *Index) StorageAttach(ctx context.Context, si storiface.StorageInfo, st fsutil.FsStat) error {
 	var allow, deny = make([]string, 0, len(si.AllowTypes)), make([]string, 0, len(si.DenyTypes))
 
@@ -315,13 +367,26 @@ func (i *Index) StorageReportHealth(ctx context.Context, id storiface.ID, report
 		return xerrors.Errorf("health report for unknown storage: %s", id)
 	}
 
+	before, _ := ent.health()
+
 	ent.fsi = report.Stat
 	if report.Err != "" {
 		ent.heartbeatErr = errors.New(report.Err)
+		ent.heartbeatErrs++
 	} else {
 		ent.heartbeatErr = nil
+		ent.heartbeatErrs = 0
 	}
 	ent.lastHeartbeat = time.Now()
+	ent.latency = report.Latency
+
+	if after, reason := ent.health(); after != before {
+		if after == storiface.PathHealthOK {
+			log.Infow("storage path is healthy again", "path", id, "was", before)
+		} else {
+			log.Warnw("storage path is unhealthy", "path", id, "health", after, "reason", reason)
+		}
+	}
 
 	if report.Stat.Capacity > 0 {
 		ctx, _ = tag.New(ctx,
@@ -464,6 +529,8 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 			allowTo = nil // allow to any
 		}
 
+		health, _ := st.health()
+
 		out = append(out, storiface.SectorStorageInfo{
 			ID:       id,
 			URLs:     urls,
@@ -477,6 +544,8 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 
 			AllowTypes: st.info.AllowTypes,
 			DenyTypes:  st.info.DenyTypes,
+
+			Health: health,
 		})
 	}
 
@@ -496,13 +565,8 @@ func (i *Index) StorageFindSector(ctx context.Context, s abi.SectorID, ft storif
 				continue
 			}
 
-			if time.Since(st.lastHeartbeat) > SkippedHeartbeatThresh {
-				log.Debugf("not selecting on %s, didn't receive heartbeats for %s", st.info.ID, time.Since(st.lastHeartbeat))
-				continue
-			}
-
-			if st.heartbeatErr != nil {
-				log.Debugf("not selecting on %s, heartbeat error: %s", st.info.ID, st.heartbeatErr)
+			if health, reason := st.health(); health != storiface.PathHealthOK {
+				log.Debugf("not selecting on %s, path is %s: %s", st.info.ID, health, reason)
 				continue
 			}
 
@@ -608,13 +672,8 @@ func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorF
 			continue
 		}
 
-		if time.Since(p.lastHeartbeat) > SkippedHeartbeatThresh {
-			log.Debugf("not allocating on %s, didn't receive heartbeats for %s", p.info.ID, time.Since(p.lastHeartbeat))
-			continue
-		}
-
-		if p.heartbeatErr != nil {
-			log.Debugf("not allocating on %s, heartbeat error: %s", p.info.ID, p.heartbeatErr)
+		if health, reason := p.health(); health != storiface.PathHealthOK {
+			log.Debugf("not allocating on %s, path is %s: %s", p.info.ID, health, reason)
 			continue
 		}
 
@@ -3,6 +3,7 @@ package paths
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	logging "github.com/ipfs/go-log/v2"
@@ -152,3 +153,56 @@ func TestFindAllow(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageHealth(t *testing.T) {
+	ctx := context.Background()
+
+	i := NewIndex(nil)
+	stor1 := newTestStorage()
+	stor2 := newTestStorage()
+
+	require.NoError(t, i.StorageAttach(ctx, stor1, bigFsStat))
+	require.NoError(t, i.StorageAttach(ctx, stor2, bigFsStat))
+
+	s1 := abi.SectorID{
+		Miner:  12,
+		Number: 34,
+	}
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s1, storiface.FTSealed, true))
+
+	// slow stat makes the path read-only
+	require.NoError(t, i.StorageReportHealth(ctx, stor1.ID, storiface.HealthReport{Stat: bigFsStat, Latency: SlowStatThresh + time.Second}))
+
+	h, err := i.StorageHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, storiface.PathHealthReadOnly, h[stor1.ID].State)
+	require.Equal(t, storiface.PathHealthOK, h[stor2.ID].State)
+
+	best, err := i.StorageBestAlloc(ctx, storiface.FTSealed, s32g, storiface.PathStorage)
+	require.NoError(t, err)
+	require.Len(t, best, 1)
+	require.Equal(t, stor2.ID, best[0].ID)
+
+	// read-only paths are still found
+	si, err := i.StorageFindSector(ctx, s1, storiface.FTSealed, s32g, false)
+	require.NoError(t, err)
+	require.Len(t, si, 1)
+	require.Equal(t, storiface.PathHealthReadOnly, si[0].Health)
+
+	// heartbeat errors make the path offline
+	require.NoError(t, i.StorageReportHealth(ctx, stor1.ID, storiface.HealthReport{Stat: bigFsStat, Err: "io error"}))
+	require.NoError(t, i.StorageReportHealth(ctx, stor1.ID, storiface.HealthReport{Stat: bigFsStat, Err: "io error"}))
+
+	h, err = i.StorageHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, storiface.PathHealthOffline, h[stor1.ID].State)
+	require.Equal(t, 2, h[stor1.ID].Errors)
+
+	// and healthy again after a good heartbeat
+	require.NoError(t, i.StorageReportHealth(ctx, stor1.ID, storiface.HealthReport{Stat: bigFsStat}))
+
+	h, err = i.StorageHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, storiface.PathHealthOK, h[stor1.ID].State)
+	require.Equal(t, 0, h[stor1.ID].Errors)
+}
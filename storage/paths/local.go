@@ -381,8 +381,9 @@ func (st *Local) reportStorage(ctx context.Context) {
 
 	toReport := map[storiface.ID]storiface.HealthReport{}
 	for id, p := range st.paths {
+		start := time.Now()
 		stat, err := p.stat(st.localStorage)
-		r := storiface.HealthReport{Stat: stat}
+		r := storiface.HealthReport{Stat: stat, Latency: time.Since(start)}
 		if err != nil {
 			r.Err = err.Error()
 		}
@@ -140,6 +140,21 @@ func (mr *MockSectorIndexMockRecorder) StorageGetLocks(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageGetLocks", reflect.TypeOf((*MockSectorIndex)(nil).StorageGetLocks), arg0)
 }
 
+// StorageHealth mocks base method.
+func (m *MockSectorIndex) StorageHealth(arg0 context.Context) (map[storiface.ID]storiface.PathHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorageHealth", arg0)
+	ret0, _ := ret[0].(map[storiface.ID]storiface.PathHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StorageHealth indicates an expected call of StorageHealth.
+func (mr *MockSectorIndexMockRecorder) StorageHealth(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageHealth", reflect.TypeOf((*MockSectorIndex)(nil).StorageHealth), arg0)
+}
+
 // StorageInfo mocks base method.
 func (m *MockSectorIndex) StorageInfo(arg0 context.Context, arg1 storiface.ID) (storiface.StorageInfo, error) {
 	m.ctrl.T.Helper()
@@ -219,6 +219,7 @@ func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType
 	sort.Slice(si, func(i, j int) bool {
 		return si[i].Weight < si[j].Weight
 	})
+	healthyFirst(si)
 
 	var merr error
 	for _, info := range si {
@@ -532,6 +533,7 @@ func (r *Remote) CheckIsUnsealed(ctx context.Context, s storiface.SectorRef, off
 	sort.Slice(si, func(i, j int) bool {
 		return si[i].Weight < si[j].Weight
 	})
+	healthyFirst(si)
 
 	for _, info := range si {
 		for _, url := range info.URLs {
@@ -670,6 +672,7 @@ func (r *Remote) Reader(ctx context.Context, s storiface.SectorRef, offset, size
 	sort.Slice(si, func(i, j int) bool {
 		return si[i].Weight > si[j].Weight
 	})
+	healthyFirst(si)
 
 	var lastErr error
 	for _, info := range si {
@@ -805,3 +808,11 @@ func (f funcCloser) Close() error {
 }
 
 var _ io.Closer = funcCloser(nil)
+
+// healthyFirst moves the paths which are offline after the other ones, so that
+// they are only read from when no other path has the sector files.
+func healthyFirst(si []storiface.SectorStorageInfo) {
+	sort.SliceStable(si, func(i, j int) bool {
+		return si[i].Health != storiface.PathHealthOffline && si[j].Health == storiface.PathHealthOffline
+	})
+}
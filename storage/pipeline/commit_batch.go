@@ -71,6 +71,8 @@ type CommitBatcher struct {
 	todo    map[abi.SectorNumber]AggregateInput
 	waiting map[abi.SectorNumber][]chan sealiface.CommitBatchRes
 
+	nextCheck time.Time
+
 	notify, stop, stopped chan struct{}
 	force                 chan chan []sealiface.CommitBatchRes
 	lk                    sync.Mutex
@@ -110,7 +112,12 @@ func (b *CommitBatcher) run() {
 		panic(err)
 	}
 
-	timer := time.NewTimer(b.batchWait(cfg.CommitBatchWait, cfg.CommitBatchSlack))
+	wait := b.batchWait(cfg.CommitBatchWait, cfg.CommitBatchSlack)
+	timer := time.NewTimer(wait)
+	b.lk.Lock()
+	b.nextCheck = time.Now().Add(wait)
+	b.lk.Unlock()
+
 	for {
 		if forceRes != nil {
 			forceRes <- lastMsg
@@ -133,7 +140,7 @@ func (b *CommitBatcher) run() {
 		}
 
 		var err error
-		lastMsg, err = b.maybeStartBatch(sendAboveMax)
+		lastMsg, err = b.maybeStartBatch(sendAboveMax, forceRes != nil)
 		if err != nil {
 			log.Warnw("CommitBatcher processBatch error", "error", err)
 		}
@@ -145,7 +152,12 @@ func (b *CommitBatcher) run() {
 			}
 		}
 
-		timer.Reset(b.batchWait(cfg.CommitBatchWait, cfg.CommitBatchSlack))
+		wait = b.batchWait(cfg.CommitBatchWait, cfg.CommitBatchSlack)
+		timer.Reset(wait)
+
+		b.lk.Lock()
+		b.nextCheck = time.Now().Add(wait)
+		b.lk.Unlock()
 	}
 }
 
@@ -190,7 +202,7 @@ func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration {
 	return wait
 }
 
-func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes, error) {
+func (b *CommitBatcher) maybeStartBatch(notif, force bool) ([]sealiface.CommitBatchRes, error) {
 	b.lk.Lock()
 	defer b.lk.Unlock()
 
@@ -215,22 +227,13 @@ func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes,
 		return nil, err
 	}
 
-	blackedOut := func() bool {
-		const nv16BlackoutWindow = abi.ChainEpoch(20) // a magik number
-		if ts.Height() <= build.UpgradeSkyrHeight && build.UpgradeSkyrHeight-ts.Height() < nv16BlackoutWindow {
-			return true
-		}
-		return false
-	}
-
-	individual := (total < cfg.MinCommitBatch) || (total < miner.MinAggregatedSectors) || blackedOut()
-
-	if !individual && !cfg.AggregateAboveBaseFee.Equals(big.Zero()) {
-		if ts.MinTicketBlock().ParentBaseFee.LessThan(cfg.AggregateAboveBaseFee) {
-			individual = true
-		}
+	mode := b.sendMode(cfg, ts, force)
+	if mode == sealiface.BatchModeHold {
+		log.Infow("holding commit batch back while the base fee is high", "baseFee", ts.MinTicketBlock().ParentBaseFee, "sectors", total)
+		return nil, nil
 	}
 
+	individual := mode == sealiface.BatchModeIndividual
 	if individual {
 		res, err = b.processIndividually(cfg)
 	} else {
@@ -264,6 +267,35 @@ func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes,
 	return res, nil
 }
 
+// sendMode decides how the pending sectors are sent at ts. Unless forced, they
+// are held back while the base fee is above HoldCommitsAboveBaseFee.
+func (b *CommitBatcher) sendMode(cfg sealiface.Config, ts *types.TipSet, force bool) sealiface.BatchMode {
+	total := len(b.todo)
+	baseFee := ts.MinTicketBlock().ParentBaseFee
+
+	if !force && holdBatch(cfg.HoldCommitsAboveBaseFee, baseFee, b.cutoffs, cfg.CommitBatchSlack) {
+		return sealiface.BatchModeHold
+	}
+
+	blackedOut := func() bool {
+		const nv16BlackoutWindow = abi.ChainEpoch(20) // a magik number
+		if ts.Height() <= build.UpgradeSkyrHeight && build.UpgradeSkyrHeight-ts.Height() < nv16BlackoutWindow {
+			return true
+		}
+		return false
+	}
+
+	if (total < cfg.MinCommitBatch) || (total < miner.MinAggregatedSectors) || blackedOut() {
+		return sealiface.BatchModeIndividual
+	}
+
+	if !cfg.AggregateAboveBaseFee.Equals(big.Zero()) && baseFee.LessThan(cfg.AggregateAboveBaseFee) {
+		return sealiface.BatchModeIndividual
+	}
+
+	return sealiface.BatchModeBatch
+}
+
 func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBatchRes, error) {
 	ts, err := b.api.ChainHead(b.mctx)
 	if err != nil {
@@ -551,6 +583,30 @@ func (b *CommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error) {
 	return res, nil
 }
 
+// Status describes the pending sectors, and how and when they are going to be
+// sent.
+func (b *CommitBatcher) Status(ctx context.Context) (sealiface.BatchStatus, error) {
+	cfg, err := b.getConfig()
+	if err != nil {
+		return sealiface.BatchStatus{}, xerrors.Errorf("getting config: %w", err)
+	}
+
+	ts, err := b.api.ChainHead(ctx)
+	if err != nil {
+		return sealiface.BatchStatus{}, err
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	sectors := make([]abi.SectorNumber, 0, len(b.todo))
+	for sn := range b.todo {
+		sectors = append(sectors, sn)
+	}
+
+	return batchStatus(sectors, b.cutoffs, b.sendMode(cfg, ts, false), ts, b.nextCheck), nil
+}
+
 func (b *CommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
@@ -481,6 +481,12 @@ func (m *Sealing) plan(events []statemachine.Event, state *SectorInfo) (func(sta
 		log.Errorw("update sector stats", "error", err)
 	}
 
+	if m.statePaused(state.State) {
+		// the sector is restarted when the state is resumed
+		log.Infow("sector state paused, waiting for resume", "sector", state.SectorNumber, "state", state.State)
+		return func(statemachine.Context, SectorInfo) error { return nil }, processed, nil
+	}
+
 	switch state.State {
 	case ReceiveSector:
 		return m.handleReceiveSector, processed, nil
@@ -1,8 +1,11 @@
 package sealing
 
 import (
+	"context"
 	"testing"
 
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/stretchr/testify/require"
 
@@ -451,3 +454,41 @@ func TestCreationTimeCleared(t *testing.T) {
 
 	require.NotEqual(t, int64(0), m.state.CreationTime)
 }
+
+func TestPausedState(t *testing.T) {
+	ctx := context.Background()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	ma, _ := address.NewIDAddress(55151)
+	m := test{
+		s: &Sealing{
+			maddr: ma,
+			ds:    ds,
+			stats: SectorStats{
+				bySector: map[abi.SectorID]SectorState{},
+				byState:  map[SectorState]int64{},
+			},
+			paused: map[SectorState]struct{}{},
+		},
+		t:     t,
+		state: &SectorInfo{State: PreCommit1},
+	}
+
+	require.Error(t, m.s.PauseStates(ctx, []SectorState{"NotAState"}))
+	require.NoError(t, m.s.PauseStates(ctx, []SectorState{PreCommit2}))
+	require.Equal(t, []SectorState{PreCommit2}, m.s.PausedStates())
+
+	// the sector enters the paused state, but its handler isn't run
+	next, _, err := m.s.plan([]statemachine.Event{{User: SectorPreCommit1{}}}, m.state)
+	require.NoError(t, err)
+	require.Equal(t, PreCommit2, m.state.State)
+	require.NoError(t, next(statemachine.Context{}, *m.state))
+
+	// paused states are persisted
+	s2 := &Sealing{ds: ds, paused: map[SectorState]struct{}{}}
+	require.NoError(t, s2.loadPausedStates(ctx))
+	require.Equal(t, []SectorState{PreCommit2}, s2.PausedStates())
+
+	// resuming states which aren't paused doesn't restart any sector
+	require.NoError(t, m.s.ResumeStates(ctx, []SectorState{Committing}))
+	require.Equal(t, []SectorState{PreCommit2}, m.s.PausedStates())
+}
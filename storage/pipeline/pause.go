@@ -0,0 +1,134 @@
+package sealing
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+)
+
+var pausedStatesKey = datastore.NewKey("/storage/pausedstates")
+
+// PauseStates stops sectors from doing the work of the given states. Sectors
+// entering a paused state wait in it, without scheduling any task, until the
+// state is resumed. Work already running in the state isn't interrupted.
+func (m *Sealing) PauseStates(ctx context.Context, states []SectorState) error {
+	m.pauseLk.Lock()
+	defer m.pauseLk.Unlock()
+
+	for _, st := range states {
+		if _, ok := ExistSectorStateList[st]; !ok {
+			return xerrors.Errorf("unknown sector state: %s", st)
+		}
+	}
+
+	for _, st := range states {
+		m.paused[st] = struct{}{}
+	}
+
+	return m.savePausedStatesLocked(ctx)
+}
+
+// ResumeStates resumes the given paused states, restarting the sectors waiting
+// in them.
+func (m *Sealing) ResumeStates(ctx context.Context, states []SectorState) error {
+	m.pauseLk.Lock()
+	resumed := map[SectorState]struct{}{}
+	for _, st := range states {
+		if _, ok := m.paused[st]; ok {
+			resumed[st] = struct{}{}
+			delete(m.paused, st)
+		}
+	}
+	err := m.savePausedStatesLocked(ctx)
+	m.pauseLk.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if len(resumed) == 0 {
+		return nil
+	}
+
+	sectors, err := m.ListSectors()
+	if err != nil {
+		return xerrors.Errorf("listing sectors: %w", err)
+	}
+
+	for _, sector := range sectors {
+		if _, ok := resumed[sector.State]; !ok {
+			continue
+		}
+
+		if err := m.sectors.Send(uint64(sector.SectorNumber), SectorRestart{}); err != nil {
+			return xerrors.Errorf("restarting sector %d: %w", sector.SectorNumber, err)
+		}
+	}
+
+	return nil
+}
+
+// PausedStates lists the paused states.
+func (m *Sealing) PausedStates() []SectorState {
+	m.pauseLk.Lock()
+	defer m.pauseLk.Unlock()
+
+	out := make([]SectorState, 0, len(m.paused))
+	for st := range m.paused {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+	return out
+}
+
+func (m *Sealing) statePaused(st SectorState) bool {
+	m.pauseLk.Lock()
+	defer m.pauseLk.Unlock()
+
+	_, ok := m.paused[st]
+	return ok
+}
+
+func (m *Sealing) loadPausedStates(ctx context.Context) error {
+	m.pauseLk.Lock()
+	defer m.pauseLk.Unlock()
+
+	b, err := m.ds.Get(ctx, pausedStatesKey)
+	if err == datastore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("getting paused states: %w", err)
+	}
+
+	var states []SectorState
+	if err := json.Unmarshal(b, &states); err != nil {
+		return xerrors.Errorf("unmarshaling paused states: %w", err)
+	}
+
+	for _, st := range states {
+		m.paused[st] = struct{}{}
+	}
+	return nil
+}
+
+func (m *Sealing) savePausedStatesLocked(ctx context.Context) error {
+	states := make([]SectorState, 0, len(m.paused))
+	for st := range m.paused {
+		states = append(states, st)
+	}
+
+	b, err := json.Marshal(states)
+	if err != nil {
+		return xerrors.Errorf("marshaling paused states: %w", err)
+	}
+
+	if err := m.ds.Put(ctx, pausedStatesKey, b); err != nil {
+		return xerrors.Errorf("persisting paused states: %w", err)
+	}
+	return nil
+}
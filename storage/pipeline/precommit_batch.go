@@ -61,6 +61,8 @@ type PreCommitBatcher struct {
 	todo    map[abi.SectorNumber]*preCommitEntry
 	waiting map[abi.SectorNumber][]chan sealiface.PreCommitBatchRes
 
+	nextCheck time.Time
+
 	notify, stop, stopped chan struct{}
 	force                 chan chan []sealiface.PreCommitBatchRes
 	lk                    sync.Mutex
@@ -99,7 +101,12 @@ func (b *PreCommitBatcher) run() {
 		panic(err)
 	}
 
-	timer := time.NewTimer(b.batchWait(cfg.PreCommitBatchWait, cfg.PreCommitBatchSlack))
+	wait := b.batchWait(cfg.PreCommitBatchWait, cfg.PreCommitBatchSlack)
+	timer := time.NewTimer(wait)
+	b.lk.Lock()
+	b.nextCheck = time.Now().Add(wait)
+	b.lk.Unlock()
+
 	for {
 		if forceRes != nil {
 			forceRes <- lastRes
@@ -121,7 +128,7 @@ func (b *PreCommitBatcher) run() {
 		}
 
 		var err error
-		lastRes, err = b.maybeStartBatch(sendAboveMax)
+		lastRes, err = b.maybeStartBatch(sendAboveMax, forceRes != nil)
 		if err != nil {
 			log.Warnw("PreCommitBatcher processBatch error", "error", err)
 		}
@@ -133,7 +140,12 @@ func (b *PreCommitBatcher) run() {
 			}
 		}
 
-		timer.Reset(b.batchWait(cfg.PreCommitBatchWait, cfg.PreCommitBatchSlack))
+		wait = b.batchWait(cfg.PreCommitBatchWait, cfg.PreCommitBatchSlack)
+		timer.Reset(wait)
+
+		b.lk.Lock()
+		b.nextCheck = time.Now().Add(wait)
+		b.lk.Unlock()
 	}
 }
 
@@ -178,7 +190,7 @@ func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration
 	return wait
 }
 
-func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBatchRes, error) {
+func (b *PreCommitBatcher) maybeStartBatch(notif, force bool) ([]sealiface.PreCommitBatchRes, error) {
 	b.lk.Lock()
 	defer b.lk.Unlock()
 
@@ -207,14 +219,15 @@ func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBat
 		return nil, xerrors.Errorf("couldn't get network version: %w", err)
 	}
 
-	individual := false
-	if !cfg.BatchPreCommitAboveBaseFee.Equals(big.Zero()) && ts.MinTicketBlock().ParentBaseFee.LessThan(cfg.BatchPreCommitAboveBaseFee) && nv >= network.Version14 {
-		individual = true
+	mode := b.sendMode(cfg, ts, nv, force)
+	if mode == sealiface.BatchModeHold {
+		log.Infow("holding precommit batch back while the base fee is high", "baseFee", ts.MinTicketBlock().ParentBaseFee, "sectors", total)
+		return nil, nil
 	}
 
 	// todo support multiple batches
 	var res []sealiface.PreCommitBatchRes
-	if !individual {
+	if mode == sealiface.BatchModeBatch {
 		res, err = b.processBatch(cfg, ts.Key(), ts.MinTicketBlock().ParentBaseFee, nv)
 	} else {
 		res, err = b.processIndividually(cfg)
@@ -242,6 +255,22 @@ func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBat
 	return res, nil
 }
 
+// sendMode decides how the pending sectors are sent at ts. Unless forced, they
+// are held back while the base fee is above HoldPreCommitsAboveBaseFee.
+func (b *PreCommitBatcher) sendMode(cfg sealiface.Config, ts *types.TipSet, nv network.Version, force bool) sealiface.BatchMode {
+	baseFee := ts.MinTicketBlock().ParentBaseFee
+
+	if !force && holdBatch(cfg.HoldPreCommitsAboveBaseFee, baseFee, b.cutoffs, cfg.PreCommitBatchSlack) {
+		return sealiface.BatchModeHold
+	}
+
+	if !cfg.BatchPreCommitAboveBaseFee.Equals(big.Zero()) && baseFee.LessThan(cfg.BatchPreCommitAboveBaseFee) && nv >= network.Version14 {
+		return sealiface.BatchModeIndividual
+	}
+
+	return sealiface.BatchModeBatch
+}
+
 func (b *PreCommitBatcher) processIndividually(cfg sealiface.Config) ([]sealiface.PreCommitBatchRes, error) {
 	mi, err := b.api.StateMinerInfo(b.mctx, b.maddr, types.EmptyTSK)
 	if err != nil {
@@ -472,6 +501,35 @@ func (b *PreCommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error)
 	return res, nil
 }
 
+// Status describes the pending sectors, and how and when they are going to be
+// sent.
+func (b *PreCommitBatcher) Status(ctx context.Context) (sealiface.BatchStatus, error) {
+	cfg, err := b.getConfig()
+	if err != nil {
+		return sealiface.BatchStatus{}, xerrors.Errorf("getting config: %w", err)
+	}
+
+	ts, err := b.api.ChainHead(ctx)
+	if err != nil {
+		return sealiface.BatchStatus{}, err
+	}
+
+	nv, err := b.api.StateNetworkVersion(ctx, ts.Key())
+	if err != nil {
+		return sealiface.BatchStatus{}, xerrors.Errorf("couldn't get network version: %w", err)
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	sectors := make([]abi.SectorNumber, 0, len(b.todo))
+	for sn := range b.todo {
+		sectors = append(sectors, sn)
+	}
+
+	return batchStatus(sectors, b.cutoffs, b.sendMode(cfg, ts, nv, false), ts, b.nextCheck), nil
+}
+
 func (b *PreCommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
@@ -483,6 +541,44 @@ func (b *PreCommitBatcher) Stop(ctx context.Context) error {
 	}
 }
 
+// holdBatch returns whether a batch is held back because the base fee is above
+// hold, which lasts until one of the sectors gets within slack of its cutoff.
+func holdBatch(hold, baseFee abi.TokenAmount, cutoffs map[abi.SectorNumber]time.Time, slack time.Duration) bool {
+	if hold.Nil() || hold.IsZero() || !baseFee.GreaterThan(hold) {
+		return false
+	}
+
+	deadline := time.Now().Add(slack)
+	for _, cutoff := range cutoffs {
+		if !cutoff.IsZero() && cutoff.Before(deadline) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func batchStatus(sectors []abi.SectorNumber, cutoffs map[abi.SectorNumber]time.Time, mode sealiface.BatchMode, ts *types.TipSet, nextCheck time.Time) sealiface.BatchStatus {
+	st := sealiface.BatchStatus{
+		Sectors:   make([]sealiface.PendingSector, 0, len(sectors)),
+		Mode:      mode,
+		BaseFee:   ts.MinTicketBlock().ParentBaseFee,
+		NextCheck: nextCheck,
+	}
+	for _, sn := range sectors {
+		st.Sectors = append(st.Sectors, sealiface.PendingSector{
+			Number: sn,
+			Cutoff: cutoffs[sn],
+		})
+	}
+
+	sort.Slice(st.Sectors, func(i, j int) bool {
+		return st.Sectors[i].Number < st.Sectors[j].Number
+	})
+
+	return st
+}
+
 func getDealStartCutoff(si SectorInfo) abi.ChainEpoch {
 	cutoffEpoch := si.TicketEpoch + policy.MaxPreCommitRandomnessLookback
 	for _, p := range si.Pieces {
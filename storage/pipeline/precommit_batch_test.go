@@ -61,6 +61,7 @@ func TestPrecommitBatcher(t *testing.T) {
 			PreCommitBatchWait:         24 * time.Hour,
 			PreCommitBatchSlack:        3 * time.Hour,
 			BatchPreCommitAboveBaseFee: big.NewInt(10000),
+			HoldPreCommitsAboveBaseFee: big.NewInt(20000),
 
 			AggregateCommits: true,
 			MinCommitBatch:   miner6.MinAggregatedSectors,
@@ -213,6 +214,24 @@ func TestPrecommitBatcher(t *testing.T) {
 		}
 	}
 
+	expectStatus := func(basefee int64, mode sealiface.BatchMode, n int) action {
+		return func(t *testing.T, s *mocks.MockPreCommitBatcherApi, pcb *pipeline.PreCommitBatcher) promise {
+			s.EXPECT().ChainHead(gomock.Any()).Return(makeBFTs(t, big.NewInt(basefee), 1), nil)
+			s.EXPECT().StateNetworkVersion(gomock.Any(), gomock.Any()).Return(network.Version14, nil)
+
+			st, err := pcb.Status(ctx)
+			require.NoError(t, err)
+			require.Equal(t, mode, st.Mode)
+			require.Len(t, st.Sectors, n)
+			require.False(t, st.NextCheck.IsZero())
+			for _, ps := range st.Sectors {
+				require.False(t, ps.Cutoff.IsZero())
+			}
+
+			return nil
+		}
+	}
+
 	getSectors := func(n int) []abi.SectorNumber {
 		out := make([]abi.SectorNumber, n)
 		for i := range out {
@@ -244,6 +263,16 @@ func TestPrecommitBatcher(t *testing.T) {
 				addSectors(getSectors(maxBatch), true),
 			},
 		},
+		"hold-aboveBaseFee": {
+			actions: []action{
+				addSectors(getSectors(2), true),
+				waitPending(2),
+				expectStatus(30000, sealiface.BatchModeHold, 2),
+				expectStatus(15000, sealiface.BatchModeBatch, 2),
+				expectStatus(9999, sealiface.BatchModeIndividual, 2),
+				flush(getSectors(2)),
+			},
+		},
 		"addMax-belowBaseFee": {
 			actions: []action{
 				expectSendsSingle(getSectors(maxBatch)),
@@ -1,6 +1,8 @@
 package sealiface
 
 import (
+	"time"
+
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -21,3 +23,34 @@ type PreCommitBatchRes struct {
 	Msg   *cid.Cid
 	Error string // if set, means that all sectors are failed, implies Msg==nil
 }
+
+// BatchMode is how the sectors pending in a batcher are sent
+type BatchMode string
+
+const (
+	// BatchModeBatch sends the sectors in a single batch or aggregate message
+	BatchModeBatch BatchMode = "batch"
+	// BatchModeIndividual sends a message for each sector
+	BatchModeIndividual BatchMode = "individual"
+	// BatchModeHold holds the sectors back because of a high base fee
+	BatchModeHold BatchMode = "hold"
+)
+
+type PendingSector struct {
+	Number abi.SectorNumber
+	// Cutoff is when the sector has to be on chain at the latest, zero if unknown
+	Cutoff time.Time
+}
+
+// BatchStatus describes the sectors pending in a PreCommit or Commit batcher
+type BatchStatus struct {
+	Sectors []PendingSector
+
+	// Mode is how the pending sectors would be sent at the current chain head
+	Mode    BatchMode
+	BaseFee abi.TokenAmount
+
+	// NextCheck is when the batcher next considers sending the pending sectors,
+	// sooner if the batch fills up
+	NextCheck time.Time
+}
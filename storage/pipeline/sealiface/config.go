@@ -57,6 +57,8 @@ type Config struct {
 
 	AggregateAboveBaseFee      abi.TokenAmount
 	BatchPreCommitAboveBaseFee abi.TokenAmount
+	HoldPreCommitsAboveBaseFee abi.TokenAmount
+	HoldCommitsAboveBaseFee    abi.TokenAmount
 
 	TerminateBatchMax  uint64
 	TerminateBatchMin  uint64
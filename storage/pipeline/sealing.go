@@ -129,6 +129,9 @@ type Sealing struct {
 	sclk     sync.Mutex
 	legacySc *storedcounter.StoredCounter
 
+	pauseLk sync.Mutex
+	paused  map[SectorState]struct{}
+
 	getConfig dtypes.GetSealingConfigFunc
 }
 
@@ -236,6 +239,8 @@ func New(mctx context.Context, api SealingAPI, fc config.MinerFeeConfig, events
 
 		available: map[abi.SectorID]struct{}{},
 
+		paused: map[SectorState]struct{}{},
+
 		journal:        journal,
 		sealingEvtType: journal.RegisterEventType("storage", "sealing_states"),
 
@@ -275,6 +280,10 @@ func New(mctx context.Context, api SealingAPI, fc config.MinerFeeConfig, events
 }
 
 func (m *Sealing) Run(ctx context.Context) {
+	if err := m.loadPausedStates(ctx); err != nil {
+		log.Errorf("failed to load paused states: %+v", err)
+	}
+
 	if err := m.restartSectors(ctx); err != nil {
 		log.Errorf("failed load sector states: %+v", err)
 	}
@@ -311,6 +320,15 @@ func (m *Sealing) TerminatePending(ctx context.Context) ([]abi.SectorID, error)
 	return m.terminator.Pending(ctx)
 }
 
+// PipelineStats returns the statistics of the sectors in the sealing pipeline.
+func (m *Sealing) PipelineStats() api.SectorsPipelineStats {
+	states, failures := m.stats.pipelineStats()
+	return api.SectorsPipelineStats{
+		States:   states,
+		Failures: failures,
+	}
+}
+
 func (m *Sealing) SectorPreCommitFlush(ctx context.Context) ([]sealiface.PreCommitBatchRes, error) {
 	return m.precommiter.Flush(ctx)
 }
@@ -319,6 +337,10 @@ func (m *Sealing) SectorPreCommitPending(ctx context.Context) ([]abi.SectorID, e
 	return m.precommiter.Pending(ctx)
 }
 
+func (m *Sealing) SectorPreCommitBatchStatus(ctx context.Context) (sealiface.BatchStatus, error) {
+	return m.precommiter.Status(ctx)
+}
+
 func (m *Sealing) CommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) {
 	return m.commiter.Flush(ctx)
 }
@@ -327,6 +349,10 @@ func (m *Sealing) CommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.commiter.Pending(ctx)
 }
 
+func (m *Sealing) CommitBatchStatus(ctx context.Context) (sealiface.BatchStatus, error) {
+	return m.commiter.Status(ctx)
+}
+
 func (m *Sealing) currentSealProof(ctx context.Context) (abi.RegisteredSealProof, error) {
 	mi, err := m.Api.StateMinerInfo(ctx, m.maddr, types.EmptyTSK)
 	if err != nil {
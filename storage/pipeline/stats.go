@@ -3,12 +3,14 @@ package sealing
 import (
 	"context"
 	"sync"
+	"time"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 )
@@ -23,12 +25,30 @@ const (
 	nsst
 )
 
+// restingStates are the states sectors stay in once sealed or removed.
+var restingStates = map[SectorState]struct{}{
+	Proving:   {},
+	Available: {},
+	Removed:   {},
+}
+
+type stateDurations struct {
+	left  int64
+	total time.Duration
+	max   time.Duration
+}
+
 type SectorStats struct {
 	lk sync.Mutex
 
 	bySector map[abi.SectorID]SectorState
 	byState  map[SectorState]int64
 	totals   [nsst]uint64
+
+	// entered is when sectors entered their current state
+	entered   map[abi.SectorID]time.Time
+	durations map[SectorState]*stateDurations
+	failures  int64
 }
 
 func (ss *SectorStats) updateSector(ctx context.Context, cfg sealiface.Config, id abi.SectorID, st SectorState) (updateInput bool) {
@@ -38,6 +58,12 @@ func (ss *SectorStats) updateSector(ctx context.Context, cfg sealiface.Config, i
 	preSealing := ss.curSealingLocked()
 	preStaging := ss.curStagingLocked()
 
+	if ss.entered == nil {
+		ss.entered = map[abi.SectorID]time.Time{}
+		ss.durations = map[SectorState]*stateDurations{}
+	}
+	now := time.Now()
+
 	// update totals
 	oldst, found := ss.bySector[id]
 	if found {
@@ -46,6 +72,23 @@ func (ss *SectorStats) updateSector(ctx context.Context, cfg sealiface.Config, i
 
 		mctx, _ := tag.New(ctx, tag.Upsert(metrics.SectorState, string(oldst)))
 		stats.Record(mctx, metrics.SectorStates.M(ss.byState[oldst]))
+
+		if since, ok := ss.entered[id]; ok && oldst != st {
+			took := now.Sub(since)
+
+			d, ok := ss.durations[oldst]
+			if !ok {
+				d = &stateDurations{}
+				ss.durations[oldst] = d
+			}
+			d.left++
+			d.total += took
+			if took > d.max {
+				d.max = took
+			}
+
+			stats.Record(mctx, metrics.SectorStateDuration.M(took.Seconds()))
+		}
 	}
 
 	sst := toStatState(st, cfg.FinalizeEarly)
@@ -56,6 +99,23 @@ func (ss *SectorStats) updateSector(ctx context.Context, cfg sealiface.Config, i
 	mctx, _ := tag.New(ctx, tag.Upsert(metrics.SectorState, string(st)))
 	stats.Record(mctx, metrics.SectorStates.M(ss.byState[st]))
 
+	if !found || oldst != st {
+		// sectors stay in the resting states indefinitely, their duration isn't
+		// tracked so that entered only holds the sectors moving through the pipeline
+		if _, resting := restingStates[st]; resting {
+			delete(ss.entered, id)
+		} else {
+			ss.entered[id] = now
+		}
+
+		// sectors are first seen in their restored state on startup, failures
+		// are only counted when a sector transitions into a failed state
+		if found && sst == sstFailed {
+			ss.failures++
+			stats.Record(mctx, metrics.SectorFailures.M(1))
+		}
+	}
+
 	// check if we may need be able to process more deals
 	sealing := ss.curSealingLocked()
 	staging := ss.curStagingLocked()
@@ -100,3 +160,28 @@ func (ss *SectorStats) curStaging() uint64 {
 
 	return ss.curStagingLocked()
 }
+
+// pipelineStats returns the statistics of the sectors in each state, and the
+// number of times sectors entered a failed state.
+func (ss *SectorStats) pipelineStats() (map[api.SectorState]api.SectorStateStats, int64) {
+	ss.lk.Lock()
+	defer ss.lk.Unlock()
+
+	out := map[api.SectorState]api.SectorStateStats{}
+	for st, n := range ss.byState {
+		if n == 0 {
+			continue
+		}
+		out[api.SectorState(st)] = api.SectorStateStats{Sectors: int(n)}
+	}
+
+	for st, d := range ss.durations {
+		s := out[api.SectorState(st)]
+		s.Left = d.left
+		s.AvgDuration = d.total / time.Duration(d.left)
+		s.MaxDuration = d.max
+		out[api.SectorState(st)] = s
+	}
+
+	return out, ss.failures
+}
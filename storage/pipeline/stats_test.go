@@ -0,0 +1,77 @@
+package sealing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+)
+
+func TestPipelineStats(t *testing.T) {
+	ctx := context.Background()
+	cfg := sealiface.Config{}
+
+	ss := SectorStats{
+		bySector: map[abi.SectorID]SectorState{},
+		byState:  map[SectorState]int64{},
+	}
+
+	s1 := abi.SectorID{Miner: 1000, Number: 1}
+	s2 := abi.SectorID{Miner: 1000, Number: 2}
+
+	ss.updateSector(ctx, cfg, s1, Packing)
+	ss.updateSector(ctx, cfg, s2, Packing)
+	ss.updateSector(ctx, cfg, s1, PreCommit1)
+	ss.updateSector(ctx, cfg, s1, PreCommit1) // no state change
+	ss.updateSector(ctx, cfg, s2, PreCommit1)
+	ss.updateSector(ctx, cfg, s2, SealPreCommit1Failed)
+
+	states, failures := ss.pipelineStats()
+	require.Equal(t, int64(1), failures)
+
+	require.Equal(t, 0, states[api.SectorState(Packing)].Sectors)
+	require.Equal(t, int64(2), states[api.SectorState(Packing)].Left)
+
+	require.Equal(t, 1, states[api.SectorState(PreCommit1)].Sectors)
+	require.Equal(t, int64(1), states[api.SectorState(PreCommit1)].Left)
+	require.GreaterOrEqual(t, states[api.SectorState(PreCommit1)].MaxDuration, states[api.SectorState(PreCommit1)].AvgDuration)
+
+	require.Equal(t, 1, states[api.SectorState(SealPreCommit1Failed)].Sectors)
+	require.Equal(t, int64(0), states[api.SectorState(SealPreCommit1Failed)].Left)
+}
+
+func TestPipelineStatsRestart(t *testing.T) {
+	ctx := context.Background()
+	cfg := sealiface.Config{}
+
+	ss := SectorStats{
+		bySector: map[abi.SectorID]SectorState{},
+		byState:  map[SectorState]int64{},
+	}
+
+	s1 := abi.SectorID{Miner: 1000, Number: 1}
+	s2 := abi.SectorID{Miner: 1000, Number: 2}
+
+	// sectors restored on startup, failed ones were counted before the restart
+	ss.updateSector(ctx, cfg, s1, SealPreCommit1Failed)
+	ss.updateSector(ctx, cfg, s2, Proving)
+
+	_, failures := ss.pipelineStats()
+	require.Equal(t, int64(0), failures)
+
+	ss.updateSector(ctx, cfg, s1, PreCommit1)
+	ss.updateSector(ctx, cfg, s1, SealPreCommit1Failed)
+
+	_, failures = ss.pipelineStats()
+	require.Equal(t, int64(1), failures)
+
+	// sectors which are done sealing aren't tracked
+	ss.updateSector(ctx, cfg, s1, PreCommit1)
+	ss.updateSector(ctx, cfg, s1, Proving)
+	require.Empty(t, ss.entered)
+}
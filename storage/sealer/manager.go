@@ -172,10 +172,21 @@ func New(ctx context.Context, lstor *paths.Local, stor paths.Store, ls paths.Loc
 		localTasks = append(localTasks, sealtasks.TTRegenSectorKey)
 	}
 
+	priorities := map[sealtasks.TaskType]int{}
+	for short, prio := range sc.LocalWorkerTaskPriorities {
+		tt, err := sealtasks.TaskTypeFromShort(short)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing local worker task priorities: %w", err)
+		}
+		priorities[tt] = prio
+	}
+
 	wcfg := WorkerConfig{
 		IgnoreResourceFiltering: sc.ResourceFiltering == config.ResourceFilteringDisabled,
 		TaskTypes:               localTasks,
 		Name:                    sc.LocalWorkerName,
+		ResourceOverrides:       sc.ResourceOverrides,
+		TaskPriorities:          priorities,
 	}
 	worker := NewLocalWorker(wcfg, stor, lstor, si, m, wss)
 	err = m.AddWorker(ctx, worker)
@@ -837,6 +848,10 @@ func (m *Manager) ReleaseUnsealed(ctx context.Context, sector storiface.SectorRe
 	})
 }
 
+func (m *Manager) SectorsReleaseUnsealed(ctx context.Context, sector storiface.SectorRef) error {
+	return m.ReleaseUnsealed(ctx, sector, nil)
+}
+
 func (m *Manager) ReleaseSectorKey(ctx context.Context, sector storiface.SectorRef) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
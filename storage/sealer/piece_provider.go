@@ -21,6 +21,11 @@ type Unsealer interface {
 	SectorsUnsealPiece(ctx context.Context, sector storiface.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error
 }
 
+type UnsealedReleaser interface {
+	// SectorsReleaseUnsealed removes the unsealed sector file of the given sector.
+	SectorsReleaseUnsealed(ctx context.Context, sector storiface.SectorRef) error
+}
+
 type PieceProvider interface {
 	// ReadPiece is used to read an Unsealed piece at the given offset and of the given size from a Sector
 	// pieceOffset + pieceSize specify piece bounds for unsealing (note: with SDR the entire sector will be unsealed by
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -395,6 +397,11 @@ func (sh *Scheduler) trySched() {
 	defer done()
 
 	sh.assigner.TrySched(sh)
+
+	for _, w := range sh.Workers {
+		ctx, _ := tag.New(sh.mctx, tag.Upsert(metrics.WorkerHostname, w.Info.Hostname))
+		stats.Record(ctx, metrics.WorkerUtilization.M(w.Utilization()), metrics.WorkerActiveTasks.M(int64(w.TaskCounts())))
+	}
 }
 
 func (sh *Scheduler) schedClose() {
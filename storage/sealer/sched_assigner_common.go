@@ -2,6 +2,7 @@ package sealer
 
 import (
 	"context"
+	"math"
 	"math/rand"
 	"sort"
 	"sync"
@@ -26,11 +27,13 @@ func (a *AssignerCommon) TrySched(sh *Scheduler) {
 		This assigns tasks to workers based on:
 		- Task priority (achieved by handling sh.SchedQueue in order, since it's already sorted by priority)
 		- Worker resource availability
+		- Worker priority for the task type (only the workers with the highest priority are considered)
 		- Task-specified worker preference (acceptableWindows array below sorted by this preference)
 		- Window request age
 
 		1. For each task in the SchedQueue find windows which can handle them
-		1.1. Create list of windows capable of handling a task
+		1.1. Create list of windows capable of handling a task, from the workers
+		     with the highest priority for the task type
 		1.2. Sort windows according to task selector preferences
 		2. Going through SchedQueue again, assign task to first acceptable window
 		   with resources available
@@ -86,6 +89,7 @@ func (a *AssignerCommon) TrySched(sh *Scheduler) {
 			task.IndexHeap = sqi
 
 			var havePreferred bool
+			bestPriority := math.MinInt
 
 			for wnd, windowRequest := range sh.OpenWindows {
 				worker, ok := cachedWorkers.Get(windowRequest.Worker)
@@ -119,6 +123,18 @@ func (a *AssignerCommon) TrySched(sh *Scheduler) {
 					continue
 				}
 
+				priority := worker.Info.TaskPriority(task.TaskType)
+				if priority < bestPriority {
+					// workers with a higher priority for the task can take it
+					continue
+				}
+				if priority > bestPriority {
+					// all workers we considered previously have a lower priority
+					acceptableWindows[sqi] = acceptableWindows[sqi][:0]
+					havePreferred = false
+					bestPriority = priority
+				}
+
 				if havePreferred && !preferred {
 					// we have a way better worker for this task
 					continue
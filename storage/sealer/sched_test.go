@@ -676,6 +676,53 @@ func BenchmarkTrySched(b *testing.B) {
 	b.Run("200w-400q", test(200, 400))
 }
 
+func TestSchedWorkerTaskPriority(t *testing.T) {
+	ctx := context.Background()
+
+	var whnd api.WorkerStruct
+	whnd.Internal.TaskTypes = func(p0 context.Context) (map[sealtasks.TaskType]struct{}, error) {
+		return nil, nil
+	}
+	whnd.Internal.Paths = func(p0 context.Context) ([]storiface.StoragePath, error) {
+		return nil, nil
+	}
+
+	sched, err := newScheduler(ctx, "")
+	require.NoError(t, err)
+
+	low, high := storiface.WorkerID(uuid.New()), storiface.WorkerID(uuid.New())
+	for wid, prio := range map[storiface.WorkerID]int{low: 0, high: 10} {
+		sched.Workers[wid] = &WorkerHandle{
+			workerRpc: &tw{Worker: &whnd},
+			Info: storiface.WorkerInfo{
+				Hostname:       "t",
+				Resources:      decentWorkerResources,
+				TaskPriorities: map[sealtasks.TaskType]int{sealtasks.TTPreCommit2: prio},
+			},
+			Enabled:   true,
+			preparing: NewActiveResources(newTaskCounter()),
+			active:    NewActiveResources(newTaskCounter()),
+		}
+	}
+
+	lowWnd := &SchedWindowRequest{Worker: low, Done: make(chan *SchedWindow, 1)}
+	highWnd := &SchedWindowRequest{Worker: high, Done: make(chan *SchedWindow, 1)}
+	sched.OpenWindows = append(sched.OpenWindows, lowWnd, highWnd)
+
+	sched.SchedQueue.Push(&WorkerRequest{
+		Sector:   storiface.SectorRef{ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1},
+		TaskType: sealtasks.TTPreCommit2,
+		Sel:      slowishSelector(true),
+		Ctx:      ctx,
+	})
+
+	sched.trySched()
+
+	require.Len(t, highWnd.Done, 1)
+	require.Len(t, lowWnd.Done, 0)
+	require.Equal(t, []*SchedWindowRequest{lowWnd}, sched.OpenWindows)
+}
+
 func TestWindowCompact(t *testing.T) {
 	sh := Scheduler{}
 	spt := abi.RegisteredSealProof_StackedDrg32GiBV1
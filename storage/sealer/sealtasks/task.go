@@ -130,6 +130,16 @@ func (a TaskType) Short() string {
 	return n
 }
 
+// TaskTypeFromShort returns the task type with the given short name, e.g. PC1.
+func TaskTypeFromShort(short string) (TaskType, error) {
+	for tt, n := range shortNames {
+		if strings.EqualFold(n, short) {
+			return tt, nil
+		}
+	}
+	return TTNoop, xerrors.Errorf("unknown task type: %s", short)
+}
+
 type SealTaskType struct {
 	TaskType
 	abi.RegisteredSealProof
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
@@ -40,6 +43,8 @@ func (m *Manager) WorkerStats(ctx context.Context) map[uuid.UUID]storiface.Worke
 			GpuUsed:    handle.active.gpuUsed,
 			CpuUse:     handle.active.cpuUse,
 
+			Utilization: handle.active.utilization(handle.Info.Resources),
+
 			TaskCounts: map[string]int{},
 		}
 
@@ -62,6 +67,39 @@ func (m *Manager) WorkerStats(ctx context.Context) map[uuid.UUID]storiface.Worke
 	return out
 }
 
+// WorkerResourceTable returns the resources the scheduler assumes each task
+// needs on the worker, including the overrides set on the worker.
+func (m *Manager) WorkerResourceTable(ctx context.Context, wid uuid.UUID) (map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) {
+	var info *storiface.WorkerInfo
+
+	cb := func(ctx context.Context, id storiface.WorkerID, handle *WorkerHandle) {
+		if uuid.UUID(id) != wid {
+			return
+		}
+
+		handle.lk.Lock()
+		i := handle.Info
+		handle.lk.Unlock()
+
+		info = &i
+	}
+
+	m.sched.workersLk.RLock()
+	for id, handle := range m.sched.Workers {
+		cb(ctx, id, handle)
+	}
+	m.sched.workersLk.RUnlock()
+
+	m.winningPoStSched.WorkerStats(ctx, cb)
+	m.windowPoStSched.WorkerStats(ctx, cb)
+
+	if info == nil {
+		return nil, xerrors.Errorf("worker %s not found", wid)
+	}
+
+	return info.Resources.ResourceTable(), nil
+}
+
 func (m *Manager) WorkerJobs() map[uuid.UUID][]storiface.WorkerJob {
 	out := map[uuid.UUID][]storiface.WorkerJob{}
 	calls := map[storiface.CallID]struct{}{}
@@ -2,6 +2,7 @@ package storiface
 
 import (
 	"strings"
+	"time"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
@@ -91,6 +92,34 @@ type StorageInfo struct {
 type HealthReport struct {
 	Stat fsutil.FsStat
 	Err  string
+
+	// Latency is the time it took to stat the path
+	Latency time.Duration
+}
+
+type PathHealthState string
+
+const (
+	PathHealthOK PathHealthState = "ok"
+	// PathHealthReadOnly paths are slow or low on space. No sector files are
+	// allocated on, or fetched to them, but their sector files are still read.
+	PathHealthReadOnly PathHealthState = "read-only"
+	// PathHealthOffline paths miss heartbeats or report errors. Their sector
+	// files are only read when no other path has them.
+	PathHealthOffline PathHealthState = "offline"
+)
+
+type PathHealth struct {
+	State  PathHealthState
+	Reason string // why the path isn't ok
+
+	LastHeartbeat time.Time
+	Latency       time.Duration
+	Err           string
+	// Errors is the number of consecutive heartbeats reporting an error
+	Errors int
+
+	Stat fsutil.FsStat
 }
 
 type SectorStorageInfo struct {
@@ -106,6 +135,8 @@ type SectorStorageInfo struct {
 
 	AllowTypes []string
 	DenyTypes  []string
+
+	Health PathHealthState
 }
 
 type Decl struct {
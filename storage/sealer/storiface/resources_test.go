@@ -119,3 +119,27 @@ func TestUnsizedNotPreferred(t *testing.T) {
 	// check that defaults don't get mutated
 	require.Equal(t, 1, ResourceTable[sealtasks.TTUnseal][stabi.RegisteredSealProof_StackedDrg2KiBV1_1].MaxParallelism)
 }
+
+func TestWorkerResourceTable(t *testing.T) {
+	rt, err := ParseResourceEnv(func(key, def string) (string, bool) {
+		if key == "PC2_2K_MAX_MEMORY" {
+			return "2222", true
+		}
+
+		return "", false
+	})
+	require.NoError(t, err)
+
+	// partial tables fall back to the defaults
+	delete(rt, sealtasks.TTPreCommit1)
+
+	wr := WorkerResources{Resources: rt}
+	table := wr.ResourceTable()
+
+	require.Equal(t, uint64(2222), table[sealtasks.TTPreCommit2][stabi.RegisteredSealProof_StackedDrg2KiBV1_1].MaxMemory)
+	require.Equal(t, ResourceTable[sealtasks.TTPreCommit1][stabi.RegisteredSealProof_StackedDrg2KiBV1_1], table[sealtasks.TTPreCommit1][stabi.RegisteredSealProof_StackedDrg2KiBV1_1])
+	require.Equal(t, len(ResourceTable), len(table))
+
+	// no overrides
+	require.Equal(t, ResourceTable[sealtasks.TTCommit2][stabi.RegisteredSealProof_StackedDrg32GiBV1_1], WorkerResources{}.ResourceTable()[sealtasks.TTCommit2][stabi.RegisteredSealProof_StackedDrg32GiBV1_1])
+}
@@ -30,6 +30,15 @@ type WorkerInfo struct {
 	// Default should be false (zero value, i.e. resources taken into account).
 	IgnoreResources bool
 	Resources       WorkerResources
+
+	// TaskPriorities are the priorities of the worker for task types. Tasks
+	// are only scheduled on the workers with the highest priority which can
+	// take them. Task types without a priority have priority 0.
+	TaskPriorities map[sealtasks.TaskType]int
+}
+
+func (wi WorkerInfo) TaskPriority(tt sealtasks.TaskType) int {
+	return wi.TaskPriorities[tt]
 }
 
 type WorkerResources struct {
@@ -65,6 +74,19 @@ func (wr WorkerResources) ResourceSpec(spt abi.RegisteredSealProof, tt sealtasks
 	return res
 }
 
+// ResourceTable returns the resources assumed for each task and proof type on
+// the worker: the default resource table, with the worker overrides applied.
+func (wr WorkerResources) ResourceTable() map[sealtasks.TaskType]map[abi.RegisteredSealProof]Resources {
+	out := map[sealtasks.TaskType]map[abi.RegisteredSealProof]Resources{}
+	for tt, byProof := range ResourceTable {
+		out[tt] = map[abi.RegisteredSealProof]Resources{}
+		for spt := range byProof {
+			out[tt][spt] = wr.ResourceSpec(spt, tt)
+		}
+	}
+	return out
+}
+
 // PrepResourceSpec is like ResourceSpec, but meant for use limiting parallel preparing
 // tasks.
 func (wr WorkerResources) PrepResourceSpec(spt abi.RegisteredSealProof, tt, prepTT sealtasks.TaskType) Resources {
@@ -89,6 +111,10 @@ type WorkerStats struct {
 	GpuUsed    float64 // nolint
 	CpuUse     uint64  // nolint
 
+	// Utilization is the fraction of the most used worker resource by the
+	// running tasks
+	Utilization float64
+
 	TaskCounts map[string]int
 }
 
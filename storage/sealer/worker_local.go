@@ -44,6 +44,15 @@ type WorkerConfig struct {
 
 	MaxParallelChallengeReads int           // 0 = no limit
 	ChallengeReadTimeout      time.Duration // 0 = no timeout
+
+	// TaskPriorities are the priorities of the worker for task types, see
+	// storiface.WorkerInfo.
+	TaskPriorities map[sealtasks.TaskType]int
+
+	// ResourceOverrides override the resources the scheduler assumes tasks
+	// need on this worker. Keys are the resource env var names, e.g.
+	// PC1_32G_MAX_MEMORY, and take precedence over the environment.
+	ResourceOverrides map[string]string
 }
 
 // used do provide custom proofs impl (mostly used in testing)
@@ -63,6 +72,7 @@ type LocalWorker struct {
 
 	// see equivalent field on WorkerConfig.
 	ignoreResources bool
+	taskPriorities  map[sealtasks.TaskType]int
 
 	ct          *workerCallTracker
 	acceptTasks map[sealtasks.TaskType]struct{}
@@ -83,6 +93,10 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 		acceptTasks[taskType] = struct{}{}
 	}
 
+	if len(wcfg.ResourceOverrides) > 0 {
+		envLookup = overrideEnv(wcfg.ResourceOverrides, envLookup)
+	}
+
 	w := &LocalWorker{
 		storage:    store,
 		localStore: local,
@@ -98,6 +112,7 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 		noSwap:               wcfg.NoSwap,
 		envLookup:            envLookup,
 		ignoreResources:      wcfg.IgnoreResourceFiltering,
+		taskPriorities:       wcfg.TaskPriorities,
 		challengeReadTimeout: wcfg.ChallengeReadTimeout,
 		session:              uuid.New(),
 		closing:              make(chan struct{}),
@@ -146,6 +161,16 @@ func NewLocalWorker(wcfg WorkerConfig, store paths.Store, local *paths.Local, si
 	return newLocalWorker(nil, wcfg, os.LookupEnv, store, local, sindex, ret, cst)
 }
 
+// overrideEnv returns an EnvFunc looking up the keys in overrides first.
+func overrideEnv(overrides map[string]string, env EnvFunc) EnvFunc {
+	return func(key string) (string, bool) {
+		if v, ok := overrides[key]; ok {
+			return v, true
+		}
+		return env(key)
+	}
+}
+
 type localWorkerPathProvider struct {
 	w  *LocalWorker
 	op storiface.AcquireMode
@@ -811,6 +836,7 @@ func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 	return storiface.WorkerInfo{
 		Hostname:        l.name,
 		IgnoreResources: l.ignoreResources,
+		TaskPriorities:  l.taskPriorities,
 		Resources: storiface.WorkerResources{
 			MemPhysical: memPhysical,
 			MemUsed:     memUsed,
@@ -403,9 +403,9 @@ func (s *WindowPoStScheduler) declareManualRecoveries(ctx context.Context, maddr
 	if s.maxPartitionsPerRecoveryMessage > 0 {
 
 		// Create batched
-		for len(RecoveryDecls) > s.maxPartitionsPerPostMessage {
+		for len(RecoveryDecls) > s.maxPartitionsPerRecoveryMessage {
 			Batch := RecoveryDecls[len(RecoveryDecls)-s.maxPartitionsPerRecoveryMessage:]
-			RecoveryDecls = RecoveryDecls[:len(RecoveryDecls)-s.maxPartitionsPerPostMessage]
+			RecoveryDecls = RecoveryDecls[:len(RecoveryDecls)-s.maxPartitionsPerRecoveryMessage]
 			RecoveryBatches = append(RecoveryBatches, Batch)
 		}
 
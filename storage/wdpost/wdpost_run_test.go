@@ -27,6 +27,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
@@ -531,6 +532,73 @@ func TestWDPostDeclareRecoveriesPartLimitConfig(t *testing.T) {
 	}
 }
 
+func TestWDPostManualRecoveriesPartLimitConfig(t *testing.T) {
+	ctx := context.Background()
+
+	proofType := abi.RegisteredPoStProof_StackedDrgWindow2KiBV1
+	postAct := tutils.NewIDAddr(t, 100)
+
+	mockStgMinerAPI := newMockStorageMinerAPI()
+
+	// one sector in each of 7 partitions, and a config of 3 partitions per message
+	userPartLimit := 3
+	partitionCount := 7
+
+	var sectors []abi.SectorNumber
+	for s := 0; s < partitionCount; s++ {
+		sectors = append(sectors, abi.SectorNumber(s))
+	}
+
+	scheduler := &WindowPoStScheduler{
+		api:          mockStgMinerAPI,
+		prover:       &mockProver{},
+		verifier:     &mockVerif{},
+		faultTracker: &mockFaultTracker{},
+		proofType:    proofType,
+		actor:        postAct,
+		journal:      journal.NilJournal(),
+		addrSel:      &ctladdr.AddressSelector{},
+
+		maxPartitionsPerRecoveryMessage: userPartLimit,
+	}
+
+	expectedMsgCount := (partitionCount + userPartLimit - 1) / userPartLimit
+
+	type result struct {
+		msgs []cid.Cid
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		msgs, err := scheduler.ManualFaultRecovery(ctx, postAct, sectors)
+		done <- result{msgs, err}
+	}()
+
+	var declared int
+	for i := 0; i < expectedMsgCount; i++ {
+		msg := <-mockStgMinerAPI.pushedMessages
+		require.Equal(t, builtin.MethodsMiner.DeclareFaultsRecovered, msg.Method)
+		var params minertypes.DeclareFaultsRecoveredParams
+		err := params.UnmarshalCBOR(bytes.NewReader(msg.Params))
+		require.NoError(t, err)
+
+		require.LessOrEqual(t, len(params.Recoveries), userPartLimit)
+		declared += len(params.Recoveries)
+	}
+	require.Equal(t, partitionCount, declared)
+
+	res := <-done
+	require.NoError(t, res.err, "failed to declare recoveries")
+	require.Equal(t, expectedMsgCount, len(res.msgs))
+}
+
+func (m *mockStorageMinerAPI) StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*lminer.SectorLocation, error) {
+	return &lminer.SectorLocation{
+		Deadline:  0,
+		Partition: uint64(sectorNumber),
+	}, nil
+}
+
 func mockTipSet(t *testing.T) *types.TipSet {
 	minerAct := tutils.NewActorAddr(t, "miner")
 	c, err := cid.Decode("QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH")